@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+// economicKey 经济等价键：目标合约 + 交易对 + 方向 + 规模分桶
+type economicKey string
+
+// dedupGroup 窗口内经济等价的机会：窗口结束时只放行其中净盈利最高的一个
+type dedupGroup struct {
+	best   *types.ProfitAnalysis
+	decide func(admitted bool) // 当前最优机会的决策回调
+}
+
+// opportunityDedup 按经济等价去重（同一窗口内只放行最优的机会）
+type opportunityDedup struct {
+	mu         sync.Mutex
+	groups     map[economicKey]*dedupGroup
+	duplicates int64
+	improved   int64
+
+	windows sync.WaitGroup // 尚未结束的窗口
+}
+
+// economicKeyOf 计算机会的经济等价键（缺少原始交换信息时返回 false）
+func economicKeyOf(analysis *types.ProfitAnalysis, bucketWidth float64) (economicKey, bool) {
+	source := analysis.Source
+	if source == nil || source.Transaction == nil || len(source.Path) < 2 || source.AmountIn == nil || source.AmountIn.Sign() <= 0 {
+		return "", false
+	}
+
+	// 第一跳交易对（代币顺序无关，原生代币按包装代币处理）
+	path := types.PoolPath(source.Path[:2], source.Transaction.ChainID)
+	a, b := path[0], path[1]
+	if b.Hex() < a.Hex() {
+		a, b = b, a
+	}
+
+	// 对数分桶：规模相差不超过 bucketWidth 的交易落在同一档
+	size, _ := new(big.Float).SetInt(source.AmountIn).Float64()
+	bucket := int64(math.Floor(math.Log(size) / math.Log1p(bucketWidth)))
+
+	return economicKey(fmt.Sprintf("%s|%s|%s|%s|%d", source.TargetContract.Hex(), a.Hex(), b.Hex(), source.SwapDirection, bucket)), true
+}
+
+// submit 提交机会，以是否放行调用 decide（windowMs <= 0 表示不去重，立即放行）：
+// 窗口内已有不差于它的等价机会时立即拒绝；更优的等价机会取代窗口内的记录，被取代的立即拒绝。
+// 每组等价机会的窗口由单独的goroutine计时，窗口结束时放行最优的一个，调用方不等待窗口
+func (d *opportunityDedup) submit(ctx context.Context, analysis *types.ProfitAnalysis, windowMs int, bucketWidth float64, decide func(admitted bool)) {
+	if windowMs <= 0 || analysis.NetProfit == nil {
+		decide(true)
+		return
+	}
+	key, ok := economicKeyOf(analysis, bucketWidth)
+	if !ok {
+		decide(true)
+		return
+	}
+
+	d.mu.Lock()
+	if d.groups == nil {
+		d.groups = make(map[economicKey]*dedupGroup)
+	}
+	group, exists := d.groups[key]
+	if exists {
+		if analysis.NetProfit.Cmp(group.best.NetProfit) <= 0 {
+			d.duplicates++
+			d.mu.Unlock()
+			decide(false)
+			return
+		}
+		superseded := group.decide
+		group.best, group.decide = analysis, decide
+		d.improved++
+		d.duplicates++
+		d.mu.Unlock()
+		superseded(false)
+		return
+	}
+
+	group = &dedupGroup{best: analysis, decide: decide}
+	d.groups[key] = group
+	d.windows.Add(1)
+	d.mu.Unlock()
+
+	go d.close(ctx, key, group, time.Duration(windowMs)*time.Millisecond)
+}
+
+// close 窗口结束（或上下文取消）时移除该组，放行组内最优的机会（上下文取消时拒绝）
+func (d *opportunityDedup) close(ctx context.Context, key economicKey, group *dedupGroup, window time.Duration) {
+	defer d.windows.Done()
+
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+
+	d.mu.Lock()
+	delete(d.groups, key)
+	decide := group.decide
+	d.mu.Unlock()
+	decide(ctx.Err() == nil)
+}
+
+// wait 等待所有窗口结束并完成决策
+func (d *opportunityDedup) wait() {
+	d.windows.Wait()
+}
+
+// GetStats 获取统计信息
+func (d *opportunityDedup) GetStats() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return map[string]interface{}{
+		"tracked":    len(d.groups),
+		"duplicates": d.duplicates,
+		"improved":   d.improved,
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/executor"
+	"mempool-sniper/internal/output"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// dedupOpportunity 主网 Uniswap V2 上 WETH→USDC 买入的机会
+func dedupOpportunity(amountIn, netProfit int64) *types.ProfitAnalysis {
+	return &types.ProfitAnalysis{
+		NetProfit: big.NewInt(netProfit),
+		Source: &types.DecodedTransaction{
+			Transaction:    &types.Transaction{ChainID: big.NewInt(1)},
+			TargetContract: common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"),
+			Path:           []common.Address{common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"), usdc},
+			AmountIn:       big.NewInt(amountIn),
+			SwapDirection:  "buy",
+		},
+	}
+}
+
+// submitted 提交机会，返回接收决策结果的通道
+func submitted(d *opportunityDedup, ctx context.Context, analysis *types.ProfitAnalysis, windowMs int) <-chan bool {
+	decided := make(chan bool, 1)
+	d.submit(ctx, analysis, windowMs, 0.1, func(admitted bool) { decided <- admitted })
+	return decided
+}
+
+func TestDedupKeepsBestEquivalentOpportunity(t *testing.T) {
+	const window = 100
+
+	tests := []struct {
+		name   string
+		first  *types.ProfitAnalysis
+		second *types.ProfitAnalysis
+		want   [2]bool
+	}{
+		{name: "better one arrives second", first: dedupOpportunity(1e18, 100), second: dedupOpportunity(1e18, 200), want: [2]bool{false, true}},
+		{name: "worse one arrives second", first: dedupOpportunity(1e18, 200), second: dedupOpportunity(1e18, 100), want: [2]bool{true, false}},
+		{name: "different size bucket", first: dedupOpportunity(1e18, 200), second: dedupOpportunity(5e18, 100), want: [2]bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dedup opportunityDedup
+			ctx := context.Background()
+
+			first := submitted(&dedup, ctx, tt.first, window)
+			second := submitted(&dedup, ctx, tt.second, window)
+			if got := [2]bool{<-first, <-second}; got != tt.want {
+				t.Errorf("admitted = %v, want %v", got, tt.want)
+			}
+			dedup.wait()
+			if tracked := dedup.GetStats()["tracked"]; tracked != 0 {
+				t.Errorf("%v groups still tracked after their windows closed", tracked)
+			}
+		})
+	}
+}
+
+func TestDedupWindowExpires(t *testing.T) {
+	var dedup opportunityDedup
+	ctx := context.Background()
+
+	if !<-submitted(&dedup, ctx, dedupOpportunity(1e18, 200), 20) {
+		t.Fatal("first opportunity rejected")
+	}
+	// 上一个窗口已结束：新窗口重新计算
+	if !<-submitted(&dedup, ctx, dedupOpportunity(1e18, 100), 20) {
+		t.Error("opportunity in a new window rejected")
+	}
+	if !<-submitted(&dedup, ctx, dedupOpportunity(1e18, 100), 0) {
+		t.Error("opportunity rejected with dedup disabled")
+	}
+}
+
+func TestDedupDoesNotSerializeUnrelatedOpportunities(t *testing.T) {
+	const window = 200
+	var dedup opportunityDedup
+	ctx := context.Background()
+
+	// 两个不同规模分桶的机会同时到达：提交立即返回，两者都在一个窗口后放行，互不等待
+	start := time.Now()
+	first := submitted(&dedup, ctx, dedupOpportunity(1e18, 200), window)
+	second := submitted(&dedup, ctx, dedupOpportunity(5e18, 100), window)
+	if elapsed := time.Since(start); elapsed > window*time.Millisecond/4 {
+		t.Fatalf("submit blocked for %v, want it to return before the window closes", elapsed)
+	}
+	if !<-first || !<-second {
+		t.Fatal("unrelated opportunity rejected")
+	}
+	if elapsed := time.Since(start); elapsed > 3*window*time.Millisecond/2 {
+		t.Errorf("both admitted after %v, want about one window (%dms)", elapsed, window)
+	}
+}
+
+func TestDedupRejectsOpenWindowsOnShutdown(t *testing.T) {
+	var dedup opportunityDedup
+	ctx, cancel := context.WithCancel(context.Background())
+	decided := submitted(&dedup, ctx, dedupOpportunity(1e18, 200), 60000)
+
+	cancel()
+	dedup.wait()
+	if <-decided {
+		t.Error("opportunity admitted after shutdown")
+	}
+}
+
+func TestDedupWindowDoesNotBlockResultWorker(t *testing.T) {
+	const window, opportunities = 200, 6
+	cfg := &config.Config{
+		Sniper:    config.SniperConfig{MinProfit: big.NewInt(1), ResultWorkers: 1},
+		Execution: config.ExecutionConfig{DedupWindowMs: window, DedupBucketWidth: 0.1},
+	}
+	recorder := &alertRecorder{}
+	p := &resultProcessor{
+		cfgManager: config.NewManager(cfg),
+		inflight:   executor.NewInFlightLimiter(),
+		notifiers:  []output.Notifier{recorder},
+	}
+	profitChan := make(chan *types.ProfitAnalysis)
+	p.start(context.Background(), profitChan)
+
+	// 单个工作线程、互不等价的机会：每个只等自己的窗口，而不是排队依次等待
+	start := time.Now()
+	for i := int64(0); i < opportunities; i++ {
+		profitChan <- dedupOpportunity(1e15<<i, 1e16)
+	}
+	close(profitChan)
+	p.wait()
+
+	if len(recorder.notified) != opportunities {
+		t.Errorf("notified %d opportunities, want %d", len(recorder.notified), opportunities)
+	}
+	if elapsed := time.Since(start); elapsed > 3*window*time.Millisecond/2 {
+		t.Errorf("processed %d opportunities in %v, want about one window (%dms)", opportunities, elapsed, window)
+	}
+}
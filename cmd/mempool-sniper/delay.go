@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// actionDelay 执行前的随机延迟（模拟人工时序，所有结果处理工作线程共享）
+type actionDelay struct {
+	mu      sync.Mutex
+	rng     *rand.Rand
+	delayed int64
+	totalMs int64
+	maxMs   int64
+}
+
+// pick 在 [minMs, maxMs] 内均匀选取延迟（maxMs <= 0 表示不延迟）
+func (d *actionDelay) pick(minMs, maxMs int) time.Duration {
+	if maxMs <= 0 || maxMs < minMs {
+		return 0
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.rng == nil {
+		d.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return time.Duration(minMs+d.rng.Intn(maxMs-minMs+1)) * time.Millisecond
+}
+
+// wait 等待随机延迟，上下文取消时返回 false
+func (d *actionDelay) wait(ctx context.Context, minMs, maxMs int) bool {
+	delay := d.pick(minMs, maxMs)
+	if delay <= 0 {
+		return true
+	}
+
+	d.mu.Lock()
+	d.delayed++
+	d.totalMs += delay.Milliseconds()
+	if delay.Milliseconds() > d.maxMs {
+		d.maxMs = delay.Milliseconds()
+	}
+	d.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// GetStats 获取统计信息
+func (d *actionDelay) GetStats() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	avgMs := float64(0)
+	if d.delayed > 0 {
+		avgMs = float64(d.totalMs) / float64(d.delayed)
+	}
+	return map[string]interface{}{
+		"delayed":      d.delayed,
+		"avg_delay_ms": avgMs,
+		"max_delay_ms": d.maxMs,
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestActionDelayStaysInRange(t *testing.T) {
+	tests := []struct {
+		name         string
+		minMs, maxMs int
+		lo, hi       time.Duration // 允许的范围
+	}{
+		{name: "disabled", minMs: 0, maxMs: 0},
+		{name: "range", minMs: 10, maxMs: 50, lo: 10 * time.Millisecond, hi: 50 * time.Millisecond},
+		{name: "small range hits both ends", minMs: 0, maxMs: 2, hi: 2 * time.Millisecond},
+		{name: "fixed", minMs: 20, maxMs: 20, lo: 20 * time.Millisecond, hi: 20 * time.Millisecond},
+		{name: "min above max", minMs: 50, maxMs: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &actionDelay{}
+			seen := make(map[time.Duration]bool)
+			for i := 0; i < 1000; i++ {
+				delay := d.pick(tt.minMs, tt.maxMs)
+				if delay < tt.lo || delay > tt.hi {
+					t.Fatalf("pick(%d, %d) = %v, outside [%v, %v]", tt.minMs, tt.maxMs, delay, tt.lo, tt.hi)
+				}
+				seen[delay] = true
+			}
+			// 两端都可取到
+			if !seen[tt.lo] || !seen[tt.hi] {
+				t.Errorf("pick(%d, %d) never returned %v or %v in 1000 draws", tt.minMs, tt.maxMs, tt.lo, tt.hi)
+			}
+		})
+	}
+}
+
+func TestActionDelayWait(t *testing.T) {
+	d := &actionDelay{}
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		if !d.wait(context.Background(), 10, 30) {
+			t.Fatal("wait() = false without cancellation")
+		}
+		if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+			t.Errorf("wait() returned after %v, before ACTION_DELAY_MIN_MS", elapsed)
+		}
+	}
+	stats := d.GetStats()
+	avg, _ := stats["avg_delay_ms"].(float64)
+	maxMs, _ := stats["max_delay_ms"].(int64)
+	if stats["delayed"] != int64(5) || avg < 10 || avg > 30 || maxMs < 10 || maxMs > 30 {
+		t.Errorf("stats = %v, want 5 delays within [10, 30] ms", stats)
+	}
+
+	// 等待期间取消：立即返回 false
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if d.wait(ctx, 1000, 1000) {
+		t.Error("wait() = true after cancellation")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("cancelled wait() took %v", elapsed)
+	}
+}
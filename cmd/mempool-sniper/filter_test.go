@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"mempool-sniper/internal/decoder"
+	"mempool-sniper/pkg/types"
+)
+
+func TestMatchFilterFailsClosed(t *testing.T) {
+	chain, err := decoder.LookupChain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &resultProcessor{chain: chain}
+	analysis := &types.ProfitAnalysis{NetProfit: big.NewInt(1e16), CompetitionCount: 1, PriceImpactBps: 50}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{filter: "net_profit > 5e15 && competition_count < 2", want: true},
+		{filter: "price_impact_bps > 100", want: false},
+		// 无法编译的表达式拒绝机会，而不是放行
+		{filter: "net_profit >", want: false},
+		{filter: "net_profit > 'high'", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			if got := p.matchFilter(tt.filter, analysis); got != tt.want {
+				t.Errorf("matchFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math/big"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/pkg/types"
+)
+
+// netProfitAfterCosts 扣除全部成本后的净盈利 = 净盈利(已扣Gas) - 构建者小费 - 安全缓冲
+func netProfitAfterCosts(analysis *types.ProfitAnalysis, exec *config.ExecutionConfig) *big.Int {
+	if analysis.NetProfit == nil {
+		return new(big.Int)
+	}
+
+	after := new(big.Int).Set(analysis.NetProfit)
+	if exec == nil {
+		return after
+	}
+
+	// 构建者小费按净盈利比例支付，亏损时不支付
+	if exec.BuilderTipBps > 0 && after.Sign() > 0 {
+		tip := new(big.Int).Mul(after, new(big.Int).SetUint64(exec.BuilderTipBps))
+		after.Sub(after, tip.Div(tip, big.NewInt(10000)))
+	}
+	if exec.SafetyBuffer != nil {
+		after.Sub(after, exec.SafetyBuffer)
+	}
+	return after
+}
+
+// passesCostGate 最终盈利门槛：扣除全部成本后的净盈利不低于 floor，结果写入分析
+func passesCostGate(analysis *types.ProfitAnalysis, exec *config.ExecutionConfig, floor *big.Int) bool {
+	analysis.NetProfitAfterCosts = netProfitAfterCosts(analysis, exec)
+	return analysis.NetProfitAfterCosts.Cmp(floor) >= 0
+}
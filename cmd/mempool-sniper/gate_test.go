@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/pkg/types"
+)
+
+func TestPassesCostGate(t *testing.T) {
+	tests := []struct {
+		name      string
+		netProfit *big.Int
+		exec      *config.ExecutionConfig
+		floor     int64
+		wantAfter int64
+		want      bool
+	}{
+		{name: "no execution costs", netProfit: big.NewInt(1000), exec: nil, floor: 1000, wantAfter: 1000, want: true},
+		{name: "builder tip", netProfit: big.NewInt(1000), exec: &config.ExecutionConfig{BuilderTipBps: 2000}, floor: 900, wantAfter: 800, want: false},
+		{name: "safety buffer", netProfit: big.NewInt(1000), exec: &config.ExecutionConfig{SafetyBuffer: big.NewInt(300)}, floor: 700, wantAfter: 700, want: true},
+		{name: "tip then buffer", netProfit: big.NewInt(1000), exec: &config.ExecutionConfig{BuilderTipBps: 1000, SafetyBuffer: big.NewInt(100)}, floor: 801, wantAfter: 800, want: false},
+		{name: "no tip on a loss", netProfit: big.NewInt(-100), exec: &config.ExecutionConfig{BuilderTipBps: 5000, SafetyBuffer: big.NewInt(50)}, floor: -200, wantAfter: -150, want: true},
+		{name: "missing net profit", netProfit: nil, exec: &config.ExecutionConfig{SafetyBuffer: big.NewInt(50)}, floor: 1, wantAfter: 0, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := &types.ProfitAnalysis{NetProfit: tt.netProfit}
+			if got := passesCostGate(analysis, tt.exec, big.NewInt(tt.floor)); got != tt.want {
+				t.Errorf("passesCostGate() = %v, want %v", got, tt.want)
+			}
+			if analysis.NetProfitAfterCosts.Cmp(big.NewInt(tt.wantAfter)) != 0 {
+				t.Errorf("NetProfitAfterCosts = %s, want %d", analysis.NetProfitAfterCosts, tt.wantAfter)
+			}
+		})
+	}
+}
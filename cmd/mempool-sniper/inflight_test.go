@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/executor"
+	"mempool-sniper/internal/output"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// blockingNotifier 阻塞到 release 关闭的通知器，记录同时进行中的通知数
+type blockingNotifier struct {
+	release chan struct{}
+
+	mu       sync.Mutex
+	active   int
+	peak     int
+	notified int
+}
+
+func (n *blockingNotifier) Notify(ctx context.Context, analysis *types.ProfitAnalysis) error {
+	n.mu.Lock()
+	n.active++
+	if n.active > n.peak {
+		n.peak = n.active
+	}
+	n.mu.Unlock()
+
+	<-n.release
+
+	n.mu.Lock()
+	n.active--
+	n.notified++
+	n.mu.Unlock()
+	return nil
+}
+
+func (n *blockingNotifier) snapshot() (active, peak, notified int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.active, n.peak, n.notified
+}
+
+func TestInFlightLimitDoesNotBlockResultWorkers(t *testing.T) {
+	const limit, opportunities = 2, 4
+
+	tests := []struct {
+		mode     string
+		notified int
+		dropped  int64
+	}{
+		{mode: ThrottleQueue, notified: opportunities},
+		{mode: ThrottleDrop, notified: limit, dropped: opportunities - limit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cfg := &config.Config{
+				Sniper:    config.SniperConfig{MinProfit: big.NewInt(1), ResultWorkers: 1},
+				Execution: config.ExecutionConfig{MaxInFlight: limit, InFlightMode: tt.mode},
+			}
+			notifier := &blockingNotifier{release: make(chan struct{})}
+			p := &resultProcessor{
+				cfgManager: config.NewManager(cfg),
+				inflight:   executor.NewInFlightLimiter(),
+				notifiers:  []output.Notifier{notifier},
+			}
+			profitChan := make(chan *types.ProfitAnalysis)
+			p.start(context.Background(), profitChan)
+
+			// 单个工作线程：执行名额占满时仍能继续接收结果
+			for i := int64(1); i <= opportunities; i++ {
+				select {
+				case profitChan <- &types.ProfitAnalysis{TxHash: common.BigToHash(big.NewInt(i)), NetProfit: big.NewInt(1e16)}:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("result worker blocked on opportunity %d", i)
+				}
+			}
+			// 等待名额占满，其余机会排队或被丢弃
+			for {
+				active, _, _ := notifier.snapshot()
+				stats := p.inflight.GetStats()
+				if active == limit && stats["queued"].(int64)+stats["dropped"].(int64) == opportunities-limit {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			close(notifier.release)
+			close(profitChan)
+			p.wait()
+
+			_, peak, notified := notifier.snapshot()
+			if peak > limit || notified != tt.notified {
+				t.Errorf("peak = %d, notified = %d; want at most %d at once and %d in total", peak, notified, limit, tt.notified)
+			}
+			if stats := p.inflight.GetStats(); stats["dropped"] != tt.dropped || stats["in_flight"] != 0 {
+				t.Errorf("in-flight stats = %v", stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+// sanitySwitch 异常盈利熔断：净盈利高得不合理时（通常是程序缺陷或价格源异常）暂停执行，需手动恢复
+type sanitySwitch struct {
+	mu        sync.Mutex
+	tripped   bool
+	trippedAt time.Time
+	trigger   string // 触发熔断的受害者交易
+	profit    string // 触发熔断的净盈利
+	trips     int64
+	blocked   int64
+}
+
+// allow 检查机会是否允许执行：已熔断时拒绝；净盈利超过上限时熔断并拒绝（maxProfit 为0表示不检查）。
+// 本次检查触发熔断时 alert 为告警内容，由调用方发给通知器
+func (s *sanitySwitch) allow(analysis *types.ProfitAnalysis, maxProfit *big.Int) (allowed bool, alert string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tripped {
+		s.blocked++
+		return false, ""
+	}
+	if maxProfit == nil || maxProfit.Sign() <= 0 || analysis.NetProfit == nil || analysis.NetProfit.Cmp(maxProfit) <= 0 {
+		return true, ""
+	}
+
+	s.tripped = true
+	s.trippedAt = time.Now()
+	s.trigger = analysis.TxHash.Hex()
+	s.profit = analysis.NetProfit.String()
+	s.trips++
+	s.blocked++
+	return false, fmt.Sprintf("异常盈利熔断! 交易 %s 净盈利 %s 超过上限 %s，已暂停执行；排查后发送 SIGUSR1 恢复 (kill -USR1 %d)",
+		s.trigger, analysis.FormatProfit(analysis.NetProfit), analysis.FormatProfit(maxProfit), os.Getpid())
+}
+
+// resume 手动恢复执行
+func (s *sanitySwitch) resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.tripped {
+		log.Println("ℹ️ 异常盈利熔断未触发，无需恢复")
+		return
+	}
+	s.tripped = false
+	log.Printf("▶️ 异常盈利熔断已手动恢复（熔断于 %s，期间拒绝 %d 个机会）",
+		s.trippedAt.Format(time.RFC3339), s.blocked)
+}
+
+// GetStats 获取统计信息
+func (s *sanitySwitch) GetStats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"tripped":    s.tripped,
+		"tripped_at": s.trippedAt,
+		"trigger_tx": s.trigger,
+		"profit":     s.profit,
+		"trips":      s.trips,
+		"blocked":    s.blocked,
+	}
+}
+
+// setupResumeHandler 收到 SIGUSR1 时手动恢复异常盈利熔断
+func setupResumeHandler(ctx context.Context, sanity *sanitySwitch) {
+	usrChan := make(chan os.Signal, 1)
+	signal.Notify(usrChan, syscall.SIGUSR1)
+
+	go func() {
+		defer signal.Stop(usrChan)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-usrChan:
+				sanity.resume()
+			}
+		}
+	}()
+}
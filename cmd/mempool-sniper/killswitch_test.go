@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/executor"
+	"mempool-sniper/internal/output"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func sanityOpportunity(n, netProfit int64) *types.ProfitAnalysis {
+	return &types.ProfitAnalysis{TxHash: common.BigToHash(big.NewInt(n)), NetProfit: big.NewInt(netProfit)}
+}
+
+func TestSanitySwitchTripsAboveBound(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxProfit *big.Int
+		profits   []int64
+		allowed   []bool
+		trips     int64
+	}{
+		{name: "below the bound", maxProfit: big.NewInt(100), profits: []int64{50, 99}, allowed: []bool{true, true}},
+		{name: "at the bound", maxProfit: big.NewInt(100), profits: []int64{100}, allowed: []bool{true}},
+		{name: "above the bound pauses until resumed", maxProfit: big.NewInt(100), profits: []int64{50, 101, 50, 1}, allowed: []bool{true, false, false, false}, trips: 1},
+		{name: "disabled", maxProfit: nil, profits: []int64{1e18}, allowed: []bool{true}},
+		{name: "zero disables", maxProfit: big.NewInt(0), profits: []int64{1e18}, allowed: []bool{true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sanity sanitySwitch
+			alerts := 0
+			for i, profit := range tt.profits {
+				allowed, alert := sanity.allow(sanityOpportunity(int64(i), profit), tt.maxProfit)
+				if allowed != tt.allowed[i] {
+					t.Errorf("allow(net profit %d) = %v, want %v", profit, allowed, tt.allowed[i])
+				}
+				if alert != "" {
+					alerts++
+				}
+			}
+			if stats := sanity.GetStats(); stats["trips"] != tt.trips || stats["tripped"] != (tt.trips > 0) {
+				t.Errorf("stats = %v", stats)
+			}
+			if int64(alerts) != tt.trips {
+				t.Errorf("%d alerts, want one per trip (%d)", alerts, tt.trips)
+			}
+		})
+	}
+}
+
+func TestSanitySwitchResume(t *testing.T) {
+	var sanity sanitySwitch
+	maxProfit := big.NewInt(100)
+	if allowed, _ := sanity.allow(sanityOpportunity(1, 1000), maxProfit); allowed {
+		t.Fatal("opportunity above the bound allowed")
+	}
+
+	sanity.resume()
+	if allowed, _ := sanity.allow(sanityOpportunity(2, 50), maxProfit); !allowed {
+		t.Error("opportunity below the bound rejected after resume")
+	}
+	// 恢复后再次超过上限时重新熔断并告警
+	if allowed, alert := sanity.allow(sanityOpportunity(3, 1000), maxProfit); allowed || alert == "" {
+		t.Errorf("allow() = %v, alert %q after resume; want a second trip with an alert", allowed, alert)
+	}
+	if stats := sanity.GetStats(); stats["trips"] != int64(2) || stats["blocked"] != int64(2) {
+		t.Errorf("stats = %v", stats)
+	}
+}
+
+// alertRecorder 记录机会通知和告警的通知器
+type alertRecorder struct {
+	mu       sync.Mutex
+	notified []common.Hash
+	alerts   []string
+}
+
+func (r *alertRecorder) Notify(ctx context.Context, analysis *types.ProfitAnalysis) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notified = append(r.notified, analysis.TxHash)
+	return nil
+}
+
+func (r *alertRecorder) Alert(ctx context.Context, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, text)
+	return nil
+}
+
+func TestSanityTripAlertsNotifiers(t *testing.T) {
+	cfg := &config.Config{
+		Sniper:    config.SniperConfig{MinProfit: big.NewInt(1), ResultWorkers: 1},
+		Execution: config.ExecutionConfig{SanityMaxProfit: big.NewInt(1e18)},
+	}
+	recorder := &alertRecorder{}
+	p := &resultProcessor{
+		cfgManager: config.NewManager(cfg),
+		inflight:   executor.NewInFlightLimiter(),
+		notifiers:  []output.Notifier{recorder, output.NewWebhook("http://127.0.0.1:0", "", output.JSONEncoder{}, time.Second, 0)},
+	}
+	profitChan := make(chan *types.ProfitAnalysis)
+	p.start(context.Background(), profitChan)
+
+	// 正常机会、触发熔断的机会、熔断期间的正常机会
+	for i, profit := range []int64{1e16, 5e18, 1e16} {
+		profitChan <- sanityOpportunity(int64(i+1), profit)
+	}
+	close(profitChan)
+	p.wait()
+
+	if len(recorder.notified) != 1 || recorder.notified[0] != common.BigToHash(big.NewInt(1)) {
+		t.Errorf("notified %v, want only the opportunity before the trip", recorder.notified)
+	}
+	if len(recorder.alerts) != 1 {
+		t.Fatalf("alerts = %q, want exactly one for the trip", recorder.alerts)
+	}
+	if want := common.BigToHash(big.NewInt(2)).Hex(); !strings.Contains(recorder.alerts[0], want) {
+		t.Errorf("alert %q does not name the triggering transaction %s", recorder.alerts[0], want)
+	}
+}
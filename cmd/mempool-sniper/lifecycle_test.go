@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/decoder"
+	"mempool-sniper/internal/executor"
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/internal/listener"
+	"mempool-sniper/internal/simulator"
+	"mempool-sniper/pkg/types"
+)
+
+// eventLog 按写入顺序保存生命周期事件
+type eventLog struct {
+	mu     sync.Mutex
+	events []lifecycle.Event
+}
+
+func (e *eventLog) Write(event lifecycle.Event) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+	return nil
+}
+
+// 夹具中的盈利交换依次经过解码、模拟和结果处理：各阶段事件按顺序写出，共用同一个机会ID和受害者哈希，
+// 未通过门槛的机会止于决策事件
+func TestLifecycleEventSequence(t *testing.T) {
+	var fx fixture
+	if err := json.Unmarshal(selftestFixture, &fx); err != nil {
+		t.Fatal(err)
+	}
+	nodeURL, stopNode, err := fx.startNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stopNode()
+	txs, err := fx.signedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := decoder.LookupChain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		minProfit *big.Int
+		accepted  bool
+		stages    []string
+	}{
+		{name: "accepted", minProfit: big.NewInt(1), accepted: true,
+			stages: []string{lifecycle.StageDetected, lifecycle.StageSimulated, lifecycle.StageDecision, lifecycle.StageExecution}},
+		{name: "below the threshold", minProfit: new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil),
+			stages: []string{lifecycle.StageDetected, lifecycle.StageSimulated, lifecycle.StageDecision}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Sniper: config.SniperConfig{
+					MinProfit:           tt.minProfit,
+					MaxGasPrice:         big.NewInt(500000000000),
+					MaxGasLimit:         3000000,
+					RPCPoolSize:         1,
+					ResultWorkers:       1,
+					TargetBlockOffset:   1,
+					GasSafetyMultiplier: 1.0,
+					SwapDirections:      []string{"buy", "sell", "swap"},
+					SuccessRateCeiling:  1,
+				},
+				Execution: config.ExecutionConfig{PaperTrading: true},
+			}
+			events := &eventLog{}
+			recorder := lifecycle.NewRecorder(events)
+
+			dec := decoder.NewDecoder(chain)
+			dec.SetLifecycleRecorder(recorder)
+			sim := simulator.NewSimulator(nodeURL, chain.Info)
+			sim.SetConfig(&cfg.Sniper, 1)
+			sim.SetLifecycleRecorder(recorder)
+			p := &resultProcessor{
+				chain:      chain,
+				cfgManager: config.NewManager(cfg),
+				lifecycle:  recorder,
+				inflight:   executor.NewInFlightLimiter(),
+			}
+
+			decoded := dec.DecodeTransaction(listener.WrapTransaction(txs[0]))
+			if decoded == nil {
+				t.Fatal("DecodeTransaction() = nil for the profitable fixture swap")
+			}
+			analysis := sim.SimulateTransaction(context.Background(), decoded)
+			if analysis == nil {
+				t.Fatal("SimulateTransaction() = nil for the profitable fixture swap")
+			}
+			profitChan := make(chan *types.ProfitAnalysis, 1)
+			p.start(context.Background(), profitChan)
+			profitChan <- analysis
+			close(profitChan)
+			p.wait()
+
+			if len(events.events) != len(tt.stages) {
+				t.Fatalf("%d events written, want %d (%v)", len(events.events), len(tt.stages), tt.stages)
+			}
+			id := events.events[0].OpportunityID
+			if id == "" || id != decoded.OpportunityID || id != analysis.OpportunityID {
+				t.Fatalf("opportunity id = %q, decoded %q, analysis %q; want one shared non-empty id", id, decoded.OpportunityID, analysis.OpportunityID)
+			}
+			for i, event := range events.events {
+				if event.Stage != tt.stages[i] {
+					t.Errorf("event %d stage = %q, want %q", i, event.Stage, tt.stages[i])
+				}
+				if event.OpportunityID != id || event.TxHash != txs[0].Hash() {
+					t.Errorf("%s event = %s/%s, want %s/%s", event.Stage, event.OpportunityID, event.TxHash.Hex(), id, txs[0].Hash().Hex())
+				}
+				if i > 0 && event.Timestamp.Before(events.events[i-1].Timestamp) {
+					t.Errorf("%s event at %v precedes the %s event at %v", event.Stage, event.Timestamp, events.events[i-1].Stage, events.events[i-1].Timestamp)
+				}
+			}
+			if decision := events.events[2].Detail; decision["accepted"] != tt.accepted {
+				t.Errorf("decision accepted = %v, want %v", decision["accepted"], tt.accepted)
+			}
+			if tt.accepted && events.events[3].Detail["mode"] != "paper" {
+				t.Errorf("execution detail = %v, want a paper trade", events.events[3].Detail)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -10,11 +11,27 @@ import (
 
 	"math/big"
 
+	"mempool-sniper/internal/audit"
+	"mempool-sniper/internal/buildinfo"
 	"mempool-sniper/internal/config"
 	"mempool-sniper/internal/decoder"
+	"mempool-sniper/internal/executor"
+	"mempool-sniper/internal/lifecycle"
 	"mempool-sniper/internal/listener"
+	"mempool-sniper/internal/logging"
+	"mempool-sniper/internal/outcome"
+	"mempool-sniper/internal/output"
+	"mempool-sniper/internal/pnl"
 	"mempool-sniper/internal/simulator"
+	"mempool-sniper/internal/status"
+	"mempool-sniper/internal/storage"
+	"mempool-sniper/internal/training"
+	"mempool-sniper/internal/watchdog"
 	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 // SniperConfig 狙击手配置（用于类型引用）
@@ -25,12 +42,30 @@ type SniperConfig struct {
 }
 
 func main() {
+	selftest := flag.Bool("selftest", false, "使用内置夹具和模拟节点跑通完整管道后退出（用于CI）")
+	verifyAudit := flag.String("verify-audit", "", "校验审计日志的哈希链后退出")
+	flag.Parse()
+
+	if *selftest {
+		os.Exit(runSelfTest())
+	}
+	if *verifyAudit != "" {
+		os.Exit(runVerifyAudit(*verifyAudit))
+	}
+
 	// 加载配置
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// 安装结构化日志（标准库 log 的输出同样经由它，以 info 级别记录）
+	logFile, err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format, cfg.Logging.FilePath)
+	if err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	defer logFile.Close()
+
 	// 创建上下文和取消函数
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -38,46 +73,360 @@ func main() {
 	// 设置信号处理
 	setupSignalHandler(cancel)
 
+	// 按配置的链加载DEX路由器（需在注册额外路由ABI之前）
+	chain, err := decoder.LookupChain(cfg.Ethereum.ChainID)
+	if err != nil {
+		log.Fatalf("❌ 加载链配置失败: %v", err)
+	}
+	log.Printf("⛓️ 链: %s (%d)，%d 个DEX路由器", chain.Info.Name, chain.Info.ChainID, len(chain.Info.Routers))
+
+	// 注册额外的路由合约ABI（需在构建服务端过滤和解码之前）
+	for router, path := range cfg.Sniper.RouterABIFiles {
+		if err := chain.LoadRouterABIFile(router, path); err != nil {
+			log.Fatalf("❌ 加载路由ABI失败: %v", err)
+		}
+		log.Printf("📜 已加载路由ABI: %s -> %s", router.Hex(), path)
+	}
+
 	// 创建监听器
-	listener, err := listener.NewListener(cfg.Ethereum.WSSURL)
+	listener, err := listener.NewListenerWithEndpoints(cfg.Ethereum.WSSURLs)
 	if err != nil {
 		log.Fatalf("Failed to create listener: %v", err)
 	}
+	listener.SetProbeCapabilities(cfg.Ethereum.ProbeCapabilities)
+	listener.SetFetchTimeout(time.Duration(cfg.Ethereum.FetchTimeout) * time.Millisecond)
+	listener.SetFetchConcurrency(cfg.Ethereum.FetchConcurrency)
+	listener.SetBackfill(cfg.Ethereum.PendingBackfill, cfg.Ethereum.PendingBackfillLimit)
+	listener.SetSeenCache(cfg.Ethereum.SeenHashCache, time.Duration(cfg.Ethereum.SeenHashTTLSec)*time.Second)
+	if cfg.Ethereum.ServerFilter {
+		listener.SetPendingFilter(chain.Routers())
+	}
+
+	// 创建生命周期事件记录器
+	var recorder *lifecycle.Recorder
+	if cfg.Logging.LifecycleFile != "" {
+		sink, err := lifecycle.NewFileSink(cfg.Logging.LifecycleFile)
+		if err != nil {
+			log.Fatalf("Failed to create lifecycle log: %v", err)
+		}
+		defer sink.Close()
+		recorder = lifecycle.NewRecorder(sink)
+	}
+
+	// 创建盈亏跟踪器（模拟盘记录）
+	pnlTracker, err := pnl.NewTracker(cfg.Execution.PnLFile)
+	if err != nil {
+		log.Fatalf("Failed to create pnl tracker: %v", err)
+	}
+	defer pnlTracker.Close()
+	if cfg.Execution.PaperTrading {
+		log.Println("📝 模拟盘模式已开启，不会广播任何交易")
+	}
+
+	// 打开执行动作审计日志（已有内容先校验哈希链）
+	var auditLog *audit.Log
+	if cfg.Execution.AuditFile != "" {
+		auditLog, err = audit.Open(cfg.Execution.AuditFile)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		defer auditLog.Close()
+	}
+	if cfg.Execution.ActionDelayMaxMs > 0 {
+		log.Printf("⏳ 执行前随机延迟 %d-%dms 已开启：降低时序特征，但每个机会的执行延迟相应增加",
+			cfg.Execution.ActionDelayMinMs, cfg.Execution.ActionDelayMaxMs)
+	}
+
+	// 创建训练数据输出端（所有模拟结果，含不盈利样本）
+	var trainingSink *training.Sink
+	if cfg.Output.TrainingFile != "" {
+		trainingSink, err = training.NewSink(cfg.Output.TrainingFile, cfg.Output.TrainingSampleRate)
+		if err != nil {
+			log.Fatalf("Failed to create training sink: %v", err)
+		}
+		defer trainingSink.Close()
+	}
+
+	// 打开盈利机会数据库（启动时执行表结构迁移）
+	var opportunityDB *storage.SQLiteStore
+	var opportunityStore storage.Store
+	if cfg.Output.OpportunityDB != "" {
+		opportunityDB, err = storage.OpenSQLite(cfg.Output.OpportunityDB)
+		if err != nil {
+			log.Fatalf("Failed to open opportunity database: %v", err)
+		}
+		defer opportunityDB.Close()
+		opportunityStore = opportunityDB
+		log.Printf("🗄️ 盈利机会写入数据库: %s", cfg.Output.OpportunityDB)
+	}
+
+	// 创建结果跟踪器（受害者交易打包后对比实际与预测数量）
+	var outcomes *outcome.Tracker
+	if client, err := ethclient.Dial(cfg.Ethereum.RPCURL); err == nil {
+		outcomes = outcome.NewTracker(client, recorder, cfg.Execution.ReorgDepth)
+	} else {
+		log.Printf("⚠️ 结果跟踪器连接RPC失败，不对比实际成交: %v", err)
+	}
+
+	// 加载签名账户（多账户轮询，各自独立管理nonce）
+	signers, err := executor.NewKeyRing(cfg.Wallet.SigningKeys())
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+	if signers.Size() > 0 {
+		log.Printf("🔑 已加载 %d 个签名账户", signers.Size())
+	}
+
+	// 创建代币符号解析器（用于日志输出交换路径）
+	var symbolResolver *decoder.SymbolResolver
+	if cfg.Logging.SwapPathSymbols {
+		if client, err := ethclient.Dial(cfg.Ethereum.RPCURL); err == nil {
+			symbolResolver = decoder.NewSymbolResolver(client, chain.NativeSymbol())
+		} else {
+			log.Printf("⚠️ 代币符号解析器连接RPC失败，日志将输出地址: %v", err)
+		}
+	}
+
+	// 解码交易调用参数的调试日志
+	var paramsLog *decoder.ParamsLog
+	if cfg.Logging.DecodedParamsFile != "" {
+		paramsLog, err = decoder.OpenParamsLog(cfg.Logging.DecodedParamsFile)
+		if err != nil {
+			log.Fatalf("Failed to create decoded params log: %v", err)
+		}
+		defer paramsLog.Close()
+	}
 
 	// 创建解码器
-	decoder := decoder.NewDecoder()
+	decoder := decoder.NewDecoder(chain)
+	decoder.SetSymbolResolver(symbolResolver)
+	decoder.SetLifecycleRecorder(recorder)
+	decoder.SetPendingBound(cfg.Sniper.MaxTrackedPending)
+	configureDecoder(decoder, cfg, nil)
+	decoder.SetParamsLog(paramsLog)
 
 	// 创建模拟器
-	simulator := simulator.NewSimulator(cfg.Ethereum.RPCURL)
+	simulator := simulator.NewSimulatorWithEndpoints(cfg.Ethereum.RPCURLs, chain.Info)
+	simulator.SetConfig(&cfg.Sniper, cfg.Version)
+	simulator.SetSupersededCheck(decoder.IsSuperseded)
+	simulator.SetLifecycleRecorder(recorder)
+	if common.IsHexAddress(cfg.Wallet.Address) {
+		simulator.SetTraceAccount(common.HexToAddress(cfg.Wallet.Address))
+	}
+
+	// 机会转化漏斗：seen → decoded → simulated → profitable → above_threshold → acted
+	funnel := lifecycle.NewFunnel()
+	decoder.SetFunnel(funnel)
+	simulator.SetFunnel(funnel)
+
+	// 新区块到达时同步给模拟器，用于计算目标区块；基础费用同步给解码器，用于比较有效小费
+	listener.SetHeadHandler(func(header *ethtypes.Header) {
+		simulator.UpdateHead(header.Number.Uint64())
+		decoder.UpdateBaseFee(header.BaseFee)
+	})
+
+	// 小费分布按整个内存池采样（跑道判断用），而不只是进入模拟的交换
+	listener.SetTxObserver(simulator.ObservePendingTip)
+
+	// 监听器侧预过滤，减少解码通道负载
+	if cfg.Ethereum.PreFilter {
+		listener.SetPreFilter(decoder.PreFilter)
+	}
+
+	// 新区块打包结果用于学习私有订单流特征，并对比受害者交易的实际成交
+	listener.SetBlockHandler(func(block *ethtypes.Block) {
+		decoder.ObserveBlock(block)
+		outcomes.ObserveBlock(ctx, block)
+	})
+	// 只在有待核对的pending交易或受害者交易时拉取完整区块
+	listener.SetBlockFilter(func() bool {
+		return decoder.NeedsBlocks() || outcomes.Watching()
+	})
+	listener.SetGapHandler(decoder.MarkListenerGap)
 
 	// 创建交易通道和盈利分析通道
 	txChan := make(chan *types.Transaction, 100)
 	decodedTxChan := make(chan *types.DecodedTransaction, 100)
 	profitChan := make(chan *types.ProfitAnalysis, 100)
 
-	// 启动监听器
-	go listener.Start(ctx, txChan)
+	// 流水线各阶段使用独立的上下文：收到退出信号后先停止监听器，
+	// 再依次排空解码器、模拟器和结果处理（见 shutdownPipeline）
+	listenerCtx, stopListener := context.WithCancel(context.Background())
+	defer stopListener()
+	pipelineCtx, stopPipeline := context.WithCancel(context.Background())
+	defer stopPipeline()
+
+	// 启动监听器（同步启动，保证关闭时 Wait 能覆盖其全部发送goroutine）
+	if err := listener.Start(listenerCtx, txChan); err != nil {
+		log.Printf("❌ 监听器启动失败: %v", err)
+	}
 
 	// 启动解码器工作池
-	go decoder.StartWorkerPool(ctx, txChan, decodedTxChan, 5)
+	decoder.StartWorkerPool(pipelineCtx, txChan, decodedTxChan, 5)
 
 	// 启动模拟器工作池
-	go simulator.StartWorkerPool(ctx, decodedTxChan, profitChan, 3)
+	go simulator.StartWorkerPool(pipelineCtx, decodedTxChan, profitChan, 3)
+
+	// 工作池看门狗：工作线程全部退出或卡死时重启
+	var supervisor *watchdog.Watchdog
+	if cfg.Sniper.WatchdogStallSec > 0 {
+		supervisor = watchdog.New(time.Duration(cfg.Sniper.WatchdogStallSec) * time.Second)
+		supervisor.Register("decoder", decoder.Progress, func() int { return len(txChan) }, decoder.RestartWorkers)
+		supervisor.Register("simulator", simulator.Progress, func() int { return len(decodedTxChan) }, simulator.RestartWorkers)
+		supervisor.Start(pipelineCtx)
+	}
+
+	// 创建配置管理器（SIGHUP触发热重载）
+	cfgManager := config.NewManager(cfg)
+	setupReloadHandler(ctx, cfgManager, decoder, simulator, listener)
+
+	// 最近机会记录（状态服务以 Grafana JSON 数据源格式输出）
+	var recent *status.OpportunityLog
+	if cfg.Output.StatusAddr != "" {
+		recent = status.NewOpportunityLog(1000)
+	}
+
+	// Webhook输出端（可选HMAC签名）
+	var webhook *output.Webhook
+	if cfg.Output.WebhookURL != "" {
+		encoder, err := output.NewEncoder(cfg.Output.Format)
+		if err != nil {
+			log.Fatalf("Failed to create output encoder: %v", err)
+		}
+		webhook = output.NewWebhook(cfg.Output.WebhookURL, cfg.Output.WebhookSecret, encoder,
+			time.Duration(cfg.Output.WebhookTimeoutMs)*time.Millisecond, cfg.Output.WebhookRetries)
+		webhook.Start(pipelineCtx)
+		log.Printf("🔗 Webhook输出已启用 (签名: %v)", cfg.Output.WebhookSecret != "")
+	}
+
+	// Telegram通知（可选）
+	var telegram *output.TelegramNotifier
+	if cfg.Output.TelegramBotToken != "" {
+		telegram = output.NewTelegramNotifier(cfg.Output.TelegramAPIURL, cfg.Output.TelegramBotToken,
+			cfg.Output.TelegramChatID, cfg.Output.TelegramBatchThreshold,
+			time.Duration(cfg.Output.WebhookTimeoutMs)*time.Millisecond)
+		telegram.Start(pipelineCtx)
+		log.Printf("📨 Telegram通知已启用 (会话: %s)", cfg.Output.TelegramChatID)
+	}
+
+	// 启动结果处理工作池
+	// 可执行机会通知器：日志 + 可选Webhook/Telegram
+	notifiers := []output.Notifier{output.LogNotifier{}}
+	if webhook != nil {
+		notifiers = append(notifiers, webhook)
+	}
+	if telegram != nil {
+		notifiers = append(notifiers, telegram)
+	}
+
+	results := &resultProcessor{
+		chain:      chain,
+		cfgManager: cfgManager,
+		lifecycle:  recorder,
+		funnel:     funnel,
+		pnl:        pnlTracker,
+		simulator:  simulator,
+		signers:    signers,
+		shadow:     executor.NewShadowExecutor(big.NewInt(cfg.Ethereum.ChainID)),
+		training:   trainingSink,
+		store:      opportunityStore,
+		outcomes:   outcomes,
+		recent:     recent,
+		audit:      auditLog,
+		notifiers:  notifiers,
+		inflight:   executor.NewInFlightLimiter(),
+	}
+	results.start(pipelineCtx, profitChan)
 
-	// 启动结果处理器
-	go processResults(ctx, profitChan, &cfg.Sniper)
+	// SIGUSR1 手动恢复异常盈利熔断
+	setupResumeHandler(ctx, &results.sanity)
+
+	// 汇总各组件统计信息（状态服务和统计快照导出共用）
+	statusServer := status.NewServer(cfg.Output.StatusAddr, cfg.Output.StatusPprof)
+	statusServer.SetOpportunityLog(recent)
+	statusServer.Register("build", buildinfo.GetStats)
+	statusServer.Register("listener", listener.GetStats)
+	statusServer.Register("decoder", decoder.GetStats)
+	statusServer.Register("simulator", simulator.GetStats)
+	statusServer.Register("funnel", funnel.GetStats)
+	statusServer.RegisterConnection("listener", listener.IsRunning)
+	statusServer.RegisterConnection("simulator", simulator.IsConnected)
+	statusServer.Register("pnl", pnlTracker.GetStats)
+	statusServer.Register("signers", signers.GetStats)
+	statusServer.Register("shadow", results.shadow.GetStats)
+	statusServer.Register("throttle", results.throttle.GetStats)
+	statusServer.Register("action_delay", results.delay.GetStats)
+	statusServer.Register("dedup", results.dedup.GetStats)
+	statusServer.Register("sanity", results.sanity.GetStats)
+	statusServer.Register("in_flight", results.inflight.GetStats)
+	statusServer.Register("stale_analyses", results.stale.GetStats)
+	if supervisor != nil {
+		statusServer.Register("watchdog", supervisor.GetStats)
+	}
+	if webhook != nil {
+		statusServer.Register("webhook", webhook.GetStats)
+	}
+	if telegram != nil {
+		statusServer.Register("telegram", telegram.GetStats)
+	}
+	if auditLog != nil {
+		statusServer.Register("audit", auditLog.GetStats)
+	}
+	if trainingSink != nil {
+		statusServer.Register("training", trainingSink.GetStats)
+	}
+	if opportunityDB != nil {
+		statusServer.Register("storage", opportunityDB.GetStats)
+	}
+	if outcomes != nil {
+		statusServer.Register("outcomes", outcomes.GetStats)
+	}
+
+	// 定期导出统计快照
+	if cfg.Output.StatsExportFile != "" {
+		exporter, err := status.NewExporter(statusServer, cfg.Output.StatsExportFile,
+			time.Duration(cfg.Output.StatsExportInterval)*time.Second,
+			int64(cfg.Output.StatsExportMaxMB)<<20, cfg.Output.StatsExportBackups)
+		if err != nil {
+			log.Fatalf("❌ 创建统计快照导出失败: %v", err)
+		}
+		statusServer.Register("stats_export", exporter.GetStats)
+		exporter.Start(ctx)
+	}
+
+	if cfg.Logging.FunnelLogInterval > 0 {
+		funnel.StartLogging(ctx, time.Duration(cfg.Logging.FunnelLogInterval)*time.Second)
+	}
+
+	// 启动状态服务
+	if cfg.Output.StatusAddr != "" {
+		statusServer.Start(ctx)
+	}
 
 	log.Println("🚀 Mempool Sniper 启动成功")
-	log.Printf("📡 监听节点: %s", cfg.Ethereum.WSSURL)
-	log.Printf("🔍 模拟节点: %s", cfg.Ethereum.RPCURL)
+	log.Printf("🏷️ 版本: %s", buildinfo.String())
+	log.Printf("📡 监听节点: %s (候选 %d 个)", logging.RedactURL(cfg.Ethereum.WSSURL), len(cfg.Ethereum.WSSURLs))
+	log.Printf("🔍 模拟节点: %s (候选 %d 个)", logging.RedactURL(cfg.Ethereum.RPCURL), len(cfg.Ethereum.RPCURLs))
 	log.Println("⏳ 等待交易...")
 
 	// 等待程序退出
 	<-ctx.Done()
 	log.Println("🛑 Mempool Sniper 正在关闭...")
 
-	// 等待所有goroutine完成
-	time.Sleep(2 * time.Second)
+	// 按 监听器 → 解码器 → 模拟器 → 结果处理 的顺序关闭，每个阶段确认退出后再关闭其输出通道
+	clean := shutdownPipeline([]pipelineStage{
+		{name: "监听器", stop: stopListener, wait: listener.Wait, close: func() { close(txChan) }},
+		{name: "解码器", wait: decoder.Wait, close: func() { close(decodedTxChan) }},
+		{name: "模拟器", wait: simulator.Wait, close: func() { close(profitChan) }},
+		{name: "结果处理", wait: results.wait},
+	}, time.Duration(cfg.Sniper.ShutdownTimeoutSec)*time.Second)
+	stopPipeline()
+	listener.Stop()
+
+	if !clean {
+		log.Println("⚠️ Mempool Sniper 已关闭 (部分阶段未能按序停止)")
+		return
+	}
 	log.Println("✅ Mempool Sniper 已安全关闭")
 }
 
@@ -93,23 +442,92 @@ func setupSignalHandler(cancel context.CancelFunc) {
 	}()
 }
 
-// processResults 处理盈利分析结果
-func processResults(ctx context.Context, profitChan chan *types.ProfitAnalysis, cfg *config.SniperConfig) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case analysis := <-profitChan:
-			if analysis != nil && analysis.Profit.Cmp(cfg.MinProfit) >= 0 {
-				log.Printf("💰 发现盈利机会!")
-				log.Printf("  交易哈希: %s", analysis.TxHash.Hex())
-				log.Printf("  预估盈利: %s ETH", analysis.Profit.String())
-				log.Printf("  目标合约: %s", analysis.TargetContract.Hex())
-				log.Printf("  方法: %s", analysis.Method)
-
-				// 这里可以添加自动交易逻辑
-				// 或者发送通知到外部系统
+// configureDecoder 应用解码器的可热重载配置（previous 为nil表示首次配置）
+// 检测器的窗口参数未变化时保留其已积累的状态
+func configureDecoder(dec *decoder.Decoder, cfg, previous *config.Config) {
+	// 交易对白名单（零地址表示ETH，按链转换为包装代币）
+	chainID := big.NewInt(cfg.Ethereum.ChainID)
+	pairWhitelist := make([]decoder.TokenPair, 0, len(cfg.Sniper.PairWhitelist))
+	for _, pair := range cfg.Sniper.PairWhitelist {
+		pairWhitelist = append(pairWhitelist, decoder.NewTokenPair(types.PoolToken(pair[0], chainID), types.PoolToken(pair[1], chainID)))
+	}
+
+	dec.SetRecipientFilter(cfg.Sniper.RecipientAllowlist, cfg.Sniper.RecipientDenylist)
+	dec.SetPairWhitelist(pairWhitelist)
+	_, liquidation := cfg.Sniper.Strategies["liquidation"]
+	dec.SetLendingDetection(liquidation)
+	if liquidation && !dec.Chain().HasLending() {
+		log.Printf("⚠️ 当前链没有支持的借贷市场，清算策略不会产生机会")
+	}
+	dec.SetSelectorBloom(cfg.Ethereum.SelectorBloom)
+
+	sniper := cfg.Sniper
+	if previous == nil || sniper.SpamWindowMs != previous.Sniper.SpamWindowMs || sniper.SpamMinSenders != previous.Sniper.SpamMinSenders {
+		dec.SetSpamDetection(time.Duration(sniper.SpamWindowMs)*time.Millisecond, sniper.SpamMinSenders)
+	}
+	if previous == nil || sniper.AttackerWindowMs != previous.Sniper.AttackerWindowMs || sniper.AttackerSizeRatio != previous.Sniper.AttackerSizeRatio {
+		dec.SetAttackerDetection(time.Duration(sniper.AttackerWindowMs)*time.Millisecond, sniper.AttackerSizeRatio)
+	}
+	if previous == nil || sniper.ApprovalWindowMs != previous.Sniper.ApprovalWindowMs {
+		dec.SetApprovalTracking(time.Duration(sniper.ApprovalWindowMs) * time.Millisecond)
+	}
+	if previous != nil && sniper.MaxTrackedPending != previous.Sniper.MaxTrackedPending {
+		log.Printf("⚠️ MAX_TRACKED_PENDING 变更需重启后生效 (当前仍为 %d)", previous.Sniper.MaxTrackedPending)
+	}
+}
+
+// setupReloadHandler 设置配置热重载（SIGHUP），新配置无效时保留当前配置；
+// 解码器和模拟器应用新配置，节点地址变化时热切换（新节点链ID不一致则拒绝，继续使用旧节点）
+func setupReloadHandler(ctx context.Context, cfgManager *config.Manager, dec *decoder.Decoder, sim *simulator.Simulator, lst *listener.Listener) {
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hupChan)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hupChan:
+				log.Println("🔄 收到SIGHUP，重新加载配置...")
+				previousConfig := cfgManager.Current()
+				previous := previousConfig.Ethereum
+				if err := cfgManager.Reload(); err != nil {
+					continue
+				}
+				current := cfgManager.Current()
+				configureDecoder(dec, current, previousConfig)
+				sim.SetConfig(&current.Sniper, current.Version)
+				if current.Ethereum.ChainID != previous.ChainID {
+					log.Printf("⚠️ ETH_CHAIN_ID 变更需重启后生效 (当前仍为 %d)", previous.ChainID)
+				}
+
+				chainID := big.NewInt(current.Ethereum.ChainID)
+				if current.Ethereum.RPCURL != previous.RPCURL {
+					if err := sim.SwapRPC(ctx, current.Ethereum.RPCURL, chainID); err != nil {
+						log.Printf("⚠️ 模拟器RPC节点切换失败，继续使用旧节点: %v", err)
+						cfgManager.Amend(func(cfg *config.Config) { cfg.Ethereum.RPCURL = previous.RPCURL })
+					}
+				}
+				if current.Ethereum.WSSURL != previous.WSSURL {
+					if err := lst.SwapEndpoint(ctx, current.Ethereum.WSSURL, chainID); err != nil {
+						log.Printf("⚠️ 监听节点切换失败，继续使用旧节点: %v", err)
+						cfgManager.Amend(func(cfg *config.Config) { cfg.Ethereum.WSSURL = previous.WSSURL })
+					}
+				}
 			}
 		}
+	}()
+}
+
+// runVerifyAudit 校验审计日志哈希链及头记录，返回进程退出码
+func runVerifyAudit(path string) int {
+	count, err := audit.VerifyFile(path)
+	if err != nil {
+		log.Printf("❌ 审计日志校验失败: %v", err)
+		return 1
 	}
+	log.Printf("✅ 审计日志哈希链完整，共 %d 条记录", count)
+	return 0
 }
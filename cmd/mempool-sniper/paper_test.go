@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"mempool-sniper/internal/executor"
+	"mempool-sniper/internal/pnl"
+	"mempool-sniper/internal/simulator"
+	"mempool-sniper/internal/storage"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// recordingNode 记录收到的JSON-RPC方法的假节点（所有调用都返回错误）
+type recordingNode struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (n *recordingNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	n.mu.Lock()
+	n.methods = append(n.methods, req.Method)
+	n.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"error":   map[string]interface{}{"code": -32601, "message": "method not found"},
+	})
+}
+
+func TestPaperTradeRecordsShadowTradeWithoutBroadcast(t *testing.T) {
+	node := &recordingNode{}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers, err := executor.NewKeyRing([]string{hex.EncodeToString(crypto.FromECDSA(key))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := pnl.NewTracker("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := storage.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	info, _ := types.LookupChain(1)
+
+	p := &resultProcessor{
+		pnl:       tracker,
+		simulator: simulator.NewSimulator(server.URL, &info),
+		signers:   signers,
+		shadow:    executor.NewShadowExecutor(big.NewInt(1)),
+		store:     store,
+	}
+
+	router := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	netProfit := big.NewInt(5e15)
+	analysis := &types.ProfitAnalysis{
+		OpportunityID:  "opp-paper",
+		TxHash:         common.HexToHash("0xabc"),
+		TargetContract: router,
+		Method:         "swapExactETHForTokens",
+		TargetBlock:    101,
+		NetProfit:      netProfit,
+		GasEstimation:  &types.GasEstimation{GasUsed: 150000, GasPrice: big.NewInt(30e9), PriorityFee: big.NewInt(2e9)},
+		Predicted:      &types.SandwichPrediction{OurIn: big.NewInt(5e17)},
+		Source: &types.DecodedTransaction{
+			Transaction:    &types.Transaction{Hash: common.HexToHash("0xabc"), GasLimit: 200000, ChainID: big.NewInt(1)},
+			TargetContract: router,
+			TokenIn:        types.NativeToken,
+			Path: []common.Address{
+				common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+				common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+			},
+			AmountIn: big.NewInt(1e18),
+		},
+	}
+
+	tx := p.recordPaperTrade(context.Background(), analysis)
+	if tx["hash"] == nil || tx["from"] != crypto.PubkeyToAddress(key.PublicKey).Hex() {
+		t.Fatalf("paper trade did not build a signed shadow tx: %v", tx)
+	}
+	if tx["value"] != "500000000000000000" || tx["gas"] != uint64(150000) {
+		t.Errorf("shadow tx value = %v, gas = %v; want the predicted 0.5 ETH buy and estimated gas", tx["value"], tx["gas"])
+	}
+
+	stats := tracker.GetStats()
+	if stats["simulated_pnl"] != netProfit.String() || stats["trades"] != int64(1) {
+		t.Errorf("pnl stats = %v, want one simulated trade of %s", stats, netProfit)
+	}
+	if saved := store.GetStats()["shadow_trades"]; saved != int64(1) {
+		t.Errorf("shadow trades saved = %v, want 1", saved)
+	}
+	if inFlight := signers.Next().Nonces.InFlight(); inFlight != 0 {
+		t.Errorf("paper trade reserved %d nonces", inFlight)
+	}
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	for _, method := range node.methods {
+		if method == "eth_sendRawTransaction" || method == "eth_sendTransaction" {
+			t.Errorf("paper trade broadcast via %s", method)
+		}
+	}
+}
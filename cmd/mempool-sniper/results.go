@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sync"
+
+	"mempool-sniper/internal/audit"
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/decoder"
+	"mempool-sniper/internal/executor"
+	"mempool-sniper/internal/filter"
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/internal/outcome"
+	"mempool-sniper/internal/output"
+	"mempool-sniper/internal/pnl"
+	"mempool-sniper/internal/simulator"
+	"mempool-sniper/internal/status"
+	"mempool-sniper/internal/storage"
+	"mempool-sniper/internal/training"
+	"mempool-sniper/pkg/types"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// resultProcessor 盈利分析结果处理器
+type resultProcessor struct {
+	chain      *decoder.Chain // 链配置（过滤表达式的 DEX 名称）
+	cfgManager *config.Manager
+	lifecycle  *lifecycle.Recorder
+	pnl        *pnl.Tracker             // 盈亏跟踪器（模拟盘记录）
+	simulator  *simulator.Simulator     // 用于执行前重新模拟
+	signers    *executor.KeyRing        // 签名账户（轮询分配）
+	shadow     *executor.ShadowExecutor // 模拟盘影子执行（签名但不广播，为nil表示只记录盈亏）
+	training   *training.Sink           // 训练数据输出端（为nil表示不输出）
+	store      storage.Store            // 盈利机会持久化（为nil表示不记录）
+	outcomes   *outcome.Tracker         // 结果跟踪器（为nil表示不跟踪）
+	recent     *status.OpportunityLog   // 最近的可执行机会（为nil表示不记录）
+	audit      *audit.Log               // 执行动作审计日志（为nil表示不记录）
+	notifiers  []output.Notifier        // 可执行机会通知器（日志、Webhook等）
+
+	filterMu sync.Mutex
+	filter   *filter.Expr // 已编译的过滤表达式（配置变化时重新编译）
+
+	throttle opportunityThrottle // 可执行机会每秒上限
+	delay    actionDelay         // 执行前随机延迟
+	dedup    opportunityDedup    // 经济等价机会去重
+	sanity   sanitySwitch        // 异常盈利熔断
+
+	inflight *executor.InFlightLimiter // 同时进行中的执行数量上限
+
+	thresholds profitThresholds // 按盈利代币的最小盈利
+
+	stale staleAnalyses // 热重载前的配置下得出的分析结果
+
+	workers    sync.WaitGroup // 运行中的工作线程
+	executions sync.WaitGroup // 运行中的执行（含等待执行名额的）
+
+	funnel *lifecycle.Funnel // 机会转化漏斗（统计 profitable 及之后的阶段）
+}
+
+// start 启动结果处理工作池（多个工作线程并发消费，不保证顺序）
+func (p *resultProcessor) start(ctx context.Context, profitChan chan *types.ProfitAnalysis) {
+	workers := p.cfgManager.Current().Sniper.ResultWorkers
+	log.Printf("📬 启动结果处理工作池，工作线程数: %d", workers)
+
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go func() {
+			defer p.workers.Done()
+			p.processResults(ctx, profitChan)
+		}()
+	}
+}
+
+// wait 等待所有工作线程退出（上下文取消或输入通道关闭且排空后），再等待去重窗口和已派发的执行结束
+func (p *resultProcessor) wait() {
+	p.workers.Wait()
+	p.dedup.wait()
+	p.executions.Wait()
+}
+
+// processResults 处理盈利分析结果
+func (p *resultProcessor) processResults(ctx context.Context, profitChan chan *types.ProfitAnalysis) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case analysis, ok := <-profitChan:
+			if !ok {
+				return
+			}
+			if analysis == nil {
+				continue
+			}
+
+			current := p.cfgManager.Current()
+			cfg := &current.Sniper
+			execCfg := &current.Execution
+
+			// 热重载后：旧配置下的分析结果按当前配置重新模拟，保证重载立即一致生效
+			analysis = p.stale.refresh(ctx, p.simulator, analysis, current.Version)
+			if analysis == nil {
+				continue
+			}
+			if analysis.NetProfit != nil && analysis.NetProfit.Sign() > 0 {
+				p.funnel.Add(lifecycle.FunnelProfitable)
+			}
+
+			// 最终盈利门槛：扣除Gas、构建者小费和安全缓冲后的净盈利（盈利代币配置了阈值时使用该阈值）
+			minProfit := p.thresholds.minProfit(ctx, p.simulator, cfg.MinProfitByToken, analysis, cfg.MinProfit)
+			accepted := passesCostGate(analysis, execCfg, minProfit)
+
+			// 异常盈利熔断：熔断期间拒绝所有机会，直到手动恢复
+			paused := !p.allowSanity(ctx, analysis, execCfg.SanityMaxProfit)
+			if paused {
+				accepted = false
+			}
+
+			if accepted && cfg.OpportunityFilter != "" {
+				accepted = p.matchFilter(cfg.OpportunityFilter, analysis)
+			}
+			// 经济等价去重：窗口结束时只放行窗口内最优的机会，工作线程不等待窗口
+			if !accepted {
+				p.decide(ctx, analysis, current, minProfit, false, false, paused)
+				continue
+			}
+			p.dedup.submit(ctx, analysis, execCfg.DedupWindowMs, execCfg.DedupBucketWidth, func(admitted bool) {
+				p.decide(ctx, analysis, current, minProfit, admitted, !admitted, paused)
+			})
+		}
+	}
+}
+
+// decide 记录机会的最终决策（去重之后），放行的机会按每秒上限派发执行
+func (p *resultProcessor) decide(ctx context.Context, analysis *types.ProfitAnalysis, current *config.Config, minProfit *big.Int, accepted, duplicate, paused bool) {
+	execCfg := &current.Execution
+
+	// 最小盈利大于0，通过门槛的机会一定已计入 profitable
+	if accepted {
+		p.funnel.Add(lifecycle.FunnelAboveThreshold)
+	}
+
+	// 记录生命周期：决策
+	p.lifecycle.Emit(analysis.OpportunityID, lifecycle.StageDecision, analysis.TxHash, map[string]interface{}{
+		"accepted":               accepted,
+		"min_profit":             minProfit.String(),
+		"net_profit_after_costs": analysis.NetProfitAfterCosts.String(),
+		"filter":                 current.Sniper.OpportunityFilter,
+		"duplicate":              duplicate,
+		"paused":                 paused,
+	})
+
+	// 训练数据：无论是否盈利都按采样率记录
+	if err := p.training.Record(analysis, accepted); err != nil {
+		log.Printf("⚠️ 写入训练数据失败: %v", err)
+	}
+
+	// 持久化盈利机会（含未通过门槛的），用于回测和审计
+	if p.store != nil && analysis.NetProfit != nil && analysis.NetProfit.Sign() > 0 {
+		if err := p.store.SaveOpportunity(ctx, analysis, accepted); err != nil {
+			log.Printf("⚠️ 写入盈利机会数据库失败: %v", err)
+		}
+	}
+
+	if !accepted {
+		return
+	}
+
+	// 每秒上限：避免下游输出/通知过载
+	if !p.throttle.acquire(ctx, execCfg.OpportunityRateLimit, execCfg.OpportunityRateMode) {
+		p.lifecycle.Emit(analysis.OpportunityID, lifecycle.StageExecution, analysis.TxHash, map[string]interface{}{
+			"aborted": true,
+			"reason":  "throttled",
+		})
+		p.recordAudit(analysis, execCfg, minProfit, nil, "aborted: throttled")
+		return
+	}
+
+	p.dispatch(ctx, analysis, current.Version, execCfg, minProfit)
+}
+
+// dispatch 占用执行名额后在独立的goroutine中执行，工作线程不等待延迟和复核，继续处理后续结果。
+// 名额已满时：drop 模式立即放弃，queue 模式由执行goroutine等待名额释放
+func (p *resultProcessor) dispatch(ctx context.Context, analysis *types.ProfitAnalysis, version uint64, execCfg *config.ExecutionConfig, minProfit *big.Int) {
+	queue := execCfg.InFlightMode == ThrottleQueue
+	if !queue && !p.inflight.Acquire(ctx, execCfg.MaxInFlight, false) {
+		p.abortInFlight(analysis, execCfg, minProfit)
+		return
+	}
+
+	p.executions.Add(1)
+	go func() {
+		defer p.executions.Done()
+		if queue && !p.inflight.Acquire(ctx, execCfg.MaxInFlight, true) {
+			p.abortInFlight(analysis, execCfg, minProfit)
+			return
+		}
+		defer p.inflight.Release()
+		p.execute(ctx, analysis, version, execCfg, minProfit)
+	}()
+}
+
+// abortInFlight 记录因执行数量上限放弃的机会
+func (p *resultProcessor) abortInFlight(analysis *types.ProfitAnalysis, execCfg *config.ExecutionConfig, minProfit *big.Int) {
+	p.lifecycle.Emit(analysis.OpportunityID, lifecycle.StageExecution, analysis.TxHash, map[string]interface{}{
+		"aborted": true,
+		"reason":  "in_flight_limit",
+	})
+	p.recordAudit(analysis, execCfg, minProfit, nil, "aborted: in_flight_limit")
+}
+
+// execute 执行可执行机会（真实交易或模拟盘）：延迟、复核并记录，调用方持有执行名额
+func (p *resultProcessor) execute(ctx context.Context, analysis *types.ProfitAnalysis, version uint64, execCfg *config.ExecutionConfig, minProfit *big.Int) {
+	// 随机延迟：避免固定时序被识别（在复核之前，复核可以看到延迟期间的状态变化）
+	if !p.delay.wait(ctx, execCfg.ActionDelayMinMs, execCfg.ActionDelayMaxMs) {
+		return
+	}
+
+	// 排队和延迟期间配置可能已重载：按新配置的门槛重新判断
+	if current := p.cfgManager.Current(); current.Version != version {
+		execCfg = &current.Execution
+		minProfit = p.thresholds.minProfit(ctx, p.simulator, current.Sniper.MinProfitByToken, analysis, current.Sniper.MinProfit)
+		if !passesCostGate(analysis, execCfg, minProfit) {
+			p.stale.abortRegate()
+			p.lifecycle.Emit(analysis.OpportunityID, lifecycle.StageExecution, analysis.TxHash, map[string]interface{}{
+				"aborted": true,
+				"reason":  "config_reloaded",
+			})
+			p.recordAudit(analysis, execCfg, minProfit, nil, "aborted: config_reloaded")
+			return
+		}
+	}
+
+	// 执行前复核：状态可能已变化，在最新区块重新模拟
+	if execCfg.PreTradeRecheck && p.simulator != nil {
+		// 与首次决策使用同一门槛：扣除构建者小费和安全缓冲后的净盈利
+		latest, err := p.simulator.Recheck(ctx, analysis, func(latest *types.ProfitAnalysis) bool {
+			return passesCostGate(latest, execCfg, minProfit)
+		})
+		if err != nil {
+			p.lifecycle.Emit(analysis.OpportunityID, lifecycle.StageExecution, analysis.TxHash, map[string]interface{}{
+				"aborted": true,
+				"reason":  err.Error(),
+			})
+			p.recordAudit(analysis, execCfg, minProfit, nil, "aborted: "+err.Error())
+			return
+		}
+		if !p.allowSanity(ctx, latest, execCfg.SanityMaxProfit) {
+			p.lifecycle.Emit(analysis.OpportunityID, lifecycle.StageExecution, analysis.TxHash, map[string]interface{}{
+				"aborted": true,
+				"reason":  "sanity_kill_switch",
+			})
+			p.recordAudit(latest, execCfg, minProfit, nil, "aborted: sanity_kill_switch")
+			return
+		}
+		analysis = latest
+	}
+
+	// 跟踪受害者交易的实际成交
+	p.funnel.Add(lifecycle.FunnelActed)
+	p.outcomes.Watch(analysis)
+	p.recent.Record(analysis)
+	p.notify(ctx, analysis)
+
+	// 模拟盘：假设在目标区块按模拟结果成交，记录盈亏
+	if execCfg.PaperTrading {
+		tx := p.recordPaperTrade(ctx, analysis)
+		p.recordAudit(analysis, execCfg, minProfit, tx, "paper_recorded")
+	}
+
+	// 这里可以添加自动交易逻辑
+	// 或者发送通知到外部系统
+}
+
+// notify 依次调用所有通知器，单个通知器失败只记录日志
+func (p *resultProcessor) notify(ctx context.Context, analysis *types.ProfitAnalysis) {
+	for _, notifier := range p.notifiers {
+		if err := notifier.Notify(ctx, analysis); err != nil {
+			log.Printf("⚠️ 通知 %s 失败: %v", analysis.TxHash.Hex(), err)
+		}
+	}
+}
+
+// allowSanity 异常盈利熔断检查，本次触发熔断时告警
+func (p *resultProcessor) allowSanity(ctx context.Context, analysis *types.ProfitAnalysis, maxProfit *big.Int) bool {
+	allowed, alert := p.sanity.allow(analysis, maxProfit)
+	if alert != "" {
+		p.alert(ctx, alert)
+	}
+	return allowed
+}
+
+// alert 把运维告警发给支持告警的通知器
+func (p *resultProcessor) alert(ctx context.Context, text string) {
+	for _, notifier := range p.notifiers {
+		alerter, ok := notifier.(output.Alerter)
+		if !ok {
+			continue
+		}
+		if err := alerter.Alert(ctx, text); err != nil {
+			log.Printf("⚠️ 发送告警失败: %v", err)
+		}
+	}
+}
+
+// matchFilter 按过滤表达式判断机会是否保留（配置加载时已校验；编译失败时拒绝，不放行未经过滤的机会）
+func (p *resultProcessor) matchFilter(src string, analysis *types.ProfitAnalysis) bool {
+	p.filterMu.Lock()
+	if p.filter == nil || p.filter.String() != src {
+		expr, err := filter.Compile(src, filter.OpportunityFields)
+		if err != nil {
+			p.filterMu.Unlock()
+			log.Printf("⚠️ 过滤表达式无效，拒绝机会: %v", err)
+			return false
+		}
+		p.filter = expr
+	}
+	expr := p.filter
+	p.filterMu.Unlock()
+
+	return expr.Match(filter.OpportunityEnv(analysis, p.chain.DEXName(analysis.TargetContract)))
+}
+
+// recordAudit 追加执行动作审计记录：决策输入、构建的交易（可为nil）和结果
+func (p *resultProcessor) recordAudit(analysis *types.ProfitAnalysis, execCfg *config.ExecutionConfig, minProfit *big.Int, tx map[string]interface{}, result string) {
+	if p.audit == nil {
+		return
+	}
+
+	mode := "live"
+	if execCfg.PaperTrading {
+		mode = "paper"
+	}
+	inputs := map[string]interface{}{
+		"victim_tx":    analysis.TxHash.Hex(),
+		"strategy":     analysis.Strategy,
+		"profit":       analysis.Profit.String(),
+		"gas_cost":     analysis.GasCost.String(),
+		"net_profit":   analysis.NetProfit.String(),
+		"min_profit":   minProfit.String(),
+		"success_rate": analysis.SuccessRate,
+		"risk_level":   analysis.RiskLevel,
+		"target_block": analysis.TargetBlock,
+	}
+	if analysis.NetProfitAfterCosts != nil {
+		inputs["net_profit_after_costs"] = analysis.NetProfitAfterCosts.String()
+	}
+
+	err := p.audit.Record(audit.Entry{
+		OpportunityID: analysis.OpportunityID,
+		Mode:          mode,
+		Inputs:        inputs,
+		Tx:            tx,
+		Result:        result,
+	})
+	if err != nil {
+		log.Printf("⚠️ 写入审计日志失败: %v", err)
+	}
+}
+
+// recordPaperTrade 记录模拟盘成交（不广播任何交易）：影子构建并签名买入腿，假设在目标区块按模拟结果成交，
+// 盈亏计入盈亏跟踪器（标记为模拟）并持久化影子成交，返回本应发送的交易概要
+func (p *resultProcessor) recordPaperTrade(ctx context.Context, analysis *types.ProfitAnalysis) map[string]interface{} {
+	trade := &types.ShadowTrade{
+		OpportunityID: analysis.OpportunityID,
+		VictimTx:      analysis.TxHash,
+		TargetBlock:   analysis.TargetBlock,
+		NetProfit:     analysis.NetProfit,
+	}
+	fields := map[string]interface{}{
+		"mode":         "paper",
+		"target_block": analysis.TargetBlock,
+		"net_profit":   analysis.NetProfit.String(),
+	}
+	tx := map[string]interface{}{
+		"to":           analysis.TargetContract.Hex(),
+		"method":       analysis.Method,
+		"target_block": analysis.TargetBlock,
+	}
+
+	// 模拟盘不占用nonce，只记录本应使用的账户和nonce
+	if signer := p.signers.Next(); signer != nil {
+		trade.Account = signer.Address
+		fields["account"] = signer.Address.Hex()
+		tx["from"] = signer.Address.Hex()
+		if shadowTx, nonce, err := p.buildShadowTx(signer, analysis); err != nil {
+			log.Printf("⚠️ 构建模拟盘影子交易失败 %s: %v", analysis.TxHash.Hex(), err)
+		} else if shadowTx != nil {
+			trade.ShadowTx, trade.Nonce = shadowTx.Hash(), nonce
+			fields["shadow_tx"] = shadowTx.Hash().Hex()
+			tx["hash"] = shadowTx.Hash().Hex()
+			tx["nonce"] = nonce
+			tx["to"] = shadowTx.To().Hex()
+			tx["value"] = shadowTx.Value().String()
+			tx["gas"] = shadowTx.Gas()
+		}
+	}
+
+	if p.pnl != nil {
+		err := p.pnl.Record(pnl.Entry{
+			OpportunityID: analysis.OpportunityID,
+			TxHash:        analysis.TxHash,
+			TargetBlock:   analysis.TargetBlock,
+			NetProfit:     analysis.NetProfit,
+			Simulated:     true,
+		})
+		if err != nil {
+			log.Printf("⚠️ 记录模拟盘盈亏失败: %v", err)
+		}
+	}
+	if p.store != nil {
+		if err := p.store.SaveShadowTrade(ctx, trade); err != nil {
+			log.Printf("⚠️ 保存模拟盘影子成交失败: %v", err)
+		}
+	}
+
+	p.lifecycle.Emit(analysis.OpportunityID, lifecycle.StageExecution, analysis.TxHash, fields)
+	log.Printf("📝 [模拟盘] 记录成交: %s 目标区块 %d 净盈利 %s",
+		analysis.TxHash.Hex(), analysis.TargetBlock, analysis.FormatProfit(analysis.NetProfit))
+	return tx
+}
+
+// buildShadowTx 影子构建并签名机会的买入腿（不广播），非V2路由等无法构建时返回nil
+func (p *resultProcessor) buildShadowTx(signer *executor.Signer, analysis *types.ProfitAnalysis) (*ethtypes.Transaction, uint64, error) {
+	if p.shadow == nil || p.simulator == nil {
+		return nil, 0, nil
+	}
+	call, err := p.simulator.FrontRunCall(analysis, signer.Address)
+	if err != nil || call == nil {
+		return nil, 0, err
+	}
+	return p.shadow.Build(signer, call, analysis.GasEstimation)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/decoder"
+	"mempool-sniper/internal/executor"
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/internal/output"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 多个工作线程并发经过过滤、去重和每秒上限：每个通过过滤的机会恰好得到一个结果（放行、去重拒绝或限流丢弃）
+func TestProcessResultsConcurrentWorkers(t *testing.T) {
+	const (
+		workers   = 4
+		keys      = 8  // 互不等价的机会组
+		perKey    = 10 // 每组内经济等价的机会数
+		filtered  = 40 // 被过滤表达式拒绝的机会数
+		rateLimit = 5
+	)
+	chain, err := decoder.LookupChain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Sniper: config.SniperConfig{
+			MinProfit:         big.NewInt(1),
+			ResultWorkers:     workers,
+			OpportunityFilter: "net_profit > 5e15",
+		},
+		Execution: config.ExecutionConfig{
+			DedupWindowMs:        100,
+			DedupBucketWidth:     0.1,
+			OpportunityRateLimit: rateLimit,
+			OpportunityRateMode:  ThrottleDrop,
+		},
+	}
+	recorder := &alertRecorder{}
+	p := &resultProcessor{
+		chain:      chain,
+		cfgManager: config.NewManager(cfg),
+		inflight:   executor.NewInFlightLimiter(),
+		notifiers:  []output.Notifier{recorder},
+	}
+
+	var opportunities []*types.ProfitAnalysis
+	rejected := make(map[common.Hash]bool)
+	for k := int64(0); k < keys; k++ {
+		for j := int64(0); j < perKey; j++ {
+			opportunity := dedupOpportunity(1e15<<k, 1e16+j)
+			opportunity.TxHash = common.BigToHash(big.NewInt(int64(len(opportunities) + 1)))
+			opportunities = append(opportunities, opportunity)
+		}
+	}
+	for i := 0; i < filtered; i++ {
+		opportunity := dedupOpportunity(1e15, 1e15)
+		opportunity.TxHash = common.BigToHash(big.NewInt(int64(len(opportunities) + 1)))
+		rejected[opportunity.TxHash] = true
+		opportunities = append(opportunities, opportunity)
+	}
+
+	start := time.Now()
+	profitChan := make(chan *types.ProfitAnalysis)
+	p.start(context.Background(), profitChan)
+	for _, opportunity := range opportunities {
+		profitChan <- opportunity
+	}
+	close(profitChan)
+	p.wait()
+
+	seen := make(map[common.Hash]bool)
+	for _, hash := range recorder.notified {
+		if seen[hash] {
+			t.Errorf("opportunity %s notified twice", hash.Hex())
+		}
+		if rejected[hash] {
+			t.Errorf("opportunity %s rejected by the filter was notified", hash.Hex())
+		}
+		seen[hash] = true
+	}
+
+	notified := int64(len(recorder.notified))
+	dropped := p.throttle.GetStats()["dropped"].(int64)
+	duplicates := p.dedup.GetStats()["duplicates"].(int64)
+	if total := notified + dropped + duplicates; total != keys*perKey {
+		t.Errorf("notified %d + throttled %d + duplicates %d = %d outcomes, want %d", notified, dropped, duplicates, total, keys*perKey)
+	}
+	// 每组至少有一个窗口放行
+	if admitted := notified + dropped; admitted < keys {
+		t.Errorf("admitted %d opportunities, want at least one per group (%d)", admitted, keys)
+	}
+	// 上限按自然秒计数：运行期间跨过的每一秒最多放行 rateLimit 个
+	if limit := rateLimit * (int64(time.Since(start)/time.Second) + 2); notified == 0 || notified > limit {
+		t.Errorf("notified %d opportunities, want between 1 and %d under a %d/s limit", notified, limit, rateLimit)
+	}
+}
+
+// 漏斗各阶段的累计计数不超过上一阶段：上游阶段按每个分析结果都经过解码和模拟计数，
+// 下游阶段由结果处理器按实际的门槛、去重和执行计数
+func TestFunnelStagesNeverExceedPreviousStage(t *testing.T) {
+	chain, err := decoder.LookupChain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Sniper: config.SniperConfig{MinProfit: big.NewInt(1e15), ResultWorkers: 4},
+		Execution: config.ExecutionConfig{
+			DedupWindowMs:    200,
+			DedupBucketWidth: 0.1,
+		},
+	}
+	funnel := lifecycle.NewFunnel()
+	recorder := &alertRecorder{}
+	p := &resultProcessor{
+		chain:      chain,
+		cfgManager: config.NewManager(cfg),
+		inflight:   executor.NewInFlightLimiter(),
+		notifiers:  []output.Notifier{recorder},
+		funnel:     funnel,
+	}
+
+	var opportunities []*types.ProfitAnalysis
+	add := func(amountIn, netProfit int64) {
+		opportunity := dedupOpportunity(amountIn, netProfit)
+		opportunity.TxHash = common.BigToHash(big.NewInt(int64(len(opportunities) + 1)))
+		opportunities = append(opportunities, opportunity)
+	}
+	for i := int64(0); i < 10; i++ {
+		add(1e15<<i, -1e15) // 亏损
+		add(1e15<<i, 0)     // 不亏不赚
+		add(1e15<<i, 1e14)  // 盈利但低于门槛
+		add(1e15<<i, 2e15)  // 通过门槛，互不等价
+		add(1e18, 3e15+i)   // 通过门槛，经济等价只放行一个
+	}
+	const (
+		profitable = 30
+		above      = 11
+	)
+
+	profitChan := make(chan *types.ProfitAnalysis)
+	p.start(context.Background(), profitChan)
+	for _, opportunity := range opportunities {
+		funnel.Add(lifecycle.FunnelSeen)
+		funnel.Add(lifecycle.FunnelSeen) // 未解码的交易
+		funnel.Add(lifecycle.FunnelDecoded)
+		funnel.Add(lifecycle.FunnelSimulated)
+		profitChan <- opportunity
+	}
+	close(profitChan)
+	p.wait()
+
+	counts := funnel.GetStats()["counts"].(map[string]int64)
+	stages := []string{
+		lifecycle.FunnelSeen, lifecycle.FunnelDecoded, lifecycle.FunnelSimulated,
+		lifecycle.FunnelProfitable, lifecycle.FunnelAboveThreshold, lifecycle.FunnelActed,
+	}
+	for i := 1; i < len(stages); i++ {
+		if counts[stages[i]] > counts[stages[i-1]] {
+			t.Errorf("%s %d > %s %d", stages[i], counts[stages[i]], stages[i-1], counts[stages[i-1]])
+		}
+	}
+	if counts[lifecycle.FunnelProfitable] != profitable || counts[lifecycle.FunnelAboveThreshold] != above {
+		t.Errorf("profitable = %d, above_threshold = %d; want %d, %d",
+			counts[lifecycle.FunnelProfitable], counts[lifecycle.FunnelAboveThreshold], profitable, above)
+	}
+	if acted := counts[lifecycle.FunnelActed]; acted != above || int64(len(recorder.notified)) != acted {
+		t.Errorf("acted = %d, notified = %d; want every opportunity above the threshold acted on (%d)", acted, len(recorder.notified), above)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http/httptest"
+	"time"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/decoder"
+	"mempool-sniper/internal/listener"
+	"mempool-sniper/internal/simulator"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+//go:embed fixtures/selftest.json
+var selftestFixture []byte
+
+// selftestKey 自检用的固定私钥（仅用于签名夹具交易）
+const selftestKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// selftestTimeout 自检最长运行时间
+const selftestTimeout = 15 * time.Second
+
+// fixture 自检夹具
+type fixture struct {
+	ChainID      int64         `json:"chain_id"`
+	Head         uint64        `json:"head"`
+	BaseFee      string        `json:"base_fee"` // 最新区块的基础费用
+	GasUsed      uint64        `json:"gas_used"` // eth_estimateGas 的返回值
+	MinProfit    string        `json:"min_profit"`
+	Reserves     [2]string     `json:"reserves"` // 所有交易对的 getReserves 返回值 (reserve0, reserve1)
+	Transactions []fixtureTx   `json:"transactions"`
+	Expect       fixtureExpect `json:"expect"`
+}
+
+type fixtureTx struct {
+	Name     string         `json:"name"`
+	To       common.Address `json:"to"`
+	Value    string         `json:"value"`
+	Gas      uint64         `json:"gas"`
+	GasPrice string         `json:"gas_price"`
+	Nonce    uint64         `json:"nonce"`
+	Data     hexutil.Bytes  `json:"data"`
+}
+
+type fixtureExpect struct {
+	Decoded       int `json:"decoded"`
+	Opportunities int `json:"opportunities"`
+}
+
+// selftestEth 模拟节点的 eth 命名空间（只实现模拟器用到的方法）
+type selftestEth struct {
+	chainID  int64
+	head     uint64
+	baseFee  *big.Int
+	gasUsed  uint64
+	reserves []byte // getReserves 返回数据
+}
+
+// 模拟节点支持的合约调用
+var (
+	selftestGetReserves = "0x0902f1ac" // getReserves()
+	selftestDecimals    = "0x313ce567" // decimals()
+)
+
+// BlockNumber eth_blockNumber
+func (e *selftestEth) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(e.head)
+}
+
+// ChainId eth_chainId
+func (e *selftestEth) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(e.chainID))
+}
+
+// GetBlockByNumber eth_getBlockByNumber：任意区块号都返回最新区块（空区块，带基础费用）
+func (e *selftestEth) GetBlockByNumber(number string, full bool) (map[string]interface{}, error) {
+	header := &ethtypes.Header{
+		Number:      new(big.Int).SetUint64(e.head),
+		Time:        uint64(time.Now().Unix()),
+		GasLimit:    30000000,
+		Difficulty:  new(big.Int),
+		BaseFee:     e.baseFee,
+		UncleHash:   ethtypes.EmptyUncleHash,
+		TxHash:      ethtypes.EmptyTxsHash,
+		ReceiptHash: ethtypes.EmptyReceiptsHash,
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	var block map[string]interface{}
+	if err := json.Unmarshal(encoded, &block); err != nil {
+		return nil, err
+	}
+	block["transactions"] = []interface{}{}
+	block["uncles"] = []interface{}{}
+	return block, nil
+}
+
+// EstimateGas eth_estimateGas：所有调用返回夹具的Gas用量
+func (e *selftestEth) EstimateGas(args map[string]interface{}, block *string) hexutil.Uint64 {
+	return hexutil.Uint64(e.gasUsed)
+}
+
+// Call eth_call：所有交易对返回夹具储备，所有代币精度为18
+func (e *selftestEth) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	data, _ := args["input"].(string)
+	if data == "" {
+		data, _ = args["data"].(string)
+	}
+	switch {
+	case len(data) >= 10 && data[:10] == selftestGetReserves:
+		return e.reserves, nil
+	case len(data) >= 10 && data[:10] == selftestDecimals:
+		return common.LeftPadBytes([]byte{18}, 32), nil
+	}
+	return nil, fmt.Errorf("execution reverted")
+}
+
+// runSelfTest 用内置夹具和模拟节点跑通 解码 → 模拟 → 结果判定 全流程，返回进程退出码
+func runSelfTest() int {
+	log.Println("🧪 开始自检...")
+
+	var fx fixture
+	if err := json.Unmarshal(selftestFixture, &fx); err != nil {
+		log.Printf("❌ 自检夹具无效: %v", err)
+		return 1
+	}
+
+	nodeURL, stopNode, err := fx.startNode()
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+	defer stopNode()
+
+	txs, err := fx.signedTransactions()
+	if err != nil {
+		log.Printf("❌ 签名夹具交易失败: %v", err)
+		return 1
+	}
+
+	minProfit, ok := new(big.Int).SetString(fx.MinProfit, 10)
+	if !ok {
+		log.Printf("❌ 夹具 min_profit 无效: %s", fx.MinProfit)
+		return 1
+	}
+	sniperCfg := &config.SniperConfig{
+		MinProfit:           minProfit,
+		MaxGasPrice:         big.NewInt(500000000000),
+		MaxGasLimit:         3000000,
+		RPCPoolSize:         1,
+		TargetBlockOffset:   1,
+		GasSafetyMultiplier: 1.0,
+		SwapDirections:      []string{"buy", "sell", "swap"},
+		SuccessRateFloor:    0,
+		SuccessRateCeiling:  1,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), selftestTimeout)
+	defer cancel()
+
+	txChan := make(chan *types.Transaction, len(txs))
+	decodedTxChan := make(chan *types.DecodedTransaction, len(txs))
+	profitChan := make(chan *types.ProfitAnalysis, len(txs))
+
+	chain, err := decoder.LookupChain(fx.ChainID)
+	if err != nil {
+		log.Printf("❌ 夹具链配置无效: %v", err)
+		return 1
+	}
+
+	dec := decoder.NewDecoder(chain)
+	sim := simulator.NewSimulator(nodeURL, chain.Info)
+	sim.SetConfig(sniperCfg, 1)
+	sim.SetSupersededCheck(dec.IsSuperseded)
+
+	go dec.StartWorkerPool(ctx, txChan, decodedTxChan, 2)
+	go sim.StartWorkerPool(ctx, decodedTxChan, profitChan, 2)
+
+	for _, tx := range txs {
+		txChan <- listener.WrapTransaction(tx)
+	}
+
+	// 每笔解码成功的交易都应产生一个分析结果
+	opportunities := 0
+	for received := 0; received < fx.Expect.Decoded; received++ {
+		select {
+		case analysis := <-profitChan:
+			if passesCostGate(analysis, nil, sniperCfg.MinProfit) {
+				opportunities++
+			}
+		case <-ctx.Done():
+			log.Printf("❌ 自检超时: 收到 %d/%d 个分析结果", received, fx.Expect.Decoded)
+			return 1
+		}
+	}
+
+	// 确认没有多余的结果
+	select {
+	case analysis := <-profitChan:
+		log.Printf("❌ 收到预期之外的分析结果: %s", analysis.TxHash.Hex())
+		return 1
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	decoded := dec.GetStats()["decoded"].(int64)
+	if int(decoded) != fx.Expect.Decoded {
+		log.Printf("❌ 解码数量不符: 期望 %d，实际 %d", fx.Expect.Decoded, decoded)
+		return 1
+	}
+	if opportunities != fx.Expect.Opportunities {
+		log.Printf("❌ 盈利机会数量不符: 期望 %d，实际 %d", fx.Expect.Opportunities, opportunities)
+		return 1
+	}
+
+	// 模拟节点应覆盖模拟器用到的全部方法：调用失败会悄悄回退到固定估算
+	stats := sim.GetStats()
+	if fails := stats["gas_estimate_fails"].(int64); fails != 0 {
+		log.Printf("❌ eth_estimateGas 失败 %d 次", fails)
+		return 1
+	}
+
+	log.Printf("✅ 自检通过: %d 笔交易，解码 %d 笔，盈利机会 %d 个", len(txs), decoded, opportunities)
+	return 0
+}
+
+// startNode 按夹具启动模拟节点，返回节点URL和关闭函数
+func (fx *fixture) startNode() (string, func(), error) {
+	reserves, err := fx.reserveData()
+	if err != nil {
+		return "", nil, fmt.Errorf("夹具 reserves 无效: %v", err)
+	}
+	baseFee, ok := new(big.Int).SetString(fx.BaseFee, 10)
+	if !ok {
+		return "", nil, fmt.Errorf("夹具 base_fee 无效: %s", fx.BaseFee)
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &selftestEth{chainID: fx.ChainID, head: fx.Head, baseFee: baseFee, gasUsed: fx.GasUsed, reserves: reserves}); err != nil {
+		return "", nil, fmt.Errorf("启动模拟节点失败: %v", err)
+	}
+	node := httptest.NewServer(server)
+	return node.URL, func() {
+		node.Close()
+		server.Stop()
+	}, nil
+}
+
+// reserveData 编码 getReserves 返回数据（reserve0, reserve1, blockTimestampLast）
+func (fx *fixture) reserveData() ([]byte, error) {
+	data := make([]byte, 0, 96)
+	for _, item := range fx.Reserves {
+		reserve, ok := new(big.Int).SetString(item, 10)
+		if !ok {
+			return nil, fmt.Errorf("储备量无效: %q", item)
+		}
+		data = append(data, common.LeftPadBytes(reserve.Bytes(), 32)...)
+	}
+	return append(data, make([]byte, 32)...), nil
+}
+
+// signedTransactions 用固定私钥签名夹具交易
+func (fx *fixture) signedTransactions() ([]*ethtypes.Transaction, error) {
+	key, err := crypto.HexToECDSA(selftestKey)
+	if err != nil {
+		return nil, err
+	}
+	signer := ethtypes.NewLondonSigner(big.NewInt(fx.ChainID))
+
+	txs := make([]*ethtypes.Transaction, 0, len(fx.Transactions))
+	for _, item := range fx.Transactions {
+		value, ok := new(big.Int).SetString(item.Value, 10)
+		if !ok {
+			return nil, fmt.Errorf("%s: value 无效", item.Name)
+		}
+		gasPrice, ok := new(big.Int).SetString(item.GasPrice, 10)
+		if !ok {
+			return nil, fmt.Errorf("%s: gas_price 无效", item.Name)
+		}
+
+		to := item.To
+		tx, err := ethtypes.SignNewTx(key, signer, &ethtypes.LegacyTx{
+			Nonce:    item.Nonce,
+			To:       &to,
+			Value:    value,
+			Gas:      item.Gas,
+			GasPrice: gasPrice,
+			Data:     item.Data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", item.Name, err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
@@ -0,0 +1,9 @@
+package main
+
+import "testing"
+
+func TestSelfTest(t *testing.T) {
+	if code := runSelfTest(); code != 0 {
+		t.Fatalf("runSelfTest() = %d, want 0 (see log output above)", code)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// pipelineStage 流水线的一个阶段：停止后等待其全部goroutine退出，再关闭它的输出通道
+type pipelineStage struct {
+	name  string
+	stop  func() // 停止接收新的输入（为nil表示靠输入通道关闭自然退出）
+	wait  func() // 等待该阶段的goroutine全部退出
+	close func() // 关闭该阶段的输出通道，通知下游排空后退出（为nil表示没有下游）
+}
+
+// shutdownPipeline 按上游到下游的顺序关闭流水线：每个阶段确认退出后才关闭其输出通道，
+// 保证没有goroutine向已关闭的通道发送，下游排空已缓冲的数据后再退出。
+// 某个阶段超时未退出时不关闭其输出通道，返回 false 由调用方强制取消
+func shutdownPipeline(stages []pipelineStage, timeout time.Duration) bool {
+	for _, stage := range stages {
+		if stage.stop != nil {
+			stage.stop()
+		}
+
+		done := make(chan struct{})
+		go func() {
+			stage.wait()
+			close(done)
+		}()
+
+		start := time.Now()
+		select {
+		case <-done:
+			log.Printf("✅ %s已停止 (%v)", stage.name, time.Since(start).Round(time.Millisecond))
+		case <-time.After(timeout):
+			log.Printf("⚠️ 等待%s停止超时 (%v)，强制关闭", stage.name, timeout)
+			return false
+		}
+
+		if stage.close != nil {
+			stage.close()
+		}
+	}
+	return true
+}
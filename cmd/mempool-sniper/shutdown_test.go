@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/decoder"
+	"mempool-sniper/internal/listener"
+	"mempool-sniper/pkg/types"
+)
+
+func TestShutdownPipelineStopsStagesInOrder(t *testing.T) {
+	var events []string
+	stage := func(name string, stop bool) pipelineStage {
+		s := pipelineStage{
+			name:  name,
+			wait:  func() { events = append(events, "wait "+name) },
+			close: func() { events = append(events, "close "+name) },
+		}
+		if stop {
+			s.stop = func() { events = append(events, "stop "+name) }
+		}
+		return s
+	}
+
+	clean := shutdownPipeline([]pipelineStage{
+		stage("listener", true),
+		stage("decoder", false),
+		stage("simulator", false),
+		{name: "results", wait: func() { events = append(events, "wait results") }},
+	}, time.Second)
+
+	want := []string{
+		"stop listener", "wait listener", "close listener",
+		"wait decoder", "close decoder",
+		"wait simulator", "close simulator",
+		"wait results",
+	}
+	if !clean || !reflect.DeepEqual(events, want) {
+		t.Errorf("shutdownPipeline() = %v, events %v; want %v", clean, events, want)
+	}
+}
+
+func TestShutdownPipelineTimeoutKeepsOutputOpen(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	closed := make(map[string]bool)
+	clean := shutdownPipeline([]pipelineStage{
+		{name: "decoder", wait: func() { <-stuck }, close: func() { closed["decoder"] = true }},
+		{name: "simulator", wait: func() {}, close: func() { closed["simulator"] = true }},
+	}, 50*time.Millisecond)
+
+	// 超时的阶段可能仍在发送：不关闭其输出通道，也不继续关闭下游
+	if clean || len(closed) != 0 {
+		t.Errorf("shutdownPipeline() = %v, closed %v; want false with no channel closed", clean, closed)
+	}
+}
+
+// 提交N笔交易后按序关闭：上下文不取消，各阶段只靠输入通道关闭退出，已提交的交易全部处理、没有丢弃
+func TestShutdownDrainsSubmittedTransactions(t *testing.T) {
+	var fx fixture
+	if err := json.Unmarshal(selftestFixture, &fx); err != nil {
+		t.Fatal(err)
+	}
+	txs, err := fx.signedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := decoder.LookupChain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const rounds = 50
+	ctx := context.Background()
+	txChan := make(chan *types.Transaction)
+	decodedTxChan := make(chan *types.DecodedTransaction, rounds*len(txs))
+
+	// 监听器：依次提交交易
+	var producer sync.WaitGroup
+	producer.Add(1)
+	go func() {
+		defer producer.Done()
+		for i := 0; i < rounds; i++ {
+			for _, tx := range txs {
+				txChan <- listener.WrapTransaction(tx)
+			}
+		}
+	}()
+
+	dec := decoder.NewDecoder(chain)
+	dec.StartWorkerPool(ctx, txChan, decodedTxChan, 4)
+
+	// 下游：排空解码结果
+	received := 0
+	var consumer sync.WaitGroup
+	consumer.Add(1)
+	go func() {
+		defer consumer.Done()
+		for range decodedTxChan {
+			received++
+		}
+	}()
+
+	clean := shutdownPipeline([]pipelineStage{
+		{name: "listener", wait: producer.Wait, close: func() { close(txChan) }},
+		{name: "decoder", wait: dec.Wait, close: func() { close(decodedTxChan) }},
+		{name: "consumer", wait: consumer.Wait},
+	}, 5*time.Second)
+	if !clean {
+		t.Fatal("shutdownPipeline() timed out")
+	}
+
+	stats := dec.GetStats()
+	if stats["processed"] != int64(rounds*len(txs)) {
+		t.Errorf("decoder processed %v transactions, want %d", stats["processed"], rounds*len(txs))
+	}
+	if decoded := stats["decoded"].(int64); decoded == 0 || int64(received) != decoded {
+		t.Errorf("received %d of %d decoded transactions", received, decoded)
+	}
+}
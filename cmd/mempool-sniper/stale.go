@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"mempool-sniper/internal/simulator"
+	"mempool-sniper/pkg/types"
+)
+
+// staleAnalyses 热重载后旧配置下得出的分析结果统计
+type staleAnalyses struct {
+	mu            sync.Mutex
+	resimulated   int64 // 按当前配置重新模拟的分析结果数
+	dropped       int64 // 重新模拟后不再是机会而丢弃的分析结果数
+	regateAborted int64 // 执行等待期间配置重载、按新配置复核未通过的机会数
+}
+
+// refresh 分析结果由旧版本配置得出时按当前配置重新模拟（没有原始交易或模拟器时原样返回），
+// 重新模拟后不再是机会时返回 nil
+func (s *staleAnalyses) refresh(ctx context.Context, sim *simulator.Simulator, analysis *types.ProfitAnalysis, version uint64) *types.ProfitAnalysis {
+	if analysis.ConfigVersion == 0 || analysis.ConfigVersion == version || sim == nil || analysis.Source == nil {
+		return analysis
+	}
+
+	// 与执行前复核一样不计入模拟统计、不重复记录生命周期事件和竞争交换
+	latest := sim.Resimulate(ctx, analysis)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resimulated++
+	if latest == nil {
+		s.dropped++
+		log.Printf("♻️ 交易 %s 的分析基于配置版本 %d，按版本 %d 重新模拟后不再是机会", analysis.TxHash.Hex(), analysis.ConfigVersion, version)
+		return nil
+	}
+	return latest
+}
+
+// abortRegate 记录一次执行等待期间配置重载后复核未通过
+func (s *staleAnalyses) abortRegate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.regateAborted++
+}
+
+// GetStats 获取统计信息
+func (s *staleAnalyses) GetStats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"resimulated":    s.resimulated,
+		"dropped":        s.dropped,
+		"regate_aborted": s.regateAborted,
+	}
+}
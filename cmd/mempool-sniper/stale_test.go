@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/decoder"
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/internal/listener"
+	"mempool-sniper/internal/simulator"
+)
+
+// countingSink 按阶段统计生命周期事件
+type countingSink struct {
+	mu     sync.Mutex
+	stages map[string]int
+}
+
+func (c *countingSink) Write(event lifecycle.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stages[event.Stage]++
+	return nil
+}
+
+func TestStaleRefreshLeavesStatsUnchanged(t *testing.T) {
+	var fx fixture
+	if err := json.Unmarshal(selftestFixture, &fx); err != nil {
+		t.Fatal(err)
+	}
+	nodeURL, stopNode, err := fx.startNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stopNode()
+	txs, err := fx.signedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.SniperConfig{
+		MinProfit:           big.NewInt(1),
+		MaxGasPrice:         big.NewInt(500000000000),
+		MaxGasLimit:         3000000,
+		RPCPoolSize:         1,
+		TargetBlockOffset:   1,
+		GasSafetyMultiplier: 1.0,
+		SwapDirections:      []string{"buy", "sell", "swap"},
+		SuccessRateCeiling:  1,
+		CompetitionWindowMs: 60000,
+	}
+	chain, err := decoder.LookupChain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &countingSink{stages: make(map[string]int)}
+	sim := simulator.NewSimulator(nodeURL, chain.Info)
+	sim.SetConfig(cfg, 1)
+	sim.SetLifecycleRecorder(lifecycle.NewRecorder(sink))
+
+	decoded := decoder.NewDecoder(chain).DecodeTransaction(listener.WrapTransaction(txs[0]))
+	if decoded == nil {
+		t.Fatal("DecodeTransaction() = nil for the profitable fixture swap")
+	}
+	analysis := sim.SimulateTransaction(context.Background(), decoded)
+	if analysis == nil {
+		t.Fatal("SimulateTransaction() = nil for the profitable fixture swap")
+	}
+	before := sim.GetStats()
+	simulatedEvents := sink.stages[lifecycle.StageSimulated]
+
+	// 热重载后按新版本配置刷新
+	sim.SetConfig(cfg, 2)
+	var stale staleAnalyses
+	refreshed := stale.refresh(context.Background(), sim, analysis, 2)
+	if refreshed == nil {
+		t.Fatal("refresh() dropped a still-profitable opportunity")
+	}
+	if refreshed.ConfigVersion != 2 {
+		t.Errorf("refreshed ConfigVersion = %d, want 2", refreshed.ConfigVersion)
+	}
+
+	after := sim.GetStats()
+	for _, key := range []string{"simulated", "profitable", "failed"} {
+		if after[key] != before[key] {
+			t.Errorf("%s = %v after a refresh, want %v", key, after[key], before[key])
+		}
+	}
+	if sink.stages[lifecycle.StageSimulated] != simulatedEvents {
+		t.Errorf("%d simulated events after a refresh, want %d", sink.stages[lifecycle.StageSimulated], simulatedEvents)
+	}
+	if refreshed.NetProfitPessimistic.Cmp(analysis.NetProfitPessimistic) != 0 {
+		t.Errorf("NetProfitPessimistic = %s after a refresh, want %s (the victim is not its own competitor)",
+			refreshed.NetProfitPessimistic, analysis.NetProfitPessimistic)
+	}
+	if stale.resimulated != 1 {
+		t.Errorf("resimulated = %d, want 1", stale.resimulated)
+	}
+}
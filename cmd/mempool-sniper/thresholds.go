@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sync"
+
+	"mempool-sniper/internal/decoder"
+	"mempool-sniper/internal/simulator"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// profitThresholds 按盈利代币的最小盈利（人类可读数量按代币精度换算为最小单位并缓存，
+// 比较前按交易对现价折算为基础资产，与以基础资产计价的净盈利比较）
+type profitThresholds struct {
+	mu       sync.Mutex
+	resolved map[string]*big.Int         // "代币=数量" -> 代币最小单位
+	values   map[thresholdValue]*big.Int // 折算为基础资产的阈值（同一目标区块内交易对现价视为不变）
+	warned   map[string]bool             // 已提示过无法解析的配置项
+}
+
+// thresholdValue 折算结果的缓存键
+type thresholdValue struct {
+	config string         // "代币=数量"
+	router common.Address // 折算使用的交易对所在路由
+	block  uint64         // 机会的目标区块
+}
+
+// minProfit 获取机会适用的最小盈利（基础资产最小单位）：盈利代币配置了阈值时使用该阈值，否则返回 fallback
+func (t *profitThresholds) minProfit(ctx context.Context, sim *simulator.Simulator, byToken map[string]string, analysis *types.ProfitAnalysis, fallback *big.Int) *big.Int {
+	if len(byToken) == 0 || sim == nil {
+		return fallback
+	}
+
+	for key, amount := range byToken {
+		token, ok := thresholdToken(analysis.Source.Transaction.ChainID, key)
+		if !ok {
+			t.warnOnce(key, "未知代币符号，请改用代币地址")
+			continue
+		}
+		if token != analysis.ProfitToken {
+			continue
+		}
+
+		cacheKey := key + "=" + amount
+		t.mu.Lock()
+		threshold, exists := t.resolved[cacheKey]
+		t.mu.Unlock()
+		if exists {
+			return t.inBaseAsset(ctx, sim, key, analysis, token, threshold, fallback)
+		}
+
+		decimals, err := sim.TokenDecimals(ctx, token)
+		if err != nil {
+			// 精度暂时无法获取：本次回退到全局阈值，下次重试
+			log.Printf("⚠️ 查询 %s 精度失败，使用 MIN_PROFIT: %v", key, err)
+			return fallback
+		}
+		threshold, err = types.ToBaseUnits(amount, decimals)
+		if err != nil {
+			t.warnOnce(cacheKey, err.Error())
+			return fallback
+		}
+
+		t.mu.Lock()
+		if t.resolved == nil {
+			t.resolved = make(map[string]*big.Int)
+		}
+		t.resolved[cacheKey] = threshold
+		t.mu.Unlock()
+
+		log.Printf("🎯 %s 最小盈利: %s (%d 位小数) = %s", key, amount, decimals, threshold)
+		return t.inBaseAsset(ctx, sim, key, analysis, token, threshold, fallback)
+	}
+	return fallback
+}
+
+// inBaseAsset 代币数量的阈值按交易对现价折算为基础资产（按目标区块缓存），无法折算时本次回退到全局阈值
+func (t *profitThresholds) inBaseAsset(ctx context.Context, sim *simulator.Simulator, key string, analysis *types.ProfitAnalysis, token common.Address, threshold, fallback *big.Int) *big.Int {
+	cacheKey := thresholdValue{config: key + "=" + threshold.String(), router: analysis.Source.TargetContract, block: analysis.TargetBlock}
+	t.mu.Lock()
+	value, exists := t.values[cacheKey]
+	t.mu.Unlock()
+	if exists {
+		return value
+	}
+
+	value, err := sim.BaseAssetValue(ctx, analysis.Source, token, threshold)
+	if err != nil {
+		log.Printf("⚠️ 无法将 %s 最小盈利折算为 %s，使用 MIN_PROFIT: %v", key, analysis.BaseAsset, err)
+		return fallback
+	}
+	// 目标区块未知时每次重新折算
+	if analysis.TargetBlock == 0 {
+		return value
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.values == nil {
+		t.values = make(map[thresholdValue]*big.Int)
+	}
+	for cached := range t.values {
+		if cached.block < analysis.TargetBlock {
+			delete(t.values, cached)
+		}
+	}
+	t.values[cacheKey] = value
+	return value
+}
+
+// thresholdToken 解析配置键：代币地址或常见代币符号
+func thresholdToken(chainID *big.Int, key string) (common.Address, bool) {
+	if common.IsHexAddress(key) {
+		return common.HexToAddress(key), true
+	}
+	return decoder.TokenBySymbol(chainID, key)
+}
+
+// warnOnce 同一配置项只提示一次
+func (t *profitThresholds) warnOnce(key, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.warned[key] {
+		return
+	}
+	if t.warned == nil {
+		t.warned = make(map[string]bool)
+	}
+	t.warned[key] = true
+	log.Printf("⚠️ MIN_PROFIT_%s 无效: %s", key, reason)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/pnl"
+	"mempool-sniper/internal/simulator"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	dai  = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+)
+
+// pricingNode 按合约地址返回代币精度和Uniswap V2交易对储备的假节点（WETH按2000 USDC/DAI计价）
+type pricingNode struct {
+	mu    sync.Mutex
+	calls map[string]int // "to/selector" -> 调用次数
+}
+
+func newPricingNode() *pricingNode {
+	return &pricingNode{calls: make(map[string]int)}
+}
+
+func (n *pricingNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	defer func() {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reply)
+	}()
+
+	var call struct {
+		To    common.Address `json:"to"`
+		Input hexutil.Bytes  `json:"input"`
+		Data  hexutil.Bytes  `json:"data"`
+	}
+	if req.Method != "eth_call" || len(req.Params) == 0 || json.Unmarshal(req.Params[0], &call) != nil {
+		reply["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		return
+	}
+	input := call.Input
+	if len(input) == 0 {
+		input = call.Data
+	}
+	selector := hexutil.Encode(input[:4])
+	n.mu.Lock()
+	n.calls[strings.ToLower(call.To.Hex())+"/"+selector]++
+	n.mu.Unlock()
+
+	word := func(v *big.Int) []byte { return common.LeftPadBytes(v.Bytes(), 32) }
+	thousandETH := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	var result []byte
+	switch {
+	case call.To == usdc && selector == "0x313ce567":
+		result = word(big.NewInt(6))
+	case call.To == dai && selector == "0x313ce567":
+		result = word(big.NewInt(18))
+	case call.To == common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc") && selector == "0x0902f1ac":
+		// USDC/WETH：token0 = USDC
+		result = append(append(word(big.NewInt(2e12)), word(thousandETH)...), word(big.NewInt(1))...)
+	case call.To == common.HexToAddress("0xA478c2975Ab1Ea89e8196811F51A7B7Ade33eB11") && selector == "0x0902f1ac":
+		// DAI/WETH：token0 = DAI
+		twoMillionDAI := new(big.Int).Mul(big.NewInt(2e6), big.NewInt(1e18))
+		result = append(append(word(twoMillionDAI), word(thousandETH)...), word(big.NewInt(1))...)
+	}
+	reply["result"] = hexutil.Bytes(result)
+}
+
+func (n *pricingNode) count(to common.Address, selector string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[strings.ToLower(to.Hex())+"/"+selector]
+}
+
+// thresholdOpportunity 在主网 Uniswap V2 上以 token 计盈利的机会
+func thresholdOpportunity(token common.Address, targetBlock uint64) *types.ProfitAnalysis {
+	router := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	return &types.ProfitAnalysis{
+		TargetContract: router,
+		TargetBlock:    targetBlock,
+		ProfitToken:    token,
+		BaseAsset:      "ETH",
+		Source: &types.DecodedTransaction{
+			Transaction:    &types.Transaction{ChainID: big.NewInt(1)},
+			TargetContract: router,
+		},
+	}
+}
+
+func TestMinProfitConvertsHumanThresholdsToBaseAsset(t *testing.T) {
+	node := newPricingNode()
+	server := httptest.NewServer(node)
+	defer server.Close()
+	info, _ := types.LookupChain(1)
+	sim := simulator.NewSimulator(server.URL, &info)
+
+	fallback := big.NewInt(1e15)
+	// 2000 USDC/DAI = 1 ETH：50 个代币 = 0.025 ETH，与代币精度无关
+	fifty := big.NewInt(2.5e16)
+	tests := []struct {
+		name    string
+		byToken map[string]string
+		token   common.Address
+		want    *big.Int
+	}{
+		{name: "USDC by symbol, 6 decimals", byToken: map[string]string{"USDC": "50"}, token: usdc, want: fifty},
+		{name: "DAI by address, 18 decimals", byToken: map[string]string{dai.Hex(): "50"}, token: dai, want: fifty},
+		{name: "fractional amount", byToken: map[string]string{"USDC": "0.5"}, token: usdc, want: big.NewInt(2.5e14)},
+		{name: "other profit token", byToken: map[string]string{"USDC": "50"}, token: dai, want: fallback},
+		{name: "more decimals than the token", byToken: map[string]string{"USDC": "0.0000001"}, token: usdc, want: fallback},
+		{name: "base asset", byToken: map[string]string{"ETH": "0.1"}, token: types.NativeToken, want: big.NewInt(1e17)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var thresholds profitThresholds
+			got := thresholds.minProfit(context.Background(), sim, tt.byToken, thresholdOpportunity(tt.token, 100), fallback)
+			if got.Cmp(tt.want) != 0 {
+				t.Errorf("minProfit() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinProfitCachesConversionPerBlock(t *testing.T) {
+	node := newPricingNode()
+	server := httptest.NewServer(node)
+	defer server.Close()
+	info, _ := types.LookupChain(1)
+	sim := simulator.NewSimulator(server.URL, &info)
+
+	var thresholds profitThresholds
+	byToken := map[string]string{"USDC": "50"}
+	pair := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+	for _, block := range []uint64{100, 100, 100, 101} {
+		thresholds.minProfit(context.Background(), sim, byToken, thresholdOpportunity(usdc, block), big.NewInt(1))
+	}
+
+	if got := node.count(usdc, "0x313ce567"); got != 1 {
+		t.Errorf("decimals() called %d times, want 1", got)
+	}
+	if got := node.count(pair, "0x0902f1ac"); got != 2 {
+		t.Errorf("getReserves() called %d times, want once per target block (2)", got)
+	}
+}
+
+// 以USDC计盈利的机会：盈利按同一兑换比例换算为基础资产后，按USDC配置的阈值、
+// 以基础资产配置的熔断上限和盈亏统计比较的是同一单位
+func TestThresholdsKillSwitchAndPnLShareBaseAssetUnits(t *testing.T) {
+	node := newPricingNode()
+	server := httptest.NewServer(node)
+	defer server.Close()
+	info, _ := types.LookupChain(1)
+	sim := simulator.NewSimulator(server.URL, &info)
+	sim.UpdateHead(99)
+
+	tracker, err := pnl.NewTracker("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &resultProcessor{simulator: sim, pnl: tracker}
+	execCfg := &config.ExecutionConfig{SanityMaxProfit: big.NewInt(1e17)} // 0.1 ETH = 200 USDC
+	byToken := map[string]string{"USDC": "50"}
+
+	tests := []struct {
+		name     string
+		usdc     int64 // 策略按输入代币计算的净盈利（USDC）
+		accepted bool
+		tripped  bool
+	}{
+		{name: "below the USDC threshold", usdc: 40, accepted: false},
+		{name: "above the USDC threshold", usdc: 60, accepted: true},
+		{name: "above the base-asset sanity cap", usdc: 300, accepted: true, tripped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := thresholdOpportunity(usdc, 100)
+			netProfit, err := sim.BaseAssetValue(context.Background(), analysis.Source, usdc, big.NewInt(tt.usdc*1e6))
+			if err != nil {
+				t.Fatal(err)
+			}
+			analysis.NetProfit = netProfit
+
+			minProfit := p.thresholds.minProfit(context.Background(), sim, byToken, analysis, big.NewInt(1))
+			if got := passesCostGate(analysis, execCfg, minProfit); got != tt.accepted {
+				t.Errorf("passesCostGate() = %v with net profit %s and min profit %s, want %v", got, netProfit, minProfit, tt.accepted)
+			}
+			var sanity sanitySwitch
+			if allowed, _ := sanity.allow(analysis, execCfg.SanityMaxProfit); !allowed != tt.tripped {
+				t.Errorf("sanity switch tripped = %v for %s, want %v", !allowed, analysis.FormatProfit(netProfit), tt.tripped)
+			}
+
+			before, _ := new(big.Int).SetString(tracker.GetStats()["simulated_pnl"].(string), 10)
+			p.recordPaperTrade(context.Background(), analysis)
+			after, _ := new(big.Int).SetString(tracker.GetStats()["simulated_pnl"].(string), 10)
+			if recorded := after.Sub(after, before); recorded.Cmp(netProfit) != 0 {
+				t.Errorf("pnl recorded %s, want the base-asset net profit %s", recorded, netProfit)
+			}
+		})
+	}
+
+	// 同一区块内的换算复用一次储备查询
+	if got := node.count(common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"), "0x0902f1ac"); got != 1 {
+		t.Errorf("getReserves() called %d times within one block, want 1", got)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 超出每秒上限时的处理方式
+const (
+	ThrottleDrop  = "drop"  // 直接丢弃（计数）
+	ThrottleQueue = "queue" // 等待下一秒的配额
+)
+
+// opportunityThrottle 可执行机会的每秒数量上限（所有结果处理工作线程共享）
+type opportunityThrottle struct {
+	mu      sync.Mutex
+	window  int64 // 当前计数的秒（Unix时间）
+	count   int
+	dropped int64
+	queued  int64
+}
+
+// reserve 尝试占用当前秒的配额，成功返回0，否则返回距下一秒的等待时间
+func (t *opportunityThrottle) reserve(limit int, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if second := now.Unix(); second != t.window {
+		t.window = second
+		t.count = 0
+	}
+	if t.count < limit {
+		t.count++
+		return 0
+	}
+	return time.Unix(t.window+1, 0).Sub(now)
+}
+
+// acquire 按上限放行机会：drop 模式超限直接拒绝，queue 模式等待下一秒配额（limit <= 0 表示不限制）
+func (t *opportunityThrottle) acquire(ctx context.Context, limit int, mode string) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	waited := false
+	for {
+		wait := t.reserve(limit, time.Now())
+		if wait <= 0 {
+			return true
+		}
+
+		t.mu.Lock()
+		if mode != ThrottleQueue {
+			t.dropped++
+			t.mu.Unlock()
+			return false
+		}
+		if !waited {
+			t.queued++
+			waited = true
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(wait):
+		}
+	}
+}
+
+// GetStats 获取统计信息
+func (t *opportunityThrottle) GetStats() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return map[string]interface{}{
+		"dropped": t.dropped,
+		"queued":  t.queued,
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/training"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestUnprofitableAnalysesReachTrainingSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "training.jsonl")
+	sink, err := training.NewSink(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Sniper: config.SniperConfig{MinProfit: big.NewInt(1e15), ResultWorkers: 1}}
+	p := &resultProcessor{cfgManager: config.NewManager(cfg), training: sink}
+
+	profitChan := make(chan *types.ProfitAnalysis, 2)
+	profitChan <- &types.ProfitAnalysis{TxHash: common.HexToHash("0x01"), NetProfit: big.NewInt(-2e15)}
+	profitChan <- &types.ProfitAnalysis{TxHash: common.HexToHash("0x02"), NetProfit: big.NewInt(5e14)}
+	close(profitChan)
+	p.start(context.Background(), profitChan)
+	p.wait()
+	sink.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Fatalf("training file has %d samples, want both rejected analyses:\n%s", lines, data)
+	}
+	if bytes.Contains(data, []byte(`"accepted":true`)) {
+		t.Errorf("rejected analysis recorded as accepted:\n%s", data)
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -13,57 +12,69 @@ import (
 	"mempool-sniper/internal/decoder"
 	"mempool-sniper/internal/listener"
 	"mempool-sniper/internal/simulator"
+	"mempool-sniper/pkg/types"
 )
 
+// 最小示例：监听器 -> 解码器 -> 模拟器 -> 结果处理，各阶段之间用通道连接
 func main() {
 	// 加载配置
-	cfg, err := config.LoadConfig()
+	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// 创建上下文
+	// 创建上下文，收到退出信号时取消，所有阶段随之停止
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// 设置信号处理
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	// 创建监听器
-	listener := listener.NewListener(cfg)
-	decoder := decoder.NewDecoder(cfg)
-	simulator := simulator.NewSimulator(cfg)
-
-	// 启动组件
-	if err := listener.Start(ctx); err != nil {
-		log.Fatalf("Failed to start listener: %v", err)
+	// 创建组件
+	lst, err := listener.NewListener(cfg.Ethereum.WSSURL)
+	if err != nil {
+		log.Fatalf("Failed to create listener: %v", err)
+	}
+	chain, err := decoder.LookupChain(cfg.Ethereum.ChainID)
+	if err != nil {
+		log.Fatalf("Failed to load chain: %v", err)
 	}
+	dec := decoder.NewDecoder(chain)
+	sim := simulator.NewSimulator(cfg.Ethereum.RPCURL, chain.Info)
+	sim.SetConfig(&cfg.Sniper, cfg.Version)
 
-	decoder.StartWorkerPool()
-	simulator.StartWorkerPool()
+	// 管道通道
+	txChan := make(chan *types.Transaction, 100)
+	decodedTxChan := make(chan *types.DecodedTransaction, 100)
+	profitChan := make(chan *types.ProfitAnalysis, 100)
 
-	// 设置交易处理管道
-	transactionCh := listener.GetTransactionChannel()
-	decodedCh := decoder.GetDecodedChannel()
+	// 启动各阶段
+	go func() {
+		if err := lst.Start(ctx, txChan); err != nil {
+			log.Printf("Listener stopped: %v", err)
+			cancel()
+		}
+	}()
+	go dec.StartWorkerPool(ctx, txChan, decodedTxChan, 2)
+	go sim.StartWorkerPool(ctx, decodedTxChan, profitChan, 2)
 
-	// 启动处理协程
+	// 处理结果
 	go func() {
 		for {
 			select {
-			case tx := <-transactionCh:
-				// 发送到解码器
-				decoder.SubmitTransaction(tx)
-			case decodedTx := <-decodedCh:
-				// 发送到模拟器
-				simulator.SubmitTransaction(decodedTx)
 			case <-ctx.Done():
 				return
+			case analysis := <-profitChan:
+				if analysis == nil || analysis.NetProfit.Cmp(cfg.Sniper.MinProfit) < 0 {
+					continue
+				}
+				log.Printf("Opportunity: %s net profit %s wei (strategy %s, success rate %.2f)",
+					analysis.TxHash.Hex(), analysis.NetProfit, analysis.Strategy, analysis.SuccessRate)
 			}
 		}
 	}()
 
-	// 启动统计报告
+	// 定期输出统计信息
 	go func() {
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
@@ -71,18 +82,17 @@ func main() {
 		for {
 			select {
 			case <-ticker.C:
-				fmt.Printf("Stats - Transactions: %d, Decoded: %d, Simulated: %d, Profitable: %d\n",
-					listener.GetStats().TotalTransactions,
-					decoder.GetStats().TotalDecoded,
-					simulator.GetStats().TotalSimulated,
-					simulator.GetStats().ProfitableTransactions)
+				log.Printf("Stats - Transactions: %v, Decoded: %v, Simulated: %v",
+					lst.GetStats()["tx_count"],
+					dec.GetStats()["decoded"],
+					sim.GetStats()["simulated"])
 			case <-ctx.Done():
 				return
 			}
 		}
 	}()
 
-	log.Println("Mempool Sniper started successfully")
+	log.Println("Mempool Sniper example started")
 
 	// 等待退出信号
 	select {
@@ -91,10 +101,9 @@ func main() {
 	case <-ctx.Done():
 	}
 
-	// 优雅关闭
-	listener.Stop()
-	decoder.Stop()
-	simulator.Stop()
-	
-	log.Println("Mempool Sniper stopped")
-}
\ No newline at end of file
+	// 优雅关闭：取消上下文停止所有工作协程，再关闭监听器连接
+	cancel()
+	lst.Stop()
+
+	log.Println("Mempool Sniper example stopped")
+}
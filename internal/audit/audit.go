@@ -0,0 +1,273 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// genesisHash 第一条记录的前一哈希
+const genesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// HeadSuffix 头记录文件的后缀：与日志同目录保存最后一条记录的序号和哈希，
+// 哈希链本身无法发现末尾记录被整段删除（截断），需要与头记录比对
+const HeadSuffix = ".head"
+
+// Entry 一次执行动作（模拟盘或实盘）的审计记录
+type Entry struct {
+	Seq           uint64                 `json:"seq"`
+	Timestamp     time.Time              `json:"timestamp"`
+	OpportunityID string                 `json:"opportunity_id"`
+	Mode          string                 `json:"mode"`         // paper 或 live
+	Inputs        map[string]interface{} `json:"inputs"`       // 决策输入
+	Tx            map[string]interface{} `json:"tx,omitempty"` // 构建的交易（未构建时为空）
+	Result        string                 `json:"result"`
+}
+
+// line 文件中的一行：body 为记录原文，hash = sha256(prev_hash + "\n" + body)
+type line struct {
+	Body     json.RawMessage `json:"body"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+}
+
+// head 头记录：最后一条记录的序号和哈希
+type head struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// Log 只追加的哈希链审计日志
+type Log struct {
+	mu       sync.Mutex
+	file     *os.File
+	headPath string
+	seq      uint64
+	lastHash string
+	written  int64
+}
+
+// Open 打开审计日志：已有内容先按头记录校验哈希链并接续，链断裂或被截断时拒绝追加
+func Open(path string) (*Log, error) {
+	l := &Log{lastHash: genesisHash, headPath: path + HeadSuffix}
+
+	if _, err := os.Stat(path); err == nil {
+		tip, verifyErr := verifyFile(path)
+		if verifyErr != nil && !errors.Is(verifyErr, errHeadBehind) {
+			return nil, fmt.Errorf("审计日志 %s 校验失败，拒绝追加: %v", path, verifyErr)
+		}
+		l.seq = uint64(tip.count)
+		l.lastHash = tip.last
+		// 头记录落后（上次写入中断）时补写
+		if errors.Is(verifyErr, errHeadBehind) {
+			if err := writeHead(l.headPath, head{Seq: l.seq, Hash: l.lastHash}); err != nil {
+				return nil, err
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// Record 追加一条审计记录（为nil时忽略）
+func (l *Log) Record(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = l.seq + 1
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	record := line{Body: body, PrevHash: l.lastHash, Hash: chainHash(l.lastHash, body)}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	l.seq = entry.Seq
+	l.lastHash = record.Hash
+	l.written++
+	return writeHead(l.headPath, head{Seq: l.seq, Hash: l.lastHash})
+}
+
+// writeHead 原子地更新头记录（先写临时文件再重命名）
+func writeHead(path string, h head) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write audit head: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// readHead 读取头记录（不存在时返回nil）
+func readHead(path string) (*head, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h head
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("头记录格式无效: %v", err)
+	}
+	return &h, nil
+}
+
+// Verify 校验日志流的哈希链，返回记录数；中间任何一行被修改、删除或插入都会报错，
+// 但无法发现末尾记录被截断（需用 VerifyFile 与头记录比对）
+func Verify(r io.Reader) (int, error) {
+	tip, err := verify(r)
+	return tip.count, err
+}
+
+// VerifyFile 校验审计日志文件：哈希链完整，且最后一条记录与头记录一致（末尾被截断或头记录缺失都会报错）。
+// 头记录与日志保存在一起，同时改写两者的篡改仍需对照外部留存的哈希（如状态接口的 last_hash）发现
+func VerifyFile(path string) (int, error) {
+	tip, err := verifyFile(path)
+	if errors.Is(err, errHeadBehind) {
+		return tip.count, nil
+	}
+	return tip.count, err
+}
+
+// errHeadBehind 日志比头记录多一条：追加记录后、更新头记录前进程退出，最后一条记录仍由哈希链校验
+var errHeadBehind = errors.New("头记录落后一条记录")
+
+// verifyFile 校验日志文件及其头记录
+func verifyFile(path string) (chainTip, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return chainTip{}, err
+	}
+	defer file.Close()
+
+	tip, err := verify(file)
+	if err != nil {
+		return tip, err
+	}
+
+	h, err := readHead(path + HeadSuffix)
+	if err != nil {
+		return tip, err
+	}
+	switch {
+	case h == nil && tip.count > 0:
+		return tip, fmt.Errorf("头记录 %s 缺失", path+HeadSuffix)
+	case h == nil:
+		return tip, nil
+	case h.Seq == uint64(tip.count) && h.Hash == tip.last:
+		return tip, nil
+	case h.Seq+1 == uint64(tip.count) && h.Hash == tip.previous:
+		return tip, errHeadBehind
+	case h.Seq > uint64(tip.count):
+		return tip, fmt.Errorf("日志有 %d 条记录，头记录为第 %d 条（末尾记录被截断或删除）", tip.count, h.Seq)
+	default:
+		return tip, fmt.Errorf("第 %d 条记录的哈希与头记录不一致", h.Seq)
+	}
+}
+
+// chainTip 哈希链末端
+type chainTip struct {
+	count    int    // 记录数
+	last     string // 最后一条记录的哈希
+	previous string // 最后一条记录的前一哈希（即倒数第二条记录的哈希）
+}
+
+// verify 校验哈希链，返回链的末端
+func verify(r io.Reader) (chainTip, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	tip := chainTip{last: genesisHash, previous: genesisHash}
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		tip.count++
+		count := tip.count
+
+		var record line
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return tip, fmt.Errorf("第 %d 条记录格式无效: %v", count, err)
+		}
+		if record.PrevHash != tip.last {
+			return tip, fmt.Errorf("第 %d 条记录的前一哈希不连续", count)
+		}
+		if record.Hash != chainHash(tip.last, record.Body) {
+			return tip, fmt.Errorf("第 %d 条记录哈希不匹配（内容被修改）", count)
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(record.Body, &entry); err != nil {
+			return tip, fmt.Errorf("第 %d 条记录内容无效: %v", count, err)
+		}
+		if entry.Seq != uint64(count) {
+			return tip, fmt.Errorf("第 %d 条记录序号为 %d", count, entry.Seq)
+		}
+		tip.previous, tip.last = tip.last, record.Hash
+	}
+	return tip, scanner.Err()
+}
+
+// chainHash 计算链上哈希
+func chainHash(prev string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prev))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GetStats 获取统计信息
+func (l *Log) GetStats() map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return map[string]interface{}{
+		"entries":   l.seq,
+		"written":   l.written,
+		"last_hash": l.lastHash,
+	}
+}
+
+// Close 关闭审计日志
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLog 写入 n 条记录，返回日志路径
+func writeLog(t *testing.T, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := l.Record(Entry{Mode: "paper", Result: "simulated"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readLines(t *testing.T, path string) [][]byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.SplitAfter(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+}
+
+func writeLines(t *testing.T, path string, lines [][]byte) {
+	t.Helper()
+	if err := os.WriteFile(path, bytes.Join(lines, nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReopenContinuesChain(t *testing.T) {
+	path := writeLog(t, 2)
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record(Entry{Mode: "paper", Result: "simulated"}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	count, err := VerifyFile(path)
+	if err != nil || count != 3 {
+		t.Errorf("VerifyFile() = %d, %v, want 3 records", count, err)
+	}
+}
+
+func TestVerifyFileDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, path string)
+		want   string
+	}{
+		{
+			name: "modified body",
+			modify: func(t *testing.T, path string) {
+				lines := readLines(t, path)
+				lines[1] = bytes.Replace(lines[1], []byte(`"simulated"`), []byte(`"confirmed"`), 1)
+				writeLines(t, path, lines)
+			},
+			want: "内容被修改",
+		},
+		{
+			name: "deleted middle record",
+			modify: func(t *testing.T, path string) {
+				lines := readLines(t, path)
+				writeLines(t, path, append(lines[:1], lines[2:]...))
+			},
+			want: "不连续",
+		},
+		{
+			name: "truncated tail",
+			modify: func(t *testing.T, path string) {
+				lines := readLines(t, path)
+				writeLines(t, path, lines[:1])
+			},
+			want: "截断",
+		},
+		{
+			name: "missing head",
+			modify: func(t *testing.T, path string) {
+				if err := os.Remove(path + HeadSuffix); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "头记录",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLog(t, 3)
+			tt.modify(t, path)
+
+			if _, err := VerifyFile(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("VerifyFile() error = %v, want %q", err, tt.want)
+			}
+			if _, err := Open(path); err == nil {
+				t.Error("Open() appended to a broken log")
+			}
+		})
+	}
+}
+
+// 追加记录后、更新头记录前退出：日志仍可校验，重新打开时补写头记录
+func TestHeadBehindByOneRecord(t *testing.T) {
+	path := writeLog(t, 2)
+	staleHead, err := os.ReadFile(path + HeadSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(Entry{Mode: "paper", Result: "simulated"})
+	l.Close()
+	if err := os.WriteFile(path+HeadSuffix, staleHead, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := VerifyFile(path); err != nil || count != 3 {
+		t.Fatalf("VerifyFile() = %d, %v, want 3 records", count, err)
+	}
+	l, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if h, _ := readHead(path + HeadSuffix); h == nil || h.Seq != 3 {
+		t.Errorf("head = %+v after reopen, want seq 3", h)
+	}
+}
@@ -0,0 +1,76 @@
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// 构建信息，通过 -ldflags 注入，例如：
+//
+//	go build -ldflags "-X mempool-sniper/internal/buildinfo.version=v1.2.0 \
+//	  -X mempool-sniper/internal/buildinfo.commit=$(git rev-parse --short HEAD) \
+//	  -X mempool-sniper/internal/buildinfo.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/mempool-sniper
+var (
+	version   = "dev"
+	commit    = ""
+	buildTime = ""
+)
+
+// Version 程序版本
+func Version() string {
+	return version
+}
+
+// Commit 构建时的提交，未注入时尝试读取Go工具链记录的VCS信息
+func Commit() string {
+	if commit != "" {
+		return commit
+	}
+	if value := vcsSetting("vcs.revision"); value != "" {
+		if len(value) > 12 {
+			value = value[:12]
+		}
+		return value
+	}
+	return "unknown"
+}
+
+// BuildTime 构建时间，未注入时尝试读取VCS提交时间
+func BuildTime() string {
+	if buildTime != "" {
+		return buildTime
+	}
+	if value := vcsSetting("vcs.time"); value != "" {
+		return value
+	}
+	return "unknown"
+}
+
+// String 单行描述，用于启动日志
+func String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", Version(), Commit(), BuildTime(), runtime.Version())
+}
+
+// GetStats 获取构建信息
+func GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"version":    Version(),
+		"commit":     Commit(),
+		"build_time": BuildTime(),
+		"go_version": runtime.Version(),
+	}
+}
+
+func vcsSetting(key string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == key {
+			return setting.Value
+		}
+	}
+	return ""
+}
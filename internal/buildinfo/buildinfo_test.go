@@ -0,0 +1,70 @@
+package buildinfo
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// setBuildInfo 模拟 -ldflags 注入，测试结束后恢复
+func setBuildInfo(t *testing.T, v, c, b string) {
+	t.Helper()
+	saved := [3]string{version, commit, buildTime}
+	t.Cleanup(func() { version, commit, buildTime = saved[0], saved[1], saved[2] })
+	version, commit, buildTime = v, c, b
+}
+
+func TestGetStatsInjected(t *testing.T) {
+	setBuildInfo(t, "v1.2.0", "5a1adad", "2026-10-15T08:00:00Z")
+
+	want := map[string]interface{}{
+		"version":    "v1.2.0",
+		"commit":     "5a1adad",
+		"build_time": "2026-10-15T08:00:00Z",
+		"go_version": runtime.Version(),
+	}
+	stats := GetStats()
+	if len(stats) != len(want) {
+		t.Errorf("GetStats() has %d fields, want %d", len(stats), len(want))
+	}
+	for key, value := range want {
+		if stats[key] != value {
+			t.Errorf("GetStats()[%q] = %v, want %v", key, stats[key], value)
+		}
+	}
+	if got := String(); got != "v1.2.0 (commit 5a1adad, built 2026-10-15T08:00:00Z, "+runtime.Version()+")" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestGetStatsDefaults(t *testing.T) {
+	setBuildInfo(t, "dev", "", "")
+
+	if Version() != "dev" {
+		t.Errorf("Version() = %q, want dev", Version())
+	}
+	// 未注入时回退到Go工具链记录的VCS信息，都没有时为 unknown；任何情况下字段都不为空
+	stats := GetStats()
+	for _, key := range []string{"version", "commit", "build_time", "go_version"} {
+		value, ok := stats[key].(string)
+		if !ok || value == "" {
+			t.Errorf("GetStats()[%q] = %v, want a non-empty string", key, stats[key])
+		}
+	}
+	wantCommit, wantTime := vcsSetting("vcs.revision"), vcsSetting("vcs.time")
+	if len(wantCommit) > 12 {
+		wantCommit = wantCommit[:12]
+	}
+	if wantCommit == "" {
+		wantCommit = "unknown"
+	}
+	if wantTime == "" {
+		wantTime = "unknown"
+	}
+	if Commit() != wantCommit || BuildTime() != wantTime {
+		t.Errorf("Commit() = %q, BuildTime() = %q; want %q, %q", Commit(), BuildTime(), wantCommit, wantTime)
+	}
+	if !strings.HasPrefix(String(), "dev (commit ") {
+		t.Errorf("String() = %q", String())
+	}
+}
@@ -1,19 +1,33 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
-	"github.com/joho/godotenv"
+	"mempool-sniper/internal/filter"
+	"mempool-sniper/internal/logging"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // Config 应用配置结构体
 type Config struct {
-	Ethereum EthereumConfig `json:"ethereum"`
-	Sniper   SniperConfig   `json:"sniper"`
-	Logging  LoggingConfig  `json:"logging"`
+	Ethereum  EthereumConfig  `json:"ethereum"`
+	Sniper    SniperConfig    `json:"sniper"`
+	Logging   LoggingConfig   `json:"logging"`
+	Output    OutputConfig    `json:"output"`
+	Wallet    WalletConfig    `json:"wallet"`
+	Execution ExecutionConfig `json:"execution"`
+
+	Version uint64 `json:"version"` // 配置版本号（首次加载为1，每次热重载递增）
+
+	parseErrors []error // 构建时的解析错误（由校验报错）
 }
 
 // EthereumConfig Ethereum节点配置
@@ -21,57 +35,431 @@ type EthereumConfig struct {
 	WSSURL  string `json:"wss_url"`
 	RPCURL  string `json:"rpc_url"`
 	ChainID int64  `json:"chain_id"`
+
+	WSSURLs []string `json:"wss_urls"` // 候选监听节点（第一个即 WSSURL，连接故障时按顺序轮换）
+	RPCURLs []string `json:"rpc_urls"` // 候选模拟节点（第一个即 RPCURL，连接故障时按顺序轮换）
+
+	ProbeCapabilities bool `json:"probe_capabilities"` // 启动时探测节点pending订阅能力
+	ServerFilter      bool `json:"server_filter"`      // 节点支持时使用服务端地址过滤（会错过取消交易）
+	PreFilter         bool `json:"pre_filter"`         // 监听器侧按合约地址+方法选择器预过滤
+	SelectorBloom     bool `json:"selector_bloom"`     // 过滤交易时先用交换方法选择器位图排除非交换交易
+
+	FetchTimeout     int `json:"fetch_timeout"`     // 监听器单次RPC请求超时(毫秒)
+	FetchConcurrency int `json:"fetch_concurrency"` // 按哈希查询交易的最大并发数（0表示不限制）
+
+	PendingBackfill      string `json:"pending_backfill"`       // 启动时回填当前pending池: off, auto, txpool_content, eth_pendingTransactions
+	PendingBackfillLimit int    `json:"pending_backfill_limit"` // 最多回填的交易数（0表示不限制）
+
+	SeenHashCache  int `json:"seen_hash_cache"`   // pending交易哈希去重缓存容量（0表示不去重）
+	SeenHashTTLSec int `json:"seen_hash_ttl_sec"` // 哈希去重的时间窗口（秒，0表示只按容量淘汰）
 }
 
 // SniperConfig 狙击手配置
 type SniperConfig struct {
-	MinProfit         *big.Int `json:"min_profit"`         // 最小盈利阈值 (wei)
-	MaxGasPrice       *big.Int `json:"max_gas_price"`      // 最大Gas价格
-	MaxGasLimit       uint64   `json:"max_gas_limit"`      // 最大Gas限制
-	WorkerPoolSize    int      `json:"worker_pool_size"`   // 工作池大小
-	SimulationTimeout int      `json:"simulation_timeout"` // 模拟超时(秒)
+	MinProfit         *big.Int `json:"min_profit"`          // 最小盈利阈值 (wei)
+	MaxGasPrice       *big.Int `json:"max_gas_price"`       // 最大Gas价格
+	MaxGasLimit       uint64   `json:"max_gas_limit"`       // 最大Gas限制
+	WorkerPoolSize    int      `json:"worker_pool_size"`    // 工作池大小
+	ResultWorkers     int      `json:"result_workers"`      // 结果处理工作池大小
+	RPCPoolSize       int      `json:"rpc_pool_size"`       // 模拟器RPC连接池大小
+	SimulationTimeout int      `json:"simulation_timeout"`  // 模拟超时(秒)
+	TargetBlockOffset uint64   `json:"target_block_offset"` // 目标区块偏移量（最新区块 + N）
+
+	MinProfitByToken map[string]string `json:"min_profit_by_token"` // 按盈利代币的最小盈利（代币符号或地址 -> 人类可读数量，按代币精度换算，比较时按现价折算为基础资产）
+
+	PairMinConfirmations uint64  `json:"pair_min_confirmations"` // 新交易对需满足的最少区块确认数（0表示不检查）
+	GasSafetyMultiplier  float64 `json:"gas_safety_multiplier"`  // Gas估算安全系数（默认1.0即不放大，需要时显式开启）
+	MaxReserveRatio      float64 `json:"max_reserve_ratio"`      // 交易对价格相对近期基准的最大偏离倍数（0表示不检查）
+
+	WarmupSeconds      int   `json:"warmup_seconds"`      // 启动后前T秒只解码不模拟
+	WarmupTransactions int64 `json:"warmup_transactions"` // 启动后前N笔交易只解码不模拟
+
+	RecipientAllowlist []common.Address `json:"recipient_allowlist"` // 接收地址白名单（为空表示不限制）
+	RecipientDenylist  []common.Address `json:"recipient_denylist"`  // 接收地址黑名单
+
+	TokenTaxRates map[common.Address]uint64 `json:"token_tax_rates"` // 代币转账税率 (bps)
+
+	RouterABIFiles map[common.Address]string `json:"router_abi_files"` // 额外路由合约ABI文件（路由地址 → JSON文件路径）
+
+	OpportunityFilter string `json:"opportunity_filter"` // 盈利机会过滤表达式（为空表示不过滤）
+
+	SimWorkersMin      int `json:"sim_workers_min"`       // 模拟器工作线程自动调节下限
+	SimWorkersMax      int `json:"sim_workers_max"`       // 模拟器工作线程自动调节上限（0表示不自动调节）
+	SimLatencyTargetMs int `json:"sim_latency_target_ms"` // 模拟延迟超过该值时不再扩容（0表示不限制）
+
+	WatchdogStallSec int `json:"watchdog_stall_sec"` // 工作池有积压但超过该秒数没有处理任何交易时重启其工作线程（0表示不监控）
+
+	ShutdownTimeoutSec int `json:"shutdown_timeout_sec"` // 关闭时等待单个流水线阶段排空并退出的最长秒数
+
+	MaxTrackedPending int `json:"max_tracked_pending"` // 各pending跟踪器共享的记录数上限（0表示不限制）
+
+	SwapDirections []string `json:"swap_directions"` // 进入模拟的交换方向: buy, sell, swap
+
+	PairWhitelist [][2]common.Address `json:"pair_whitelist"` // 交易对白名单（顺序无关，为空表示不限制）
+
+	FeeCacheMaxAgeMs int `json:"fee_cache_max_age_ms"` // 基础费用缓存最大有效期（毫秒），新区块未到达时超时也会强制刷新
+
+	SpamWindowMs   int  `json:"spam_window_ms"`   // 垃圾交易聚类统计窗口（毫秒）
+	SpamMinSenders int  `json:"spam_min_senders"` // 窗口内相同交换的不同发送者达到该值视为垃圾交易聚类（0表示不识别）
+	SpamThrottle   bool `json:"spam_throttle"`    // 是否跳过已识别聚类中交易的模拟
+
+	AttackerWindowMs  int     `json:"attacker_window_ms"`  // 夹子攻击者识别窗口（毫秒，0表示不识别）
+	AttackerSizeRatio float64 `json:"attacker_size_ratio"` // 抢跑交易规模至少为受害者交易的倍数
+
+	ApprovalWindowMs int `json:"approval_window_ms"` // 路由授权与同一发送者后续交换的关联窗口（毫秒，0表示不跟踪）
+
+	MinRunwayBlocks  uint64  `json:"min_runway_blocks"`  // 受害者预计打包前至少需要的区块数（0表示不检查）
+	RunwayBlockShare float64 `json:"runway_block_share"` // 单个区块可容纳的内存池交易占比（按小费排序）
+
+	TraceSimulation bool `json:"trace_simulation"` // 使用 debug_traceCall 检查受害者是否回滚并追踪我们的买入腿（节点不支持时回退到 eth_call）
+
+	Strategies map[string]float64 `json:"strategies"` // 启用的评估策略及得分权重
+
+	SuccessRateFloor   float64 `json:"success_rate_floor"`   // 成功率下限
+	SuccessRateCeiling float64 `json:"success_rate_ceiling"` // 成功率上限
+
+	ReplacementMode string `json:"replacement_mode"` // 替代(加速)交易处理: off 不区分, boost 不受垃圾/跑道限流, only 只模拟替代交易
+
+	GasModel string `json:"gas_model"` // Gas计费模型: auto 按链ID选择, l1 只有执行Gas, opstack 额外计入L1数据费
+
+	GasPricing string `json:"gas_pricing"` // Gas定价: auto 按区块头是否有基础费用判断, eip1559, legacy
+
+	MaxOwnImpactBps uint64 `json:"max_own_impact_bps"` // 我们自己的买入/卖出交易的最大价格冲击（万分比，0表示不限制）
+
+	SniperInputSize *big.Int `json:"sniper_input_size"` // 启发式策略的买入仓位（输入代币最小单位，0表示与受害者输入相同）
+
+	ProfitEstimate      string `json:"profit_estimate"`       // 用于门槛判断的盈利口径: pessimistic 假设同向竞争交换先成交, optimistic 假设没有竞争
+	CompetitionWindowMs int    `json:"competition_window_ms"` // 统计竞争交换的时间窗口（毫秒，0表示不统计，悲观与乐观估算相同）
+}
+
+// AllowsDirection 检查交换方向是否允许进入模拟
+func (c *SniperConfig) AllowsDirection(direction string) bool {
+	for _, allowed := range c.SwapDirections {
+		if allowed == direction {
+			return true
+		}
+	}
+	return false
 }
 
 // LoggingConfig 日志配置
 type LoggingConfig struct {
-	Level    string `json:"level"`     // 日志级别
-	FilePath string `json:"file_path"` // 日志文件路径
+	Level    string `json:"level"`     // 日志级别: debug, info, warn, error
+	Format   string `json:"format"`    // 日志格式: json（结构化）, text（本地开发阅读）
+	FilePath string `json:"file_path"` // 日志文件路径（为空表示只输出到标准错误）
+
+	SwapPathSymbols   bool   `json:"swap_path_symbols"`   // 日志中以代币符号输出交换路径
+	LifecycleFile     string `json:"lifecycle_file"`      // 盈利机会生命周期事件日志（为空表示不记录）
+	DecodedParamsFile string `json:"decoded_params_file"` // 解码交易的全部调用参数日志（JSONL，调试用，为空表示不记录）
+
+	FunnelLogInterval int `json:"funnel_log_interval"` // 定期输出机会转化漏斗日志的间隔（秒，0表示不输出）
+}
+
+// OutputConfig 盈利机会输出配置
+type OutputConfig struct {
+	Format string `json:"format"` // 输出编码格式: json, protobuf
+
+	TrainingFile       string  `json:"training_file"`        // 训练数据文件（仅JSONL，包含不盈利的样本，为空表示不输出）
+	TrainingSampleRate float64 `json:"training_sample_rate"` // 训练数据采样率 (0, 1]
+
+	OpportunityDB string `json:"opportunity_db"` // 盈利机会SQLite数据库路径（用于回测和审计，为空表示不记录）
+
+	StatusAddr  string `json:"status_addr"`  // 状态服务监听地址（为空表示不启动）
+	StatusPprof bool   `json:"status_pprof"` // 状态服务是否挂载 /debug/pprof/（仅用于调试）
+
+	WebhookURL       string `json:"webhook_url"`        // 每个可执行机会POST到该地址（为空表示不启用）
+	WebhookSecret    string `json:"-"`                  // Webhook请求体HMAC-SHA256签名密钥（为空表示不签名）
+	WebhookTimeoutMs int    `json:"webhook_timeout_ms"` // Webhook/Telegram单次请求超时（毫秒）
+	WebhookRetries   int    `json:"webhook_retries"`    // Webhook发送失败后的重试次数（指数退避）
+
+	TelegramBotToken       string `json:"-"`                        // Telegram机器人token（为空表示不启用）
+	TelegramChatID         string `json:"telegram_chat_id"`         // 接收通知的Telegram会话ID
+	TelegramAPIURL         string `json:"telegram_api_url"`         // Telegram Bot API地址
+	TelegramBatchThreshold int    `json:"telegram_batch_threshold"` // 一秒内机会超过该数量时合并为一条消息（Telegram 单个会话每秒约1条）
+
+	StatsExportFile     string `json:"stats_export_file"`     // 统计快照导出文件（JSONL，为空表示不导出）
+	StatsExportInterval int    `json:"stats_export_interval"` // 统计快照导出间隔（秒）
+	StatsExportMaxMB    int    `json:"stats_export_max_mb"`   // 导出文件超过该大小时轮转（MB，0表示不轮转）
+	StatsExportBackups  int    `json:"stats_export_backups"`  // 轮转后保留的旧文件数
+}
+
+// WalletConfig 钱包配置（用于自动交易）
+type WalletConfig struct {
+	PrivateKey string `json:"-"`       // 私钥（不参与序列化）
+	Address    string `json:"address"` // 钱包地址
+
+	PrivateKeys []string `json:"-"` // 多个签名私钥（轮询使用，各自独立管理nonce）
+}
+
+// SigningKeys 所有签名私钥（PRIVATE_KEY 在前，去除重复）
+func (w *WalletConfig) SigningKeys() []string {
+	keys := make([]string, 0, len(w.PrivateKeys)+1)
+	seen := make(map[string]bool)
+	for _, key := range append([]string{w.PrivateKey}, w.PrivateKeys...) {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ExecutionConfig 执行配置
+type ExecutionConfig struct {
+	PaperTrading bool   `json:"paper_trading"` // 模拟盘模式：只记录假设成交的盈亏，不广播交易
+	PnLFile      string `json:"pnl_file"`      // 盈亏记录文件（JSONL，为空表示只在内存统计）
+	AuditFile    string `json:"audit_file"`    // 执行动作审计日志（哈希链JSONL，为空表示不记录）
+
+	PreTradeRecheck bool   `json:"pre_trade_recheck"` // 执行前在最新区块重新模拟，盈利不足则放弃
+	ReorgDepth      uint64 `json:"reorg_depth"`       // 已记录结果可被区块重组推翻的深度（0表示不处理重组）
+
+	OpportunityRateLimit int    `json:"opportunity_rate_limit"` // 每秒最多处理的可执行机会数（0表示不限制）
+	OpportunityRateMode  string `json:"opportunity_rate_mode"`  // 超出上限时的处理方式: drop, queue
+
+	BuilderTipBps uint64   `json:"builder_tip_bps"` // 支付给区块构建者的小费（净盈利的万分比）
+	SafetyBuffer  *big.Int `json:"safety_buffer"`   // 执行安全缓冲 (基础资产最小单位)，从净盈利中额外扣除
+
+	ActionDelayMinMs int `json:"action_delay_min_ms"` // 执行前随机延迟下限（毫秒），避免固定时序被识别
+	ActionDelayMaxMs int `json:"action_delay_max_ms"` // 执行前随机延迟上限（毫秒，0表示不延迟）
+
+	DedupWindowMs    int     `json:"dedup_window_ms"`    // 经济等价机会去重窗口（毫秒，0表示不去重）
+	DedupBucketWidth float64 `json:"dedup_bucket_width"` // 受害者交易规模分桶宽度（相对比例，0.1表示每档相差10%）
+
+	SanityMaxProfit *big.Int `json:"sanity_max_profit"` // 净盈利超过该值时暂停执行并告警，需手动恢复 (基础资产最小单位，0表示不检查)
+
+	MaxInFlight  int    `json:"max_in_flight"`  // 同时进行中的执行数量上限（真实交易和模拟盘，0表示不限制）
+	InFlightMode string `json:"in_flight_mode"` // 超出上限时的处理方式: drop, queue
 }
 
 // Load 加载配置
 func Load() (*Config, error) {
-	// 加载.env文件（如果存在）
-	_ = godotenv.Load()
+	// 加载.env文件（如果存在，不覆盖已有环境变量）并验证配置
+	cfg, err := loadFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Version = 1
+	return cfg, nil
+}
+
+// build 根据候选环境变量 buildEnv 构建配置（不做验证，调用方需持有 envMu）
+func build() *Config {
+	// 地址列表中无法解析的项和无法读取的密钥文件不能静默忽略（黑名单漏掉一项就会失效，
+	// 私钥文件读取失败会回退到环境变量中可能过期的值），记录后由校验报错
+	var parseErrors []error
+	secret := func(key, defaultValue string) string {
+		value, err := getSecret(key, defaultValue)
+		if err != nil {
+			parseErrors = append(parseErrors, err)
+		}
+		return value
+	}
+	secretList := func(key, fallback string) []string {
+		list, err := getSecretList(key, fallback)
+		if err != nil {
+			parseErrors = append(parseErrors, err)
+		}
+		return list
+	}
+	addresses := func(key string) []common.Address {
+		list, err := getEnvAddresses(key)
+		if err != nil {
+			parseErrors = append(parseErrors, err)
+		}
+		return list
+	}
+	pairs := func(key string) [][2]common.Address {
+		list, err := getEnvPairs(key)
+		if err != nil {
+			parseErrors = append(parseErrors, err)
+		}
+		return list
+	}
+
+	wssURLs := secretList("ETH_WSS_URLS", secret("ETH_WSS_URL", "wss://mainnet.infura.io/ws/v3/YOUR_INFURA_PROJECT_ID"))
+	rpcURLs := secretList("ETH_RPC_URLS", secret("ETH_RPC_URL", "https://mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID"))
 
 	cfg := &Config{
 		Ethereum: EthereumConfig{
-			WSSURL:  getEnv("ETH_WSS_URL", "wss://mainnet.infura.io/ws/v3/YOUR_INFURA_PROJECT_ID"),
-			RPCURL:  getEnv("ETH_RPC_URL", "https://mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID"),
+			WSSURL:  wssURLs[0],
+			RPCURL:  rpcURLs[0],
 			ChainID: getEnvInt64("ETH_CHAIN_ID", 1),
+
+			WSSURLs: wssURLs,
+			RPCURLs: rpcURLs,
+
+			ProbeCapabilities: getEnvBool("ETH_PROBE_CAPABILITIES", true),
+			ServerFilter:      getEnvBool("ETH_SERVER_FILTER", false),
+			PreFilter:         getEnvBool("ETH_PRE_FILTER", false),
+			SelectorBloom:     getEnvBool("ETH_SELECTOR_BLOOM", true),
+
+			FetchTimeout:     getEnvInt("FETCH_TIMEOUT", 3000),
+			FetchConcurrency: getEnvInt("FETCH_CONCURRENCY", 256),
+
+			PendingBackfill:      getEnv("PENDING_BACKFILL", "off"),
+			PendingBackfillLimit: getEnvInt("PENDING_BACKFILL_LIMIT", 5000),
+
+			SeenHashCache:  getEnvInt("SEEN_HASH_CACHE", 10000),
+			SeenHashTTLSec: getEnvInt("SEEN_HASH_TTL_SEC", 120),
 		},
 		Sniper: SniperConfig{
 			MinProfit:         getEnvBigInt("MIN_PROFIT", "1000000000000000"), // 0.001 ETH
 			MaxGasPrice:       getEnvBigInt("MAX_GAS_PRICE", "50000000000"),   // 50 Gwei
 			MaxGasLimit:       getEnvUint64("MAX_GAS_LIMIT", 300000),
 			WorkerPoolSize:    getEnvInt("WORKER_POOL_SIZE", 5),
+			ResultWorkers:     getEnvInt("RESULT_WORKERS", 2),
+			RPCPoolSize:       getEnvInt("RPC_POOL_SIZE", 1),
 			SimulationTimeout: getEnvInt("SIMULATION_TIMEOUT", 10),
+			TargetBlockOffset: getEnvUint64("TARGET_BLOCK_OFFSET", 1),
+
+			MinProfitByToken: getEnvPrefixed("MIN_PROFIT_"),
+
+			PairMinConfirmations: getEnvUint64("PAIR_MIN_CONFIRMATIONS", 0),
+			GasSafetyMultiplier:  getEnvFloat64("GAS_SAFETY_MULTIPLIER", 1.0),
+			MaxReserveRatio:      getEnvFloat64("MAX_RESERVE_RATIO", 0),
+
+			WarmupSeconds:      getEnvInt("WARMUP_SECONDS", 0),
+			WarmupTransactions: getEnvInt64("WARMUP_TRANSACTIONS", 0),
+
+			RecipientAllowlist: addresses("RECIPIENT_ALLOWLIST"),
+			RecipientDenylist:  addresses("RECIPIENT_DENYLIST"),
+
+			TokenTaxRates: getEnvTaxRates("TOKEN_TAX_RATES"),
+
+			RouterABIFiles: getEnvAddressPaths("ROUTER_ABIS"),
+
+			OpportunityFilter: getEnv("OPPORTUNITY_FILTER", ""),
+
+			SimWorkersMin:      getEnvInt("SIM_WORKERS_MIN", 1),
+			SimWorkersMax:      getEnvInt("SIM_WORKERS_MAX", 0),
+			SimLatencyTargetMs: getEnvInt("SIM_LATENCY_TARGET_MS", 500),
+
+			WatchdogStallSec: getEnvInt("WATCHDOG_STALL_SEC", 60),
+
+			ShutdownTimeoutSec: getEnvInt("SHUTDOWN_TIMEOUT_SEC", 10),
+
+			MaxTrackedPending: getEnvInt("MAX_TRACKED_PENDING", 50000),
+
+			SwapDirections: getEnvList("SWAP_DIRECTIONS", "buy,sell,swap"),
+
+			PairWhitelist: pairs("PAIR_WHITELIST"),
+
+			FeeCacheMaxAgeMs: getEnvInt("FEE_CACHE_MAX_AGE", 15000),
+
+			SpamWindowMs:   getEnvInt("SPAM_WINDOW_MS", 3000),
+			SpamMinSenders: getEnvInt("SPAM_MIN_SENDERS", 0),
+			SpamThrottle:   getEnvBool("SPAM_THROTTLE", false),
+
+			AttackerWindowMs:  getEnvInt("ATTACKER_WINDOW_MS", 0),
+			AttackerSizeRatio: getEnvFloat64("ATTACKER_SIZE_RATIO", 1),
+
+			ApprovalWindowMs: getEnvInt("APPROVAL_WINDOW_MS", 0),
+
+			MinRunwayBlocks:  getEnvUint64("MIN_RUNWAY_BLOCKS", 0),
+			RunwayBlockShare: getEnvFloat64("RUNWAY_BLOCK_SHARE", 0.25),
+
+			TraceSimulation: getEnvBool("TRACE_SIMULATION", false),
+
+			Strategies: getEnvWeights("STRATEGIES", "heuristic"),
+
+			SuccessRateFloor:   getEnvFloat64("SUCCESS_RATE_FLOOR", 0),
+			SuccessRateCeiling: getEnvFloat64("SUCCESS_RATE_CEILING", 1),
+
+			ReplacementMode: strings.ToLower(getEnv("REPLACEMENT_MODE", "off")),
+
+			GasModel: strings.ToLower(getEnv("GAS_MODEL", "auto")),
+
+			GasPricing: strings.ToLower(getEnv("GAS_PRICING", "auto")),
+
+			MaxOwnImpactBps: getEnvUint64("MAX_OWN_IMPACT_BPS", 0),
+
+			SniperInputSize: getEnvBigInt("SNIPER_INPUT_SIZE", "0"),
+
+			ProfitEstimate:      strings.ToLower(getEnv("PROFIT_ESTIMATE", "pessimistic")),
+			CompetitionWindowMs: getEnvInt("COMPETITION_WINDOW_MS", 12000),
 		},
 		Logging: LoggingConfig{
 			Level:    getEnv("LOG_LEVEL", "info"),
+			Format:   getEnv("LOG_FORMAT", "json"),
 			FilePath: getEnv("LOG_FILE", "mempool-sniper.log"),
+
+			SwapPathSymbols:   getEnvBool("LOG_SWAP_SYMBOLS", true),
+			LifecycleFile:     getEnv("LOG_LIFECYCLE_FILE", ""),
+			DecodedParamsFile: getEnv("LOG_DECODED_PARAMS_FILE", ""),
+
+			FunnelLogInterval: getEnvInt("LOG_FUNNEL_INTERVAL", 0),
 		},
-	}
+		Output: OutputConfig{
+			Format: getEnv("OUTPUT_FORMAT", "json"),
 
-	// 验证配置
-	if err := cfg.validate(); err != nil {
-		return nil, err
-	}
+			TrainingFile:       getEnv("TRAINING_FILE", ""),
+			TrainingSampleRate: getEnvFloat64("TRAINING_SAMPLE_RATE", 1.0),
 
-	return cfg, nil
+			OpportunityDB: getEnv("OPPORTUNITY_DB", ""),
+
+			StatusAddr:  getEnv("STATUS_ADDR", ""),
+			StatusPprof: getEnvBool("STATUS_PPROF", false),
+
+			WebhookURL:       getEnv("WEBHOOK_URL", ""),
+			WebhookSecret:    secret("WEBHOOK_SECRET", ""),
+			WebhookTimeoutMs: getEnvInt("WEBHOOK_TIMEOUT_MS", 5000),
+			WebhookRetries:   getEnvInt("WEBHOOK_RETRIES", 2),
+
+			TelegramBotToken:       secret("TELEGRAM_BOT_TOKEN", ""),
+			TelegramChatID:         getEnv("TELEGRAM_CHAT_ID", ""),
+			TelegramAPIURL:         getEnv("TELEGRAM_API_URL", "https://api.telegram.org"),
+			TelegramBatchThreshold: getEnvInt("TELEGRAM_BATCH_THRESHOLD", 1),
+
+			StatsExportFile:     getEnv("STATS_EXPORT_FILE", ""),
+			StatsExportInterval: getEnvInt("STATS_EXPORT_INTERVAL", 60),
+			StatsExportMaxMB:    getEnvInt("STATS_EXPORT_MAX_MB", 100),
+			StatsExportBackups:  getEnvInt("STATS_EXPORT_BACKUPS", 5),
+		},
+		Wallet: WalletConfig{
+			PrivateKey: secret("PRIVATE_KEY", ""),
+			Address:    getEnv("WALLET_ADDRESS", ""),
+
+			PrivateKeys: splitKeys(secret("PRIVATE_KEYS", "")),
+		},
+		Execution: ExecutionConfig{
+			PaperTrading: getEnvBool("PAPER_TRADING", false),
+			PnLFile:      getEnv("PNL_FILE", ""),
+			AuditFile:    getEnv("AUDIT_FILE", ""),
+
+			PreTradeRecheck: getEnvBool("PRE_TRADE_RECHECK", true),
+			ReorgDepth:      getEnvUint64("REORG_DEPTH", 12),
+
+			OpportunityRateLimit: getEnvInt("OPPORTUNITY_RATE_LIMIT", 0),
+			OpportunityRateMode:  strings.ToLower(getEnv("OPPORTUNITY_RATE_MODE", "drop")),
+
+			BuilderTipBps: getEnvUint64("BUILDER_TIP_BPS", 0),
+			SafetyBuffer:  getEnvBigInt("SAFETY_BUFFER", "0"),
+
+			ActionDelayMinMs: getEnvInt("ACTION_DELAY_MIN_MS", 0),
+			ActionDelayMaxMs: getEnvInt("ACTION_DELAY_MAX_MS", 0),
+
+			DedupWindowMs:    getEnvInt("OPPORTUNITY_DEDUP_WINDOW_MS", 0),
+			DedupBucketWidth: getEnvFloat64("OPPORTUNITY_DEDUP_BUCKET", 0.1),
+
+			SanityMaxProfit: getEnvBigInt("SANITY_MAX_PROFIT", "0"),
+
+			MaxInFlight:  getEnvInt("MAX_IN_FLIGHT", 0),
+			InFlightMode: strings.ToLower(getEnv("IN_FLIGHT_MODE", "drop")),
+		},
+	}
+	cfg.parseErrors = parseErrors
+	return cfg
 }
 
 // validate 验证配置
 func (c *Config) validate() error {
+	if len(c.parseErrors) > 0 {
+		return errors.Join(c.parseErrors...)
+	}
+
 	if c.Ethereum.WSSURL == "" || c.Ethereum.WSSURL == "wss://mainnet.infura.io/ws/v3/YOUR_INFURA_PROJECT_ID" {
 		return fmt.Errorf("ETH_WSS_URL 必须配置为有效的WebSocket URL")
 	}
@@ -80,10 +468,54 @@ func (c *Config) validate() error {
 		return fmt.Errorf("ETH_RPC_URL 必须配置为有效的RPC URL")
 	}
 
+	for _, url := range c.Ethereum.WSSURLs[1:] {
+		if url == "wss://mainnet.infura.io/ws/v3/YOUR_INFURA_PROJECT_ID" {
+			return fmt.Errorf("ETH_WSS_URLS 中的节点必须配置为有效的WebSocket URL")
+		}
+	}
+	for _, url := range c.Ethereum.RPCURLs[1:] {
+		if url == "https://mainnet.infura.io/v3/YOUR_INFURA_PROJECT_ID" {
+			return fmt.Errorf("ETH_RPC_URLS 中的节点必须配置为有效的RPC URL")
+		}
+	}
+
+	if _, exists := types.LookupChain(c.Ethereum.ChainID); !exists {
+		return fmt.Errorf("ETH_CHAIN_ID 不支持: %d（支持的链: %v）", c.Ethereum.ChainID, types.SupportedChainIDs())
+	}
+
+	if c.Ethereum.FetchTimeout <= 0 {
+		return fmt.Errorf("FETCH_TIMEOUT 必须大于0")
+	}
+
+	if c.Ethereum.FetchConcurrency < 0 {
+		return fmt.Errorf("FETCH_CONCURRENCY 不能小于0")
+	}
+
+	switch c.Ethereum.PendingBackfill {
+	case "off", "auto", "txpool_content", "eth_pendingTransactions":
+	default:
+		return fmt.Errorf("PENDING_BACKFILL 必须为 off、auto、txpool_content 或 eth_pendingTransactions")
+	}
+
+	if c.Ethereum.PendingBackfillLimit < 0 {
+		return fmt.Errorf("PENDING_BACKFILL_LIMIT 不能小于0")
+	}
+
+	if c.Ethereum.SeenHashCache < 0 || c.Ethereum.SeenHashTTLSec < 0 {
+		return fmt.Errorf("SEEN_HASH_CACHE 和 SEEN_HASH_TTL_SEC 不能小于0")
+	}
+
 	if c.Sniper.MinProfit.Cmp(big.NewInt(0)) <= 0 {
 		return fmt.Errorf("MIN_PROFIT 必须大于0")
 	}
 
+	for token, amount := range c.Sniper.MinProfitByToken {
+		value, ok := new(big.Rat).SetString(amount)
+		if !ok || value.Sign() <= 0 {
+			return fmt.Errorf("MIN_PROFIT_%s 必须为大于0的数量: %q", token, amount)
+		}
+	}
+
 	if c.Sniper.MaxGasPrice.Cmp(big.NewInt(0)) <= 0 {
 		return fmt.Errorf("MAX_GAS_PRICE 必须大于0")
 	}
@@ -96,19 +528,307 @@ func (c *Config) validate() error {
 		return fmt.Errorf("WORKER_POOL_SIZE 必须大于0")
 	}
 
+	for token, bps := range c.Sniper.TokenTaxRates {
+		if bps >= 10000 {
+			return fmt.Errorf("TOKEN_TAX_RATES 中 %s 的税率必须小于10000 bps", token.Hex())
+		}
+	}
+
+	if c.Sniper.MaxReserveRatio != 0 && c.Sniper.MaxReserveRatio < 1 {
+		return fmt.Errorf("MAX_RESERVE_RATIO 必须大于等于1（0表示不检查）")
+	}
+
+	if c.Sniper.ResultWorkers <= 0 {
+		return fmt.Errorf("RESULT_WORKERS 必须大于0")
+	}
+
+	if c.Sniper.RPCPoolSize <= 0 {
+		return fmt.Errorf("RPC_POOL_SIZE 必须大于0")
+	}
+
+	if c.Sniper.TargetBlockOffset == 0 {
+		return fmt.Errorf("TARGET_BLOCK_OFFSET 必须大于0")
+	}
+
+	if c.Sniper.GasSafetyMultiplier < 1 {
+		return fmt.Errorf("GAS_SAFETY_MULTIPLIER 不能小于1")
+	}
+
+	if c.Sniper.SimWorkersMax > 0 && (c.Sniper.SimWorkersMin < 1 || c.Sniper.SimWorkersMax < c.Sniper.SimWorkersMin) {
+		return fmt.Errorf("SIM_WORKERS_MIN 必须大于0且不超过 SIM_WORKERS_MAX")
+	}
+
+	if c.Sniper.WatchdogStallSec < 0 {
+		return fmt.Errorf("WATCHDOG_STALL_SEC 不能小于0")
+	}
+
+	if c.Sniper.ShutdownTimeoutSec <= 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT_SEC 必须大于0")
+	}
+
+	if c.Sniper.FeeCacheMaxAgeMs <= 0 {
+		return fmt.Errorf("FEE_CACHE_MAX_AGE 必须大于0")
+	}
+
+	if c.Sniper.SpamMinSenders > 0 && c.Sniper.SpamWindowMs <= 0 {
+		return fmt.Errorf("启用 SPAM_MIN_SENDERS 时 SPAM_WINDOW_MS 必须大于0")
+	}
+
+	if c.Sniper.AttackerWindowMs < 0 {
+		return fmt.Errorf("ATTACKER_WINDOW_MS 不能小于0")
+	}
+
+	if c.Sniper.AttackerSizeRatio <= 0 {
+		return fmt.Errorf("ATTACKER_SIZE_RATIO 必须大于0")
+	}
+
+	if c.Sniper.ApprovalWindowMs < 0 {
+		return fmt.Errorf("APPROVAL_WINDOW_MS 不能小于0")
+	}
+
+	if c.Sniper.RunwayBlockShare <= 0 || c.Sniper.RunwayBlockShare > 1 {
+		return fmt.Errorf("RUNWAY_BLOCK_SHARE 必须在 (0, 1] 范围内")
+	}
+
+	switch c.Sniper.ReplacementMode {
+	case "off", "boost", "only":
+	default:
+		return fmt.Errorf("REPLACEMENT_MODE 必须为 off、boost 或 only")
+	}
+
+	switch c.Sniper.GasModel {
+	case "auto", "l1", "opstack":
+	default:
+		return fmt.Errorf("GAS_MODEL 必须为 auto、l1 或 opstack")
+	}
+
+	switch c.Sniper.GasPricing {
+	case "auto", "eip1559", "legacy":
+	default:
+		return fmt.Errorf("GAS_PRICING 必须为 auto、eip1559 或 legacy")
+	}
+
+	if c.Sniper.MaxOwnImpactBps > 10000 {
+		return fmt.Errorf("MAX_OWN_IMPACT_BPS 不能超过10000")
+	}
+
+	if c.Sniper.SniperInputSize.Sign() < 0 {
+		return fmt.Errorf("SNIPER_INPUT_SIZE 不能小于0")
+	}
+
+	switch c.Sniper.ProfitEstimate {
+	case "pessimistic", "optimistic":
+	default:
+		return fmt.Errorf("PROFIT_ESTIMATE 必须为 pessimistic 或 optimistic")
+	}
+
+	if c.Sniper.CompetitionWindowMs < 0 {
+		return fmt.Errorf("COMPETITION_WINDOW_MS 不能小于0")
+	}
+
+	if c.Sniper.SuccessRateFloor < 0 || c.Sniper.SuccessRateCeiling > 1 || c.Sniper.SuccessRateFloor > c.Sniper.SuccessRateCeiling {
+		return fmt.Errorf("SUCCESS_RATE_FLOOR/SUCCESS_RATE_CEILING 必须满足 0 <= 下限 <= 上限 <= 1")
+	}
+
+	if len(c.Sniper.Strategies) == 0 {
+		return fmt.Errorf("STRATEGIES 至少需要启用一个策略")
+	}
+	for name, weight := range c.Sniper.Strategies {
+		if name == "backrun" {
+			return fmt.Errorf("STRATEGIES 中的策略 backrun 尚未实现")
+		}
+		if !isKnownStrategy(name) {
+			return fmt.Errorf("STRATEGIES 包含未知策略 %q（可选: heuristic, sandwich, liquidation）", name)
+		}
+		if weight <= 0 {
+			return fmt.Errorf("STRATEGIES 中策略 %s 的权重必须大于0", name)
+		}
+	}
+
+	if c.Sniper.MaxTrackedPending < 0 {
+		return fmt.Errorf("MAX_TRACKED_PENDING 不能小于0")
+	}
+
+	if len(c.Sniper.SwapDirections) == 0 {
+		return fmt.Errorf("SWAP_DIRECTIONS 至少需要一个方向")
+	}
+	for _, direction := range c.Sniper.SwapDirections {
+		switch direction {
+		case "buy", "sell", "swap":
+		default:
+			return fmt.Errorf("SWAP_DIRECTIONS 包含无效方向 %q（可选 buy, sell, swap）", direction)
+		}
+	}
+
+	if c.Sniper.OpportunityFilter != "" {
+		if _, err := filter.Compile(c.Sniper.OpportunityFilter, filter.OpportunityFields); err != nil {
+			return fmt.Errorf("OPPORTUNITY_FILTER 无效: %v", err)
+		}
+	}
+
+	if c.Output.TrainingSampleRate <= 0 || c.Output.TrainingSampleRate > 1 {
+		return fmt.Errorf("TRAINING_SAMPLE_RATE 必须在 (0, 1] 之间")
+	}
+	// 训练数据只输出JSONL，避免写出扩展名为 .parquet 的JSONL文件被下游按parquet读取
+	if strings.EqualFold(filepath.Ext(c.Output.TrainingFile), ".parquet") {
+		return fmt.Errorf("TRAINING_FILE 只支持JSONL格式，暂不支持parquet")
+	}
+
+	if (c.Output.WebhookURL != "" || c.Output.TelegramBotToken != "") && c.Output.WebhookTimeoutMs <= 0 {
+		return fmt.Errorf("WEBHOOK_TIMEOUT_MS 必须大于0")
+	}
+
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		return fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+
+	switch c.Logging.Format {
+	case logging.FormatJSON, logging.FormatText:
+	default:
+		return fmt.Errorf("LOG_FORMAT 无效: %q（可选 json, text）", c.Logging.Format)
+	}
+
+	if c.Logging.FunnelLogInterval < 0 {
+		return fmt.Errorf("LOG_FUNNEL_INTERVAL 不能小于0")
+	}
+
+	if c.Output.WebhookRetries < 0 {
+		return fmt.Errorf("WEBHOOK_RETRIES 不能小于0")
+	}
+
+	if c.Output.TelegramBotToken != "" {
+		if c.Output.TelegramChatID == "" {
+			return fmt.Errorf("设置 TELEGRAM_BOT_TOKEN 时必须设置 TELEGRAM_CHAT_ID")
+		}
+		if c.Output.TelegramBatchThreshold <= 0 {
+			return fmt.Errorf("TELEGRAM_BATCH_THRESHOLD 必须大于0")
+		}
+	}
+
+	if c.Output.StatsExportFile != "" {
+		if c.Output.StatsExportInterval <= 0 {
+			return fmt.Errorf("STATS_EXPORT_INTERVAL 必须大于0")
+		}
+		if c.Output.StatsExportMaxMB < 0 || c.Output.StatsExportBackups < 0 {
+			return fmt.Errorf("STATS_EXPORT_MAX_MB/STATS_EXPORT_BACKUPS 不能小于0")
+		}
+	}
+
+	switch c.Output.Format {
+	case "json", "protobuf":
+	default:
+		return fmt.Errorf("OUTPUT_FORMAT 必须为 json 或 protobuf")
+	}
+
+	if c.Execution.BuilderTipBps > 10000 {
+		return fmt.Errorf("BUILDER_TIP_BPS 不能超过10000")
+	}
+
+	if c.Execution.SafetyBuffer.Sign() < 0 {
+		return fmt.Errorf("SAFETY_BUFFER 不能小于0")
+	}
+
+	if c.Execution.OpportunityRateLimit < 0 {
+		return fmt.Errorf("OPPORTUNITY_RATE_LIMIT 不能小于0")
+	}
+
+	switch c.Execution.OpportunityRateMode {
+	case "drop", "queue":
+	default:
+		return fmt.Errorf("OPPORTUNITY_RATE_MODE 必须为 drop 或 queue")
+	}
+
+	if c.Execution.ActionDelayMinMs < 0 || c.Execution.ActionDelayMaxMs < 0 {
+		return fmt.Errorf("ACTION_DELAY_MIN_MS/ACTION_DELAY_MAX_MS 不能小于0")
+	}
+
+	if c.Execution.ActionDelayMinMs > c.Execution.ActionDelayMaxMs {
+		return fmt.Errorf("ACTION_DELAY_MIN_MS 不能大于 ACTION_DELAY_MAX_MS")
+	}
+
+	if c.Execution.DedupWindowMs < 0 {
+		return fmt.Errorf("OPPORTUNITY_DEDUP_WINDOW_MS 不能小于0")
+	}
+
+	if c.Execution.DedupBucketWidth <= 0 {
+		return fmt.Errorf("OPPORTUNITY_DEDUP_BUCKET 必须大于0")
+	}
+
+	if c.Execution.MaxInFlight < 0 {
+		return fmt.Errorf("MAX_IN_FLIGHT 不能小于0")
+	}
+
+	switch c.Execution.InFlightMode {
+	case "drop", "queue":
+	default:
+		return fmt.Errorf("IN_FLIGHT_MODE 必须为 drop 或 queue")
+	}
+
+	if c.Execution.SanityMaxProfit.Sign() < 0 {
+		return fmt.Errorf("SANITY_MAX_PROFIT 不能小于0")
+	}
+
 	return nil
 }
 
 // 辅助函数
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := buildEnv[key]; value != "" {
 		return value
 	}
 	return defaultValue
 }
 
+// getSecret 读取敏感配置，优先使用 KEY_FILE 指向的文件（Docker secrets约定），其次是 KEY 环境变量；
+// 配置了 KEY_FILE 但无法读取时返回错误，不回退到 KEY
+func getSecret(key, defaultValue string) (string, error) {
+	if path := buildEnv[key+"_FILE"]; path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取 %s_FILE 失败: %v", key, err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	return getEnv(key, defaultValue), nil
+}
+
+// getSecretList 读取逗号分隔的敏感地址列表（保留大小写，忽略空项），未配置时只包含 fallback
+func getSecretList(key, fallback string) ([]string, error) {
+	value, err := getSecret(key, "")
+	if err != nil {
+		return []string{fallback}, err
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return []string{fallback}, nil
+	}
+	return items, nil
+}
+
+// splitKeys 拆分逗号或换行分隔的私钥列表
+func splitKeys(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := buildEnv[key]; value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := buildEnv[key]; value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
@@ -117,7 +837,7 @@ func getEnvInt(key string, defaultValue int) int {
 }
 
 func getEnvInt64(key string, defaultValue int64) int64 {
-	if value := os.Getenv(key); value != "" {
+	if value := buildEnv[key]; value != "" {
 		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
 			return intValue
 		}
@@ -126,7 +846,7 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 }
 
 func getEnvUint64(key string, defaultValue uint64) uint64 {
-	if value := os.Getenv(key); value != "" {
+	if value := buildEnv[key]; value != "" {
 		if uintValue, err := strconv.ParseUint(value, 10, 64); err == nil {
 			return uintValue
 		}
@@ -134,8 +854,143 @@ func getEnvUint64(key string, defaultValue uint64) uint64 {
 	return defaultValue
 }
 
+func getEnvFloat64(key string, defaultValue float64) float64 {
+	if value := buildEnv[key]; value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAddresses 解析逗号分隔的地址列表（忽略空项），包含无效地址时返回错误
+func getEnvAddresses(key string) ([]common.Address, error) {
+	var addresses []common.Address
+	for _, item := range strings.Split(buildEnv[key], ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !common.IsHexAddress(item) {
+			return nil, fmt.Errorf("%s 包含无效地址: %q", key, item)
+		}
+		addresses = append(addresses, common.HexToAddress(item))
+	}
+	return addresses, nil
+}
+
+// getEnvList 解析逗号分隔的列表（统一小写，忽略空项）
+func getEnvList(key, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvWeights 解析 "name:weight" 逗号分隔列表，省略权重时为1（解析失败的项权重记为0，由校验报错）
+func getEnvWeights(key, defaultValue string) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, item := range getEnvList(key, defaultValue) {
+		name, weight, hasWeight := strings.Cut(item, ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !hasWeight {
+			weights[name] = 1
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil {
+			value = 0
+		}
+		weights[name] = value
+	}
+	return weights
+}
+
+// isKnownStrategy 检查策略名称是否为已实现的策略
+func isKnownStrategy(name string) bool {
+	switch name {
+	case "heuristic", "sandwich", "liquidation":
+		return true
+	}
+	return false
+}
+
+// getEnvPairs 解析 "代币A:代币B,代币C:代币D" 格式的交易对列表（忽略空项），格式错误时返回错误
+func getEnvPairs(key string) ([][2]common.Address, error) {
+	var pairs [][2]common.Address
+	for _, item := range strings.Split(buildEnv[key], ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s 的交易对格式应为 代币A:代币B: %q", key, item)
+		}
+		a, b := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if !common.IsHexAddress(a) || !common.IsHexAddress(b) {
+			return nil, fmt.Errorf("%s 的交易对包含无效地址: %q", key, item)
+		}
+		pairs = append(pairs, [2]common.Address{common.HexToAddress(a), common.HexToAddress(b)})
+	}
+	return pairs, nil
+}
+
+// getEnvAddressPaths 解析 "地址:文件路径,地址:文件路径" 格式的列表（路径中可以包含冒号）
+func getEnvAddressPaths(key string) map[common.Address]string {
+	paths := make(map[common.Address]string)
+	for _, item := range strings.Split(buildEnv[key], ",") {
+		address, path, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || !common.IsHexAddress(address) || strings.TrimSpace(path) == "" {
+			continue
+		}
+		paths[common.HexToAddress(address)] = strings.TrimSpace(path)
+	}
+	return paths
+}
+
+// getEnvTaxRates 解析 "地址:bps,地址:bps" 格式的代币税率
+func getEnvTaxRates(key string) map[common.Address]uint64 {
+	rates := make(map[common.Address]uint64)
+	for _, item := range strings.Split(buildEnv[key], ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) != 2 || !common.IsHexAddress(parts[0]) {
+			continue
+		}
+		if bps, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64); err == nil {
+			rates[common.HexToAddress(parts[0])] = bps
+		}
+	}
+	return rates
+}
+
+// getEnvPrefixed 收集以 prefix 开头的环境变量（键为去掉前缀后的部分，地址保持原样，符号转为大写）
+func getEnvPrefixed(prefix string) map[string]string {
+	values := make(map[string]string)
+	for name, value := range buildEnv {
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, prefix)
+		if !common.IsHexAddress(key) {
+			key = strings.ToUpper(key)
+		}
+		if value := strings.TrimSpace(value); value != "" {
+			values[key] = value
+		}
+	}
+	return values
+}
+
 func getEnvBigInt(key string, defaultValue string) *big.Int {
-	if value := os.Getenv(key); value != "" {
+	if value := buildEnv[key]; value != "" {
 		if bigIntValue, ok := new(big.Int).SetString(value, 10); ok {
 			return bigIntValue
 		}
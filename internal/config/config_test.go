@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAddressListsRejectInvalidEntries(t *testing.T) {
+	const (
+		weth = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+		usdc = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	)
+
+	tests := []struct {
+		name    string
+		env     []string
+		wantErr bool
+	}{
+		{name: "valid lists", env: []string{"RECIPIENT_DENYLIST=" + weth + ", " + usdc + ",", "PAIR_WHITELIST=" + weth + ":" + usdc}},
+		{name: "empty lists", env: []string{"RECIPIENT_DENYLIST=", "PAIR_WHITELIST="}},
+		{name: "typo in denylist", env: []string{"RECIPIENT_DENYLIST=" + weth + ",0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756C"}, wantErr: true},
+		{name: "invalid allowlist", env: []string{"RECIPIENT_ALLOWLIST=our-contract"}, wantErr: true},
+		{name: "pair missing second token", env: []string{"PAIR_WHITELIST=" + weth}, wantErr: true},
+		{name: "pair with invalid token", env: []string{"PAIR_WHITELIST=" + weth + ":usdc"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempDir(t)
+			writeDotenv(t, append(validEndpoints, tt.env...)...)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.name == "valid lists" && (len(cfg.Sniper.RecipientDenylist) != 2 || len(cfg.Sniper.PairWhitelist) != 1) {
+				t.Errorf("parsed %d denylist entries and %d pairs, want 2 and 1", len(cfg.Sniper.RecipientDenylist), len(cfg.Sniper.PairWhitelist))
+			}
+		})
+	}
+}
+
+func TestUnreadableSecretFileIsFatal(t *testing.T) {
+	for _, key := range []string{"PRIVATE_KEY", "WEBHOOK_SECRET", "ETH_RPC_URL"} {
+		t.Run(key, func(t *testing.T) {
+			useTempDir(t)
+			writeDotenv(t, append(validEndpoints, key+"_FILE=missing-secret")...)
+
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), key+"_FILE") {
+				t.Fatalf("Load() error = %v, want an error naming %s_FILE", err, key)
+			}
+		})
+	}
+}
+
+func TestWebhookSecretReadFromFile(t *testing.T) {
+	useTempDir(t)
+	if err := os.WriteFile("webhook_secret", []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	writeDotenv(t, append(validEndpoints, "WEBHOOK_SECRET=inline", "WEBHOOK_SECRET_FILE=webhook_secret")...)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Output.WebhookSecret != "s3cret" {
+		t.Errorf("WebhookSecret = %q, want the file contents", cfg.Output.WebhookSecret)
+	}
+}
+
+func TestStrategiesValidation(t *testing.T) {
+	tests := []struct {
+		strategies string
+		wantErr    bool
+	}{
+		{strategies: "heuristic:1,sandwich:1.5,liquidation"},
+		{strategies: "backrun", wantErr: true},
+		{strategies: "heuristic,backrun:2", wantErr: true},
+		{strategies: "arbitrage", wantErr: true},
+		{strategies: "heuristic:0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategies, func(t *testing.T) {
+			useTempDir(t)
+			writeDotenv(t, append(validEndpoints, "STRATEGIES="+tt.strategies)...)
+
+			if _, err := Load(); (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSuccessRateBoundsDefaultToNoClamp(t *testing.T) {
+	useTempDir(t)
+	writeDotenv(t, validEndpoints...)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Sniper.SuccessRateFloor != 0 || cfg.Sniper.SuccessRateCeiling != 1 {
+		t.Errorf("success rate bounds = [%v, %v], want [0, 1]", cfg.Sniper.SuccessRateFloor, cfg.Sniper.SuccessRateCeiling)
+	}
+}
+
+func TestOpportunityFilterValidation(t *testing.T) {
+	tests := []struct {
+		filter  string
+		wantErr bool
+	}{
+		{filter: "net_profit > 5e15 && competition_count <= 2 && price_impact_bps < 300"},
+		{filter: "net_profit > 'high'", wantErr: true},
+		{filter: "net_profit >", wantErr: true},
+		{filter: "unknown_field == 1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			useTempDir(t)
+			writeDotenv(t, append(validEndpoints, "OPPORTUNITY_FILTER="+tt.filter)...)
+
+			if _, err := Load(); (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrainingFileRejectsParquet(t *testing.T) {
+	useTempDir(t)
+	writeDotenv(t, append(validEndpoints, "TRAINING_FILE=samples.parquet")...)
+
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() accepted a parquet training file")
+	}
+}
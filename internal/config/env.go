@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+var (
+	envMu sync.Mutex
+	// buildEnv build 读取的候选环境变量（进程环境叠加.env文件），只在 loadFromEnv 期间有效
+	buildEnv map[string]string
+	// dotenvApplied 上次从.env文件写入进程环境的键值（用于识别.env中已删除的键）
+	dotenvApplied map[string]string
+)
+
+// loadFromEnv 读取.env文件构建候选环境并验证配置，验证通过后才写入进程环境；
+// 验证失败时进程环境保持不变。进程本身设置的环境变量优先于.env文件（与启动时一致），
+// 上次从.env写入而本次已删除的键会被清除
+func loadFromEnv() (*Config, error) {
+	envMu.Lock()
+	defer envMu.Unlock()
+
+	file, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("⚠️ 读取.env文件失败，只使用进程环境变量: %v", err)
+	}
+
+	// 进程环境去掉上次从.env写入的值，再叠加本次的.env
+	candidate := environMap()
+	for key, value := range dotenvApplied {
+		if candidate[key] == value {
+			delete(candidate, key)
+		}
+	}
+	applied := make(map[string]string, len(file))
+	for key, value := range file {
+		if _, exists := candidate[key]; !exists {
+			candidate[key] = value
+			applied[key] = value
+		}
+	}
+
+	buildEnv = candidate
+	cfg := build()
+	buildEnv = nil
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	for key, value := range dotenvApplied {
+		if _, exists := applied[key]; !exists && os.Getenv(key) == value {
+			os.Unsetenv(key)
+		}
+	}
+	for key, value := range applied {
+		os.Setenv(key, value)
+	}
+	dotenvApplied = applied
+	return cfg, nil
+}
+
+// environMap 当前进程环境变量
+func environMap() map[string]string {
+	env := make(map[string]string)
+	for _, item := range os.Environ() {
+		if key, value, ok := strings.Cut(item, "="); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// Manager 运行时配置管理器，支持热重载
+// 重载时先完整验证新配置，验证失败则保留当前配置，保证运行中的配置始终有效
+type Manager struct {
+	current  atomic.Pointer[Config]
+	reloadMu sync.Mutex
+	loader   func() (*Config, error)
+}
+
+// NewManager 创建配置管理器
+func NewManager(cfg *Config) *Manager {
+	m := &Manager{loader: reloadFromEnv}
+	m.current.Store(cfg)
+	return m
+}
+
+// Current 获取当前生效的配置
+func (m *Manager) Current() *Config {
+	return m.current.Load()
+}
+
+// Reload 重新加载配置，新配置无效时保留当前配置并返回错误
+func (m *Manager) Reload() error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	cfg, err := m.loader()
+	if err != nil {
+		log.Printf("⚠️ 配置重载失败，继续使用当前配置: %v", err)
+		return err
+	}
+
+	cfg.Version = m.current.Load().Version + 1
+	m.current.Store(cfg)
+	log.Printf("✅ 配置重载成功 (版本: %d)", cfg.Version)
+	return nil
+}
+
+// Amend 在当前配置的副本上修改并替换（版本号不变），用于撤销重载后未能生效的配置项
+// （副本为浅拷贝，fn 只应修改值字段）
+func (m *Manager) Amend(fn func(cfg *Config)) {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	cfg := *m.current.Load()
+	fn(&cfg)
+	m.current.Store(&cfg)
+}
+
+// reloadFromEnv 重新读取.env文件并验证，验证通过后才更新进程环境（.env中删除的键随之清除）
+func reloadFromEnv() (*Config, error) {
+	cfg, err := loadFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("新配置无效: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDotenv 在当前目录写入.env文件，测试结束后清除写入进程环境的键
+func writeDotenv(t *testing.T, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(".", ".env"), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range lines {
+		key, _, _ := strings.Cut(line, "=")
+		t.Cleanup(func() { os.Unsetenv(key) })
+	}
+}
+
+// useTempDir 切换到临时目录并重置.env写入记录
+func useTempDir(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	dotenvApplied = nil
+	t.Cleanup(func() { dotenvApplied = nil })
+}
+
+var validEndpoints = []string{
+	"ETH_WSS_URL=wss://node.example/ws",
+	"ETH_RPC_URL=https://node.example/rpc",
+}
+
+func TestReloadKeepsRunningConfigOnInvalidEnv(t *testing.T) {
+	useTempDir(t)
+	writeDotenv(t, append(validEndpoints, "MIN_PROFIT=5")...)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	manager := NewManager(cfg)
+
+	writeDotenv(t, append(validEndpoints, "MIN_PROFIT=0")...)
+	if err := manager.Reload(); err == nil {
+		t.Fatal("Reload() accepted MIN_PROFIT=0")
+	}
+	if manager.Current() != cfg {
+		t.Fatal("Reload() replaced the running config with an invalid one")
+	}
+	if got := os.Getenv("MIN_PROFIT"); got != "5" {
+		t.Errorf("MIN_PROFIT in process env = %q after a rejected reload, want 5", got)
+	}
+
+	writeDotenv(t, append(validEndpoints, "MIN_PROFIT=7")...)
+	if err := manager.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	current := manager.Current()
+	if current.Sniper.MinProfit.Int64() != 7 || current.Version != 2 {
+		t.Errorf("Reload() = MinProfit %s version %d, want 7 version 2", current.Sniper.MinProfit, current.Version)
+	}
+}
+
+func TestReloadUnsetsKeysRemovedFromDotenv(t *testing.T) {
+	useTempDir(t)
+	writeDotenv(t, append(validEndpoints, "SPAM_MIN_SENDERS=3")...)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Sniper.SpamMinSenders != 3 {
+		t.Fatalf("SpamMinSenders = %d, want 3", cfg.Sniper.SpamMinSenders)
+	}
+
+	manager := NewManager(cfg)
+	writeDotenv(t, validEndpoints...)
+	if err := manager.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := manager.Current().Sniper.SpamMinSenders; got != 0 {
+		t.Errorf("SpamMinSenders = %d after removing it from .env, want default 0", got)
+	}
+	if _, exists := os.LookupEnv("SPAM_MIN_SENDERS"); exists {
+		t.Error("SPAM_MIN_SENDERS still set in process env after removing it from .env")
+	}
+}
+
+func TestProcessEnvTakesPrecedenceOverDotenv(t *testing.T) {
+	useTempDir(t)
+	t.Setenv("MIN_PROFIT", "9")
+	writeDotenv(t, append(validEndpoints, "MIN_PROFIT=5")...)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Sniper.MinProfit.Int64() != 9 {
+		t.Fatalf("MinProfit = %s, want process value 9", cfg.Sniper.MinProfit)
+	}
+
+	// 重载同样不会用.env覆盖进程本身的环境变量
+	manager := NewManager(cfg)
+	writeDotenv(t, append(validEndpoints, "MIN_PROFIT=6")...)
+	if err := manager.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := manager.Current().Sniper.MinProfit.Int64(); got != 9 {
+		t.Errorf("MinProfit = %d after reload, want process value 9", got)
+	}
+}
+
+func TestAmendKeepsVersionAndOtherFields(t *testing.T) {
+	useTempDir(t)
+	writeDotenv(t, append(validEndpoints, "MIN_PROFIT=5")...)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	manager := NewManager(cfg)
+	manager.Amend(func(cfg *Config) { cfg.Ethereum.RPCURL = "https://fallback.example/rpc" })
+
+	current := manager.Current()
+	if current.Ethereum.RPCURL != "https://fallback.example/rpc" {
+		t.Errorf("RPCURL = %q after Amend", current.Ethereum.RPCURL)
+	}
+	if current.Version != cfg.Version || current.Ethereum.WSSURL != cfg.Ethereum.WSSURL {
+		t.Errorf("Amend changed version %d -> %d or WSSURL", cfg.Version, current.Version)
+	}
+	if cfg.Ethereum.RPCURL != "https://node.example/rpc" {
+		t.Error("Amend modified the previous config in place")
+	}
+}
@@ -0,0 +1,225 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// uniswapV2RouterABI Uniswap V2 路由合约的交换方法（只包含解码需要的部分）
+const uniswapV2RouterABI = `[
+	{"name":"swapExactETHForTokens","type":"function","stateMutability":"payable",
+	 "inputs":[{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],
+	 "outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapExactTokensForETH","type":"function","stateMutability":"nonpayable",
+	 "inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],
+	 "outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapExactTokensForTokens","type":"function","stateMutability":"nonpayable",
+	 "inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],
+	 "outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapETHForExactTokens","type":"function","stateMutability":"payable",
+	 "inputs":[{"name":"amountOut","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],
+	 "outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapTokensForExactETH","type":"function","stateMutability":"nonpayable",
+	 "inputs":[{"name":"amountOut","type":"uint256"},{"name":"amountInMax","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],
+	 "outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"name":"swapTokensForExactTokens","type":"function","stateMutability":"nonpayable",
+	 "inputs":[{"name":"amountOut","type":"uint256"},{"name":"amountInMax","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],
+	 "outputs":[{"name":"amounts","type":"uint256[]"}]}
+]`
+
+// uniswapV3RouterABI Uniswap V3 SwapRouter 的交换方法（参数为单个结构体）
+const uniswapV3RouterABI = `[
+	{"name":"exactInputSingle","type":"function","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"fee","type":"uint24"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountIn","type":"uint256"},{"name":"amountOutMinimum","type":"uint256"},{"name":"sqrtPriceLimitX96","type":"uint160"}]}],
+	 "outputs":[{"name":"amountOut","type":"uint256"}]},
+	{"name":"exactInput","type":"function","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[{"name":"path","type":"bytes"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountIn","type":"uint256"},{"name":"amountOutMinimum","type":"uint256"}]}],
+	 "outputs":[{"name":"amountOut","type":"uint256"}]},
+	{"name":"exactOutputSingle","type":"function","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"fee","type":"uint24"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountOut","type":"uint256"},{"name":"amountInMaximum","type":"uint256"},{"name":"sqrtPriceLimitX96","type":"uint160"}]}],
+	 "outputs":[{"name":"amountIn","type":"uint256"}]},
+	{"name":"exactOutput","type":"function","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[{"name":"path","type":"bytes"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountOut","type":"uint256"},{"name":"amountInMaximum","type":"uint256"}]}],
+	 "outputs":[{"name":"amountIn","type":"uint256"}]}
+]`
+
+// routerABIs 已解析的路由合约ABI（按方法ID依次查找）
+var routerABIs = []abi.ABI{
+	mustParseABI(uniswapV2RouterABI),
+	mustParseABI(uniswapV3RouterABI),
+}
+
+// isSwapShaped 方法参数（含结构体字段）是否包含交换路径：path，或 tokenIn 和 tokenOut
+func isSwapShaped(method *abi.Method) bool {
+	names := make(map[string]bool)
+	for _, input := range method.Inputs {
+		names[input.Name] = true
+		if input.Type.T == abi.TupleTy {
+			for _, name := range input.Type.TupleRawNames {
+				names[name] = true
+			}
+		}
+	}
+	return names["path"] || (names["tokenIn"] && names["tokenOut"])
+}
+
+// errMalformedCalldata calldata 无法按ABI解码
+var errMalformedCalldata = errors.New("malformed calldata")
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid router ABI: %v", err))
+	}
+	return parsed
+}
+
+// swapArgs 交换方法的参数（不存在的参数为nil/零值，如 swapExactETHForTokens 没有 amountIn）
+type swapArgs struct {
+	AmountIn     *big.Int
+	AmountOutMin *big.Int
+	Path         []common.Address // 按成交方向（输入代币在前）
+	To           common.Address
+	Deadline     *big.Int
+
+	AmountOut   *big.Int // 精确输出交换的目标输出金额
+	AmountInMax *big.Int // 精确输出交换的最大输入金额
+	FeeTier     uint32   // V3 第一跳的手续费档位（百万分比，如 3000 = 0.3%）
+
+	Params []types.DecodedParameter // 按ABI顺序的全部参数（名称+值）
+}
+
+// v3HopSize V3 打包路径中每一跳的长度：20字节代币 + 3字节手续费
+const v3HopSize = common.AddressLength + 3
+
+// decodeV3Path 解析 V3 打包路径（代币、手续费、代币……），返回代币列表和每一跳的手续费
+func decodeV3Path(packed []byte) ([]common.Address, []uint32, error) {
+	if len(packed) < common.AddressLength+v3HopSize || (len(packed)-common.AddressLength)%v3HopSize != 0 {
+		return nil, nil, fmt.Errorf("%w: V3 路径长度 %d 无效", errMalformedCalldata, len(packed))
+	}
+
+	tokens := []common.Address{common.BytesToAddress(packed[:common.AddressLength])}
+	var fees []uint32
+	for offset := common.AddressLength; offset < len(packed); offset += v3HopSize {
+		fee := packed[offset : offset+3]
+		fees = append(fees, uint32(fee[0])<<16|uint32(fee[1])<<8|uint32(fee[2]))
+		tokens = append(tokens, common.BytesToAddress(packed[offset+3:offset+v3HopSize]))
+	}
+	return tokens, fees, nil
+}
+
+// lookupMethod 按方法ID在已知路由合约ABI中查找方法
+func lookupMethod(id []byte) (*abi.Method, error) {
+	var lastErr error
+	for _, parsed := range routerABIs {
+		method, err := parsed.MethodById(id)
+		if err == nil {
+			return method, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// unpackSwap 按路由合约ABI解码交换calldata（路由注册了额外ABI时优先使用）
+func (c *Chain) unpackSwap(router common.Address, data []byte) (*swapArgs, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("%w: calldata过短", errMalformedCalldata)
+	}
+	method, custom := c.customSwapMethod(router, data[:4])
+	if !custom {
+		var err error
+		method, err = lookupMethod(data[:4])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errMalformedCalldata, err)
+		}
+	}
+
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errMalformedCalldata, method.Name, err)
+	}
+
+	// V3 方法的参数是单个结构体，展开为与 V2 相同的按名称查找
+	named := make(map[string]interface{})
+	params := make([]types.DecodedParameter, 0, len(method.Inputs))
+	for i, input := range method.Inputs {
+		if input.Type.T != abi.TupleTy {
+			named[input.Name] = values[i]
+			params = append(params, types.NewDecodedParameter(input.Name, input.Type.String(), values[i]))
+			continue
+		}
+		tuple := reflect.ValueOf(values[i])
+		for j, name := range input.Type.TupleRawNames {
+			named[name] = tuple.Field(j).Interface()
+			params = append(params, types.NewDecodedParameter(input.Name+"."+name, input.Type.TupleElems[j].String(), named[name]))
+		}
+	}
+
+	args := &swapArgs{Params: params}
+	var tokenIn, tokenOut common.Address
+	for name, value := range named {
+		switch name {
+		case "amountIn":
+			args.AmountIn, _ = value.(*big.Int)
+		case "amountOutMin", "amountOutMinimum":
+			args.AmountOutMin, _ = value.(*big.Int)
+		case "amountOut":
+			args.AmountOut, _ = value.(*big.Int)
+		case "amountInMax", "amountInMaximum":
+			args.AmountInMax, _ = value.(*big.Int)
+		case "path":
+			switch path := value.(type) {
+			case []common.Address:
+				args.Path = path
+			case []byte:
+				tokens, fees, err := decodeV3Path(path)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", method.Name, err)
+				}
+				// exactOutput 的路径从输出代币开始编码，统一反转为成交方向
+				if method.Name == "exactOutput" {
+					reverseAddresses(tokens)
+					args.FeeTier = fees[len(fees)-1]
+				} else {
+					args.FeeTier = fees[0]
+				}
+				args.Path = tokens
+			}
+		case "to", "recipient":
+			args.To, _ = value.(common.Address)
+		case "deadline":
+			args.Deadline, _ = value.(*big.Int)
+		case "tokenIn":
+			tokenIn, _ = value.(common.Address)
+		case "tokenOut":
+			tokenOut, _ = value.(common.Address)
+		case "fee":
+			if fee, ok := value.(*big.Int); ok {
+				args.FeeTier = uint32(fee.Uint64())
+			}
+		}
+	}
+	if args.Path == nil && tokenIn != (common.Address{}) {
+		args.Path = []common.Address{tokenIn, tokenOut}
+	}
+	if len(args.Path) < 2 {
+		return nil, fmt.Errorf("%w: %s 路径长度为 %d", errMalformedCalldata, method.Name, len(args.Path))
+	}
+	return args, nil
+}
+
+// reverseAddresses 原地反转地址列表
+func reverseAddresses(addresses []common.Address) {
+	for i, j := 0, len(addresses)-1; i < j; i, j = i+1, j-1 {
+		addresses[i], addresses[j] = addresses[j], addresses[i]
+	}
+}
@@ -0,0 +1,163 @@
+package decoder
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// uniswapV3Router 主网 Uniswap V3 SwapRouter
+var uniswapV3Router = common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564")
+
+func TestDecodeV3SwapFromCalldata(t *testing.T) {
+	recipient := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+	tests := []struct {
+		name         string
+		value        *big.Int
+		calldata     string
+		amountIn     string
+		amountOutMin string
+		path         []common.Address
+		feeTier      uint32
+		exactOutput  bool
+		direction    string
+		tokenIn      common.Address
+		tokenOut     common.Address
+	}{
+		{
+			// exactInputSingle(WETH -> USDC, fee 500, 1 ETH, 1800 USDC)，附带 1 ETH
+			name:         "exactInputSingle",
+			value:        big.NewInt(1e18),
+			calldata:     "0x414bf389000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb4800000000000000000000000000000000000000000000000000000000000001f40000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f1000000000000000000000000000000000000000000000000000de0b6b3a7640000000000000000000000000000000000000000000000000000000000006b49d2000000000000000000000000000000000000000000000000000000000000000000",
+			amountIn:     "1000000000000000000",
+			amountOutMin: "1800000000",
+			path:         []common.Address{weth, usdc},
+			feeTier:      500,
+			direction:    "buy",
+			tokenIn:      types.NativeToken,
+			tokenOut:     usdc,
+		},
+		{
+			// exactInput(USDC -3000- WETH -500- DAI, 5000 USDC, 4975 DAI)
+			name:         "exactInput",
+			value:        big.NewInt(0),
+			calldata:     "0xc04b8d59000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f100000000000000000000000000000000000000000000000000000000012a05f20000000000000000000000000000000000000000000000010db1fe8d52005c00000000000000000000000000000000000000000000000000000000000000000042a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48000bb8c02aaa39b223fe8d0a0e5c4f27ead9083c756cc20001f46b175474e89094c44da98b954eedeac495271d0f000000000000000000000000000000000000000000000000000000000000",
+			amountIn:     "5000000000",
+			amountOutMin: "4975000000000000000000",
+			path:         []common.Address{usdc, weth, dai},
+			feeTier:      3000,
+			direction:    "swap",
+			tokenIn:      usdc,
+			tokenOut:     dai,
+		},
+		{
+			// exactOutputSingle(USDT -> WETH, fee 3000, 1 ETH 输出, 最多 1850 USDT)
+			name:         "exactOutputSingle",
+			value:        big.NewInt(0),
+			calldata:     "0xdb3e2198000000000000000000000000dac17f958d2ee523a2206206994597c13d831ec7000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc20000000000000000000000000000000000000000000000000000000000000bb80000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f1000000000000000000000000000000000000000000000000000de0b6b3a7640000000000000000000000000000000000000000000000000000000000006e44c2800000000000000000000000000000000000000000000000000000000000000000",
+			amountIn:     "1850000000",
+			amountOutMin: "1000000000000000000",
+			path:         []common.Address{usdt, weth},
+			feeTier:      3000,
+			exactOutput:  true,
+			direction:    "sell",
+			tokenIn:      usdt,
+			tokenOut:     types.NativeToken,
+		},
+		{
+			// exactOutput(路径按输出方向编码：DAI -100- USDC -500- WETH，1000 DAI 输出, 最多 0.6 ETH)
+			name:         "exactOutput",
+			value:        big.NewInt(6e17),
+			calldata:     "0xf28c0498000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f10000000000000000000000000000000000000000000000003635c9adc5dea000000000000000000000000000000000000000000000000000000853a0d2313c000000000000000000000000000000000000000000000000000000000000000000426b175474e89094c44da98b954eedeac495271d0f000064a0b86991c6218b36c1d19d4a2e9eb0ce3606eb480001f4c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2000000000000000000000000000000000000000000000000000000000000",
+			amountIn:     "600000000000000000",
+			amountOutMin: "1000000000000000000000",
+			path:         []common.Address{weth, usdc, dai},
+			feeTier:      500,
+			exactOutput:  true,
+			direction:    "buy",
+			tokenIn:      types.NativeToken,
+			tokenOut:     dai,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := NewDecoder(mainnetChain(t)).DecodeTransaction(swapTx(t, uniswapV3Router, tt.value, tt.calldata))
+			if decoded == nil {
+				t.Fatal("DecodeTransaction() = nil")
+			}
+			if got := decoded.AmountIn.String(); got != tt.amountIn {
+				t.Errorf("AmountIn = %s, want %s", got, tt.amountIn)
+			}
+			if got := decoded.AmountOutMin.String(); got != tt.amountOutMin {
+				t.Errorf("AmountOutMin = %s, want %s", got, tt.amountOutMin)
+			}
+			if !reflect.DeepEqual(decoded.Path, tt.path) {
+				t.Errorf("Path = %v, want %v", decoded.Path, tt.path)
+			}
+			if decoded.FeeTier != tt.feeTier {
+				t.Errorf("FeeTier = %d, want %d", decoded.FeeTier, tt.feeTier)
+			}
+			if decoded.ExactOutput != tt.exactOutput {
+				t.Errorf("ExactOutput = %v, want %v", decoded.ExactOutput, tt.exactOutput)
+			}
+			if tt.exactOutput && (decoded.AmountInMax == nil || decoded.AmountInMax.String() != tt.amountIn) {
+				t.Errorf("AmountInMax = %v, want %s", decoded.AmountInMax, tt.amountIn)
+			}
+			if decoded.SwapDirection != tt.direction || decoded.TokenIn != tt.tokenIn || decoded.TokenOut != tt.tokenOut {
+				t.Errorf("direction %s, %s -> %s; want %s, %s -> %s", decoded.SwapDirection, decoded.TokenIn.Hex(), decoded.TokenOut.Hex(),
+					tt.direction, tt.tokenIn.Hex(), tt.tokenOut.Hex())
+			}
+			if decoded.Recipient != recipient || decoded.Deadline.Int64() != 1700000000 {
+				t.Errorf("to = %s, deadline = %s", decoded.Recipient.Hex(), decoded.Deadline)
+			}
+		})
+	}
+}
+
+func TestDecodeV3Path(t *testing.T) {
+	tests := []struct {
+		name   string
+		packed string
+		tokens []common.Address
+		fees   []uint32
+	}{
+		{
+			name:   "single hop",
+			packed: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc20001f4a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			tokens: []common.Address{weth, usdc},
+			fees:   []uint32{500},
+		},
+		{
+			name:   "two hops",
+			packed: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48000bb8c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2002710dac17f958d2ee523a2206206994597c13d831ec7",
+			tokens: []common.Address{usdc, weth, usdt},
+			fees:   []uint32{3000, 10000},
+		},
+		{name: "token only", packed: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"},
+		{name: "truncated hop", packed: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc20001f4a0b86991c6218b36c1d19d4a2e9eb0ce3606eb"},
+		{name: "empty", packed: "0x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, fees, err := decodeV3Path(hexutil.MustDecode(tt.packed))
+			if tt.tokens == nil {
+				if !errors.Is(err, errMalformedCalldata) {
+					t.Fatalf("decodeV3Path() error = %v, want errMalformedCalldata", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tokens, tt.tokens) || !reflect.DeepEqual(fees, tt.fees) {
+				t.Errorf("decodeV3Path() = %v, %v; want %v, %v", tokens, fees, tt.tokens, tt.fees)
+			}
+		})
+	}
+}
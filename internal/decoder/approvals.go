@@ -0,0 +1,134 @@
+package decoder
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MethodApprove ERC20 approve(address spender, uint256 amount) 方法签名
+var MethodApprove = []byte{0x09, 0x5e, 0xa7, 0xb3}
+
+// approvalKey 授权所有者 + 代币 + 被授权的路由合约
+type approvalKey struct {
+	owner  common.Address
+	token  common.Address
+	router common.Address
+}
+
+// ApprovalTracker 跟踪对路由合约的代币授权：代币上线时，同一发送者先授权路由、
+// 随后在新交易对上交换是很强的领先信号，后续交换标记为 LeadingApproval
+type ApprovalTracker struct {
+	chain      *Chain
+	mu         sync.Mutex
+	window     time.Duration
+	approvals  map[approvalKey]time.Time
+	lastPrune  time.Time
+	observed   int64 // 记录的授权数
+	correlated int64 // 与后续交换关联的授权数
+}
+
+// NewApprovalTracker 创建授权跟踪器
+func NewApprovalTracker(chain *Chain, window time.Duration) *ApprovalTracker {
+	return &ApprovalTracker{
+		chain:     chain,
+		window:    window,
+		approvals: make(map[approvalKey]time.Time),
+		lastPrune: time.Now(),
+	}
+}
+
+// IsRouterApproval 判断是否为对已支持路由合约的非零授权，返回代币和路由地址
+func (c *Chain) IsRouterApproval(tx *types.Transaction) (common.Address, common.Address, bool) {
+	if tx.To == nil || len(tx.Data) < 4+32*2 || !bytes.Equal(tx.Data[:4], MethodApprove) {
+		return common.Address{}, common.Address{}, false
+	}
+	spender := readAddress(tx.Data, 0)
+	if !c.IsSupportedContract(spender) {
+		return common.Address{}, common.Address{}, false
+	}
+	if amount := readUint256(tx.Data, 1); amount == nil || amount.Cmp(big.NewInt(0)) == 0 {
+		// 授权为0是撤销授权
+		return common.Address{}, common.Address{}, false
+	}
+	return *tx.To, spender, true
+}
+
+// Observe 记录对路由合约的授权，不是授权交易时返回 false
+func (t *ApprovalTracker) Observe(tx *types.Transaction) bool {
+	if t == nil {
+		return false
+	}
+	token, router, ok := t.chain.IsRouterApproval(tx)
+	if !ok {
+		return false
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneLocked(now)
+	t.approvals[approvalKey{owner: tx.From, token: token, router: router}] = now
+	t.observed++
+	return true
+}
+
+// Leading 检查交换的发送者是否在窗口内授权过该路由使用路径中的代币（关联后清除该授权）
+func (t *ApprovalTracker) Leading(decodedTx *types.DecodedTransaction) bool {
+	if t == nil {
+		return false
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, token := range decodedTx.Path {
+		key := approvalKey{owner: decodedTx.Transaction.From, token: token, router: decodedTx.TargetContract}
+		seen, exists := t.approvals[key]
+		if !exists {
+			continue
+		}
+		delete(t.approvals, key)
+		if now.Sub(seen) <= t.window {
+			t.correlated++
+			return true
+		}
+	}
+	return false
+}
+
+// pruneLocked 定期清理窗口外的授权（调用方需持有 t.mu）
+func (t *ApprovalTracker) pruneLocked(now time.Time) {
+	if now.Sub(t.lastPrune) < t.window {
+		return
+	}
+	t.lastPrune = now
+	for key, seen := range t.approvals {
+		if now.Sub(seen) > t.window {
+			delete(t.approvals, key)
+		}
+	}
+}
+
+// GetStats 获取统计信息
+func (t *ApprovalTracker) GetStats() map[string]interface{} {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return map[string]interface{}{
+		"tracked":    len(t.approvals),
+		"observed":   t.observed,
+		"correlated": t.correlated,
+	}
+}
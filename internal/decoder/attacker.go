@@ -0,0 +1,199 @@
+package decoder
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// poolKey 交易对键：路由合约 + 第一跳交易对（代币顺序无关）
+type poolKey struct {
+	router common.Address
+	token0 common.Address
+	token1 common.Address
+}
+
+// poolSwap 交易对上的一笔pending交换
+type poolSwap struct {
+	hash      common.Hash
+	from      common.Address
+	tokenIn   common.Address
+	direction string
+	amountIn  *big.Int
+	tipCap    *big.Int // 小费上限（legacy交易为Gas价格）
+	feeCap    *big.Int // 费用上限（legacy交易为Gas价格）
+	seen      time.Time
+}
+
+// PoolIndex 按交易对索引窗口内的pending交换，用于识别夹子攻击的抢跑交易：
+// 与同一交易对上已pending的其他发送者交易同向、有效小费更高（排在其前面）、
+// 且规模不小于其 sizeRatio 倍的交换视为攻击者交易（LikelyAttacker）
+type PoolIndex struct {
+	mu        sync.Mutex
+	window    time.Duration
+	sizeRatio *big.Float
+	baseFee   *big.Int // 最新区块的基础费用（未知时按小费上限比较）
+	pools     map[poolKey][]*poolSwap
+	lastPrune time.Time
+	flagged   int64
+}
+
+// NewPoolIndex 创建交易对pending索引
+func NewPoolIndex(window time.Duration, sizeRatio float64) *PoolIndex {
+	return &PoolIndex{
+		window:    window,
+		sizeRatio: big.NewFloat(sizeRatio),
+		pools:     make(map[poolKey][]*poolSwap),
+		lastPrune: time.Now(),
+	}
+}
+
+// poolKeyOf 计算交换的交易对键（路径不足两跳时返回 false）
+func poolKeyOf(decodedTx *types.DecodedTransaction) (poolKey, bool) {
+	if len(decodedTx.Path) < 2 {
+		return poolKey{}, false
+	}
+	path := types.PoolPath(decodedTx.Path[:2], decodedTx.Transaction.ChainID)
+	a, b := path[0], path[1]
+	if b.Hex() < a.Hex() {
+		a, b = b, a
+	}
+	return poolKey{router: decodedTx.TargetContract, token0: a, token1: b}, true
+}
+
+// Observe 将交换加入索引，并返回它是否疑似抢跑同一交易对上的pending交易
+func (p *PoolIndex) Observe(decodedTx *types.DecodedTransaction) bool {
+	if p == nil || decodedTx.AmountIn == nil || decodedTx.AmountIn.Sign() <= 0 {
+		return false
+	}
+	key, ok := poolKeyOf(decodedTx)
+	if !ok {
+		return false
+	}
+
+	tx := decodedTx.Transaction
+	tipCap, feeCap := tx.GasPrice, tx.GasPrice
+	if tx.RawTx != nil {
+		tipCap, feeCap = tx.RawTx.GasTipCap(), tx.RawTx.GasFeeCap()
+	}
+	if tipCap == nil || feeCap == nil {
+		tipCap, feeCap = big.NewInt(0), big.NewInt(0)
+	}
+	swap := &poolSwap{
+		hash:      tx.Hash,
+		from:      tx.From,
+		tokenIn:   decodedTx.Path[0],
+		direction: decodedTx.SwapDirection,
+		amountIn:  decodedTx.AmountIn,
+		tipCap:    tipCap,
+		feeCap:    feeCap,
+		seen:      time.Now(),
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pruneLocked(swap.seen)
+
+	attacker := false
+	live := p.pools[key][:0]
+	for _, pending := range p.pools[key] {
+		if swap.seen.Sub(pending.seen) > p.window {
+			continue
+		}
+		live = append(live, pending)
+		if !attacker && p.frontruns(swap, pending) {
+			attacker = true
+		}
+	}
+	p.pools[key] = append(live, swap)
+
+	if attacker {
+		p.flagged++
+	}
+	return attacker
+}
+
+// frontruns 判断 swap 是否为针对 victim 的抢跑：不同发送者、同向同输入代币、有效小费更高、规模足够大
+// （调用方需持有 p.mu）
+func (p *PoolIndex) frontruns(swap, victim *poolSwap) bool {
+	if swap.from == victim.from || swap.hash == victim.hash {
+		return false
+	}
+	if swap.direction != victim.direction || swap.tokenIn != victim.tokenIn {
+		return false
+	}
+	if swap.priorityFee(p.baseFee).Cmp(victim.priorityFee(p.baseFee)) <= 0 {
+		return false
+	}
+
+	minSize := new(big.Float).Mul(new(big.Float).SetInt(victim.amountIn), p.sizeRatio)
+	return new(big.Float).SetInt(swap.amountIn).Cmp(minSize) >= 0
+}
+
+// priorityFee 按基础费用计算的有效小费：min(tipCap, feeCap - baseFee)，区块按它排序；
+// 基础费用未知时取小费上限
+func (s *poolSwap) priorityFee(baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return s.tipCap
+	}
+	headroom := new(big.Int).Sub(s.feeCap, baseFee)
+	if headroom.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	if headroom.Cmp(s.tipCap) < 0 {
+		return headroom
+	}
+	return s.tipCap
+}
+
+// SetBaseFee 更新最新区块的基础费用
+func (p *PoolIndex) SetBaseFee(baseFee *big.Int) {
+	if p == nil || baseFee == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.baseFee = new(big.Int).Set(baseFee)
+}
+
+// pruneLocked 定期清理窗口外的记录（调用方需持有 p.mu）
+func (p *PoolIndex) pruneLocked(now time.Time) {
+	if now.Sub(p.lastPrune) < p.window {
+		return
+	}
+	p.lastPrune = now
+
+	for key, swaps := range p.pools {
+		live := swaps[:0]
+		for _, swap := range swaps {
+			if now.Sub(swap.seen) <= p.window {
+				live = append(live, swap)
+			}
+		}
+		if len(live) == 0 {
+			delete(p.pools, key)
+		} else {
+			p.pools[key] = live
+		}
+	}
+}
+
+// GetStats 获取统计信息
+func (p *PoolIndex) GetStats() map[string]interface{} {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return map[string]interface{}{
+		"pools":   len(p.pools),
+		"flagged": p.flagged,
+	}
+}
@@ -0,0 +1,103 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+const gwei = 1e9
+
+// feeSwap WETH→USDC 买入；tipCap 为 0 时构造 legacy 交易（Gas价格为 feeCap）
+func feeSwap(from byte, tipCap, feeCap int64) *types.DecodedTransaction {
+	sender := common.BytesToAddress([]byte{from})
+	tx := &types.Transaction{
+		Hash:     common.BytesToHash([]byte{from, byte(tipCap / gwei), byte(feeCap / gwei)}),
+		From:     sender,
+		GasPrice: big.NewInt(feeCap),
+		ChainID:  big.NewInt(1),
+	}
+	if tipCap > 0 {
+		tx.RawTx = ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			GasTipCap: big.NewInt(tipCap),
+			GasFeeCap: big.NewInt(feeCap),
+		})
+	}
+	return &types.DecodedTransaction{
+		Transaction:    tx,
+		TargetContract: uniswapV2Router,
+		Path: []common.Address{
+			common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+			common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		},
+		AmountIn:      big.NewInt(1e18),
+		SwapDirection: "buy",
+	}
+}
+
+func TestPoolIndexComparesEffectivePriorityFee(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseFee  *big.Int
+		victim   *types.DecodedTransaction
+		swap     *types.DecodedTransaction
+		attacker bool
+	}{
+		{
+			// 受害者费用上限高但小费低：按有效小费攻击者排在前面
+			name:     "higher tip under a lower fee cap",
+			baseFee:  big.NewInt(30 * gwei),
+			victim:   feeSwap(1, 1*gwei, 100*gwei),
+			swap:     feeSwap(2, 5*gwei, 50*gwei),
+			attacker: true,
+		},
+		{
+			// 小费上限更高，但费用上限只比基础费用高 1 gwei
+			name:     "tip capped by base fee",
+			baseFee:  big.NewInt(30 * gwei),
+			victim:   feeSwap(1, 2*gwei, 100*gwei),
+			swap:     feeSwap(2, 10*gwei, 31*gwei),
+			attacker: false,
+		},
+		{
+			name:     "tip capped by base fee, base fee unknown",
+			victim:   feeSwap(1, 2*gwei, 100*gwei),
+			swap:     feeSwap(2, 10*gwei, 31*gwei),
+			attacker: true,
+		},
+		{
+			// legacy交易的有效小费为 Gas价格 - 基础费用
+			name:     "legacy attacker",
+			baseFee:  big.NewInt(30 * gwei),
+			victim:   feeSwap(1, 2*gwei, 100*gwei),
+			swap:     feeSwap(2, 0, 40*gwei),
+			attacker: true,
+		},
+		{
+			name:     "below base fee",
+			baseFee:  big.NewInt(30 * gwei),
+			victim:   feeSwap(1, 2*gwei, 100*gwei),
+			swap:     feeSwap(2, 0, 20*gwei),
+			attacker: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := NewPoolIndex(time.Minute, 0.5)
+			index.SetBaseFee(tt.baseFee)
+
+			if index.Observe(tt.victim) {
+				t.Fatal("first swap flagged")
+			}
+			if got := index.Observe(tt.swap); got != tt.attacker {
+				t.Errorf("Observe() = %v, want %v", got, tt.attacker)
+			}
+		})
+	}
+}
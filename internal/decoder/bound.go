@@ -0,0 +1,101 @@
+package decoder
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// pendingEvictor 持有pending交易状态的跟踪器
+type pendingEvictor interface {
+	// evictPending 丢弃指定交易的状态（不得回调 PendingBound）
+	evictPending(hash common.Hash)
+}
+
+// boundKey 跟踪器 + 交易哈希
+type boundKey struct {
+	owner pendingEvictor
+	hash  common.Hash
+}
+
+// PendingBound 各pending跟踪器共享的全局容量上限，
+// 超出时按首次发现顺序淘汰最旧的记录（内存池洪峰时防止无限增长）
+type PendingBound struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List // 按首次发现排序，最旧的在前
+	index   map[boundKey]*list.Element
+	evicted int64
+}
+
+// NewPendingBound 创建容量上限，max <= 0 表示不限制
+func NewPendingBound(max int) *PendingBound {
+	return &PendingBound{
+		max:   max,
+		order: list.New(),
+		index: make(map[boundKey]*list.Element),
+	}
+}
+
+// add 登记一条记录，调用方不得持有跟踪器的锁（淘汰时会回调跟踪器）
+func (b *PendingBound) add(owner pendingEvictor, hash common.Hash) {
+	if b == nil {
+		return
+	}
+
+	key := boundKey{owner: owner, hash: hash}
+
+	b.mu.Lock()
+	if _, exists := b.index[key]; exists {
+		b.mu.Unlock()
+		return
+	}
+	b.index[key] = b.order.PushBack(key)
+
+	var victims []boundKey
+	for b.max > 0 && b.order.Len() > b.max {
+		oldest := b.order.Front()
+		victim := b.order.Remove(oldest).(boundKey)
+		delete(b.index, victim)
+		victims = append(victims, victim)
+		b.evicted++
+	}
+	b.mu.Unlock()
+
+	for _, victim := range victims {
+		victim.owner.evictPending(victim.hash)
+	}
+}
+
+// remove 跟踪器自行清理记录时注销
+func (b *PendingBound) remove(owner pendingEvictor, hash common.Hash) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := boundKey{owner: owner, hash: hash}
+	if elem, exists := b.index[key]; exists {
+		b.order.Remove(elem)
+		delete(b.index, key)
+	}
+}
+
+// GetStats 获取统计信息
+func (b *PendingBound) GetStats() map[string]interface{} {
+	if b == nil {
+		return map[string]interface{}{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return map[string]interface{}{
+		"tracked": b.order.Len(),
+		"max":     b.max,
+		"evicted": b.evicted,
+	}
+}
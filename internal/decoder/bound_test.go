@@ -0,0 +1,140 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// boundTx 发送者各不相同的pending交易
+func boundTx(n int64) *types.Transaction {
+	return &types.Transaction{
+		Hash:     common.BigToHash(big.NewInt(n)),
+		From:     common.BigToAddress(big.NewInt(0x1000 + n)),
+		Nonce:    1,
+		GasPrice: big.NewInt(1e9),
+	}
+}
+
+// tracking 交易是否仍被 PendingTracker 跟踪（同一nonce的其他交易会被视为替代）
+func tracking(tracker *PendingTracker, tx *types.Transaction) bool {
+	probe := *tx
+	probe.Hash = common.HexToHash("0xffff")
+	return tracker.Replaces(&probe)
+}
+
+func boundStats(t *testing.T, bound *PendingBound, tracked int, evicted int64) {
+	t.Helper()
+	stats := bound.GetStats()
+	if stats["tracked"] != tracked || stats["evicted"] != evicted {
+		t.Errorf("bound stats tracked = %v, evicted = %v; want %d, %d", stats["tracked"], stats["evicted"], tracked, evicted)
+	}
+}
+
+func TestPendingBoundEvictsOldestAcrossTrackers(t *testing.T) {
+	bound := NewPendingBound(3)
+	pending := NewPendingTracker(time.Minute)
+	privacy := NewPrivacyTracker(nil)
+	pending.SetBound(bound)
+	privacy.SetBound(bound)
+
+	txs := make([]*types.Transaction, 6)
+	for i := range txs {
+		txs[i] = boundTx(int64(i + 1))
+	}
+
+	pending.Track(txs[0])
+	pending.Track(txs[1])
+	privacy.Seen(txs[2])
+	boundStats(t, bound, 3, 0)
+
+	// 超过上限：两个跟踪器共享一个首次发现顺序，最旧的先淘汰
+	pending.Track(txs[3])
+	if tracking(pending, txs[0]) {
+		t.Error("oldest transaction still tracked past the bound")
+	}
+	privacy.Seen(txs[4])
+	if tracking(pending, txs[1]) {
+		t.Error("second oldest transaction still tracked past the bound")
+	}
+	boundStats(t, bound, 3, 2)
+
+	// 重复发现不刷新首次发现时间，也不重复计数
+	privacy.Seen(txs[2])
+	boundStats(t, bound, 3, 2)
+
+	privacy.Seen(txs[5])
+	if privacy.Pending() != 2 {
+		t.Errorf("privacy tracker holds %d first-seen records, want 2", privacy.Pending())
+	}
+	// 最近的记录保留
+	if !tracking(pending, txs[3]) {
+		t.Error("recent transaction evicted")
+	}
+	boundStats(t, bound, 3, 3)
+}
+
+func TestPendingBoundReleasesForgottenTransactions(t *testing.T) {
+	bound := NewPendingBound(2)
+	pending := NewPendingTracker(time.Minute)
+	pending.SetBound(bound)
+
+	original := boundTx(1)
+	pending.Track(original)
+	pending.Track(boundTx(2))
+
+	// 被取消的交易由跟踪器自行清理并注销，不占用容量，也不计为淘汰
+	cancel := &types.Transaction{
+		Hash:     common.HexToHash("0x99"),
+		From:     original.From,
+		To:       &original.From,
+		Value:    big.NewInt(0),
+		Nonce:    original.Nonce,
+		GasPrice: big.NewInt(2e9),
+	}
+	if _, ok := pending.CheckCancel(cancel); !ok {
+		t.Fatal("CheckCancel() did not cancel the tracked transaction")
+	}
+	boundStats(t, bound, 1, 0)
+
+	pending.Track(boundTx(3))
+	if !tracking(pending, boundTx(2)) {
+		t.Error("transaction evicted although the bound had room")
+	}
+	boundStats(t, bound, 2, 0)
+}
+
+func TestPendingBoundReleasesExpiredTransactions(t *testing.T) {
+	bound := NewPendingBound(10)
+	pending := NewPendingTracker(20 * time.Millisecond)
+	pending.SetBound(bound)
+
+	pending.Track(boundTx(1))
+	pending.Track(boundTx(2))
+	time.Sleep(50 * time.Millisecond)
+
+	// 过期清理同样从全局上限注销
+	pending.Track(boundTx(3))
+	if tracking(pending, boundTx(1)) || tracking(pending, boundTx(2)) {
+		t.Error("expired transactions still tracked")
+	}
+	boundStats(t, bound, 1, 0)
+}
+
+func TestPendingBoundUnlimited(t *testing.T) {
+	bound := NewPendingBound(0)
+	pending := NewPendingTracker(time.Minute)
+	pending.SetBound(bound)
+
+	for i := int64(1); i <= 100; i++ {
+		pending.Track(boundTx(i))
+	}
+	if !tracking(pending, boundTx(1)) {
+		t.Error("transaction evicted without a bound")
+	}
+	boundStats(t, bound, 100, 0)
+}
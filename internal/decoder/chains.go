@@ -0,0 +1,191 @@
+package decoder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// v3SwapMethods 仅 Uniswap V3 SwapRouter 提供的交换方法
+var v3SwapMethods = map[string]bool{
+	"exactInputSingle":  true,
+	"exactInput":        true,
+	"exactOutputSingle": true,
+	"exactOutput":       true,
+}
+
+// Chain 单条链的解码配置：按链注册表构建的路由器、交换方法和借贷市场，以及启动时注册的路由ABI
+type Chain struct {
+	Info *types.ChainInfo
+
+	dex     map[common.Address]string // 路由器地址 -> DEX名称
+	methods map[string][]byte         // 链上路由器支持的交换方法
+	lending map[common.Address]string // 借贷市场 Pool 地址 -> 名称
+
+	customMu   sync.RWMutex
+	customABIs map[common.Address]abi.ABI // 启动时从文件注册的路由合约ABI（按路由地址，优先于内置ABI）
+
+	selectors atomic.Pointer[SelectorBloom] // 交换方法选择器位图
+}
+
+// NewChain 按链注册表中的配置创建解码配置
+func NewChain(info *types.ChainInfo) *Chain {
+	hasV3 := info.HasRouterVersion(types.RouterV3)
+	methods := make(map[string][]byte, len(swapMethods))
+	for name, id := range swapMethods {
+		if v3SwapMethods[name] && !hasV3 {
+			continue
+		}
+		methods[name] = id
+	}
+
+	dex := make(map[common.Address]string, len(info.Routers))
+	for _, router := range info.Routers {
+		dex[router.Address] = router.Name
+	}
+	lending := make(map[common.Address]string, len(info.Lending))
+	for _, market := range info.Lending {
+		lending[market.Pool] = market.Name
+	}
+
+	c := &Chain{
+		Info:       info,
+		dex:        dex,
+		methods:    methods,
+		lending:    lending,
+		customABIs: make(map[common.Address]abi.ABI),
+	}
+	c.rebuildSelectors()
+	return c
+}
+
+// LookupChain 按链ID从链注册表创建解码配置
+func LookupChain(chainID int64) (*Chain, error) {
+	info, exists := types.LookupChain(chainID)
+	if !exists {
+		return nil, fmt.Errorf("不支持的链ID: %d", chainID)
+	}
+	return NewChain(&info), nil
+}
+
+// NativeSymbol 链的原生代币符号（零地址的显示名称）
+func (c *Chain) NativeSymbol() string {
+	return c.Info.NativeSymbol
+}
+
+// IsSupportedContract 检查是否支持该合约
+func (c *Chain) IsSupportedContract(address common.Address) bool {
+	c.customMu.RLock()
+	defer c.customMu.RUnlock()
+	_, exists := c.dex[address]
+	return exists
+}
+
+// IsSwapMethod 检查是否是链上路由器支持的交换方法
+func (c *Chain) IsSwapMethod(methodID []byte) bool {
+	return c.MethodName(methodID) != "unknown"
+}
+
+// MethodName 根据方法ID获取交换方法名称
+func (c *Chain) MethodName(methodID []byte) string {
+	if len(methodID) < 4 {
+		return "unknown"
+	}
+	for name, id := range c.methods {
+		if string(methodID[:4]) == string(id) {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// DEXName 根据合约地址获取DEX名称
+func (c *Chain) DEXName(address common.Address) string {
+	c.customMu.RLock()
+	defer c.customMu.RUnlock()
+	if name, exists := c.dex[address]; exists {
+		return name
+	}
+	return address.Hex()[:10] + "..."
+}
+
+// Routers 支持的路由合约地址（含注册了额外ABI的路由）
+func (c *Chain) Routers() []common.Address {
+	c.customMu.RLock()
+	defer c.customMu.RUnlock()
+	routers := make([]common.Address, 0, len(c.dex))
+	for router := range c.dex {
+		routers = append(routers, router)
+	}
+	return routers
+}
+
+// HasLending 链上是否有清算策略支持的借贷市场
+func (c *Chain) HasLending() bool {
+	return len(c.lending) > 0
+}
+
+// RegisterRouterABI 注册路由合约的额外ABI：该路由的调用按此ABI的交换方法解码（参数按名称提取，
+// 需要 path 或 tokenIn/tokenOut），路由不在链的路由器中时以 name 加入（只应在启动时、解码开始前调用）
+func (c *Chain) RegisterRouterABI(router common.Address, name string, definition []byte) error {
+	parsed, err := abi.JSON(strings.NewReader(string(definition)))
+	if err != nil {
+		return fmt.Errorf("ABI JSON 无效: %v", err)
+	}
+
+	swaps := 0
+	for _, method := range parsed.Methods {
+		if isSwapShaped(&method) {
+			swaps++
+		}
+	}
+	if swaps == 0 {
+		return fmt.Errorf("ABI 中没有可解码的交换方法（参数需包含 path 或 tokenIn/tokenOut）")
+	}
+
+	c.customMu.Lock()
+	c.customABIs[router] = parsed
+	if _, exists := c.dex[router]; !exists {
+		c.dex[router] = name
+	}
+	c.customMu.Unlock()
+	c.rebuildSelectors()
+	return nil
+}
+
+// LoadRouterABIFile 从文件注册路由合约ABI，DEX名称取文件名（不含扩展名）
+func (c *Chain) LoadRouterABIFile(router common.Address, path string) error {
+	definition, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if err := c.RegisterRouterABI(router, name, definition); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// customSwapMethod 按路由注册的ABI查找交换方法
+func (c *Chain) customSwapMethod(router common.Address, id []byte) (*abi.Method, bool) {
+	c.customMu.RLock()
+	parsed, exists := c.customABIs[router]
+	c.customMu.RUnlock()
+	if !exists || len(id) < 4 {
+		return nil, false
+	}
+
+	method, err := parsed.MethodById(id[:4])
+	if err != nil || !isSwapShaped(method) {
+		return nil, false
+	}
+	return method, true
+}
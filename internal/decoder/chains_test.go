@@ -0,0 +1,68 @@
+package decoder
+
+import (
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestChainResolvesOwnRouters(t *testing.T) {
+	for _, chainID := range types.SupportedChainIDs() {
+		chain, err := LookupChain(chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, router := range chain.Info.Routers {
+			if !chain.IsSupportedContract(router.Address) || chain.DEXName(router.Address) != router.Name {
+				t.Errorf("chain %d: %s resolved to %q", chainID, router.Address.Hex(), chain.DEXName(router.Address))
+			}
+		}
+		if got, want := chain.IsSwapMethod(MethodExactInputSingle), chain.Info.HasRouterVersion(types.RouterV3); got != want {
+			t.Errorf("chain %d: exactInputSingle accepted = %v, want %v", chainID, got, want)
+		}
+		if !chain.IsSwapMethod(MethodSwapExactETHForTokens) {
+			t.Errorf("chain %d: swapExactETHForTokens not accepted", chainID)
+		}
+	}
+
+	if _, err := LookupChain(999); err == nil {
+		t.Error("LookupChain(999) accepted an unregistered chain")
+	}
+}
+
+func TestChainsDoNotShareRouters(t *testing.T) {
+	mainnet, _ := LookupChain(1)
+	bsc, _ := LookupChain(56)
+
+	pancake := common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E")
+	if mainnet.IsSupportedContract(pancake) || !bsc.IsSupportedContract(pancake) {
+		t.Error("PancakeSwap router resolved on the wrong chain")
+	}
+	if bsc.IsSupportedContract(uniswapV2Router) {
+		t.Error("mainnet Uniswap V2 router resolved on BSC")
+	}
+
+	// 在一条链上注册的路由ABI不影响其他链
+	router := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	definition := []byte(`[{"name":"swap","type":"function","inputs":[{"name":"path","type":"address[]"},{"name":"amountIn","type":"uint256"}],"outputs":[]}]`)
+	if err := bsc.RegisterRouterABI(router, "Custom", definition); err != nil {
+		t.Fatal(err)
+	}
+	if !bsc.IsSupportedContract(router) || bsc.DEXName(router) != "Custom" {
+		t.Errorf("registered router resolved to %q on BSC", bsc.DEXName(router))
+	}
+	if mainnet.IsSupportedContract(router) {
+		t.Error("router registered on BSC resolved on mainnet")
+	}
+
+	// 解码器按自己的链判断：mainnet 解码器不解码 BSC 路由的交换
+	tx := swapTx(t, pancake, nil, "0x7ff36ab50000000000000000000000000000000000000000000000000000000000000000")
+	if NewDecoder(mainnet).FilterTransaction(tx) {
+		t.Error("mainnet decoder accepted a PancakeSwap swap")
+	}
+	if !NewDecoder(bsc).FilterTransaction(tx) {
+		t.Error("BSC decoder rejected a PancakeSwap swap")
+	}
+}
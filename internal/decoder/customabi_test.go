@@ -0,0 +1,157 @@
+package decoder
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// customRouter 不在内置路由器中的路由合约
+var customRouter = common.HexToAddress("0x1111111254EEB25477B68fb85Ed929f73A960582")
+
+// customRouterABI 自定义路由：数组路径的 swapTokens，以及 tokenIn/tokenOut 结构体参数的 trade
+const customRouterABI = `[
+	{"type":"function","name":"swapTokens","stateMutability":"nonpayable",
+	 "inputs":[{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],
+	 "outputs":[]},
+	{"type":"function","name":"trade","stateMutability":"payable",
+	 "inputs":[{"name":"order","type":"tuple","components":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"amountIn","type":"uint256"},{"name":"amountOutMinimum","type":"uint256"},{"name":"recipient","type":"address"}]}],
+	 "outputs":[]},
+	{"type":"function","name":"setOwner","stateMutability":"nonpayable",
+	 "inputs":[{"name":"owner","type":"address"}],"outputs":[]}
+]`
+
+// writeABIFile 写入临时ABI文件
+func writeABIFile(t *testing.T, name, definition string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(definition), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCustomRouterABIDecodesCalls(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(customRouterABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+	pack := func(method string, args ...interface{}) string {
+		data, err := parsed.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hexutil.Encode(data)
+	}
+	type order struct {
+		TokenIn          common.Address
+		TokenOut         common.Address
+		AmountIn         *big.Int
+		AmountOutMinimum *big.Int
+		Recipient        common.Address
+	}
+
+	tests := []struct {
+		name         string
+		calldata     string
+		method       string
+		amountIn     string
+		amountOutMin string
+		path         []common.Address
+	}{
+		{
+			name:         "address path",
+			calldata:     pack("swapTokens", big.NewInt(5000e6), big.NewInt(2e18), []common.Address{usdc, weth}, recipient, big.NewInt(1700000000)),
+			method:       "swapTokens",
+			amountIn:     "5000000000",
+			amountOutMin: "2000000000000000000",
+			path:         []common.Address{usdc, weth},
+		},
+		{
+			name:         "tokenIn and tokenOut in a tuple",
+			calldata:     pack("trade", order{TokenIn: usdt, TokenOut: dai, AmountIn: big.NewInt(1500e6), AmountOutMinimum: big.NewInt(1490e6), Recipient: recipient}),
+			method:       "trade",
+			amountIn:     "1500000000",
+			amountOutMin: "1490000000",
+			path:         []common.Address{usdt, dai},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 未注册时该路由的调用不解码
+			if decoded := NewDecoder(mainnetChain(t)).DecodeTransaction(swapTx(t, customRouter, big.NewInt(0), tt.calldata)); decoded != nil {
+				t.Fatalf("DecodeTransaction() = %+v before the ABI was registered", decoded)
+			}
+
+			chain := mainnetChain(t)
+			if err := chain.LoadRouterABIFile(customRouter, writeABIFile(t, "oneinch.json", customRouterABI)); err != nil {
+				t.Fatalf("LoadRouterABIFile() error = %v", err)
+			}
+			if !chain.IsSupportedContract(customRouter) || chain.DEXName(customRouter) != "oneinch" {
+				t.Errorf("router supported = %v, DEX name = %q; want true, oneinch", chain.IsSupportedContract(customRouter), chain.DEXName(customRouter))
+			}
+
+			decoded := NewDecoder(chain).DecodeTransaction(swapTx(t, customRouter, big.NewInt(0), tt.calldata))
+			if decoded == nil {
+				t.Fatal("DecodeTransaction() = nil")
+			}
+			if decoded.Method != tt.method || !decoded.IsSwap {
+				t.Errorf("Method = %q, IsSwap = %v; want %q, true", decoded.Method, decoded.IsSwap, tt.method)
+			}
+			if decoded.AmountIn.String() != tt.amountIn || decoded.AmountOutMin.String() != tt.amountOutMin {
+				t.Errorf("AmountIn = %s, AmountOutMin = %s; want %s, %s", decoded.AmountIn, decoded.AmountOutMin, tt.amountIn, tt.amountOutMin)
+			}
+			if !reflect.DeepEqual(decoded.Path, tt.path) {
+				t.Errorf("Path = %v, want %v", decoded.Path, tt.path)
+			}
+			if decoded.Recipient != recipient {
+				t.Errorf("Recipient = %s, want %s", decoded.Recipient.Hex(), recipient.Hex())
+			}
+		})
+	}
+
+	// 注册的ABI中不是交换的方法不解码
+	chain := mainnetChain(t)
+	if err := chain.RegisterRouterABI(customRouter, "oneinch", []byte(customRouterABI)); err != nil {
+		t.Fatal(err)
+	}
+	if decoded := NewDecoder(chain).DecodeTransaction(swapTx(t, customRouter, big.NewInt(0), pack("setOwner", recipient))); decoded != nil {
+		t.Errorf("DecodeTransaction(setOwner) = %+v, want nil", decoded)
+	}
+}
+
+func TestLoadRouterABIFileValidates(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string // 空表示文件不存在
+		wantErr    string
+	}{
+		{name: "missing file", wantErr: "no such file"},
+		{name: "malformed JSON", definition: `[{"type":"function","name":"swap",`, wantErr: "ABI JSON 无效"},
+		{name: "no swap method", definition: `[{"type":"function","name":"setOwner","inputs":[{"name":"owner","type":"address"}],"outputs":[]}]`, wantErr: "没有可解码的交换方法"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "router.json")
+			if tt.definition != "" {
+				path = writeABIFile(t, "router.json", tt.definition)
+			}
+			chain := mainnetChain(t)
+			err := chain.LoadRouterABIFile(customRouter, path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadRouterABIFile() error = %v, want one containing %q", err, tt.wantErr)
+			}
+			if chain.IsSupportedContract(customRouter) {
+				t.Error("router registered although its ABI was rejected")
+			}
+		})
+	}
+}
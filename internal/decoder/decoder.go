@@ -2,111 +2,259 @@ package decoder
 
 import (
 	"context"
-	"log"
+	"math/big"
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/internal/logging"
 	"mempool-sniper/pkg/types"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
 )
 
+// logger 解码器日志（component=decoder）
+var logger = logging.Component("decoder")
+
 // Transaction 交易包装类型
 // 由于pkg/types包导入问题，这里定义本地类型
 
 // Decoder 交易解码器
 type Decoder struct {
+	chain *Chain // 链的路由器、交换方法和借贷市场（创建后不变）
+
 	mu        sync.RWMutex
 	processed int64
 	filtered  int64
 	decoded   int64
+	cancelled int64
+
+	anomalousGas int64 // Gas限制异常的交易数
+	blobSkipped  int64 // 跳过的blob交易数
+	likelyPriv   int64 // 疑似私有订单流的交易数
+	nonceBlocked int64 // 前面存在nonce空缺的交易数
+
+	recipientFiltered int64                   // 因接收地址被过滤的交易数
+	recipientAllow    map[common.Address]bool // 接收地址白名单（为空表示不限制）
+	recipientDeny     map[common.Address]bool // 接收地址黑名单
+
+	lending        bool  // 是否解码借贷交易（清算策略）
+	lendingDecoded int64 // 解码的借贷交易数
+	mevResistant   int64 // 抗MEV订单流交易数
+	replacements   int64 // 替代(加速)已跟踪交易的交易数
+
+	pairFiltered  int64              // 因交易对不在白名单被过滤的交易数
+	pairWhitelist map[TokenPair]bool // 交易对白名单（为空表示不限制）
+
+	pending   *PendingTracker     // pending交换交易跟踪器（用于识别取消交易）
+	privacy   *PrivacyTracker     // 私有订单流识别器
+	nonces    *NonceTracker       // 按发送者的pending nonce序列跟踪器
+	spam      *SpamDetector       // 重复逻辑垃圾交易识别器（为nil表示不启用）
+	pools     *PoolIndex          // 按交易对的pending交换索引，识别夹子攻击者（为nil表示不启用）
+	approvals *ApprovalTracker    // 对路由合约的代币授权跟踪（为nil表示不启用）
+	bound     *PendingBound       // pending交易状态全局容量上限
+	lifecycle *lifecycle.Recorder // 生命周期事件记录器
+	symbols   *SymbolResolver     // 代币符号解析器（为nil时日志输出截断地址）
+
+	workers sync.WaitGroup // 运行中的工作线程
+
+	funnel *lifecycle.Funnel // 机会转化漏斗（为nil表示不统计）
+
+	params *ParamsLog // 调用参数调试日志（为nil表示不记录）
+
+	selectorBloomOff atomic.Bool // 禁用选择器位图预过滤（热路径原子读取，不加锁）
+
+	poolCtx      context.Context                  // 工作池上下文（重启时新线程在其下启动）
+	poolCancel   context.CancelFunc               // 取消当前这一批工作线程
+	poolIn       <-chan *types.Transaction        // 工作池输入通道
+	poolOut      chan<- *types.DecodedTransaction // 工作池输出通道
+	poolSize     int                              // 每批工作线程数
+	nextWorkerID int                              // 下一个工作线程ID
 }
 
 // NewDecoder 创建新的解码器
-func NewDecoder() *Decoder {
+func NewDecoder(chain *Chain) *Decoder {
 	return &Decoder{
+		chain:     chain,
 		processed: 0,
 		filtered:  0,
 		decoded:   0,
+		pending:   NewPendingTracker(10 * time.Minute),
+		privacy:   NewPrivacyTracker(chain),
+		nonces:    NewNonceTracker(10 * time.Minute),
 	}
 }
 
+// Chain 解码器所用的链配置
+func (d *Decoder) Chain() *Chain {
+	return d.chain
+}
+
 // StartWorkerPool 启动解码器工作池
 func (d *Decoder) StartWorkerPool(ctx context.Context, txChan <-chan *types.Transaction, decodedTxChan chan<- *types.DecodedTransaction, workerCount int) {
-	log.Printf("🔍 启动解码器工作池，工作线程数: %d", workerCount)
+	logger.Info("启动解码器工作池", "workers", workerCount)
 
-	for i := 0; i < workerCount; i++ {
-		go d.worker(ctx, txChan, decodedTxChan, i)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.poolCtx, d.poolIn, d.poolOut, d.poolSize = ctx, txChan, decodedTxChan, workerCount
+	d.spawnWorkers()
+}
+
+// spawnWorkers 在可单独取消的上下文中启动一批工作线程（调用方需持有锁）
+func (d *Decoder) spawnWorkers() {
+	workerCtx, cancel := context.WithCancel(d.poolCtx)
+	d.poolCancel = cancel
+
+	for i := 0; i < d.poolSize; i++ {
+		workerID := d.nextWorkerID
+		d.nextWorkerID++
+		d.workers.Add(1)
+		go func() {
+			defer d.workers.Done()
+			d.worker(workerCtx, d.poolIn, d.poolOut, workerID)
+		}()
+	}
+}
+
+// RestartWorkers 取消当前这一批工作线程并启动新的一批（看门狗发现工作池停滞时调用）：
+// 卡死的旧线程无法强制结束，由新线程接管输入通道
+func (d *Decoder) RestartWorkers() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.poolCancel == nil || d.poolCtx.Err() != nil {
+		return
 	}
+
+	d.poolCancel()
+	d.spawnWorkers()
+	logger.Warn("解码器工作池已重启", "workers", d.poolSize)
+}
+
+// Progress 从输入通道取出的交易数（看门狗据此判断工作池是否停滞）
+func (d *Decoder) Progress() int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.processed
+}
+
+// Wait 等待所有工作线程退出（上下文取消或输入通道关闭且排空后）
+func (d *Decoder) Wait() {
+	d.workers.Wait()
 }
 
 // worker 解码器工作线程
 func (d *Decoder) worker(ctx context.Context, txChan <-chan *types.Transaction, decodedTxChan chan<- *types.DecodedTransaction, workerID int) {
-	log.Printf("👷 解码器工作线程 %d 启动", workerID)
+	logger.Debug("工作线程启动", "worker_id", workerID)
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("🛑 解码器工作线程 %d 停止", workerID)
+			logger.Debug("工作线程停止", "worker_id", workerID)
 			return
-		case tx := <-txChan:
+		case tx, ok := <-txChan:
+			if !ok {
+				logger.Debug("工作线程输入已排空，停止", "worker_id", workerID)
+				return
+			}
 			if tx == nil {
 				continue
 			}
 
 			d.mu.Lock()
 			d.processed++
+			funnel := d.funnel
 			d.mu.Unlock()
+			funnel.Add(lifecycle.FunnelSeen)
 
 			// 解码交易
 			decodedTx := d.decodeTransaction(tx)
 			if decodedTx != nil {
+				funnel.Add(lifecycle.FunnelDecoded)
+
 				// 🚨 猎物发现！输出醒目标志
 				d.logHuntingResult(decodedTx, workerID)
 
 				// 将解码后的交易发送到模拟器
 				select {
 				case decodedTxChan <- decodedTx:
-					log.Printf("✅ 工作线程 %d 解码成功并发送到模拟器: %s -> %s",
-						workerID, tx.Hash.Hex(), decodedTx.Method)
+					logger.Debug("解码成功并发送到模拟器", "worker_id", workerID, "tx_hash", tx.Hash.Hex(),
+						"method", decodedTx.Method, "path", d.formatPath(ctx, decodedTx.Path))
 				case <-ctx.Done():
 					return
 				default:
-					log.Printf("⚠️ 工作线程 %d 解码器通道已满，丢弃交易: %s", workerID, tx.Hash.Hex())
+					logger.Warn("模拟器通道已满，丢弃交易", "worker_id", workerID, "tx_hash", tx.Hash.Hex())
 				}
 			}
 		}
 	}
 }
 
+// formatPath 格式化交换路径
+func (d *Decoder) formatPath(ctx context.Context, path []common.Address) string {
+	d.mu.RLock()
+	symbols := d.symbols
+	d.mu.RUnlock()
+	return symbols.FormatPath(ctx, path)
+}
+
+// SetLifecycleRecorder 设置生命周期事件记录器
+func (d *Decoder) SetLifecycleRecorder(recorder *lifecycle.Recorder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lifecycle = recorder
+}
+
+// SetFunnel 设置机会转化漏斗（统计 seen / decoded 阶段）
+func (d *Decoder) SetFunnel(funnel *lifecycle.Funnel) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.funnel = funnel
+}
+
+// SetParamsLog 设置调用参数调试日志（每笔成功解析的交换交易写入一行）
+func (d *Decoder) SetParamsLog(params *ParamsLog) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.params = params
+}
+
+// lifecycleRecorder 获取生命周期事件记录器
+func (d *Decoder) lifecycleRecorder() *lifecycle.Recorder {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.lifecycle
+}
+
+// SetSymbolResolver 设置代币符号解析器
+func (d *Decoder) SetSymbolResolver(resolver *SymbolResolver) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.symbols = resolver
+}
+
 // logHuntingResult 记录猎物发现结果
 func (d *Decoder) logHuntingResult(decodedTx *types.DecodedTransaction, workerID int) {
 	if decodedTx.IsSwap {
-		// 根据交易方向输出不同的日志
-		switch decodedTx.SwapDirection {
-		case "buy":
-			log.Printf("🔥🔥🔥 [%s] 发现买单! 工作线程: %d",
-				GetDEXName(decodedTx.TargetContract), workerID)
-			log.Printf("💰 交易哈希: %s", decodedTx.Transaction.Hash.Hex())
-			log.Printf("🎯 投入金额: %s Wei (ETH)", decodedTx.Transaction.Value.String())
-			log.Printf("📊 方法: %s", decodedTx.Method)
-
-		case "sell":
-			log.Printf("🔥🔥🔥 [%s] 发现卖单! 工作线程: %d",
-				GetDEXName(decodedTx.TargetContract), workerID)
-			log.Printf("💰 交易哈希: %s", decodedTx.Transaction.Hash.Hex())
-			log.Printf("📊 方法: %s", decodedTx.Method)
-
-		case "swap":
-			log.Printf("🔥 [%s] 发现代币交换! 工作线程: %d",
-				GetDEXName(decodedTx.TargetContract), workerID)
-			log.Printf("💰 交易哈希: %s", decodedTx.Transaction.Hash.Hex())
-			log.Printf("📊 方法: %s", decodedTx.Method)
+		// 一条记录包含交易方向和全部字段，买单额外带投入金额
+		fields := []any{
+			"worker_id", workerID,
+			"tx_hash", decodedTx.Transaction.Hash.Hex(),
+			"dex", d.chain.DEXName(decodedTx.TargetContract),
+			"direction", decodedTx.SwapDirection,
+			"method", decodedTx.Method,
+		}
+		if decodedTx.SwapDirection == "buy" {
+			fields = append(fields, "value", types.FromBaseUnits(decodedTx.Transaction.Value, 18),
+				"asset", types.BaseAssetSymbol(decodedTx.Transaction.ChainID))
 		}
+		logger.Info("发现目标交换交易", fields...)
 
 		// 每10笔猎物交易打印一次统计信息
 		d.mu.RLock()
 		if d.decoded%10 == 0 {
-			log.Printf("📊 解码器统计 - 总处理: %d, 成功解码: %d, 成功率: %.2f%%",
-				d.processed, d.decoded, float64(d.decoded)/float64(d.processed)*100)
+			logger.Info("解码器统计", "processed", d.processed, "decoded", d.decoded,
+				"success_rate", float64(d.decoded)/float64(d.processed))
 		}
 		d.mu.RUnlock()
 	}
@@ -114,6 +262,38 @@ func (d *Decoder) logHuntingResult(decodedTx *types.DecodedTransaction, workerID
 
 // decodeTransaction 解码交易
 func (d *Decoder) decodeTransaction(tx *types.Transaction) *types.DecodedTransaction {
+	// 记录发送者的nonce序列（任意交易都可能填上其后交换交易前面的空缺）
+	d.nonces.Observe(tx)
+
+	// blob交易（EIP-4844）不会是交换交易，直接跳过
+	if tx.IsBlob() {
+		d.mu.Lock()
+		d.blobSkipped++
+		d.filtered++
+		d.mu.Unlock()
+		return nil
+	}
+
+	// 检查是否为已跟踪交换交易的取消交易
+	if original, cancelled := d.pending.CheckCancel(tx); cancelled {
+		logger.Info("交易已被取消交易替代", "tx_hash", original.Hex(), "cancel_tx_hash", tx.Hash.Hex())
+		d.mu.Lock()
+		d.cancelled++
+		d.filtered++
+		d.mu.Unlock()
+		return nil
+	}
+
+	// 非交换交易以相同nonce替代已跟踪的交换交易（改发其他合约或转账）：原交易不会再打包
+	if !d.FilterTransaction(tx) {
+		if original, replaced := d.pending.CheckReplaced(tx); replaced {
+			logger.Info("交易已被非交换交易替代", "tx_hash", original.Hex(), "replacement_tx_hash", tx.Hash.Hex())
+			d.mu.Lock()
+			d.replacements++
+			d.mu.Unlock()
+		}
+	}
+
 	if tx.To == nil {
 		// 合约创建交易，跳过
 		d.mu.Lock()
@@ -122,8 +302,26 @@ func (d *Decoder) decodeTransaction(tx *types.Transaction) *types.DecodedTransac
 		return nil
 	}
 
+	// 对路由合约的授权：记录后等待同一发送者的后续交换
+	if d.approvalTracker().Observe(tx) {
+		d.mu.Lock()
+		d.filtered++
+		d.mu.Unlock()
+		return nil
+	}
+
+	// 抗MEV的结算合约：成交价由结算方决定，标记后不参与夹子类策略
+	if IsMEVResistant(tx) {
+		return d.decodeMEVResistant(tx)
+	}
+
+	// 借贷协议交易（清算策略）
+	if d.lendingEnabled() && d.chain.IsLendingTransaction(tx) {
+		return d.decodeLendingTransaction(tx)
+	}
+
 	// 检查是否支持该合约
-	if !IsSupportedContract(*tx.To) {
+	if !d.chain.IsSupportedContract(*tx.To) {
 		d.mu.Lock()
 		d.filtered++
 		d.mu.Unlock()
@@ -141,37 +339,138 @@ func (d *Decoder) decodeTransaction(tx *types.Transaction) *types.DecodedTransac
 	// 提取方法ID
 	methodID := tx.Data[:4]
 
-	// 检查是否是交换方法
-	if !IsSwapMethod(methodID) {
+	// 检查是否是交换方法（内置方法或路由注册ABI中的交换方法）
+	customMethod, custom := d.chain.customSwapMethod(*tx.To, methodID)
+	if !d.chain.IsSwapMethod(methodID) && !custom {
 		d.mu.Lock()
 		d.filtered++
 		d.mu.Unlock()
 		return nil
 	}
 
+	// 记录首次发现时间（在后续过滤之前，被过滤的交换同样在公开内存池出现过）
+	d.privacy.Seen(tx)
+
 	// 构建解码后的交易信息
 	decodedTx := &types.DecodedTransaction{
 		Transaction:    tx,
-		Method:         GetMethodName(methodID),
+		Method:         d.chain.MethodName(methodID),
 		MethodID:       methodID,
 		TargetContract: *tx.To,
 		IsSwap:         true,
 	}
 
+	if custom {
+		decodedTx.Method = customMethod.Name
+	}
+
 	// 根据方法类型设置交换方向
 	switch decodedTx.Method {
 	case "swapExactETHForTokens":
 		decodedTx.SwapDirection = "buy"
-		decodedTx.TokenIn = common.HexToAddress("0x0000000000000000000000000000000000000000") // ETH
+		decodedTx.TokenIn = types.NativeToken
 	case "swapExactTokensForETH":
 		decodedTx.SwapDirection = "sell"
-		decodedTx.TokenOut = common.HexToAddress("0x0000000000000000000000000000000000000000") // ETH
+		decodedTx.TokenOut = types.NativeToken
 	case "swapExactTokensForTokens":
 		decodedTx.SwapDirection = "swap"
+	case "swapETHForExactTokens":
+		decodedTx.SwapDirection = "buy"
+		decodedTx.TokenIn = types.NativeToken
+	case "swapTokensForExactETH":
+		decodedTx.SwapDirection = "sell"
+		decodedTx.TokenOut = types.NativeToken
+	case "swapTokensForExactTokens":
+		decodedTx.SwapDirection = "swap"
+	}
+
+	// 按ABI解析交易参数，calldata无效的交易直接过滤
+	if err := d.parseTransactionParameters(decodedTx); err != nil {
+		logger.Warn("参数解码失败", "tx_hash", tx.Hash.Hex(), "error", err)
+		d.mu.Lock()
+		d.filtered++
+		d.mu.Unlock()
+		return nil
 	}
+	d.mu.RLock()
+	params := d.params
+	d.mu.RUnlock()
+	params.Record(decodedTx)
 
-	// 解析交易参数（简化版）
-	d.parseTransactionParameters(decodedTx)
+	// 检查Gas限制是否异常（批量调用、multicall或诱饵交易）
+	if IsAnomalousGasLimit(decodedTx.Method, tx.GasLimit) {
+		decodedTx.AnomalousGas = true
+		d.mu.Lock()
+		d.anomalousGas++
+		d.mu.Unlock()
+		logger.Warn("Gas限制异常", "tx_hash", tx.Hash.Hex(), "gas_limit", tx.GasLimit,
+			"method", decodedTx.Method, "expected_gas", ExpectedGasLimits[decodedTx.Method])
+	}
+
+	// 检查接收地址白名单/黑名单
+	if !d.isRecipientAllowed(decodedTx.Recipient) {
+		d.mu.Lock()
+		d.recipientFiltered++
+		d.filtered++
+		d.mu.Unlock()
+		return nil
+	}
+
+	// 检查交易对白名单
+	if !d.isPairAllowed(types.PoolPath(decodedTx.Path, tx.ChainID)) {
+		d.mu.Lock()
+		d.pairFiltered++
+		d.filtered++
+		d.mu.Unlock()
+		return nil
+	}
+
+	// 跟踪该交换交易，以便识别后续的取消交易；相同nonce的新交易为替代(加速)交易
+	if replaced, ok := d.pending.Track(tx); ok {
+		decodedTx.Replaces = replaced
+		d.mu.Lock()
+		d.replacements++
+		d.mu.Unlock()
+	}
+
+	// 根据发送者历史判断是否为私有订单流
+	if d.privacy.IsLikelyPrivate(tx.From) {
+		decodedTx.LikelyPrivate = true
+		d.mu.Lock()
+		d.likelyPriv++
+		d.mu.Unlock()
+	}
+
+	// 前面存在未填上的nonce空缺，要等空缺填上才能打包
+	if d.nonces.Blocked(tx.From, tx.Nonce) {
+		decodedTx.NonceBlocked = true
+		d.mu.Lock()
+		d.nonceBlocked++
+		d.mu.Unlock()
+	}
+
+	// 识别大量发送者的相同交换（代币上线时的机器人刷单）
+	if d.spamDetector().Observe(decodedTx) {
+		decodedTx.SpamCluster = true
+	}
+
+	// 识别抢跑同一交易对上pending交易的攻击者（不作为受害者）
+	if d.poolIndex().Observe(decodedTx) {
+		decodedTx.LikelyAttacker = true
+	}
+
+	// 发送者不久前授权过该路由使用路径中的代币（代币上线狙击的领先信号）
+	if d.approvalTracker().Leading(decodedTx) {
+		decodedTx.LeadingApproval = true
+	}
+
+	// 记录生命周期：发现
+	decodedTx.OpportunityID = lifecycle.NewID()
+	d.lifecycleRecorder().Emit(decodedTx.OpportunityID, lifecycle.StageDetected, tx.Hash, map[string]interface{}{
+		"method":    decodedTx.Method,
+		"contract":  decodedTx.TargetContract,
+		"direction": decodedTx.SwapDirection,
+	})
 
 	d.mu.Lock()
 	d.decoded++
@@ -180,49 +479,106 @@ func (d *Decoder) decodeTransaction(tx *types.Transaction) *types.DecodedTransac
 	return decodedTx
 }
 
-// parseTransactionParameters 解析交易参数
-func (d *Decoder) parseTransactionParameters(decodedTx *types.DecodedTransaction) {
-	// 这里实现具体的参数解析逻辑
-	// 由于ABI解析比较复杂，这里先实现简化版本
+// parseTransactionParameters 按路由合约ABI解析交换参数，calldata无效时返回错误
+func (d *Decoder) parseTransactionParameters(decodedTx *types.DecodedTransaction) error {
+	args, err := d.chain.unpackSwap(decodedTx.TargetContract, decodedTx.Transaction.Data)
+	if err != nil {
+		return err
+	}
 
-	data := decodedTx.Transaction.Data
+	decodedTx.AmountIn = args.AmountIn
+	decodedTx.AmountOutMin = args.AmountOutMin
+	if args.AmountOut != nil {
+		// 精确输出交换：输入金额取上限（实际输入由模拟器按储备反推），输出金额即最少输出
+		decodedTx.ExactOutput = true
+		decodedTx.AmountIn = args.AmountInMax
+		decodedTx.AmountOutMin = args.AmountOut
+	}
+	if decodedTx.AmountIn == nil {
+		// swapExactETHForTokens 的输入金额即交易附带的ETH（swapETHForExactTokens 的输入上限同理）
+		decodedTx.AmountIn = decodedTx.Transaction.Value
+	}
+	if decodedTx.ExactOutput {
+		decodedTx.AmountInMax = decodedTx.AmountIn
+	}
+	decodedTx.Path = args.Path
+	decodedTx.Recipient = args.To
+	decodedTx.Deadline = args.Deadline
+	decodedTx.FeeTier = args.FeeTier
+	decodedTx.Parameters = args.Params
+
+	// V3 方法名不区分ETH一侧，按包装原生代币在路径中的位置判断方向
+	if decodedTx.SwapDirection == "" {
+		weth, _ := types.WrappedNative(decodedTx.Transaction.ChainID)
+		switch {
+		case args.Path[0] == weth:
+			decodedTx.SwapDirection = "buy"
+			decodedTx.TokenIn = types.NativeToken
+		case args.Path[len(args.Path)-1] == weth:
+			decodedTx.SwapDirection = "sell"
+			decodedTx.TokenOut = types.NativeToken
+		default:
+			decodedTx.SwapDirection = "swap"
+		}
+	}
 
-	// 根据方法类型解析不同的参数
-	switch decodedTx.Method {
-	case "swapExactETHForTokens":
-		// swapExactETHForTokens(uint amountOutMin, address[] path, address to, uint deadline)
-		if len(data) >= 4+32*4 {
-			// 解析amountOutMin (第1个参数)
-			if len(data) >= 4+32 {
-				amountOutMin := decodedTx.Transaction.Value // 使用交易价值作为输入金额
-				decodedTx.AmountIn = amountOutMin
-			}
+	// 输入/输出代币取路径首尾（ETH一侧仍以零地址表示）
+	if decodedTx.SwapDirection != "buy" {
+		decodedTx.TokenIn = args.Path[0]
+	}
+	if decodedTx.SwapDirection != "sell" {
+		decodedTx.TokenOut = args.Path[len(args.Path)-1]
+	}
+	return nil
+}
 
-			// 这里可以继续解析其他参数
-			// 实际项目中需要使用完整的ABI解析
-		}
+// readUint256 读取calldata中第index个32字节参数（跳过4字节方法ID）
+func readUint256(data []byte, index int) *big.Int {
+	start := 4 + index*32
+	if len(data) < start+32 {
+		return nil
+	}
+	return new(big.Int).SetBytes(data[start : start+32])
+}
 
-	case "swapExactTokensForETH":
-		// swapExactTokensForETH(uint amountIn, uint amountOutMin, address[] path, address to, uint deadline)
-		if len(data) >= 4+32*5 {
-			// 解析amountIn (第1个参数)
-			if len(data) >= 4+32 {
-				// 这里需要从calldata中提取amountIn
-				// 简化处理：使用交易价值
-				decodedTx.AmountIn = decodedTx.Transaction.Value
-			}
-		}
+// readAddress 读取calldata中第index个address参数
+func readAddress(data []byte, index int) common.Address {
+	start := 4 + index*32
+	if len(data) < start+32 {
+		return common.Address{}
+	}
+	return common.BytesToAddress(data[start+12 : start+32])
+}
 
-	case "swapExactTokensForTokens":
-		// swapExactTokensForTokens(uint amountIn, uint amountOutMin, address[] path, address to, uint deadline)
-		if len(data) >= 4+32*5 {
-			// 解析amountIn (第1个参数)
-			if len(data) >= 4+32 {
-				// 简化处理
-				decodedTx.AmountIn = decodedTx.Transaction.Value
-			}
-		}
+// isRecipientAllowed 检查接收地址是否通过白名单/黑名单
+func (d *Decoder) isRecipientAllowed(recipient common.Address) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.recipientDeny[recipient] {
+		return false
 	}
+	if len(d.recipientAllow) > 0 && !d.recipientAllow[recipient] {
+		return false
+	}
+	return true
+}
+
+// SetRecipientFilter 设置接收地址白名单/黑名单
+func (d *Decoder) SetRecipientFilter(allowlist, denylist []common.Address) {
+	allow := make(map[common.Address]bool, len(allowlist))
+	for _, address := range allowlist {
+		allow[address] = true
+	}
+	deny := make(map[common.Address]bool, len(denylist))
+	for _, address := range denylist {
+		deny[address] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.recipientAllow = allow
+	d.recipientDeny = deny
 }
 
 // GetStats 获取统计信息
@@ -231,9 +587,23 @@ func (d *Decoder) GetStats() map[string]interface{} {
 	defer d.mu.RUnlock()
 
 	return map[string]interface{}{
-		"processed": d.processed,
-		"filtered":  d.filtered,
-		"decoded":   d.decoded,
+		"processed":          d.processed,
+		"filtered":           d.filtered,
+		"decoded":            d.decoded,
+		"cancelled":          d.cancelled,
+		"recipient_filtered": d.recipientFiltered,
+		"pair_filtered":      d.pairFiltered,
+		"lending_decoded":    d.lendingDecoded,
+		"mev_resistant":      d.mevResistant,
+		"replacements":       d.replacements,
+		"anomalous_gas":      d.anomalousGas,
+		"blob_skipped":       d.blobSkipped,
+		"likely_private":     d.likelyPriv,
+		"nonce_blocked":      d.nonceBlocked,
+		"pending_bound":      d.bound.GetStats(),
+		"spam":               d.spam.GetStats(),
+		"attackers":          d.pools.GetStats(),
+		"approvals":          d.approvals.GetStats(),
 		"success_rate": func() float64 {
 			if d.processed == 0 {
 				return 0
@@ -243,80 +613,162 @@ func (d *Decoder) GetStats() map[string]interface{} {
 	}
 }
 
-// FilterTransaction 过滤交易（公开方法，可供外部调用）
+// SetPendingBound 设置各pending跟踪器共享的容量上限（max <= 0 表示不限制）
+func (d *Decoder) SetPendingBound(max int) {
+	bound := NewPendingBound(max)
+	d.pending.SetBound(bound)
+	d.privacy.SetBound(bound)
+	d.nonces.SetBound(bound)
 
-// IsSupportedContract 检查是否支持该合约
-func IsSupportedContract(address common.Address) bool {
-	_, exists := SupportedDEX[address]
-	return exists
+	d.mu.Lock()
+	d.bound = bound
+	d.mu.Unlock()
 }
 
-// IsSwapMethod 检查是否是交换方法
-func IsSwapMethod(methodID []byte) bool {
-	for _, supportedMethod := range SupportedSwapMethods {
-		if len(methodID) >= 4 && len(supportedMethod) >= 4 {
-			if string(methodID[:4]) == string(supportedMethod[:4]) {
-				return true
-			}
-		}
+// SetSpamDetection 设置垃圾交易聚类识别：窗口内不同发送者达到 minSenders 时标记（minSenders <= 1 表示不启用）
+func (d *Decoder) SetSpamDetection(window time.Duration, minSenders int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if minSenders <= 1 {
+		d.spam = nil
+		return
 	}
-	return false
+	d.spam = NewSpamDetector(window, minSenders)
+}
+
+func (d *Decoder) spamDetector() *SpamDetector {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.spam
 }
 
-// GetDEXName 根据合约地址获取DEX名称
-func GetDEXName(address common.Address) string {
-	if name, exists := SupportedDEX[address]; exists {
-		return name
+// SetAttackerDetection 设置夹子攻击者识别：window 内同一交易对上抢跑pending交易的交换标记为 LikelyAttacker（window <= 0 表示不启用）
+func (d *Decoder) SetAttackerDetection(window time.Duration, sizeRatio float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if window <= 0 {
+		d.pools = nil
+		return
 	}
-	return address.Hex()[:10] + "..."
+	d.pools = NewPoolIndex(window, sizeRatio)
 }
 
-// GetMethodName 根据方法ID获取方法名称
-func GetMethodName(methodID []byte) string {
-	for name, id := range SupportedSwapMethods {
-		if len(methodID) >= 4 && len(id) >= 4 {
-			if string(methodID[:4]) == string(id[:4]) {
-				return name
-			}
-		}
+// UpdateBaseFee 新区块到达时更新基础费用，攻击者识别按有效小费比较
+func (d *Decoder) UpdateBaseFee(baseFee *big.Int) {
+	d.poolIndex().SetBaseFee(baseFee)
+}
+
+func (d *Decoder) poolIndex() *PoolIndex {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.pools
+}
+
+// SetApprovalTracking 设置路由授权跟踪：window 内同一发送者的后续交换标记为 LeadingApproval（window <= 0 表示不启用）
+func (d *Decoder) SetApprovalTracking(window time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if window <= 0 {
+		d.approvals = nil
+		return
 	}
-	return "unknown"
+	d.approvals = NewApprovalTracker(d.chain, window)
+}
+
+func (d *Decoder) approvalTracker() *ApprovalTracker {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.approvals
+}
+
+// ObserveBlock 用新区块更新私有订单流识别统计和发送者的已打包nonce
+func (d *Decoder) ObserveBlock(block *ethtypes.Block) {
+	d.privacy.ObserveBlock(block)
+	d.nonces.ObserveBlock(block)
+}
+
+// NeedsBlocks 是否有待与区块核对的pending交易（首次发现记录或nonce序列）：没有时不需要拉取完整区块，
+// 该区块中未见过的交换也不计入私有订单流统计
+func (d *Decoder) NeedsBlocks() bool {
+	return d.privacy.Pending() > 0 || d.nonces.Pending() > 0
+}
+
+// MarkListenerGap 监听器可能漏掉了pending交易（丢弃或断线），暂停把未见过的交换计为私有订单流
+func (d *Decoder) MarkListenerGap() {
+	d.privacy.MarkGap()
 }
 
 // 预定义的合约地址和方法签名
 var (
-	// 常见DEX路由器地址
-	UniswapV2Router = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
-	UniswapV3Router = common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564")
-	SushiSwapRouter = common.HexToAddress("0xd9e1cE17f2641f24aE83637ab66a2cca9C378B9F")
-
 	// 常见交换方法签名
 	MethodSwapExactETHForTokens    = []byte{0x7f, 0xf3, 0x6a, 0xb5} // swapExactETHForTokens
-	MethodSwapExactTokensForETH    = []byte{0x18, 0xcb, 0xaf, 0x05} // swapExactTokensForETH
+	MethodSwapExactTokensForETH    = []byte{0x18, 0xcb, 0xaf, 0xe5} // swapExactTokensForETH
 	MethodSwapExactTokensForTokens = []byte{0x38, 0xed, 0x17, 0x39} // swapExactTokensForTokens
-
-	// 支持的DEX列表
-	SupportedDEX = map[common.Address]string{
-		UniswapV2Router: "Uniswap V2",
-		UniswapV3Router: "Uniswap V3",
-		SushiSwapRouter: "SushiSwap",
-	}
-
-	// 支持的交换方法
-	SupportedSwapMethods = map[string][]byte{
+	MethodSwapETHForExactTokens    = []byte{0xfb, 0x3b, 0xdb, 0x41} // swapETHForExactTokens
+	MethodSwapTokensForExactETH    = []byte{0x4a, 0x25, 0xd9, 0x4a} // swapTokensForExactETH
+	MethodSwapTokensForExactTokens = []byte{0x88, 0x03, 0xdb, 0xee} // swapTokensForExactTokens
+
+	// Uniswap V3 SwapRouter 交换方法签名
+	MethodExactInputSingle  = []byte{0x41, 0x4b, 0xf3, 0x89} // exactInputSingle
+	MethodExactInput        = []byte{0xc0, 0x4b, 0x8d, 0x59} // exactInput
+	MethodExactOutputSingle = []byte{0xdb, 0x3e, 0x21, 0x98} // exactOutputSingle
+	MethodExactOutput       = []byte{0xf2, 0x8c, 0x04, 0x98} // exactOutput
+
+	// swapMethods 全部可解码的交换方法（NewChain 按链上路由器版本从中筛选）
+	swapMethods = map[string][]byte{
 		"swapExactETHForTokens":    MethodSwapExactETHForTokens,
 		"swapExactTokensForETH":    MethodSwapExactTokensForETH,
 		"swapExactTokensForTokens": MethodSwapExactTokensForTokens,
+		"swapETHForExactTokens":    MethodSwapETHForExactTokens,
+		"swapTokensForExactETH":    MethodSwapTokensForExactETH,
+		"swapTokensForExactTokens": MethodSwapTokensForExactTokens,
+
+		"exactInputSingle":  MethodExactInputSingle,
+		"exactInput":        MethodExactInput,
+		"exactOutputSingle": MethodExactOutputSingle,
+		"exactOutput":       MethodExactOutput,
 	}
+
+	// 各交换方法的典型Gas限制（单跳交换的宽松上限）
+	ExpectedGasLimits = map[string]uint64{
+		"swapExactETHForTokens":    250000,
+		"swapExactTokensForETH":    250000,
+		"swapExactTokensForTokens": 300000,
+		"swapETHForExactTokens":    250000,
+		"swapTokensForExactETH":    250000,
+		"swapTokensForExactTokens": 300000,
+
+		"exactInputSingle":  250000,
+		"exactInput":        400000,
+		"exactOutputSingle": 250000,
+		"exactOutput":       400000,
+	}
+
+	// Gas限制超过典型值的倍数时视为异常
+	AnomalousGasFactor uint64 = 4
 )
 
+// IsAnomalousGasLimit 检查Gas限制是否远超该方法的预期值
+func IsAnomalousGasLimit(method string, gasLimit uint64) bool {
+	expected, exists := ExpectedGasLimits[method]
+	if !exists {
+		return false
+	}
+	return gasLimit > expected*AnomalousGasFactor
+}
+
 // FilterTransaction 过滤交易（公开方法，可供外部调用）
 func (d *Decoder) FilterTransaction(tx *types.Transaction) bool {
+	// 第一道过滤：选择器不在交换方法位图中的交易无需任何查找
+	if !d.selectorBloomOff.Load() && !d.chain.MayBeSwapSelector(tx.Data) {
+		return false
+	}
+
 	if tx.To == nil {
 		return false
 	}
 
-	if !IsSupportedContract(*tx.To) {
+	if !d.chain.IsSupportedContract(*tx.To) {
 		return false
 	}
 
@@ -325,7 +777,33 @@ func (d *Decoder) FilterTransaction(tx *types.Transaction) bool {
 	}
 
 	methodID := tx.Data[:4]
-	return IsSwapMethod(methodID)
+	if _, custom := d.chain.customSwapMethod(*tx.To, methodID); custom {
+		return true
+	}
+	return d.chain.IsSwapMethod(methodID)
+}
+
+// PreFilter 监听器侧的廉价预过滤：只放行目标交换交易、抗MEV结算、替代已跟踪交换的交易
+// （含取消交易）以及启用时的授权和借贷交易。被丢弃的交易仍计入发送者的nonce序列
+func (d *Decoder) PreFilter(tx *types.Transaction) bool {
+	// 任意交易都可能填上其后交换交易前面的nonce空缺，丢弃前先记录
+	d.nonces.Observe(tx)
+
+	if tx.IsBlob() {
+		return false
+	}
+	if d.approvalTracker() != nil {
+		if _, _, ok := d.chain.IsRouterApproval(tx); ok {
+			return true
+		}
+	}
+	return d.FilterTransaction(tx) || IsMEVResistant(tx) || d.pending.Replaces(tx) ||
+		(d.lendingEnabled() && d.chain.IsLendingTransaction(tx))
+}
+
+// IsSuperseded 检查交易是否已被取消交易替代
+func (d *Decoder) IsSuperseded(hash common.Hash) bool {
+	return d.pending.IsSuperseded(hash)
 }
 
 // DecodeTransaction 解码交易（公开方法，可供外部调用）
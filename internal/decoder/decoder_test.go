@@ -0,0 +1,392 @@
+package decoder
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// uniswapV2Router 主网 Uniswap V2 Router02
+var uniswapV2Router = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+
+// mainnetChain 以太坊主网的解码配置（每次新建，测试注册的路由ABI互不影响）
+func mainnetChain(t *testing.T) *Chain {
+	t.Helper()
+	chain, err := LookupChain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+// swapTx 构造发往 router 的交易（calldata 为十六进制字符串）
+func swapTx(t *testing.T, router common.Address, value *big.Int, calldata string) *types.Transaction {
+	t.Helper()
+	data, err := hexutil.Decode(calldata)
+	if err != nil {
+		t.Fatalf("invalid calldata fixture: %v", err)
+	}
+	return &types.Transaction{
+		Hash:     common.BytesToHash(data[:32]),
+		From:     common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72"),
+		To:       &router,
+		Value:    value,
+		GasPrice: big.NewInt(20e9),
+		GasLimit: 200000,
+		Data:     data,
+		ChainID:  big.NewInt(1),
+	}
+}
+
+// 主网代币
+var (
+	weth = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	usdt = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	dai  = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+)
+
+func TestDecodeAmountOutMinFromCalldata(t *testing.T) {
+	recipient := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+	tests := []struct {
+		name         string
+		value        *big.Int
+		calldata     string
+		amountIn     string
+		amountOutMin string
+		path         []common.Address
+		direction    string
+		tokenIn      common.Address
+		tokenOut     common.Address
+	}{
+		{
+			// swapExactETHForTokens(29412.345678 USDC, [WETH, USDC], to, deadline)，附带 10 ETH
+			name:         "swapExactETHForTokens",
+			value:        new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)),
+			calldata:     "0x7ff36ab500000000000000000000000000000000000000000000000000000006d91cc74e00000000000000000000000000000000000000000000000000000000000000800000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f1000000000000000000000000000000000000000000000000000000000000000002000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			amountIn:     "10000000000000000000",
+			amountOutMin: "29412345678",
+			path:         []common.Address{weth, usdc},
+			direction:    "buy",
+			tokenIn:      types.NativeToken,
+			tokenOut:     usdc,
+		},
+		{
+			// swapExactTokensForETH(1500 USDT, 0.731 ETH, [USDT, WETH], to, deadline)
+			name:         "swapExactTokensForETH",
+			value:        big.NewInt(0),
+			calldata:     "0x18cbafe50000000000000000000000000000000000000000000000000000000059682f000000000000000000000000000000000000000000000000000a2508a082cf800000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f1000000000000000000000000000000000000000000000000000000000000000002000000000000000000000000dac17f958d2ee523a2206206994597c13d831ec7000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+			amountIn:     "1500000000",
+			amountOutMin: "731000000000000000",
+			path:         []common.Address{usdt, weth},
+			direction:    "sell",
+			tokenIn:      usdt,
+			tokenOut:     types.NativeToken,
+		},
+		{
+			// swapExactTokensForTokens(5000 USDC, 4975 DAI, [USDC, WETH, DAI], to, deadline)
+			name:         "swapExactTokensForTokens",
+			value:        big.NewInt(0),
+			calldata:     "0x38ed1739000000000000000000000000000000000000000000000000000000012a05f20000000000000000000000000000000000000000000000010db1fe8d52005c000000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f1000000000000000000000000000000000000000000000000000000000000000003000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc20000000000000000000000006b175474e89094c44da98b954eedeac495271d0f",
+			amountIn:     "5000000000",
+			amountOutMin: "4975000000000000000000",
+			path:         []common.Address{usdc, weth, dai},
+			direction:    "swap",
+			tokenIn:      usdc,
+			tokenOut:     dai,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := NewDecoder(mainnetChain(t)).DecodeTransaction(swapTx(t, uniswapV2Router, tt.value, tt.calldata))
+			if decoded == nil {
+				t.Fatal("DecodeTransaction() = nil")
+			}
+			if got := decoded.AmountOutMin.String(); got != tt.amountOutMin {
+				t.Errorf("AmountOutMin = %s, want %s", got, tt.amountOutMin)
+			}
+			if got := decoded.AmountIn.String(); got != tt.amountIn {
+				t.Errorf("AmountIn = %s, want %s", got, tt.amountIn)
+			}
+			if !reflect.DeepEqual(decoded.Path, tt.path) {
+				t.Errorf("Path = %v, want %v", decoded.Path, tt.path)
+			}
+			if decoded.SwapDirection != tt.direction || decoded.TokenIn != tt.tokenIn || decoded.TokenOut != tt.tokenOut {
+				t.Errorf("direction %s, %s -> %s; want %s, %s -> %s", decoded.SwapDirection, decoded.TokenIn.Hex(), decoded.TokenOut.Hex(),
+					tt.direction, tt.tokenIn.Hex(), tt.tokenOut.Hex())
+			}
+			if decoded.Recipient != recipient || decoded.Deadline.Int64() != 1700000000 {
+				t.Errorf("to = %s, deadline = %s", decoded.Recipient.Hex(), decoded.Deadline)
+			}
+		})
+	}
+}
+
+// 内置的交换方法ID与路由ABI中按签名计算的一致（否则该方法的交易永远无法按ABI解码）
+func TestSwapMethodIDsMatchRouterABI(t *testing.T) {
+	for name, id := range swapMethods {
+		found := false
+		for _, parsed := range routerABIs {
+			if method, ok := parsed.Methods[name]; ok {
+				found = true
+				if !bytes.Equal(method.ID, id) {
+					t.Errorf("%s: method ID %x, want %x", name, id, method.ID)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: not in any router ABI", name)
+		}
+	}
+}
+
+func TestDecodeMalformedCalldataIsFiltered(t *testing.T) {
+	valid := swapExactETHForTokensCalldata
+	tests := []struct {
+		name     string
+		calldata string
+	}{
+		{name: "selector only", calldata: valid[:10]},
+		{name: "truncated path", calldata: valid[:len(valid)-64]},
+		{name: "path offset out of range", calldata: valid[:74] + "00000000000000000000000000000000000000000000000000000000000fffff" + valid[138:]},
+		// swapExactTokensForETH(1500 USDT, 0.731 ETH, [USDT], to, deadline)：路径只有一个代币
+		{name: "single-token path", calldata: "0x18cbafe50000000000000000000000000000000000000000000000000000000059682f000000000000000000000000000000000000000000000000000a2508a082cf800000000000000000000000000000000000000000000000000000000000000000a00000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f1000000000000000000000000000000000000000000000000000000000000000001000000000000000000000000dac17f958d2ee523a2206206994597c13d831ec7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(mainnetChain(t))
+			data, err := hexutil.Decode(tt.calldata)
+			if err != nil {
+				t.Fatalf("invalid calldata fixture: %v", err)
+			}
+			tx := &types.Transaction{
+				Hash:     common.HexToHash("0x01"),
+				From:     common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72"),
+				To:       &uniswapV2Router,
+				Value:    big.NewInt(1e18),
+				GasPrice: big.NewInt(20e9),
+				GasLimit: 200000,
+				Data:     data,
+				ChainID:  big.NewInt(1),
+			}
+			if decoded := d.DecodeTransaction(tx); decoded != nil {
+				t.Fatalf("DecodeTransaction() = %+v, want nil", decoded)
+			}
+			if stats := d.GetStats(); stats["filtered"] != int64(1) || stats["decoded"] != int64(0) {
+				t.Errorf("stats = %v, want the transaction counted as filtered", stats)
+			}
+		})
+	}
+}
+
+// swapExactETHForTokensCalldata swapExactETHForTokens(29412.345678 USDC, [WETH, USDC], to, deadline)
+const swapExactETHForTokensCalldata = "0x7ff36ab500000000000000000000000000000000000000000000000000000006d91cc74e00000000000000000000000000000000000000000000000000000000000000800000000000000000000000008ba1f109551bd432803012645ac136ddd64dba72000000000000000000000000000000000000000000000000000000006553f1000000000000000000000000000000000000000000000000000000000000000002000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+
+// transferTx 构造发送者的普通转账
+func transferTx(from common.Address, nonce uint64, hash string, gasPrice int64) *types.Transaction {
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	return &types.Transaction{
+		Hash:     common.HexToHash(hash),
+		From:     from,
+		To:       &to,
+		Value:    big.NewInt(1e15),
+		Nonce:    nonce,
+		GasPrice: big.NewInt(gasPrice),
+		GasLimit: 21000,
+		ChainID:  big.NewInt(1),
+	}
+}
+
+// cowSettleCalldata GPv2Settlement.settle(tokens, clearingPrices, trades, interactions) 的调用数据
+const cowSettleCalldata = "0x13d79a0b" +
+	"0000000000000000000000000000000000000000000000000000000000000080" + // tokens 偏移
+	"00000000000000000000000000000000000000000000000000000000000000e0" + // clearingPrices 偏移
+	"0000000000000000000000000000000000000000000000000000000000000140" + // trades 偏移
+	"0000000000000000000000000000000000000000000000000000000000000160" + // interactions 偏移
+	"0000000000000000000000000000000000000000000000000000000000000002" + // tokens: WETH, USDC
+	"000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2" +
+	"000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48" +
+	"0000000000000000000000000000000000000000000000000000000000000002" + // clearingPrices
+	"0000000000000000000000000000000000000000000000000000000077359400" +
+	"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+	"0000000000000000000000000000000000000000000000000000000000000000" + // trades（为简洁省略）
+	"0000000000000000000000000000000000000000000000000000000000000060" + // interactions[3]：前置/中间/后置交互均为空
+	"0000000000000000000000000000000000000000000000000000000000000080" +
+	"00000000000000000000000000000000000000000000000000000000000000a0" +
+	"0000000000000000000000000000000000000000000000000000000000000000" +
+	"0000000000000000000000000000000000000000000000000000000000000000" +
+	"0000000000000000000000000000000000000000000000000000000000000000"
+
+func TestPreFilterPassesNonSwapsTheDecoderNeeds(t *testing.T) {
+	d := NewDecoder(mainnetChain(t))
+	swap := swapTx(t, uniswapV2Router, big.NewInt(1e18), swapExactETHForTokensCalldata)
+	swap.Nonce = 9
+	if d.DecodeTransaction(swap) == nil {
+		t.Fatal("DecodeTransaction() = nil for the tracked swap")
+	}
+
+	cow := common.HexToAddress("0x9008D19f58AAbD9eD0D60971565AA8510560ab41")
+	settlement := transferTx(common.HexToAddress("0x3333333333333333333333333333333333333333"), 0, "0x0c", 30e9)
+	settlement.To = &cow
+	settlement.Value = big.NewInt(0)
+	settlement.Data = []byte{0x13, 0xd7, 0x9a, 0x0b}
+
+	tests := []struct {
+		name string
+		tx   *types.Transaction
+		want bool
+	}{
+		{name: "swap", tx: swap, want: true},
+		{name: "unrelated transfer", tx: transferTx(swap.From, 3, "0x0a", 30e9), want: false},
+		{name: "CoW settlement", tx: settlement, want: true},
+		{name: "transfer replacing tracked swap", tx: transferTx(swap.From, swap.Nonce, "0x0b", 30e9), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.PreFilter(tt.tx); got != tt.want {
+				t.Errorf("PreFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonSwapReplacementSupersedesTrackedSwap(t *testing.T) {
+	d := NewDecoder(mainnetChain(t))
+	swap := swapTx(t, uniswapV2Router, big.NewInt(1e18), swapExactETHForTokensCalldata)
+	if d.DecodeTransaction(swap) == nil {
+		t.Fatal("DecodeTransaction() = nil for the tracked swap")
+	}
+
+	underpriced := transferTx(swap.From, swap.Nonce, "0x0a", 21e9)
+	if !d.PreFilter(underpriced) {
+		t.Fatal("PreFilter() dropped a transaction reusing a tracked swap's nonce")
+	}
+	d.DecodeTransaction(underpriced)
+	if d.IsSuperseded(swap.Hash) {
+		t.Fatal("swap superseded by an underpriced replacement")
+	}
+
+	replacement := transferTx(swap.From, swap.Nonce, "0x0b", 22e9)
+	if decoded := d.DecodeTransaction(replacement); decoded != nil {
+		t.Errorf("DecodeTransaction() decoded the transfer as %q", decoded.Method)
+	}
+	if !d.IsSuperseded(swap.Hash) {
+		t.Error("swap not superseded by a non-swap replacement")
+	}
+}
+
+func TestPreFilterObservesDroppedNonceGapFills(t *testing.T) {
+	d := NewDecoder(mainnetChain(t))
+	sender := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+
+	if d.PreFilter(transferTx(sender, 4, "0x04", 20e9)) {
+		t.Fatal("PreFilter() passed an unrelated transfer")
+	}
+	swap := swapTx(t, uniswapV2Router, big.NewInt(1e18), swapExactETHForTokensCalldata)
+	swap.Nonce = 6
+	if !d.PreFilter(swap) {
+		t.Fatal("PreFilter() dropped the swap")
+	}
+	if decoded := d.DecodeTransaction(swap); decoded == nil || !decoded.NonceBlocked {
+		t.Fatal("swap behind a nonce gap not marked NonceBlocked")
+	}
+
+	// 填上空缺的转账被预过滤丢弃，但仍计入nonce序列
+	d.PreFilter(transferTx(sender, 5, "0x05", 20e9))
+	if d.nonces.Blocked(sender, swap.Nonce) {
+		t.Error("gap filled by a prefiltered transfer still reported as blocked")
+	}
+}
+
+func TestNeedsBlocksOnlyWithPendingTransactions(t *testing.T) {
+	d := NewDecoder(mainnetChain(t))
+	if d.NeedsBlocks() {
+		t.Fatal("NeedsBlocks() = true with nothing tracked")
+	}
+
+	d.PreFilter(transferTx(common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72"), 4, "0x04", 20e9))
+	if !d.NeedsBlocks() {
+		t.Error("NeedsBlocks() = false with a tracked nonce")
+	}
+}
+
+func TestDecodeCoWSettlementIsNotASwap(t *testing.T) {
+	d := NewDecoder(mainnetChain(t))
+	settlement := swapTx(t, common.HexToAddress("0x9008D19f58AAbD9eD0D60971565AA8510560ab41"), big.NewInt(0), cowSettleCalldata)
+
+	decoded := d.DecodeTransaction(settlement)
+	if decoded == nil {
+		t.Fatal("DecodeTransaction() = nil for a CoW settlement")
+	}
+	if !decoded.MEVResistant || decoded.Method != "settle" {
+		t.Errorf("decoded as %q, MEVResistant = %v; want a MEV-resistant settle", decoded.Method, decoded.MEVResistant)
+	}
+	if decoded.IsSwap || len(decoded.Path) != 0 || decoded.SwapDirection != "" {
+		t.Errorf("settlement decoded as a swap: IsSwap = %v, path = %v, direction = %q", decoded.IsSwap, decoded.Path, decoded.SwapDirection)
+	}
+}
+
+func BenchmarkPreFilter(b *testing.B) {
+	chain, err := LookupChain(1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	d := NewDecoder(chain)
+	data, _ := hexutil.Decode(swapExactETHForTokensCalldata)
+	swap := &types.Transaction{
+		Hash:     common.HexToHash("0x01"),
+		From:     common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72"),
+		To:       &uniswapV2Router,
+		Value:    big.NewInt(1e18),
+		GasPrice: big.NewInt(20e9),
+		Data:     data,
+		ChainID:  big.NewInt(1),
+	}
+	txs := []*types.Transaction{swap}
+	for i := 0; i < 15; i++ {
+		txs = append(txs, transferTx(common.BigToAddress(big.NewInt(int64(i+1))), uint64(i), hexutil.EncodeUint64(uint64(i+2)), 20e9))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.PreFilter(txs[i%len(txs)])
+	}
+}
+
+func TestRestartWorkersResumesConsumption(t *testing.T) {
+	d := NewDecoder(mainnetChain(t))
+	txChan := make(chan *types.Transaction, 4)
+	decodedTxChan := make(chan *types.DecodedTransaction, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.StartWorkerPool(ctx, txChan, decodedTxChan, 1)
+
+	// 模拟工作线程全部退出：之后进入的交易无人处理
+	d.mu.Lock()
+	d.poolCancel()
+	d.mu.Unlock()
+	d.Wait()
+	txChan <- swapTx(t, uniswapV2Router, big.NewInt(1e18), swapExactETHForTokensCalldata)
+	if got := d.Progress(); got != 0 {
+		t.Fatalf("Progress() = %d after workers exited, want 0", got)
+	}
+
+	d.RestartWorkers()
+	select {
+	case <-decodedTxChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("restarted workers did not consume the backlog")
+	}
+	if got := d.Progress(); got != 1 {
+		t.Errorf("Progress() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,196 @@
+package decoder
+
+import (
+	"math/big"
+
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// 借贷协议方法签名（目前支持 Aave V3）
+var (
+	MethodAaveBorrow   = []byte{0xa4, 0x15, 0xbc, 0xad} // borrow(address,uint256,uint256,uint16,address)
+	MethodAaveWithdraw = []byte{0x69, 0x32, 0x8d, 0xec} // withdraw(address,uint256,address)
+
+	// MethodOracleTransmit Chainlink OCR 聚合器提交新价格：transmit(bytes,bytes32[],bytes32[],bytes32)
+	MethodOracleTransmit = []byte{0xc9, 0x80, 0x75, 0x39}
+
+	// 可能降低健康因子的借贷方法
+	SupportedLendingMethods = map[string][]byte{
+		"borrow":   MethodAaveBorrow,
+		"withdraw": MethodAaveWithdraw,
+	}
+)
+
+// transmit 的参数和 OCR 报告的编码：report = (bytes32 rawReportContext, bytes32 rawObservers, int192[] observations)
+var (
+	transmitArgs = abi.Arguments{{Type: mustNewType("bytes")}, {Type: mustNewType("bytes32[]")}, {Type: mustNewType("bytes32[]")}, {Type: mustNewType("bytes32")}}
+	reportArgs   = abi.Arguments{{Type: mustNewType("bytes32")}, {Type: mustNewType("bytes32")}, {Type: mustNewType("int192[]")}}
+)
+
+func mustNewType(name string) abi.Type {
+	t, err := abi.NewType(name, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// isOracleTransmit 是否为预言机聚合器的价格提交（聚合器地址不固定，只按方法签名识别，由模拟器按价格源过滤）
+func isOracleTransmit(data []byte) bool {
+	return len(data) >= 4 && string(data[:4]) == string(MethodOracleTransmit)
+}
+
+// oracleAnswer 解析 transmit 报告中的新价格：观测值已排序，中位数即聚合器写入的价格
+func oracleAnswer(data []byte) (*big.Int, bool) {
+	values, err := transmitArgs.Unpack(data[4:])
+	if err != nil {
+		return nil, false
+	}
+	report, err := reportArgs.Unpack(values[0].([]byte))
+	if err != nil {
+		return nil, false
+	}
+	observations := report[2].([]*big.Int)
+	if len(observations) == 0 {
+		return nil, false
+	}
+	answer := observations[len(observations)/2]
+	if answer.Sign() <= 0 {
+		return nil, false
+	}
+	return answer, true
+}
+
+// lendingMethodName 根据方法ID获取借贷方法名称，不支持时返回空
+func lendingMethodName(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	for name, id := range SupportedLendingMethods {
+		if string(data[:4]) == string(id) {
+			return name
+		}
+	}
+	return ""
+}
+
+// IsLendingTransaction 检查是否为支持的借贷协议中可能降低健康因子的交易，或可能改变头寸价值的预言机价格更新
+func (c *Chain) IsLendingTransaction(tx *types.Transaction) bool {
+	if tx.To == nil || len(c.lending) == 0 {
+		return false
+	}
+	if isOracleTransmit(tx.Data) {
+		return true
+	}
+	if _, exists := c.lending[*tx.To]; !exists {
+		return false
+	}
+	return lendingMethodName(tx.Data) != ""
+}
+
+// SetLendingDetection 设置是否解码借贷交易（用于清算策略）
+func (d *Decoder) SetLendingDetection(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lending = enabled
+}
+
+func (d *Decoder) lendingEnabled() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.lending
+}
+
+// decodeLendingTransaction 解码借贷交易：资产、数量和头寸所属账户；
+// 预言机价格更新的 TargetContract 为聚合器地址，AmountIn 为新价格
+func (d *Decoder) decodeLendingTransaction(tx *types.Transaction) *types.DecodedTransaction {
+	if isOracleTransmit(tx.Data) {
+		return d.decodeOracleUpdate(tx)
+	}
+
+	method := lendingMethodName(tx.Data)
+	data := tx.Data
+
+	decodedTx := &types.DecodedTransaction{
+		Transaction:    tx,
+		Method:         method,
+		MethodID:       data[:4],
+		TargetContract: *tx.To,
+		Account:        tx.From,
+	}
+
+	switch method {
+	case "borrow":
+		// borrow(address asset, uint256 amount, uint256 interestRateMode, uint16 referralCode, address onBehalfOf)
+		if len(data) < 4+32*5 {
+			return d.rejectLending()
+		}
+		decodedTx.TokenIn = readAddress(data, 0)
+		decodedTx.AmountIn = readUint256(data, 1)
+		decodedTx.Account = readAddress(data, 4)
+	case "withdraw":
+		// withdraw(address asset, uint256 amount, address to)，头寸属于调用者
+		if len(data) < 4+32*3 {
+			return d.rejectLending()
+		}
+		decodedTx.TokenIn = readAddress(data, 0)
+		decodedTx.AmountIn = readUint256(data, 1)
+		decodedTx.Recipient = readAddress(data, 2)
+	}
+
+	decodedTx.OpportunityID = lifecycle.NewID()
+	d.lifecycleRecorder().Emit(decodedTx.OpportunityID, lifecycle.StageDetected, tx.Hash, map[string]interface{}{
+		"method":   decodedTx.Method,
+		"contract": decodedTx.TargetContract,
+		"account":  decodedTx.Account,
+	})
+	logger.Info("发现借贷交易", "tx_hash", tx.Hash.Hex(), "protocol", d.chain.lending[*tx.To], "method", method, "account", decodedTx.Account.Hex())
+
+	d.mu.Lock()
+	d.lendingDecoded++
+	d.decoded++
+	d.mu.Unlock()
+
+	return decodedTx
+}
+
+// decodeOracleUpdate 解码预言机价格更新
+func (d *Decoder) decodeOracleUpdate(tx *types.Transaction) *types.DecodedTransaction {
+	answer, ok := oracleAnswer(tx.Data)
+	if !ok {
+		return d.rejectLending()
+	}
+
+	decodedTx := &types.DecodedTransaction{
+		Transaction:    tx,
+		Method:         types.MethodOracleUpdate,
+		MethodID:       tx.Data[:4],
+		TargetContract: *tx.To,
+		AmountIn:       answer,
+		OpportunityID:  lifecycle.NewID(),
+	}
+	d.lifecycleRecorder().Emit(decodedTx.OpportunityID, lifecycle.StageDetected, tx.Hash, map[string]interface{}{
+		"method":   decodedTx.Method,
+		"contract": decodedTx.TargetContract,
+		"answer":   answer.String(),
+	})
+	logger.Debug("发现预言机价格更新", "tx_hash", tx.Hash.Hex(), "aggregator", tx.To.Hex(), "answer", answer.String())
+
+	d.mu.Lock()
+	d.lendingDecoded++
+	d.decoded++
+	d.mu.Unlock()
+
+	return decodedTx
+}
+
+// rejectLending 参数不完整的借贷交易计为过滤
+func (d *Decoder) rejectLending() *types.DecodedTransaction {
+	d.mu.Lock()
+	d.filtered++
+	d.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,78 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// transmitCalldata 构造 Chainlink OCR transmit 调用，报告中包含给定的（已排序）观测值
+func transmitCalldata(t *testing.T, observations ...int64) string {
+	t.Helper()
+	values := make([]*big.Int, len(observations))
+	for i, v := range observations {
+		values[i] = big.NewInt(v)
+	}
+	report, err := reportArgs.Pack([32]byte{1}, [32]byte{2}, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := transmitArgs.Pack(report, [][32]byte{{3}}, [][32]byte{{4}}, [32]byte{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hexutil.Encode(append(append([]byte{}, MethodOracleTransmit...), data...))
+}
+
+func TestOracleAnswer(t *testing.T) {
+	tests := []struct {
+		name         string
+		observations []int64
+		want         *big.Int
+	}{
+		{name: "median of odd count", observations: []int64{1990e8, 2000e8, 2010e8}, want: big.NewInt(2000e8)},
+		{name: "upper median of even count", observations: []int64{1990e8, 2000e8, 2010e8, 2020e8}, want: big.NewInt(2010e8)},
+		{name: "no observations", observations: nil, want: nil},
+		{name: "non-positive answer", observations: []int64{-1, 0, 0}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := hexutil.Decode(transmitCalldata(t, tt.observations...))
+			answer, ok := oracleAnswer(data)
+			if ok != (tt.want != nil) || (ok && answer.Cmp(tt.want) != 0) {
+				t.Errorf("oracleAnswer() = %v, %v, want %v", answer, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := oracleAnswer(append(append([]byte{}, MethodOracleTransmit...), 0x01)); ok {
+		t.Error("oracleAnswer() accepted truncated calldata")
+	}
+}
+
+func TestDecodeOracleTransmit(t *testing.T) {
+	aggregator := common.HexToAddress("0xE62B71cf983019BFf55bC83B48601ce8419650CC")
+	tx := swapTx(t, aggregator, big.NewInt(0), transmitCalldata(t, 1990e8, 2000e8, 2010e8))
+
+	d := NewDecoder(mainnetChain(t))
+	d.SetLendingDetection(true)
+	decoded := d.DecodeTransaction(tx)
+	if decoded == nil {
+		t.Fatal("DecodeTransaction() = nil for an oracle transmit")
+	}
+	if decoded.Method != types.MethodOracleUpdate || decoded.TargetContract != aggregator {
+		t.Errorf("decoded method/target = %s/%s, want %s/%s", decoded.Method, decoded.TargetContract.Hex(), types.MethodOracleUpdate, aggregator.Hex())
+	}
+	if decoded.AmountIn == nil || decoded.AmountIn.Cmp(big.NewInt(2000e8)) != 0 {
+		t.Errorf("decoded answer = %v, want 2000e8", decoded.AmountIn)
+	}
+
+	// 未开启借贷检测时不解码
+	if decoded := NewDecoder(mainnetChain(t)).DecodeTransaction(tx); decoded != nil {
+		t.Errorf("DecodeTransaction() = %+v with lending detection disabled", decoded)
+	}
+}
@@ -0,0 +1,73 @@
+package decoder
+
+import (
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MEVResistantContracts 抗MEV的订单结算合约（批量拍卖、荷兰拍、提交-揭示等），
+// 成交价由结算方决定，不能按普通交换计算夹子收益
+var MEVResistantContracts = map[common.Address]string{
+	common.HexToAddress("0x9008D19f58AAbD9eD0D60971565AA8510560ab41"): "CoW Protocol",
+	common.HexToAddress("0x00000011F84B9aa48e5f8aA8B9897600006289Be"): "UniswapX",
+	common.HexToAddress("0x6000da47483062A0D734Ba3dc7576Ce6A0B645C4"): "UniswapX",
+	common.HexToAddress("0xA88800CD213dA5Ae406ce248380802BD53b47647"): "1inch Fusion",
+}
+
+// MEVResistantSelectors 抗MEV的结算方法
+var MEVResistantSelectors = map[string][]byte{
+	"settle":       {0x13, 0xd7, 0x9a, 0x0b}, // CoW GPv2Settlement.settle
+	"execute":      {0x3f, 0x62, 0x19, 0x2e}, // UniswapX Reactor.execute
+	"executeBatch": {0x0d, 0x7a, 0x16, 0xc3}, // UniswapX Reactor.executeBatch
+	"settleOrders": {0x09, 0x65, 0xd0, 0x4b}, // 1inch Settlement.settleOrders
+}
+
+// IsMEVResistant 检查交易是否调用抗MEV的结算合约
+func IsMEVResistant(tx *types.Transaction) bool {
+	if tx.To == nil {
+		return false
+	}
+	_, exists := MEVResistantContracts[*tx.To]
+	return exists
+}
+
+// mevResistantMethod 结算方法名称（未知方法返回 "unknown"）
+func mevResistantMethod(data []byte) string {
+	if len(data) >= 4 {
+		for name, id := range MEVResistantSelectors {
+			if string(data[:4]) == string(id) {
+				return name
+			}
+		}
+	}
+	return "unknown"
+}
+
+// decodeMEVResistant 标记抗MEV订单流的交易：不按交换解码（结算交易不携带可夹的交换路径），模拟器直接跳过
+func (d *Decoder) decodeMEVResistant(tx *types.Transaction) *types.DecodedTransaction {
+	decodedTx := &types.DecodedTransaction{
+		Transaction:    tx,
+		Method:         mevResistantMethod(tx.Data),
+		TargetContract: *tx.To,
+		MEVResistant:   true,
+	}
+	if len(tx.Data) >= 4 {
+		decodedTx.MethodID = tx.Data[:4]
+	}
+
+	decodedTx.OpportunityID = lifecycle.NewID()
+	d.lifecycleRecorder().Emit(decodedTx.OpportunityID, lifecycle.StageDetected, tx.Hash, map[string]interface{}{
+		"method":        decodedTx.Method,
+		"contract":      decodedTx.TargetContract,
+		"mev_resistant": true,
+	})
+
+	d.mu.Lock()
+	d.mevResistant++
+	d.decoded++
+	d.mu.Unlock()
+
+	return decodedTx
+}
@@ -0,0 +1,210 @@
+package decoder
+
+import (
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+const nonceMaxGap = 64 // 基准未知时与最小pending nonce相差超过N视为无法判断（最小的pending记录可能已过时）
+
+// nonceEntry 发送者的一笔pending交易
+type nonceEntry struct {
+	hash      common.Hash
+	firstSeen time.Time
+}
+
+// senderNonces 发送者的pending nonce序列
+type senderNonces struct {
+	pending    map[uint64]nonceEntry
+	nextMined  uint64 // 下一个待打包的nonce（区块中观测到的最大nonce+1）
+	minedKnown bool   // nextMined 是否有效（未在区块中观测到时以最小pending nonce为基准）
+}
+
+// NonceTracker 按发送者跟踪pending交易的nonce序列：
+// 交易前面存在未出现的nonce空缺时，要等空缺被填上才能打包（可能长时间卡住）。
+// 开启监听器预过滤时，被丢弃的交易由 PreFilter 记录。
+type NonceTracker struct {
+	mu        sync.Mutex
+	senders   map[common.Address]*senderNonces
+	byHash    map[common.Hash]senderNonce
+	ttl       time.Duration
+	lastPrune time.Time
+	bound     *PendingBound // 全局容量上限（为nil表示不限制）
+}
+
+// NewNonceTracker 创建nonce序列跟踪器
+func NewNonceTracker(ttl time.Duration) *NonceTracker {
+	return &NonceTracker{
+		senders:   make(map[common.Address]*senderNonces),
+		byHash:    make(map[common.Hash]senderNonce),
+		ttl:       ttl,
+		lastPrune: time.Now(),
+	}
+}
+
+// Observe 记录发送者的一笔pending交易（任意类型，nonce空缺可能由普通转账填上）
+func (t *NonceTracker) Observe(tx *types.Transaction) {
+	if tx.From == (common.Address{}) {
+		return
+	}
+
+	t.mu.Lock()
+	s, exists := t.senders[tx.From]
+	if !exists {
+		s = &senderNonces{pending: make(map[uint64]nonceEntry)}
+		t.senders[tx.From] = s
+	}
+
+	// 已打包的nonce，忽略
+	if s.minedKnown && tx.Nonce < s.nextMined {
+		t.mu.Unlock()
+		return
+	}
+
+	if old, exists := s.pending[tx.Nonce]; exists {
+		if old.hash == tx.Hash {
+			t.mu.Unlock()
+			return
+		}
+		// 相同nonce的替代交易
+		delete(t.byHash, old.hash)
+		t.bound.remove(t, old.hash)
+	}
+	s.pending[tx.Nonce] = nonceEntry{hash: tx.Hash, firstSeen: time.Now()}
+	t.byHash[tx.Hash] = senderNonce{from: tx.From, nonce: tx.Nonce}
+	t.pruneLocked()
+	t.mu.Unlock()
+
+	t.bound.add(t, tx.Hash)
+}
+
+// Blocked 检查交易前面是否存在未填上的nonce空缺
+// 基准为已打包的下一个nonce，未知时为该发送者最小的pending nonce；
+// 基准未知且相差过大时无法判断，不视为有空缺
+func (t *NonceTracker) Blocked(from common.Address, nonce uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, exists := t.senders[from]
+	if !exists {
+		return false
+	}
+
+	base := nonce
+	if s.minedKnown {
+		base = s.nextMined
+	} else {
+		for n := range s.pending {
+			if n < base {
+				base = n
+			}
+		}
+	}
+
+	if nonce <= base {
+		return false
+	}
+	if !s.minedKnown && nonce-base > nonceMaxGap {
+		return false
+	}
+	// pending记录少于中间的nonce数时必有空缺，不必逐个检查
+	if uint64(len(s.pending)) < nonce-base {
+		return true
+	}
+	for n := base; n < nonce; n++ {
+		if _, exists := s.pending[n]; !exists {
+			return true
+		}
+	}
+	return false
+}
+
+// ObserveBlock 根据新区块推进发送者的已打包nonce，并清理已打包的pending记录
+func (t *NonceTracker) ObserveBlock(block *ethtypes.Block) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tx := range block.Transactions() {
+		key, exists := t.byHash[tx.Hash()]
+		if !exists {
+			continue
+		}
+		s := t.senders[key.from]
+		if !s.minedKnown || key.nonce+1 > s.nextMined {
+			s.nextMined = key.nonce + 1
+			s.minedKnown = true
+		}
+		for n, entry := range s.pending {
+			if n < s.nextMined {
+				t.forgetLocked(key.from, n, entry.hash)
+			}
+		}
+	}
+}
+
+// Pending 跟踪中的pending交易数
+func (t *NonceTracker) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.byHash)
+}
+
+// SetBound 设置全局容量上限
+func (t *NonceTracker) SetBound(bound *PendingBound) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bound = bound
+}
+
+// evictPending 被全局容量上限淘汰
+func (t *NonceTracker) evictPending(hash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, exists := t.byHash[hash]
+	if !exists {
+		return
+	}
+	delete(t.byHash, hash)
+	if s, exists := t.senders[key.from]; exists {
+		delete(s.pending, key.nonce)
+		if len(s.pending) == 0 {
+			delete(t.senders, key.from)
+		}
+	}
+}
+
+// forgetLocked 删除一笔pending记录并从全局上限注销（调用方需持有锁）
+// 发送者没有pending交易后整体删除（已打包nonce基准随之丢弃）
+func (t *NonceTracker) forgetLocked(from common.Address, nonce uint64, hash common.Hash) {
+	delete(t.byHash, hash)
+	t.bound.remove(t, hash)
+	if s, exists := t.senders[from]; exists {
+		delete(s.pending, nonce)
+		if len(s.pending) == 0 {
+			delete(t.senders, from)
+		}
+	}
+}
+
+// pruneLocked 清理过期记录（调用方需持有锁）
+func (t *NonceTracker) pruneLocked() {
+	now := time.Now()
+	if now.Sub(t.lastPrune) < t.ttl/10 {
+		return
+	}
+	t.lastPrune = now
+
+	for from, s := range t.senders {
+		for n, entry := range s.pending {
+			if now.Sub(entry.firstSeen) > t.ttl {
+				t.forgetLocked(from, n, entry.hash)
+			}
+		}
+	}
+}
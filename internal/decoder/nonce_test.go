@@ -0,0 +1,75 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// pendingNonce 构造发送者的一笔pending交易（哈希与链上交易一致，供 ObserveBlock 匹配）
+func pendingNonce(from common.Address, nonce uint64) (*types.Transaction, *ethtypes.Transaction) {
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	raw := ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(20e9), Gas: 21000, To: &to, Value: big.NewInt(1)})
+	return &types.Transaction{Hash: raw.Hash(), From: from, To: &to, Nonce: nonce}, raw
+}
+
+func TestNonceTrackerGapFillAndMinedAdvance(t *testing.T) {
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tracker := NewNonceTracker(time.Minute)
+
+	tx4, raw4 := pendingNonce(sender, 4)
+	tx5, _ := pendingNonce(sender, 5)
+	tx6, _ := pendingNonce(sender, 6)
+	tx8, _ := pendingNonce(sender, 8)
+
+	steps := []struct {
+		name    string
+		observe *types.Transaction
+		mined   *ethtypes.Transaction
+		nonce   uint64
+		blocked bool
+	}{
+		{name: "first nonce seen", observe: tx4, nonce: 4, blocked: false},
+		{name: "gap at 5", observe: tx6, nonce: 6, blocked: true},
+		{name: "gap filled", observe: tx5, nonce: 6, blocked: false},
+		{name: "gap at 7", observe: tx8, nonce: 8, blocked: true},
+		{name: "mined advance keeps gap", mined: raw4, nonce: 8, blocked: true},
+		{name: "nonce below mined base", nonce: 3, blocked: false},
+		{name: "far nonce with known base", nonce: 4 + nonceMaxGap + 10, blocked: true},
+	}
+	for _, step := range steps {
+		if step.observe != nil {
+			tracker.Observe(step.observe)
+		}
+		if step.mined != nil {
+			header := &ethtypes.Header{Number: big.NewInt(1)}
+			tracker.ObserveBlock(ethtypes.NewBlockWithHeader(header).WithBody([]*ethtypes.Transaction{step.mined}, nil))
+		}
+		if got := tracker.Blocked(sender, step.nonce); got != step.blocked {
+			t.Errorf("%s: Blocked(%d) = %v, want %v", step.name, step.nonce, got, step.blocked)
+		}
+	}
+}
+
+func TestNonceTrackerUnknownBaseFarGapNotBlocked(t *testing.T) {
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tracker := NewNonceTracker(time.Minute)
+
+	// 只见过一笔很早的pending交易（可能已被未观测到的交易替代打包），基准未知
+	stale, _ := pendingNonce(sender, 4)
+	tracker.Observe(stale)
+	if tracker.Blocked(sender, 4+nonceMaxGap+1) {
+		t.Error("far nonce flagged blocked without a known mined base")
+	}
+	if !tracker.Blocked(sender, 6) {
+		t.Error("nearby gap not flagged")
+	}
+	if tracker.Blocked(common.HexToAddress("0x3333333333333333333333333333333333333333"), 9) {
+		t.Error("unseen sender flagged blocked")
+	}
+}
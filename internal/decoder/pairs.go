@@ -0,0 +1,49 @@
+package decoder
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TokenPair 代币对（顺序无关，构造时按地址排序）
+type TokenPair struct {
+	Token0 common.Address
+	Token1 common.Address
+}
+
+// NewTokenPair 创建代币对，a/b 顺序不影响结果
+func NewTokenPair(a, b common.Address) TokenPair {
+	if bytes.Compare(a.Bytes(), b.Bytes()) > 0 {
+		a, b = b, a
+	}
+	return TokenPair{Token0: a, Token1: b}
+}
+
+// SetPairWhitelist 设置交易对白名单（为空表示不限制）
+func (d *Decoder) SetPairWhitelist(pairs []TokenPair) {
+	whitelist := make(map[TokenPair]bool, len(pairs))
+	for _, pair := range pairs {
+		whitelist[NewTokenPair(pair.Token0, pair.Token1)] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pairWhitelist = whitelist
+}
+
+// isPairAllowed 交换路径中任一跳的交易对在白名单中即通过
+func (d *Decoder) isPairAllowed(path []common.Address) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if len(d.pairWhitelist) == 0 {
+		return true
+	}
+	for i := 0; i+1 < len(path); i++ {
+		if d.pairWhitelist[NewTokenPair(path[i], path[i+1])] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package decoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// paramsRecord 调用参数日志中的一行
+type paramsRecord struct {
+	Timestamp  time.Time                `json:"timestamp"`
+	TxHash     common.Hash              `json:"tx_hash"`
+	Router     common.Address           `json:"router"`
+	Method     string                   `json:"method"`
+	Parameters []types.DecodedParameter `json:"parameters"`
+}
+
+// ParamsLog 以JSONL格式记录每笔解码交易的全部调用参数（用于排查解码是否正确）
+type ParamsLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenParamsLog 打开调用参数日志（追加写入）
+func OpenParamsLog(path string) (*ParamsLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open decoded params log: %v", err)
+	}
+	return &ParamsLog{file: file}, nil
+}
+
+// Record 写入一笔解码交易的调用参数（为nil时忽略）
+func (p *ParamsLog) Record(decodedTx *types.DecodedTransaction) {
+	if p == nil {
+		return
+	}
+
+	line, err := json.Marshal(paramsRecord{
+		Timestamp:  time.Now(),
+		TxHash:     decodedTx.Transaction.Hash,
+		Router:     decodedTx.TargetContract,
+		Method:     decodedTx.Method,
+		Parameters: decodedTx.Parameters,
+	})
+	if err != nil {
+		logger.Warn("序列化调用参数失败", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		logger.Warn("写入调用参数日志失败", "error", err)
+	}
+}
+
+// Close 关闭文件
+func (p *ParamsLog) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.file.Close()
+}
@@ -0,0 +1,228 @@
+package decoder
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// replacementBumpPercent 节点接受相同nonce替代交易所需的最低Gas价格涨幅（geth 默认10%），
+// 涨幅不足的取消/加速交易会被节点拒绝，原交易仍会打包
+const replacementBumpPercent = 10
+
+// senderNonce 发送者 + nonce
+type senderNonce struct {
+	from  common.Address
+	nonce uint64
+}
+
+// pendingEntry 已跟踪的pending交易
+type pendingEntry struct {
+	hash      common.Hash
+	gasPrice  *big.Int // Gas价格（EIP-1559 交易为费用上限）
+	tipCap    *big.Int // 小费上限（传统交易与 gasPrice 相同）
+	firstSeen time.Time
+}
+
+// PendingTracker 按(发送者, nonce)跟踪已解码的pending交换交易
+type PendingTracker struct {
+	mu         sync.RWMutex
+	byNonce    map[senderNonce]*pendingEntry
+	byHash     map[common.Hash]senderNonce
+	superseded map[common.Hash]time.Time
+	ttl        time.Duration
+	lastPrune  time.Time
+	bound      *PendingBound // 全局容量上限（为nil表示不限制）
+}
+
+// NewPendingTracker 创建pending交易跟踪器
+func NewPendingTracker(ttl time.Duration) *PendingTracker {
+	return &PendingTracker{
+		byNonce:    make(map[senderNonce]*pendingEntry),
+		byHash:     make(map[common.Hash]senderNonce),
+		superseded: make(map[common.Hash]time.Time),
+		ttl:        ttl,
+		lastPrune:  time.Now(),
+	}
+}
+
+// Track 记录一笔已解码的交换交易
+// 如果替代了已跟踪的交易（相同发送者和nonce），将原交易标记为已被替代并返回其哈希；
+// Gas价格涨幅不足的替代交易会被节点拒绝，不记录
+func (t *PendingTracker) Track(tx *types.Transaction) (common.Hash, bool) {
+	if tx.From == (common.Address{}) {
+		return common.Hash{}, false
+	}
+
+	t.mu.Lock()
+	key := senderNonce{from: tx.From, nonce: tx.Nonce}
+	var replacedHash common.Hash
+	if replaced, exists := t.byNonce[key]; exists {
+		if replaced.hash != tx.Hash && !meetsReplacementBump(tx, replaced) {
+			t.mu.Unlock()
+			return common.Hash{}, false
+		}
+		t.forgetLocked(replaced.hash)
+		if replaced.hash != tx.Hash {
+			replacedHash = replaced.hash
+			t.superseded[replaced.hash] = time.Now()
+		}
+	}
+	t.byNonce[key] = &pendingEntry{
+		hash:      tx.Hash,
+		gasPrice:  tx.GasPrice,
+		tipCap:    tipCap(tx),
+		firstSeen: time.Now(),
+	}
+	t.byHash[tx.Hash] = key
+	t.pruneLocked()
+	t.mu.Unlock()
+
+	t.bound.add(t, tx.Hash)
+	return replacedHash, replacedHash != (common.Hash{})
+}
+
+// SetBound 设置全局容量上限
+func (t *PendingTracker) SetBound(bound *PendingBound) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bound = bound
+}
+
+// evictPending 被全局容量上限淘汰
+func (t *PendingTracker) evictPending(hash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if key, exists := t.byHash[hash]; exists {
+		delete(t.byNonce, key)
+		delete(t.byHash, hash)
+	}
+}
+
+// forgetLocked 删除跟踪记录并从全局上限注销（调用方需持有写锁）
+func (t *PendingTracker) forgetLocked(hash common.Hash) {
+	if key, exists := t.byHash[hash]; exists {
+		delete(t.byNonce, key)
+		delete(t.byHash, hash)
+	}
+	t.bound.remove(t, hash)
+}
+
+// CheckCancel 检查交易是否为取消交易（自转账、0金额、相同nonce、Gas价格至少高出10%）
+// 如果取消了已跟踪的交易，将原交易标记为已被替代并返回其哈希
+func (t *PendingTracker) CheckCancel(tx *types.Transaction) (common.Hash, bool) {
+	if !IsCancelTransaction(tx) {
+		return common.Hash{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := senderNonce{from: tx.From, nonce: tx.Nonce}
+	original, exists := t.byNonce[key]
+	if !exists || original.hash == tx.Hash || !meetsReplacementBump(tx, original) {
+		return common.Hash{}, false
+	}
+
+	t.forgetLocked(original.hash)
+	t.superseded[original.hash] = time.Now()
+	return original.hash, true
+}
+
+// CheckReplaced 检查非交换交易是否以相同nonce替代了已跟踪的交换交易（改发其他合约或转账），
+// Gas价格涨幅满足要求时将原交易标记为已被替代并返回其哈希
+func (t *PendingTracker) CheckReplaced(tx *types.Transaction) (common.Hash, bool) {
+	if tx.From == (common.Address{}) {
+		return common.Hash{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := senderNonce{from: tx.From, nonce: tx.Nonce}
+	original, exists := t.byNonce[key]
+	if !exists || original.hash == tx.Hash || !meetsReplacementBump(tx, original) {
+		return common.Hash{}, false
+	}
+
+	t.forgetLocked(original.hash)
+	t.superseded[original.hash] = time.Now()
+	return original.hash, true
+}
+
+// Replaces 交易是否与已跟踪的交换交易使用相同的发送者和nonce（可能是替代交易）
+func (t *PendingTracker) Replaces(tx *types.Transaction) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	original, exists := t.byNonce[senderNonce{from: tx.From, nonce: tx.Nonce}]
+	return exists && original.hash != tx.Hash
+}
+
+// IsSuperseded 检查交易是否已被取消/替代
+func (t *PendingTracker) IsSuperseded(hash common.Hash) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, exists := t.superseded[hash]
+	return exists
+}
+
+// pruneLocked 清理过期记录（调用方需持有写锁）
+func (t *PendingTracker) pruneLocked() {
+	now := time.Now()
+	if now.Sub(t.lastPrune) < t.ttl/10 {
+		return
+	}
+	t.lastPrune = now
+
+	for _, entry := range t.byNonce {
+		if now.Sub(entry.firstSeen) > t.ttl {
+			t.forgetLocked(entry.hash)
+		}
+	}
+	for hash, seen := range t.superseded {
+		if now.Sub(seen) > t.ttl {
+			delete(t.superseded, hash)
+		}
+	}
+}
+
+// meetsReplacementBump 替代交易的Gas价格和小费上限是否都比原交易至少高出 replacementBumpPercent
+func meetsReplacementBump(tx *types.Transaction, original *pendingEntry) bool {
+	return bumped(original.gasPrice, tx.GasPrice) && bumped(original.tipCap, tipCap(tx))
+}
+
+// bumped next >= prev × (100 + replacementBumpPercent) / 100（原值未知时视为满足）
+func bumped(prev, next *big.Int) bool {
+	if prev == nil {
+		return true
+	}
+	if next == nil {
+		return false
+	}
+	required := new(big.Int).Mul(prev, big.NewInt(100+replacementBumpPercent))
+	return new(big.Int).Mul(next, big.NewInt(100)).Cmp(required) >= 0
+}
+
+// tipCap 交易的小费上限（EIP-1559 交易取原始交易的小费上限，否则为Gas价格）
+func tipCap(tx *types.Transaction) *big.Int {
+	if tx.RawTx != nil {
+		return tx.RawTx.GasTipCap()
+	}
+	return tx.GasPrice
+}
+
+// IsCancelTransaction 判断是否为取消交易：发给自己、金额为0
+func IsCancelTransaction(tx *types.Transaction) bool {
+	if tx.To == nil || tx.From == (common.Address{}) {
+		return false
+	}
+	if *tx.To != tx.From {
+		return false
+	}
+	return tx.Value == nil || tx.Value.Sign() == 0
+}
@@ -0,0 +1,78 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCheckCancelRequiresReplacementBump(t *testing.T) {
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	original := &types.Transaction{
+		Hash:     common.HexToHash("0x01"),
+		From:     sender,
+		To:       &uniswapV2Router,
+		Nonce:    7,
+		GasPrice: big.NewInt(100e9),
+	}
+
+	tests := []struct {
+		name     string
+		gasPrice int64
+		want     bool
+	}{
+		{name: "same price", gasPrice: 100e9, want: false},
+		{name: "9.9% bump", gasPrice: 109.9e9, want: false},
+		{name: "10% bump", gasPrice: 110e9, want: true},
+		{name: "large bump", gasPrice: 200e9, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewPendingTracker(time.Minute)
+			tracker.Track(original)
+
+			cancel := &types.Transaction{
+				Hash:     common.HexToHash("0x02"),
+				From:     sender,
+				To:       &sender,
+				Value:    big.NewInt(0),
+				Nonce:    original.Nonce,
+				GasPrice: big.NewInt(tt.gasPrice),
+			}
+			replaced, ok := tracker.CheckCancel(cancel)
+			if ok != tt.want {
+				t.Fatalf("CheckCancel() = %v, want %v", ok, tt.want)
+			}
+			if ok && replaced != original.Hash {
+				t.Errorf("CheckCancel() replaced %s, want %s", replaced.Hex(), original.Hash.Hex())
+			}
+			if tracker.IsSuperseded(original.Hash) != tt.want {
+				t.Errorf("IsSuperseded() = %v, want %v", !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackIgnoresUnderpricedReplacement(t *testing.T) {
+	sender := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tracker := NewPendingTracker(time.Minute)
+	original := &types.Transaction{Hash: common.HexToHash("0x01"), From: sender, Nonce: 3, GasPrice: big.NewInt(50e9)}
+	tracker.Track(original)
+
+	underpriced := &types.Transaction{Hash: common.HexToHash("0x02"), From: sender, Nonce: 3, GasPrice: big.NewInt(54e9)}
+	if _, replaced := tracker.Track(underpriced); replaced {
+		t.Fatal("Track() treated an 8% bump as a replacement")
+	}
+	if tracker.IsSuperseded(original.Hash) {
+		t.Fatal("original marked superseded by an underpriced replacement")
+	}
+
+	bumped := &types.Transaction{Hash: common.HexToHash("0x03"), From: sender, Nonce: 3, GasPrice: big.NewInt(55e9)}
+	if hash, replaced := tracker.Track(bumped); !replaced || hash != original.Hash {
+		t.Fatalf("Track() = (%s, %v), want (%s, true)", hash.Hex(), replaced, original.Hash.Hex())
+	}
+}
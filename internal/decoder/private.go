@@ -0,0 +1,194 @@
+package decoder
+
+import (
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	privacyMinSamples   = 3                // 发送者至少有N次打包观测才做判断
+	privacyMinThreshold = time.Second      // "极快打包"阈值下限
+	privacySeenTTL      = 10 * time.Minute // 首次发现记录保留时间
+	privacyMaxSenders   = 100000           // 发送者统计上限，超出时清空重新学习
+	privacyHealthyAfter = 2 * time.Minute  // 监听连续无空缺N时间后，才把区块中未见过的交换视为私有
+)
+
+// seenEntry 交易首次在公开内存池出现的记录
+type seenEntry struct {
+	from common.Address
+	at   time.Time
+}
+
+// senderInclusion 发送者的打包速度观测
+type senderInclusion struct {
+	fast int // 首次发现到打包间隔极短（或从未公开出现）
+	slow int
+}
+
+// PrivacyTracker 识别疑似私有订单流的交易：
+// 同一发送者的交换交易经常在打包前极短时间才公开出现（或根本没在内存池出现过），
+// 很可能来自私有通道（受保护或诱饵），不应作为夹子目标。
+// "极短"的阈值随观测到的普通打包间隔自适应调整。
+type PrivacyTracker struct {
+	chain     *Chain
+	mu        sync.Mutex
+	firstSeen map[common.Hash]seenEntry
+	senders   map[common.Address]*senderInclusion
+	gapEWMA   float64       // 首次发现到打包的平均间隔(秒)
+	lastGap   time.Time     // 监听最近一次可能漏掉交易的时间（启动时间也视为空缺）
+	bound     *PendingBound // 全局容量上限（为nil表示不限制）
+}
+
+// NewPrivacyTracker 创建私有交易识别器
+func NewPrivacyTracker(chain *Chain) *PrivacyTracker {
+	return &PrivacyTracker{
+		chain:     chain,
+		firstSeen: make(map[common.Hash]seenEntry),
+		senders:   make(map[common.Address]*senderInclusion),
+		lastGap:   time.Now(),
+	}
+}
+
+// MarkGap 记录监听出现空缺（交易被丢弃或连接断开）：之后一段时间内区块中未见过的交换
+// 可能只是我们漏掉了，不计为私有订单流
+func (t *PrivacyTracker) MarkGap() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastGap = time.Now()
+}
+
+// Seen 记录交易首次在内存池出现的时间
+func (t *PrivacyTracker) Seen(tx *types.Transaction) {
+	t.mu.Lock()
+	_, exists := t.firstSeen[tx.Hash]
+	if !exists {
+		t.firstSeen[tx.Hash] = seenEntry{from: tx.From, at: time.Now()}
+	}
+	t.mu.Unlock()
+
+	if !exists {
+		t.bound.add(t, tx.Hash)
+	}
+}
+
+// Pending 等待打包核对的首次发现记录数
+func (t *PrivacyTracker) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.firstSeen)
+}
+
+// SetBound 设置全局容量上限
+func (t *PrivacyTracker) SetBound(bound *PendingBound) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bound = bound
+}
+
+// evictPending 被全局容量上限淘汰
+func (t *PrivacyTracker) evictPending(hash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.firstSeen, hash)
+}
+
+// ObserveBlock 根据新区块中的交换交易更新发送者的打包速度统计
+func (t *PrivacyTracker) ObserveBlock(block *ethtypes.Block) {
+	blockTime := time.Unix(int64(block.Time()), 0)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	threshold := t.fastThresholdLocked()
+	healthy := time.Since(t.lastGap) >= privacyHealthyAfter
+	for _, tx := range block.Transactions() {
+		if entry, seen := t.firstSeen[tx.Hash()]; seen {
+			delete(t.firstSeen, tx.Hash())
+			t.bound.remove(t, tx.Hash())
+			gap := blockTime.Sub(entry.at)
+			t.recordLocked(entry.from, gap < threshold)
+			t.updateGapLocked(gap)
+			continue
+		}
+
+		// 从未在公开内存池出现过的交换交易（监听有空缺时无法区分私有交易和漏掉的交易）
+		if !healthy || tx.To() == nil || !t.chain.IsSupportedContract(*tx.To()) || !t.chain.IsSwapMethod(tx.Data()) {
+			continue
+		}
+		if from, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+			t.recordLocked(from, true)
+		}
+	}
+
+	t.pruneLocked()
+}
+
+// IsLikelyPrivate 发送者的交易是否大多来自私有订单流
+func (t *PrivacyTracker) IsLikelyPrivate(from common.Address) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, exists := t.senders[from]
+	if !exists {
+		return false
+	}
+	total := stats.fast + stats.slow
+	return total >= privacyMinSamples && stats.fast*2 >= total
+}
+
+// fastThresholdLocked 极快打包阈值：平均间隔的1/4，不低于下限
+func (t *PrivacyTracker) fastThresholdLocked() time.Duration {
+	threshold := time.Duration(t.gapEWMA / 4 * float64(time.Second))
+	if threshold < privacyMinThreshold {
+		return privacyMinThreshold
+	}
+	return threshold
+}
+
+func (t *PrivacyTracker) recordLocked(from common.Address, fast bool) {
+	if from == (common.Address{}) {
+		return
+	}
+
+	stats, exists := t.senders[from]
+	if !exists {
+		stats = &senderInclusion{}
+		t.senders[from] = stats
+	}
+	if fast {
+		stats.fast++
+	} else {
+		stats.slow++
+	}
+}
+
+func (t *PrivacyTracker) updateGapLocked(gap time.Duration) {
+	if gap < 0 {
+		gap = 0
+	}
+	seconds := gap.Seconds()
+	if t.gapEWMA == 0 {
+		t.gapEWMA = seconds
+		return
+	}
+	t.gapEWMA = 0.05*seconds + 0.95*t.gapEWMA
+}
+
+func (t *PrivacyTracker) pruneLocked() {
+	now := time.Now()
+	for hash, entry := range t.firstSeen {
+		if now.Sub(entry.at) > privacySeenTTL {
+			delete(t.firstSeen, hash)
+			t.bound.remove(t, hash)
+		}
+	}
+
+	if len(t.senders) > privacyMaxSenders {
+		t.senders = make(map[common.Address]*senderInclusion)
+	}
+}
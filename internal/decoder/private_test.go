@@ -0,0 +1,88 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// unseenSwapBlocks 构造 n 个区块，每个区块包含同一发送者一笔从未在内存池出现过的交换
+func unseenSwapBlocks(t *testing.T, n int) (common.Address, []*ethtypes.Block) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := ethtypes.LatestSignerForChainID(big.NewInt(1))
+
+	// swapExactETHForTokens
+	data := append([]byte{0x7f, 0xf3, 0x6a, 0xb5}, make([]byte, 128)...)
+	blocks := make([]*ethtypes.Block, 0, n)
+	for i := 0; i < n; i++ {
+		tx, err := ethtypes.SignTx(ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(1e9),
+			GasFeeCap: big.NewInt(30e9),
+			Gas:       200000,
+			To:        &uniswapV2Router,
+			Value:     big.NewInt(1e18),
+			Data:      data,
+		}), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := &ethtypes.Header{Number: big.NewInt(int64(i + 1)), Time: uint64(time.Now().Unix())}
+		blocks = append(blocks, ethtypes.NewBlockWithHeader(header).WithBody([]*ethtypes.Transaction{tx}, nil))
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), blocks
+}
+
+func TestUnseenSwapsCountedOnlyWhileListenerHealthy(t *testing.T) {
+	tests := []struct {
+		name    string
+		lastGap time.Duration // 最近一次空缺距今
+		want    bool
+	}{
+		{name: "just started", lastGap: 0, want: false},
+		{name: "recent drop or reconnect", lastGap: privacyHealthyAfter / 2, want: false},
+		{name: "healthy window elapsed", lastGap: privacyHealthyAfter, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewPrivacyTracker(mainnetChain(t))
+			tracker.lastGap = time.Now().Add(-tt.lastGap)
+
+			sender, blocks := unseenSwapBlocks(t, privacyMinSamples)
+			for _, block := range blocks {
+				tracker.ObserveBlock(block)
+			}
+			if got := tracker.IsLikelyPrivate(sender); got != tt.want {
+				t.Errorf("IsLikelyPrivate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkGapPausesUnseenSwapCounting(t *testing.T) {
+	tracker := NewPrivacyTracker(mainnetChain(t))
+	tracker.lastGap = time.Now().Add(-privacyHealthyAfter)
+
+	sender, blocks := unseenSwapBlocks(t, privacyMinSamples)
+	tracker.ObserveBlock(blocks[0])
+	tracker.MarkGap()
+	for _, block := range blocks[1:] {
+		tracker.ObserveBlock(block)
+	}
+
+	if tracker.IsLikelyPrivate(sender) {
+		t.Error("IsLikelyPrivate() = true for swaps that may have been dropped by the listener")
+	}
+	if stats := tracker.senders[sender]; stats == nil || stats.fast != 1 {
+		t.Errorf("sender stats = %+v, want exactly the swap observed before the gap", stats)
+	}
+}
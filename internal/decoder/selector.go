@@ -0,0 +1,63 @@
+package decoder
+
+import "encoding/binary"
+
+// selectorBloomBits 选择器位图大小（位，须为2的幂）
+const selectorBloomBits = 4096
+
+// SelectorBloom 4字节方法选择器位图：按选择器低12位置位，检查为O(1)且不分配内存。
+// 未置位的选择器一定不是已知的交换方法，置位只表示可能是，仍需精确查找
+type SelectorBloom [selectorBloomBits / 64]uint64
+
+// selectorIndex 选择器在位图中的位置（选择器是keccak哈希的前4字节，低位分布均匀）
+func selectorIndex(selector []byte) uint32 {
+	return binary.BigEndian.Uint32(selector[:4]) & (selectorBloomBits - 1)
+}
+
+// Add 加入一个选择器（不足4字节时忽略）
+func (b *SelectorBloom) Add(selector []byte) {
+	if len(selector) < 4 {
+		return
+	}
+	index := selectorIndex(selector)
+	b[index/64] |= 1 << (index % 64)
+}
+
+// MayContain 调用数据的前4字节是否可能是位图中的选择器（不足4字节时返回false）
+func (b *SelectorBloom) MayContain(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	index := selectorIndex(data)
+	return b[index/64]&(1<<(index%64)) != 0
+}
+
+// rebuildSelectors 按链支持的交换方法和路由注册ABI中的交换方法重建位图（RegisterRouterABI 修改后调用）
+func (c *Chain) rebuildSelectors() {
+	bloom := new(SelectorBloom)
+	for _, id := range c.methods {
+		bloom.Add(id)
+	}
+
+	c.customMu.RLock()
+	for _, parsed := range c.customABIs {
+		for _, method := range parsed.Methods {
+			if isSwapShaped(&method) {
+				bloom.Add(method.ID)
+			}
+		}
+	}
+	c.customMu.RUnlock()
+
+	c.selectors.Store(bloom)
+}
+
+// MayBeSwapSelector 调用数据的前4字节是否可能是交换方法选择器（false 表示一定不是）
+func (c *Chain) MayBeSwapSelector(data []byte) bool {
+	return c.selectors.Load().MayContain(data)
+}
+
+// SetSelectorBloom 启用/禁用 FilterTransaction 的选择器位图预过滤（默认启用）
+func (d *Decoder) SetSelectorBloom(enabled bool) {
+	d.selectorBloomOff.Store(!enabled)
+}
@@ -0,0 +1,122 @@
+package decoder
+
+import (
+	"math/big"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// selectorTx 以 selector 开头、发往 router 的交易
+func selectorTx(router common.Address, selector []byte) *types.Transaction {
+	return &types.Transaction{
+		Hash:     common.BytesToHash(selector),
+		To:       &router,
+		Value:    big.NewInt(0),
+		GasPrice: big.NewInt(20e9),
+		Data:     append(append([]byte(nil), selector...), make([]byte, 64)...),
+		ChainID:  big.NewInt(1),
+	}
+}
+
+// 位图只是预过滤：对任何链上支持的选择器，启用位图时的过滤结果与禁用时一致
+func TestSelectorBloomAcceptsEverySupportedSelector(t *testing.T) {
+	custom := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	definition := []byte(`[{"name":"swapViaPath","type":"function","inputs":[{"name":"path","type":"address[]"},{"name":"amountIn","type":"uint256"}],"outputs":[]}]`)
+
+	for _, chainID := range types.SupportedChainIDs() {
+		chain, err := LookupChain(chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chain.RegisterRouterABI(custom, "Custom", definition); err != nil {
+			t.Fatal(err)
+		}
+		d := NewDecoder(chain)
+
+		selectors := make(map[string][]byte)
+		for name, id := range chain.methods {
+			selectors[name] = id
+		}
+		for name, method := range chain.customABIs[custom].Methods {
+			selectors["custom "+name] = method.ID
+		}
+		if len(selectors) < 2 {
+			t.Fatalf("chain %d: only %d selectors under test", chainID, len(selectors))
+		}
+
+		routers := append(chain.Routers(), custom)
+		for name, selector := range selectors {
+			if !chain.MayBeSwapSelector(selector) {
+				t.Errorf("chain %d: bloom rejects %s (%x)", chainID, name, selector)
+			}
+			for _, router := range routers {
+				tx := selectorTx(router, selector)
+				d.SetSelectorBloom(true)
+				withBloom := d.FilterTransaction(tx)
+				d.SetSelectorBloom(false)
+				if exact := d.FilterTransaction(tx); withBloom != exact {
+					t.Errorf("chain %d: %s to %s accepted = %v with the bloom, %v without",
+						chainID, name, router.Hex(), withBloom, exact)
+				}
+			}
+		}
+	}
+}
+
+func TestSelectorBloomRejectsShortCalldata(t *testing.T) {
+	var bloom SelectorBloom
+	bloom.Add(MethodSwapExactETHForTokens)
+	bloom.Add([]byte{0x01, 0x02}) // 不足4字节：忽略
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "added selector", data: MethodSwapExactETHForTokens, want: true},
+		{name: "no calldata", data: nil, want: false},
+		{name: "three bytes", data: MethodSwapExactETHForTokens[:3], want: false},
+		{name: "ERC20 transfer", data: hexutil.MustDecode("0xa9059cbb"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bloom.MayContain(tt.data); got != tt.want {
+				t.Errorf("MayContain(%x) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkFilterTransaction 典型的内存池组合（大部分为转账和非交换调用）下位图预过滤的效果
+func BenchmarkFilterTransaction(b *testing.B) {
+	chain, err := LookupChain(1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	d := NewDecoder(chain)
+
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	txs := []*types.Transaction{selectorTx(uniswapV2Router, MethodSwapExactETHForTokens)}
+	for i := 0; i < 7; i++ {
+		txs = append(txs,
+			transferTx(common.BigToAddress(big.NewInt(int64(i+1))), uint64(i), hexutil.EncodeUint64(uint64(i+2)), 20e9),
+			selectorTx(token, hexutil.MustDecode("0xa9059cbb")))
+	}
+
+	for _, bench := range []struct {
+		name  string
+		bloom bool
+	}{{"bloom", true}, {"no bloom", false}} {
+		b.Run(bench.name, func(b *testing.B) {
+			d.SetSelectorBloom(bench.bloom)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				d.FilterTransaction(txs[i%len(txs)])
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package decoder
+
+import (
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// spamMaxClusters 聚类记录上限，超出时清空重新统计
+const spamMaxClusters = 100000
+
+// spamCluster 同一聚类键在窗口内的发送者
+type spamCluster struct {
+	senders map[common.Address]time.Time
+	flagged bool
+}
+
+// SpamDetector 识别重复逻辑的垃圾交易：代币上线时大量机器人用不同地址
+// 发送几乎相同的交换（相同calldata，或相同方法+路径），窗口内不同发送者
+// 达到阈值后，该聚类的后续交易均标记为 SpamCluster。
+type SpamDetector struct {
+	mu         sync.Mutex
+	window     time.Duration
+	minSenders int
+	clusters   map[common.Hash]*spamCluster
+	flagged    int64 // 被标记的交易数
+	clustersN  int64 // 识别出的聚类数
+}
+
+// NewSpamDetector 创建垃圾交易识别器（minSenders <= 1 表示不启用）
+func NewSpamDetector(window time.Duration, minSenders int) *SpamDetector {
+	return &SpamDetector{
+		window:     window,
+		minSenders: minSenders,
+		clusters:   make(map[common.Hash]*spamCluster),
+	}
+}
+
+// clusterKey 聚类键：已解析路径时使用方法选择器+路径（忽略数量参数），否则使用完整calldata
+func clusterKey(decodedTx *types.DecodedTransaction) common.Hash {
+	data := decodedTx.Transaction.Data
+	if len(decodedTx.Path) == 0 || len(data) < 4 {
+		return crypto.Keccak256Hash(decodedTx.TargetContract.Bytes(), data)
+	}
+
+	buf := make([]byte, 0, common.AddressLength+4+len(decodedTx.Path)*common.AddressLength)
+	buf = append(buf, decodedTx.TargetContract.Bytes()...)
+	buf = append(buf, data[:4]...)
+	for _, token := range decodedTx.Path {
+		buf = append(buf, token.Bytes()...)
+	}
+	return crypto.Keccak256Hash(buf)
+}
+
+// Observe 记录交易并返回其所属聚类是否已被识别为垃圾交易
+func (s *SpamDetector) Observe(decodedTx *types.DecodedTransaction) bool {
+	if s == nil || s.minSenders <= 1 {
+		return false
+	}
+
+	key := clusterKey(decodedTx)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, exists := s.clusters[key]
+	if !exists {
+		if len(s.clusters) >= spamMaxClusters {
+			s.prune(now)
+			if len(s.clusters) >= spamMaxClusters {
+				s.clusters = make(map[common.Hash]*spamCluster)
+			}
+		}
+		cluster = &spamCluster{senders: make(map[common.Address]time.Time)}
+		s.clusters[key] = cluster
+	}
+
+	// 移除窗口外的发送者
+	for sender, at := range cluster.senders {
+		if now.Sub(at) > s.window {
+			delete(cluster.senders, sender)
+		}
+	}
+	cluster.senders[decodedTx.Transaction.From] = now
+
+	if len(cluster.senders) >= s.minSenders {
+		if !cluster.flagged {
+			cluster.flagged = true
+			s.clustersN++
+		}
+	} else {
+		cluster.flagged = false
+	}
+
+	if cluster.flagged {
+		s.flagged++
+	}
+	return cluster.flagged
+}
+
+// prune 清理窗口内已无发送者的聚类（调用方需持有 s.mu）
+func (s *SpamDetector) prune(now time.Time) {
+	for key, cluster := range s.clusters {
+		active := false
+		for _, at := range cluster.senders {
+			if now.Sub(at) <= s.window {
+				active = true
+				break
+			}
+		}
+		if !active {
+			delete(s.clusters, key)
+		}
+	}
+}
+
+// GetStats 获取统计信息
+func (s *SpamDetector) GetStats() map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"tracked":  len(s.clusters),
+		"clusters": s.clustersN,
+		"flagged":  s.flagged,
+	}
+}
@@ -0,0 +1,208 @@
+package decoder
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// symbol() 方法签名
+var methodSymbol = []byte{0x95, 0xd8, 0x9b, 0x41}
+
+// 常见代币符号（无需RPC查询）
+var knownSymbols = map[common.Address]string{
+	common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"): "WETH",
+	common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"): "USDC",
+	common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"): "USDT",
+	common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"): "DAI",
+	common.HexToAddress("0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599"): "WBTC",
+}
+
+// TokenBySymbol 按符号查找常见代币地址（不区分大小写，链的原生代币符号如 ETH/BNB 返回零地址）
+func TokenBySymbol(chainID *big.Int, symbol string) (common.Address, bool) {
+	symbol = strings.ToUpper(symbol)
+	if symbol == types.BaseAssetSymbol(chainID) {
+		return types.NativeToken, true
+	}
+	for address, known := range knownSymbols {
+		if known == symbol {
+			return address, true
+		}
+	}
+	return common.Address{}, false
+}
+
+const (
+	symbolFailureTTL  = 10 * time.Minute // 查询失败的代币在此期间不再重试
+	symbolMaxInflight = 4                // 同时进行的异步查询上限，超出时本次跳过
+	symbolMaxLength   = 32               // 符号最大长度（字符），超出部分截断
+)
+
+// SymbolResolver 代币符号解析器（带缓存）：未缓存的代币在后台异步查询，
+// 查询完成前（以及查询失败后的一段时间内）以截断的地址显示，不阻塞解码热路径
+type SymbolResolver struct {
+	client   *ethclient.Client
+	native   string // 链的原生代币符号
+	timeout  time.Duration
+	fetch    func(ctx context.Context, token common.Address) string // 查询符号（为空字符串表示失败）
+	mu       sync.RWMutex
+	cache    map[common.Address]string
+	failed   map[common.Address]time.Time // 查询失败的时间（负缓存）
+	inflight map[common.Address]struct{}  // 正在查询的代币
+}
+
+// NewSymbolResolver 创建代币符号解析器（nativeSymbol 为链的原生代币符号）
+func NewSymbolResolver(client *ethclient.Client, nativeSymbol string) *SymbolResolver {
+	cache := make(map[common.Address]string, len(knownSymbols))
+	for address, symbol := range knownSymbols {
+		cache[address] = symbol
+	}
+
+	r := &SymbolResolver{
+		client:   client,
+		native:   nativeSymbol,
+		timeout:  2 * time.Second,
+		cache:    cache,
+		failed:   make(map[common.Address]time.Time),
+		inflight: make(map[common.Address]struct{}),
+	}
+	r.fetch = r.fetchSymbol
+	return r
+}
+
+// Symbol 获取代币符号：未缓存时发起后台查询并先返回截断的地址
+func (r *SymbolResolver) Symbol(ctx context.Context, token common.Address) string {
+	// 原生代币（零地址）不是合约，无需查询
+	if types.IsNativeToken(token) {
+		return r.native
+	}
+
+	r.mu.RLock()
+	symbol, exists := r.cache[token]
+	r.mu.RUnlock()
+	if exists {
+		return symbol
+	}
+
+	r.resolveAsync(token)
+	return shortAddress(token)
+}
+
+// resolveAsync 在后台查询代币符号（已在查询、近期失败或并发已满时跳过）
+func (r *SymbolResolver) resolveAsync(token common.Address) {
+	r.mu.Lock()
+	if failedAt, failed := r.failed[token]; failed && time.Since(failedAt) < symbolFailureTTL {
+		r.mu.Unlock()
+		return
+	}
+	if _, running := r.inflight[token]; running || len(r.inflight) >= symbolMaxInflight {
+		r.mu.Unlock()
+		return
+	}
+	r.inflight[token] = struct{}{}
+	r.mu.Unlock()
+
+	go func() {
+		// 不使用调用方的上下文：查询结果供之后所有交易使用
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		symbol := sanitizeSymbol(r.fetch(ctx, token))
+		cancel()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.inflight, token)
+		if symbol == "" {
+			r.failed[token] = time.Now()
+			return
+		}
+		delete(r.failed, token)
+		r.cache[token] = symbol
+	}()
+}
+
+// fetchSymbol 通过eth_call调用symbol()
+func (r *SymbolResolver) fetchSymbol(ctx context.Context, token common.Address) string {
+	if r.client == nil {
+		return ""
+	}
+
+	result, err := r.client.CallContract(ctx, ethereum.CallMsg{
+		To:   &token,
+		Data: methodSymbol,
+	}, nil)
+	if err != nil {
+		return ""
+	}
+
+	return decodeSymbol(result)
+}
+
+// sanitizeSymbol 清理链上返回的符号：去除控制字符和不可打印字符（防止日志注入），
+// 替换无效UTF-8并限制长度
+func sanitizeSymbol(symbol string) string {
+	symbol = strings.ToValidUTF8(symbol, "")
+	symbol = strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, symbol)
+	symbol = strings.TrimSpace(symbol)
+
+	if runes := []rune(symbol); len(runes) > symbolMaxLength {
+		symbol = string(runes[:symbolMaxLength])
+	}
+	return symbol
+}
+
+// decodeSymbol 解析symbol()返回值（兼容string和bytes32两种实现）
+func decodeSymbol(result []byte) string {
+	// 部分老代币（如MKR）返回bytes32
+	if len(result) == 32 {
+		return strings.TrimRight(string(result), "\x00")
+	}
+
+	if len(result) < 64 {
+		return ""
+	}
+
+	offset := new(big.Int).SetBytes(result[:32])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(result)) {
+		return ""
+	}
+	start := offset.Uint64()
+
+	length := new(big.Int).SetBytes(result[start : start+32])
+	if !length.IsUint64() || start+32+length.Uint64() > uint64(len(result)) {
+		return ""
+	}
+
+	return string(result[start+32 : start+32+length.Uint64()])
+}
+
+// FormatPath 将交换路径格式化为 "WETH → USDC → PEPE"
+func (r *SymbolResolver) FormatPath(ctx context.Context, path []common.Address) string {
+	symbols := make([]string, 0, len(path))
+	for _, token := range path {
+		if r == nil {
+			symbols = append(symbols, shortAddress(token))
+			continue
+		}
+		symbols = append(symbols, r.Symbol(ctx, token))
+	}
+	return strings.Join(symbols, " → ")
+}
+
+// shortAddress 截断地址用于日志输出
+func shortAddress(address common.Address) string {
+	return address.Hex()[:10] + "..."
+}
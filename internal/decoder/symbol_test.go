@@ -0,0 +1,80 @@
+package decoder
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSanitizeSymbol(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "PEPE", want: "PEPE"},
+		{raw: "FAKE\n2024/01/01 00:00:00 ✅ 执行成功", want: "FAKE2024/01/01 00:00:00 ✅ 执行成功"},
+		{raw: "\x1b[31mRED\x1b[0m", want: "[31mRED[0m"},
+		{raw: "  MKR\x00\x00", want: "MKR"},
+		{raw: "\xff\xfeBAD", want: "BAD"},
+		{raw: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", want: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeSymbol(tt.raw); got != tt.want {
+			t.Errorf("sanitizeSymbol(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+// waitSymbol 等待后台查询结束
+func waitSymbol(t *testing.T, r *SymbolResolver, token common.Address) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.RLock()
+		_, running := r.inflight[token]
+		r.mu.RUnlock()
+		if !running {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("symbol lookup did not finish")
+}
+
+func TestSymbolResolvesAsyncAndCachesFailures(t *testing.T) {
+	good := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bad := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	var calls atomic.Int64
+	r := NewSymbolResolver(nil, "ETH")
+	r.fetch = func(ctx context.Context, token common.Address) string {
+		calls.Add(1)
+		if token == good {
+			return "GOOD\n"
+		}
+		return ""
+	}
+
+	// 第一次不等待查询结果，返回截断地址
+	if got := r.Symbol(context.Background(), good); got != shortAddress(good) {
+		t.Errorf("first Symbol() = %q, want %q", got, shortAddress(good))
+	}
+	waitSymbol(t, r, good)
+	if got := r.Symbol(context.Background(), good); got != "GOOD" {
+		t.Errorf("cached Symbol() = %q, want %q", got, "GOOD")
+	}
+
+	// 查询失败的代币在负缓存有效期内不再重试
+	for i := 0; i < 5; i++ {
+		if got := r.Symbol(context.Background(), bad); got != shortAddress(bad) {
+			t.Errorf("Symbol(bad) = %q, want %q", got, shortAddress(bad))
+		}
+		waitSymbol(t, r, bad)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("fetch called %d times, want 2", got)
+	}
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"sync"
+)
+
+// InFlightLimiter 同时进行中的执行数量上限（真实交易和模拟盘共用），限制风险敞口和nonce压力
+type InFlightLimiter struct {
+	mu       sync.Mutex
+	active   int
+	released chan struct{} // 每次释放时关闭并替换，唤醒所有排队者
+	peak     int
+	dropped  int64
+	queued   int64
+}
+
+// NewInFlightLimiter 创建执行数量限制器
+func NewInFlightLimiter() *InFlightLimiter {
+	return &InFlightLimiter{released: make(chan struct{})}
+}
+
+// Acquire 占用一个执行名额：超出上限时 queue 为true则等待名额释放，否则拒绝并计数（limit <= 0 表示不限制）
+// 返回true时调用方必须在执行结束后调用 Release
+func (l *InFlightLimiter) Acquire(ctx context.Context, limit int, queue bool) bool {
+	waited := false
+	for {
+		l.mu.Lock()
+		if limit <= 0 || l.active < limit {
+			l.active++
+			if l.active > l.peak {
+				l.peak = l.active
+			}
+			l.mu.Unlock()
+			return true
+		}
+		if !queue {
+			l.dropped++
+			l.mu.Unlock()
+			return false
+		}
+		if !waited {
+			l.queued++
+			waited = true
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-released:
+		}
+	}
+}
+
+// Release 释放执行名额
+func (l *InFlightLimiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active > 0 {
+		l.active--
+	}
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// GetStats 获取统计信息
+func (l *InFlightLimiter) GetStats() map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return map[string]interface{}{
+		"in_flight": l.active,
+		"peak":      l.peak,
+		"dropped":   l.dropped,
+		"queued":    l.queued,
+	}
+}
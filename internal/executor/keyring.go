@@ -0,0 +1,138 @@
+package executor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer 签名账户，每个账户独立管理nonce
+type Signer struct {
+	Key     *ecdsa.PrivateKey
+	Address common.Address
+	Nonces  *NonceManager
+}
+
+// KeyRing 多签名账户集合，按轮询（或最空闲）为每个机会分配账户
+type KeyRing struct {
+	mu      sync.Mutex
+	signers []*Signer
+	cursor  int
+	picks   map[common.Address]int64
+}
+
+// NewKeyRing 从十六进制私钥列表创建账户集合（重复私钥只保留一个）
+func NewKeyRing(hexKeys []string) (*KeyRing, error) {
+	ring := &KeyRing{picks: make(map[common.Address]int64)}
+	seen := make(map[common.Address]bool)
+
+	for i, hexKey := range hexKeys {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个私钥无效: %v", i+1, err)
+		}
+
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		ring.signers = append(ring.signers, &Signer{
+			Key:     key,
+			Address: address,
+			Nonces:  NewNonceManager(address),
+		})
+	}
+
+	return ring, nil
+}
+
+// Size 账户数量
+func (r *KeyRing) Size() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.signers)
+}
+
+// Next 轮询选择下一个账户，没有账户时返回nil
+func (r *KeyRing) Next() *Signer {
+	if r.Size() == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	signer := r.signers[r.cursor]
+	r.cursor = (r.cursor + 1) % len(r.signers)
+	r.picks[signer.Address]++
+	return signer
+}
+
+// LeastBusy 选择占用nonce最少的账户，相同时按轮询顺序
+func (r *KeyRing) LeastBusy() *Signer {
+	if r.Size() == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	best := -1
+	bestInFlight := 0
+	for i := 0; i < len(r.signers); i++ {
+		idx := (r.cursor + i) % len(r.signers)
+		inFlight := r.signers[idx].Nonces.InFlight()
+		if best == -1 || inFlight < bestInFlight {
+			best, bestInFlight = idx, inFlight
+		}
+	}
+
+	signer := r.signers[best]
+	r.cursor = (best + 1) % len(r.signers)
+	r.picks[signer.Address]++
+	return signer
+}
+
+// Reserve 为一次真实执行选择最空闲的账户并分配其下一个nonce，
+// 交易上链或被替换后调用 signer.Nonces.Done()，发送失败时调用 Reset()
+func (r *KeyRing) Reserve(ctx context.Context, source NonceSource) (*Signer, uint64, error) {
+	signer := r.LeastBusy()
+	if signer == nil {
+		return nil, 0, fmt.Errorf("没有可用的签名账户")
+	}
+	nonce, err := signer.Nonces.Next(ctx, source)
+	if err != nil {
+		return nil, 0, fmt.Errorf("同步账户 %s 的nonce失败: %v", signer.Address.Hex(), err)
+	}
+	return signer, nonce, nil
+}
+
+// GetStats 获取统计信息
+func (r *KeyRing) GetStats() map[string]interface{} {
+	if r.Size() == 0 {
+		return map[string]interface{}{"signers": 0}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	accounts := make(map[string]interface{}, len(r.signers))
+	for _, signer := range r.signers {
+		stats := signer.Nonces.GetStats()
+		stats["picks"] = r.picks[signer.Address]
+		accounts[signer.Address.Hex()] = stats
+	}
+
+	return map[string]interface{}{
+		"signers":  len(r.signers),
+		"accounts": accounts,
+	}
+}
@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestKeyRingRotatesAndDeduplicates(t *testing.T) {
+	var keys []string
+	for i := 0; i < 3; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, hex.EncodeToString(crypto.FromECDSA(key)))
+	}
+	// 重复的私钥（带0x前缀）只保留一个
+	ring, err := NewKeyRing(append(keys, "0x"+keys[0]))
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+	if ring.Size() != 3 {
+		t.Fatalf("Size() = %d, want 3", ring.Size())
+	}
+
+	first := ring.Next()
+	seen := map[string]bool{first.Address.Hex(): true}
+	for i := 1; i < 3; i++ {
+		seen[ring.Next().Address.Hex()] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("3 picks used %d accounts, want 3", len(seen))
+	}
+	if again := ring.Next(); again.Address != first.Address {
+		t.Errorf("4th pick = %s, want rotation back to %s", again.Address.Hex(), first.Address.Hex())
+	}
+}
+
+func TestKeyRingRejectsInvalidKey(t *testing.T) {
+	if _, err := NewKeyRing([]string{"not-a-key"}); err == nil {
+		t.Fatal("NewKeyRing() accepted an invalid key")
+	}
+	var empty *KeyRing
+	if empty.Size() != 0 || empty.Next() != nil {
+		t.Error("nil KeyRing should have no signers")
+	}
+}
+
+// fakeNonceSource 按账户返回固定的pending nonce，并统计同步次数
+type fakeNonceSource struct {
+	mu    sync.Mutex
+	seeds map[common.Address]uint64
+	calls map[common.Address]int
+}
+
+func (f *fakeNonceSource) PendingNonceAt(_ context.Context, account common.Address) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[account]++
+	return f.seeds[account], nil
+}
+
+func TestKeyRingNoncesIsolatedPerKey(t *testing.T) {
+	var keys []string
+	for i := 0; i < 2; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, hex.EncodeToString(crypto.FromECDSA(key)))
+	}
+	ring, err := NewKeyRing(keys)
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+
+	a, b := ring.signers[0].Address, ring.signers[1].Address
+	source := &fakeNonceSource{
+		seeds: map[common.Address]uint64{a: 5, b: 100},
+		calls: make(map[common.Address]int),
+	}
+
+	// 两个账户同时并发提交，每个账户各分配 perKey 个nonce
+	const perKey = 50
+	var mu sync.Mutex
+	got := make(map[common.Address][]uint64)
+	var wg sync.WaitGroup
+	for _, signer := range ring.signers {
+		for i := 0; i < perKey; i++ {
+			wg.Add(1)
+			go func(signer *Signer) {
+				defer wg.Done()
+				nonce, err := signer.Nonces.Next(context.Background(), source)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				got[signer.Address] = append(got[signer.Address], nonce)
+				mu.Unlock()
+			}(signer)
+		}
+	}
+	wg.Wait()
+
+	for address, seed := range source.seeds {
+		if source.calls[address] != 1 {
+			t.Errorf("%s synced %d times, want 1", address.Hex(), source.calls[address])
+		}
+		nonces := got[address]
+		sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+		for i, nonce := range nonces {
+			if nonce != seed+uint64(i) {
+				t.Fatalf("%s nonces = %v, want %d..%d without gaps or repeats", address.Hex(), nonces, seed, seed+perKey-1)
+			}
+		}
+	}
+	if ring.signers[0].Nonces.InFlight() != perKey || ring.signers[1].Nonces.InFlight() != perKey {
+		t.Errorf("in flight = %d/%d, want %d each", ring.signers[0].Nonces.InFlight(), ring.signers[1].Nonces.InFlight(), perKey)
+	}
+}
+
+func TestKeyRingReservePrefersLeastBusy(t *testing.T) {
+	var keys []string
+	for i := 0; i < 2; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, hex.EncodeToString(crypto.FromECDSA(key)))
+	}
+	ring, err := NewKeyRing(keys)
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+	source := &fakeNonceSource{seeds: map[common.Address]uint64{}, calls: make(map[common.Address]int)}
+
+	first, nonce, err := ring.Reserve(context.Background(), source)
+	if err != nil || nonce != 0 {
+		t.Fatalf("Reserve() = %d, %v", nonce, err)
+	}
+	second, _, _ := ring.Reserve(context.Background(), source)
+	if second.Address == first.Address {
+		t.Fatal("second Reserve() reused the busy account")
+	}
+
+	// 第一个账户的交易已上链，释放后应优先选择它
+	first.Nonces.Done()
+	third, nonce, _ := ring.Reserve(context.Background(), source)
+	if third.Address != first.Address || nonce != 1 {
+		t.Errorf("third Reserve() = %s nonce %d, want %s nonce 1", third.Address.Hex(), nonce, first.Address.Hex())
+	}
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceSource 获取账户pending nonce的接口（ethclient.Client 满足该接口）
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager 单个账户的nonce管理器，首次使用时从节点同步
+type NonceManager struct {
+	address  common.Address
+	mu       sync.Mutex
+	next     uint64
+	synced   bool
+	inFlight int // 已分配但尚未确认/释放的nonce数
+}
+
+// NewNonceManager 创建nonce管理器
+func NewNonceManager(address common.Address) *NonceManager {
+	return &NonceManager{address: address}
+}
+
+// Next 分配下一个nonce：同一账户的分配在账户锁内串行，首次分配时从节点的pending nonce同步
+func (n *NonceManager) Next(ctx context.Context, source NonceSource) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.synced {
+		nonce, err := source.PendingNonceAt(ctx, n.address)
+		if err != nil {
+			return 0, err
+		}
+		n.next = nonce
+		n.synced = true
+	}
+
+	nonce := n.next
+	n.next++
+	n.inFlight++
+	return nonce, nil
+}
+
+// Peek 下一个将分配的nonce，不占用（尚未从节点同步时返回false）
+func (n *NonceManager) Peek() (uint64, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.next, n.synced
+}
+
+// Done 交易已上链或被替换，释放占用
+func (n *NonceManager) Done() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.inFlight > 0 {
+		n.inFlight--
+	}
+}
+
+// Reset 发送失败时丢弃本地状态，下次分配重新从节点同步
+func (n *NonceManager) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.synced = false
+	n.inFlight = 0
+}
+
+// InFlight 当前占用中的nonce数
+func (n *NonceManager) InFlight() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.inFlight
+}
+
+// GetStats 获取统计信息
+func (n *NonceManager) GetStats() map[string]interface{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return map[string]interface{}{
+		"next_nonce": n.next,
+		"synced":     n.synced,
+		"in_flight":  n.inFlight,
+	}
+}
@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// ShadowExecutor 模拟盘影子执行：按真实执行的方式构建并签名我们的交易，但不广播、不占用nonce，
+// 假设交易在目标区块按模拟状态成交。执行器本身没有任何发送交易的途径
+type ShadowExecutor struct {
+	chainID *big.Int
+	signer  ethtypes.Signer
+
+	mu     sync.Mutex
+	built  int64 // 已签名的影子交易数
+	failed int64 // 构建或签名失败数
+}
+
+// NewShadowExecutor 创建影子执行器
+func NewShadowExecutor(chainID *big.Int) *ShadowExecutor {
+	return &ShadowExecutor{
+		chainID: chainID,
+		signer:  ethtypes.LatestSignerForChainID(chainID),
+	}
+}
+
+// Build 使用签名账户签名买入腿调用：nonce 取账户本地的下一个nonce（未同步时为0），
+// Gas价格按模拟时的有效Gas价格和小费（不支持EIP-1559的链签名传统交易），Gas上限按模拟估算的用量
+func (e *ShadowExecutor) Build(signer *Signer, call *ethereum.CallMsg, gas *types.GasEstimation) (*ethtypes.Transaction, uint64, error) {
+	if call == nil || call.To == nil || gas == nil || gas.GasPrice == nil {
+		e.count(&e.failed)
+		return nil, 0, fmt.Errorf("影子交易缺少调用或Gas估算")
+	}
+
+	nonce, _ := signer.Nonces.Peek()
+	gasLimit := gas.GasUsed
+	if gasLimit == 0 {
+		gasLimit = call.Gas
+	}
+
+	tx, err := ethtypes.SignNewTx(signer.Key, e.signer, e.txData(nonce, gasLimit, call, gas))
+	if err != nil {
+		e.count(&e.failed)
+		return nil, 0, fmt.Errorf("签名影子交易失败: %v", err)
+	}
+	e.count(&e.built)
+	return tx, nonce, nil
+}
+
+// txData 按链的计价方式构建交易：不支持EIP-1559的链只接受传统交易，
+// 其余按有效Gas价格作为费用上限、模拟时的小费作为小费上限
+func (e *ShadowExecutor) txData(nonce, gasLimit uint64, call *ethereum.CallMsg, gas *types.GasEstimation) ethtypes.TxData {
+	if gas.Legacy {
+		return &ethtypes.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: gas.GasPrice,
+			Gas:      gasLimit,
+			To:       call.To,
+			Value:    call.Value,
+			Data:     call.Data,
+		}
+	}
+
+	tip := gas.PriorityFee
+	if tip == nil || tip.Cmp(gas.GasPrice) > 0 {
+		tip = gas.GasPrice
+	}
+	return &ethtypes.DynamicFeeTx{
+		ChainID:   e.chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: gas.GasPrice,
+		Gas:       gasLimit,
+		To:        call.To,
+		Value:     call.Value,
+		Data:      call.Data,
+	}
+}
+
+// count 统计计数加一
+func (e *ShadowExecutor) count(counter *int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	*counter++
+}
+
+// GetStats 获取统计信息
+func (e *ShadowExecutor) GetStats() map[string]interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return map[string]interface{}{
+		"built":  e.built,
+		"failed": e.failed,
+	}
+}
@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fixedNonce 固定pending nonce的节点
+type fixedNonce uint64
+
+func (n fixedNonce) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return uint64(n), nil
+}
+
+func TestShadowBuildSignsWithoutReservingNonce(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := NewKeyRing([]string{hex.EncodeToString(crypto.FromECDSA(key))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := ring.Next()
+	if _, err := signer.Nonces.Next(context.Background(), fixedNonce(41)); err != nil {
+		t.Fatal(err)
+	}
+	signer.Nonces.Done()
+
+	router := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	call := &ethereum.CallMsg{To: &router, Gas: 200000, Value: big.NewInt(1e17), Data: []byte{0x7f, 0xf3, 0x6a, 0xb5}}
+	gas := &types.GasEstimation{GasUsed: 150000, GasPrice: big.NewInt(30e9), PriorityFee: big.NewInt(2e9)}
+
+	shadow := NewShadowExecutor(big.NewInt(1))
+	tx, nonce, err := shadow.Build(signer, call, gas)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	from, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(big.NewInt(1)), tx)
+	if err != nil || from != signer.Address {
+		t.Errorf("shadow tx sender = %s, %v; want %s", from.Hex(), err, signer.Address.Hex())
+	}
+	if nonce != 42 || tx.Nonce() != 42 {
+		t.Errorf("shadow tx nonce = %d, want the account's next nonce 42", tx.Nonce())
+	}
+	if tx.Gas() != 150000 || tx.GasTipCap().Cmp(gas.PriorityFee) != 0 || tx.GasFeeCap().Cmp(gas.GasPrice) != 0 {
+		t.Errorf("shadow tx gas = %d tip %s cap %s", tx.Gas(), tx.GasTipCap(), tx.GasFeeCap())
+	}
+	if next, _ := signer.Nonces.Peek(); next != 42 || signer.Nonces.InFlight() != 0 {
+		t.Errorf("Build() consumed a nonce: next = %d, in flight = %d", next, signer.Nonces.InFlight())
+	}
+
+	if _, _, err := shadow.Build(signer, call, nil); err == nil {
+		t.Error("Build() accepted a call without a gas estimation")
+	}
+	if stats := shadow.GetStats(); stats["built"] != int64(1) || stats["failed"] != int64(1) {
+		t.Errorf("stats = %v", stats)
+	}
+}
+
+// shadowSigner 随机私钥的签名账户
+func shadowSigner(t *testing.T) *Signer {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := NewKeyRing([]string{hex.EncodeToString(crypto.FromECDSA(key))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring.Next()
+}
+
+func TestShadowBuildTxTypeFollowsChainPricing(t *testing.T) {
+	router := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	call := &ethereum.CallMsg{To: &router, Value: big.NewInt(1e17), Data: []byte{0x7f, 0xf3, 0x6a, 0xb5}}
+
+	tests := []struct {
+		name     string
+		gas      *types.GasEstimation
+		wantType uint8
+		tip      *big.Int
+	}{
+		{name: "EIP-1559 chain", gas: &types.GasEstimation{GasUsed: 150000, GasPrice: big.NewInt(30e9), PriorityFee: big.NewInt(2e9)},
+			wantType: ethtypes.DynamicFeeTxType, tip: big.NewInt(2e9)},
+		{name: "EIP-1559 chain, legacy victim without a tip", gas: &types.GasEstimation{GasUsed: 150000, GasPrice: big.NewInt(30e9)},
+			wantType: ethtypes.DynamicFeeTxType, tip: big.NewInt(30e9)},
+		// 不支持EIP-1559的链拒绝类型2交易
+		{name: "chain without base fee", gas: &types.GasEstimation{GasUsed: 150000, GasPrice: big.NewInt(30e9), Legacy: true},
+			wantType: ethtypes.LegacyTxType, tip: big.NewInt(30e9)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := shadowSigner(t)
+			tx, _, err := NewShadowExecutor(big.NewInt(56)).Build(signer, call, tt.gas)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if tx.Type() != tt.wantType {
+				t.Errorf("shadow tx type = %d, want %d", tx.Type(), tt.wantType)
+			}
+			if tx.GasPrice().Cmp(tt.gas.GasPrice) != 0 || tx.GasFeeCap().Cmp(tt.gas.GasPrice) != 0 || tx.GasTipCap().Cmp(tt.tip) != 0 {
+				t.Errorf("shadow tx gasPrice %s cap %s tip %s", tx.GasPrice(), tx.GasFeeCap(), tx.GasTipCap())
+			}
+			// 传统交易同样带 EIP-155 重放保护
+			if !tx.Protected() || tx.ChainId().Cmp(big.NewInt(56)) != 0 {
+				t.Errorf("shadow tx chain ID = %s, protected = %v; want 56", tx.ChainId(), tx.Protected())
+			}
+			from, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(big.NewInt(56)), tx)
+			if err != nil || from != signer.Address {
+				t.Errorf("shadow tx sender = %s, %v; want %s", from.Hex(), err, signer.Address.Hex())
+			}
+		})
+	}
+}
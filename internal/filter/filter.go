@@ -0,0 +1,323 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind 字段/表达式值类型
+type Kind int
+
+const (
+	KindNumber Kind = iota
+	KindString
+	KindBool
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	default:
+		return "bool"
+	}
+}
+
+// Schema 可用字段及其类型
+type Schema map[string]Kind
+
+// Env 求值时的字段取值（number 为 float64，string 为 string，bool 为 bool）
+type Env map[string]interface{}
+
+// Expr 编译后的过滤表达式
+type Expr struct {
+	source string
+	root   node
+}
+
+// node 语法树节点
+type node interface {
+	kind() Kind
+	eval(env Env) interface{}
+}
+
+// Compile 编译过滤表达式，并按字段类型做静态检查；表达式结果必须为bool
+func Compile(src string, schema Schema) (*Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, schema: schema}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("位置 %d: 多余的 %q", tok.pos, tok.text)
+	}
+	if root.kind() != KindBool {
+		return nil, fmt.Errorf("表达式结果必须为bool，实际为 %s", root.kind())
+	}
+
+	return &Expr{source: src, root: root}, nil
+}
+
+// Match 对给定字段求值
+func (e *Expr) Match(env Env) bool {
+	return e.root.eval(env).(bool)
+}
+
+// String 返回原始表达式
+func (e *Expr) String() string {
+	return e.source
+}
+
+// parser 递归下降解析器
+// 优先级（低到高）: || , && , ! , 比较运算
+type parser struct {
+	tokens []token
+	pos    int
+	schema Schema
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		tok := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != KindBool || right.kind() != KindBool {
+			return nil, fmt.Errorf("位置 %d: || 两侧必须为bool", tok.pos)
+		}
+		left = &logicNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		tok := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != KindBool || right.kind() != KindBool {
+			return nil, fmt.Errorf("位置 %d: && 两侧必须为bool", tok.pos)
+		}
+		left = &logicNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().kind == tokOp && p.peek().text == "!" {
+		tok := p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != KindBool {
+			return nil, fmt.Errorf("位置 %d: ! 只能用于bool", tok.pos)
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return left, nil
+	}
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind() != right.kind() {
+		return nil, fmt.Errorf("位置 %d: 无法比较 %s 与 %s", tok.pos, left.kind(), right.kind())
+	}
+	if tok.text != "==" && tok.text != "!=" && left.kind() == KindBool {
+		return nil, fmt.Errorf("位置 %d: bool 不支持 %s", tok.pos, tok.text)
+	}
+
+	return &compareNode{op: tok.text, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokNumber:
+		value, err := strconv.ParseFloat(strings.ReplaceAll(tok.text, "_", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("位置 %d: 无效数字 %q", tok.pos, tok.text)
+		}
+		return &literalNode{value: value, k: KindNumber}, nil
+
+	case tokString:
+		return &literalNode{value: tok.text, k: KindString}, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true, k: KindBool}, nil
+		case "false":
+			return &literalNode{value: false, k: KindBool}, nil
+		}
+		k, exists := p.schema[tok.text]
+		if !exists {
+			return nil, fmt.Errorf("位置 %d: 未知字段 %q", tok.pos, tok.text)
+		}
+		return &fieldNode{name: tok.text, k: k}, nil
+
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("位置 %d: 缺少 )", closing.pos)
+		}
+		return inner, nil
+
+	case tokEOF:
+		return nil, fmt.Errorf("表达式不完整")
+
+	default:
+		return nil, fmt.Errorf("位置 %d: 意外的 %q", tok.pos, tok.text)
+	}
+}
+
+// literalNode 字面量
+type literalNode struct {
+	value interface{}
+	k     Kind
+}
+
+func (n *literalNode) kind() Kind               { return n.k }
+func (n *literalNode) eval(env Env) interface{} { return n.value }
+
+// fieldNode 字段引用，缺失的字段按零值处理
+type fieldNode struct {
+	name string
+	k    Kind
+}
+
+func (n *fieldNode) kind() Kind { return n.k }
+
+func (n *fieldNode) eval(env Env) interface{} {
+	if value, ok := env[n.name]; ok {
+		return value
+	}
+	switch n.k {
+	case KindNumber:
+		return float64(0)
+	case KindString:
+		return ""
+	default:
+		return false
+	}
+}
+
+// logicNode 逻辑运算（短路求值）
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicNode) kind() Kind { return KindBool }
+
+func (n *logicNode) eval(env Env) interface{} {
+	left := n.left.eval(env).(bool)
+	if n.op == "&&" {
+		return left && n.right.eval(env).(bool)
+	}
+	return left || n.right.eval(env).(bool)
+}
+
+// notNode 逻辑非
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) kind() Kind               { return KindBool }
+func (n *notNode) eval(env Env) interface{} { return !n.operand.eval(env).(bool) }
+
+// compareNode 比较运算（两侧类型在编译期已检查一致）
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) kind() Kind { return KindBool }
+
+func (n *compareNode) eval(env Env) interface{} {
+	left, right := n.left.eval(env), n.right.eval(env)
+
+	switch l := left.(type) {
+	case float64:
+		return compareOrdered(n.op, l, right.(float64))
+	case string:
+		return compareOrdered(n.op, l, right.(string))
+	case bool:
+		if n.op == "==" {
+			return l == right.(bool)
+		}
+		return l != right.(bool)
+	}
+	return false
+}
+
+func compareOrdered[T float64 | string](op string, l, r T) bool {
+	switch op {
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	default:
+		return l >= r
+	}
+}
@@ -0,0 +1,80 @@
+package filter
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+)
+
+func TestMatch(t *testing.T) {
+	analysis := &types.ProfitAnalysis{
+		NetProfit:        big.NewInt(1e16),
+		RiskLevel:        "LOW",
+		Strategy:         "sandwich",
+		CompetitionCount: 3,
+		PriceImpactBps:   120,
+		LowConfidence:    true,
+	}
+	env := OpportunityEnv(analysis, "Uniswap V2")
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "net_profit > 5e15", want: true},
+		{expr: "net_profit >= 10_000_000_000_000_000", want: true},
+		{expr: "risk_level == 'LOW' && protocol == \"Uniswap V2\"", want: true},
+		{expr: "competition_count < 3", want: false},
+		{expr: "competition_count <= 3 && price_impact_bps > 100", want: true},
+		// && 优先于 ||
+		{expr: "true || false && false", want: true},
+		{expr: "(true || false) && false", want: false},
+		{expr: "competition_count > 5 || price_impact_bps > 100 && risk_level == 'LOW'", want: true},
+		{expr: "(competition_count > 5 || price_impact_bps > 100) && risk_level == 'HIGH'", want: false},
+		// ! 只作用于紧随的操作数
+		{expr: "!low_confidence || net_profit > 0", want: true},
+		{expr: "!(low_confidence || net_profit > 0)", want: false},
+		{expr: "not low_confidence and strategy == 'sandwich'", want: false},
+		{expr: "low_confidence == true && leading_approval != true", want: true},
+		// 字符串按字典序比较
+		{expr: "method < 'a'", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Compile(tt.expr, OpportunityFields)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if got := expr.Match(env); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsInvalidExpressions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "net_profit > 'high'", want: "无法比较"},
+		{expr: "risk_level == 1", want: "无法比较"},
+		{expr: "low_confidence > false", want: "bool 不支持"},
+		{expr: "net_profit", want: "必须为bool"},
+		{expr: "!net_profit", want: "只能用于bool"},
+		{expr: "net_profit > 0 && competition_count", want: "必须为bool"},
+		{expr: "slippage > 0", want: "未知字段"},
+		{expr: "(net_profit > 0", want: "缺少 )"},
+		{expr: "net_profit >", want: "不完整"},
+		{expr: "net_profit > 0 risk_level", want: "多余"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if _, err := Compile(tt.expr, OpportunityFields); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package filter
+
+import (
+	"math/big"
+
+	"mempool-sniper/pkg/types"
+)
+
+// OpportunityFields 盈利机会过滤可用字段（金额单位为wei）
+var OpportunityFields = Schema{
+	"net_profit":     KindNumber,
+	"profit":         KindNumber,
+	"gas_cost":       KindNumber,
+	"success_rate":   KindNumber,
+	"risk_level":     KindString,
+	"method":         KindString,
+	"protocol":       KindString,
+	"strategy":       KindString,
+	"target_block":   KindNumber,
+	"low_confidence": KindBool,
+
+	"leading_approval":  KindBool,
+	"competition_count": KindNumber,
+	"price_impact_bps":  KindNumber,
+}
+
+// OpportunityEnv 从盈利分析构造求值字段，protocol 为目标DEX名称
+func OpportunityEnv(analysis *types.ProfitAnalysis, protocol string) Env {
+	return Env{
+		"net_profit":     weiToFloat(analysis.NetProfit),
+		"profit":         weiToFloat(analysis.Profit),
+		"gas_cost":       weiToFloat(analysis.GasCost),
+		"success_rate":   analysis.SuccessRate,
+		"risk_level":     analysis.RiskLevel,
+		"method":         analysis.Method,
+		"protocol":       protocol,
+		"strategy":       analysis.Strategy,
+		"target_block":   float64(analysis.TargetBlock),
+		"low_confidence": analysis.LowConfidence,
+
+		"leading_approval":  analysis.LeadingApproval,
+		"competition_count": float64(analysis.CompetitionCount),
+		"price_impact_bps":  float64(analysis.PriceImpactBps),
+	}
+}
+
+func weiToFloat(value *big.Int) float64 {
+	if value == nil {
+		return 0
+	}
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return f
+}
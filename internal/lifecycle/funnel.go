@@ -0,0 +1,106 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 转化漏斗阶段（按顺序，每个阶段的计数不超过上一阶段）
+const (
+	FunnelSeen           = "seen"            // 进入解码器的交易
+	FunnelDecoded        = "decoded"         // 解码为目标交易
+	FunnelSimulated      = "simulated"       // 完成首次模拟（不含执行前复核）
+	FunnelProfitable     = "profitable"      // 结果处理器收到的净盈利为正的分析结果
+	FunnelAboveThreshold = "above_threshold" // 通过成本门槛及过滤条件
+	FunnelActed          = "acted"           // 最终执行（通知/模拟盘记录）
+)
+
+// funnelStages 漏斗阶段顺序
+var funnelStages = []string{FunnelSeen, FunnelDecoded, FunnelSimulated, FunnelProfitable, FunnelAboveThreshold, FunnelActed}
+
+// Funnel 机会转化漏斗的累计计数（nil漏斗不做任何事）
+type Funnel struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// NewFunnel 创建转化漏斗
+func NewFunnel() *Funnel {
+	return &Funnel{counts: make(map[string]int64, len(funnelStages))}
+}
+
+// Add 某个阶段计数加一
+func (f *Funnel) Add(stage string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[stage]++
+}
+
+// GetStats 获取各阶段累计计数，以及相对上一阶段和相对 seen 的转化率
+func (f *Funnel) GetStats() map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	counts := make(map[string]int64, len(funnelStages))
+	stepRates := make(map[string]float64, len(funnelStages)-1)
+	overallRates := make(map[string]float64, len(funnelStages)-1)
+	seen := f.counts[FunnelSeen]
+	for i, stage := range funnelStages {
+		counts[stage] = f.counts[stage]
+		if i == 0 {
+			continue
+		}
+		stepRates[stage] = conversionRate(f.counts[stage], f.counts[funnelStages[i-1]])
+		overallRates[stage] = conversionRate(f.counts[stage], seen)
+	}
+
+	return map[string]interface{}{
+		"counts":       counts,
+		"step_rate":    stepRates,
+		"overall_rate": overallRates,
+	}
+}
+
+// Summary 单行漏斗摘要（用于日志）
+func (f *Funnel) Summary() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := make([]string, len(funnelStages))
+	for i, stage := range funnelStages {
+		parts[i] = fmt.Sprintf("%s %d", stage, f.counts[stage])
+	}
+	return strings.Join(parts, " → ")
+}
+
+// StartLogging 每隔 interval 输出一次漏斗摘要，直到上下文取消
+func (f *Funnel) StartLogging(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				log.Printf("🪣 转化漏斗: %s", f.Summary())
+			}
+		}
+	}()
+}
+
+// conversionRate 转化率（上一阶段为0时返回0）
+func conversionRate(count, previous int64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return float64(count) / float64(previous)
+}
@@ -0,0 +1,74 @@
+package lifecycle
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFunnelStats(t *testing.T) {
+	f := NewFunnel()
+	counts := map[string]int{
+		FunnelSeen:           200,
+		FunnelDecoded:        50,
+		FunnelSimulated:      40,
+		FunnelProfitable:     10,
+		FunnelAboveThreshold: 4,
+		FunnelActed:          0,
+	}
+	// 各阶段由不同的工作线程并发计数
+	var wg sync.WaitGroup
+	for stage, n := range counts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				f.Add(stage)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := f.GetStats()
+	gotCounts := stats["counts"].(map[string]int64)
+	stepRates := stats["step_rate"].(map[string]float64)
+	overallRates := stats["overall_rate"].(map[string]float64)
+	tests := []struct {
+		stage   string
+		step    float64
+		overall float64
+	}{
+		{stage: FunnelDecoded, step: 0.25, overall: 0.25},
+		{stage: FunnelSimulated, step: 0.8, overall: 0.2},
+		{stage: FunnelProfitable, step: 0.25, overall: 0.05},
+		{stage: FunnelAboveThreshold, step: 0.4, overall: 0.02},
+		{stage: FunnelActed, step: 0, overall: 0},
+	}
+	for _, tt := range tests {
+		if gotCounts[tt.stage] != int64(counts[tt.stage]) {
+			t.Errorf("counts[%s] = %d, want %d", tt.stage, gotCounts[tt.stage], counts[tt.stage])
+		}
+		if stepRates[tt.stage] != tt.step || overallRates[tt.stage] != tt.overall {
+			t.Errorf("%s step_rate = %v, overall_rate = %v; want %v, %v", tt.stage, stepRates[tt.stage], overallRates[tt.stage], tt.step, tt.overall)
+		}
+	}
+	if _, exists := stepRates[FunnelSeen]; exists {
+		t.Error("step_rate has an entry for the first stage")
+	}
+
+	want := "seen 200 → decoded 50 → simulated 40 → profitable 10 → above_threshold 4 → acted 0"
+	if got := f.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestFunnelEmptyAndNil(t *testing.T) {
+	stats := NewFunnel().GetStats()
+	for stage, rate := range stats["step_rate"].(map[string]float64) {
+		if rate != 0 {
+			t.Errorf("empty funnel step_rate[%s] = %v, want 0", stage, rate)
+		}
+	}
+
+	var f *Funnel
+	f.Add(FunnelSeen) // nil漏斗不做任何事
+}
@@ -0,0 +1,109 @@
+package lifecycle
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 生命周期阶段
+const (
+	StageDetected  = "detected"  // 解码器发现交换交易
+	StageSimulated = "simulated" // 模拟器完成模拟
+	StageDecision  = "decision"  // 结果处理器做出决策
+	StageExecution = "execution" // 执行阶段（模拟盘记录或中止，尚无实盘执行器）
+	StageOutcome   = "outcome"   // 最终结果
+)
+
+// Event 盈利机会生命周期事件
+type Event struct {
+	OpportunityID string                 `json:"opportunity_id"`
+	Stage         string                 `json:"stage"`
+	TxHash        common.Hash            `json:"tx_hash"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Detail        map[string]interface{} `json:"detail,omitempty"`
+}
+
+// Sink 生命周期事件输出端
+type Sink interface {
+	Write(event Event) error
+}
+
+// Recorder 生命周期事件记录器（nil记录器不做任何事）
+type Recorder struct {
+	sink Sink
+}
+
+// NewRecorder 创建事件记录器
+func NewRecorder(sink Sink) *Recorder {
+	return &Recorder{sink: sink}
+}
+
+// NewID 生成新的盈利机会ID
+func NewID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Emit 记录一个生命周期事件
+func (r *Recorder) Emit(opportunityID, stage string, txHash common.Hash, detail map[string]interface{}) {
+	if r == nil || r.sink == nil || opportunityID == "" {
+		return
+	}
+
+	event := Event{
+		OpportunityID: opportunityID,
+		Stage:         stage,
+		TxHash:        txHash,
+		Timestamp:     time.Now(),
+		Detail:        detail,
+	}
+	if err := r.sink.Write(event); err != nil {
+		log.Printf("⚠️ 写入生命周期事件失败: %v", err)
+	}
+}
+
+// FileSink 以JSONL格式追加写入文件
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink 创建文件输出端
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lifecycle log: %v", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Write 写入一行事件
+func (f *FileSink) Write(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close 关闭文件
+func (f *FileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
@@ -0,0 +1,72 @@
+package lifecycle
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 事件按调用顺序逐行写入JSONL文件；nil记录器和空机会ID不写入
+func TestFileSinkWritesEventsInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lifecycle.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := NewRecorder(sink)
+	hash := common.HexToHash("0x01")
+	id := NewID()
+
+	var nilRecorder *Recorder
+	nilRecorder.Emit(id, StageDetected, hash, nil)
+	recorder.Emit("", StageDetected, hash, nil)
+	stages := []string{StageDetected, StageSimulated, StageDecision, StageExecution}
+	for _, stage := range stages {
+		recorder.Emit(id, stage, hash, map[string]interface{}{"stage": stage})
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid event line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != len(stages) {
+		t.Fatalf("%d events written, want %d", len(events), len(stages))
+	}
+	for i, event := range events {
+		if event.OpportunityID != id || event.Stage != stages[i] || event.TxHash != hash || event.Detail["stage"] != stages[i] {
+			t.Errorf("event %d = %+v, want stage %s of opportunity %s", i, event, stages[i], id)
+		}
+		if i > 0 && event.Timestamp.Before(events[i-1].Timestamp) {
+			t.Errorf("event %d timestamp %v precedes the previous event", i, event.Timestamp)
+		}
+	}
+}
+
+func TestNewIDUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := NewID()
+		if len(id) != 16 || seen[id] {
+			t.Fatalf("NewID() = %q, want a fresh 16-character id", id)
+		}
+		seen[id] = true
+	}
+}
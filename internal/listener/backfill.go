@@ -0,0 +1,119 @@
+package listener
+
+import (
+	"context"
+	"sort"
+
+	"mempool-sniper/pkg/types"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// 冷启动回填方式
+const (
+	BackfillOff         = "off"                     // 不回填
+	BackfillAuto        = "auto"                    // 依次尝试 txpool_content、eth_pendingTransactions
+	BackfillTxpool      = "txpool_content"          // geth/erigon txpool 命名空间
+	BackfillPendingTxns = "eth_pendingTransactions" // 部分节点提供的pending交易列表
+)
+
+// SetBackfill 设置启动时回填当前pending交易的方式及最多回填数量（需在Start之前调用）
+func (l *Listener) SetBackfill(mode string, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.backfillMode = mode
+	l.backfillLimit = limit
+}
+
+// backfillPending 启动时拉取一次节点当前的pending交易池并送入处理管道，
+// 弥补订阅建立前的空窗（节点不支持对应方法时只记录日志）
+func (l *Listener) backfillPending(ctx context.Context, txChan chan<- *types.Transaction) {
+	l.mu.RLock()
+	mode := l.backfillMode
+	limit := l.backfillLimit
+	l.mu.RUnlock()
+
+	if mode == "" || mode == BackfillOff {
+		return
+	}
+
+	methods := []string{mode}
+	if mode == BackfillAuto {
+		methods = []string{BackfillTxpool, BackfillPendingTxns}
+	}
+
+	for _, method := range methods {
+		txs, err := l.fetchPendingPool(ctx, method)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warn("冷启动回填不可用", "method", method, "error", err)
+			continue
+		}
+
+		if limit > 0 && len(txs) > limit {
+			txs = txs[:limit]
+		}
+		for _, tx := range txs {
+			if ctx.Err() != nil {
+				return
+			}
+			l.processTransaction(ctx, tx, txChan)
+		}
+
+		logger.Info("冷启动回填完成", "method", method, "txs", len(txs))
+		l.mu.Lock()
+		l.backfillMethod = method
+		l.backfilled += int64(len(txs))
+		l.mu.Unlock()
+		return
+	}
+}
+
+// fetchPendingPool 通过指定方法获取节点当前的pending交易
+func (l *Listener) fetchPendingPool(ctx context.Context, method string) ([]*ethtypes.Transaction, error) {
+	callCtx, cancel := l.callContext(ctx)
+	defer cancel()
+
+	if method == BackfillTxpool {
+		var content struct {
+			Pending map[string]map[string]*ethtypes.Transaction `json:"pending"`
+		}
+		if err := l.getRPCClient().CallContext(callCtx, &content, method); err != nil {
+			l.countTimeout(ctx, err)
+			return nil, err
+		}
+		return flattenTxpool(content.Pending), nil
+	}
+
+	var txs []*ethtypes.Transaction
+	if err := l.getRPCClient().CallContext(callCtx, &txs, method); err != nil {
+		l.countTimeout(ctx, err)
+		return nil, err
+	}
+	return txs, nil
+}
+
+// flattenTxpool 展开 txpool_content 的 发送者 -> nonce -> 交易 结构，
+// 按发送者内nonce升序排列，保证同一发送者的交易按可执行顺序进入管道
+func flattenTxpool(pending map[string]map[string]*ethtypes.Transaction) []*ethtypes.Transaction {
+	senders := make([]string, 0, len(pending))
+	for sender := range pending {
+		senders = append(senders, sender)
+	}
+	sort.Strings(senders)
+
+	var txs []*ethtypes.Transaction
+	for _, sender := range senders {
+		byNonce := make([]*ethtypes.Transaction, 0, len(pending[sender]))
+		for _, tx := range pending[sender] {
+			if tx != nil {
+				byNonce = append(byNonce, tx)
+			}
+		}
+		sort.Slice(byNonce, func(i, j int) bool { return byNonce[i].Nonce() < byNonce[j].Nonce() })
+		txs = append(txs, byNonce...)
+	}
+	return txs
+}
@@ -0,0 +1,112 @@
+package listener
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// txpoolNode 实现 txpool_content 的测试节点
+type txpoolNode struct {
+	pending map[string]map[string]*ethtypes.Transaction
+}
+
+func (n *txpoolNode) Content() map[string]map[string]map[string]*ethtypes.Transaction {
+	return map[string]map[string]map[string]*ethtypes.Transaction{"pending": n.pending, "queued": {}}
+}
+
+// pendingListNode 实现 eth_pendingTransactions 的测试节点
+type pendingListNode struct {
+	txs []*ethtypes.Transaction
+}
+
+func (n *pendingListNode) PendingTransactions() []*ethtypes.Transaction {
+	return n.txs
+}
+
+// backfillTx 以 nonce 和 gasPrice 区分的pending交易
+func backfillTx(nonce uint64, gasPrice int64) *ethtypes.Transaction {
+	return ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(gasPrice), Gas: 21000, Value: big.NewInt(1)})
+}
+
+func TestBackfillPending(t *testing.T) {
+	// txpool_content：两个发送者，各自的nonce乱序返回
+	txpool := &txpoolNode{pending: map[string]map[string]*ethtypes.Transaction{
+		"0x2222222222222222222222222222222222222222": {"1": backfillTx(1, 2e9), "0": backfillTx(0, 2e9)},
+		"0x1111111111111111111111111111111111111111": {"7": backfillTx(7, 1e9), "5": backfillTx(5, 1e9), "6": backfillTx(6, 1e9)},
+	}}
+	txpoolOrder := []*ethtypes.Transaction{
+		txpool.pending["0x1111111111111111111111111111111111111111"]["5"],
+		txpool.pending["0x1111111111111111111111111111111111111111"]["6"],
+		txpool.pending["0x1111111111111111111111111111111111111111"]["7"],
+		txpool.pending["0x2222222222222222222222222222222222222222"]["0"],
+		txpool.pending["0x2222222222222222222222222222222222222222"]["1"],
+	}
+	pendingList := &pendingListNode{txs: []*ethtypes.Transaction{backfillTx(0, 3e9), backfillTx(1, 3e9)}}
+
+	tests := []struct {
+		name        string
+		txpool      bool // 节点提供 txpool_content
+		pendingList bool // 节点提供 eth_pendingTransactions
+		mode        string
+		limit       int
+		want        []*ethtypes.Transaction
+		method      string
+	}{
+		{name: "txpool_content", txpool: true, pendingList: true, mode: BackfillTxpool, want: txpoolOrder, method: BackfillTxpool},
+		{name: "eth_pendingTransactions", txpool: true, pendingList: true, mode: BackfillPendingTxns, want: pendingList.txs, method: BackfillPendingTxns},
+		{name: "auto prefers txpool_content", txpool: true, pendingList: true, mode: BackfillAuto, want: txpoolOrder, method: BackfillTxpool},
+		{name: "auto falls back", pendingList: true, mode: BackfillAuto, want: pendingList.txs, method: BackfillPendingTxns},
+		{name: "auto with neither method", mode: BackfillAuto},
+		{name: "unsupported method", pendingList: true, mode: BackfillTxpool},
+		{name: "limit", txpool: true, mode: BackfillTxpool, limit: 2, want: txpoolOrder[:2], method: BackfillTxpool},
+		{name: "off", txpool: true, pendingList: true, mode: BackfillOff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rpc.NewServer()
+			defer server.Stop()
+			if tt.txpool {
+				if err := server.RegisterName("txpool", txpool); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.pendingList {
+				if err := server.RegisterName("eth", pendingList); err != nil {
+					t.Fatal(err)
+				}
+			}
+			rpcClient := rpc.DialInProc(server)
+			defer rpcClient.Close()
+
+			l := &Listener{client: ethclient.NewClient(rpcClient), rpcClient: rpcClient}
+			l.SetBackfill(tt.mode, tt.limit)
+			txChan := make(chan *types.Transaction, 10)
+			l.backfillPending(context.Background(), txChan)
+			close(txChan)
+
+			var got []*ethtypes.Transaction
+			for tx := range txChan {
+				got = append(got, tx.RawTx)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("backfilled %d transactions, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Hash() != tt.want[i].Hash() {
+					t.Errorf("transaction %d = nonce %d, want nonce %d", i, got[i].Nonce(), tt.want[i].Nonce())
+				}
+			}
+			stats := l.GetStats()
+			if stats["backfill_method"] != tt.method || stats["backfilled"] != int64(len(tt.want)) {
+				t.Errorf("stats backfill_method = %v, backfilled = %v; want %q, %d", stats["backfill_method"], stats["backfilled"], tt.method, len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// pendingBacklogSize 订阅消息积压上限（超过后丢弃最旧的消息）
+const pendingBacklogSize = 1000
+
+// pendingBacklog 订阅消息的丢弃最旧环形缓冲：WS读取循环只写入、从不阻塞，
+// 分发协程按顺序取出处理
+type pendingBacklog struct {
+	mu      sync.Mutex
+	buf     []json.RawMessage
+	head    int // 最旧消息的位置
+	count   int
+	dropped int64
+	notify  chan struct{} // 有新消息时唤醒分发协程
+}
+
+// newPendingBacklog 创建容量为 size 的积压缓冲
+func newPendingBacklog(size int) *pendingBacklog {
+	if size < 1 {
+		size = 1
+	}
+	return &pendingBacklog{
+		buf:    make([]json.RawMessage, size),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// push 写入消息，缓冲已满时覆盖最旧的消息并返回 true
+func (b *pendingBacklog) push(message json.RawMessage) bool {
+	b.mu.Lock()
+	dropped := false
+	if b.count == len(b.buf) {
+		b.buf[b.head] = nil
+		b.head = (b.head + 1) % len(b.buf)
+		b.count--
+		b.dropped++
+		dropped = true
+	}
+	b.buf[(b.head+b.count)%len(b.buf)] = message
+	b.count++
+	b.mu.Unlock()
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+	return dropped
+}
+
+// pop 取出最旧的消息，缓冲为空时等待（上下文取消时返回 false）
+func (b *pendingBacklog) pop(ctx context.Context) (json.RawMessage, bool) {
+	for {
+		b.mu.Lock()
+		if b.count > 0 {
+			message := b.buf[b.head]
+			b.buf[b.head] = nil
+			b.head = (b.head + 1) % len(b.buf)
+			b.count--
+			b.mu.Unlock()
+			return message, true
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-b.notify:
+		}
+	}
+}
+
+// stats 当前积压数量和累计丢弃数量
+func (b *pendingBacklog) stats() (int, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count, b.dropped
+}
@@ -0,0 +1,182 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestPendingBacklogDropsOldest(t *testing.T) {
+	backlog := newPendingBacklog(3)
+	for i := 0; i < 5; i++ {
+		dropped := backlog.push(json.RawMessage{byte('0' + i)})
+		if want := i >= 3; dropped != want {
+			t.Errorf("push(%d) dropped = %v, want %v", i, dropped, want)
+		}
+	}
+	if pending, dropped := backlog.stats(); pending != 3 || dropped != 2 {
+		t.Fatalf("stats() = %d pending, %d dropped; want 3 and 2", pending, dropped)
+	}
+
+	for _, want := range []string{"2", "3", "4"} {
+		message, ok := backlog.pop(context.Background())
+		if !ok || string(message) != want {
+			t.Errorf("pop() = %q, %v; want %q", message, ok, want)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := backlog.pop(ctx); ok {
+		t.Error("pop() on an empty backlog returned a message after cancellation")
+	}
+}
+
+// floodNode 订阅 newPendingTransactions 后立即推送全部交易的测试节点
+type floodNode struct {
+	txs []*ethtypes.Transaction
+}
+
+func (n *floodNode) NewPendingTransactions(ctx context.Context, fullTx *bool) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for _, tx := range n.txs {
+			if err := notifier.Notify(sub.ID, tx); err != nil {
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// 下游完全卡住时，读取循环仍把订阅消息全部读入积压缓冲（丢弃最旧），订阅不会因读取停滞而断开
+func TestReadLoopKeepsDrainingWhenDispatchIsSaturated(t *testing.T) {
+	const total = 3 * pendingBacklogSize
+	node := &floodNode{}
+	for nonce := uint64(0); nonce < total; nonce++ {
+		node.txs = append(node.txs, ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(1e9), Value: big.NewInt(1)}))
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	rpcClient := rpc.DialInProc(server)
+	defer rpcClient.Close()
+
+	l := &Listener{client: ethclient.NewClient(rpcClient), rpcClient: rpcClient}
+	l.capabilities.FullTxBodies = true
+	var gaps atomic.Int64
+	l.SetGapHandler(func() { gaps.Add(1) })
+
+	// 预过滤卡住分发协程，直到测试放行
+	release := make(chan struct{})
+	l.SetPreFilter(func(tx *types.Transaction) bool {
+		<-release
+		return true
+	})
+
+	txChan := make(chan *types.Transaction, total)
+	ctx, cancel := context.WithCancel(context.Background())
+	l.goSend(func() { l.subscribePendingTransactions(ctx, txChan) })
+	defer func() {
+		cancel()
+		l.Wait()
+	}()
+
+	// 分发协程取出第一条后卡住，其余消息全部由读取循环读入积压缓冲
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		stats := l.GetStats()
+		pending, dropped := stats["backlog_pending"], stats["backlog_dropped"]
+		if pending == pendingBacklogSize && dropped == int64(total-1-pendingBacklogSize) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("read loop stalled: %v pending, %v dropped of %d messages", pending, dropped, total)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if resubscribes := l.GetStats()["resubscribes"]; resubscribes != int64(0) {
+		t.Errorf("subscription re-established %v times while dispatch was saturated", resubscribes)
+	}
+	if got := gaps.Load(); got != total-1-pendingBacklogSize {
+		t.Errorf("gap handler called %d times, want once per dropped message (%d)", got, total-1-pendingBacklogSize)
+	}
+
+	// 放行后分发协程处理第一条和保留下来的最新消息
+	close(release)
+	deadline = time.Now().Add(10 * time.Second)
+	for len(txChan) < pendingBacklogSize+1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("dispatched %d transactions after release, want %d", len(txChan), pendingBacklogSize+1)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if first := <-txChan; first.Nonce != 0 {
+		t.Errorf("first dispatched nonce = %d, want 0", first.Nonce)
+	}
+	if second := <-txChan; second.Nonce != total-pendingBacklogSize {
+		t.Errorf("oldest kept nonce = %d, want %d", second.Nonce, total-pendingBacklogSize)
+	}
+}
+
+// 正常停止时订阅直接退出：不进入重连，也不标记漏单
+func TestPendingSubscriptionShutdownIsNotAGap(t *testing.T) {
+	node := &floodNode{txs: []*ethtypes.Transaction{
+		ethtypes.NewTx(&ethtypes.LegacyTx{GasPrice: big.NewInt(1e9), Value: big.NewInt(1)}),
+	}}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	rpcClient := rpc.DialInProc(server)
+	defer rpcClient.Close()
+
+	l := &Listener{client: ethclient.NewClient(rpcClient), rpcClient: rpcClient}
+	l.capabilities.FullTxBodies = true
+	var gaps atomic.Int64
+	l.SetGapHandler(func() { gaps.Add(1) })
+
+	txChan := make(chan *types.Transaction, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	l.goSend(func() {
+		l.subscribePendingTransactions(ctx, txChan)
+		close(done)
+	})
+
+	// 收到推送说明订阅已建立，此时停止
+	select {
+	case <-txChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no transaction delivered by the subscription")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription did not return after cancellation")
+	}
+	l.Wait()
+
+	if got := gaps.Load(); got != 0 {
+		t.Errorf("gap handler called %d times on shutdown", got)
+	}
+	if reconnects := l.GetStats()["reconnects"]; reconnects != int64(0) {
+		t.Errorf("reconnects = %v on shutdown", reconnects)
+	}
+}
@@ -0,0 +1,98 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// blockNode 只实现 eth_getBlockByHash 的测试节点，记录拉取次数
+type blockNode struct {
+	fetches atomic.Int64
+}
+
+func (n *blockNode) GetBlockByHash(hash common.Hash, full bool) (json.RawMessage, error) {
+	n.fetches.Add(1)
+	header := testHeader(1)
+	fields, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	var block map[string]interface{}
+	if err := json.Unmarshal(fields, &block); err != nil {
+		return nil, err
+	}
+	block["transactions"] = []interface{}{}
+	block["uncles"] = []interface{}{}
+	return json.Marshal(block)
+}
+
+func testHeader(number int64) *ethtypes.Header {
+	return &ethtypes.Header{
+		Number:     big.NewInt(number),
+		Difficulty: big.NewInt(0),
+		TxHash:     ethtypes.EmptyTxsHash,
+		UncleHash:  ethtypes.EmptyUncleHash,
+	}
+}
+
+func TestDeliverBlocksSkipsUnwantedBlocks(t *testing.T) {
+	node := &blockNode{}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	rpcClient := rpc.DialInProc(server)
+	defer rpcClient.Close()
+
+	l := &Listener{client: ethclient.NewClient(rpcClient)}
+	delivered := make(chan *ethtypes.Block, 4)
+	l.SetBlockHandler(func(block *ethtypes.Block) { delivered <- block })
+	var wanted atomic.Bool
+	l.SetBlockFilter(wanted.Load)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	headers := make(chan *ethtypes.Header)
+	go l.deliverBlocks(ctx, headers)
+
+	// 没有待核对的交易：不拉取区块体
+	headers <- testHeader(1)
+	headers <- testHeader(2)
+	deadline := time.Now().Add(5 * time.Second)
+	for l.GetStats()["block_skipped"] != int64(2) {
+		if time.Now().After(deadline) {
+			t.Fatalf("block_skipped = %v, want 2", l.GetStats()["block_skipped"])
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	wanted.Store(true)
+	headers <- testHeader(3)
+	select {
+	case block := <-delivered:
+		if block.NumberU64() != 1 {
+			t.Errorf("delivered block %d, want the fetched block", block.NumberU64())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("wanted block was not delivered")
+	}
+
+	if got := node.fetches.Load(); got != 1 {
+		t.Errorf("eth_getBlockByHash called %d times, want 1", got)
+	}
+	select {
+	case block := <-delivered:
+		t.Errorf("unwanted block %d delivered", block.NumberU64())
+	default:
+	}
+}
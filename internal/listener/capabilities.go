@@ -0,0 +1,113 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Capabilities 节点pending交易订阅能力
+type Capabilities struct {
+	Probed       bool `json:"probed"`         // 是否已完成探测
+	FullTxBodies bool `json:"full_tx_bodies"` // 支持推送完整交易体 (newPendingTransactions, true)
+	ServerFilter bool `json:"server_filter"`  // 支持服务端按地址过滤 (alchemy_pendingTransactions)
+}
+
+// 探测时等待首条推送的超时时间（探测期间pending订阅尚未开始，不宜过长）
+const probeTimeout = 1500 * time.Millisecond
+
+// SetProbeCapabilities 设置是否在启动时探测节点能力（需在Start之前调用）
+func (l *Listener) SetProbeCapabilities(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.probeEnabled = enabled
+}
+
+// SetPendingFilter 设置服务端过滤的目标合约地址（节点支持时生效）
+func (l *Listener) SetPendingFilter(addresses []common.Address) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pendingFilter = addresses
+}
+
+// probeCapabilities 同时尝试更丰富的订阅方式，记录节点支持的能力（最多耗时一个 probeTimeout）
+func (l *Listener) probeCapabilities(ctx context.Context) Capabilities {
+	l.mu.RLock()
+	enabled := l.probeEnabled
+	filter := l.pendingFilter
+	l.mu.RUnlock()
+
+	caps := Capabilities{}
+	if !enabled {
+		return caps
+	}
+
+	caps.Probed = true
+	var wg sync.WaitGroup
+	if len(filter) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			caps.ServerFilter = l.probeFullBodies(ctx, "alchemy_pendingTransactions", l.serverFilterParams(filter))
+		}()
+	}
+	caps.FullTxBodies = l.probeFullBodies(ctx, "newPendingTransactions", true)
+	wg.Wait()
+
+	logger.Info("节点能力探测完成", "full_tx_bodies", caps.FullTxBodies, "server_filter", caps.ServerFilter)
+
+	l.mu.Lock()
+	l.capabilities = caps
+	l.mu.Unlock()
+
+	return caps
+}
+
+// probeFullBodies 尝试订阅并等待首条推送，确认推送的是完整交易体而不是哈希
+func (l *Listener) probeFullBodies(ctx context.Context, args ...interface{}) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	messages := make(chan json.RawMessage, 1)
+	sub, err := l.getRPCClient().EthSubscribe(probeCtx, messages, args...)
+	if err != nil {
+		return false
+	}
+	defer sub.Unsubscribe()
+
+	select {
+	case message := <-messages:
+		// 部分节点接受参数但仍只推送哈希
+		return len(message) > 0 && message[0] == '{'
+	case <-sub.Err():
+		return false
+	case <-probeCtx.Done():
+		return false
+	}
+}
+
+// serverFilterParams 构建服务端过滤参数
+func (l *Listener) serverFilterParams(filter []common.Address) map[string]interface{} {
+	return map[string]interface{}{
+		"toAddress":  filter,
+		"hashesOnly": false,
+	}
+}
+
+// pendingSubscriptionArgs 根据节点能力选择最优的订阅参数
+func (l *Listener) pendingSubscriptionArgs() []interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	switch {
+	case l.capabilities.ServerFilter:
+		return []interface{}{"alchemy_pendingTransactions", l.serverFilterParams(l.pendingFilter)}
+	case l.capabilities.FullTxBodies:
+		return []interface{}{"newPendingTransactions", true}
+	default:
+		return []interface{}{"newPendingTransactions"}
+	}
+}
@@ -0,0 +1,106 @@
+package listener
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// probeNode 可配置订阅行为的测试节点：fullBodies 为是否按参数推送完整交易体，
+// serverFilter 为是否提供 alchemy_pendingTransactions，silent 为订阅后不推送任何消息
+type probeNode struct {
+	fullBodies   bool
+	serverFilter bool
+	silent       bool
+}
+
+var probeTx = ethtypes.NewTx(&ethtypes.LegacyTx{GasPrice: big.NewInt(1e9), Gas: 21000, Value: big.NewInt(1)})
+
+// push 创建订阅并推送一条消息（完整交易体或哈希）
+func (n *probeNode) push(ctx context.Context, full bool) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	if !n.silent {
+		go func() {
+			if full {
+				notifier.Notify(sub.ID, probeTx)
+			} else {
+				notifier.Notify(sub.ID, probeTx.Hash())
+			}
+		}()
+	}
+	return sub, nil
+}
+
+func (n *probeNode) NewPendingTransactions(ctx context.Context, fullTx *bool) (*rpc.Subscription, error) {
+	return n.push(ctx, n.fullBodies && fullTx != nil && *fullTx)
+}
+
+// Alchemy_pendingTransactions 订阅名按方法名首字母小写注册，下划线是为了得到 alchemy_pendingTransactions
+func (n *probeNode) Alchemy_pendingTransactions(ctx context.Context, params map[string]interface{}) (*rpc.Subscription, error) {
+	if !n.serverFilter {
+		return nil, codeError{code: rpcCodeMethodNotFound, message: "no alchemy_pendingTransactions subscription"}
+	}
+	return n.push(ctx, params["hashesOnly"] != true)
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	target := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+
+	tests := []struct {
+		name    string
+		node    *probeNode
+		disable bool
+		filter  []common.Address
+		want    Capabilities
+		args    []interface{}
+	}{
+		{name: "probe disabled", node: &probeNode{fullBodies: true}, disable: true,
+			want: Capabilities{}, args: []interface{}{"newPendingTransactions"}},
+		{name: "hashes only", node: &probeNode{},
+			want: Capabilities{Probed: true}, args: []interface{}{"newPendingTransactions"}},
+		{name: "full bodies", node: &probeNode{fullBodies: true},
+			want: Capabilities{Probed: true, FullTxBodies: true}, args: []interface{}{"newPendingTransactions", true}},
+		{name: "no filter configured", node: &probeNode{fullBodies: true, serverFilter: true},
+			want: Capabilities{Probed: true, FullTxBodies: true}, args: []interface{}{"newPendingTransactions", true}},
+		{name: "server filter", node: &probeNode{fullBodies: true, serverFilter: true}, filter: []common.Address{target},
+			want: Capabilities{Probed: true, FullTxBodies: true, ServerFilter: true},
+			args: []interface{}{"alchemy_pendingTransactions", map[string]interface{}{"toAddress": []common.Address{target}, "hashesOnly": false}}},
+		{name: "server filter unsupported", node: &probeNode{fullBodies: true}, filter: []common.Address{target},
+			want: Capabilities{Probed: true, FullTxBodies: true}, args: []interface{}{"newPendingTransactions", true}},
+		// 订阅成功但超时前没有推送：不能确认能力，按最保守的方式订阅
+		{name: "no message before timeout", node: &probeNode{fullBodies: true, silent: true},
+			want: Capabilities{Probed: true}, args: []interface{}{"newPendingTransactions"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rpc.NewServer()
+			if err := server.RegisterName("eth", tt.node); err != nil {
+				t.Fatal(err)
+			}
+			defer server.Stop()
+			rpcClient := rpc.DialInProc(server)
+			defer rpcClient.Close()
+
+			l := &Listener{client: ethclient.NewClient(rpcClient), rpcClient: rpcClient}
+			l.SetProbeCapabilities(!tt.disable)
+			l.SetPendingFilter(tt.filter)
+
+			if got := l.probeCapabilities(context.Background()); got != tt.want {
+				t.Errorf("probeCapabilities() = %+v, want %+v", got, tt.want)
+			}
+			if got := l.pendingSubscriptionArgs(); !reflect.DeepEqual(got, tt.args) {
+				t.Errorf("pendingSubscriptionArgs() = %v, want %v", got, tt.args)
+			}
+		})
+	}
+}
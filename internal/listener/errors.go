@@ -0,0 +1,45 @@
+package listener
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// JSON-RPC 永久性错误码
+const (
+	rpcCodeMethodNotFound = -32601 // 方法不存在
+	rpcCodeInvalidRequest = -32600 // 请求无效
+)
+
+// IsPermanentSubscriptionError 判断订阅错误是否为永久性错误
+// 永久性错误（认证失败、方法不支持等）重连也无法恢复，应停止订阅而不是无限重连；
+// 只依据JSON-RPC错误码、HTTP状态码和go-ethereum的错误值判断，不匹配错误描述（各服务商措辞不一，临时错误也可能包含相同字样）
+func IsPermanentSubscriptionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.ErrorCode() {
+		case rpcCodeMethodNotFound, rpcCodeInvalidRequest:
+			return true
+		}
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+			return true
+		}
+	}
+
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return true
+	}
+
+	return false
+}
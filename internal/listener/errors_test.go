@@ -0,0 +1,88 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// codeError 携带JSON-RPC错误码的节点错误
+type codeError struct {
+	code    int
+	message string
+}
+
+func (e codeError) Error() string  { return e.message }
+func (e codeError) ErrorCode() int { return e.code }
+
+func TestIsPermanentSubscriptionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "method not found code", err: codeError{code: -32601, message: "the method eth_subscribe does not exist/is not available"}, want: true},
+		{name: "invalid request code", err: codeError{code: -32600, message: "invalid request"}, want: true},
+		{name: "wrapped code", err: fmt.Errorf("subscribe: %w", codeError{code: -32601, message: "no such subscription"}), want: true},
+		{name: "unauthorized status", err: rpc.HTTPError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"}, want: true},
+		{name: "forbidden status", err: rpc.HTTPError{StatusCode: http.StatusForbidden, Status: "403 Forbidden"}, want: true},
+		{name: "not found status", err: rpc.HTTPError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}, want: true},
+		{name: "notifications unsupported", err: fmt.Errorf("dial: %w", rpc.ErrNotificationsUnsupported), want: true},
+		// 描述中带有“永久性”字样的临时错误不应停止订阅
+		{name: "server error mentioning a missing method", err: codeError{code: -32000, message: "upstream method does not exist on this shard, retry"}, want: false},
+		{name: "plain error mentioning authentication", err: errors.New("authentication service timeout"), want: false},
+		{name: "plain error mentioning not supported", err: errors.New("batch not supported while syncing"), want: false},
+		{name: "bad gateway status", err: rpc.HTTPError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}, want: false},
+		{name: "connection reset", err: errors.New("read: connection reset by peer"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPermanentSubscriptionError(tt.err); got != tt.want {
+				t.Errorf("IsPermanentSubscriptionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// 节点不支持 newPendingTransactions 订阅（-32601）时，订阅协程直接退出，不进入重连循环
+func TestPendingSubscriptionStopsOnPermanentError(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &numberedNode{number: 1}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	rpcClient := rpc.DialInProc(server)
+	defer rpcClient.Close()
+
+	l := &Listener{client: ethclient.NewClient(rpcClient), rpcClient: rpcClient}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		l.Wait()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		l.subscribePendingTransactions(ctx, make(chan *types.Transaction, 1))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription kept retrying after a permanent error")
+	}
+	stats := l.GetStats()
+	if stats["reconnects"] != int64(0) || stats["resubscribes"] != int64(0) {
+		t.Errorf("stats = %v, want no reconnects or resubscribes", stats)
+	}
+}
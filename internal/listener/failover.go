@@ -0,0 +1,56 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NewListenerWithEndpoints 创建支持故障切换的监听器：按顺序连接第一个可用节点，
+// 重连时轮换到下一个节点而不是反复重试同一个故障节点
+func NewListenerWithEndpoints(wssURLs []string) (*Listener, error) {
+	if len(wssURLs) == 0 {
+		return nil, fmt.Errorf("no WebSocket endpoint configured")
+	}
+
+	var lastErr error
+	for i, wssURL := range wssURLs {
+		client, rpcClient, err := dial(context.Background(), wssURL)
+		if err != nil {
+			if len(wssURLs) > 1 {
+				logger.Warn("监听节点连接失败，尝试下一个", "endpoint", i+1, "endpoints", len(wssURLs), "error", err)
+			}
+			lastErr = err
+			continue
+		}
+
+		return &Listener{
+			client:      client,
+			rpcClient:   rpcClient,
+			wssURL:      wssURL,
+			endpoints:   wssURLs,
+			endpointIdx: i,
+			startTime:   time.Now(),
+		}, nil
+	}
+	return nil, lastErr
+}
+
+// nextEndpoint 轮换到下一个候选节点，返回其地址和序号（只有一个节点时始终是当前节点）
+func (l *Listener) nextEndpoint() (string, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.endpoints) <= 1 {
+		return l.wssURL, l.endpointIdx
+	}
+	l.endpointIdx = (l.endpointIdx + 1) % len(l.endpoints)
+	return l.endpoints[l.endpointIdx], l.endpointIdx
+}
+
+// endpointCount 候选节点数（至少为1）
+func (l *Listener) endpointCount() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return max(len(l.endpoints), 1)
+}
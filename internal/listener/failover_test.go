@@ -0,0 +1,190 @@
+package listener
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// numberedNode 只实现 eth_blockNumber 的测试节点，按返回的区块号区分节点
+type numberedNode struct {
+	number uint64
+}
+
+func (n *numberedNode) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(n.number)
+}
+
+// startWSNode 启动 WebSocket 测试节点，返回其地址和停止函数
+func startWSNode(t *testing.T, number uint64) (string, func()) {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &numberedNode{number: number}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	stop := func() {
+		server.Stop()
+		httpServer.Close()
+	}
+	t.Cleanup(stop)
+	return "ws://" + strings.TrimPrefix(httpServer.URL, "http://"), stop
+}
+
+func TestListenerFailsOverToNextEndpoint(t *testing.T) {
+	first, stopFirst := startWSNode(t, 1)
+	second, _ := startWSNode(t, 2)
+
+	l, err := NewListenerWithEndpoints([]string{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+	if number, err := l.getClient().BlockNumber(context.Background()); err != nil || number != 1 {
+		t.Fatalf("BlockNumber() = %d, %v; want the first endpoint", number, err)
+	}
+
+	// 第一个节点宕机：重连切换到第二个节点
+	stopFirst()
+	l.reconnect(context.Background(), nil)
+
+	if number, err := l.getClient().BlockNumber(context.Background()); err != nil || number != 2 {
+		t.Fatalf("BlockNumber() = %d, %v after failover; want the second endpoint", number, err)
+	}
+	stats := l.GetStats()
+	if stats["failovers"] != int64(1) || stats["endpoint_index"] != 1 {
+		t.Errorf("stats = %v, want one failover to the second endpoint", stats)
+	}
+}
+
+func TestListenerSkipsDeadEndpointAtStart(t *testing.T) {
+	dead, stopDead := startWSNode(t, 1)
+	stopDead()
+	live, _ := startWSNode(t, 2)
+
+	l, err := NewListenerWithEndpoints([]string{dead, live})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+	if number, err := l.getClient().BlockNumber(context.Background()); err != nil || number != 2 {
+		t.Errorf("BlockNumber() = %d, %v; want the live endpoint", number, err)
+	}
+}
+
+// startHangingNode 接受连接但永远不完成 WebSocket 握手的测试节点
+func startHangingNode(t *testing.T) string {
+	t.Helper()
+	release := make(chan struct{})
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(func() { close(release) })
+	return "ws://" + strings.TrimPrefix(httpServer.URL, "http://")
+}
+
+// 握手挂起的节点在单次RPC超时后放弃，重连继续轮换到下一个节点
+func TestReconnectTimesOutHangingEndpoint(t *testing.T) {
+	live, _ := startWSNode(t, 1)
+	hanging := startHangingNode(t)
+
+	l, err := NewListenerWithEndpoints([]string{live, hanging})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+	l.SetFetchTimeout(200 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		l.reconnect(context.Background(), nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reconnect is stuck dialing the hanging endpoint")
+	}
+
+	if number, err := l.getClient().BlockNumber(context.Background()); err != nil || number != 1 {
+		t.Errorf("BlockNumber() = %d, %v after reconnect; want the live endpoint", number, err)
+	}
+}
+
+func TestSwapEndpointTimesOutHangingEndpoint(t *testing.T) {
+	live, _ := startWSNode(t, 1)
+	l, err := NewListener(live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+	l.SetFetchTimeout(200 * time.Millisecond)
+
+	hanging := startHangingNode(t)
+	result := make(chan error, 1)
+	go func() { result <- l.SwapEndpoint(context.Background(), hanging, big.NewInt(1)) }()
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Fatal("SwapEndpoint() to a hanging endpoint succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SwapEndpoint() is stuck dialing the hanging endpoint")
+	}
+}
+
+// 新区块和pending订阅同时断开时只重连一次：并发的重连请求共享同一次拨号，晚到的请求发现代次已更新直接复用
+func TestConcurrentReconnectsDialOnce(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &numberedNode{number: 1}); err != nil {
+		t.Fatal(err)
+	}
+	var handshakes atomic.Int64
+	ws := server.WebsocketHandler([]string{"*"})
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handshakes.Add(1)
+		ws.ServeHTTP(w, r)
+	}))
+	defer func() {
+		server.Stop()
+		httpServer.Close()
+	}()
+
+	l, err := NewListener("ws://" + strings.TrimPrefix(httpServer.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+	perDial := handshakes.Load() // 每次拨号建立的连接数（ethclient 和 rpc 客户端各一个）
+
+	const subscriptions = 8
+	gen := l.generation()
+	var wg sync.WaitGroup
+	for i := 0; i < subscriptions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.reconnectShared(context.Background(), nil, gen); err != nil {
+				t.Errorf("reconnectShared() = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if dials := handshakes.Load()/perDial - 1; dials != 1 {
+		t.Errorf("%d concurrent reconnects dialed %d times, want 1", subscriptions, dials)
+	}
+	if stats := l.GetStats(); stats["reconnects"] != int64(1) || l.generation() != gen+1 {
+		t.Errorf("reconnects = %v, generation = %d; want 1 and %d", stats["reconnects"], l.generation(), gen+1)
+	}
+}
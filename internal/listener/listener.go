@@ -2,20 +2,27 @@ package listener
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"sync"
 	"time"
 
+	"mempool-sniper/internal/logging"
 	"mempool-sniper/pkg/types"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/sync/singleflight"
 )
 
+// logger 监听器日志（component=listener）
+var logger = logging.Component("listener")
+
 // Listener 交易监听器
 type Listener struct {
 	client    *ethclient.Client
@@ -25,28 +32,68 @@ type Listener struct {
 	mu        sync.RWMutex
 	txCount   int64
 	startTime time.Time
+
+	headHandler  func(header *ethtypes.Header) // 新区块回调
+	blockHandler func(block *ethtypes.Block)   // 完整区块回调（需要时按哈希拉取区块体，按订阅顺序串行回调）
+	blockFilter  func() bool                   // 是否需要拉取完整区块（为nil表示每个新区块都拉取）
+	blockDropped int64                         // 区块拉取队列已满而跳过的新区块数
+	blockSkipped int64                         // 没有回调需要而未拉取的新区块数
+
+	preFilter   func(tx *types.Transaction) bool // 发送到解码通道前的预过滤（为nil表示不过滤）
+	txObserver  func(tx *types.Transaction)      // 预过滤之前对每笔pending交易的回调（为nil表示不回调）
+	gapHandler  func()                           // 可能漏掉pending交易时的回调（丢弃或断线）
+	preFiltered int64                            // 被预过滤丢弃的交易数
+
+	fetchTimeout  time.Duration // 单次RPC请求超时（0表示不限制）
+	fetchTimeouts int64         // RPC请求超时次数
+
+	probeEnabled  bool             // 启动时是否探测节点能力
+	pendingFilter []common.Address // 服务端过滤的目标合约地址
+	capabilities  Capabilities     // 探测到的节点能力
+
+	reconnectGroup singleflight.Group // 保证同一时间只有一个重连在执行
+	reconnects     int64              // 重连成功次数
+	connGen        uint64             // 连接代次（每次重连成功加一，订阅据此判断出错的连接是否已被替换）
+	resubscribes   int64              // 重连后在新连接上恢复的订阅数
+
+	endpoints   []string // 候选节点（重连时按顺序轮换，为空表示只使用 wssURL）
+	endpointIdx int      // 当前使用的候选节点序号
+	failovers   int64    // 切换到其他候选节点的次数
+
+	backlog *pendingBacklog // 订阅消息积压（丢弃最旧），读取循环与分发解耦
+
+	backfillMode   string // 冷启动回填方式（空或off表示不回填）
+	backfillLimit  int    // 最多回填的交易数（0表示不限制）
+	backfillMethod string // 实际成功使用的回填方法
+	backfilled     int64  // 回填送入管道的交易数
+
+	senders sync.WaitGroup // 会向交易通道发送的goroutine（停止时等待其全部退出）
+
+	seen *seenHashes // 最近查询过的pending交易哈希（为nil表示不去重）
+
+	fetchSlots   chan struct{} // 并发查询交易的名额（为nil表示不限制）
+	fetchDropped int64         // 名额已满而丢弃的交易哈希数
 }
 
 // NewListener 创建新的监听器
 func NewListener(wssURL string) (*Listener, error) {
-	client, err := ethclient.Dial(wssURL)
+	return NewListenerWithEndpoints([]string{wssURL})
+}
+
+// dial 建立节点连接（ctx 只限制建立连接的过程，不影响建立后的连接）
+func dial(ctx context.Context, wssURL string) (*ethclient.Client, *rpc.Client, error) {
+	client, err := ethclient.DialContext(ctx, wssURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum node: %v", err)
+		return nil, nil, fmt.Errorf("failed to connect to Ethereum node: %v", err)
 	}
 
-	rpcClient, err := rpc.Dial(wssURL)
+	rpcClient, err := rpc.DialContext(ctx, wssURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RPC endpoint: %v", err)
+		client.Close()
+		return nil, nil, fmt.Errorf("failed to connect to RPC endpoint: %v", err)
 	}
 
-	return &Listener{
-		client:    client,
-		rpcClient: rpcClient,
-		wssURL:    wssURL,
-		isRunning: false,
-		txCount:   0,
-		startTime: time.Now(),
-	}, nil
+	return client, rpcClient, nil
 }
 
 // Start 启动监听器
@@ -60,14 +107,15 @@ func (l *Listener) Start(ctx context.Context, txChan chan<- *types.Transaction)
 	l.startTime = time.Now()
 	l.mu.Unlock()
 
-	log.Printf("📡 开始监听内存池交易...")
+	logger.Info("开始监听内存池交易")
 
 	// 创建新区块通道
 	// 注：这是个经验值：100 个区块大约涵盖了 20 分钟的数据量，这给下游处理留足了‘喘息时间’，既保证了不阻塞网络 IO，又不会占用过多内存。
 	headChan := make(chan *ethtypes.Header, 100)
 
 	// 订阅新区块
-	headSub, err := l.client.SubscribeNewHead(ctx, headChan)
+	gen := l.generation()
+	headSub, err := l.getClient().SubscribeNewHead(ctx, headChan)
 	if err != nil {
 		l.mu.Lock()
 		l.isRunning = false
@@ -78,31 +126,84 @@ func (l *Listener) Start(ctx context.Context, txChan chan<- *types.Transaction)
 	// 启动区块处理goroutine
 	go l.processHeads(ctx, headChan, txChan)
 
-	// 启动pending交易监听goroutine
-	go l.subscribePendingTransactions(ctx, txChan)
+	// 探测节点能力并启动pending交易监听goroutine，订阅的同时回填当前pending池
+	l.goSend(func() {
+		l.probeCapabilities(ctx)
+		l.goSend(func() { l.backfillPending(ctx, txChan) })
+		l.subscribePendingTransactions(ctx, txChan)
+	})
 
-	// 处理订阅事件
-	go func() {
-		defer headSub.Unsubscribe()
+	// 处理订阅事件，断开后重连并在新连接上重新订阅
+	go l.watchHeads(ctx, headSub, gen, headChan, txChan)
+
+	return nil
+}
+
+// watchHeads 维持新区块订阅：订阅出错时与pending订阅共享同一次重连，再在新连接上重新订阅，
+// 只有这一个goroutine持有新区块订阅，不会重复订阅
+func (l *Listener) watchHeads(ctx context.Context, sub ethereum.Subscription, gen uint64, headChan chan *ethtypes.Header, txChan chan<- *types.Transaction) {
+	backoff := time.Second
+	maxBackoff := 30 * time.Second
+
+	for {
+		permanent := func() bool {
+			defer sub.Unsubscribe()
 
-		for {
 			select {
 			case <-ctx.Done():
-				log.Println("🛑 监听器收到停止信号")
+				logger.Info("新区块订阅收到停止信号")
 				l.mu.Lock()
 				l.isRunning = false
 				l.mu.Unlock()
+				return true
+			case err := <-sub.Err():
+				if IsPermanentSubscriptionError(err) {
+					logger.Error("新区块订阅遇到永久性错误，停止订阅", "error", err)
+					return true
+				}
+				logger.Warn("新区块订阅错误", "error", err)
+				return false
+			}
+		}()
+		if permanent {
+			return
+		}
+
+		// 重连（其他订阅已重连过时直接复用新连接），然后重新订阅；订阅失败时退避后再检查连接
+		for {
+			if err := l.reconnectShared(ctx, txChan, gen); err != nil {
+				return
+			}
+
+			gen = l.generation()
+			resub, err := l.getClient().SubscribeNewHead(ctx, headChan)
+			if err == nil {
+				sub = resub
+				break
+			}
+			if IsPermanentSubscriptionError(err) {
+				logger.Error("新区块订阅遇到永久性错误，停止订阅", "error", err)
 				return
-			case err := <-headSub.Err():
-				log.Printf("⚠️ 新区块订阅错误: %v", err)
-				// 尝试重新连接
-				go l.reconnect(ctx, txChan)
+			}
+			logger.Warn("重新订阅新区块失败", "backoff", backoff, "error", err)
+
+			select {
+			case <-ctx.Done():
 				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
 			}
 		}
-	}()
 
-	return nil
+		logger.Info("新区块订阅已在新连接上恢复")
+		backoff = time.Second
+		l.mu.Lock()
+		l.resubscribes++
+		l.mu.Unlock()
+	}
 }
 
 // subscribePendingTransactions 订阅pending交易（改进版：支持自动重连）
@@ -111,6 +212,14 @@ func (l *Listener) subscribePendingTransactions(ctx context.Context, txChan chan
 	backoff := time.Second
 	maxBackoff := 30 * time.Second
 	retryCount := 0
+	subscribed := false // 是否已成功订阅过（之后的成功订阅计为恢复）
+
+	// 读取循环只写入积压缓冲，由单独的分发协程处理，避免下游变慢时阻塞WS读取
+	backlog := newPendingBacklog(pendingBacklogSize)
+	l.mu.Lock()
+	l.backlog = backlog
+	l.mu.Unlock()
+	l.goSend(func() { l.dispatchPending(ctx, backlog, txChan) })
 
 	for {
 		retryCount++
@@ -118,18 +227,22 @@ func (l *Listener) subscribePendingTransactions(ctx context.Context, txChan chan
 		// 检查是否被主动停止
 		select {
 		case <-ctx.Done():
-			log.Println("🛑 Pending交易订阅收到停止信号")
+			logger.Info("Pending交易订阅收到停止信号")
 			return
 		default:
 		}
 
-		// 使用rpc客户端订阅pending交易
-		pendingTxChan := make(chan string, 1000)
+		// 使用rpc客户端订阅pending交易（根据探测到的节点能力选择订阅方式）
+		pendingTxChan := make(chan json.RawMessage, 1000)
 
-		sub, err := l.rpcClient.EthSubscribe(ctx, pendingTxChan, "newPendingTransactions")
+		gen := l.generation()
+		sub, err := l.getRPCClient().EthSubscribe(ctx, pendingTxChan, l.pendingSubscriptionArgs()...)
 		if err != nil {
-			log.Printf("❌ 无法订阅pending交易 (尝试 %d), %v后重试: %v",
-				retryCount, backoff, err)
+			if IsPermanentSubscriptionError(err) {
+				logger.Error("Pending交易订阅遇到永久性错误，停止订阅", "error", err)
+				return
+			}
+			logger.Warn("无法订阅pending交易", "attempt", retryCount, "backoff", backoff, "error", err)
 
 			// 指数退避等待
 			select {
@@ -146,83 +259,232 @@ func (l *Listener) subscribePendingTransactions(ctx context.Context, txChan chan
 			continue
 		}
 
-		log.Printf("✅ 第%d次订阅pending交易成功", retryCount)
+		logger.Info("订阅pending交易成功", "attempt", retryCount)
+		if subscribed {
+			l.mu.Lock()
+			l.resubscribes++
+			l.mu.Unlock()
+		}
+		subscribed = true
 
 		// 连接成功后重置退避时间
 		backoff = time.Second
 		retryCount = 0
 
-		// 处理订阅事件
-		func() {
+		// 处理订阅事件（返回true表示停止订阅：收到停止信号或遇到永久性错误）
+		stop := func() bool {
 			defer sub.Unsubscribe()
 
 			for {
 				select {
 				case <-ctx.Done():
-					log.Println("🛑 Pending交易订阅内部处理收到停止信号")
-					return
+					// 正常停止不是断线：不触发重连，也不标记漏单
+					logger.Debug("Pending交易订阅内部处理收到停止信号")
+					return true
 				case err := <-sub.Err():
-					log.Printf("⚠️ Pending交易订阅错误，触发重连: %v", err)
-					return
-				case txHashStr := <-pendingTxChan:
-					if txHashStr == "" {
+					if IsPermanentSubscriptionError(err) {
+						logger.Error("Pending交易订阅遇到永久性错误，停止订阅", "error", err)
+						return true
+					}
+					logger.Warn("Pending交易订阅错误，触发重连", "error", err)
+					return false
+				case message := <-pendingTxChan:
+					if len(message) == 0 {
 						continue
 					}
 
-					// 检查是否被主动停止
-					select {
-					case <-ctx.Done():
-						log.Println("🛑 Pending交易订阅处理交易时收到停止信号")
-						return
-					default:
+					if backlog.push(message) {
+						l.markGap()
 					}
-
-					// 将字符串转换为Hash
-					txHash := common.HexToHash(txHashStr)
-
-					// 打印pending交易日志
-					log.Printf("[PENDING] 收到交易: %s", txHash.Hex())
-
-					// 异步处理交易
-					go l.fetchAndProcessTransaction(ctx, txHash, txChan)
 				}
 			}
 		}()
 
-		// 订阅断开后，继续外层循环进行重连
-		log.Println("🔄 Pending交易订阅断开，准备重连...")
+		if stop {
+			return
+		}
+
+		// 订阅断开后，与其他订阅共享同一次重连，然后继续外层循环重新订阅
+		if err := l.reconnectShared(ctx, txChan, gen); err != nil {
+			return
+		}
+		logger.Info("Pending交易订阅断开，准备重新订阅")
 	}
 }
 
+// dispatchPending 按顺序处理积压的订阅消息，直到上下文取消
+func (l *Listener) dispatchPending(ctx context.Context, backlog *pendingBacklog, txChan chan<- *types.Transaction) {
+	for {
+		message, ok := backlog.pop(ctx)
+		if !ok {
+			return
+		}
+		l.handlePendingMessage(ctx, message, txChan)
+	}
+}
+
+// handlePendingMessage 处理订阅推送：交易哈希需再次查询，完整交易体直接处理
+func (l *Listener) handlePendingMessage(ctx context.Context, message json.RawMessage, txChan chan<- *types.Transaction) {
+	if message[0] == '"' {
+		var txHashStr string
+		if err := json.Unmarshal(message, &txHashStr); err != nil || txHashStr == "" {
+			return
+		}
+
+		// 将字符串转换为Hash
+		txHash := common.HexToHash(txHashStr)
+
+		// 打印pending交易日志
+		logger.Debug("收到pending交易哈希", "tx_hash", txHash.Hex())
+
+		// 异步处理交易（并发查询数达到上限时丢弃，与通道满时丢弃一致）
+		release, ok := l.acquireFetchSlot()
+		if !ok {
+			logger.Warn("并发查询已达上限，丢弃交易哈希", "tx_hash", txHash.Hex())
+			l.markGap()
+			return
+		}
+		l.goSend(func() {
+			defer release()
+			l.fetchAndProcessTransaction(ctx, txHash, txChan)
+		})
+		return
+	}
+
+	tx := new(ethtypes.Transaction)
+	if err := json.Unmarshal(message, tx); err != nil {
+		logger.Warn("解析pending交易失败", "error", err)
+		return
+	}
+
+	logger.Debug("收到完整pending交易", "tx_hash", tx.Hash().Hex())
+	l.processTransaction(ctx, tx, txChan)
+}
+
 // processHeads 处理新区块
 func (l *Listener) processHeads(ctx context.Context, headChan <-chan *ethtypes.Header, txChan chan<- *types.Transaction) {
+	// 完整区块按订阅顺序串行拉取和回调（重组检测依赖区块顺序），不阻塞新区块回调
+	blocks := make(chan *ethtypes.Header, 100)
+	go l.deliverBlocks(ctx, blocks)
+
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("🛑 区块处理goroutine收到停止信号")
+			logger.Debug("区块处理goroutine收到停止信号")
 			return
 		case header := <-headChan:
 			if header == nil {
 				continue
 			}
 
+			// 通知新区块回调
+			l.mu.RLock()
+			handler := l.headHandler
+			l.mu.RUnlock()
+			if handler != nil {
+				handler(header)
+			}
+
+			// 拉取完整区块并通知回调
+			l.mu.RLock()
+			blockHandler := l.blockHandler
+			l.mu.RUnlock()
+			if blockHandler != nil {
+				select {
+				case blocks <- header:
+				default:
+					l.mu.Lock()
+					l.blockDropped++
+					l.mu.Unlock()
+					logger.Warn("区块拉取队列已满，跳过区块", "block", header.Number.String())
+				}
+			}
+
 			// 当新区块到达时，获取当前pending transactions
 			go l.fetchPendingTransactions(ctx, header.Number, txChan)
 		}
 	}
 }
 
+// callContext 为单次RPC请求创建带超时的上下文，避免请求挂起占住工作goroutine
+func (l *Listener) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	l.mu.RLock()
+	timeout := l.fetchTimeout
+	l.mu.RUnlock()
+
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// dialEndpoint 以单次RPC请求超时连接节点，避免握手挂起的节点卡住重连或切换
+func (l *Listener) dialEndpoint(ctx context.Context, wssURL string) (*ethclient.Client, *rpc.Client, error) {
+	dialCtx, cancel := l.callContext(ctx)
+	defer cancel()
+	return dial(dialCtx, wssURL)
+}
+
+// countTimeout 请求因单次超时（而非上层停止）失败时计数
+func (l *Listener) countTimeout(ctx context.Context, err error) {
+	if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+		return
+	}
+	l.mu.Lock()
+	l.fetchTimeouts++
+	l.mu.Unlock()
+}
+
+// deliverBlocks 按新区块到达顺序依次拉取完整区块并回调
+func (l *Listener) deliverBlocks(ctx context.Context, headers <-chan *ethtypes.Header) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case header := <-headers:
+			l.mu.RLock()
+			handler, wanted := l.blockHandler, l.blockFilter
+			l.mu.RUnlock()
+			if handler == nil {
+				continue
+			}
+			// 拉取时再判断（而不是入队时），拉取前到达的pending交易也计入
+			if wanted != nil && !wanted() {
+				l.mu.Lock()
+				l.blockSkipped++
+				l.mu.Unlock()
+				continue
+			}
+			l.fetchBlock(ctx, header, handler)
+		}
+	}
+}
+
+// fetchBlock 按区块头哈希拉取完整区块（按哈希而不是区块号，期间发生重组也拿到的是该区块头对应的区块）
+func (l *Listener) fetchBlock(ctx context.Context, header *ethtypes.Header, handler func(block *ethtypes.Block)) {
+	callCtx, cancel := l.callContext(ctx)
+	defer cancel()
+
+	block, err := l.getClient().BlockByHash(callCtx, header.Hash())
+	if err != nil {
+		l.countTimeout(ctx, err)
+		logger.Warn("获取区块失败", "block", header.Number.String(), "error", err)
+		return
+	}
+	handler(block)
+}
+
 // fetchPendingTransactions 获取pending transactions
 func (l *Listener) fetchPendingTransactions(ctx context.Context, blockNumber *big.Int, txChan chan<- *types.Transaction) {
 	// 检查是否被主动停止
 	select {
 	case <-ctx.Done():
-		log.Println("🛑 fetchPendingTransactions收到停止信号")
+		logger.Debug("fetchPendingTransactions收到停止信号")
 		return
 	default:
 	}
-	
-	log.Printf("📦 新区块到达: %s", blockNumber.String())
+
+	logger.Debug("新区块到达", "block", blockNumber.String())
 	// 现在有了SubscribePendingTransactions，此函数主要用于区块到达时的处理
 }
 
@@ -231,24 +493,33 @@ func (l *Listener) fetchAndProcessTransaction(ctx context.Context, txHash common
 	// 检查是否被主动停止
 	select {
 	case <-ctx.Done():
-		log.Println("🛑 fetchAndProcessTransaction收到停止信号")
+		logger.Debug("fetchAndProcessTransaction收到停止信号", "tx_hash", txHash.Hex())
 		return
 	default:
 	}
 
+	// 同一哈希被多个节点重复广播时只查询一次
+	seen := l.seenCache()
+	if seen != nil && seen.check(txHash) {
+		return
+	}
+
 	// 重试机制
 	for i := 0; i < 3; i++ {
 		select {
 		case <-ctx.Done():
-			log.Println("🛑 fetchAndProcessTransaction重试过程中收到停止信号")
+			logger.Debug("fetchAndProcessTransaction重试过程中收到停止信号", "tx_hash", txHash.Hex())
 			return
 		default:
-			tx, isPending, err := l.client.TransactionByHash(ctx, txHash)
+			callCtx, cancel := l.callContext(ctx)
+			tx, isPending, err := l.getClient().TransactionByHash(callCtx, txHash)
+			cancel()
 			if err != nil {
+				l.countTimeout(ctx, err)
 				// 交易可能已被丢弃，等待后重试
 				select {
 				case <-ctx.Done():
-					log.Println("🛑 fetchAndProcessTransaction等待重试时收到停止信号")
+					logger.Debug("fetchAndProcessTransaction等待重试时收到停止信号", "tx_hash", txHash.Hex())
 					return
 				case <-time.After(time.Duration(i+1) * 100 * time.Millisecond):
 				}
@@ -260,71 +531,155 @@ func (l *Listener) fetchAndProcessTransaction(ctx context.Context, txHash common
 				return
 			}
 
-			// 创建交易对象
-			transaction := &types.Transaction{
-				Hash:      tx.Hash(),
-				RawTx:     tx,
-				To:        tx.To(),
-				Value:     tx.Value(),
-				GasPrice:  tx.GasPrice(),
-				GasLimit:  tx.Gas(),
-				Data:      tx.Data(),
-				Nonce:     tx.Nonce(),
-				ChainID:   tx.ChainId(),
-				Timestamp: time.Now().Unix(),
-			}
+			l.processTransaction(ctx, tx, txChan)
+			return
+		}
+	}
 
-			// 尝试获取发送者地址
-			signer := ethtypes.NewLondonSigner(tx.ChainId())
-			if from, err := signer.Sender(tx); err == nil {
-				transaction.From = from
-			}
+	logger.Warn("无法获取交易，重试3次失败", "tx_hash", txHash.Hex())
+	l.markGap()
+	if seen != nil {
+		seen.forget(txHash)
+	}
+}
 
-			// 发送到处理通道（非阻塞发送，避免缓冲区满时阻塞）
-				select {
-				case txChan <- transaction:
-					// 更新交易计数
-					l.mu.Lock()
-					l.txCount++
-					l.mu.Unlock()
+// processTransaction 包装交易并发送到处理通道
+func (l *Listener) processTransaction(ctx context.Context, tx *ethtypes.Transaction, txChan chan<- *types.Transaction) {
+	txHash := tx.Hash()
 
-					// 打印处理成功的日志
-					toAddress := "合约创建"
-					if transaction.To != nil {
-						toAddress = transaction.To.Hex()[:10] + "..."
-					}
-					log.Printf("[PENDING] 处理成功: %s (From: %s, To: %s, Value: %s ETH)",
-						txHash.Hex()[:10]+"...",
-						transaction.From.Hex()[:10]+"...",
-						toAddress,
-						transaction.Value.String())
-
-					// 统计信息（每100笔交易打印一次）
-					if l.txCount%100 == 0 {
-						l.logStats()
-					}
-					return
-				case <-ctx.Done():
-					log.Println("🛑 fetchAndProcessTransaction发送交易时收到停止信号")
-					return
-				default:
-					log.Printf("⚠️ 交易通道已满，丢弃交易: %s", txHash.Hex()[:10]+"...")
-					return
-				}
+	// 创建交易对象
+	transaction := WrapTransaction(tx)
+
+	// 预过滤：明显无关的交易不进入解码通道
+	l.mu.RLock()
+	preFilter, observer := l.preFilter, l.txObserver
+	l.mu.RUnlock()
+
+	// 观察整个内存池（如小费分布），不受预过滤影响
+	if observer != nil {
+		observer(transaction)
+	}
+
+	if preFilter != nil && !preFilter(transaction) {
+		l.mu.Lock()
+		l.preFiltered++
+		l.mu.Unlock()
+		return
+	}
+
+	// 发送到处理通道（非阻塞发送，避免缓冲区满时阻塞）
+	select {
+	case txChan <- transaction:
+		// 更新交易计数
+		l.mu.Lock()
+		l.txCount++
+		txCount := l.txCount
+		l.mu.Unlock()
+
+		// 打印处理成功的日志
+		toAddress := "合约创建"
+		if transaction.To != nil {
+			toAddress = transaction.To.Hex()
+		}
+		logger.Debug("pending交易处理成功",
+			"tx_hash", txHash.Hex(),
+			"from", transaction.From.Hex(),
+			"to", toAddress,
+			"value", types.FromBaseUnits(transaction.Value, 18),
+			"asset", types.BaseAssetSymbol(transaction.ChainID))
+
+		// 统计信息（每100笔交易打印一次）
+		if txCount%100 == 0 {
+			l.logStats()
 		}
+	case <-ctx.Done():
+		logger.Debug("processTransaction发送交易时收到停止信号", "tx_hash", txHash.Hex())
+	default:
+		logger.Warn("交易通道已满，丢弃交易", "tx_hash", txHash.Hex())
+		l.markGap()
 	}
+}
 
-	log.Printf("❌ 无法获取交易: %s (重试3次失败)", txHash.Hex()[:10]+"...")
+// WrapTransaction 将链上交易转换为管道使用的交易包装类型
+func WrapTransaction(tx *ethtypes.Transaction) *types.Transaction {
+	transaction := &types.Transaction{
+		Hash:      tx.Hash(),
+		RawTx:     tx,
+		To:        tx.To(),
+		Value:     tx.Value(),
+		GasPrice:  tx.GasPrice(),
+		GasLimit:  tx.Gas(),
+		Data:      tx.Data(),
+		Nonce:     tx.Nonce(),
+		ChainID:   tx.ChainId(),
+		Timestamp: time.Now().Unix(),
+		Type:      tx.Type(),
+	}
+
+	// blob交易的gas单独计价，记录下来避免按普通gas误估
+	if tx.Type() == ethtypes.BlobTxType {
+		transaction.BlobGas = tx.BlobGas()
+		transaction.BlobGasFeeCap = tx.BlobGasFeeCap()
+	}
+
+	// 尝试获取发送者地址（Cancun签名器兼容blob交易）
+	signer := ethtypes.NewCancunSigner(tx.ChainId())
+	if from, err := signer.Sender(tx); err == nil {
+		transaction.From = from
+	}
+
+	return transaction
 }
 
-// reconnect 重新连接（改进版：无限重连 + 指数退避）
+// reconnectShared 单飞重连：新区块和pending订阅可能同时出错，
+// 同一时间只执行一次重连，其他订阅等待并复用其结果；
+// gen 是出错订阅所在连接的代次，连接已被其他订阅的重连替换时不再重复重连
+func (l *Listener) reconnectShared(ctx context.Context, txChan chan<- *types.Transaction, gen uint64) error {
+	_, err, shared := l.reconnectGroup.Do("reconnect", func() (interface{}, error) {
+		if l.generation() != gen {
+			return nil, ctx.Err()
+		}
+		l.reconnect(ctx, txChan)
+		return nil, ctx.Err()
+	})
+	if shared {
+		logger.Debug("复用进行中的重连结果")
+	}
+	return err
+}
+
+// getClient 获取当前的ethclient（重连时会被替换）
+func (l *Listener) getClient() *ethclient.Client {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.client
+}
+
+// generation 获取当前连接的代次
+func (l *Listener) generation() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.connGen
+}
+
+// getRPCClient 获取当前的rpc客户端（重连时会被替换）
+func (l *Listener) getRPCClient() *rpc.Client {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.rpcClient
+}
+
+// reconnect 重新连接（改进版：无限重连 + 指数退避）：每次尝试轮换到下一个候选节点，
+// 所有节点都失败一轮后才退避等待
 func (l *Listener) reconnect(ctx context.Context, txChan chan<- *types.Transaction) {
-	log.Println("🔄 检测到连接断开，启动自动重连...")
+	logger.Warn("检测到连接断开，启动自动重连")
+	l.markGap()
 
 	// 指数退避配置
 	backoff := time.Second
 	maxBackoff := 30 * time.Second
 	retryCount := 0
+	endpoints := l.endpointCount()
 
 	for {
 		retryCount++
@@ -332,16 +687,20 @@ func (l *Listener) reconnect(ctx context.Context, txChan chan<- *types.Transacti
 		// 检查是否被主动停止
 		select {
 		case <-ctx.Done():
-			log.Println("🛑 重连过程被主动停止")
+			logger.Info("重连过程被主动停止")
 			return
 		default:
 		}
 
-		// 尝试重新连接
-		newListener, err := NewListener(l.wssURL)
+		// 尝试重新连接（只替换连接，计数器、启动时间和节点能力等状态保留在当前监听器上）
+		wssURL, index := l.nextEndpoint()
+		client, rpcClient, err := l.dialEndpoint(ctx, wssURL)
 		if err != nil {
-			log.Printf("❌ 重连失败 (尝试 %d), %v后重试: %v",
-				retryCount, backoff, err)
+			if retryCount%endpoints != 0 {
+				logger.Warn("重连节点失败，切换到下一个节点", "endpoint", index+1, "endpoints", endpoints, "attempt", retryCount, "error", err)
+				continue
+			}
+			logger.Error("重连失败", "attempt", retryCount, "backoff", backoff, "error", err)
 
 			// 指数退避等待
 			select {
@@ -358,23 +717,31 @@ func (l *Listener) reconnect(ctx context.Context, txChan chan<- *types.Transacti
 			continue
 		}
 
-		// 替换旧的监听器（简单替换，避免热切换的复杂度）
+		// 原地替换连接
 		l.mu.Lock()
 		if l.client != nil {
 			l.client.Close()
 		}
-		l.client = newListener.client
-		l.rpcClient = newListener.rpcClient
+		if l.rpcClient != nil {
+			l.rpcClient.Close()
+		}
+		l.client = client
+		l.rpcClient = rpcClient
+		if l.wssURL != wssURL {
+			l.failovers++
+		}
+		l.wssURL = wssURL
 		l.isRunning = true
+		l.reconnects++
+		l.connGen++
 		l.mu.Unlock()
 
-		log.Printf("✅ 第%d次重连成功，重置退避时间", retryCount)
+		logger.Info("重连成功，重置退避时间", "attempt", retryCount, "endpoint", index+1, "endpoints", endpoints)
 
 		// 连接成功后重置退避时间
 		backoff = time.Second
 
-		// 重新启动订阅（这里需要确保不会重复启动）
-		// 由于Start函数有运行状态检查，这里直接返回，由外层的错误处理重新调用Start
+		// 订阅由各自的goroutine（watchHeads、subscribePendingTransactions）在新连接上重新建立
 		return
 	}
 }
@@ -387,8 +754,7 @@ func (l *Listener) logStats() {
 	duration := time.Since(l.startTime)
 	tps := float64(l.txCount) / duration.Seconds()
 
-	log.Printf("📊 统计信息 - 总交易数: %d, 运行时间: %v, TPS: %.2f",
-		l.txCount, duration.Round(time.Second), tps)
+	logger.Info("监听器统计", "txs", l.txCount, "uptime", duration.Round(time.Second), "tps", tps)
 }
 
 // GetStats 获取统计信息
@@ -399,14 +765,62 @@ func (l *Listener) GetStats() map[string]interface{} {
 	duration := time.Since(l.startTime)
 	tps := float64(l.txCount) / duration.Seconds()
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"is_running": l.isRunning,
 		"tx_count":   l.txCount,
 		"start_time": l.startTime,
 		"duration":   duration,
 		"tps":        tps,
-		"wss_url":    l.wssURL,
+		"wss_url":    logging.RedactURL(l.wssURL),
+
+		"capabilities": l.capabilities,
+		"reconnects":   l.reconnects,
+		"pre_filtered": l.preFiltered,
+		"resubscribes": l.resubscribes,
+
+		"endpoint_index": l.endpointIdx,
+		"endpoints":      max(len(l.endpoints), 1),
+		"failovers":      l.failovers,
+
+		"fetch_timeouts": l.fetchTimeouts,
+		"block_dropped":  l.blockDropped,
+		"block_skipped":  l.blockSkipped,
+
+		"backfill_method": l.backfillMethod,
+		"backfilled":      l.backfilled,
+	}
+	if l.fetchSlots != nil {
+		stats["fetch_in_flight"] = len(l.fetchSlots)
+		stats["fetch_limit"] = cap(l.fetchSlots)
+		stats["fetch_dropped"] = l.fetchDropped
+	}
+	if l.seen != nil {
+		hits, misses, size := l.seen.stats()
+		stats["seen_hits"] = hits
+		stats["seen_misses"] = misses
+		stats["seen_size"] = size
 	}
+	if l.backlog != nil {
+		pending, dropped := l.backlog.stats()
+		stats["backlog_pending"] = pending
+		stats["backlog_dropped"] = dropped
+	}
+	return stats
+}
+
+// goSend 启动会向交易通道发送的goroutine并跟踪其退出
+func (l *Listener) goSend(fn func()) {
+	l.senders.Add(1)
+	go func() {
+		defer l.senders.Done()
+		fn()
+	}()
+}
+
+// Wait 等待所有会向交易通道发送的goroutine退出（需先取消Start的上下文），
+// 返回后可以安全关闭交易通道
+func (l *Listener) Wait() {
+	l.senders.Wait()
 }
 
 // Stop 停止监听器
@@ -422,7 +836,97 @@ func (l *Listener) Stop() {
 		if l.rpcClient != nil {
 			l.rpcClient.Close()
 		}
-		log.Println("🛑 监听器已停止")
+		logger.Info("监听器已停止")
+	}
+}
+
+// SetHeadHandler 设置新区块回调（需在Start之前调用）
+func (l *Listener) SetHeadHandler(handler func(header *ethtypes.Header)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.headHandler = handler
+}
+
+// SetFetchConcurrency 设置按哈希查询交易的最大并发数（limit <= 0 表示不限制，需在Start之前调用）
+func (l *Listener) SetFetchConcurrency(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.fetchSlots = nil
+	if limit > 0 {
+		l.fetchSlots = make(chan struct{}, limit)
+	}
+}
+
+// acquireFetchSlot 占用一个查询名额，名额已满时计数并返回 false
+func (l *Listener) acquireFetchSlot() (func(), bool) {
+	l.mu.RLock()
+	slots := l.fetchSlots
+	l.mu.RUnlock()
+
+	if slots == nil {
+		return func() {}, true
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		l.mu.Lock()
+		l.fetchDropped++
+		l.mu.Unlock()
+		return nil, false
+	}
+}
+
+// SetFetchTimeout 设置单次RPC请求超时
+func (l *Listener) SetFetchTimeout(timeout time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fetchTimeout = timeout
+}
+
+// SetPreFilter 设置预过滤函数，返回false的交易不会发送到解码通道
+func (l *Listener) SetPreFilter(filter func(tx *types.Transaction) bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.preFilter = filter
+}
+
+// SetTxObserver 设置pending交易观察回调：每笔交易在预过滤之前回调一次（需轻量，在监听器的goroutine中同步执行）
+func (l *Listener) SetTxObserver(observer func(tx *types.Transaction)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.txObserver = observer
+}
+
+// SetBlockHandler 设置完整区块回调
+func (l *Listener) SetBlockHandler(handler func(block *ethtypes.Block)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.blockHandler = handler
+}
+
+// SetBlockFilter 设置完整区块的拉取条件：返回false时跳过该区块（区块体较大，没有待检查的交易时不拉取）
+func (l *Listener) SetBlockFilter(wanted func() bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.blockFilter = wanted
+}
+
+// SetGapHandler 设置可能漏掉pending交易时的回调（交易被丢弃、查询失败或连接断开）
+func (l *Listener) SetGapHandler(handler func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gapHandler = handler
+}
+
+// markGap 通知监听出现了空缺
+func (l *Listener) markGap() {
+	l.mu.RLock()
+	handler := l.gapHandler
+	l.mu.RUnlock()
+	if handler != nil {
+		handler()
 	}
 }
 
@@ -431,4 +935,4 @@ func (l *Listener) IsRunning() bool {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 	return l.isRunning
-}
\ No newline at end of file
+}
@@ -0,0 +1,31 @@
+package listener
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestTxObserverSeesPreFilteredTransactions(t *testing.T) {
+	l := &Listener{}
+	var observed []*types.Transaction
+	l.SetTxObserver(func(tx *types.Transaction) { observed = append(observed, tx) })
+	l.SetPreFilter(func(tx *types.Transaction) bool { return tx.Value.Sign() > 0 })
+
+	txChan := make(chan *types.Transaction, 2)
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx := ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(1e9), Value: big.NewInt(int64(nonce))})
+		l.processTransaction(context.Background(), tx, txChan)
+	}
+
+	if len(observed) != 2 {
+		t.Errorf("observed %d transactions, want both, including the pre-filtered one", len(observed))
+	}
+	if len(txChan) != 1 || l.GetStats()["pre_filtered"] != int64(1) {
+		t.Errorf("sent %d transactions, stats %v; want 1 sent and 1 pre-filtered", len(txChan), l.GetStats())
+	}
+}
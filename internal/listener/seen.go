@@ -0,0 +1,104 @@
+package listener
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// seenEntry 已处理哈希及首次出现时间
+type seenEntry struct {
+	hash common.Hash
+	seen time.Time
+}
+
+// seenHashes 最近处理过的pending交易哈希（LRU + 时间窗口），
+// 同一交易被多个对等节点重复广播时只查询一次
+type seenHashes struct {
+	mu     sync.Mutex
+	size   int
+	ttl    time.Duration
+	order  *list.List // 最近使用的在后
+	index  map[common.Hash]*list.Element
+	hits   int64
+	misses int64
+}
+
+// newSeenHashes 创建容量为 size、有效期为 ttl 的哈希集合（ttl <= 0 表示只按容量淘汰）
+func newSeenHashes(size int, ttl time.Duration) *seenHashes {
+	return &seenHashes{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		index: make(map[common.Hash]*list.Element),
+	}
+}
+
+// check 哈希在有效期内出现过时返回 true（命中），否则记录并返回 false
+func (c *seenHashes) check(hash common.Hash) bool {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.index[hash]; exists {
+		entry := element.Value.(*seenEntry)
+		if c.ttl <= 0 || now.Sub(entry.seen) <= c.ttl {
+			c.order.MoveToBack(element)
+			c.hits++
+			return true
+		}
+		// 已过期：按新出现处理
+		entry.seen = now
+		c.order.MoveToBack(element)
+		c.misses++
+		return false
+	}
+
+	c.index[hash] = c.order.PushBack(&seenEntry{hash: hash, seen: now})
+	for c.order.Len() > c.size {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.index, oldest.Value.(*seenEntry).hash)
+	}
+	c.misses++
+	return false
+}
+
+// forget 移除哈希（查询失败时调用，之后的重复广播可以再次尝试）
+func (c *seenHashes) forget(hash common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.index[hash]; exists {
+		c.order.Remove(element)
+		delete(c.index, hash)
+	}
+}
+
+// stats 命中/未命中次数及当前记录数
+func (c *seenHashes) stats() (int64, int64, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.order.Len()
+}
+
+// SetSeenCache 设置pending哈希去重缓存（size <= 0 表示不去重，需在Start之前调用）
+func (l *Listener) SetSeenCache(size int, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seen = nil
+	if size > 0 {
+		l.seen = newSeenHashes(size, ttl)
+	}
+}
+
+// seenCache 当前的哈希去重缓存（未启用时为nil）
+func (l *Listener) seenCache() *seenHashes {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.seen
+}
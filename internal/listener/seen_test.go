@@ -0,0 +1,149 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// lookupNode 按哈希返回pending交易并统计查询次数的测试节点
+type lookupNode struct {
+	tx      *ethtypes.Transaction
+	lookups atomic.Int64
+}
+
+func (n *lookupNode) GetTransactionByHash(hash common.Hash) *ethtypes.Transaction {
+	n.lookups.Add(1)
+	if hash != n.tx.Hash() {
+		return nil
+	}
+	return n.tx
+}
+
+// 同一哈希被多个对等节点重复广播（包括同时到达）时只查询一次、只送入管道一次
+func TestDuplicateHashesFetchOnce(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := ethtypes.LatestSignerForChainID(big.NewInt(1))
+	tx := ethtypes.MustSignNewTx(key, signer, &ethtypes.LegacyTx{GasPrice: big.NewInt(1e9), Gas: 21000, Value: big.NewInt(1)})
+
+	node := &lookupNode{tx: tx}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	rpcClient := rpc.DialInProc(server)
+	defer rpcClient.Close()
+
+	l := &Listener{client: ethclient.NewClient(rpcClient), rpcClient: rpcClient}
+	l.SetSeenCache(100, time.Minute)
+
+	message, err := json.Marshal(tx.Hash().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const announcements = 10
+	txChan := make(chan *types.Transaction, announcements)
+	var wg sync.WaitGroup
+	for i := 0; i < announcements; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.handlePendingMessage(context.Background(), message, txChan)
+		}()
+	}
+	wg.Wait()
+	l.Wait()
+	// 查询完成后晚到的重复广播同样命中
+	l.handlePendingMessage(context.Background(), message, txChan)
+	l.Wait()
+
+	if lookups := node.lookups.Load(); lookups != 1 {
+		t.Errorf("%d announcements of one hash fetched it %d times, want 1", announcements+1, lookups)
+	}
+	if len(txChan) != 1 {
+		t.Errorf("%d transactions sent to the pipeline, want 1", len(txChan))
+	}
+	hits, misses, _ := l.seen.stats()
+	if hits != announcements || misses != 1 {
+		t.Errorf("seen cache hits = %d, misses = %d; want %d and 1", hits, misses, announcements)
+	}
+}
+
+// 重连只替换连接：断线前已处理的哈希在新连接上再次广播时不会重复查询或送入管道
+func TestSeenHashesSurviveReconnect(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := ethtypes.LatestSignerForChainID(big.NewInt(1))
+	tx := ethtypes.MustSignNewTx(key, signer, &ethtypes.LegacyTx{GasPrice: big.NewInt(1e9), Gas: 21000, Value: big.NewInt(1)})
+
+	node := &lookupNode{tx: tx}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer func() {
+		server.Stop()
+		httpServer.Close()
+	}()
+
+	l, err := NewListener("ws://" + strings.TrimPrefix(httpServer.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+	l.SetSeenCache(100, time.Minute)
+
+	message, err := json.Marshal(tx.Hash().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txChan := make(chan *types.Transaction, 2)
+	l.handlePendingMessage(context.Background(), message, txChan)
+	l.Wait()
+
+	gen := l.generation()
+	if err := l.reconnectShared(context.Background(), nil, gen); err != nil {
+		t.Fatalf("reconnectShared() = %v", err)
+	}
+	if l.generation() != gen+1 {
+		t.Fatalf("generation = %d after reconnect, want %d", l.generation(), gen+1)
+	}
+
+	l.handlePendingMessage(context.Background(), message, txChan)
+	l.Wait()
+
+	if lookups := node.lookups.Load(); lookups != 1 {
+		t.Errorf("hash fetched %d times across a reconnect, want 1", lookups)
+	}
+	if len(txChan) != 1 {
+		t.Errorf("%d transactions sent to the pipeline, want 1", len(txChan))
+	}
+	stats := l.GetStats()
+	if stats["seen_hits"] != int64(1) || stats["seen_misses"] != int64(1) {
+		t.Errorf("seen_hits = %v, seen_misses = %v; want 1 and 1", stats["seen_hits"], stats["seen_misses"])
+	}
+	if stats["reconnects"] != int64(1) || stats["tx_count"] != int64(1) {
+		t.Errorf("reconnects = %v, tx_count = %v; want counters kept across the reconnect", stats["reconnects"], stats["tx_count"])
+	}
+}
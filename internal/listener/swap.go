@@ -0,0 +1,73 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"mempool-sniper/internal/logging"
+)
+
+// SwapEndpoint 运行时切换监听节点：新节点链ID与当前节点一致才切换。
+// 旧连接关闭后订阅会按原有重连流程在新节点上重新建立（expectedChainID 为nil时与当前节点比较）
+func (l *Listener) SwapEndpoint(ctx context.Context, wssURL string, expectedChainID *big.Int) error {
+	if expectedChainID == nil {
+		chainID, err := l.getClient().ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("获取当前节点链ID失败: %w", err)
+		}
+		expectedChainID = chainID
+	}
+
+	client, rpcClient, err := l.dialEndpoint(ctx, wssURL)
+	if err != nil {
+		return err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		rpcClient.Close()
+		return fmt.Errorf("获取新节点链ID失败: %w", err)
+	}
+	if chainID.Cmp(expectedChainID) != 0 {
+		client.Close()
+		rpcClient.Close()
+		return fmt.Errorf("新节点链ID %s 与预期 %s 不一致，拒绝切换", chainID, expectedChainID)
+	}
+
+	l.mu.Lock()
+	oldClient, oldRPC := l.client, l.rpcClient
+	l.client = client
+	l.rpcClient = rpcClient
+	l.wssURL = wssURL
+	l.endpoints, l.endpointIdx = swapCandidate(l.endpoints, l.endpointIdx, wssURL)
+	l.connGen++
+	l.mu.Unlock()
+
+	// 关闭旧连接：旧订阅随之断开，代次已更新，订阅直接在新连接上重新建立而不会触发重连
+	if oldClient != nil {
+		oldClient.Close()
+	}
+	if oldRPC != nil {
+		oldRPC.Close()
+	}
+
+	logger.Info("监听节点已切换", "url", logging.RedactURL(wssURL), "chain_id", chainID)
+	return nil
+}
+
+// swapCandidate 切换后的候选节点列表：新节点已在列表中时指向它，否则替换当前位置的节点，
+// 之后的重连不会轮换回被替换的旧节点（返回新切片，不修改调用方传入的配置）
+func swapCandidate(endpoints []string, index int, wssURL string) ([]string, int) {
+	for i, endpoint := range endpoints {
+		if endpoint == wssURL {
+			return endpoints, i
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, 0
+	}
+	replaced := append([]string(nil), endpoints...)
+	replaced[index] = wssURL
+	return replaced, index
+}
@@ -0,0 +1,33 @@
+package listener
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSwapCandidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []string
+		index     int
+		wssURL    string
+		want      []string
+		wantIndex int
+	}{
+		{name: "single endpoint", endpoints: nil, index: 0, wssURL: "wss://b", want: nil, wantIndex: 0},
+		{name: "already a candidate", endpoints: []string{"wss://a", "wss://b"}, index: 0, wssURL: "wss://b", want: []string{"wss://a", "wss://b"}, wantIndex: 1},
+		{name: "replaces active candidate", endpoints: []string{"wss://a", "wss://b"}, index: 1, wssURL: "wss://c", want: []string{"wss://a", "wss://c"}, wantIndex: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]string(nil), tt.endpoints...)
+			got, index := swapCandidate(tt.endpoints, tt.index, tt.wssURL)
+			if !reflect.DeepEqual(got, tt.want) || index != tt.wantIndex {
+				t.Errorf("swapCandidate() = %v, %d, want %v, %d", got, index, tt.want, tt.wantIndex)
+			}
+			if !reflect.DeepEqual(tt.endpoints, original) {
+				t.Errorf("swapCandidate() modified the configured endpoints: %v", tt.endpoints)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// 日志输出格式
+const (
+	FormatJSON = "json" // 每行一个JSON对象，供日志采集管道解析
+	FormatText = "text" // key=value 文本，便于本地开发时阅读
+)
+
+// ParseLevel 解析日志级别: debug, info, warn, error（不区分大小写）
+func ParseLevel(level string) (slog.Level, error) {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(strings.ToLower(level))); err != nil {
+		return 0, fmt.Errorf("无效的日志级别 %q（可选 debug, info, warn, error）", level)
+	}
+	return parsed, nil
+}
+
+// NewHandler 按级别和格式创建输出到 w 的日志处理器
+func NewHandler(w io.Writer, level slog.Level, format string) (slog.Handler, error) {
+	options := &slog.HandlerOptions{Level: level}
+	switch format {
+	case FormatJSON:
+		return slog.NewJSONHandler(w, options), nil
+	case FormatText:
+		return slog.NewTextHandler(w, options), nil
+	default:
+		return nil, fmt.Errorf("无效的日志格式 %q（可选 json, text）", format)
+	}
+}
+
+// Setup 安装默认日志记录器：输出到标准错误，filePath 不为空时同时追加写入该文件。
+// 标准库 log 包的输出也经由该记录器以 info 级别输出；返回的 Closer 用于关闭日志文件
+func Setup(level, format, filePath string) (io.Closer, error) {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.Writer = os.Stderr
+	var closer io.Closer = nopCloser{}
+	if filePath != "" {
+		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = io.MultiWriter(os.Stderr, file)
+		closer = file
+	}
+
+	handler, err := NewHandler(w, parsed, format)
+	if err != nil {
+		closer.Close()
+		return nil, err
+	}
+	slog.SetDefault(slog.New(handler))
+	return closer, nil
+}
+
+// RedactURL 节点地址只保留主机名和端口：路径、查询参数和用户信息中常带有API密钥，
+// 不能出现在日志和状态接口中（无法解析时返回空字符串）
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// nopCloser 没有日志文件时返回的空 Closer
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// Component 带 component 字段的日志记录器。每次记录时使用当前的默认处理器，
+// 因此可以在包初始化时创建，Setup 之后的配置同样生效
+func Component(name string) *slog.Logger {
+	return slog.New(&deferredHandler{attrs: []slog.Attr{slog.String("component", name)}})
+}
+
+// deferredHandler 记录时才取默认处理器，并附加创建时的字段
+type deferredHandler struct {
+	attrs []slog.Attr
+}
+
+func (h *deferredHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, level)
+}
+
+func (h *deferredHandler) Handle(ctx context.Context, record slog.Record) error {
+	return slog.Default().Handler().WithAttrs(h.attrs).Handle(ctx, record)
+}
+
+func (h *deferredHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	combined := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	combined = append(combined, h.attrs...)
+	return &deferredHandler{attrs: append(combined, attrs...)}
+}
+
+// WithGroup 分组在记录时无法延迟应用，直接基于当前默认处理器创建
+func (h *deferredHandler) WithGroup(name string) slog.Handler {
+	return slog.Default().Handler().WithAttrs(h.attrs).WithGroup(name)
+}
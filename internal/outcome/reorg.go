@@ -0,0 +1,119 @@
+package outcome
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// HeaderSource 按哈希获取区块头（ethclient.Client 满足该接口）
+type HeaderSource interface {
+	HeaderByHash(ctx context.Context, hash common.Hash) (*ethtypes.Header, error)
+}
+
+// ReorgDetector 记录最近N个区块的规范哈希，发现父哈希或同高度哈希不一致时判定重组。
+// 区块需按链上顺序依次传入（监听器按订阅顺序串行拉取）
+type ReorgDetector struct {
+	mu        sync.Mutex
+	depth     uint64
+	canonical map[uint64]common.Hash
+	headers   HeaderSource
+	reorgs    int64
+}
+
+// NewReorgDetector 创建重组检测器，depth 为回溯的最大区块数
+func NewReorgDetector(headers HeaderSource, depth uint64) *ReorgDetector {
+	return &ReorgDetector{
+		depth:     depth,
+		canonical: make(map[uint64]common.Hash),
+		headers:   headers,
+	}
+}
+
+// Observe 记录新区块，发生重组时返回被替换的最低区块号
+func (r *ReorgDetector) Observe(ctx context.Context, header *ethtypes.Header) (uint64, bool) {
+	number := header.Number.Uint64()
+
+	r.mu.Lock()
+	reorgFrom, reorged := uint64(0), false
+	if old, exists := r.canonical[number]; exists && old != header.Hash() {
+		reorgFrom, reorged = number, true
+	}
+	var known map[uint64]common.Hash
+	if number > 0 {
+		if old, exists := r.canonical[number-1]; exists && old != header.ParentHash {
+			known = make(map[uint64]common.Hash, len(r.canonical))
+			for n, hash := range r.canonical {
+				known[n] = hash
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	// 父哈希不一致：沿新链的父哈希回溯（RPC调用不持有锁）
+	var replaced map[uint64]common.Hash
+	if known != nil {
+		var fork uint64
+		fork, replaced = r.findFork(ctx, number-1, header.ParentHash, known)
+		if !reorged || fork < reorgFrom {
+			reorgFrom = fork
+		}
+		reorged = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n, hash := range replaced {
+		r.canonical[n] = hash
+	}
+	r.canonical[number] = header.Hash()
+	for n := range r.canonical {
+		// 新链更短时，更高的区块已不在规范链上；过旧的记录不再需要
+		if (reorged && n > number) || n+r.depth < number {
+			delete(r.canonical, n)
+		}
+	}
+
+	if reorged {
+		r.reorgs++
+		log.Printf("🔀 检测到区块重组: 区块 %d 起被替换 (新区块 %d %s)", reorgFrom, number, header.Hash().Hex())
+	}
+	return reorgFrom, reorged
+}
+
+// findFork 从新链上区块号为 from、哈希为 hash 的区块沿父哈希回溯，直到与记录的规范哈希一致，
+// 返回第一个被替换的区块号及新链上被替换高度的哈希（最多回溯 depth 个区块）
+func (r *ReorgDetector) findFork(ctx context.Context, from uint64, hash common.Hash, known map[uint64]common.Hash) (uint64, map[uint64]common.Hash) {
+	fork := from
+	replaced := make(map[uint64]common.Hash)
+	for n := from; n+r.depth > from; n-- {
+		old, exists := known[n]
+		if !exists || old == hash {
+			break
+		}
+		replaced[n] = hash
+		fork = n
+		if n == 0 || r.headers == nil {
+			break
+		}
+
+		header, err := r.headers.HeaderByHash(ctx, hash)
+		if err != nil {
+			log.Printf("⚠️ 回溯区块 %d 失败: %v", n, err)
+			break
+		}
+		hash = header.ParentHash
+	}
+	return fork, replaced
+}
+
+// Reorgs 已检测到的重组次数
+func (r *ReorgDetector) Reorgs() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reorgs
+}
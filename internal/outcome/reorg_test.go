@@ -0,0 +1,113 @@
+package outcome
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeHeaders 按哈希提供区块头，probe 在每次查询时调用（用于检查回溯时是否持有锁）
+type fakeHeaders struct {
+	byHash map[common.Hash]*ethtypes.Header
+	probe  func()
+	calls  int
+}
+
+func (f *fakeHeaders) HeaderByHash(ctx context.Context, hash common.Hash) (*ethtypes.Header, error) {
+	f.calls++
+	if f.probe != nil {
+		f.probe()
+	}
+	header, exists := f.byHash[hash]
+	if !exists {
+		return nil, errors.New("not found")
+	}
+	return header, nil
+}
+
+// chainFrom 从 parent 之后构建 n 个区块头（fork 区分不同分叉上相同高度的区块）
+func (f *fakeHeaders) chainFrom(parent *ethtypes.Header, n int, fork byte) []*ethtypes.Header {
+	headers := make([]*ethtypes.Header, 0, n)
+	for i := 0; i < n; i++ {
+		header := &ethtypes.Header{
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			ParentHash: parent.Hash(),
+			Difficulty: new(big.Int),
+			Extra:      []byte{fork},
+		}
+		f.byHash[header.Hash()] = header
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+func TestReorgDetectorFindsDepthTwoFork(t *testing.T) {
+	source := &fakeHeaders{byHash: make(map[common.Hash]*ethtypes.Header)}
+	genesis := &ethtypes.Header{Number: big.NewInt(100), Difficulty: new(big.Int)}
+	canonical := source.chainFrom(genesis, 5, 'a') // 101..105
+	detector := NewReorgDetector(source, 8)
+	source.probe = func() { detector.Reorgs() }
+
+	for _, header := range canonical {
+		if from, reorged := detector.Observe(context.Background(), header); reorged {
+			t.Fatalf("block %d reported as reorg from %d", header.Number, from)
+		}
+	}
+
+	// 新分叉从 103 开始：104、105 被替换，新链的 106 先到达
+	fork := source.chainFrom(canonical[2], 3, 'b') // 104'..106'
+	type result struct {
+		from    uint64
+		reorged bool
+	}
+	done := make(chan result, 1)
+	go func() {
+		from, reorged := detector.Observe(context.Background(), fork[2])
+		done <- result{from, reorged}
+	}()
+
+	select {
+	case got := <-done:
+		if !got.reorged || got.from != 104 {
+			t.Fatalf("Observe(106') = (%d, %v), want (104, true)", got.from, got.reorged)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Observe() held the lock while walking back parent headers")
+	}
+	if source.calls != 2 {
+		t.Errorf("walked back with %d header fetches, want 2", source.calls)
+	}
+
+	// 回溯得到的新链哈希已记录：新链上的下一个区块不再判定为重组
+	next := source.chainFrom(fork[2], 1, 'b')[0]
+	if from, reorged := detector.Observe(context.Background(), next); reorged {
+		t.Errorf("block on the new chain reported as reorg from %d", from)
+	}
+	if detector.Reorgs() != 1 {
+		t.Errorf("Reorgs() = %d, want 1", detector.Reorgs())
+	}
+}
+
+func TestReorgDetectorSameHeightReplacement(t *testing.T) {
+	source := &fakeHeaders{byHash: make(map[common.Hash]*ethtypes.Header)}
+	genesis := &ethtypes.Header{Number: big.NewInt(100), Difficulty: new(big.Int)}
+	canonical := source.chainFrom(genesis, 3, 'a')
+	detector := NewReorgDetector(source, 8)
+	for _, header := range canonical {
+		detector.Observe(context.Background(), header)
+	}
+
+	sibling := source.chainFrom(canonical[1], 1, 'b')[0] // 103'
+	if from, reorged := detector.Observe(context.Background(), sibling); !reorged || from != 103 {
+		t.Errorf("Observe(103') = (%d, %v), want (103, true)", from, reorged)
+	}
+	if source.calls != 0 {
+		t.Errorf("same-height replacement fetched %d headers", source.calls)
+	}
+}
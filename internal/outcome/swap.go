@@ -0,0 +1,152 @@
+package outcome
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// v2PairABI Uniswap V2 交易对的 Swap 事件
+const v2PairABI = `[{"anonymous":false,"type":"event","name":"Swap","inputs":[
+	{"indexed":true,"name":"sender","type":"address"},
+	{"indexed":false,"name":"amount0In","type":"uint256"},
+	{"indexed":false,"name":"amount1In","type":"uint256"},
+	{"indexed":false,"name":"amount0Out","type":"uint256"},
+	{"indexed":false,"name":"amount1Out","type":"uint256"},
+	{"indexed":true,"name":"to","type":"address"}]}]`
+
+// v3PoolABI Uniswap V3 池子的 Swap 事件
+const v3PoolABI = `[{"anonymous":false,"type":"event","name":"Swap","inputs":[
+	{"indexed":true,"name":"sender","type":"address"},
+	{"indexed":true,"name":"recipient","type":"address"},
+	{"indexed":false,"name":"amount0","type":"int256"},
+	{"indexed":false,"name":"amount1","type":"int256"},
+	{"indexed":false,"name":"sqrtPriceX96","type":"uint160"},
+	{"indexed":false,"name":"liquidity","type":"uint128"},
+	{"indexed":false,"name":"tick","type":"int24"}]}]`
+
+var (
+	v2SwapEvent = mustParseEvent(v2PairABI)
+	v3SwapEvent = mustParseEvent(v3PoolABI)
+)
+
+// Swap事件签名
+var (
+	// SwapV2Topic Swap(address indexed sender, uint amount0In, uint amount1In, uint amount0Out, uint amount1Out, address indexed to)
+	SwapV2Topic = v2SwapEvent.ID
+	// SwapV3Topic Swap(address indexed sender, address indexed recipient, int256 amount0, int256 amount1, uint160 sqrtPriceX96, uint128 liquidity, int24 tick)
+	SwapV3Topic = v3SwapEvent.ID
+)
+
+func mustParseEvent(definition string) abi.Event {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid swap event ABI: %v", err))
+	}
+	return parsed.Events["Swap"]
+}
+
+// SwapEvent 解码后的Swap事件（统一为流入/流出池子的数量）
+type SwapEvent struct {
+	Pool       common.Address `json:"pool"`
+	Version    int            `json:"version"` // 2 或 3
+	Amount0In  *big.Int       `json:"amount0_in"`
+	Amount1In  *big.Int       `json:"amount1_in"`
+	Amount0Out *big.Int       `json:"amount0_out"`
+	Amount1Out *big.Int       `json:"amount1_out"`
+}
+
+// AmountIn 本次交换流入池子的数量
+func (e *SwapEvent) AmountIn() *big.Int {
+	if e.Amount0In.Sign() > 0 {
+		return e.Amount0In
+	}
+	return e.Amount1In
+}
+
+// AmountOut 本次交换流出池子的数量
+func (e *SwapEvent) AmountOut() *big.Int {
+	if e.Amount0Out.Sign() > 0 {
+		return e.Amount0Out
+	}
+	return e.Amount1Out
+}
+
+// SameDirection 两次交换是否在同一池子上以相同方向成交（流入同一侧代币）
+func (e *SwapEvent) SameDirection(other *SwapEvent) bool {
+	return e.Pool == other.Pool && (e.Amount0In.Sign() > 0) == (other.Amount0In.Sign() > 0)
+}
+
+// DecodeSwapLog 按ABI解码V2/V3 Swap事件，非Swap事件或数据无效时返回false
+func DecodeSwapLog(log *ethtypes.Log) (*SwapEvent, bool) {
+	if len(log.Topics) == 0 {
+		return nil, false
+	}
+
+	switch log.Topics[0] {
+	case SwapV2Topic:
+		values, err := v2SwapEvent.Inputs.Unpack(log.Data)
+		if err != nil {
+			return nil, false
+		}
+		return &SwapEvent{
+			Pool:       log.Address,
+			Version:    2,
+			Amount0In:  values[0].(*big.Int),
+			Amount1In:  values[1].(*big.Int),
+			Amount0Out: values[2].(*big.Int),
+			Amount1Out: values[3].(*big.Int),
+		}, true
+
+	case SwapV3Topic:
+		values, err := v3SwapEvent.Inputs.Unpack(log.Data)
+		if err != nil {
+			return nil, false
+		}
+		// V3以池子视角记录有符号数量：正数流入，负数流出
+		event := &SwapEvent{
+			Pool:       log.Address,
+			Version:    3,
+			Amount0In:  new(big.Int),
+			Amount1In:  new(big.Int),
+			Amount0Out: new(big.Int),
+			Amount1Out: new(big.Int),
+		}
+		splitSigned(values[0].(*big.Int), event.Amount0In, event.Amount0Out)
+		splitSigned(values[1].(*big.Int), event.Amount1In, event.Amount1Out)
+		return event, true
+	}
+
+	return nil, false
+}
+
+// DecodeSwapLogs 按顺序解码收据中的所有Swap事件
+func DecodeSwapLogs(logs []*ethtypes.Log) []*SwapEvent {
+	var events []*SwapEvent
+	for _, log := range logs {
+		if event, ok := DecodeSwapLog(log); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// RealizedAmounts 多跳交换的实际数量：第一跳流入、最后一跳流出
+func RealizedAmounts(events []*SwapEvent) (amountIn, amountOut *big.Int, ok bool) {
+	if len(events) == 0 {
+		return nil, nil, false
+	}
+	return events[0].AmountIn(), events[len(events)-1].AmountOut(), true
+}
+
+func splitSigned(value, in, out *big.Int) {
+	if value.Sign() >= 0 {
+		in.Set(value)
+	} else {
+		out.Neg(value)
+	}
+}
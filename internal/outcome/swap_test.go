@@ -0,0 +1,124 @@
+package outcome
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	pool   = common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc") // USDC/WETH V2
+	sender = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+)
+
+// words 按ABI编码为连续的32字节字（负数为int256补码）
+func words(values ...*big.Int) []byte {
+	var data []byte
+	for _, value := range values {
+		data = append(data, math.U256Bytes(new(big.Int).Set(value))...)
+	}
+	return data
+}
+
+func TestSwapTopicsMatchEventSignatures(t *testing.T) {
+	if want := crypto.Keccak256Hash([]byte("Swap(address,uint256,uint256,uint256,uint256,address)")); SwapV2Topic != want {
+		t.Errorf("SwapV2Topic = %s, want %s", SwapV2Topic.Hex(), want.Hex())
+	}
+	if want := crypto.Keccak256Hash([]byte("Swap(address,address,int256,int256,uint160,uint128,int24)")); SwapV3Topic != want {
+		t.Errorf("SwapV3Topic = %s, want %s", SwapV3Topic.Hex(), want.Hex())
+	}
+}
+
+func TestDecodeSwapLog(t *testing.T) {
+	topics := func(topic common.Hash) []common.Hash {
+		return []common.Hash{topic, common.BytesToHash(sender.Bytes()), common.BytesToHash(sender.Bytes())}
+	}
+	tests := []struct {
+		name    string
+		log     *ethtypes.Log
+		want    *SwapEvent
+		wantErr bool
+	}{
+		{
+			name: "v2 token1 in",
+			log: &ethtypes.Log{Address: pool, Topics: topics(SwapV2Topic),
+				Data: words(big.NewInt(0), big.NewInt(1e18), big.NewInt(3000e6), big.NewInt(0))},
+			want: &SwapEvent{Pool: pool, Version: 2, Amount0In: big.NewInt(0), Amount1In: big.NewInt(1e18),
+				Amount0Out: big.NewInt(3000e6), Amount1Out: big.NewInt(0)},
+		},
+		{
+			name: "v3 signed amounts",
+			log: &ethtypes.Log{Address: pool, Topics: topics(SwapV3Topic),
+				Data: words(big.NewInt(-3000e6), big.NewInt(1e18), big.NewInt(1), big.NewInt(1), big.NewInt(-200000))},
+			want: &SwapEvent{Pool: pool, Version: 3, Amount0In: big.NewInt(0), Amount1In: big.NewInt(1e18),
+				Amount0Out: big.NewInt(3000e6), Amount1Out: big.NewInt(0)},
+		},
+		{
+			name:    "truncated data",
+			log:     &ethtypes.Log{Address: pool, Topics: topics(SwapV2Topic), Data: words(big.NewInt(1), big.NewInt(2))},
+			wantErr: true,
+		},
+		{
+			name:    "other event",
+			log:     &ethtypes.Log{Address: pool, Topics: []common.Hash{crypto.Keccak256Hash([]byte("Sync(uint112,uint112)"))}, Data: words(big.NewInt(1), big.NewInt(2))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, ok := DecodeSwapLog(tt.log)
+			if ok == tt.wantErr {
+				t.Fatalf("DecodeSwapLog() ok = %v, want %v", ok, !tt.wantErr)
+			}
+			if !ok {
+				return
+			}
+			if event.Pool != tt.want.Pool || event.Version != tt.want.Version ||
+				event.Amount0In.Cmp(tt.want.Amount0In) != 0 || event.Amount1In.Cmp(tt.want.Amount1In) != 0 ||
+				event.Amount0Out.Cmp(tt.want.Amount0Out) != 0 || event.Amount1Out.Cmp(tt.want.Amount1Out) != 0 {
+				t.Errorf("DecodeSwapLog() = %+v, want %+v", event, tt.want)
+			}
+			if event.AmountIn().Cmp(big.NewInt(1e18)) != 0 || event.AmountOut().Cmp(big.NewInt(3000e6)) != 0 {
+				t.Errorf("AmountIn/AmountOut = %s/%s, want 1e18/3000e6", event.AmountIn(), event.AmountOut())
+			}
+		})
+	}
+}
+
+func TestMatchSandwich(t *testing.T) {
+	buy := func(in, out int64) *SwapEvent {
+		return &SwapEvent{Pool: pool, Amount0In: big.NewInt(0), Amount1In: big.NewInt(in), Amount0Out: big.NewInt(out), Amount1Out: big.NewInt(0)}
+	}
+	sell := func(in, out int64) *SwapEvent {
+		return &SwapEvent{Pool: pool, Amount0In: big.NewInt(in), Amount1In: big.NewInt(0), Amount0Out: big.NewInt(0), Amount1Out: big.NewInt(out)}
+	}
+	elsewhere := buy(1, 1)
+	elsewhere.Pool = sender
+
+	victim := buy(10, 100)
+	tests := []struct {
+		name          string
+		before, after []*SwapEvent
+		wantFront     *SwapEvent
+		wantBack      *SwapEvent
+	}{
+		{name: "sandwiched", before: []*SwapEvent{buy(5, 60)}, after: []*SwapEvent{sell(60, 6)}},
+		{name: "no front-run", before: nil, after: []*SwapEvent{sell(60, 6)}},
+		{name: "front-run on another pool", before: []*SwapEvent{elsewhere}, after: []*SwapEvent{sell(60, 6)}},
+		{name: "same direction after victim", before: []*SwapEvent{buy(5, 60)}, after: []*SwapEvent{buy(5, 40)}},
+	}
+	tests[0].wantFront, tests[0].wantBack = tests[0].before[0], tests[0].after[0]
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			front, back := matchSandwich(victim, tt.before, tt.after)
+			if front != tt.wantFront || back != tt.wantBack {
+				t.Errorf("matchSandwich() = %+v, %+v, want %+v, %+v", front, back, tt.wantFront, tt.wantBack)
+			}
+		})
+	}
+}
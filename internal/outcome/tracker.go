@@ -0,0 +1,306 @@
+package outcome
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sync"
+
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// watchExpiryBlocks 超过目标区块N个区块仍未打包视为未成交
+const watchExpiryBlocks = 50
+
+// watch 等待打包的受害者交易及我们的预测值
+type watch struct {
+	opportunityID string
+	targetBlock   uint64
+	amountIn      *big.Int                  // 受害者的输入数量
+	predicted     *types.SandwichPrediction // 夹子模拟的预测成交数量（为nil表示没有模拟第一跳）
+}
+
+// settled 已记录结果的受害者交易（在重组深度内保留，以便失效后重新计算）
+type settled struct {
+	watch       *watch
+	blockNumber uint64
+	blockHash   common.Hash
+}
+
+// Tracker 结果跟踪器：受害者交易打包后解码Swap事件，对比实际数量与预测值
+type Tracker struct {
+	client    *ethclient.Client
+	lifecycle *lifecycle.Recorder
+	reorgs    *ReorgDetector // 为nil表示不处理重组
+	depth     uint64
+
+	mu          sync.Mutex
+	watches     map[common.Hash]*watch
+	settled     map[common.Hash]*settled
+	included    int64
+	expired     int64
+	noSwapEvent int64
+	invalidated int64
+	noSandwich  int64 // 有预测但受害者未被夹的次数
+}
+
+// NewTracker 创建结果跟踪器，reorgDepth 为已记录结果可被重组推翻的区块数（0表示不处理重组）
+func NewTracker(client *ethclient.Client, recorder *lifecycle.Recorder, reorgDepth uint64) *Tracker {
+	t := &Tracker{
+		client:    client,
+		lifecycle: recorder,
+		depth:     reorgDepth,
+		watches:   make(map[common.Hash]*watch),
+		settled:   make(map[common.Hash]*settled),
+	}
+	if reorgDepth > 0 {
+		t.reorgs = NewReorgDetector(client, reorgDepth)
+	}
+	return t
+}
+
+// Watch 跟踪一个已决定执行的机会的受害者交易
+func (t *Tracker) Watch(analysis *types.ProfitAnalysis) {
+	if t == nil || analysis.Source == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.watches[analysis.TxHash] = &watch{
+		opportunityID: analysis.OpportunityID,
+		targetBlock:   analysis.TargetBlock,
+		amountIn:      analysis.Source.AmountIn,
+		predicted:     analysis.Predicted,
+	}
+}
+
+// Watching 是否有等待打包或仍在重组深度内的受害者交易（没有时不需要新区块）
+func (t *Tracker) Watching() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.watches) > 0 || len(t.settled) > 0
+}
+
+// ObserveBlock 检查新区块中被跟踪的受害者交易
+func (t *Tracker) ObserveBlock(ctx context.Context, block *ethtypes.Block) {
+	if t == nil {
+		return
+	}
+
+	number := block.NumberU64()
+	found := make(map[common.Hash]*watch)
+
+	// 先处理重组：被替换区块中的结果失效，重新等待打包
+	if t.reorgs != nil {
+		if reorgFrom, reorged := t.reorgs.Observe(ctx, block.Header()); reorged {
+			t.invalidate(reorgFrom)
+		}
+	}
+
+	t.mu.Lock()
+	for _, tx := range block.Transactions() {
+		if w, exists := t.watches[tx.Hash()]; exists {
+			found[tx.Hash()] = w
+			delete(t.watches, tx.Hash())
+		}
+	}
+	for hash, w := range t.watches {
+		if number > w.targetBlock+watchExpiryBlocks {
+			delete(t.watches, hash)
+			t.expired++
+			t.lifecycle.Emit(w.opportunityID, lifecycle.StageOutcome, hash, map[string]interface{}{
+				"included": false,
+			})
+		}
+	}
+	for hash, entry := range t.settled {
+		if entry.blockNumber+t.depth < number {
+			delete(t.settled, hash)
+		}
+	}
+	t.mu.Unlock()
+
+	for hash, w := range found {
+		t.reconcile(ctx, hash, w, block)
+	}
+}
+
+// invalidate 重组后使区块号 >= reorgFrom 的已记录结果失效，并重新跟踪
+func (t *Tracker) invalidate(reorgFrom uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for hash, entry := range t.settled {
+		if entry.blockNumber < reorgFrom {
+			continue
+		}
+		delete(t.settled, hash)
+		t.watches[hash] = entry.watch
+		t.invalidated++
+
+		log.Printf("🔀 受害者交易 %s 所在区块 %d 被重组，结果失效，重新等待打包", hash.Hex(), entry.blockNumber)
+		t.lifecycle.Emit(entry.watch.opportunityID, lifecycle.StageOutcome, hash, map[string]interface{}{
+			"invalidated": true,
+			"block":       entry.blockNumber,
+			"block_hash":  entry.blockHash.Hex(),
+			"reorg_from":  reorgFrom,
+		})
+	}
+}
+
+// reconcile 解码受害者收据中的Swap事件并与预测值对比：受害者第一跳的输出对比模拟的 victimOut，
+// 紧邻受害者前后在同一池子上反向夹住它的两笔交换对比模拟的 ourOut/exitOut
+func (t *Tracker) reconcile(ctx context.Context, hash common.Hash, w *watch, block *ethtypes.Block) {
+	blockNumber, blockHash := block.NumberU64(), block.Hash()
+	receipt, err := t.client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		log.Printf("⚠️ 获取交易收据失败 %s: %v", hash.Hex(), err)
+		return
+	}
+
+	t.mu.Lock()
+	t.included++
+	if t.depth > 0 {
+		t.settled[hash] = &settled{watch: w, blockNumber: blockNumber, blockHash: blockHash}
+	}
+	t.mu.Unlock()
+
+	detail := map[string]interface{}{
+		"included":     true,
+		"block":        blockNumber,
+		"block_hash":   blockHash.Hex(),
+		"target_block": w.targetBlock,
+		"status":       receipt.Status,
+	}
+
+	events := DecodeSwapLogs(receipt.Logs)
+	amountIn, amountOut, ok := RealizedAmounts(events)
+	if !ok {
+		t.mu.Lock()
+		t.noSwapEvent++
+		t.mu.Unlock()
+		log.Printf("🧾 受害者交易 %s 已打包 (区块 %d)，未找到Swap事件", hash.Hex(), blockNumber)
+		t.lifecycle.Emit(w.opportunityID, lifecycle.StageOutcome, hash, detail)
+		return
+	}
+
+	detail["realized_amount_in"] = amountIn.String()
+	detail["realized_amount_out"] = amountOut.String()
+	inDelta := delta(amountIn, w.amountIn)
+	if inDelta != nil {
+		detail["amount_in_delta"] = inDelta.String()
+	}
+	if w.predicted == nil {
+		log.Printf("🧾 受害者交易 %s 已打包 (区块 %d，目标 %d)\n  实际输入: %s (预测偏差 %v)\n  实际输出: %s",
+			hash.Hex(), blockNumber, w.targetBlock, amountIn, inDelta, amountOut)
+		t.lifecycle.Emit(w.opportunityID, lifecycle.StageOutcome, hash, detail)
+		return
+	}
+
+	victimDelta := delta(events[0].AmountOut(), w.predicted.VictimOut)
+	detail["victim_out_delta"] = victimDelta.String()
+
+	front, back := t.sandwichLegs(ctx, block, receipt.TransactionIndex, events[0])
+	if front == nil {
+		t.mu.Lock()
+		t.noSandwich++
+		t.mu.Unlock()
+		log.Printf("🧾 受害者交易 %s 已打包 (区块 %d，目标 %d)，未被夹\n  实际输入: %s (预测偏差 %v)\n  第一跳输出: %s (预测偏差 %v)",
+			hash.Hex(), blockNumber, w.targetBlock, amountIn, inDelta, events[0].AmountOut(), victimDelta)
+		t.lifecycle.Emit(w.opportunityID, lifecycle.StageOutcome, hash, detail)
+		return
+	}
+
+	ourOutDelta := delta(front.AmountOut(), w.predicted.OurOut)
+	exitOutDelta := delta(back.AmountOut(), w.predicted.ExitOut)
+	detail["sandwiched"] = true
+	detail["realized_our_out"] = front.AmountOut().String()
+	detail["realized_exit_out"] = back.AmountOut().String()
+	detail["our_out_delta"] = ourOutDelta.String()
+	detail["exit_out_delta"] = exitOutDelta.String()
+
+	log.Printf("🧾 受害者交易 %s 已打包并被夹 (区块 %d，目标 %d)\n  第一跳输出: %s (预测偏差 %v)\n  买入到账: %s (预测偏差 %v)\n  卖出换回: %s (预测偏差 %v)",
+		hash.Hex(), blockNumber, w.targetBlock, events[0].AmountOut(), victimDelta,
+		front.AmountOut(), ourOutDelta, back.AmountOut(), exitOutDelta)
+	t.lifecycle.Emit(w.opportunityID, lifecycle.StageOutcome, hash, detail)
+}
+
+// sandwichLegs 读取区块中紧邻受害者前后两笔交易的收据，找出夹住受害者第一跳的买入和卖出交换
+func (t *Tracker) sandwichLegs(ctx context.Context, block *ethtypes.Block, index uint, victim *SwapEvent) (front, back *SwapEvent) {
+	txs := block.Transactions()
+	if index == 0 || int(index)+1 >= len(txs) {
+		return nil, nil
+	}
+
+	var legs [2][]*SwapEvent
+	for i, tx := range []*ethtypes.Transaction{txs[index-1], txs[index+1]} {
+		receipt, err := t.client.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			log.Printf("⚠️ 获取交易收据失败 %s: %v", tx.Hash().Hex(), err)
+			return nil, nil
+		}
+		legs[i] = DecodeSwapLogs(receipt.Logs)
+	}
+	return matchSandwich(victim, legs[0], legs[1])
+}
+
+// matchSandwich 在受害者前一笔交易的Swap事件中找同池同向的买入，在后一笔中找同池反向的卖出，
+// 两者都存在时才视为被夹
+func matchSandwich(victim *SwapEvent, before, after []*SwapEvent) (front, back *SwapEvent) {
+	for i := len(before) - 1; i >= 0; i-- {
+		if before[i].SameDirection(victim) {
+			front = before[i]
+			break
+		}
+	}
+	if front == nil {
+		return nil, nil
+	}
+	for _, event := range after {
+		if event.Pool == victim.Pool && !event.SameDirection(victim) {
+			return front, event
+		}
+	}
+	return nil, nil
+}
+
+// delta 实际值 - 预测值（预测值缺失时返回nil）
+func delta(actual, predicted *big.Int) *big.Int {
+	if predicted == nil {
+		return nil
+	}
+	return new(big.Int).Sub(actual, predicted)
+}
+
+func (t *Tracker) reorgCount() int64 {
+	if t.reorgs == nil {
+		return 0
+	}
+	return t.reorgs.Reorgs()
+}
+
+// GetStats 获取统计信息
+func (t *Tracker) GetStats() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return map[string]interface{}{
+		"watching":      len(t.watches),
+		"included":      t.included,
+		"expired":       t.expired,
+		"no_swap_event": t.noSwapEvent,
+		"invalidated":   t.invalidated,
+		"no_sandwich":   t.noSandwich,
+		"reorgs":        t.reorgCount(),
+	}
+}
@@ -0,0 +1,136 @@
+package outcome
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// receiptNode 为任意交易返回没有日志的成功收据
+type receiptNode struct{}
+
+func (receiptNode) GetTransactionReceipt(hash common.Hash) *ethtypes.Receipt {
+	return &ethtypes.Receipt{Status: ethtypes.ReceiptStatusSuccessful, TxHash: hash, Logs: []*ethtypes.Log{}}
+}
+
+// outcomeSink 记录 outcome 阶段的事件
+type outcomeSink struct {
+	mu     sync.Mutex
+	events []lifecycle.Event
+}
+
+func (s *outcomeSink) Write(event lifecycle.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event.Stage == lifecycle.StageOutcome {
+		s.events = append(s.events, event)
+	}
+	return nil
+}
+
+func (s *outcomeSink) snapshot() []lifecycle.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]lifecycle.Event(nil), s.events...)
+}
+
+// withTxs 以区块头构建包含指定交易的区块
+func withTxs(header *ethtypes.Header, txs ...*ethtypes.Transaction) *ethtypes.Block {
+	return ethtypes.NewBlockWithHeader(header).WithBody(txs, nil)
+}
+
+func TestReorgInvalidatesSettledOutcomes(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", receiptNode{}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	sink := &outcomeSink{}
+	tracker := NewTracker(ethclient.NewClient(rpc.DialInProc(server)), lifecycle.NewRecorder(sink), 8)
+	source := &fakeHeaders{byHash: make(map[common.Hash]*ethtypes.Header)}
+	tracker.reorgs = NewReorgDetector(source, 8)
+
+	early := ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000})
+	late := ethtypes.NewTx(&ethtypes.LegacyTx{Nonce: 2, GasPrice: big.NewInt(1e9), Gas: 21000})
+	for id, tx := range map[string]*ethtypes.Transaction{"early": early, "late": late} {
+		tracker.Watch(&types.ProfitAnalysis{
+			OpportunityID: id,
+			TxHash:        tx.Hash(),
+			TargetBlock:   101,
+			Source:        &types.DecodedTransaction{AmountIn: big.NewInt(1e18)},
+		})
+	}
+
+	// 规范链 101..104：early 在 102 打包，late 在 104 打包
+	genesis := &ethtypes.Header{Number: big.NewInt(100), Difficulty: new(big.Int)}
+	canonical := source.chainFrom(genesis, 4, 'a')
+	for i, header := range canonical {
+		var txs []*ethtypes.Transaction
+		switch i {
+		case 1:
+			txs = append(txs, early)
+		case 3:
+			txs = append(txs, late)
+		}
+		tracker.ObserveBlock(context.Background(), withTxs(header, txs...))
+	}
+	if stats := tracker.GetStats(); stats["included"] != int64(2) || stats["watching"] != 0 {
+		t.Fatalf("before the reorg: included = %v, watching = %v; want 2, 0", stats["included"], stats["watching"])
+	}
+
+	// 新分叉从 102 之后开始（103、104 被替换），新链的 104' 先到达且不含 late
+	fork := source.chainFrom(canonical[1], 3, 'b') // 103'..105'
+	tracker.ObserveBlock(context.Background(), withTxs(fork[1]))
+
+	stats := tracker.GetStats()
+	if stats["invalidated"] != int64(1) || stats["watching"] != 1 || stats["reorgs"] != int64(1) {
+		t.Errorf("after the reorg: invalidated = %v, watching = %v, reorgs = %v; want 1, 1, 1", stats["invalidated"], stats["watching"], stats["reorgs"])
+	}
+	events := sink.snapshot()
+	invalidated := events[len(events)-1]
+	if invalidated.OpportunityID != "late" || invalidated.Detail["invalidated"] != true ||
+		invalidated.Detail["block"] != uint64(104) || invalidated.Detail["reorg_from"] != uint64(103) {
+		t.Errorf("last outcome event = %+v, want late invalidated from block 104 (reorg from 103)", invalidated)
+	}
+	for _, event := range events {
+		if event.OpportunityID == "early" && event.Detail["invalidated"] == true {
+			t.Error("outcome below the fork point invalidated")
+		}
+	}
+
+	// 重新等待打包的受害者在新链上打包：重新计算结果
+	tracker.ObserveBlock(context.Background(), withTxs(fork[2], late))
+	if stats := tracker.GetStats(); stats["included"] != int64(3) || stats["watching"] != 0 {
+		t.Errorf("after re-inclusion: included = %v, watching = %v; want 3, 0", stats["included"], stats["watching"])
+	}
+	events = sink.snapshot()
+	if last := events[len(events)-1]; last.OpportunityID != "late" || last.Detail["included"] != true || last.Detail["block"] != uint64(105) {
+		t.Errorf("last outcome event = %+v, want late included in block 105", last)
+	}
+}
+
+func TestTrackerIgnoresReorgsWithoutDepth(t *testing.T) {
+	tracker := NewTracker(nil, nil, 0)
+	if tracker.reorgs != nil {
+		t.Fatal("reorg detector created with REORG_DEPTH 0")
+	}
+	source := &fakeHeaders{byHash: make(map[common.Hash]*ethtypes.Header)}
+	genesis := &ethtypes.Header{Number: big.NewInt(100), Difficulty: new(big.Int)}
+	canonical := source.chainFrom(genesis, 2, 'a')
+	for _, header := range canonical {
+		tracker.ObserveBlock(context.Background(), withTxs(header))
+	}
+	tracker.ObserveBlock(context.Background(), withTxs(source.chainFrom(canonical[0], 1, 'b')[0]))
+	if stats := tracker.GetStats(); stats["reorgs"] != int64(0) || stats["invalidated"] != int64(0) {
+		t.Errorf("reorgs = %v, invalidated = %v; want 0, 0", stats["reorgs"], stats["invalidated"])
+	}
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mempool-sniper/pkg/types"
+)
+
+// 支持的输出格式
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+)
+
+// Encoder 盈利机会编码器（供文件/消息总线/Webhook等输出端使用）
+type Encoder interface {
+	Encode(analysis *types.ProfitAnalysis) ([]byte, error)
+	Decode(data []byte) (*types.ProfitAnalysis, error)
+	ContentType() string
+}
+
+// NewEncoder 根据格式名称创建编码器
+func NewEncoder(format string) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		return JSONEncoder{}, nil
+	case FormatProtobuf:
+		return ProtobufEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+// JSONEncoder JSON编码器
+type JSONEncoder struct{}
+
+// Encode 编码为JSON
+func (JSONEncoder) Encode(analysis *types.ProfitAnalysis) ([]byte, error) {
+	return json.Marshal(analysis)
+}
+
+// Decode 从JSON解码
+func (JSONEncoder) Decode(data []byte) (*types.ProfitAnalysis, error) {
+	analysis := &types.ProfitAnalysis{}
+	if err := json.Unmarshal(data, analysis); err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+// ContentType HTTP内容类型
+func (JSONEncoder) ContentType() string {
+	return "application/json"
+}
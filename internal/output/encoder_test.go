@@ -0,0 +1,157 @@
+package output
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// sampleAnalysis 只设置 profit_analysis.proto 中存在的字段（protobuf 不携带其余字段）
+func sampleAnalysis() *types.ProfitAnalysis {
+	profit, _ := new(big.Int).SetString("123456789012345678901234567890", 10) // 超过 uint64
+	return &types.ProfitAnalysis{
+		TxHash:         common.HexToHash("0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"),
+		TargetContract: common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"),
+		Method:         "swapExactETHForTokens",
+		Profit:         profit,
+		GasCost:        big.NewInt(4200000000000000),
+		NetProfit:      big.NewInt(-1500000000000000), // 亏损的机会也要原样保留符号
+		SuccessRate:    0.35,
+		RiskLevel:      "MEDIUM",
+		SimulationTime: 42,
+		TargetBlock:    19000001,
+		LowConfidence:  true,
+		VictimPrice:    "3012.5",
+		EntryPrice:     "3010.25",
+		ExitPrice:      "3014.75",
+	}
+}
+
+func TestEncodersRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatProtobuf} {
+		t.Run(format, func(t *testing.T) {
+			encoder, err := NewEncoder(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := sampleAnalysis()
+			data, err := encoder.Encode(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := encoder.Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// 按 profit_analysis.proto 的字段编号和类型逐个读取编码结果，确保手工编码与描述文件一致
+func TestProtobufWireMatchesSchema(t *testing.T) {
+	analysis := sampleAnalysis()
+	data, err := ProtobufEncoder{}.Encode(analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := map[protowire.Number]protowire.Type{
+		1: protowire.BytesType, 2: protowire.BytesType, 3: protowire.BytesType,
+		4: protowire.BytesType, 5: protowire.BytesType, 6: protowire.BytesType,
+		7: protowire.Fixed64Type, 8: protowire.BytesType, 9: protowire.VarintType,
+		10: protowire.VarintType, 11: protowire.VarintType, 12: protowire.BytesType,
+		13: protowire.BytesType, 14: protowire.BytesType,
+	}
+	fields := make(map[protowire.Number]interface{})
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		data = data[n:]
+		if want, ok := schema[num]; !ok || typ != want {
+			t.Fatalf("field %d has wire type %d, schema says %d", num, typ, want)
+		}
+		if _, dup := fields[num]; dup {
+			t.Fatalf("field %d encoded twice", num)
+		}
+
+		switch typ {
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fields[num], data = string(value), data[n:]
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fields[num], data = value, data[n:]
+		case protowire.Fixed64Type:
+			value, n := protowire.ConsumeFixed64(data)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fields[num], data = math.Float64frombits(value), data[n:]
+		}
+	}
+
+	want := map[protowire.Number]interface{}{
+		1:  string(analysis.TxHash.Bytes()),
+		2:  string(analysis.TargetContract.Bytes()),
+		3:  "swapExactETHForTokens",
+		4:  "123456789012345678901234567890",
+		5:  "4200000000000000",
+		6:  "-1500000000000000",
+		7:  0.35,
+		8:  "MEDIUM",
+		9:  uint64(42),
+		10: uint64(19000001),
+		11: uint64(1),
+		12: "3012.5",
+		13: "3010.25",
+		14: "3014.75",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("decoded wire fields = %v, want %v", fields, want)
+	}
+}
+
+func TestProtobufDecodeSkipsUnknownFields(t *testing.T) {
+	data, err := ProtobufEncoder{}.Encode(sampleAnalysis())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 较新版本的描述文件新增的字段
+	data = protowire.AppendTag(data, 99, protowire.BytesType)
+	data = protowire.AppendString(data, "future")
+	data = protowire.AppendTag(data, 100, protowire.VarintType)
+	data = protowire.AppendVarint(data, 7)
+
+	got, err := ProtobufEncoder{}.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sampleAnalysis(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %+v, want %+v", got, want)
+	}
+}
+
+func TestProtobufDecodeRejectsInvalidAmount(t *testing.T) {
+	data := protowire.AppendTag(nil, fieldNetProfit, protowire.BytesType)
+	data = protowire.AppendString(data, "0x10")
+	if _, err := (ProtobufEncoder{}).Decode(data); err == nil {
+		t.Error("Decode() accepted a non-decimal amount")
+	}
+}
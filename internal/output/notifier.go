@@ -0,0 +1,44 @@
+package output
+
+import (
+	"context"
+	"log"
+
+	"mempool-sniper/pkg/types"
+)
+
+// Notifier 可执行机会通知接口（结果处理器对每个通过复核的机会依次调用所有通知器）
+type Notifier interface {
+	Notify(ctx context.Context, analysis *types.ProfitAnalysis) error
+}
+
+// Alerter 运维告警接口（如异常盈利熔断），实现了该接口的通知器同时接收告警
+type Alerter interface {
+	Alert(ctx context.Context, text string) error
+}
+
+// LogNotifier 把机会写入日志（盈利为扣除Gas等成本后的净盈利，与其他通知器一致）
+type LogNotifier struct{}
+
+// Notify 单次输出，避免多个工作线程的日志交错
+func (LogNotifier) Notify(ctx context.Context, analysis *types.ProfitAnalysis) error {
+	log.Printf("💰 发现盈利机会!\n  交易哈希: %s\n  净盈利: %s\n  目标合约: %s\n  方法: %s\n  目标区块: %d",
+		analysis.TxHash.Hex(),
+		analysis.FormatProfit(analysis.NetProfit),
+		analysis.TargetContract.Hex(),
+		analysis.Method,
+		analysis.TargetBlock)
+	return nil
+}
+
+// Alert 把告警写入日志
+func (LogNotifier) Alert(ctx context.Context, text string) error {
+	log.Printf("🚨 %s", text)
+	return nil
+}
+
+// Notify 加入Webhook发送队列（异步发送，失败按配置重试，不影响结果处理）
+func (w *Webhook) Notify(ctx context.Context, analysis *types.ProfitAnalysis) error {
+	w.Publish(analysis)
+	return nil
+}
@@ -0,0 +1,80 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogNotifierPrintsNetProfit(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	analysis := testOpportunity(1)
+	analysis.Profit = big.NewInt(5e16)
+	analysis.NetProfit = big.NewInt(2e16)
+	if err := (LogNotifier{}).Notify(context.Background(), analysis); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "净盈利: "+analysis.FormatProfit(analysis.NetProfit)) ||
+		strings.Contains(got, analysis.FormatProfit(analysis.Profit)) {
+		t.Errorf("log = %q, want the net profit only", got)
+	}
+}
+
+func TestWebhookPostsSignedPayload(t *testing.T) {
+	type request struct {
+		body      []byte
+		signature string
+		mediaType string
+	}
+	received := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- request{body: body, signature: r.Header.Get(SignatureHeader), mediaType: r.Header.Get("Content-Type")}
+	}))
+	defer server.Close()
+
+	secret := "s3cret"
+	webhook := NewWebhook(server.URL, secret, JSONEncoder{}, 5*time.Second, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	webhook.Start(ctx)
+
+	analysis := testOpportunity(7)
+	analysis.Profit = big.NewInt(5e16)
+	analysis.NetProfit = big.NewInt(2e16)
+	if err := webhook.Notify(ctx, analysis); err != nil {
+		t.Fatal(err)
+	}
+
+	var req request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	if req.mediaType != "application/json" {
+		t.Errorf("Content-Type = %q", req.mediaType)
+	}
+	if !Verify([]byte(secret), req.body, req.signature) {
+		t.Errorf("signature %q does not verify", req.signature)
+	}
+	decoded, err := JSONEncoder{}.Decode(req.body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.TxHash != analysis.TxHash || decoded.NetProfit.Cmp(analysis.NetProfit) != 0 {
+		t.Errorf("payload = %s, want tx %s with net profit %s", req.body, analysis.TxHash.Hex(), analysis.NetProfit)
+	}
+}
@@ -0,0 +1,184 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProfitAnalysis 字段编号（与 profit_analysis.proto 保持一致）
+const (
+	fieldTxHash         protowire.Number = 1
+	fieldTargetContract protowire.Number = 2
+	fieldMethod         protowire.Number = 3
+	fieldProfit         protowire.Number = 4
+	fieldGasCost        protowire.Number = 5
+	fieldNetProfit      protowire.Number = 6
+	fieldSuccessRate    protowire.Number = 7
+	fieldRiskLevel      protowire.Number = 8
+	fieldSimulationTime protowire.Number = 9
+	fieldTargetBlock    protowire.Number = 10
+	fieldLowConfidence  protowire.Number = 11
+	fieldVictimPrice    protowire.Number = 12
+	fieldEntryPrice     protowire.Number = 13
+	fieldExitPrice      protowire.Number = 14
+)
+
+// ProtobufEncoder protobuf编码器（按 profit_analysis.proto 手工编码，无需代码生成）
+type ProtobufEncoder struct{}
+
+// Encode 编码为protobuf
+func (ProtobufEncoder) Encode(analysis *types.ProfitAnalysis) ([]byte, error) {
+	var b []byte
+
+	b = appendBytes(b, fieldTxHash, analysis.TxHash.Bytes())
+	b = appendBytes(b, fieldTargetContract, analysis.TargetContract.Bytes())
+	b = appendString(b, fieldMethod, analysis.Method)
+	b = appendBigInt(b, fieldProfit, analysis.Profit)
+	b = appendBigInt(b, fieldGasCost, analysis.GasCost)
+	b = appendBigInt(b, fieldNetProfit, analysis.NetProfit)
+	if analysis.SuccessRate != 0 {
+		b = protowire.AppendTag(b, fieldSuccessRate, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(analysis.SuccessRate))
+	}
+	b = appendString(b, fieldRiskLevel, analysis.RiskLevel)
+	if analysis.SimulationTime != 0 {
+		b = protowire.AppendTag(b, fieldSimulationTime, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(analysis.SimulationTime))
+	}
+	if analysis.TargetBlock != 0 {
+		b = protowire.AppendTag(b, fieldTargetBlock, protowire.VarintType)
+		b = protowire.AppendVarint(b, analysis.TargetBlock)
+	}
+	if analysis.LowConfidence {
+		b = protowire.AppendTag(b, fieldLowConfidence, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(true))
+	}
+	b = appendString(b, fieldVictimPrice, analysis.VictimPrice)
+	b = appendString(b, fieldEntryPrice, analysis.EntryPrice)
+	b = appendString(b, fieldExitPrice, analysis.ExitPrice)
+
+	return b, nil
+}
+
+// Decode 从protobuf解码
+func (ProtobufEncoder) Decode(data []byte) (*types.ProfitAnalysis, error) {
+	analysis := &types.ProfitAnalysis{}
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch {
+		case typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			data = data[n:]
+			if err := decodeBytesField(analysis, num, value); err != nil {
+				return nil, err
+			}
+
+		case typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			data = data[n:]
+			switch num {
+			case fieldSimulationTime:
+				analysis.SimulationTime = int64(value)
+			case fieldTargetBlock:
+				analysis.TargetBlock = value
+			case fieldLowConfidence:
+				analysis.LowConfidence = protowire.DecodeBool(value)
+			}
+
+		case typ == protowire.Fixed64Type && num == fieldSuccessRate:
+			value, n := protowire.ConsumeFixed64(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			data = data[n:]
+			analysis.SuccessRate = math.Float64frombits(value)
+
+		default:
+			// 跳过未知字段，保持向前兼容
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+
+	return analysis, nil
+}
+
+// ContentType HTTP内容类型
+func (ProtobufEncoder) ContentType() string {
+	return "application/x-protobuf"
+}
+
+// decodeBytesField 解码长度前缀类型的字段
+func decodeBytesField(analysis *types.ProfitAnalysis, num protowire.Number, value []byte) error {
+	switch num {
+	case fieldTxHash:
+		analysis.TxHash = common.BytesToHash(value)
+	case fieldTargetContract:
+		analysis.TargetContract = common.BytesToAddress(value)
+	case fieldMethod:
+		analysis.Method = string(value)
+	case fieldRiskLevel:
+		analysis.RiskLevel = string(value)
+	case fieldVictimPrice:
+		analysis.VictimPrice = string(value)
+	case fieldEntryPrice:
+		analysis.EntryPrice = string(value)
+	case fieldExitPrice:
+		analysis.ExitPrice = string(value)
+	case fieldProfit, fieldGasCost, fieldNetProfit:
+		amount, ok := new(big.Int).SetString(string(value), 10)
+		if !ok {
+			return fmt.Errorf("字段 %d 不是有效的十进制整数: %q", num, value)
+		}
+		switch num {
+		case fieldProfit:
+			analysis.Profit = amount
+		case fieldGasCost:
+			analysis.GasCost = amount
+		default:
+			analysis.NetProfit = amount
+		}
+	}
+	return nil
+}
+
+func appendBytes(b []byte, num protowire.Number, value []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+func appendString(b []byte, num protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendBigInt(b []byte, num protowire.Number, value *big.Int) []byte {
+	if value == nil {
+		return b
+	}
+	return appendString(b, num, value.String())
+}
@@ -0,0 +1,284 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+// TelegramAPIURL Telegram Bot API 默认地址
+const TelegramAPIURL = "https://api.telegram.org"
+
+// telegramMaxMessage 单条消息的最大长度（Bot API 限制4096字符，留出余量）
+const telegramMaxMessage = 4000
+
+const (
+	telegramMaxPending  = 1000 // 待发送消息上限（超出时丢弃最旧的，Bot API 不可用时不无限积压）
+	telegramMaxAttempts = 3    // 单条消息最多发送次数（被限流等待不计入）
+)
+
+// telegramMessage 待发送（或等待重试）的消息
+type telegramMessage struct {
+	text     string
+	attempts int
+}
+
+// rateLimitError Bot API 返回429：需要等待 retryAfter 后再发送
+type rateLimitError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.retryAfter)
+}
+
+// TelegramNotifier 通过 Telegram Bot API 发送机会通知（实现 Notifier）：
+// 每秒汇总一次待发送消息，不超过 batchAbove 条时逐条发送，超过时合并为一条，避免触发限流。
+// 发送失败的消息在之后的周期重试，被限流（429）时按 retry_after 暂停发送
+type TelegramNotifier struct {
+	endpoint   string
+	chatID     string
+	batchAbove int
+	client     *http.Client
+
+	mu          sync.Mutex
+	pending     []string          // 本周期新加入的消息（发送前按阈值合并）
+	outbox      []telegramMessage // 已合并、等待重试的消息
+	retryAt     time.Time         // 被限流时恢复发送的时间
+	sent        int64
+	batched     int64
+	failed      int64
+	retried     int64
+	dropped     int64
+	rateLimited int64
+}
+
+// NewTelegramNotifier 创建Telegram通知器（baseURL 为空时使用 TelegramAPIURL）
+func NewTelegramNotifier(baseURL, botToken, chatID string, batchAbove int, timeout time.Duration) *TelegramNotifier {
+	if baseURL == "" {
+		baseURL = TelegramAPIURL
+	}
+	return &TelegramNotifier{
+		endpoint:   strings.TrimRight(baseURL, "/") + "/bot" + botToken + "/sendMessage",
+		chatID:     chatID,
+		batchAbove: batchAbove,
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+// Notify 格式化机会并加入待发送队列，由 Start 启动的协程每秒发送
+func (t *TelegramNotifier) Notify(ctx context.Context, analysis *types.ProfitAnalysis) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, formatTelegramMessage(analysis))
+	if over := len(t.pending) + len(t.outbox) - telegramMaxPending; over > 0 {
+		t.pending = t.pending[over:]
+		t.dropped += int64(over)
+	}
+	return nil
+}
+
+// Alert 把告警加入等待发送的队列：不与机会通知合并，下一周期单独发送
+func (t *TelegramNotifier) Alert(ctx context.Context, text string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requeueLocked([]telegramMessage{{text: "🚨 " + text}})
+	return nil
+}
+
+// formatTelegramMessage 机会通知内容：交易哈希、方法、净盈利（按基础资产计价）、风险等级
+func formatTelegramMessage(analysis *types.ProfitAnalysis) string {
+	return fmt.Sprintf("💰 盈利机会\n交易: %s\n方法: %s\n净盈利: %s\n风险: %s",
+		analysis.TxHash.Hex(),
+		analysis.Method,
+		analysis.FormatProfit(analysis.NetProfit),
+		analysis.RiskLevel)
+}
+
+// Start 启动发送协程，每秒发送一次待发送消息，直到上下文取消（取消时未发送的消息丢弃）
+func (t *TelegramNotifier) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.flush(ctx)
+			}
+		}
+	}()
+}
+
+// flush 发送等待重试的消息和本秒积累的消息：新消息数量超过阈值时合并发送
+func (t *TelegramNotifier) flush(ctx context.Context) {
+	t.mu.Lock()
+	if time.Now().Before(t.retryAt) {
+		t.mu.Unlock()
+		return
+	}
+	messages := t.pending
+	t.pending = nil
+	if t.batchAbove > 0 && len(messages) > t.batchAbove {
+		t.batched += int64(len(messages))
+		messages = batchMessages(messages)
+	}
+	queue := t.outbox
+	t.outbox = nil
+	for _, text := range messages {
+		queue = append(queue, telegramMessage{text: text})
+	}
+	t.mu.Unlock()
+
+	for i, message := range queue {
+		err := t.send(ctx, message.text)
+		if err == nil {
+			t.mu.Lock()
+			t.sent++
+			t.mu.Unlock()
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		// 被限流：暂停发送，本条及之后的消息原样留到恢复后
+		var limited *rateLimitError
+		if errors.As(err, &limited) {
+			log.Printf("⚠️ Telegram通知被限流，%s 后重试", limited.retryAfter)
+			t.mu.Lock()
+			t.rateLimited++
+			t.retryAt = time.Now().Add(limited.retryAfter)
+			t.requeueLocked(queue[i:])
+			t.mu.Unlock()
+			return
+		}
+
+		message.attempts++
+		t.mu.Lock()
+		if message.attempts >= telegramMaxAttempts {
+			log.Printf("⚠️ Telegram通知发送失败，放弃: %v", err)
+			t.failed++
+		} else {
+			log.Printf("⚠️ Telegram通知发送失败，稍后重试: %v", err)
+			t.retried++
+			t.requeueLocked([]telegramMessage{message})
+		}
+		t.mu.Unlock()
+	}
+}
+
+// requeueLocked 把消息放回等待重试队列（调用方需持有锁），超出上限时丢弃最旧的
+func (t *TelegramNotifier) requeueLocked(messages []telegramMessage) {
+	t.outbox = append(t.outbox, messages...)
+	if over := len(t.outbox) + len(t.pending) - telegramMaxPending; over > 0 {
+		over = min(over, len(t.outbox))
+		t.outbox = t.outbox[over:]
+		t.dropped += int64(over)
+	}
+}
+
+// batchMessages 把多条消息合并为尽量少的几条（每条不超过长度上限）
+func batchMessages(messages []string) []string {
+	header := fmt.Sprintf("📦 %d 个盈利机会\n\n", len(messages))
+	var batches []string
+	current := header
+	for _, message := range messages {
+		if len(current)+len(message)+2 > telegramMaxMessage && current != header {
+			batches = append(batches, strings.TrimRight(current, "\n"))
+			current = header
+		}
+		current += message + "\n\n"
+	}
+	return append(batches, strings.TrimRight(current, "\n"))
+}
+
+// send 调用 sendMessage
+func (t *TelegramNotifier) send(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"chat_id": t.chatID,
+		"text":    text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// url.Error 中包含带bot token的URL，只保留底层错误
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &rateLimitError{retryAfter: telegramRetryAfter(resp)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// telegramRetryAfter 429响应要求的等待时间：优先取响应体的 parameters.retry_after，
+// 其次是 Retry-After 头，都没有时等待1秒
+func telegramRetryAfter(resp *http.Response) time.Duration {
+	var body struct {
+		Parameters struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body); err == nil && body.Parameters.RetryAfter > 0 {
+		return time.Duration(body.Parameters.RetryAfter) * time.Second
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Second
+}
+
+// GetStats 获取统计信息
+func (t *TelegramNotifier) GetStats() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return map[string]interface{}{
+		"sent":         t.sent,
+		"batched":      t.batched,
+		"failed":       t.failed,
+		"retried":      t.retried,
+		"dropped":      t.dropped,
+		"rate_limited": t.rateLimited,
+		"pending":      len(t.pending) + len(t.outbox),
+	}
+}
@@ -0,0 +1,174 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// botAPI 记录 sendMessage 请求的测试 Bot API，按顺序返回预设的状态码
+type botAPI struct {
+	mu       sync.Mutex
+	texts    []string
+	statuses []int
+}
+
+func (b *botAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := http.StatusOK
+	if len(b.statuses) > 0 {
+		status, b.statuses = b.statuses[0], b.statuses[1:]
+	}
+	if status == http.StatusTooManyRequests {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 30","parameters":{"retry_after":30}}`))
+		return
+	}
+	if status == http.StatusOK {
+		b.texts = append(b.texts, body.Text)
+	}
+	w.WriteHeader(status)
+}
+
+func (b *botAPI) received() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.texts...)
+}
+
+func testOpportunity(n int64) *types.ProfitAnalysis {
+	return &types.ProfitAnalysis{
+		TxHash:    common.BigToHash(big.NewInt(n)),
+		Method:    "swapExactETHForTokens",
+		NetProfit: big.NewInt(1e16),
+		RiskLevel: "LOW",
+	}
+}
+
+func newTestTelegram(t *testing.T, api *botAPI, batchAbove int) *TelegramNotifier {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return NewTelegramNotifier(server.URL, "token", "chat", batchAbove, 5*time.Second)
+}
+
+func TestTelegramBatchesAboveThreshold(t *testing.T) {
+	api := &botAPI{}
+	notifier := newTestTelegram(t, api, 1)
+	ctx := context.Background()
+
+	notifier.Notify(ctx, testOpportunity(1))
+	notifier.flush(ctx)
+	for i := int64(2); i <= 4; i++ {
+		notifier.Notify(ctx, testOpportunity(i))
+	}
+	notifier.flush(ctx)
+
+	texts := api.received()
+	if len(texts) != 2 {
+		t.Fatalf("sent %d messages, want 2: %q", len(texts), texts)
+	}
+	if strings.Contains(texts[0], "个盈利机会") {
+		t.Errorf("single opportunity was batched: %q", texts[0])
+	}
+	if !strings.HasPrefix(texts[1], "📦 3 个盈利机会") || strings.Count(texts[1], "💰") != 3 {
+		t.Errorf("batch = %q, want 3 opportunities in one message", texts[1])
+	}
+	if stats := notifier.GetStats(); stats["batched"] != int64(3) || stats["sent"] != int64(2) {
+		t.Errorf("stats = %v", stats)
+	}
+}
+
+func TestTelegramHonorsRetryAfterAndRetries(t *testing.T) {
+	api := &botAPI{statuses: []int{http.StatusTooManyRequests, http.StatusInternalServerError}}
+	notifier := newTestTelegram(t, api, 1)
+	ctx := context.Background()
+
+	notifier.Notify(ctx, testOpportunity(1))
+	notifier.flush(ctx)
+	if wait := time.Until(notifier.retryAt); wait < 29*time.Second {
+		t.Fatalf("paused for %s after 429, want retry_after (30s)", wait)
+	}
+
+	// 限流期间不发送，消息保留
+	notifier.Notify(ctx, testOpportunity(2))
+	notifier.flush(ctx)
+	if stats := notifier.GetStats(); stats["pending"] != 2 || stats["rate_limited"] != int64(1) {
+		t.Fatalf("stats during rate limit = %v", stats)
+	}
+
+	// 恢复后：第一条遇到500留待重试，下一周期发出
+	notifier.retryAt = time.Time{}
+	notifier.flush(ctx)
+	notifier.flush(ctx)
+
+	texts := api.received()
+	if len(texts) != 2 {
+		t.Fatalf("sent %d messages, want 2: %q", len(texts), texts)
+	}
+	stats := notifier.GetStats()
+	if stats["retried"] != int64(1) || stats["failed"] != int64(0) || stats["pending"] != 0 {
+		t.Errorf("stats = %v", stats)
+	}
+}
+
+func TestTelegramBoundsPendingQueue(t *testing.T) {
+	notifier := NewTelegramNotifier("http://127.0.0.1:0", "token", "chat", 1, time.Second)
+	ctx := context.Background()
+	for i := int64(0); i < telegramMaxPending+10; i++ {
+		notifier.Notify(ctx, testOpportunity(i))
+	}
+
+	stats := notifier.GetStats()
+	if stats["pending"] != telegramMaxPending || stats["dropped"] != int64(10) {
+		t.Errorf("stats = %v, want %d pending and 10 dropped", stats, telegramMaxPending)
+	}
+	// 丢弃的是最旧的消息
+	if !strings.Contains(notifier.pending[0], common.BigToHash(big.NewInt(10)).Hex()) {
+		t.Errorf("oldest kept message = %q", notifier.pending[0])
+	}
+}
+
+func TestTelegramSendsAlertsUnbatched(t *testing.T) {
+	api := &botAPI{}
+	notifier := newTestTelegram(t, api, 1)
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		notifier.Notify(ctx, testOpportunity(i))
+	}
+	if err := notifier.Alert(ctx, "异常盈利熔断!"); err != nil {
+		t.Fatal(err)
+	}
+	notifier.flush(ctx)
+
+	texts := api.received()
+	if len(texts) != 2 {
+		t.Fatalf("sent %d messages, want the alert and one batch: %q", len(texts), texts)
+	}
+	if texts[0] != "🚨 异常盈利熔断!" {
+		t.Errorf("first message = %q, want the alert on its own", texts[0])
+	}
+	if !strings.HasPrefix(texts[1], "📦 3 个盈利机会") {
+		t.Errorf("batch = %q", texts[1])
+	}
+}
@@ -0,0 +1,165 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+// SignatureHeader HMAC签名请求头，值为 "sha256=" + hex(HMAC-SHA256(secret, body))
+const SignatureHeader = "X-Signature"
+
+// webhookQueueSize 待发送队列长度（满时丢弃，避免阻塞结果处理）
+const webhookQueueSize = 100
+
+// webhookRetryBackoff 第一次重试前的等待时间（之后每次翻倍）
+const webhookRetryBackoff = 500 * time.Millisecond
+
+// Webhook 盈利机会Webhook输出端（实现 Notifier）：每个机会POST一次，可选HMAC签名，失败时重试
+type Webhook struct {
+	url     string
+	secret  []byte
+	encoder Encoder
+	client  *http.Client
+	queue   chan *types.ProfitAnalysis
+	retries int
+
+	mu      sync.Mutex
+	sent    int64
+	failed  int64
+	retried int64
+	dropped int64
+}
+
+// NewWebhook 创建Webhook输出端（secret 为空表示不签名，retries 为失败后的重试次数）
+func NewWebhook(url, secret string, encoder Encoder, timeout time.Duration, retries int) *Webhook {
+	return &Webhook{
+		url:     url,
+		secret:  []byte(secret),
+		encoder: encoder,
+		client:  &http.Client{Timeout: timeout},
+		queue:   make(chan *types.ProfitAnalysis, webhookQueueSize),
+		retries: retries,
+	}
+}
+
+// Sign 计算请求体的签名头取值
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名头（供接收方参考，常数时间比较）
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Start 启动发送协程，直到上下文取消
+func (w *Webhook) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case analysis := <-w.queue:
+				if err := w.sendWithRetry(ctx, analysis); err != nil {
+					log.Printf("⚠️ Webhook发送失败 %s: %v", analysis.TxHash.Hex(), err)
+					w.mu.Lock()
+					w.failed++
+					w.mu.Unlock()
+					continue
+				}
+				w.mu.Lock()
+				w.sent++
+				w.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Publish 加入发送队列（为nil时忽略，队列满时丢弃并计数）
+func (w *Webhook) Publish(analysis *types.ProfitAnalysis) {
+	if w == nil {
+		return
+	}
+
+	select {
+	case w.queue <- analysis:
+	default:
+		w.mu.Lock()
+		w.dropped++
+		w.mu.Unlock()
+	}
+}
+
+// sendWithRetry 发送失败时按指数退避重试
+func (w *Webhook) sendWithRetry(ctx context.Context, analysis *types.ProfitAnalysis) error {
+	backoff := webhookRetryBackoff
+	err := w.send(ctx, analysis)
+	for attempt := 0; err != nil && attempt < w.retries; attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		w.mu.Lock()
+		w.retried++
+		w.mu.Unlock()
+		err = w.send(ctx, analysis)
+	}
+	return err
+}
+
+// send 编码、签名并POST一个机会
+func (w *Webhook) send(ctx context.Context, analysis *types.ProfitAnalysis) error {
+	body, err := w.encoder.Encode(analysis)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.encoder.ContentType())
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// GetStats 获取统计信息
+func (w *Webhook) GetStats() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return map[string]interface{}{
+		"sent":    w.sent,
+		"failed":  w.failed,
+		"retried": w.retried,
+		"dropped": w.dropped,
+		"signed":  len(w.secret) > 0,
+	}
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignMatchesHMACSHA256(t *testing.T) {
+	// RFC 4231 测试用例2
+	got := Sign([]byte("Jefe"), []byte("what do ya want for nothing?"))
+	if want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"; got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("s3cret")
+	body := []byte(`{"tx_hash":"0x01","net_profit":"20000000000000000"}`)
+	signature := Sign(secret, body)
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "matching", secret: secret, body: body, signature: signature, want: true},
+		{name: "wrong secret", secret: []byte("other"), body: body, signature: signature, want: false},
+		{name: "tampered body", secret: secret, body: []byte(`{"tx_hash":"0x01","net_profit":"90000000000000000"}`), signature: signature, want: false},
+		{name: "missing prefix", secret: secret, body: body, signature: signature[len("sha256="):], want: false},
+		{name: "missing signature", secret: secret, body: body, signature: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.body, tt.signature); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookWithoutSecretIsUnsigned(t *testing.T) {
+	signatures := make(chan []string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signatures <- r.Header.Values(SignatureHeader)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL, "", JSONEncoder{}, 5*time.Second, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	webhook.Start(ctx)
+	webhook.Notify(ctx, testOpportunity(1))
+
+	select {
+	case got := <-signatures:
+		if len(got) != 0 {
+			t.Errorf("%s = %q, want no signature without a secret", SignatureHeader, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+}
@@ -0,0 +1,111 @@
+package pnl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Entry 一笔交易的盈亏记录
+type Entry struct {
+	OpportunityID string      `json:"opportunity_id"`
+	TxHash        common.Hash `json:"tx_hash"`
+	TargetBlock   uint64      `json:"target_block"`
+	NetProfit     *big.Int    `json:"net_profit"` // wei，可为负
+	Simulated     bool        `json:"simulated"`  // 模拟盘记录（未实际广播）
+	Timestamp     time.Time   `json:"timestamp"`
+}
+
+// Tracker 盈亏跟踪器，可选将记录追加写入JSONL文件
+type Tracker struct {
+	mu        sync.Mutex
+	file      *os.File
+	trades    int64
+	wins      int64
+	losses    int64
+	total     *big.Int
+	simulated *big.Int
+}
+
+// NewTracker 创建盈亏跟踪器，path为空时只在内存中统计
+func NewTracker(path string) (*Tracker, error) {
+	t := &Tracker{
+		total:     big.NewInt(0),
+		simulated: big.NewInt(0),
+	}
+
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open pnl file: %v", err)
+		}
+		t.file = file
+	}
+
+	return t, nil
+}
+
+// Record 记录一笔盈亏
+func (t *Tracker) Record(entry Entry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if entry.NetProfit == nil {
+		entry.NetProfit = big.NewInt(0)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.trades++
+	if entry.NetProfit.Sign() > 0 {
+		t.wins++
+	} else if entry.NetProfit.Sign() < 0 {
+		t.losses++
+	}
+	t.total.Add(t.total, entry.NetProfit)
+	if entry.Simulated {
+		t.simulated.Add(t.simulated, entry.NetProfit)
+	}
+
+	if t.file == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = t.file.Write(append(line, '\n'))
+	return err
+}
+
+// GetStats 获取统计信息
+func (t *Tracker) GetStats() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return map[string]interface{}{
+		"trades":        t.trades,
+		"wins":          t.wins,
+		"losses":        t.losses,
+		"total_pnl":     t.total.String(),
+		"simulated_pnl": t.simulated.String(),
+	}
+}
+
+// Close 关闭记录文件
+func (t *Tracker) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return nil
+	}
+	return t.file.Close()
+}
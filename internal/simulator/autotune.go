@@ -0,0 +1,161 @@
+package simulator
+
+import (
+	"context"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+const (
+	autoTuneInterval  = 5 * time.Second
+	autoTuneIdleTicks = 3   // 连续空闲N个周期才缩容，避免抖动
+	latencyEWMAWeight = 0.2 // 延迟指数滑动平均权重
+)
+
+// workerHandle 单个工作线程的控制句柄
+type workerHandle struct {
+	id     int
+	cancel context.CancelFunc
+}
+
+// spawnWorker 启动一个工作线程（调用方需持有锁）
+func (s *Simulator) spawnWorker(ctx context.Context, decodedTxChan <-chan *types.DecodedTransaction, profitChan chan<- *types.ProfitAnalysis) {
+	workerCtx, cancel := context.WithCancel(ctx)
+	id := s.nextWorkerID
+	s.nextWorkerID++
+	s.workers = append(s.workers, workerHandle{id: id, cancel: cancel})
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.worker(workerCtx, decodedTxChan, profitChan, id)
+		s.removeWorker(id)
+	}()
+}
+
+// removeWorker 工作线程退出后移除其句柄（已被 stopWorker 移除时不处理），
+// 避免自动调节和看门狗把已退出的线程算作运行中
+func (s *Simulator) removeWorker(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, handle := range s.workers {
+		if handle.id == id {
+			handle.cancel()
+			s.workers = append(s.workers[:i], s.workers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Wait 等待所有工作线程退出（上下文取消或输入通道关闭且排空后）
+func (s *Simulator) Wait() {
+	s.running.Wait()
+}
+
+// RestartWorkers 停止当前所有工作线程并按原数量重新启动（看门狗发现工作池停滞时调用）：
+// 卡死的旧线程无法强制结束，取消其上下文后由新线程接管输入通道
+func (s *Simulator) RestartWorkers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.poolCtx == nil || s.poolCtx.Err() != nil {
+		return
+	}
+
+	count := max(len(s.workers), 1)
+	for len(s.workers) > 0 {
+		s.stopWorker()
+	}
+	for i := 0; i < count; i++ {
+		s.spawnWorker(s.poolCtx, s.poolIn, s.poolOut)
+	}
+	logger.Warn("模拟器工作池已重启", "workers", count)
+}
+
+// Progress 工作线程从输入通道取出的交易数（看门狗据此判断工作池是否停滞）
+func (s *Simulator) Progress() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dequeued
+}
+
+// stopWorker 停止最后启动的工作线程（调用方需持有锁）
+func (s *Simulator) stopWorker() {
+	last := s.workers[len(s.workers)-1]
+	s.workers = s.workers[:len(s.workers)-1]
+	last.cancel()
+}
+
+// recordLatency 记录一次模拟耗时
+func (s *Simulator) recordLatency(d time.Duration) {
+	ms := float64(d.Milliseconds())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latencyEWMA == 0 {
+		s.latencyEWMA = ms
+		return
+	}
+	s.latencyEWMA = latencyEWMAWeight*ms + (1-latencyEWMAWeight)*s.latencyEWMA
+}
+
+// autoTuneEnabled 是否开启工作线程数自动调节
+func autoTuneEnabled(minWorkers, maxWorkers int) bool {
+	return maxWorkers > 0 && maxWorkers > minWorkers
+}
+
+// runAutoTuner 根据队列积压和RPC延迟在[min, max]之间调整工作线程数：
+// 积压且延迟未超标时扩容，持续空闲时缩容，至少保留 max(min, 1) 个；
+// 热更新后 SIM_WORKERS_MAX 为0（或不大于下限）表示停用自动调节，保持当前线程数
+func (s *Simulator) runAutoTuner(ctx context.Context, decodedTxChan <-chan *types.DecodedTransaction, profitChan chan<- *types.ProfitAnalysis) {
+	ticker := time.NewTicker(autoTuneInterval)
+	defer ticker.Stop()
+
+	idleTicks := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		depth, capacity := len(decodedTxChan), cap(decodedTxChan)
+
+		s.mu.Lock()
+		minWorkers, maxWorkers := s.cfg.SimWorkersMin, s.cfg.SimWorkersMax
+		latencyTarget := float64(s.cfg.SimLatencyTargetMs)
+		latency := s.latencyEWMA
+		active := len(s.workers)
+		if !autoTuneEnabled(minWorkers, maxWorkers) {
+			s.mu.Unlock()
+			idleTicks = 0
+			continue
+		}
+		minWorkers = max(minWorkers, 1)
+
+		switch {
+		case active < minWorkers:
+			// 配置热更新后低于下限
+			s.spawnWorker(ctx, decodedTxChan, profitChan)
+			idleTicks = 0
+		case active > maxWorkers:
+			s.stopWorker()
+			idleTicks = 0
+		case depth*4 > capacity && active < maxWorkers && (latencyTarget <= 0 || latency <= latencyTarget):
+			// 队列积压超过1/4，RPC尚有余量
+			s.spawnWorker(ctx, decodedTxChan, profitChan)
+			idleTicks = 0
+			logger.Info("模拟队列积压，工作线程扩容", "queue_depth", depth, "queue_capacity", capacity, "workers", len(s.workers), "latency_ms", latency)
+		case depth == 0 && active > minWorkers:
+			idleTicks++
+			if idleTicks >= autoTuneIdleTicks {
+				s.stopWorker()
+				idleTicks = 0
+				logger.Info("模拟队列空闲，工作线程缩容", "workers", len(s.workers))
+			}
+		default:
+			idleTicks = 0
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,57 @@
+package simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+func TestExitedWorkerIsRemoved(t *testing.T) {
+	s := &Simulator{pool: newConnPool([]string{"http://127.0.0.1:1"}, 0, nil, 1)}
+	in := make(chan *types.DecodedTransaction)
+	out := make(chan *types.ProfitAnalysis, 1)
+
+	s.mu.Lock()
+	s.spawnWorker(context.Background(), in, out)
+	s.spawnWorker(context.Background(), in, out)
+	s.mu.Unlock()
+
+	// 输入通道关闭后工作线程自行退出，句柄应随之移除
+	close(in)
+	done := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("workers did not exit after the input channel closed")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.workers) != 0 {
+		t.Errorf("%d exited workers still tracked", len(s.workers))
+	}
+}
+
+func TestAutoTuneEnabled(t *testing.T) {
+	tests := []struct {
+		min, max int
+		want     bool
+	}{
+		{min: 1, max: 0, want: false}, // SIM_WORKERS_MAX=0 停用
+		{min: 4, max: 4, want: false},
+		{min: 4, max: 2, want: false},
+		{min: 0, max: 8, want: true},
+		{min: 2, max: 8, want: true},
+	}
+	for _, tt := range tests {
+		if got := autoTuneEnabled(tt.min, tt.max); got != tt.want {
+			t.Errorf("autoTuneEnabled(%d, %d) = %v, want %v", tt.min, tt.max, got, tt.want)
+		}
+	}
+}
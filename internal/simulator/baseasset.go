@@ -0,0 +1,150 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// baseAssetRate 代币与基础资产的兑换比例（包装原生代币交易对的储备）
+type baseAssetRate struct {
+	base  *big.Int // 基础资产储备
+	token *big.Int // 代币储备
+}
+
+// identityRate 代币即基础资产
+var identityRate = baseAssetRate{base: big.NewInt(1), token: big.NewInt(1)}
+
+// toBase 代币数量换算为基础资产
+func (r baseAssetRate) toBase(amount *big.Int) *big.Int {
+	converted := new(big.Int).Mul(amount, r.base)
+	return converted.Quo(converted, r.token)
+}
+
+// fromBase 基础资产数量换算为代币
+func (r baseAssetRate) fromBase(amount *big.Int) *big.Int {
+	converted := new(big.Int).Mul(amount, r.token)
+	return converted.Quo(converted, r.base)
+}
+
+// rateCache 按区块缓存代币与基础资产的兑换比例：同一区块内交易对储备视为不变，
+// 模拟盈利换算和按代币配置的阈值折算共用，新区块到达后整体失效
+type rateCache struct {
+	mu    sync.Mutex
+	block uint64
+	rates map[pairKey]baseAssetRate
+	hits  int64
+}
+
+// get 读取 block 的缓存
+func (c *rateCache) get(block uint64, key pairKey) (baseAssetRate, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rate, exists := c.rates[key]
+	if !exists || c.block != block {
+		return baseAssetRate{}, false
+	}
+	c.hits++
+	return rate, true
+}
+
+// put 写入 block 的兑换比例（较早区块的查询晚返回时不覆盖较新的缓存）
+func (c *rateCache) put(block uint64, key pairKey, rate baseAssetRate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if block < c.block {
+		return
+	}
+	if block > c.block || c.rates == nil {
+		c.block = block
+		c.rates = make(map[pairKey]baseAssetRate)
+	}
+	c.rates[key] = rate
+}
+
+// hitCount 缓存命中次数
+func (c *rateCache) hitCount() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// tokenRate 代币按目标路由工厂上包装原生代币/代币交易对现价换算为基础资产的比例：
+// 代币即基础资产（原生代币或其包装代币）时为1:1，没有该交易对时返回错误
+func (s *Simulator) tokenRate(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction, token common.Address) (baseAssetRate, error) {
+	chainID := decodedTx.Transaction.ChainID
+	if types.IsBaseAsset(token, chainID) {
+		return identityRate, nil
+	}
+
+	weth, ok := types.WrappedNative(chainID)
+	if !ok {
+		return baseAssetRate{}, fmt.Errorf("链 %v 没有包装原生代币，无法按 %s 计价", chainID, types.BaseAssetSymbol(chainID))
+	}
+	factory, exists := s.chain.factory(decodedTx.TargetContract)
+	if !exists {
+		return baseAssetRate{}, fmt.Errorf("路由 %s 没有V2工厂，无法按 %s 计价", decodedTx.TargetContract.Hex(), types.BaseAssetSymbol(chainID))
+	}
+
+	key := newPairKey(factory, weth, token)
+	s.mu.RLock()
+	head := s.latestBlock
+	s.mu.RUnlock()
+	// 区块号未知时不缓存
+	if head > 0 {
+		if rate, ok := s.rates.get(head, key); ok {
+			return rate, nil
+		}
+	}
+
+	reserves, err := s.getReserves(ctx, conn, key)
+	if err != nil {
+		return baseAssetRate{}, err
+	}
+	reserveBase, reserveToken := reserves.reserve0, reserves.reserve1
+	if key.token0 != weth {
+		reserveBase, reserveToken = reserveToken, reserveBase
+	}
+	if reserveBase.Sign() == 0 || reserveToken.Sign() == 0 {
+		return baseAssetRate{}, fmt.Errorf("%s/%s 交易对没有流动性，无法按 %s 计价", types.BaseAssetSymbol(chainID), token.Hex(), types.BaseAssetSymbol(chainID))
+	}
+	rate := baseAssetRate{base: reserveBase, token: reserveToken}
+	if head > 0 {
+		s.rates.put(head, key, rate)
+	}
+	return rate, nil
+}
+
+// profitRate 策略计算盈利使用的代币（交换路径的输入代币）换算为基础资产的比例，非交换交易的盈利已按基础资产计价
+func (s *Simulator) profitRate(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (baseAssetRate, error) {
+	if len(decodedTx.Path) == 0 {
+		return identityRate, nil
+	}
+	return s.tokenRate(ctx, conn, decodedTx, decodedTx.Path[0])
+}
+
+// BaseAssetValue 代币数量按交易所在路由的交易对现价换算为基础资产（用于把按代币配置的阈值换算为基础资产）
+func (s *Simulator) BaseAssetValue(ctx context.Context, decodedTx *types.DecodedTransaction, token common.Address, amount *big.Int) (*big.Int, error) {
+	conn, release := s.leaseConn()
+	defer release()
+
+	if decodedTx == nil || decodedTx.Transaction == nil {
+		return nil, errInvalidTransaction
+	}
+	if conn.client == nil && !types.IsBaseAsset(token, decodedTx.Transaction.ChainID) {
+		return nil, rpc.ErrClientQuit
+	}
+	rate, err := s.tokenRate(ctx, conn, decodedTx, token)
+	if err != nil {
+		return nil, err
+	}
+	return rate.toBase(amount), nil
+}
@@ -0,0 +1,79 @@
+package simulator
+
+import (
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// chainConfig 按链注册表构建的模拟器配置：V2风格路由器的工厂和init code hash（用于CREATE2计算交易对地址），
+// 以及清算策略使用的借贷市场。为nil时表示没有任何路由器和借贷市场
+type chainConfig struct {
+	info           *types.ChainInfo
+	factories      map[common.Address]common.Address      // 路由器 -> 工厂
+	initCodeHashes map[common.Address]common.Hash         // 工厂 -> 交易对init code hash
+	lending        map[common.Address]types.LendingMarket // Pool 地址 -> 市场
+}
+
+// newChainConfig 按链注册表中的配置构建查找表
+func newChainConfig(info *types.ChainInfo) *chainConfig {
+	c := &chainConfig{
+		info:           info,
+		factories:      make(map[common.Address]common.Address),
+		initCodeHashes: make(map[common.Address]common.Hash),
+		lending:        make(map[common.Address]types.LendingMarket, len(info.Lending)),
+	}
+	for _, router := range info.Routers {
+		if router.Version != types.RouterV2 {
+			continue
+		}
+		c.factories[router.Address] = router.Factory
+		c.initCodeHashes[router.Factory] = router.InitCodeHash
+	}
+	for _, market := range info.Lending {
+		c.lending[market.Pool] = market
+	}
+	return c
+}
+
+// factory 路由器对应的V2工厂，非V2风格路由器返回false
+func (c *chainConfig) factory(router common.Address) (common.Address, bool) {
+	if c == nil {
+		return common.Address{}, false
+	}
+	factory, exists := c.factories[router]
+	return factory, exists
+}
+
+// pairAddress 通过CREATE2计算Uniswap V2风格交易对地址
+func (c *chainConfig) pairAddress(key pairKey) (common.Address, bool) {
+	if c == nil {
+		return common.Address{}, false
+	}
+	initCodeHash, exists := c.initCodeHashes[key.factory]
+	if !exists {
+		return common.Address{}, false
+	}
+
+	salt := crypto.Keccak256(key.token0.Bytes(), key.token1.Bytes())
+	hash := crypto.Keccak256([]byte{0xff}, key.factory.Bytes(), salt, initCodeHash.Bytes())
+	return common.BytesToAddress(hash[12:]), true
+}
+
+// lendingMarket 按 Pool 地址查找借贷市场
+func (c *chainConfig) lendingMarket(pool common.Address) (types.LendingMarket, bool) {
+	if c == nil {
+		return types.LendingMarket{}, false
+	}
+	market, exists := c.lending[pool]
+	return market, exists
+}
+
+// lendingMarkets 链上全部借贷市场
+func (c *chainConfig) lendingMarkets() []types.LendingMarket {
+	if c == nil {
+		return nil
+	}
+	return c.info.Lending
+}
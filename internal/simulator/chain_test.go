@@ -0,0 +1,117 @@
+package simulator
+
+import (
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// testChain 测试用的链配置
+func testChain(t *testing.T, chainID int64) *chainConfig {
+	t.Helper()
+	info, exists := types.LookupChain(chainID)
+	if !exists {
+		t.Fatalf("chain %d not registered", chainID)
+	}
+	return newChainConfig(&info)
+}
+
+func TestPairAddressPerChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		chainID int64
+		router  string
+		tokenA  string
+		tokenB  string
+		want    string
+	}{
+		{
+			name:    "Uniswap V2 WETH/USDC",
+			chainID: 1,
+			router:  "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+			tokenA:  "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+			tokenB:  "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+			want:    "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc",
+		},
+		{
+			name:    "PancakeSwap V2 WBNB/BUSD",
+			chainID: 56,
+			router:  "0x10ED43C718714eb63d5aA57B78B54704E256024E",
+			tokenA:  "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
+			tokenB:  "0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56",
+			want:    "0x58F876857a02D6762E0101bb5C46A8c1ED44Dc16",
+		},
+		{
+			name:    "QuickSwap WMATIC/USDC",
+			chainID: 137,
+			router:  "0xa5E0829CaCEd8fFDD4De3c43696c57F7D7A678ff",
+			tokenA:  "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270",
+			tokenB:  "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174",
+			want:    "0x6e7a5FAFcec6BB1e78bAE2A1F0B612012BF14827",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := testChain(t, tt.chainID)
+			factory, ok := chain.factory(common.HexToAddress(tt.router))
+			if !ok {
+				t.Fatalf("factory(%s) not found", tt.router)
+			}
+			// 代币顺序不影响交易对地址
+			for _, key := range []pairKey{
+				newPairKey(factory, common.HexToAddress(tt.tokenA), common.HexToAddress(tt.tokenB)),
+				newPairKey(factory, common.HexToAddress(tt.tokenB), common.HexToAddress(tt.tokenA)),
+			} {
+				pair, ok := chain.pairAddress(key)
+				if !ok || pair != common.HexToAddress(tt.want) {
+					t.Errorf("pairAddress() = %s, %v; want %s", pair.Hex(), ok, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestChainConfigIsolatesChains(t *testing.T) {
+	mainnetRouter := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	v3Router := common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564")
+
+	for _, chainID := range types.SupportedChainIDs() {
+		chain := testChain(t, chainID)
+		info := chain.info
+
+		if _, ok := chain.factory(mainnetRouter); ok != (chainID == 1) {
+			t.Errorf("chain %d: factory(mainnet Uniswap V2) found = %v", chainID, ok)
+		}
+		// V3 路由器不按储备定价，没有工厂
+		if _, ok := chain.factory(v3Router); ok {
+			t.Errorf("chain %d: V3 router resolved to a V2 factory", chainID)
+		}
+		for _, router := range info.Routers {
+			if _, ok := chain.factory(router.Address); ok != (router.Version == types.RouterV2) {
+				t.Errorf("chain %d: factory(%s) found = %v", chainID, router.Name, ok)
+			}
+		}
+		for _, market := range info.Lending {
+			if _, ok := chain.lendingMarket(market.Pool); !ok {
+				t.Errorf("chain %d: lending market %s not resolved", chainID, market.Pool.Hex())
+			}
+		}
+		if _, ok := types.WrappedNativeTokens[chainID]; !ok {
+			t.Errorf("chain %d has no wrapped native token", chainID)
+		}
+	}
+
+	// 每个有包装原生代币的链都已注册，配置校验不会拒绝它们
+	for chainID := range types.WrappedNativeTokens {
+		if _, ok := types.LookupChain(chainID); !ok {
+			t.Errorf("wrapped native token for unregistered chain %d", chainID)
+		}
+	}
+
+	var none *chainConfig
+	if _, ok := none.factory(mainnetRouter); ok {
+		t.Error("nil chainConfig resolved a factory")
+	}
+}
@@ -0,0 +1,140 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 盈利估算口径（决定 NetProfit 取哪一个估算值）
+const (
+	ProfitEstimateOptimistic  = "optimistic"  // 假设没有竞争交易
+	ProfitEstimatePessimistic = "pessimistic" // 假设同一交易对上的同向pending交换先成交
+)
+
+// competingSwap 近期内存池中的一笔V2交换
+type competingSwap struct {
+	pair     pairKey
+	tokenIn  common.Address
+	amountIn *big.Int
+	seen     time.Time
+}
+
+// competitionTracker 按交易哈希记录近期的V2交换，用于估算与受害者竞争的同向成交量
+type competitionTracker struct {
+	mu    sync.Mutex
+	swaps map[common.Hash]competingSwap
+}
+
+// observe 记录一笔V2交换（factory 为路由器对应的工厂；同一交易重复记录只保留一条），并清理窗口外的记录
+func (c *competitionTracker) observe(decodedTx *types.DecodedTransaction, factory common.Address, window time.Duration) {
+	if window <= 0 || !decodedTx.IsSwap || len(decodedTx.Path) < 2 || decodedTx.AmountIn == nil || decodedTx.AmountIn.Sign() <= 0 {
+		return
+	}
+	path := poolPath(decodedTx)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.swaps == nil {
+		c.swaps = make(map[common.Hash]competingSwap)
+	}
+	for hash, swap := range c.swaps {
+		if now.Sub(swap.seen) > window {
+			delete(c.swaps, hash)
+		}
+	}
+	if _, seen := c.swaps[decodedTx.Transaction.Hash]; !seen {
+		c.swaps[decodedTx.Transaction.Hash] = competingSwap{
+			pair:     newPairKey(factory, path[0], path[1]),
+			tokenIn:  path[0],
+			amountIn: decodedTx.AmountIn,
+			seen:     now,
+		}
+	}
+}
+
+// volume 窗口内同一交易对上与受害者同向的其他交换的输入总量和笔数
+func (c *competitionTracker) volume(decodedTx *types.DecodedTransaction, key pairKey, tokenIn common.Address, window time.Duration) (*big.Int, int) {
+	total := new(big.Int)
+	count := 0
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for hash, swap := range c.swaps {
+		if hash == decodedTx.Transaction.Hash || swap.pair != key || swap.tokenIn != tokenIn || now.Sub(swap.seen) > window {
+			continue
+		}
+		total.Add(total, swap.amountIn)
+		count++
+	}
+	return total, count
+}
+
+// competitionCount 窗口内同一V2交易对上与受害者同向的其他pending交换笔数
+func (s *Simulator) competitionCount(decodedTx *types.DecodedTransaction, factory common.Address, window time.Duration) int {
+	if window <= 0 || len(decodedTx.Path) < 2 {
+		return 0
+	}
+	path := poolPath(decodedTx)
+	_, count := s.competition.volume(decodedTx, newPairKey(factory, path[0], path[1]), path[0], window)
+	return count
+}
+
+// competitionSettings 当前配置的竞争窗口和盈利估算口径
+func (s *Simulator) competitionSettings() (time.Duration, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.cfg == nil {
+		return 0, ProfitEstimatePessimistic
+	}
+	mode := s.cfg.ProfitEstimate
+	if mode == "" {
+		mode = ProfitEstimatePessimistic
+	}
+	return time.Duration(s.cfg.CompetitionWindowMs) * time.Millisecond, mode
+}
+
+// pessimisticProfit 假设窗口内同向的竞争交换先于我们成交后重新估算策略盈利：
+// 只适用于按第一跳V2储备估算的策略，其他策略或没有竞争交换时返回原盈利；结果不超过原盈利
+func (s *Simulator) pessimisticProfit(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction, best *strategyCandidate, window time.Duration) *big.Int {
+	factory, exists := s.chain.factory(decodedTx.TargetContract)
+	if window <= 0 || !exists || (best.name != StrategyHeuristic && best.name != StrategySandwich) {
+		return best.profit
+	}
+	if len(decodedTx.Path) < 2 || decodedTx.AmountIn == nil || decodedTx.AmountIn.Sign() <= 0 {
+		return best.profit
+	}
+
+	path := poolPath(decodedTx)
+	key := newPairKey(factory, path[0], path[1])
+	competing, _ := s.competition.volume(decodedTx, key, path[0], window)
+	if competing.Sign() == 0 {
+		return best.profit
+	}
+
+	pool, err := s.sandwichPool(ctx, conn, decodedTx)
+	if err != nil || pool == nil {
+		return best.profit
+	}
+
+	// 竞争交换先成交：输入侧储备增加，输出侧储备减少（受害者的滑点余量随之变小）
+	// 受害者会回滚时没有盈利，同样不超过原盈利（原盈利可能为负）
+	profit := big.NewInt(0)
+	if amounts := pool.after(competing).run(s.strategyInput(best.name, decodedTx), decodedTx.AmountIn); amounts != nil {
+		profit = amounts.profit()
+	}
+	if profit.Cmp(best.profit) > 0 {
+		return best.profit
+	}
+	return profit
+}
@@ -0,0 +1,75 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/config"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 悲观估算不会高于乐观估算：随机的储备、受害者规模、滑点和竞争成交量下逐一检查，
+// 并且竞争成交量越大悲观盈利越低（或相等）
+func TestPessimisticProfitNeverExceedsOptimistic(t *testing.T) {
+	const window = time.Minute
+	rng := rand.New(rand.NewSource(1261))
+	lowered := 0 // 竞争确实压低了盈利的检查次数（避免性质检查空转）
+
+	for trial := 0; trial < 50; trial++ {
+		weth := int64(100 + rng.Intn(10000))
+		conn := fakePairConn(t, eth(weth), big.NewInt(weth*2000e6))
+		s := &Simulator{chain: testChain(t, 1), cfg: &config.SniperConfig{SniperInputSize: eth(int64(1 + rng.Intn(20)))}}
+
+		victim := swapTx(new(big.Int).Div(eth(int64(1+rng.Intn(1000))), big.NewInt(10)))
+		expected := v2AmountOut(victim.AmountIn, eth(weth), big.NewInt(weth*2000e6))
+		slippageBps := int64(10 + rng.Intn(500))
+		victim.AmountOutMin = new(big.Int).Div(new(big.Int).Mul(expected, big.NewInt(10000-slippageBps)), big.NewInt(10000))
+
+		pool, err := s.sandwichPool(context.Background(), conn, victim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		factory, _ := s.chain.factory(victim.TargetContract)
+
+		for _, name := range []string{StrategySandwich, StrategyHeuristic} {
+			amounts := pool.run(s.strategyInput(name, victim), victim.AmountIn)
+			if amounts == nil {
+				continue
+			}
+			// 乐观估算的毛盈利，以及一个人为压低的盈利（检查结果同样被它封顶）
+			for _, optimistic := range []*big.Int{amounts.profit(), big.NewInt(-1)} {
+				best := &strategyCandidate{name: name, profit: optimistic}
+				s.competition = competitionTracker{}
+				previous := s.pessimisticProfit(context.Background(), conn, victim, best, window)
+				if previous.Cmp(optimistic) != 0 {
+					t.Fatalf("trial %d %s: pessimistic %s != optimistic %s without competition", trial, name, previous, optimistic)
+				}
+
+				for i := 0; i < 3; i++ {
+					competing := swapTx(new(big.Int).Div(eth(int64(1+rng.Intn(2000))), big.NewInt(10)))
+					competing.Transaction.Hash = common.BigToHash(big.NewInt(int64(i + 2)))
+					s.competition.observe(competing, factory, window)
+
+					pessimistic := s.pessimisticProfit(context.Background(), conn, victim, best, window)
+					if pessimistic.Cmp(optimistic) > 0 {
+						t.Fatalf("trial %d %s: pessimistic %s > optimistic %s after %d competing swaps", trial, name, pessimistic, optimistic, i+1)
+					}
+					if pessimistic.Cmp(previous) > 0 {
+						t.Errorf("trial %d %s: pessimistic rose from %s to %s with more competing volume", trial, name, previous, pessimistic)
+					}
+					if pessimistic.Cmp(optimistic) < 0 {
+						lowered++
+					}
+					previous = pessimistic
+				}
+			}
+		}
+	}
+	if lowered == 0 {
+		t.Error("competing swaps never lowered the estimate")
+	}
+}
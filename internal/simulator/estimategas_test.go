@@ -0,0 +1,171 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeEstimateEth 假节点的 eth_estimateGas：返回固定估算、报错或一直不返回
+type fakeEstimateEth struct {
+	gas     uint64
+	err     error
+	release chan struct{} // 不为nil时等待关闭后才返回
+
+	mu   sync.Mutex
+	args map[string]interface{}
+}
+
+func (e *fakeEstimateEth) EstimateGas(args map[string]interface{}, block *string) (hexutil.Uint64, error) {
+	e.mu.Lock()
+	e.args = args
+	e.mu.Unlock()
+	if e.release != nil {
+		<-e.release
+	}
+	return hexutil.Uint64(e.gas), e.err
+}
+
+func TestEstimateGasUnits(t *testing.T) {
+	const fallback = 21000 + 50000
+
+	tests := []struct {
+		name     string
+		eth      *fakeEstimateEth
+		want     uint64
+		failures int64
+	}{
+		{name: "uses the node estimate", eth: &fakeEstimateEth{gas: 123456}, want: 123456},
+		{name: "falls back on error", eth: &fakeEstimateEth{err: errors.New("execution reverted")}, want: fallback, failures: 1},
+		{name: "falls back on timeout", eth: &fakeEstimateEth{gas: 123456, release: make(chan struct{})}, want: fallback, failures: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rpc.NewServer()
+			if err := server.RegisterName("eth", tt.eth); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(server.Stop)
+			if tt.eth.release != nil {
+				t.Cleanup(func() { close(tt.eth.release) })
+			}
+			conn := &rpcConn{client: ethclient.NewClient(rpc.DialInProc(server))}
+
+			s := &Simulator{cfg: &config.SniperConfig{SimulationTimeout: 1}}
+			router := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+			from := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+			decodedTx := &types.DecodedTransaction{Transaction: &types.Transaction{
+				Hash:  common.HexToHash("0x01"),
+				From:  from,
+				To:    &router,
+				Value: big.NewInt(1e18),
+				Data:  []byte{0x7f, 0xf3, 0x6a, 0xb5},
+			}}
+
+			start := time.Now()
+			if got := s.estimateGasUnits(context.Background(), conn, decodedTx); got != tt.want {
+				t.Errorf("estimateGasUnits() = %d, want %d", got, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("estimateGasUnits() took %v, want it bounded by SimulationTimeout (1s)", elapsed)
+			}
+			stats := s.GetStats()
+			if stats["gas_estimate_fails"] != tt.failures || stats["gas_estimated"] != 1-tt.failures {
+				t.Errorf("stats = gas_estimated %v, gas_estimate_fails %v", stats["gas_estimated"], stats["gas_estimate_fails"])
+			}
+
+			// 调用按受害者交易重建：From、To、Value、Data
+			tt.eth.mu.Lock()
+			args := tt.eth.args
+			tt.eth.mu.Unlock()
+			if common.HexToAddress(args["from"].(string)) != from || common.HexToAddress(args["to"].(string)) != router ||
+				args["value"] != "0xde0b6b3a7640000" || (args["input"] != "0x7ff36ab5" && args["data"] != "0x7ff36ab5") {
+				t.Errorf("eth_estimateGas args = %v", args)
+			}
+		})
+	}
+}
+
+// gasNode 同时提供交易对储备、eth_estimateGas 和区块头的假节点
+type gasNode struct {
+	*fakeLendingEth
+	*fakeEstimateEth
+	*fakeFeeEth
+}
+
+func TestGasSafetyMultiplierAppliedToCost(t *testing.T) {
+	const estimate = 100001
+	gasPrice := gwei(10)
+
+	simulate := func(t *testing.T, multiplier float64) *types.ProfitAnalysis {
+		t.Helper()
+		server := rpc.NewServer()
+		node := &gasNode{
+			fakeLendingEth:  fakePairEth(t, eth(1000), big.NewInt(2e12)),
+			fakeEstimateEth: &fakeEstimateEth{gas: estimate},
+			fakeFeeEth:      &fakeFeeEth{headBaseFee: gwei(5)},
+		}
+		if err := server.RegisterName("eth", node); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(server.Stop)
+		conn := &rpcConn{client: ethclient.NewClient(rpc.DialInProc(server))}
+
+		s := &Simulator{chain: testChain(t, 1), failures: make(map[string]int64), latestBlock: 100, cfg: &config.SniperConfig{
+			SniperInputSize:     eth(1),
+			GasSafetyMultiplier: multiplier,
+			Strategies:          map[string]float64{StrategySandwich: 1},
+		}}
+		victim := swapTx(eth(10))
+		victim.Transaction.Value = big.NewInt(0)
+		victim.Transaction.GasPrice = gasPrice
+
+		analysis := s.simulate(context.Background(), conn, victim)
+		if analysis == nil {
+			t.Fatalf("simulate() = nil, failures %v", s.failures)
+		}
+		return analysis
+	}
+
+	base := simulate(t, 1)
+	tests := []struct {
+		name       string
+		multiplier float64
+		gasUsed    uint64
+	}{
+		{name: "disabled", multiplier: 1, gasUsed: estimate},
+		{name: "rounds up", multiplier: 1.2, gasUsed: 120002}, // 100001 × 1.2 = 120001.2
+		{name: "doubled", multiplier: 2, gasUsed: 200002},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := simulate(t, tt.multiplier)
+			if analysis.GasUsed != tt.gasUsed {
+				t.Errorf("GasUsed = %d, want %d", analysis.GasUsed, tt.gasUsed)
+			}
+			wantCost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(tt.gasUsed))
+			if analysis.GasCost.Cmp(wantCost) != 0 {
+				t.Errorf("GasCost = %s, want %s", analysis.GasCost, wantCost)
+			}
+			// 毛盈利不受影响，净盈利恰好少了放大部分的Gas成本
+			if analysis.Profit.Cmp(base.Profit) != 0 {
+				t.Errorf("Profit = %s, want %s regardless of the multiplier", analysis.Profit, base.Profit)
+			}
+			extra := new(big.Int).Sub(wantCost, base.GasCost)
+			if got := new(big.Int).Sub(base.NetProfit, analysis.NetProfit); got.Cmp(extra) != 0 {
+				t.Errorf("NetProfit lowered by %s, want the extra gas cost %s", got, extra)
+			}
+		})
+	}
+}
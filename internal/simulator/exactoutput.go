@@ -0,0 +1,53 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+
+	"mempool-sniper/pkg/types"
+)
+
+// resolveExactOutput 精确输出交换（exactOutput/swap*ForExact*）的实际输入按 getAmountsIn 沿路径反推，
+// 第一跳交易对先计入我们的抢跑买入；所需输入超过 amountInMax 时受害者交易会回滚（wouldRevert）。
+// 未回滚时返回 AmountIn 替换为实际输入的副本，非V2路由的交换原样返回
+func (s *Simulator) resolveExactOutput(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (*types.DecodedTransaction, bool, error) {
+	factory, exists := s.chain.factory(decodedTx.TargetContract)
+	if !exists || !decodedTx.ExactOutput || len(decodedTx.Path) < 2 || decodedTx.AmountInMax == nil || decodedTx.AmountOutMin == nil {
+		return decodedTx, false, nil
+	}
+
+	path := poolPath(decodedTx)
+	amount := decodedTx.AmountOutMin
+	for i := len(path) - 1; i > 0; i-- {
+		key := newPairKey(factory, path[i-1], path[i])
+		reserves, err := s.getReserves(ctx, conn, key)
+		if err != nil {
+			return nil, false, err
+		}
+		reserveIn, reserveOut := reserves.reserve0, reserves.reserve1
+		if key.token0 != path[i-1] {
+			reserveIn, reserveOut = reserveOut, reserveIn
+		}
+
+		if i == 1 {
+			// 我们的买入在受害者之前成交，受害者面对的是抢跑后的储备
+			ourIn := s.sniperInput(decodedTx)
+			ourOut := v2AmountOut(ourIn, reserveIn, reserveOut)
+			reserveIn = new(big.Int).Add(reserveIn, ourIn)
+			reserveOut = new(big.Int).Sub(reserveOut, ourOut)
+		}
+
+		amount = v2AmountIn(amount, reserveIn, reserveOut)
+		if amount == nil {
+			return nil, true, nil
+		}
+	}
+
+	if amount.Cmp(decodedTx.AmountInMax) > 0 {
+		return nil, true, nil
+	}
+
+	resolved := *decodedTx
+	resolved.AmountIn = amount
+	return &resolved, false, nil
+}
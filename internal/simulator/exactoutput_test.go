@@ -0,0 +1,118 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"mempool-sniper/internal/config"
+)
+
+func bigString(t *testing.T, s string) *big.Int {
+	t.Helper()
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("invalid integer fixture %q", s)
+	}
+	return n
+}
+
+func TestV2AmountIn(t *testing.T) {
+	tests := []struct {
+		name       string
+		amountOut  *big.Int
+		reserveIn  *big.Int
+		reserveOut *big.Int
+		want       string // 空表示储备不足（nil）
+	}{
+		// 100000*1000*1000 / (99000*997) = 1013.1...，向上取整为 1014
+		{name: "rounds up", amountOut: big.NewInt(1000), reserveIn: big.NewInt(100000), reserveOut: big.NewInt(100000), want: "1014"},
+		{name: "1 ETH out of a 1000:2000 pool", amountOut: eth(1), reserveIn: eth(1000), reserveOut: eth(2000), want: "501755391236239986"},
+		{name: "whole reserve", amountOut: eth(2000), reserveIn: eth(1000), reserveOut: eth(2000)},
+		{name: "more than the reserve", amountOut: eth(3000), reserveIn: eth(1000), reserveOut: eth(2000)},
+		{name: "zero output", amountOut: big.NewInt(0), reserveIn: eth(1000), reserveOut: eth(2000)},
+		{name: "empty input reserve", amountOut: eth(1), reserveIn: big.NewInt(0), reserveOut: eth(2000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := v2AmountIn(tt.amountOut, tt.reserveIn, tt.reserveOut)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("v2AmountIn() = %s, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.String() != tt.want {
+				t.Fatalf("v2AmountIn() = %v, want %s", got, tt.want)
+			}
+			// 反推的输入恰好够：按 getAmountOut 正向计算不少于目标输出，少1 wei 则不够
+			if out := v2AmountOut(got, tt.reserveIn, tt.reserveOut); out.Cmp(tt.amountOut) < 0 {
+				t.Errorf("v2AmountOut(%s) = %s, short of %s", got, out, tt.amountOut)
+			}
+			less := new(big.Int).Sub(got, big.NewInt(1))
+			if out := v2AmountOut(less, tt.reserveIn, tt.reserveOut); out.Cmp(tt.amountOut) >= 0 {
+				t.Errorf("v2AmountOut(%s) = %s, one wei less already reaches %s", less, out, tt.amountOut)
+			}
+		})
+	}
+}
+
+func TestResolveExactOutputChecksAmountInMax(t *testing.T) {
+	// WETH/USDC 1000 ETH : 2,000,000 USDC，我们先买入 1 ETH，受害者要精确换出 19,000 USDC
+	reserveWETH, reserveUSDC := eth(1000), big.NewInt(2e12)
+	amountOut := big.NewInt(19000e6)
+	// 抢跑后储备 (1001 ETH, 2e12-1992013962) 下 getAmountIn 的结果
+	required := bigString(t, "9639288381169716799")
+
+	tests := []struct {
+		name        string
+		amountOut   *big.Int
+		amountInMax *big.Int
+		revert      bool
+	}{
+		{name: "cap covers the input", amountOut: amountOut, amountInMax: eth(10)},
+		{name: "cap exactly the input", amountOut: amountOut, amountInMax: required},
+		{name: "cap one wei short", amountOut: amountOut, amountInMax: new(big.Int).Sub(required, big.NewInt(1)), revert: true},
+		{name: "output exceeds the reserve", amountOut: reserveUSDC, amountInMax: eth(1000000), revert: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := fakePairConn(t, reserveWETH, reserveUSDC)
+			s := &Simulator{chain: testChain(t, 1), cfg: &config.SniperConfig{SniperInputSize: eth(1)}}
+
+			decodedTx := swapTx(tt.amountInMax)
+			decodedTx.ExactOutput = true
+			decodedTx.AmountInMax = tt.amountInMax
+			decodedTx.AmountOutMin = tt.amountOut
+
+			resolved, revert, err := s.resolveExactOutput(context.Background(), conn, decodedTx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revert != tt.revert {
+				t.Fatalf("wouldRevert = %v, want %v", revert, tt.revert)
+			}
+			if tt.revert {
+				return
+			}
+			if resolved.AmountIn.Cmp(required) != 0 {
+				t.Errorf("resolved AmountIn = %s, want %s", resolved.AmountIn, required)
+			}
+			if decodedTx.AmountIn != tt.amountInMax {
+				t.Error("resolveExactOutput modified the decoded transaction in place")
+			}
+		})
+	}
+}
+
+func TestResolveExactOutputIgnoresExactInput(t *testing.T) {
+	s := &Simulator{chain: testChain(t, 1)}
+	decodedTx := swapTx(eth(1))
+	decodedTx.AmountOutMin = big.NewInt(1e6)
+
+	// 精确输入交换不查询储备，原样返回
+	resolved, revert, err := s.resolveExactOutput(context.Background(), nil, decodedTx)
+	if err != nil || revert || resolved != decodedTx {
+		t.Errorf("resolveExactOutput() = %p, %v, %v; want the input unchanged", resolved, revert, err)
+	}
+}
@@ -0,0 +1,110 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 模拟失败类型
+const (
+	FailureRPC     = "rpc"     // 连接/节点错误
+	FailureTimeout = "timeout" // 超时
+	FailureRevert  = "revert"  // 调用被回滚
+	FailureDecode  = "decode"  // 返回值或交易数据无法解析
+	FailureOther   = "other"   // 其他
+)
+
+// FailureKinds 所有失败类型（用于统计输出）
+var FailureKinds = []string{FailureRPC, FailureTimeout, FailureRevert, FailureDecode, FailureOther}
+
+// errInvalidResponse 合约返回值无法解析
+var errInvalidResponse = errors.New("invalid contract response")
+
+// errInvalidTransaction 解码后的交易数据不完整
+var errInvalidTransaction = errors.New("invalid decoded transaction")
+
+// JSON-RPC 错误码
+const (
+	revertErrorCode = 3      // 执行回滚（附带回滚数据）
+	serverErrorCode = -32000 // 节点通用错误（Geth 对不带回滚数据的回滚也使用该错误码）
+)
+
+// revertMessage 不带回滚数据时 Geth 返回的错误消息（vm.ErrExecutionReverted）
+const revertMessage = "execution reverted"
+
+// isExecutionReverted 错误是否为合约执行回滚（而不是网络或节点错误）：只按节点返回的 JSON-RPC 错误判断，
+// 错误码 3，或 -32000 且附带回滚数据或消息恰为 revertMessage
+func isExecutionReverted(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	switch rpcErr.ErrorCode() {
+	case revertErrorCode:
+		return true
+	case serverErrorCode:
+		var dataErr rpc.DataError
+		if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+			return true
+		}
+		return rpcErr.Error() == revertMessage
+	}
+	return false
+}
+
+// classifyFailure 将模拟错误归类（context.Canceled 是主动停止，不应记录为失败，见 recordFailure）
+func classifyFailure(err error) string {
+	if err == nil {
+		return FailureOther
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return FailureTimeout
+	}
+	// 节点返回空结果（null）或连接被对端关闭都是节点侧问题
+	if errors.Is(err, rpc.ErrNoResult) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return FailureRPC
+	}
+	if errors.Is(err, errInvalidResponse) || errors.Is(err, errInvalidTransaction) {
+		return FailureDecode
+	}
+
+	if isExecutionReverted(err) {
+		return FailureRevert
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return FailureRPC
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return FailureTimeout
+		}
+		return FailureRPC
+	}
+	if errors.Is(err, rpc.ErrClientQuit) || errors.Is(err, net.ErrClosed) {
+		return FailureRPC
+	}
+
+	return FailureOther
+}
+
+// recordFailure 记录一次模拟失败并按类型计数（停机或交易被取消导致的 context.Canceled 不计）
+func (s *Simulator) recordFailure(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	kind := classifyFailure(err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed++
+	s.failures[kind]++
+}
@@ -0,0 +1,74 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// jsonRPCError 节点返回的 JSON-RPC 错误
+type jsonRPCError struct {
+	code    int
+	message string
+}
+
+func (e jsonRPCError) Error() string  { return e.message }
+func (e jsonRPCError) ErrorCode() int { return e.code }
+
+// jsonRPCDataError 附带 data 字段的 JSON-RPC 错误
+type jsonRPCDataError struct {
+	jsonRPCError
+	data interface{}
+}
+
+func (e jsonRPCDataError) ErrorData() interface{} { return e.data }
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "deadline", err: fmt.Errorf("call: %w", context.DeadlineExceeded), want: FailureTimeout},
+		{name: "no result", err: rpc.ErrNoResult, want: FailureRPC},
+		{name: "wrapped no result", err: fmt.Errorf("getReserves: %w", rpc.ErrNoResult), want: FailureRPC},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, want: FailureRPC},
+		{name: "connection closed", err: io.ErrUnexpectedEOF, want: FailureRPC},
+		{name: "client quit", err: rpc.ErrClientQuit, want: FailureRPC},
+		{name: "revert with data", err: jsonRPCDataError{jsonRPCError{code: 3, message: "execution reverted: K"}, "0x08c379a0"}, want: FailureRevert},
+		{name: "wrapped revert", err: fmt.Errorf("getAmountsOut: %w", jsonRPCError{code: 3, message: "execution reverted"}), want: FailureRevert},
+		{name: "revert without data", err: jsonRPCError{code: -32000, message: "execution reverted"}, want: FailureRevert},
+		{name: "server error with revert data", err: jsonRPCDataError{jsonRPCError{code: -32000, message: "reverted"}, "0x"}, want: FailureRevert},
+		{name: "node error", err: jsonRPCError{code: -32000, message: "header not found"}, want: FailureRPC},
+		{name: "node error mentioning revert", err: jsonRPCError{code: -32603, message: "internal error: revert in tracer"}, want: FailureRPC},
+		{name: "server error containing revert", err: jsonRPCError{code: -32000, message: "tracer reverted: out of memory"}, want: FailureRPC},
+		{name: "local error mentioning revert", err: errors.New("execution reverted"), want: FailureOther},
+		{name: "invalid response", err: fmt.Errorf("%w: short result", errInvalidResponse), want: FailureDecode},
+		{name: "unknown", err: errors.New("boom"), want: FailureOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyFailure(tt.err); got != tt.want {
+				t.Errorf("classifyFailure(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordFailureSkipsCanceled(t *testing.T) {
+	s := &Simulator{failures: make(map[string]int64)}
+	s.recordFailure(fmt.Errorf("eth_call: %w", context.Canceled))
+	if s.failed != 0 || len(s.failures) != 0 {
+		t.Errorf("canceled call recorded: failed = %d, failures = %v", s.failed, s.failures)
+	}
+
+	s.recordFailure(rpc.ErrNoResult)
+	if s.failed != 1 || s.failures[FailureRPC] != 1 {
+		t.Errorf("failed = %d, failures = %v, want one rpc failure", s.failed, s.failures)
+	}
+}
@@ -0,0 +1,137 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Gas计费模型名称
+const (
+	GasModelAuto    = "auto"    // 按链ID选择
+	GasModelL1      = "l1"      // 只有执行Gas（以太坊主网等）
+	GasModelOPStack = "opstack" // OP Stack：执行Gas + L1数据费
+)
+
+// FeeModel 链的Gas计费模型：在执行Gas成本之外追加链特有的费用
+type FeeModel interface {
+	Name() string
+	// L1DataFee 估算交易的L1数据费（wei），不适用时返回0
+	L1DataFee(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error)
+}
+
+// feeModels 已实现的计费模型
+var feeModels = map[string]FeeModel{
+	GasModelL1:      l1FeeModel{},
+	GasModelOPStack: opStackFeeModel{},
+}
+
+// chainFeeModels 按链ID自动选择的计费模型（未列出的链使用 l1）
+var chainFeeModels = map[int64]string{
+	10:   GasModelOPStack, // Optimism
+	8453: GasModelOPStack, // Base
+}
+
+// feeModelFor 获取交易适用的计费模型
+func (s *Simulator) feeModelFor(decodedTx *types.DecodedTransaction) FeeModel {
+	s.mu.RLock()
+	name := GasModelAuto
+	if s.cfg != nil && s.cfg.GasModel != "" {
+		name = s.cfg.GasModel
+	}
+	s.mu.RUnlock()
+
+	if name == GasModelAuto {
+		name = GasModelL1
+		if chainID := decodedTx.Transaction.ChainID; chainID != nil && chainID.IsInt64() {
+			if model, exists := chainFeeModels[chainID.Int64()]; exists {
+				name = model
+			}
+		}
+	}
+	if model, exists := feeModels[name]; exists {
+		return model
+	}
+	return l1FeeModel{}
+}
+
+// l1FeeModel 以太坊主网：没有额外费用
+type l1FeeModel struct{}
+
+func (l1FeeModel) Name() string { return GasModelL1 }
+
+func (l1FeeModel) L1DataFee(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+// OP Stack GasPriceOracle 预部署合约及 getL1Fee(bytes) 方法签名
+var (
+	OPGasPriceOracle = common.HexToAddress("0x420000000000000000000000000000000000000F")
+	methodGetL1Fee   = []byte{0x49, 0x94, 0x8e, 0x0e}
+)
+
+// opStackFeeModel OP Stack：通过 GasPriceOracle.getL1Fee 估算L1数据费
+// （以受害者交易的序列化大小近似我们的交易，签名已包含在内，结果略偏保守）
+type opStackFeeModel struct{}
+
+func (opStackFeeModel) Name() string { return GasModelOPStack }
+
+func (opStackFeeModel) L1DataFee(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error) {
+	payload := decodedTx.Transaction.Data
+	if raw := decodedTx.Transaction.RawTx; raw != nil {
+		if encoded, err := raw.MarshalBinary(); err == nil {
+			payload = encoded
+		}
+	}
+
+	result, err := conn.client.CallContract(ctx, ethereum.CallMsg{To: &OPGasPriceOracle, Data: encodeBytesCall(methodGetL1Fee, payload)}, nil)
+	if err != nil {
+		conn.fail(err)
+		return nil, err
+	}
+	if len(result) < 32 {
+		return nil, fmt.Errorf("%w: getL1Fee返回无效", errInvalidResponse)
+	}
+	return new(big.Int).SetBytes(result[:32]), nil
+}
+
+// encodeBytesCall ABI编码只有一个 bytes 参数的调用
+func encodeBytesCall(selector, data []byte) []byte {
+	padded := (len(data) + 31) / 32 * 32
+	encoded := make([]byte, 0, 4+64+padded)
+	encoded = append(encoded, selector...)
+	encoded = append(encoded, common.LeftPadBytes(big.NewInt(32).Bytes(), 32)...)
+	encoded = append(encoded, common.LeftPadBytes(big.NewInt(int64(len(data))).Bytes(), 32)...)
+	encoded = append(encoded, data...)
+	return append(encoded, make([]byte, padded-len(data))...)
+}
+
+// estimateGas 按链的计费模型估算Gas成本：执行Gas × Gas价格 + L1数据费
+// L1数据费查询失败时返回错误（L2上数据费通常占成本大头，缺失会把亏损机会当成盈利）
+func (s *Simulator) estimateGas(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction, gasUsed uint64, gasPrice *big.Int) (*types.GasEstimation, error) {
+	estimation := &types.GasEstimation{
+		GasUsed:   gasUsed,
+		GasPrice:  gasPrice,
+		TotalCost: new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasUsed)),
+		L1DataFee: big.NewInt(0),
+	}
+
+	model := s.feeModelFor(decodedTx)
+	l1Fee, err := model.L1DataFee(ctx, conn, decodedTx)
+	if err != nil {
+		s.mu.Lock()
+		s.l1FeeFailures++
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%s 计费模型估算L1数据费失败: %w", model.Name(), err)
+	}
+	if l1Fee.Sign() > 0 {
+		estimation.L1DataFee = l1Fee
+		estimation.TotalCost.Add(estimation.TotalCost, l1Fee)
+	}
+	return estimation, nil
+}
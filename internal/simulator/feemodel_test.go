@@ -0,0 +1,85 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeOracleEth 假节点的 eth_call：模拟 OP Stack GasPriceOracle.getL1Fee
+type fakeOracleEth struct {
+	l1Fee *big.Int // 为nil时调用失败
+	calls int
+}
+
+func (e *fakeOracleEth) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	e.calls++
+	if to, _ := args["to"].(string); common.HexToAddress(to) != OPGasPriceOracle {
+		return nil, errors.New("unexpected call target")
+	}
+	if e.l1Fee == nil {
+		return nil, errors.New("oracle unavailable")
+	}
+	return common.LeftPadBytes(e.l1Fee.Bytes(), 32), nil
+}
+
+func TestEstimateGasAddsL1DataFee(t *testing.T) {
+	tests := []struct {
+		name      string
+		chainID   int64
+		l1Fee     *big.Int
+		wantCost  *big.Int
+		wantErr   bool
+		wantCalls int
+	}{
+		// 100000 Gas × 1 gwei = 0.0001 ETH
+		{name: "mainnet has no L1 fee", chainID: 1, l1Fee: gwei(1), wantCost: gwei(100000), wantCalls: 0},
+		{name: "OP Stack adds L1 fee", chainID: 10, l1Fee: gwei(50000), wantCost: gwei(150000), wantCalls: 1},
+		{name: "OP Stack oracle failure", chainID: 8453, wantErr: true, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eth := &fakeOracleEth{l1Fee: tt.l1Fee}
+			server := rpc.NewServer()
+			if err := server.RegisterName("eth", eth); err != nil {
+				t.Fatal(err)
+			}
+			defer server.Stop()
+			conn := &rpcConn{client: ethclient.NewClient(rpc.DialInProc(server))}
+
+			s := &Simulator{}
+			decodedTx := &types.DecodedTransaction{Transaction: &types.Transaction{
+				Hash:    common.HexToHash("0x01"),
+				Data:    []byte{0x7f, 0xf3, 0x6a, 0xb5},
+				ChainID: big.NewInt(tt.chainID),
+			}}
+			estimation, err := s.estimateGas(context.Background(), conn, decodedTx, 100000, gwei(1))
+			if eth.calls != tt.wantCalls {
+				t.Errorf("getL1Fee called %d times, want %d", eth.calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("estimateGas() = %v, want an error", estimation.TotalCost)
+				}
+				if s.l1FeeFailures != 1 {
+					t.Errorf("l1FeeFailures = %d, want 1", s.l1FeeFailures)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if estimation.TotalCost.Cmp(tt.wantCost) != 0 {
+				t.Errorf("TotalCost = %s, want %s", estimation.TotalCost, tt.wantCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Gas定价方式
+const (
+	GasPricingAuto    = "auto"    // 按最新区块头是否有基础费用判断
+	GasPricingEIP1559 = "eip1559" // 强制按EIP-1559计价
+	GasPricingLegacy  = "legacy"  // 强制按传统Gas价格计价（链不支持EIP-1559）
+)
+
+// dynamicFeeTxType EIP-1559 交易类型（maxFeePerGas/maxPriorityFeePerGas，之后的类型同样适用）
+const dynamicFeeTxType = 2
+
+// defaultGasPrice 无法获取任何Gas价格时使用的默认值 (30 Gwei)
+var defaultGasPrice = big.NewInt(30000000000)
+
+// feeRPCTimeout 刷新基础费用缓存和探测EIP-1559的RPC超时（查询不持有缓存锁，超时只影响本次查询的交易）
+const feeRPCTimeout = 3 * time.Second
+
+// gasPricing 受害者交易的有效Gas价格及其组成（传统交易的基础费用和小费为nil）
+type gasPricing struct {
+	price       *big.Int
+	baseFee     *big.Int
+	priorityFee *big.Int
+	legacy      bool // 链不支持EIP-1559，我们的交易也只能按传统交易发送
+}
+
+// feeCache 按区块缓存 eth_feeHistory 得到的下一区块基础费用，
+// 同一区块内复用；新区块订阅滞后时超过最大缓存时间也会强制刷新。
+// mu 只保护缓存字段，RPC在锁外通过 group 合并，节点卡住不会阻塞读取缓存和统计
+type feeCache struct {
+	mu        sync.Mutex
+	group     singleflight.Group
+	block     uint64
+	fetchedAt time.Time
+	baseFee   *big.Int
+	hits      int64
+	refreshes int64
+
+	latest atomic.Pointer[big.Int] // 最近一次查询到的基础费用（热路径无锁读取）
+
+	eip1559 *bool // 链是否启用EIP-1559（auto 模式下探测一次后缓存）
+}
+
+// baseFee 获取下一区块的基础费用（缓存）
+func (s *Simulator) baseFee(ctx context.Context, conn *rpcConn) (*big.Int, error) {
+	s.mu.RLock()
+	head := s.latestBlock
+	maxAge := time.Duration(0)
+	if s.cfg != nil {
+		maxAge = time.Duration(s.cfg.FeeCacheMaxAgeMs) * time.Millisecond
+	}
+	s.mu.RUnlock()
+
+	cache := &s.fees
+	if baseFee, ok := cache.cached(head, maxAge); ok {
+		return baseFee, nil
+	}
+
+	// 同一区块的并发刷新合并为一次查询
+	result, err, _ := cache.group.Do(fmt.Sprintf("base_fee:%d", head), func() (interface{}, error) {
+		callCtx, cancel := context.WithTimeout(ctx, feeRPCTimeout)
+		defer cancel()
+
+		history, err := conn.client.FeeHistory(callCtx, 1, nil, nil)
+		if err != nil {
+			conn.fail(err)
+			return nil, err
+		}
+		// BaseFee 最后一个元素为下一区块的基础费用
+		if len(history.BaseFee) == 0 {
+			return nil, fmt.Errorf("%w: eth_feeHistory 未返回基础费用", errInvalidResponse)
+		}
+		baseFee := history.BaseFee[len(history.BaseFee)-1]
+		cache.store(head, baseFee)
+		return baseFee, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*big.Int), nil
+}
+
+// cached 读取仍然有效的缓存（命中时计数）
+func (c *feeCache) cached(head uint64, maxAge time.Duration) (*big.Int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.baseFee == nil || c.block != head || (maxAge > 0 && time.Since(c.fetchedAt) >= maxAge) {
+		return nil, false
+	}
+	c.hits++
+	return c.baseFee, true
+}
+
+// store 写入查询结果（较早区块的查询晚返回时不覆盖较新的缓存）
+func (c *feeCache) store(head uint64, baseFee *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refreshes++
+	if c.baseFee != nil && head < c.block {
+		return
+	}
+	c.baseFee = baseFee
+	c.block = head
+	c.fetchedAt = time.Now()
+	c.latest.Store(baseFee)
+}
+
+// supportsEIP1559 判断链是否启用EIP-1559：GAS_PRICING 指定时直接使用配置，
+// auto 时按最新区块头是否有基础费用判断（结果缓存；探测失败时按支持处理，下次重新探测）
+func (s *Simulator) supportsEIP1559(ctx context.Context, conn *rpcConn) bool {
+	s.mu.RLock()
+	mode := GasPricingAuto
+	if s.cfg != nil && s.cfg.GasPricing != "" {
+		mode = s.cfg.GasPricing
+	}
+	s.mu.RUnlock()
+
+	switch mode {
+	case GasPricingEIP1559:
+		return true
+	case GasPricingLegacy:
+		return false
+	}
+
+	cache := &s.fees
+	cache.mu.Lock()
+	probed := cache.eip1559
+	cache.mu.Unlock()
+	if probed != nil {
+		return *probed
+	}
+
+	// 探测在锁外进行，并发探测合并为一次查询
+	result, err, _ := cache.group.Do("eip1559", func() (interface{}, error) {
+		callCtx, cancel := context.WithTimeout(ctx, feeRPCTimeout)
+		defer cancel()
+
+		header, err := conn.client.HeaderByNumber(callCtx, nil)
+		if err != nil {
+			conn.fail(err)
+			return nil, err
+		}
+		supported := header.BaseFee != nil
+		cache.mu.Lock()
+		cache.eip1559 = &supported
+		cache.mu.Unlock()
+		if !supported {
+			logger.Info("最新区块头没有基础费用，链不支持EIP-1559，按传统Gas价格计价")
+		}
+		return supported, nil
+	})
+	if err != nil {
+		logger.Warn("探测EIP-1559支持失败，暂按支持处理", "error", err)
+		return true
+	}
+	return result.(bool)
+}
+
+// effectiveGasPrice 计算受害者交易的有效Gas价格：EIP-1559 交易为
+// min(maxFeePerGas, baseFee + maxPriorityFeePerGas)，传统交易直接使用 gasPrice
+// （London之后 tx.GasPrice() 对1559交易返回的是 maxFeePerGas，会高估成本）
+func (s *Simulator) effectiveGasPrice(ctx context.Context, conn *rpcConn, tx *types.Transaction) gasPricing {
+	london := s.supportsEIP1559(ctx, conn)
+	if !london || tx.RawTx == nil || tx.Type < dynamicFeeTxType {
+		pricing := s.legacyGasPrice(ctx, conn, tx, london)
+		pricing.legacy = !london
+		return pricing
+	}
+
+	feeCap := tx.RawTx.GasFeeCap()
+	tip := tx.RawTx.GasTipCap()
+	baseFee, err := s.baseFee(ctx, conn)
+	if err != nil {
+		// 基础费用未知时按上限计价，宁可高估成本
+		logger.Warn("获取基础费用失败，按 maxFeePerGas 计价", "tx_hash", tx.Hash.Hex(), "error", err)
+		return gasPricing{price: feeCap, priorityFee: tip}
+	}
+
+	price := new(big.Int).Add(baseFee, tip)
+	if price.Cmp(feeCap) > 0 {
+		price.Set(feeCap)
+	}
+	priorityFee := new(big.Int).Sub(price, baseFee)
+	if priorityFee.Sign() < 0 {
+		priorityFee.SetInt64(0)
+	}
+	return gasPricing{price: price, baseFee: baseFee, priorityFee: priorityFee}
+}
+
+// legacyGasPrice 传统交易的Gas价格：没有Gas价格时使用下一区块基础费用（按区块缓存；
+// 不支持EIP-1559的链使用 eth_gasPrice），获取失败时使用默认值
+func (s *Simulator) legacyGasPrice(ctx context.Context, conn *rpcConn, tx *types.Transaction, london bool) gasPricing {
+	if tx.GasPrice != nil && tx.GasPrice.Sign() > 0 {
+		return gasPricing{price: tx.GasPrice}
+	}
+	if london {
+		if baseFee, err := s.baseFee(ctx, conn); err == nil {
+			return gasPricing{price: baseFee}
+		}
+		return gasPricing{price: defaultGasPrice}
+	}
+	gasPrice, err := conn.client.SuggestGasPrice(ctx)
+	if err != nil {
+		conn.fail(err)
+		return gasPricing{price: defaultGasPrice}
+	}
+	return gasPricing{price: gasPrice}
+}
+
+// feeStats 基础费用缓存统计
+func (s *Simulator) feeStats() map[string]interface{} {
+	cache := &s.fees
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"hits":      cache.hits,
+		"refreshes": cache.refreshes,
+	}
+	if cache.eip1559 != nil {
+		stats["eip1559"] = *cache.eip1559
+	}
+	if cache.baseFee != nil {
+		stats["base_fee"] = cache.baseFee.String()
+		stats["block"] = cache.block
+		stats["age_ms"] = time.Since(cache.fetchedAt).Milliseconds()
+	}
+	return stats
+}
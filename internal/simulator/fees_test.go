@@ -0,0 +1,281 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeFeeEth 假节点的 eth 命名空间：基础费用、区块头和Gas价格，并统计调用次数
+type fakeFeeEth struct {
+	mu          sync.Mutex
+	nextBaseFee *big.Int      // eth_feeHistory 返回的下一区块基础费用
+	headBaseFee *big.Int      // 最新区块头的基础费用（nil 表示不支持EIP-1559的链）
+	gasPrice    *big.Int      // eth_gasPrice
+	hold        chan struct{} // 非nil时 eth_feeHistory 等待其关闭后才返回
+
+	feeHistoryCalls int
+	headerCalls     int
+}
+
+func (e *fakeFeeEth) FeeHistory(count hexutil.Uint64, newest string, percentiles []float64) (map[string]interface{}, error) {
+	e.mu.Lock()
+	e.feeHistoryCalls++
+	hold, baseFee := e.hold, e.nextBaseFee
+	e.mu.Unlock()
+
+	if hold != nil {
+		<-hold
+	}
+	return map[string]interface{}{
+		"oldestBlock":   (*hexutil.Big)(big.NewInt(1)),
+		"baseFeePerGas": []*hexutil.Big{(*hexutil.Big)(baseFee), (*hexutil.Big)(baseFee)},
+		"gasUsedRatio":  []float64{0.5},
+	}, nil
+}
+
+func (e *fakeFeeEth) GetBlockByNumber(number string, full bool) (map[string]interface{}, error) {
+	e.mu.Lock()
+	e.headerCalls++
+	baseFee := e.headBaseFee
+	e.mu.Unlock()
+
+	header := &ethtypes.Header{
+		Number:      big.NewInt(100),
+		Difficulty:  new(big.Int),
+		BaseFee:     baseFee,
+		UncleHash:   ethtypes.EmptyUncleHash,
+		TxHash:      ethtypes.EmptyTxsHash,
+		ReceiptHash: ethtypes.EmptyReceiptsHash,
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	var block map[string]interface{}
+	err = json.Unmarshal(encoded, &block)
+	return block, err
+}
+
+func (e *fakeFeeEth) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(e.gasPrice)
+}
+
+func (e *fakeFeeEth) calls() (int, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.feeHistoryCalls, e.headerCalls
+}
+
+func fakeFeeConn(t *testing.T, eth *fakeFeeEth) *rpcConn {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", eth); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	return &rpcConn{client: ethclient.NewClient(rpc.DialInProc(server))}
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9))
+}
+
+func TestBaseFeeCachedPerBlock(t *testing.T) {
+	eth := &fakeFeeEth{nextBaseFee: gwei(20)}
+	conn := fakeFeeConn(t, eth)
+	s := &Simulator{latestBlock: 100}
+
+	for i := 0; i < 3; i++ {
+		baseFee, err := s.baseFee(context.Background(), conn)
+		if err != nil || baseFee.Cmp(gwei(20)) != 0 {
+			t.Fatalf("baseFee() = %v, %v; want 20 gwei", baseFee, err)
+		}
+	}
+	if calls, _ := eth.calls(); calls != 1 {
+		t.Errorf("eth_feeHistory called %d times within one block, want 1", calls)
+	}
+	if s.fees.hits != 2 {
+		t.Errorf("hits = %d, want 2", s.fees.hits)
+	}
+
+	// 新区块：重新查询
+	eth.mu.Lock()
+	eth.nextBaseFee = gwei(25)
+	eth.mu.Unlock()
+	s.latestBlock = 101
+	baseFee, err := s.baseFee(context.Background(), conn)
+	if err != nil || baseFee.Cmp(gwei(25)) != 0 {
+		t.Fatalf("baseFee() on a new head = %v, %v; want 25 gwei", baseFee, err)
+	}
+	if calls, _ := eth.calls(); calls != 2 {
+		t.Errorf("eth_feeHistory called %d times after a new head, want 2", calls)
+	}
+}
+
+func TestBaseFeeRefreshDoesNotBlockCacheReaders(t *testing.T) {
+	eth := &fakeFeeEth{nextBaseFee: gwei(20), hold: make(chan struct{})}
+	conn := fakeFeeConn(t, eth)
+	s := &Simulator{latestBlock: 100}
+
+	// 并发刷新合并为一次查询
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.baseFee(context.Background(), conn); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// 查询卡住期间统计和缓存读取不被阻塞
+	deadline := time.Now().Add(time.Second)
+	for calls, _ := eth.calls(); calls == 0 && time.Now().Before(deadline); calls, _ = eth.calls() {
+		time.Sleep(time.Millisecond)
+	}
+	done := make(chan struct{})
+	go func() {
+		s.feeStats()
+		s.cachedBaseFee()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("feeStats() blocked while eth_feeHistory was in flight")
+	}
+
+	close(eth.hold)
+	wg.Wait()
+	if calls, _ := eth.calls(); calls != 1 {
+		t.Errorf("eth_feeHistory called %d times for concurrent misses, want 1", calls)
+	}
+}
+
+func TestEffectiveGasPriceByHeaderShape(t *testing.T) {
+	dynamicTx := func(feeCap, tip *big.Int) *types.Transaction {
+		raw := ethtypes.NewTx(&ethtypes.DynamicFeeTx{GasFeeCap: feeCap, GasTipCap: tip})
+		return &types.Transaction{RawTx: raw, Type: ethtypes.DynamicFeeTxType, GasPrice: feeCap}
+	}
+	legacyTx := func(gasPrice *big.Int) *types.Transaction {
+		raw := ethtypes.NewTx(&ethtypes.LegacyTx{GasPrice: gasPrice})
+		return &types.Transaction{RawTx: raw, Type: ethtypes.LegacyTxType, GasPrice: gasPrice}
+	}
+
+	tests := []struct {
+		name        string
+		headBaseFee *big.Int // nil: 区块头没有基础费用（链不支持EIP-1559）
+		tx          *types.Transaction
+		want        *big.Int
+		wantBaseFee bool
+	}{
+		{name: "1559 header, 1559 tx pays base fee plus tip", headBaseFee: gwei(18), tx: dynamicTx(gwei(100), gwei(2)), want: gwei(22), wantBaseFee: true},
+		{name: "1559 header, 1559 tx capped by maxFee", headBaseFee: gwei(18), tx: dynamicTx(gwei(21), gwei(2)), want: gwei(21), wantBaseFee: true},
+		{name: "1559 header, legacy tx pays gasPrice", headBaseFee: gwei(18), tx: legacyTx(gwei(30)), want: gwei(30)},
+		{name: "1559 header, legacy tx without gasPrice uses base fee", headBaseFee: gwei(18), tx: legacyTx(new(big.Int)), want: gwei(20)},
+		{name: "legacy header, legacy tx pays gasPrice", tx: legacyTx(gwei(30)), want: gwei(30)},
+		{name: "legacy header, legacy tx without gasPrice uses eth_gasPrice", tx: legacyTx(new(big.Int)), want: gwei(7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eth := &fakeFeeEth{nextBaseFee: gwei(20), headBaseFee: tt.headBaseFee, gasPrice: gwei(7)}
+			conn := fakeFeeConn(t, eth)
+			s := &Simulator{latestBlock: 100}
+
+			for i := 0; i < 2; i++ {
+				pricing := s.effectiveGasPrice(context.Background(), conn, tt.tx)
+				if pricing.price.Cmp(tt.want) != 0 {
+					t.Fatalf("price = %s, want %s", pricing.price, tt.want)
+				}
+				if (pricing.baseFee != nil) != tt.wantBaseFee {
+					t.Errorf("baseFee = %v, want set = %v", pricing.baseFee, tt.wantBaseFee)
+				}
+			}
+			// 区块头只探测一次
+			if _, headers := eth.calls(); headers != 1 {
+				t.Errorf("eth_getBlockByNumber called %d times, want 1", headers)
+			}
+			if got, want := s.supportsEIP1559(context.Background(), conn), tt.headBaseFee != nil; got != want {
+				t.Errorf("supportsEIP1559() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEstimateGasCostSelectsFeeModel(t *testing.T) {
+	const gasUsed = 100000
+	to := common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	transaction := func(raw *ethtypes.Transaction) *types.Transaction {
+		return &types.Transaction{Hash: raw.Hash(), To: &to, ChainID: big.NewInt(1), RawTx: raw, Type: raw.Type(), GasPrice: raw.GasPrice()}
+	}
+
+	tests := []struct {
+		name        string
+		tx          *types.Transaction
+		noBaseFee   bool // 链不支持EIP-1559（区块头没有基础费用）
+		price       *big.Int
+		baseFee     *big.Int // nil 表示按传统交易计价
+		priorityFee *big.Int
+	}{
+		{name: "legacy", tx: transaction(ethtypes.NewTx(&ethtypes.LegacyTx{GasPrice: gwei(30)})), price: gwei(30)},
+		{name: "access list", tx: transaction(ethtypes.NewTx(&ethtypes.AccessListTx{GasPrice: gwei(25)})), price: gwei(25)},
+		{name: "dynamic fee", tx: transaction(ethtypes.NewTx(&ethtypes.DynamicFeeTx{GasFeeCap: gwei(100), GasTipCap: gwei(2)})),
+			price: gwei(22), baseFee: gwei(20), priorityFee: gwei(2)},
+		{name: "dynamic fee capped", tx: transaction(ethtypes.NewTx(&ethtypes.DynamicFeeTx{GasFeeCap: gwei(21), GasTipCap: gwei(2)})),
+			price: gwei(21), baseFee: gwei(20), priorityFee: gwei(1)},
+		{name: "dynamic fee without the raw transaction", tx: &types.Transaction{Hash: common.HexToHash("0x01"), To: &to, ChainID: big.NewInt(1), Type: ethtypes.DynamicFeeTxType, GasPrice: gwei(100)},
+			price: gwei(100)},
+		{name: "dynamic fee on a chain without base fee", tx: transaction(ethtypes.NewTx(&ethtypes.DynamicFeeTx{GasFeeCap: gwei(100), GasTipCap: gwei(2)})),
+			noBaseFee: true, price: gwei(100)},
+		{name: "legacy on a chain without base fee", tx: transaction(ethtypes.NewTx(&ethtypes.LegacyTx{GasPrice: gwei(30)})),
+			noBaseFee: true, price: gwei(30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eth := &fakeFeeEth{nextBaseFee: gwei(20), headBaseFee: gwei(18)}
+			if tt.noBaseFee {
+				eth.headBaseFee = nil
+			}
+			conn := fakeFeeConn(t, eth)
+			s := &Simulator{chain: testChain(t, 1), latestBlock: 100}
+
+			estimation, err := s.estimateGasCost(context.Background(), conn, &types.DecodedTransaction{Transaction: tt.tx}, gasUsed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if estimation.GasPrice.Cmp(tt.price) != 0 {
+				t.Errorf("GasPrice = %s, want %s", estimation.GasPrice, tt.price)
+			}
+			if totalCost := new(big.Int).Mul(tt.price, big.NewInt(gasUsed)); estimation.TotalCost.Cmp(totalCost) != 0 {
+				t.Errorf("TotalCost = %s, want %s", estimation.TotalCost, totalCost)
+			}
+			if estimation.Legacy != tt.noBaseFee {
+				t.Errorf("Legacy = %v, want %v", estimation.Legacy, tt.noBaseFee)
+			}
+			if !equalFee(estimation.BaseFee, tt.baseFee) || !equalFee(estimation.PriorityFee, tt.priorityFee) {
+				t.Errorf("BaseFee = %v, PriorityFee = %v; want %v, %v", estimation.BaseFee, estimation.PriorityFee, tt.baseFee, tt.priorityFee)
+			}
+		})
+	}
+}
+
+// equalFee 比较可能为nil的费用
+func equalFee(got, want *big.Int) bool {
+	if got == nil || want == nil {
+		return got == nil && want == nil
+	}
+	return got.Cmp(want) == 0
+}
@@ -0,0 +1,141 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// acquire 开始一次模拟调用，连接池已退役时返回false（调用方应改用当前连接池）
+func (p *connPool) acquire() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.retired {
+		return false
+	}
+	p.inflight++
+	return true
+}
+
+// release 结束一次模拟调用
+func (p *connPool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inflight--
+	if p.retired && p.inflight == 0 {
+		p.drained.Broadcast()
+	}
+}
+
+// retire 停止接受新调用，等待进行中的调用结束后关闭所有连接
+func (p *connPool) retire() {
+	p.mu.Lock()
+	p.retired = true
+	for p.inflight > 0 {
+		p.drained.Wait()
+	}
+	clients := p.clients
+	p.clients = make([]*ethclient.Client, len(clients))
+	p.mu.Unlock()
+
+	for _, client := range clients {
+		if client != nil {
+			client.Close()
+		}
+	}
+}
+
+// currentPool 获取当前连接池
+func (s *Simulator) currentPool() *connPool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pool
+}
+
+// leaseConn 为工作线程之外的调用（执行前复核、过期检查、RawCall、阈值换算）借用当前连接池的主连接，
+// 用完后必须调用 release：热切换后旧连接池要等借出的连接全部归还才关闭
+func (s *Simulator) leaseConn() (*rpcConn, func()) {
+	for {
+		s.mu.RLock()
+		pool, client := s.pool, s.client
+		s.mu.RUnlock()
+
+		if pool == nil {
+			return &rpcConn{client: client}, func() {}
+		}
+		// 读取后连接池刚好被热切换退役时重新读取
+		if pool.acquire() {
+			return &rpcConn{client: client}, pool.release
+		}
+	}
+}
+
+// swapDialTimeout 热切换时连接新节点并核对链ID的超时
+const swapDialTimeout = 10 * time.Second
+
+// SwapRPC 运行时切换模拟器RPC节点：新节点链ID与当前节点一致才切换，
+// 旧连接池在进行中的调用（含借出的连接）全部结束后关闭（expectedChainID 为nil时与当前节点比较）；
+// 切换后只使用新节点，不再轮换启动时配置的候选节点
+func (s *Simulator) SwapRPC(ctx context.Context, rpcURL string, expectedChainID *big.Int) error {
+	ctx, cancel := context.WithTimeout(ctx, swapDialTimeout)
+	defer cancel()
+
+	if expectedChainID == nil {
+		conn, release := s.leaseConn()
+		if conn.client != nil {
+			chainID, err := conn.client.ChainID(ctx)
+			if err != nil {
+				release()
+				return fmt.Errorf("获取当前节点链ID失败: %w", err)
+			}
+			expectedChainID = chainID
+		}
+		release()
+	}
+
+	client, err := ethclient.DialContext(ctx, rpcURL)
+	if err != nil {
+		return fmt.Errorf("连接新RPC节点失败: %w", err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return fmt.Errorf("获取新节点链ID失败: %w", err)
+	}
+	if expectedChainID != nil && chainID.Cmp(expectedChainID) != 0 {
+		client.Close()
+		return fmt.Errorf("新节点链ID %s 与预期 %s 不一致，拒绝切换", chainID, expectedChainID)
+	}
+
+	// 新连接池在锁外建立（扩容连接需要逐个连接新节点）
+	s.mu.RLock()
+	poolSize := 1
+	if s.cfg != nil {
+		poolSize = s.cfg.RPCPoolSize
+	}
+	s.mu.RUnlock()
+	endpoints := []string{rpcURL}
+	pool := newConnPool(endpoints, 0, client, poolSize)
+
+	s.mu.Lock()
+	old := s.pool
+	s.client = client
+	s.rpcURL = rpcURL
+	s.endpoints = endpoints
+	s.endpointIdx = 0
+	s.pool = pool
+	s.rpcSwaps++
+	s.mu.Unlock()
+
+	logger.Info("模拟器RPC节点已切换，等待旧连接上的调用结束", "chain_id", chainID)
+	if old != nil {
+		go func() {
+			old.retire()
+			logger.Info("旧RPC连接池已排空并关闭")
+		}()
+	}
+	return nil
+}
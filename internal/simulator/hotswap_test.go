@@ -0,0 +1,119 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeEndpointEth 假节点的 eth 命名空间：链ID和区块号（用于区分请求打到了哪个节点）
+type fakeEndpointEth struct {
+	chainID int64
+	head    uint64
+}
+
+func (e *fakeEndpointEth) ChainId() *hexutil.Big { return (*hexutil.Big)(big.NewInt(e.chainID)) }
+
+func (e *fakeEndpointEth) BlockNumber() hexutil.Uint64 { return hexutil.Uint64(e.head) }
+
+// fakeEndpoint 启动WebSocket假节点，返回其URL（HTTP客户端的 Close 不生效，无法观察旧连接是否关闭）
+func fakeEndpoint(t *testing.T, eth *fakeEndpointEth) string {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", eth); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return "ws" + strings.TrimPrefix(httpServer.URL, "http")
+}
+
+// leasedHead 通过借出的连接查询区块号
+func leasedHead(t *testing.T, s *Simulator) uint64 {
+	t.Helper()
+	conn, release := s.leaseConn()
+	defer release()
+	head, err := conn.client.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("BlockNumber() error = %v", err)
+	}
+	return head
+}
+
+func TestSwapRPCDrainsLeasedConnections(t *testing.T) {
+	oldURL := fakeEndpoint(t, &fakeEndpointEth{chainID: 1, head: 100})
+	newURL := fakeEndpoint(t, &fakeEndpointEth{chainID: 1, head: 200})
+	info, _ := types.LookupChain(1)
+	s := NewSimulatorWithEndpoints([]string{oldURL}, &info)
+
+	// 切换前借出的连接（如进行中的执行前复核）
+	leased, release := s.leaseConn()
+	if err := s.SwapRPC(context.Background(), newURL, nil); err != nil {
+		t.Fatalf("SwapRPC() error = %v", err)
+	}
+	if got := leasedHead(t, s); got != 200 {
+		t.Errorf("new lease reached head %d, want the new endpoint's 200", got)
+	}
+
+	// 归还之前旧连接不会被关闭
+	time.Sleep(20 * time.Millisecond)
+	if head, err := leased.client.BlockNumber(context.Background()); err != nil || head != 100 {
+		t.Fatalf("leased connection after swap = %d, %v; want 100 from the old endpoint", head, err)
+	}
+	release()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err := leased.client.BlockNumber(context.Background())
+		if errors.Is(err, rpc.ErrClientQuit) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("old connection not closed after the lease was returned: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s.rpcSwaps != 1 || s.rpcURL != newURL {
+		t.Errorf("rpcSwaps = %d, rpcURL = %s; want 1, %s", s.rpcSwaps, s.rpcURL, newURL)
+	}
+}
+
+func TestSwapRPCRejectsChainIDMismatch(t *testing.T) {
+	oldURL := fakeEndpoint(t, &fakeEndpointEth{chainID: 1, head: 100})
+	bscURL := fakeEndpoint(t, &fakeEndpointEth{chainID: 56, head: 200})
+	info, _ := types.LookupChain(1)
+	s := NewSimulatorWithEndpoints([]string{oldURL}, &info)
+
+	tests := []struct {
+		name     string
+		expected *big.Int
+	}{
+		{name: "compared with current endpoint", expected: nil},
+		{name: "compared with configured chain", expected: big.NewInt(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.SwapRPC(context.Background(), bscURL, tt.expected); err == nil {
+				t.Fatal("SwapRPC() accepted an endpoint on another chain")
+			}
+			if got := leasedHead(t, s); got != 100 {
+				t.Errorf("lease after rejected swap reached head %d, want 100", got)
+			}
+			if s.rpcSwaps != 0 || s.rpcURL != oldURL {
+				t.Errorf("rpcSwaps = %d, rpcURL = %s after a rejected swap", s.rpcSwaps, s.rpcURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,566 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Aave V3 方法签名
+var (
+	methodGetUserAccountData   = []byte{0xbf, 0x92, 0x85, 0x7c} // getUserAccountData(address)
+	methodGetUserConfiguration = []byte{0x44, 0x17, 0xa5, 0x83} // getUserConfiguration(address)
+	methodGetReservesList      = []byte{0xd1, 0x94, 0x6d, 0xbc} // getReservesList()
+	methodGetReserveData       = []byte{0x35, 0xea, 0x6a, 0x75} // getReserveData(address)
+	methodGetAssetPrice        = []byte{0xb3, 0x59, 0x6f, 0x07} // getAssetPrice(address)
+	methodGetSourceOfAsset     = []byte{0x92, 0xbf, 0x2b, 0xe0} // getSourceOfAsset(address)
+	methodAggregator           = []byte{0x24, 0x5a, 0x7b, 0xfc} // aggregator()（Chainlink 价格源代理）
+	methodLatestAnswer         = []byte{0x50, 0xd2, 0x5b, 0xcd} // latestAnswer()
+	methodBalanceOf            = []byte{0x70, 0xa0, 0x82, 0x31} // balanceOf(address)
+)
+
+const (
+	aaveCloseFactorBps     = 5000
+	aaveFullCloseFactorBps = 10000
+
+	aaveReservesTTL = 10 * time.Minute // 储备配置和价格源的缓存时间
+
+	liquidationWatchTTL    = 24 * time.Hour // 借款人在观察列表中的保留时间
+	liquidationWatchMax    = 1000           // 观察列表上限，超出时淘汰最久未出现的借款人
+	liquidationPerUpdate   = 20             // 每次预言机价格更新最多重新评估的借款人数
+	aaveBonusBase          = 10000          // 储备配置中的清算奖励以 10000 为基数（10500 表示奖励5%）
+	aaveConfigThresholdBit = 16             // 储备配置位图：清算阈值所在位 (16-31)
+	aaveConfigBonusBit     = 32             // 储备配置位图：清算奖励所在位 (32-47)
+)
+
+var (
+	wad                        = big.NewInt(1e18)
+	aaveCloseFactorHFThreshold = big.NewInt(0.95e18) // 健康因子低于该值可全额清算
+)
+
+// accountData getUserAccountData 返回值（金额以预言机基础货币计价）
+type accountData struct {
+	collateral           *big.Int
+	debt                 *big.Int
+	liquidationThreshold *big.Int // 加权清算阈值 (bps)
+	healthFactor         *big.Int // 18位精度
+}
+
+// aaveReserve 储备资产的配置
+type aaveReserve struct {
+	id                   int
+	liquidationThreshold uint64 // bps
+	liquidationBonus     uint64 // 以 10000 为基数
+	aToken               common.Address
+	stableDebt           common.Address
+	variableDebt         common.Address
+	source               common.Address // 预言机价格源
+}
+
+// marketReserves 借贷市场的储备缓存
+type marketReserves struct {
+	updated time.Time
+	reserve map[common.Address]*aaveReserve   // 资产 → 配置
+	feeds   map[common.Address]common.Address // 价格源聚合器 → 资产
+}
+
+// lendingState 清算策略的状态：最近借款/取款的头寸（预言机价格更新时重新评估）和各市场的储备缓存
+type lendingState struct {
+	mu       sync.Mutex
+	watched  map[common.Address]map[common.Address]time.Time // Pool → 借款人 → 最近出现时间
+	reserves map[common.Address]*marketReserves              // Pool → 储备缓存
+}
+
+// position 头寸（以预言机基础货币计价）
+type position struct {
+	collateral *big.Int
+	debt       *big.Int
+	threshold  *big.Int // Σ 抵押 × 清算阈值 (bps)
+}
+
+func newPosition(account *accountData) *position {
+	return &position{
+		collateral: new(big.Int).Set(account.collateral),
+		debt:       new(big.Int).Set(account.debt),
+		threshold:  new(big.Int).Mul(account.collateral, account.liquidationThreshold),
+	}
+}
+
+// healthFactor 健康因子 = Σ 抵押 × 清算阈值 / 债务（18位精度），无债务时返回nil
+func (p *position) healthFactor() *big.Int {
+	if p.debt.Sign() <= 0 {
+		return nil
+	}
+	hf := new(big.Int).Mul(p.threshold, wad)
+	hf.Div(hf, big.NewInt(10000))
+	return hf.Div(hf, p.debt)
+}
+
+// LiquidationStrategy 借贷协议清算策略（Aave V3）：待处理的 borrow/withdraw 执行后，
+// 或待处理的预言机价格更新生效后，头寸健康因子低于1时预估可获得的清算奖励
+type LiquidationStrategy struct{}
+
+func (LiquidationStrategy) Name() string { return StrategyLiquidation }
+
+func (LiquidationStrategy) Evaluate(ctx context.Context, s *Simulator, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error) {
+	if decodedTx.IsSwap || decodedTx.AmountIn == nil {
+		return nil, nil
+	}
+	if decodedTx.Method == types.MethodOracleUpdate {
+		return s.evaluateOracleUpdate(ctx, conn, decodedTx)
+	}
+
+	market, exists := s.chain.lendingMarket(decodedTx.TargetContract)
+	if !exists {
+		return nil, nil
+	}
+	s.watchBorrower(market.Pool, decodedTx.Account)
+
+	reserves, err := s.marketReserves(ctx, conn, market)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.aaveAccountData(ctx, conn, market.Pool, decodedTx.Account)
+	if err != nil {
+		return nil, err
+	}
+	price, err := s.aaveAssetPrice(ctx, conn, market.Oracle, decodedTx.TokenIn)
+	if err != nil {
+		return nil, err
+	}
+	amountBase, err := s.baseCurrencyValue(ctx, conn, decodedTx.TokenIn, decodedTx.AmountIn, price)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := newPosition(account)
+	switch decodedTx.Method {
+	case "borrow":
+		pos.debt.Add(pos.debt, amountBase)
+	case "withdraw":
+		if amountBase.Cmp(pos.collateral) > 0 {
+			amountBase.Set(pos.collateral)
+		}
+		pos.collateral.Sub(pos.collateral, amountBase)
+		if reserve, exists := reserves.reserve[decodedTx.TokenIn]; exists {
+			pos.threshold.Sub(pos.threshold, new(big.Int).Mul(amountBase, new(big.Int).SetUint64(reserve.liquidationThreshold)))
+		}
+		if pos.threshold.Sign() < 0 {
+			pos.threshold.SetInt64(0)
+		}
+	default:
+		return nil, nil
+	}
+
+	return s.liquidationProfit(ctx, conn, market, reserves, decodedTx, decodedTx.Account, pos)
+}
+
+// evaluateOracleUpdate 预言机价格更新：按新价格重新评估观察列表中持有该资产的头寸，取奖励最高的一个
+func (s *Simulator) evaluateOracleUpdate(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error) {
+	var best *big.Int
+	for _, market := range s.chain.lendingMarkets() {
+		reserves, err := s.marketReserves(ctx, conn, market)
+		if err != nil {
+			return nil, err
+		}
+		asset, exists := reserves.feeds[decodedTx.TargetContract]
+		if !exists {
+			continue
+		}
+		reserve := reserves.reserve[asset]
+
+		// 新价格按价格源当前值的比例作用于预言机价格（适配器可能对价格源的值做了换算）
+		current, err := s.latestAnswer(ctx, conn, reserve.source)
+		if err != nil {
+			return nil, err
+		}
+		if current.Sign() <= 0 {
+			continue
+		}
+		price, err := s.aaveAssetPrice(ctx, conn, market.Oracle, asset)
+		if err != nil {
+			return nil, err
+		}
+		newPrice := new(big.Int).Mul(price, decodedTx.AmountIn)
+		newPrice.Div(newPrice, current)
+
+		for _, user := range s.watchedBorrowers(market.Pool, liquidationPerUpdate) {
+			profit, err := s.repricedLiquidation(ctx, conn, market, reserves, decodedTx, user, asset, price, newPrice)
+			if err != nil {
+				return nil, err
+			}
+			if profit != nil && (best == nil || profit.Cmp(best) > 0) {
+				best = profit
+			}
+		}
+	}
+	return best, nil
+}
+
+// repricedLiquidation 资产价格由 oldPrice 变为 newPrice 后头寸的清算奖励（不可清算时返回nil）
+func (s *Simulator) repricedLiquidation(ctx context.Context, conn *rpcConn, market types.LendingMarket, reserves *marketReserves, decodedTx *types.DecodedTransaction, user, asset common.Address, oldPrice, newPrice *big.Int) (*big.Int, error) {
+	account, err := s.aaveAccountData(ctx, conn, market.Pool, user)
+	if err != nil {
+		return nil, err
+	}
+	pos := newPosition(account)
+	if pos.debt.Sign() <= 0 || oldPrice.Sign() <= 0 {
+		return nil, nil
+	}
+	reserve := reserves.reserve[asset]
+	config, err := s.aaveUserConfiguration(ctx, conn, market.Pool, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// 价格变化 = 持有数量 × (新价格 - 旧价格)
+	priceDelta := new(big.Int).Sub(newPrice, oldPrice)
+	change := func(amount *big.Int) *big.Int {
+		value := new(big.Int).Mul(amount, priceDelta)
+		return value.Div(value, oldPrice)
+	}
+
+	if usedAsCollateral(config, reserve.id) {
+		balance, err := s.tokenBalance(ctx, conn, reserve.aToken, user)
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.baseCurrencyValue(ctx, conn, asset, balance, oldPrice)
+		if err != nil {
+			return nil, err
+		}
+		delta := change(value)
+		pos.collateral.Add(pos.collateral, delta)
+		pos.threshold.Add(pos.threshold, delta.Mul(delta, new(big.Int).SetUint64(reserve.liquidationThreshold)))
+	}
+	if borrowing(config, reserve.id) {
+		debt := new(big.Int)
+		for _, token := range []common.Address{reserve.stableDebt, reserve.variableDebt} {
+			if token == (common.Address{}) {
+				continue
+			}
+			balance, err := s.tokenBalance(ctx, conn, token, user)
+			if err != nil {
+				return nil, err
+			}
+			debt.Add(debt, balance)
+		}
+		value, err := s.baseCurrencyValue(ctx, conn, asset, debt, oldPrice)
+		if err != nil {
+			return nil, err
+		}
+		pos.debt.Add(pos.debt, change(value))
+	}
+
+	return s.liquidationProfit(ctx, conn, market, reserves, decodedTx, user, pos)
+}
+
+// liquidationProfit 头寸健康因子低于1时的清算奖励（以基础资产wei计价），否则返回nil。
+// 奖励按头寸抵押资产中最低的清算奖励计算（可获得的抵押品在各资产间的分布未知，取保守值）
+func (s *Simulator) liquidationProfit(ctx context.Context, conn *rpcConn, market types.LendingMarket, reserves *marketReserves, decodedTx *types.DecodedTransaction, user common.Address, pos *position) (*big.Int, error) {
+	healthFactor := pos.healthFactor()
+	if healthFactor == nil || healthFactor.Cmp(wad) >= 0 || healthFactor.Sign() < 0 {
+		return nil, nil
+	}
+
+	config, err := s.aaveUserConfiguration(ctx, conn, market.Pool, user)
+	if err != nil {
+		return nil, err
+	}
+	bonusBps := collateralBonus(config, reserves)
+	if bonusBps == 0 {
+		return nil, nil
+	}
+
+	// 预估奖励 = 可清算债务 × 清算奖励，按包装原生代币价格折算为wei
+	bonusBase := liquidationBonus(pos.debt, healthFactor, bonusBps)
+	weth, ok := types.WrappedNative(decodedTx.Transaction.ChainID)
+	if !ok {
+		return nil, nil
+	}
+	ethPrice, err := s.aaveAssetPrice(ctx, conn, market.Oracle, weth)
+	if err != nil {
+		return nil, err
+	}
+	if ethPrice.Sign() == 0 {
+		return nil, nil
+	}
+	return bonusBase.Mul(bonusBase, wad).Div(bonusBase, ethPrice), nil
+}
+
+// liquidationBonus 可清算部分（健康因子低于0.95时全额，否则50%）× 清算奖励 (bps)
+func liquidationBonus(debt, healthFactor *big.Int, bonusBps uint64) *big.Int {
+	closeFactor := int64(aaveCloseFactorBps)
+	if healthFactor.Cmp(aaveCloseFactorHFThreshold) < 0 {
+		closeFactor = aaveFullCloseFactorBps
+	}
+	bonus := new(big.Int).Mul(debt, big.NewInt(closeFactor))
+	bonus.Mul(bonus, new(big.Int).SetUint64(bonusBps))
+	return bonus.Div(bonus, big.NewInt(10000*10000))
+}
+
+// collateralBonus 头寸抵押资产中最低的清算奖励 (bps)，没有抵押资产时返回0
+func collateralBonus(config *big.Int, reserves *marketReserves) uint64 {
+	var bonus uint64
+	for _, reserve := range reserves.reserve {
+		if !usedAsCollateral(config, reserve.id) || reserve.liquidationBonus <= aaveBonusBase {
+			continue
+		}
+		if b := reserve.liquidationBonus - aaveBonusBase; bonus == 0 || b < bonus {
+			bonus = b
+		}
+	}
+	return bonus
+}
+
+// usedAsCollateral 用户配置位图中该储备是否作为抵押（第 2*id+1 位）
+func usedAsCollateral(config *big.Int, id int) bool {
+	return config.Bit(2*id+1) == 1
+}
+
+// borrowing 用户配置位图中是否借入了该储备（第 2*id 位）
+func borrowing(config *big.Int, id int) bool {
+	return config.Bit(2*id) == 1
+}
+
+// configBits 读取储备配置位图中 [offset, offset+16) 的值
+func configBits(config *big.Int, offset uint) uint64 {
+	value := new(big.Int).Rsh(config, offset)
+	return value.And(value, big.NewInt(0xffff)).Uint64()
+}
+
+// watchBorrower 记录最近借款/取款的头寸，预言机价格更新时重新评估
+func (s *Simulator) watchBorrower(pool, user common.Address) {
+	if user == (common.Address{}) {
+		return
+	}
+	state := &s.lending
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.watched == nil {
+		state.watched = make(map[common.Address]map[common.Address]time.Time)
+	}
+	users := state.watched[pool]
+	if users == nil {
+		users = make(map[common.Address]time.Time)
+		state.watched[pool] = users
+	}
+	users[user] = time.Now()
+	if len(users) <= liquidationWatchMax {
+		return
+	}
+
+	// 淘汰过期和最久未出现的借款人
+	oldest, oldestAt := common.Address{}, time.Now()
+	for addr, seen := range users {
+		if time.Since(seen) > liquidationWatchTTL {
+			delete(users, addr)
+			continue
+		}
+		if seen.Before(oldestAt) {
+			oldest, oldestAt = addr, seen
+		}
+	}
+	if len(users) > liquidationWatchMax {
+		delete(users, oldest)
+	}
+}
+
+// watchedBorrowers 观察列表中最近出现的至多 limit 个借款人
+func (s *Simulator) watchedBorrowers(pool common.Address, limit int) []common.Address {
+	state := &s.lending
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	type entry struct {
+		user common.Address
+		seen time.Time
+	}
+	entries := make([]entry, 0, len(state.watched[pool]))
+	for user, seen := range state.watched[pool] {
+		if time.Since(seen) <= liquidationWatchTTL {
+			entries = append(entries, entry{user, seen})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seen.After(entries[j].seen) })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	users := make([]common.Address, len(entries))
+	for i, e := range entries {
+		users[i] = e.user
+	}
+	return users
+}
+
+// marketReserves 读取市场的储备配置和价格源（缓存 aaveReservesTTL）
+func (s *Simulator) marketReserves(ctx context.Context, conn *rpcConn, market types.LendingMarket) (*marketReserves, error) {
+	state := &s.lending
+	state.mu.Lock()
+	cached := state.reserves[market.Pool]
+	state.mu.Unlock()
+	if cached != nil && time.Since(cached.updated) < aaveReservesTTL {
+		return cached, nil
+	}
+
+	result, err := s.lendingCall(ctx, conn, market.Pool, methodGetReservesList)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := decodeAddressArray(result)
+	if err != nil {
+		return nil, err
+	}
+
+	reserves := &marketReserves{
+		updated: time.Now(),
+		reserve: make(map[common.Address]*aaveReserve, len(assets)),
+		feeds:   make(map[common.Address]common.Address, len(assets)),
+	}
+	for _, asset := range assets {
+		data, err := s.lendingCall(ctx, conn, market.Pool, methodGetReserveData, asset)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 32*11 {
+			return nil, fmt.Errorf("%w: 资产 %s getReserveData返回无效", errInvalidResponse, asset.Hex())
+		}
+		word := func(i int) []byte { return data[32*i : 32*(i+1)] }
+		config := new(big.Int).SetBytes(word(0))
+		reserve := &aaveReserve{
+			id:                   int(new(big.Int).SetBytes(word(7)).Uint64()),
+			liquidationThreshold: configBits(config, aaveConfigThresholdBit),
+			liquidationBonus:     configBits(config, aaveConfigBonusBit),
+			aToken:               common.BytesToAddress(word(8)),
+			stableDebt:           common.BytesToAddress(word(9)),
+			variableDebt:         common.BytesToAddress(word(10)),
+		}
+		reserves.reserve[asset] = reserve
+
+		// 价格源不是 Chainlink 代理（没有 aggregator()）时无法识别其价格更新，只跳过价格触发
+		source, err := s.lendingCall(ctx, conn, market.Oracle, methodGetSourceOfAsset, asset)
+		if err != nil || len(source) < 32 {
+			continue
+		}
+		reserve.source = common.BytesToAddress(source[:32])
+		aggregator, err := s.lendingCall(ctx, conn, reserve.source, methodAggregator)
+		if err != nil || len(aggregator) < 32 {
+			continue
+		}
+		reserves.feeds[common.BytesToAddress(aggregator[:32])] = asset
+	}
+
+	state.mu.Lock()
+	if state.reserves == nil {
+		state.reserves = make(map[common.Address]*marketReserves)
+	}
+	state.reserves[market.Pool] = reserves
+	state.mu.Unlock()
+	return reserves, nil
+}
+
+// decodeAddressArray 解码 address[] 返回值
+func decodeAddressArray(data []byte) ([]common.Address, error) {
+	if len(data) < 64 {
+		return nil, fmt.Errorf("%w: address[] 返回无效", errInvalidResponse)
+	}
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: address[] 偏移无效", errInvalidResponse)
+	}
+	start := offset.Uint64()
+	count := new(big.Int).SetBytes(data[start : start+32])
+	if !count.IsUint64() || start+32+count.Uint64()*32 > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: address[] 长度无效", errInvalidResponse)
+	}
+	addresses := make([]common.Address, count.Uint64())
+	for i := range addresses {
+		begin := start + 32 + uint64(i)*32
+		addresses[i] = common.BytesToAddress(data[begin : begin+32])
+	}
+	return addresses, nil
+}
+
+// lendingCall 调用合约的只读方法（参数均为地址）
+func (s *Simulator) lendingCall(ctx context.Context, conn *rpcConn, to common.Address, method []byte, args ...common.Address) ([]byte, error) {
+	data := append([]byte{}, method...)
+	for _, arg := range args {
+		data = append(data, common.LeftPadBytes(arg.Bytes(), 32)...)
+	}
+	result, err := conn.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		conn.fail(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// lendingWord 调用只读方法并读取第一个返回字
+func (s *Simulator) lendingWord(ctx context.Context, conn *rpcConn, to common.Address, method []byte, name string, args ...common.Address) (*big.Int, error) {
+	result, err := s.lendingCall(ctx, conn, to, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) < 32 {
+		return nil, fmt.Errorf("%w: %s %s返回无效", errInvalidResponse, to.Hex(), name)
+	}
+	return new(big.Int).SetBytes(result[:32]), nil
+}
+
+// aaveAccountData 读取头寸数据
+func (s *Simulator) aaveAccountData(ctx context.Context, conn *rpcConn, pool, user common.Address) (*accountData, error) {
+	result, err := s.lendingCall(ctx, conn, pool, methodGetUserAccountData, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) < 32*6 {
+		return nil, fmt.Errorf("%w: getUserAccountData返回无效", errInvalidResponse)
+	}
+
+	word := func(i int) *big.Int { return new(big.Int).SetBytes(result[32*i : 32*(i+1)]) }
+	return &accountData{
+		collateral:           word(0),
+		debt:                 word(1),
+		liquidationThreshold: word(3),
+		healthFactor:         word(5),
+	}, nil
+}
+
+// aaveUserConfiguration 读取用户配置位图（每个储备两位：借入、作为抵押）
+func (s *Simulator) aaveUserConfiguration(ctx context.Context, conn *rpcConn, pool, user common.Address) (*big.Int, error) {
+	return s.lendingWord(ctx, conn, pool, methodGetUserConfiguration, "getUserConfiguration", user)
+}
+
+// aaveAssetPrice 读取资产的预言机价格（基础货币计价）
+func (s *Simulator) aaveAssetPrice(ctx context.Context, conn *rpcConn, oracle, asset common.Address) (*big.Int, error) {
+	return s.lendingWord(ctx, conn, oracle, methodGetAssetPrice, "getAssetPrice", asset)
+}
+
+// latestAnswer 读取价格源的当前值
+func (s *Simulator) latestAnswer(ctx context.Context, conn *rpcConn, source common.Address) (*big.Int, error) {
+	return s.lendingWord(ctx, conn, source, methodLatestAnswer, "latestAnswer")
+}
+
+// tokenBalance 读取代币余额
+func (s *Simulator) tokenBalance(ctx context.Context, conn *rpcConn, token, holder common.Address) (*big.Int, error) {
+	return s.lendingWord(ctx, conn, token, methodBalanceOf, "balanceOf", holder)
+}
+
+// baseCurrencyValue 资产数量按预言机价格折算为基础货币
+func (s *Simulator) baseCurrencyValue(ctx context.Context, conn *rpcConn, asset common.Address, amount, price *big.Int) (*big.Int, error) {
+	decimals, err := s.tokenDecimals(ctx, conn, asset)
+	if err != nil {
+		return nil, err
+	}
+	value := new(big.Int).Mul(amount, price)
+	return value.Div(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)), nil
+}
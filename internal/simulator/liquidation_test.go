@@ -0,0 +1,182 @@
+package simulator
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	lendingMarket     = types.ChainRegistry[1].Lending[0]
+	lendingBorrower   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	lendingAToken     = common.HexToAddress("0x4d5F47FA6A74757f35C14fD3a6Ef8E3C9BC514E8")
+	lendingSource     = common.HexToAddress("0x5424384B256154046E9667dDFaaa5e550145215e")
+	lendingAggregator = common.HexToAddress("0xE62B71cf983019BFf55bC83B48601ce8419650CC")
+)
+
+// fakeLendingEth 假节点的 eth 命名空间：按 (合约, calldata) 返回预设结果
+type fakeLendingEth struct {
+	results map[string][]byte
+}
+
+func (e *fakeLendingEth) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	to, _ := args["to"].(string)
+	input, _ := args["input"].(string)
+	result, exists := e.results[strings.ToLower(to)+strings.TrimPrefix(input, "0x")]
+	if !exists {
+		return nil, errors.New("execution reverted")
+	}
+	return result, nil
+}
+
+func (e *fakeLendingEth) set(to common.Address, method []byte, args []common.Address, result []byte) {
+	data := append([]byte{}, method...)
+	for _, arg := range args {
+		data = append(data, common.LeftPadBytes(arg.Bytes(), 32)...)
+	}
+	e.results[strings.ToLower(to.Hex())+hex.EncodeToString(data)] = result
+}
+
+// lendingWords 按ABI编码为连续的32字节字
+func lendingWords(values ...*big.Int) []byte {
+	var data []byte
+	for _, value := range values {
+		data = append(data, common.LeftPadBytes(value.Bytes(), 32)...)
+	}
+	return data
+}
+
+func addressWord(address common.Address) *big.Int {
+	return new(big.Int).SetBytes(address.Bytes())
+}
+
+func e8(v int64) *big.Int { return new(big.Int).Mul(big.NewInt(v), big.NewInt(1e8)) }
+
+// fakeLendingMarket 假 Aave V3 市场：WETH（id 0，清算阈值82.5%，奖励6%）作为抵押，借入 USDC（id 1，奖励4.5%）。
+// 头寸：5 WETH @ 2000 = 10000 抵押，8000 债务，健康因子 1.03
+func fakeLendingMarket(t *testing.T) (*Simulator, *rpcConn) {
+	t.Helper()
+	eth := &fakeLendingEth{results: make(map[string][]byte)}
+	pool, oracle := lendingMarket.Pool, lendingMarket.Oracle
+
+	eth.set(pool, methodGetReservesList, nil, lendingWords(big.NewInt(32), big.NewInt(2), addressWord(traceWETH), addressWord(traceUSDC)))
+	reserve := func(id, threshold, bonus int64, aToken common.Address) []byte {
+		config := new(big.Int).Lsh(big.NewInt(bonus), aaveConfigBonusBit)
+		config.Or(config, new(big.Int).Lsh(big.NewInt(threshold), aaveConfigThresholdBit))
+		words := make([]*big.Int, 15)
+		for i := range words {
+			words[i] = new(big.Int)
+		}
+		words[0], words[7], words[8] = config, big.NewInt(id), addressWord(aToken)
+		return lendingWords(words...)
+	}
+	eth.set(pool, methodGetReserveData, []common.Address{traceWETH}, reserve(0, 8250, 10600, lendingAToken))
+	eth.set(pool, methodGetReserveData, []common.Address{traceUSDC}, reserve(1, 7800, 10450, common.Address{}))
+
+	eth.set(oracle, methodGetSourceOfAsset, []common.Address{traceWETH}, lendingWords(addressWord(lendingSource)))
+	eth.set(lendingSource, methodAggregator, nil, lendingWords(addressWord(lendingAggregator)))
+	eth.set(lendingSource, methodLatestAnswer, nil, lendingWords(e8(2000)))
+	eth.set(oracle, methodGetAssetPrice, []common.Address{traceWETH}, lendingWords(e8(2000)))
+	eth.set(oracle, methodGetAssetPrice, []common.Address{traceUSDC}, lendingWords(e8(1)))
+	eth.set(traceWETH, methodDecimals, nil, lendingWords(big.NewInt(18)))
+	eth.set(traceUSDC, methodDecimals, nil, lendingWords(big.NewInt(6)))
+
+	// 借入 USDC（第2位），WETH 作为抵押（第1位）
+	eth.set(pool, methodGetUserConfiguration, []common.Address{lendingBorrower}, lendingWords(big.NewInt(0b0110)))
+	eth.set(pool, methodGetUserAccountData, []common.Address{lendingBorrower},
+		lendingWords(e8(10000), e8(8000), e8(0), big.NewInt(8250), big.NewInt(7500), big.NewInt(1.03125e18)))
+	eth.set(lendingAToken, methodBalanceOf, []common.Address{lendingBorrower}, lendingWords(big.NewInt(5e18)))
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", eth); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	s := &Simulator{chain: testChain(t, 1), decimals: make(map[common.Address]uint8)}
+	return s, &rpcConn{client: ethclient.NewClient(rpc.DialInProc(server))}
+}
+
+func lendingTx(method string, target, token common.Address, amount *big.Int) *types.DecodedTransaction {
+	return &types.DecodedTransaction{
+		Transaction:    &types.Transaction{Hash: common.HexToHash("0x01"), To: &target, ChainID: big.NewInt(1)},
+		TargetContract: target,
+		Method:         method,
+		TokenIn:        token,
+		AmountIn:       amount,
+		Account:        lendingBorrower,
+	}
+}
+
+func TestLiquidationBorrowUsesReserveBonus(t *testing.T) {
+	s, conn := fakeLendingMarket(t)
+
+	// 再借 500 USDC：债务 8500，健康因子 0.97 → 可清算50%，奖励按 WETH 抵押的6%计算
+	profit, err := LiquidationStrategy{}.Evaluate(context.Background(), s, conn,
+		lendingTx("borrow", lendingMarket.Pool, traceUSDC, big.NewInt(500e6)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 8500 × 50% × 6% = 255，按 WETH 2000 折算为 0.1275 ETH
+	if want := big.NewInt(0.1275e18); profit == nil || profit.Cmp(want) != 0 {
+		t.Errorf("Evaluate() = %v, want %s", profit, want)
+	}
+
+	// 小额借款后仍然健康
+	profit, err = LiquidationStrategy{}.Evaluate(context.Background(), s, conn,
+		lendingTx("borrow", lendingMarket.Pool, traceUSDC, big.NewInt(100e6)))
+	if err != nil || profit != nil {
+		t.Errorf("Evaluate() = %v, %v, want nil for a healthy position", profit, err)
+	}
+}
+
+func TestLiquidationOracleUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		aggregator common.Address
+		answer     *big.Int
+		want       *big.Int
+	}{
+		// WETH 跌到 1800：抵押 9000，健康因子 0.928 → 全额清算 8000 × 6% = 480，折算为 0.24 ETH
+		{name: "price drop liquidates watched borrower", aggregator: lendingAggregator, answer: e8(1800), want: big.NewInt(0.24e18)},
+		{name: "price rise keeps position healthy", aggregator: lendingAggregator, answer: e8(2100), want: nil},
+		{name: "unknown aggregator", aggregator: traceOurs, answer: e8(1800), want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, conn := fakeLendingMarket(t)
+			s.watchBorrower(lendingMarket.Pool, lendingBorrower)
+
+			profit, err := LiquidationStrategy{}.Evaluate(context.Background(), s, conn,
+				lendingTx(types.MethodOracleUpdate, tt.aggregator, common.Address{}, tt.answer))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (profit == nil) != (tt.want == nil) || (profit != nil && profit.Cmp(tt.want) != 0) {
+				t.Errorf("Evaluate() = %v, want %v", profit, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchedBorrowersBounded(t *testing.T) {
+	s := &Simulator{}
+	for i := 0; i < liquidationWatchMax+10; i++ {
+		s.watchBorrower(lendingMarket.Pool, common.BigToAddress(big.NewInt(int64(i+1))))
+	}
+	if got := len(s.lending.watched[lendingMarket.Pool]); got != liquidationWatchMax {
+		t.Errorf("watched %d borrowers, want %d", got, liquidationWatchMax)
+	}
+	if got := s.watchedBorrowers(lendingMarket.Pool, liquidationPerUpdate); len(got) != liquidationPerUpdate {
+		t.Errorf("watchedBorrowers() returned %d, want %d", len(got), liquidationPerUpdate)
+	}
+}
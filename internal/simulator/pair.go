@@ -0,0 +1,126 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// PairCreated(address indexed token0, address indexed token1, address pair, uint)
+var pairCreatedTopic = crypto.Keccak256Hash([]byte("PairCreated(address,address,address,uint256)"))
+
+// pairKey 交易对标识（token0 < token1）
+type pairKey struct {
+	factory common.Address
+	token0  common.Address
+	token1  common.Address
+}
+
+// pairAge 交易对创建区块的检查记录
+type pairAge struct {
+	createdBlock uint64 // 创建区块（0表示检查窗口内未发现创建事件）
+	checkedUpTo  uint64 // 已检查到的区块
+}
+
+// newPairKey 构建交易对标识
+func newPairKey(factory, tokenA, tokenB common.Address) pairKey {
+	if bytes.Compare(tokenA.Bytes(), tokenB.Bytes()) > 0 {
+		tokenA, tokenB = tokenB, tokenA
+	}
+	return pairKey{factory: factory, token0: tokenA, token1: tokenB}
+}
+
+// poolPath 用于查询交易对的交换路径（原生代币转换为链的包装代币）
+func poolPath(decodedTx *types.DecodedTransaction) []common.Address {
+	return types.PoolPath(decodedTx.Path, decodedTx.Transaction.ChainID)
+}
+
+// hasYoungPair 检查交换路径上是否存在创建不足N个区块的交易对
+func (s *Simulator) hasYoungPair(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) bool {
+	s.mu.RLock()
+	head := s.latestBlock
+	minConfirmations := uint64(0)
+	if s.cfg != nil {
+		minConfirmations = s.cfg.PairMinConfirmations
+	}
+	s.mu.RUnlock()
+
+	if minConfirmations == 0 || head == 0 {
+		return false
+	}
+
+	factory, exists := s.chain.factory(decodedTx.TargetContract)
+	if !exists {
+		return false
+	}
+
+	path := poolPath(decodedTx)
+	for i := 0; i+1 < len(path); i++ {
+		key := newPairKey(factory, path[i], path[i+1])
+		created, err := s.pairCreationBlock(ctx, conn, key, head, minConfirmations)
+		if err != nil {
+			conn.fail(err)
+			// 无法确认交易对年龄时，按低可信度处理
+			logger.Warn("查询交易对创建区块失败", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+			return true
+		}
+		if created > 0 && head-created < minConfirmations {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pairCreationBlock 查询交易对在最近窗口内的创建区块（增量检查，结果缓存）
+func (s *Simulator) pairCreationBlock(ctx context.Context, conn *rpcConn, key pairKey, head, window uint64) (uint64, error) {
+	s.pairMu.Lock()
+	age, exists := s.pairAges[key]
+	s.pairMu.Unlock()
+
+	if exists && (age.createdBlock > 0 || age.checkedUpTo >= head) {
+		return age.createdBlock, nil
+	}
+
+	// 只检查尚未覆盖的区块范围
+	fromBlock := uint64(0)
+	if head >= window {
+		fromBlock = head - window + 1
+	}
+	if exists && age.checkedUpTo+1 > fromBlock {
+		fromBlock = age.checkedUpTo + 1
+	}
+
+	logs, err := conn.client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(head),
+		Addresses: []common.Address{key.factory},
+		Topics: [][]common.Hash{
+			{pairCreatedTopic},
+			{common.BytesToHash(key.token0.Bytes())},
+			{common.BytesToHash(key.token1.Bytes())},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	created := uint64(0)
+	for _, vLog := range logs {
+		if !vLog.Removed {
+			created = vLog.BlockNumber
+		}
+	}
+
+	s.pairMu.Lock()
+	s.pairAges[key] = &pairAge{createdBlock: created, checkedUpTo: head}
+	s.pairMu.Unlock()
+
+	return created, nil
+}
@@ -0,0 +1,203 @@
+package simulator
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcConn 工作线程固定使用的RPC连接
+type rpcConn struct {
+	slot   int
+	client *ethclient.Client
+	failed bool // 连接层面出错，需要重新绑定
+}
+
+// fail 记录RPC错误；JSON-RPC错误说明连接正常，只有传输层错误才视为连接故障
+func (c *rpcConn) fail(err error) {
+	var rpcErr rpc.Error
+	if err != nil && !errors.As(err, &rpcErr) {
+		c.failed = true
+	}
+}
+
+// connPool RPC连接池，每个工作线程固定绑定一个连接以提高节点侧缓存命中率
+type connPool struct {
+	mu      sync.RWMutex
+	clients []*ethclient.Client
+	dialing []bool
+	repins  int64
+
+	endpoints []string // 候选RPC节点（连接故障时按顺序轮换）
+	active    int      // 当前使用的候选节点序号（新建和重建的连接都连到该节点）
+	slotEndpt []int    // 每个连接所连的候选节点序号
+	failovers int64    // 切换到其他候选节点的次数
+
+	inflight int        // 进行中的模拟调用数
+	retired  bool       // 已被热切换替代，不再接受新调用
+	drained  *sync.Cond // 退役后进行中的调用全部结束
+}
+
+// newConnPool 创建连接池，primary（连接到 endpoints[active]）作为第0个连接
+func newConnPool(endpoints []string, active int, primary *ethclient.Client, size int) *connPool {
+	p := &connPool{
+		clients:   []*ethclient.Client{primary},
+		dialing:   []bool{false},
+		endpoints: endpoints,
+		active:    active,
+		slotEndpt: []int{active},
+	}
+	p.drained = sync.NewCond(&p.mu)
+	p.grow(size)
+	return p
+}
+
+// grow 将连接池扩容到 size 个连接，新连接连到当前节点（创建失败的连接空缺，由工作线程触发重建）
+func (p *connPool) grow(size int) {
+	p.mu.RLock()
+	from, active := len(p.clients), p.active
+	p.mu.RUnlock()
+
+	added := make([]*ethclient.Client, 0, max(size-from, 0))
+	for i := from; i < size; i++ {
+		client, err := ethclient.Dial(p.endpoints[active])
+		if err != nil {
+			logger.Warn("连接池连接创建失败", "slot", i, "error", err)
+		}
+		added = append(added, client)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, client := range added {
+		if p.retired {
+			if client != nil {
+				client.Close()
+			}
+			continue
+		}
+		p.clients = append(p.clients, client)
+		p.dialing = append(p.dialing, false)
+		p.slotEndpt = append(p.slotEndpt, active)
+	}
+}
+
+// pin 为工作线程绑定连接（按工作线程ID取模）
+func (p *connPool) pin(workerID int) *rpcConn {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.pickLocked(workerID % len(p.clients))
+}
+
+// repin 当前连接故障时重新绑定到下一个可用连接，并在后台重建故障连接；
+// 故障连接连的是当前节点时先切换到下一个候选节点，重建的连接不再连回故障节点
+func (p *connPool) repin(conn *rpcConn) *rpcConn {
+	p.mu.Lock()
+	p.repins++
+	if p.slotEndpt[conn.slot] == p.active {
+		p.failoverLocked()
+	}
+	if p.clients[conn.slot] == conn.client {
+		p.clients[conn.slot] = nil
+	}
+	if !p.dialing[conn.slot] {
+		p.dialing[conn.slot] = true
+		go p.redial(conn.slot, conn.client)
+	}
+	next := p.pickLocked((conn.slot + 1) % len(p.clients))
+	p.mu.Unlock()
+
+	logger.Warn("RPC连接故障，工作线程重新绑定", "slot", conn.slot, "next_slot", next.slot)
+	return next
+}
+
+// reconnect 在后台重建空缺的连接（已在重建或连接池已退役时不处理）：
+// 重建失败后连接会一直空缺，等待连接的工作线程通过它再次触发重建
+func (p *connPool) reconnect(slot int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.retired || p.clients[slot] != nil || p.dialing[slot] {
+		return
+	}
+	p.dialing[slot] = true
+	go p.redial(slot, nil)
+}
+
+// pickLocked 从start开始查找第一个可用连接（调用方需持有锁）
+func (p *connPool) pickLocked(start int) *rpcConn {
+	for i := 0; i < len(p.clients); i++ {
+		slot := (start + i) % len(p.clients)
+		if p.clients[slot] != nil {
+			return &rpcConn{slot: slot, client: p.clients[slot]}
+		}
+	}
+	return &rpcConn{slot: start}
+}
+
+// failoverLocked 切换到下一个候选节点（只有一个节点时不切换，调用方需持有锁）
+func (p *connPool) failoverLocked() {
+	if len(p.endpoints) <= 1 {
+		return
+	}
+	p.active = (p.active + 1) % len(p.endpoints)
+	p.failovers++
+	logger.Warn("模拟器切换RPC节点", "endpoint", p.active+1, "endpoints", len(p.endpoints))
+}
+
+// redial 重建故障连接：连接当前节点失败时切换到下一个候选节点再试，每个节点最多尝试一次
+func (p *connPool) redial(slot int, old *ethclient.Client) {
+	if old != nil {
+		old.Close()
+	}
+
+	var client *ethclient.Client
+	var err error
+	for attempt := 0; attempt < max(len(p.endpoints), 1); attempt++ {
+		p.mu.RLock()
+		active := p.active
+		p.mu.RUnlock()
+
+		client, err = ethclient.Dial(p.endpoints[active])
+		if err == nil {
+			p.mu.Lock()
+			p.slotEndpt[slot] = active
+			p.mu.Unlock()
+			break
+		}
+
+		p.mu.Lock()
+		if p.active == active {
+			p.failoverLocked()
+		}
+		p.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dialing[slot] = false
+	if err != nil {
+		logger.Error("连接池连接重建失败", "slot", slot, "error", err)
+		return
+	}
+	if p.retired {
+		client.Close()
+		return
+	}
+	p.clients[slot] = client
+}
+
+// activeEndpoint 当前使用的候选节点
+func (p *connPool) activeEndpoint() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.endpoints[p.active]
+}
+
+// size 连接池大小
+func (p *connPool) size() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.clients)
+}
@@ -0,0 +1,97 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func TestPinConnWaitsForRedial(t *testing.T) {
+	// 连接池中唯一的连接空缺（启动后重建失败的情形）：工作线程应触发重建并等待，而不是退出
+	pool := newConnPool([]string{"http://127.0.0.1:1"}, 0, nil, 1)
+	s := &Simulator{pool: pool}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pinned, conn := s.pinConn(ctx, 0)
+	if conn == nil || conn.client == nil {
+		t.Fatal("pinConn() returned no connection")
+	}
+	if pinned != pool {
+		t.Error("pinConn() returned a different pool")
+	}
+}
+
+func TestPinConnStopsWithContext(t *testing.T) {
+	// 已退役的连接池不会重建连接，只有 ctx 结束才返回
+	pool := newConnPool([]string{"http://127.0.0.1:1"}, 0, nil, 1)
+	pool.retired = true
+	s := &Simulator{pool: pool}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if _, conn := s.pinConn(ctx, 0); conn != nil {
+		t.Fatalf("pinConn() = %+v, want nil after the context ends", conn)
+	}
+}
+
+// startNumberedNode 启动只返回固定区块号的HTTP测试节点
+func startNumberedNode(t *testing.T, number uint64) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": hexutil.Uint64(number)})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConnPoolFailsOverToNextEndpoint(t *testing.T) {
+	first := startNumberedNode(t, 1)
+	second := startNumberedNode(t, 2)
+	primary, err := ethclient.Dial(first.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := newConnPool([]string{first.URL, second.URL}, 0, primary, 1)
+	s := &Simulator{pool: pool}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, conn := s.pinConn(ctx, 0)
+	if number, err := conn.client.BlockNumber(ctx); err != nil || number != 1 {
+		t.Fatalf("BlockNumber() = %d, %v; want the first endpoint", number, err)
+	}
+
+	// 第一个节点宕机：传输层错误触发重新绑定，连接池切换到第二个节点并重建连接
+	first.Close()
+	_, err = conn.client.BlockNumber(ctx)
+	conn.fail(err)
+	if !conn.failed {
+		t.Fatalf("BlockNumber() error %v not treated as a connection failure", err)
+	}
+	pool.repin(conn)
+
+	_, conn = s.pinConn(ctx, 0)
+	if conn == nil {
+		t.Fatal("pinConn() returned no connection after failover")
+	}
+	if number, err := conn.client.BlockNumber(ctx); err != nil || number != 2 {
+		t.Fatalf("BlockNumber() = %d, %v after failover; want the second endpoint", number, err)
+	}
+	if pool.activeEndpoint() != second.URL || pool.failovers != 1 {
+		t.Errorf("active endpoint = %s after %d failovers, want %s after 1", pool.activeEndpoint(), pool.failovers, second.URL)
+	}
+}
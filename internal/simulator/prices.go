@@ -0,0 +1,188 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+
+	"mempool-sniper/pkg/types"
+)
+
+// priceDecimals 价格字符串保留的小数位数
+const priceDecimals = 18
+
+// v2AmountOut Uniswap V2 getAmountOut（0.3%手续费）
+func v2AmountOut(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+	if amountIn.Sign() <= 0 || reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return new(big.Int)
+	}
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(997))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(1000))
+	denominator.Add(denominator, amountInWithFee)
+	return numerator.Div(numerator, denominator)
+}
+
+// v2AmountIn Uniswap V2 getAmountIn（0.3%手续费，向上取整）：得到 amountOut 需要的最少输入，
+// 储备不足以输出 amountOut 时返回nil（路由合约会以 INSUFFICIENT_LIQUIDITY 回滚）
+func v2AmountIn(amountOut, reserveIn, reserveOut *big.Int) *big.Int {
+	if amountOut.Sign() <= 0 || reserveIn.Sign() <= 0 || amountOut.Cmp(reserveOut) >= 0 {
+		return nil
+	}
+	numerator := new(big.Int).Mul(reserveIn, amountOut)
+	numerator.Mul(numerator, big.NewInt(1000))
+	denominator := new(big.Int).Sub(reserveOut, amountOut)
+	denominator.Mul(denominator, big.NewInt(997))
+	numerator.Div(numerator, denominator)
+	return numerator.Add(numerator, big.NewInt(1))
+}
+
+// v2PriceImpactBps Uniswap V2 交换的价格冲击（万分比，不含0.3%手续费）：
+// 相对交换前中间价少得的输出比例，等于 amountInWithFee / (reserveIn + amountInWithFee)
+func v2PriceImpactBps(amountIn, reserveIn *big.Int) uint64 {
+	if amountIn.Sign() <= 0 || reserveIn.Sign() <= 0 {
+		return 0
+	}
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(997))
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(1000))
+	denominator.Add(denominator, amountInWithFee)
+	impact := new(big.Int).Mul(amountInWithFee, big.NewInt(10000))
+	return impact.Div(impact, denominator).Uint64()
+}
+
+// sandwichPrices 夹子三笔交易的成交价格（输入代币 / 输出代币，按精度归一化）
+type sandwichPrices struct {
+	entry  *big.Rat // 我们的买入价
+	victim *big.Rat // 受害者的实际成交价
+	exit   *big.Rat // 我们的卖出价
+}
+
+// legTax 交易对两侧代币的转账税率（万分比）
+type legTax struct {
+	in  uint64 // 输入代币
+	out uint64 // 输出代币
+}
+
+// afterTax 转账 amount 时对方实际到账的数量
+func afterTax(amount *big.Int, bps uint64) *big.Int {
+	if bps == 0 {
+		return amount
+	}
+	received := new(big.Int).Mul(amount, new(big.Int).SetUint64(10000-bps))
+	return received.Div(received, big.NewInt(10000))
+}
+
+// sandwichAmounts 夹子三笔交易的成交数量（我们的数量均为扣除转账税后的实际到账）
+type sandwichAmounts struct {
+	ourIn     *big.Int // 我们的买入规模（可能已按受害者滑点约束截断）
+	ourOut    *big.Int // 买入实际到账的输出代币
+	victimOut *big.Int // 受害者第一跳从交易对得到的输出代币
+	exitOut   *big.Int // 卖出实际到账的输入代币
+
+	ourImpactBps uint64 // 我们买入/卖出两笔交易中较大的价格冲击（万分比）
+}
+
+// simulateSandwich 在同一交易对上依次模拟 买入(our) → 受害者 → 卖出(our)。
+// 有转账税的代币每次转账都按税率扣减：转入交易对时交易对按税后数量记账，转出时我们按税后数量到账
+func simulateSandwich(ourIn, victimIn, reserveIn, reserveOut *big.Int, tax legTax) *sandwichAmounts {
+	rIn, rOut := new(big.Int).Set(reserveIn), new(big.Int).Set(reserveOut)
+
+	// 买入
+	paid := afterTax(ourIn, tax.in)
+	bought := v2AmountOut(paid, rIn, rOut)
+	entryImpact := v2PriceImpactBps(paid, rIn)
+	rIn.Add(rIn, paid)
+	rOut.Sub(rOut, bought)
+	ourOut := afterTax(bought, tax.out)
+
+	// 受害者
+	victimPaid := afterTax(victimIn, tax.in)
+	victimOut := v2AmountOut(victimPaid, rIn, rOut)
+	rIn.Add(rIn, victimPaid)
+	rOut.Sub(rOut, victimOut)
+
+	// 卖出：把到账的代币换回输入代币
+	sold := afterTax(ourOut, tax.out)
+	exitOut := afterTax(v2AmountOut(sold, rOut, rIn), tax.in)
+	exitImpact := v2PriceImpactBps(sold, rOut)
+
+	amounts := &sandwichAmounts{ourIn: ourIn, ourOut: ourOut, victimOut: victimOut, exitOut: exitOut, ourImpactBps: entryImpact}
+	if exitImpact > amounts.ourImpactBps {
+		amounts.ourImpactBps = exitImpact
+	}
+	return amounts
+}
+
+// profit 夹子的毛利（输入代币计价）：卖出换回的数量减去买入投入，亏损时为0
+func (a *sandwichAmounts) profit() *big.Int {
+	profit := new(big.Int).Sub(a.exitOut, a.ourIn)
+	if profit.Sign() < 0 {
+		profit.SetInt64(0)
+	}
+	return profit
+}
+
+// computeSandwichPrices 计算夹子三笔交易的成交价格
+func computeSandwichPrices(amounts *sandwichAmounts, victimIn *big.Int, decimalsIn, decimalsOut uint8) *sandwichPrices {
+	return &sandwichPrices{
+		entry:  normalizedPrice(amounts.ourIn, amounts.ourOut, decimalsIn, decimalsOut),
+		victim: normalizedPrice(victimIn, amounts.victimOut, decimalsIn, decimalsOut),
+		exit:   normalizedPrice(amounts.exitOut, amounts.ourOut, decimalsIn, decimalsOut),
+	}
+}
+
+// normalizedPrice 每单位输出代币对应的输入代币数量（按精度归一化），数量为0时返回nil
+func normalizedPrice(amountIn, amountOut *big.Int, decimalsIn, decimalsOut uint8) *big.Rat {
+	if amountIn.Sign() <= 0 || amountOut.Sign() <= 0 {
+		return nil
+	}
+	price := new(big.Rat).SetFrac(amountIn, amountOut)
+	scale := new(big.Rat).SetFrac(
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimalsOut)), nil),
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimalsIn)), nil),
+	)
+	return price.Mul(price, scale)
+}
+
+// ratString 价格转为字符串（nil时为空）
+func ratString(price *big.Rat) string {
+	if price == nil {
+		return ""
+	}
+	return price.FloatString(priceDecimals)
+}
+
+// fillPrices 按得分最高的策略 strategy 的买入仓位，根据第一跳交易对的储备填充受害者成交价、
+// 我们的买入/卖出价、各笔的预测成交数量和受害者的价格冲击，非V2路由、无法获取储备或受害者会因滑点回滚时保持为空
+func (s *Simulator) fillPrices(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction, strategy string, analysis *types.ProfitAnalysis) {
+	pool, err := s.sandwichPool(ctx, conn, decodedTx)
+	if err != nil || pool == nil {
+		return
+	}
+	analysis.PriceImpactBps = v2PriceImpactBps(decodedTx.AmountIn, pool.reserveIn)
+	amounts := pool.run(s.strategyInput(strategy, decodedTx), decodedTx.AmountIn)
+	if amounts == nil {
+		return
+	}
+	analysis.Predicted = &types.SandwichPrediction{
+		OurIn:     amounts.ourIn,
+		OurOut:    amounts.ourOut,
+		VictimOut: amounts.victimOut,
+		ExitOut:   amounts.exitOut,
+	}
+
+	path := poolPath(decodedTx)
+	tokenIn, tokenOut := path[0], path[1]
+	decimalsIn, err := s.tokenDecimals(ctx, conn, tokenIn)
+	if err != nil {
+		return
+	}
+	decimalsOut, err := s.tokenDecimals(ctx, conn, tokenOut)
+	if err != nil {
+		return
+	}
+
+	prices := computeSandwichPrices(amounts, decodedTx.AmountIn, decimalsIn, decimalsOut)
+	analysis.EntryPrice = ratString(prices.entry)
+	analysis.VictimPrice = ratString(prices.victim)
+	analysis.ExitPrice = ratString(prices.exit)
+}
@@ -0,0 +1,97 @@
+package simulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSandwichPricesKnownPool(t *testing.T) {
+	// WETH/USDC 1000 ETH : 2,000,000 USDC，我们和受害者各买入 10 ETH
+	reserveWETH, reserveUSDC := eth(1000), big.NewInt(2e12)
+	amounts := simulateSandwich(eth(10), eth(10), reserveWETH, reserveUSDC, legTax{})
+
+	// 按 getAmountOut 逐笔手算的结果
+	for _, amount := range []struct {
+		name      string
+		got, want *big.Int
+	}{
+		{name: "ourOut", got: amounts.ourOut, want: big.NewInt(19743160687)},
+		{name: "victimOut", got: amounts.victimOut, want: big.NewInt(19356609202)},
+		{name: "exitOut", got: amounts.exitOut, want: bigString(t, "10137216191660253337")},
+		{name: "profit", got: amounts.profit(), want: big.NewInt(137216191660253337)},
+	} {
+		if amount.got.Cmp(amount.want) != 0 {
+			t.Errorf("%s = %s, want %s", amount.name, amount.got, amount.want)
+		}
+	}
+
+	// 价格为每 USDC 的 ETH 数量，按 18/6 位精度归一化
+	prices := computeSandwichPrices(amounts, eth(10), 18, 6)
+	tests := []struct {
+		name  string
+		price *big.Rat
+		want  string
+	}{
+		{name: "entry", price: prices.entry, want: "0.000506504513564769"},
+		{name: "victim", price: prices.victim, want: "0.000516619408680667"},
+		{name: "exit", price: prices.exit, want: "0.000513454575605777"},
+	}
+	for _, tt := range tests {
+		if got := ratString(tt.price); got != tt.want {
+			t.Errorf("%s price = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// 我们先买入推高价格：受害者成交价最差，卖出价高于买入价即为盈利
+	if prices.entry.Cmp(prices.victim) >= 0 || prices.exit.Cmp(prices.victim) >= 0 || prices.entry.Cmp(prices.exit) >= 0 {
+		t.Errorf("prices entry %s, victim %s, exit %s; want entry < exit < victim",
+			ratString(prices.entry), ratString(prices.victim), ratString(prices.exit))
+	}
+}
+
+func TestNormalizedPrice(t *testing.T) {
+	tests := []struct {
+		name        string
+		amountIn    *big.Int
+		amountOut   *big.Int
+		decimalsIn  uint8
+		decimalsOut uint8
+		want        string
+	}{
+		{name: "ETH per USDC", amountIn: eth(1), amountOut: big.NewInt(2000e6), decimalsIn: 18, decimalsOut: 6, want: "0.000500000000000000"},
+		{name: "USDC per ETH", amountIn: big.NewInt(2000e6), amountOut: eth(1), decimalsIn: 6, decimalsOut: 18, want: "2000.000000000000000000"},
+		{name: "same decimals", amountIn: big.NewInt(3), amountOut: big.NewInt(2), decimalsIn: 18, decimalsOut: 18, want: "1.500000000000000000"},
+		{name: "rounds to 18 decimals", amountIn: big.NewInt(2), amountOut: big.NewInt(3), decimalsIn: 18, decimalsOut: 18, want: "0.666666666666666667"},
+		{name: "zero output", amountIn: eth(1), amountOut: big.NewInt(0), decimalsIn: 18, decimalsOut: 6, want: ""},
+		{name: "zero input", amountIn: big.NewInt(0), amountOut: eth(1), decimalsIn: 18, decimalsOut: 6, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ratString(normalizedPrice(tt.amountIn, tt.amountOut, tt.decimalsIn, tt.decimalsOut)); got != tt.want {
+				t.Errorf("normalizedPrice() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestV2PriceImpactBps(t *testing.T) {
+	tests := []struct {
+		name      string
+		amountIn  *big.Int
+		reserveIn *big.Int
+		want      uint64
+	}{
+		// 10×997 / (1000×1000 + 10×997) = 0.98%
+		{name: "1% of the reserve", amountIn: eth(10), reserveIn: eth(1000), want: 98},
+		{name: "equal to the reserve", amountIn: eth(1000), reserveIn: eth(1000), want: 4992},
+		{name: "dust", amountIn: big.NewInt(1), reserveIn: eth(1000), want: 0},
+		{name: "empty reserve", amountIn: eth(1), reserveIn: big.NewInt(0), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v2PriceImpactBps(tt.amountIn, tt.reserveIn); got != tt.want {
+				t.Errorf("v2PriceImpactBps() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// RawCall 调用任意JSON-RPC方法并返回原始结果，供调用节点服务商特有的方法
+// （如 trace_callMany、debug_traceCall），由调用方自行解析
+func (s *Simulator) RawCall(ctx context.Context, method string, args ...interface{}) (json.RawMessage, error) {
+	if !s.IsConnected() {
+		if err := s.reconnect(); err != nil {
+			return nil, fmt.Errorf("模拟器无法连接RPC: %w", err)
+		}
+	}
+
+	conn, release := s.leaseConn()
+	defer release()
+
+	var result json.RawMessage
+	if err := conn.client.Client().CallContext(ctx, &result, method, args...); err != nil {
+		return nil, fmt.Errorf("%s 调用失败: %w", method, err)
+	}
+	return result, nil
+}
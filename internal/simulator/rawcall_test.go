@@ -0,0 +1,119 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rawNode 记录收到的方法名和参数，返回固定结果或JSON-RPC错误
+type rawNode struct {
+	result json.RawMessage
+	code   int // 非0时返回该错误码
+
+	mu     sync.Mutex
+	method string
+	params json.RawMessage
+}
+
+func (n *rawNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	n.mu.Lock()
+	n.method, n.params = req.Method, req.Params
+	n.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if n.code != 0 {
+		response["error"] = map[string]interface{}{"code": n.code, "message": "the method does not exist"}
+	} else {
+		response["result"] = n.result
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func TestRawCallPassesThrough(t *testing.T) {
+	callArgs := map[string]interface{}{"to": "0x7a250d5630b4cf539739df2c5dacb4c659f2488d", "data": "0x7ff36ab5"}
+	tests := []struct {
+		name   string
+		method string
+		args   []interface{}
+		params string // 节点收到的参数（空表示不带参数）
+		result string
+	}{
+		{
+			name:   "trace_callMany",
+			method: "trace_callMany",
+			args:   []interface{}{[]interface{}{[]interface{}{callArgs, []string{"trace"}}}, "latest"},
+			params: `[[[{"data":"0x7ff36ab5","to":"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},["trace"]]],"latest"]`,
+			result: `[{"output":"0x","trace":[{"type":"call","subtraces":0}]}]`,
+		},
+		{
+			name:   "debug_traceCall",
+			method: "debug_traceCall",
+			args:   []interface{}{callArgs, "pending", map[string]interface{}{"tracer": "callTracer"}},
+			params: `[{"data":"0x7ff36ab5","to":"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},"pending",{"tracer":"callTracer"}]`,
+			result: `{"gasUsed":"0x5208","calls":[]}`,
+		},
+		{name: "no arguments", method: "eth_syncing", result: `false`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &rawNode{result: json.RawMessage(tt.result)}
+			s := simulatorFor(t, node)
+
+			result, err := s.RawCall(context.Background(), tt.method, tt.args...)
+			if err != nil {
+				t.Fatalf("RawCall() error = %v", err)
+			}
+			// 结果原样返回，由调用方自行解析
+			if string(result) != tt.result {
+				t.Errorf("RawCall() = %s, want %s", result, tt.result)
+			}
+
+			node.mu.Lock()
+			defer node.mu.Unlock()
+			if node.method != tt.method {
+				t.Errorf("node received method %q, want %q", node.method, tt.method)
+			}
+			if params := string(node.params); params != tt.params {
+				t.Errorf("node received params %q, want %q", params, tt.params)
+			}
+		})
+	}
+}
+
+func TestRawCallReturnsNodeErrors(t *testing.T) {
+	s := simulatorFor(t, &rawNode{code: -32601})
+
+	_, err := s.RawCall(context.Background(), "trace_callMany", []interface{}{}, "latest")
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32601 {
+		t.Fatalf("RawCall() error = %v, want the node's -32601", err)
+	}
+}
+
+// simulatorFor 连接到 handler 的模拟器
+func simulatorFor(t *testing.T, handler http.Handler) *Simulator {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return &Simulator{client: client, pool: newConnPool([]string{server.URL}, 0, client, 1)}
+}
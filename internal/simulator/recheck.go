@@ -0,0 +1,62 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+
+	"mempool-sniper/pkg/types"
+)
+
+// recheckKey 上下文标记：执行前复核和热重载后刷新的重新模拟不计入模拟统计、不记录生命周期事件、不登记竞争交换，
+// 否则同一笔交易会被重复计为 simulated/profitable
+type recheckKey struct{}
+
+// withRecheck 标记为执行前复核
+func withRecheck(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recheckKey{}, true)
+}
+
+// isRecheck 是否为执行前复核的重新模拟
+func isRecheck(ctx context.Context) bool {
+	recheck, _ := ctx.Value(recheckKey{}).(bool)
+	return recheck
+}
+
+// Recheck 执行前在最新区块重新模拟，最新结果未通过盈利门槛 accept 时放弃（只计入 recheck_aborted）
+// 返回最新的分析结果；放弃时返回错误
+func (s *Simulator) Recheck(ctx context.Context, analysis *types.ProfitAnalysis, accept func(latest *types.ProfitAnalysis) bool) (*types.ProfitAnalysis, error) {
+	if analysis.Source == nil {
+		return nil, s.abortRecheck(analysis, "缺少原始交易")
+	}
+
+	latest := s.Resimulate(ctx, analysis)
+	if latest == nil {
+		return nil, s.abortRecheck(analysis, "重新模拟失败")
+	}
+
+	if !accept(latest) {
+		return nil, s.abortRecheck(analysis, fmt.Sprintf("扣除全部成本的净盈利从 %s 降至 %s，低于阈值",
+			analysis.FormatProfit(analysis.NetProfitAfterCosts), latest.FormatProfit(latest.NetProfitAfterCosts)))
+	}
+
+	return latest, nil
+}
+
+// Resimulate 在最新状态下重新模拟已得出的分析结果（执行前复核、热重载后刷新旧配置下的结果），
+// 不计入模拟统计；缺少原始交易或不再是机会时返回nil
+func (s *Simulator) Resimulate(ctx context.Context, analysis *types.ProfitAnalysis) *types.ProfitAnalysis {
+	if analysis.Source == nil {
+		return nil
+	}
+	return s.SimulateTransaction(withRecheck(ctx), analysis.Source)
+}
+
+// abortRecheck 记录一次执行前复核放弃
+func (s *Simulator) abortRecheck(analysis *types.ProfitAnalysis, reason string) error {
+	s.mu.Lock()
+	s.recheckAborted++
+	s.mu.Unlock()
+
+	logger.Info("执行前复核未通过", "tx_hash", analysis.TxHash.Hex(), "reason", reason)
+	return fmt.Errorf("执行前复核未通过: %s", reason)
+}
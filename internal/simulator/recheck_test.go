@@ -0,0 +1,34 @@
+package simulator
+
+import (
+	"context"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+)
+
+func TestRecheckDoesNotCountAsSimulation(t *testing.T) {
+	s := &Simulator{failures: make(map[string]int64)}
+	tx := &types.DecodedTransaction{Transaction: &types.Transaction{}}
+
+	s.simulate(withRecheck(context.Background()), &rpcConn{}, tx)
+	if s.simulated != 0 {
+		t.Errorf("simulated = %d after a recheck, want 0", s.simulated)
+	}
+
+	s.simulate(context.Background(), &rpcConn{}, tx)
+	if s.simulated != 1 {
+		t.Errorf("simulated = %d after a pipeline simulation, want 1", s.simulated)
+	}
+}
+
+func TestRecheckAbortCountsOnce(t *testing.T) {
+	s := &Simulator{failures: make(map[string]int64)}
+
+	if _, err := s.Recheck(context.Background(), &types.ProfitAnalysis{}, func(*types.ProfitAnalysis) bool { return true }); err == nil {
+		t.Fatal("Recheck() without a source transaction succeeded")
+	}
+	if s.recheckAborted != 1 || s.simulated != 0 {
+		t.Errorf("recheck_aborted = %d, simulated = %d; want 1 and 0", s.recheckAborted, s.simulated)
+	}
+}
@@ -0,0 +1,169 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 方法签名
+var (
+	methodGetReserves = []byte{0x09, 0x02, 0xf1, 0xac} // getReserves()
+	methodDecimals    = []byte{0x31, 0x3c, 0xe5, 0x67} // decimals()
+)
+
+// pairReserves 交易对储备量（按token0/token1排序）
+type pairReserves struct {
+	pair     common.Address
+	reserve0 *big.Int
+	reserve1 *big.Int
+}
+
+// getReserves 读取交易对储备量
+func (s *Simulator) getReserves(ctx context.Context, conn *rpcConn, key pairKey) (*pairReserves, error) {
+	if types.IsNativeToken(key.token0) || types.IsNativeToken(key.token1) {
+		return nil, fmt.Errorf("%w: 交易对包含原生代币零地址，需先转换为包装代币", errInvalidTransaction)
+	}
+
+	pair, ok := s.chain.pairAddress(key)
+	if !ok {
+		return nil, fmt.Errorf("未知的工厂合约: %s", key.factory.Hex())
+	}
+
+	result, err := conn.client.CallContract(ctx, ethereum.CallMsg{To: &pair, Data: methodGetReserves}, nil)
+	if err != nil {
+		conn.fail(err)
+		return nil, err
+	}
+	if len(result) < 64 {
+		return nil, fmt.Errorf("%w: 交易对 %s 不存在或getReserves返回无效", errInvalidResponse, pair.Hex())
+	}
+
+	return &pairReserves{
+		pair:     pair,
+		reserve0: new(big.Int).SetBytes(result[:32]),
+		reserve1: new(big.Int).SetBytes(result[32:64]),
+	}, nil
+}
+
+// TokenDecimals 查询代币精度（带缓存，原生代币为18）
+func (s *Simulator) TokenDecimals(ctx context.Context, token common.Address) (uint8, error) {
+	conn, release := s.leaseConn()
+	defer release()
+
+	if conn.client == nil && !types.IsNativeToken(token) {
+		return 0, rpc.ErrClientQuit
+	}
+	return s.tokenDecimals(ctx, conn, token)
+}
+
+// tokenDecimals 读取代币精度（结果缓存）
+func (s *Simulator) tokenDecimals(ctx context.Context, conn *rpcConn, token common.Address) (uint8, error) {
+	if types.IsNativeToken(token) {
+		return 18, nil
+	}
+
+	s.pairMu.Lock()
+	decimals, exists := s.decimals[token]
+	s.pairMu.Unlock()
+	if exists {
+		return decimals, nil
+	}
+
+	result, err := conn.client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: methodDecimals}, nil)
+	if err != nil {
+		conn.fail(err)
+		return 0, err
+	}
+	if len(result) < 32 {
+		return 0, fmt.Errorf("%w: 代币 %s decimals返回无效", errInvalidResponse, token.Hex())
+	}
+
+	value := new(big.Int).SetBytes(result[:32])
+	if !value.IsUint64() || value.Uint64() > 77 {
+		return 0, fmt.Errorf("%w: 代币 %s decimals超出范围: %s", errInvalidResponse, token.Hex(), value)
+	}
+	decimals = uint8(value.Uint64())
+
+	s.pairMu.Lock()
+	s.decimals[token] = decimals
+	s.pairMu.Unlock()
+
+	return decimals, nil
+}
+
+const (
+	reserveBaselineWeight = 0.2              // 新观测在价格基准中的权重（持续的价格变化在数次观测后被吸收）
+	reserveBaselineTTL    = 10 * time.Minute // 基准超过该时长未更新则重新建立
+)
+
+// reserveBaseline 交易对近期价格基准（reserve0 / reserve1 的滑动平均）
+type reserveBaseline struct {
+	price   float64
+	updated time.Time
+}
+
+// priceDeviation 交易对当前价格相对其近期价格基准的偏离倍数（>=1），并用当前价格更新基准；
+// 首次观测或基准过期时为1，任一侧储备为0时为 +Inf。
+// 储备比例本身取决于两种代币的单价和精度，不能与固定阈值比较，只能与交易对自己的历史比较
+func (s *Simulator) priceDeviation(pair common.Address, reserve0, reserve1 *big.Int, now time.Time) float64 {
+	if reserve0.Sign() == 0 || reserve1.Sign() == 0 {
+		return math.Inf(1)
+	}
+	r0, _ := new(big.Float).SetInt(reserve0).Float64()
+	r1, _ := new(big.Float).SetInt(reserve1).Float64()
+	price := r0 / r1
+
+	s.pairMu.Lock()
+	defer s.pairMu.Unlock()
+
+	baseline, exists := s.reserveBaselines[pair]
+	if !exists || now.Sub(baseline.updated) > reserveBaselineTTL {
+		s.reserveBaselines[pair] = &reserveBaseline{price: price, updated: now}
+		return 1
+	}
+
+	deviation := price / baseline.price
+	if deviation < 1 {
+		deviation = 1 / deviation
+	}
+	baseline.price += reserveBaselineWeight * (price - baseline.price)
+	baseline.updated = now
+	return deviation
+}
+
+// isReserveImbalanced 检查交换路径上是否存在价格相对近期基准突变的交易对（可能被操纵或接近枯竭）：
+// 偏离倍数超过配置上限（或任一侧储备为0）视为失衡
+func (s *Simulator) isReserveImbalanced(ctx context.Context, conn *rpcConn, factory common.Address, path []common.Address) (bool, error) {
+	s.mu.RLock()
+	maxRatio := float64(0)
+	if s.cfg != nil {
+		maxRatio = s.cfg.MaxReserveRatio
+	}
+	s.mu.RUnlock()
+
+	if maxRatio <= 0 {
+		return false, nil
+	}
+
+	for i := 0; i+1 < len(path); i++ {
+		key := newPairKey(factory, path[i], path[i+1])
+		reserves, err := s.getReserves(ctx, conn, key)
+		if err != nil {
+			return false, err
+		}
+		if s.priceDeviation(reserves.pair, reserves.reserve0, reserves.reserve1, time.Now()) > maxRatio {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,73 @@
+package simulator
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPriceDeviationComparesAgainstOwnHistory(t *testing.T) {
+	pair := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		reserves [][2]*big.Int // 依次观测的 (reserve0, reserve1)
+		wantMax  float64       // 最后一次观测的偏离倍数上限
+		wantMin  float64       // 最后一次观测的偏离倍数下限
+	}{
+		{
+			// USDC(6位)/WETH(18位)：原始储备比例约 1e-9，但价格稳定，不应视为失衡
+			name:     "stable pool with very different decimals",
+			reserves: [][2]*big.Int{{big.NewInt(40e12), eth(10000)}, {big.NewInt(40.1e12), eth(10000)}},
+			wantMin:  1, wantMax: 1.01,
+		},
+		{
+			name:     "price moved 10x since last observation",
+			reserves: [][2]*big.Int{{eth(1000), eth(1000)}, {eth(10000), eth(100)}},
+			wantMin:  50, wantMax: math.Inf(1),
+		},
+		{
+			name:     "depleted reserve",
+			reserves: [][2]*big.Int{{eth(1000), eth(1000)}, {eth(1000), new(big.Int)}},
+			wantMin:  math.Inf(1), wantMax: math.Inf(1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Simulator{reserveBaselines: make(map[common.Address]*reserveBaseline)}
+			var deviation float64
+			for i, r := range tt.reserves {
+				deviation = s.priceDeviation(pair, r[0], r[1], now.Add(time.Duration(i)*time.Second))
+			}
+			if deviation < tt.wantMin || deviation > tt.wantMax {
+				t.Errorf("priceDeviation() = %v, want in [%v, %v]", deviation, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestPriceDeviationBaselineAbsorbsSustainedMoves(t *testing.T) {
+	pair := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+	s := &Simulator{reserveBaselines: make(map[common.Address]*reserveBaseline)}
+	now := time.Now()
+
+	s.priceDeviation(pair, eth(1000), eth(1000), now)
+	// 价格翻倍后保持：首次观测偏离约2倍，之后逐渐回到1附近
+	first := s.priceDeviation(pair, eth(2000), eth(1000), now.Add(time.Second))
+	var last float64
+	for i := 2; i < 30; i++ {
+		last = s.priceDeviation(pair, eth(2000), eth(1000), now.Add(time.Duration(i)*time.Second))
+	}
+	if first < 1.9 || last > 1.01 {
+		t.Errorf("deviation first = %v, after 30 observations = %v; want ~2 then ~1", first, last)
+	}
+
+	// 基准过期后重新建立
+	if got := s.priceDeviation(pair, eth(1), eth(1000), now.Add(time.Hour)); got != 1 {
+		t.Errorf("priceDeviation() after TTL = %v, want 1", got)
+	}
+}
@@ -0,0 +1,136 @@
+package simulator
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"mempool-sniper/pkg/types"
+)
+
+const (
+	tipSampleSize       = 1024 // 小费分布采样窗口
+	tipMinSamples       = 64   // 样本不足时不做跑道判断
+	tipSortEveryObserve = 64   // 每N次采样重新排序一次
+)
+
+// tipDistribution 近期内存池交易的有效小费分布（环形采样，由监听器对所有pending交易采样）
+type tipDistribution struct {
+	mu      sync.Mutex
+	samples []*big.Int
+	next    int
+	sorted  []*big.Int
+	dirty   int
+}
+
+// observe 记录一笔交易的有效小费
+func (d *tipDistribution) observe(tip *big.Int) {
+	if tip == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.samples) < tipSampleSize {
+		d.samples = append(d.samples, tip)
+	} else {
+		d.samples[d.next] = tip
+		d.next = (d.next + 1) % tipSampleSize
+	}
+	d.dirty++
+}
+
+// shareAbove 小费高于给定值的交易占比（样本不足时返回 ok=false）
+func (d *tipDistribution) shareAbove(tip *big.Int) (float64, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.samples) < tipMinSamples {
+		return 0, false
+	}
+	if d.sorted == nil || d.dirty >= tipSortEveryObserve {
+		d.sorted = append(d.sorted[:0], d.samples...)
+		sort.Slice(d.sorted, func(i, j int) bool { return d.sorted[i].Cmp(d.sorted[j]) < 0 })
+		d.dirty = 0
+	}
+
+	// 第一个大于tip的位置之后均为更高小费
+	idx := sort.Search(len(d.sorted), func(i int) bool { return d.sorted[i].Cmp(tip) > 0 })
+	return float64(len(d.sorted)-idx) / float64(len(d.sorted)), true
+}
+
+// effectiveTip 交易的有效小费：min(tipCap, feeCap - baseFee)，未知基础费用时取tipCap
+func effectiveTip(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if tx.RawTx == nil {
+		return tx.GasPrice
+	}
+
+	tip := tx.RawTx.GasTipCap()
+	if baseFee == nil {
+		return tip
+	}
+	headroom := new(big.Int).Sub(tx.RawTx.GasFeeCap(), baseFee)
+	if headroom.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	if headroom.Cmp(tip) < 0 {
+		return headroom
+	}
+	return tip
+}
+
+// estimateInclusionBlocks 估算受害者交易距打包的区块数：
+// 小费排在其前面的交易占比 / 单个区块可容纳的内存池占比，向下取整后 +1
+func estimateInclusionBlocks(shareAbove, blockShare float64) uint64 {
+	if blockShare <= 0 {
+		return 1
+	}
+	return uint64(shareAbove/blockShare) + 1
+}
+
+// cachedBaseFee 读取最近查询到的基础费用（不加锁、不发起RPC请求，未查询过时返回nil）
+func (s *Simulator) cachedBaseFee() *big.Int {
+	return s.fees.latest.Load()
+}
+
+// ObservePendingTip 记录一笔pending交易的有效小费（监听器对整个内存池调用，不限于交换交易）
+func (s *Simulator) ObservePendingTip(tx *types.Transaction) {
+	s.tips.observe(effectiveTip(tx, s.cachedBaseFee()))
+}
+
+// skipRunway 按受害者小费在内存池中的排名，若预计打包过快、没有足够区块完成提交则计数并返回true
+func (s *Simulator) skipRunway(decodedTx *types.DecodedTransaction) bool {
+	s.mu.RLock()
+	minRunway := uint64(0)
+	blockShare := float64(0)
+	if s.cfg != nil {
+		minRunway = s.cfg.MinRunwayBlocks
+		blockShare = s.cfg.RunwayBlockShare
+	}
+	s.mu.RUnlock()
+
+	if minRunway == 0 {
+		return false
+	}
+	tip := effectiveTip(decodedTx.Transaction, s.cachedBaseFee())
+	if tip == nil {
+		return false
+	}
+	share, ok := s.tips.shareAbove(tip)
+	if !ok || s.boostReplacement(decodedTx) {
+		return false
+	}
+
+	blocks := estimateInclusionBlocks(share, blockShare)
+	if blocks >= minRunway {
+		return false
+	}
+
+	logger.Debug("跑道不足，跳过", "tx_hash", decodedTx.Transaction.Hash.Hex(),
+		"tip_percentile", (1-share)*100, "inclusion_blocks", blocks, "min_runway", minRunway)
+	s.mu.Lock()
+	s.runwaySkip++
+	s.mu.Unlock()
+	return true
+}
@@ -0,0 +1,62 @@
+package simulator
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/pkg/types"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSkipRunwayRanksAgainstWholeMempool(t *testing.T) {
+	s := &Simulator{cfg: &config.SniperConfig{MinRunwayBlocks: 2, RunwayBlockShare: 0.25}}
+	s.fees.store(100, big.NewInt(30e9))
+
+	// 监听器采样的整个内存池（含非交换交易）：90% 小费 100 gwei，10% 小费 1 gwei
+	for i := 0; i < 100; i++ {
+		tip := int64(100e9)
+		if i%10 == 0 {
+			tip = 1e9
+		}
+		s.ObservePendingTip(&types.Transaction{GasPrice: big.NewInt(30e9 + tip)})
+	}
+
+	tests := []struct {
+		name   string
+		victim *types.Transaction
+		skip   bool
+	}{
+		{name: "highest tip is mined next block", victim: &types.Transaction{GasPrice: big.NewInt(230e9)}, skip: true},
+		{name: "low tip waits several blocks", victim: &types.Transaction{GasPrice: big.NewInt(32e9)}, skip: false},
+		{
+			// 小费上限很高，但费用上限只比基础费用高 1 gwei
+			name: "tip capped by the base fee",
+			victim: &types.Transaction{RawTx: ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+				GasTipCap: big.NewInt(200e9),
+				GasFeeCap: big.NewInt(31e9),
+			})},
+			skip: false,
+		},
+	}
+
+	// 跑道判断不读取基础费用缓存的锁
+	s.fees.mu.Lock()
+	defer s.fees.mu.Unlock()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan bool, 1)
+			go func() { done <- s.skipRunway(&types.DecodedTransaction{Transaction: tt.victim}) }()
+			select {
+			case skip := <-done:
+				if skip != tt.skip {
+					t.Errorf("skipRunway() = %v, want %v", skip, tt.skip)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("skipRunway() blocked on the fee cache lock")
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// victimSlippage 受害者的滑点约束：第一跳输出沿后续各跳（按当前储备）换算为整条路径的输出，
+// 低于 amountOutMin 时受害者交易回滚，夹子的卖出腿也就无利可图
+type victimSlippage struct {
+	minOut *big.Int      // 受害者整条路径的最少输出（nil 表示不检查）
+	hops   [][2]*big.Int // 第一跳之后各跳的 (reserveIn, reserveOut)
+}
+
+// finalOut 受害者第一跳得到 firstHopOut 时整条路径的输出
+func (v *victimSlippage) finalOut(firstHopOut *big.Int) *big.Int {
+	out := firstHopOut
+	for _, hop := range v.hops {
+		out = v2AmountOut(out, hop[0], hop[1])
+	}
+	return out
+}
+
+// allows 受害者第一跳得到 firstHopOut 时是否满足 amountOutMin
+func (v *victimSlippage) allows(firstHopOut *big.Int) bool {
+	if v == nil || v.minOut == nil {
+		return true
+	}
+	return v.finalOut(firstHopOut).Cmp(v.minOut) >= 0
+}
+
+// sandwichPool 受害者第一跳V2交易对上的夹子模拟参数
+type sandwichPool struct {
+	reserveIn  *big.Int
+	reserveOut *big.Int
+	tax        legTax // 交易对两侧代币的转账税率（TOKEN_TAX_RATES）
+	slippage   *victimSlippage
+}
+
+// sandwichPool 读取受害者第一跳交易对的储备和滑点约束，非V2路由时返回nil。
+// 精确输出交换的约束是 amountInMax（由 resolveExactOutput 检查），这里不检查 amountOutMin
+func (s *Simulator) sandwichPool(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (*sandwichPool, error) {
+	factory, exists := s.chain.factory(decodedTx.TargetContract)
+	if !exists || len(decodedTx.Path) < 2 || decodedTx.AmountIn == nil || decodedTx.AmountIn.Sign() <= 0 {
+		return nil, nil
+	}
+
+	path := poolPath(decodedTx)
+	reserves := make([][2]*big.Int, 0, len(path)-1)
+	for i := 1; i < len(path); i++ {
+		// 精确输出交换只需要第一跳
+		if i > 1 && (decodedTx.ExactOutput || decodedTx.AmountOutMin == nil || decodedTx.AmountOutMin.Sign() <= 0) {
+			break
+		}
+		key := newPairKey(factory, path[i-1], path[i])
+		pair, err := s.getReserves(ctx, conn, key)
+		if err != nil {
+			return nil, err
+		}
+		reserveIn, reserveOut := pair.reserve0, pair.reserve1
+		if key.token0 != path[i-1] {
+			reserveIn, reserveOut = reserveOut, reserveIn
+		}
+		reserves = append(reserves, [2]*big.Int{reserveIn, reserveOut})
+	}
+
+	pool := &sandwichPool{reserveIn: reserves[0][0], reserveOut: reserves[0][1], tax: s.legTax(path[0], path[1])}
+	if !decodedTx.ExactOutput && decodedTx.AmountOutMin != nil && decodedTx.AmountOutMin.Sign() > 0 {
+		pool.slippage = &victimSlippage{minOut: decodedTx.AmountOutMin, hops: reserves[1:]}
+	}
+	return pool, nil
+}
+
+// legTax 第一跳交易对两侧代币的转账税率
+func (s *Simulator) legTax(tokenIn, tokenOut common.Address) legTax {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.cfg == nil {
+		return legTax{}
+	}
+	return legTax{in: s.cfg.TokenTaxRates[tokenIn], out: s.cfg.TokenTaxRates[tokenOut]}
+}
+
+// after 同一交易对上先成交一笔输入为 amountIn 的同向交换后的模拟参数
+func (p *sandwichPool) after(amountIn *big.Int) *sandwichPool {
+	paid := afterTax(amountIn, p.tax.in)
+	out := v2AmountOut(paid, p.reserveIn, p.reserveOut)
+	return &sandwichPool{
+		reserveIn:  new(big.Int).Add(p.reserveIn, paid),
+		reserveOut: new(big.Int).Sub(p.reserveOut, out),
+		tax:        p.tax,
+		slippage:   p.slippage,
+	}
+}
+
+// victimOutAfter 我们先买入 ourIn 后受害者第一跳的输出
+func (p *sandwichPool) victimOutAfter(ourIn, victimIn *big.Int) *big.Int {
+	next := p.after(ourIn)
+	return v2AmountOut(afterTax(victimIn, p.tax.in), next.reserveIn, next.reserveOut)
+}
+
+// capFrontRun 滑点约束下我们最大的买入规模（不超过 ourIn）：受害者输出随我们的买入单调递减，
+// 二分查找满足 amountOutMin 的最大值；不买入受害者也会回滚时返回nil
+func (p *sandwichPool) capFrontRun(ourIn, victimIn *big.Int) *big.Int {
+	if p.slippage.allows(p.victimOutAfter(ourIn, victimIn)) {
+		return ourIn
+	}
+	lo, hi := new(big.Int), new(big.Int).Set(ourIn)
+	if !p.slippage.allows(p.victimOutAfter(lo, victimIn)) {
+		return nil
+	}
+
+	// 不变式：lo 满足约束，hi 不满足
+	one := big.NewInt(1)
+	for new(big.Int).Sub(hi, lo).Cmp(one) > 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if p.slippage.allows(p.victimOutAfter(mid, victimIn)) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// run 按滑点约束截断我们的买入规模后模拟夹子，受害者无论如何都会回滚时返回nil
+func (p *sandwichPool) run(ourIn, victimIn *big.Int) *sandwichAmounts {
+	capped := p.capFrontRun(ourIn, victimIn)
+	if capped == nil {
+		return nil
+	}
+	return simulateSandwich(capped, victimIn, p.reserveIn, p.reserveOut, p.tax)
+}
+
+// sandwich 策略评估用的夹子模拟：统计被截断的买入规模和受害者必然回滚的交易
+func (s *Simulator) sandwich(ctx context.Context, pool *sandwichPool, ourIn, victimIn *big.Int) *sandwichAmounts {
+	amounts := pool.run(ourIn, victimIn)
+	if amounts == nil {
+		s.count(ctx, &s.victimReverts)
+	} else if amounts.ourIn.Cmp(ourIn) < 0 {
+		s.count(ctx, &s.frontRunCapped)
+	}
+	return amounts
+}
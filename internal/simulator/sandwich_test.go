@@ -0,0 +1,109 @@
+package simulator
+
+import (
+	"math/big"
+	"testing"
+)
+
+// eth 以18位精度表示的数量
+func eth(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+}
+
+func TestCapFrontRunRespectsAmountOutMin(t *testing.T) {
+	reserveIn, reserveOut := eth(1000), eth(2000000)
+	victimIn := eth(10)
+
+	// 不被夹时受害者的输出
+	unsandwiched := v2AmountOut(victimIn, reserveIn, reserveOut)
+
+	tests := []struct {
+		name       string
+		minOut     *big.Int
+		ourIn      *big.Int
+		wantCapped bool
+		wantNil    bool
+	}{
+		{name: "no slippage constraint", minOut: nil, ourIn: eth(50)},
+		{name: "loose amountOutMin", minOut: big.NewInt(1), ourIn: eth(50)},
+		{name: "tight amountOutMin caps front-run", minOut: new(big.Int).Div(new(big.Int).Mul(unsandwiched, big.NewInt(99)), big.NewInt(100)), ourIn: eth(50), wantCapped: true},
+		{name: "victim reverts even without us", minOut: new(big.Int).Add(unsandwiched, big.NewInt(1)), ourIn: eth(50), wantNil: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &sandwichPool{reserveIn: reserveIn, reserveOut: reserveOut}
+			if tt.minOut != nil {
+				pool.slippage = &victimSlippage{minOut: tt.minOut}
+			}
+
+			amounts := pool.run(tt.ourIn, victimIn)
+			if tt.wantNil {
+				if amounts != nil {
+					t.Fatalf("run() = %+v, want nil", amounts)
+				}
+				return
+			}
+			if amounts == nil {
+				t.Fatal("run() = nil")
+			}
+			if tt.minOut != nil && amounts.victimOut.Cmp(tt.minOut) < 0 {
+				t.Errorf("victimOut %s < amountOutMin %s", amounts.victimOut, tt.minOut)
+			}
+			if capped := amounts.ourIn.Cmp(tt.ourIn) < 0; capped != tt.wantCapped {
+				t.Errorf("ourIn = %s, capped = %v, want %v", amounts.ourIn, capped, tt.wantCapped)
+			}
+			if tt.wantCapped {
+				// 截断后的规模是满足约束的最大值：再多买1 wei 受害者就会回滚
+				over := new(big.Int).Add(amounts.ourIn, big.NewInt(1))
+				if pool.slippage.allows(pool.victimOutAfter(over, victimIn)) {
+					t.Errorf("ourIn %s is not the largest front-run the victim tolerates", amounts.ourIn)
+				}
+			}
+		})
+	}
+}
+
+func TestVictimSlippageFollowsLaterHops(t *testing.T) {
+	// 第一跳之后还有一跳：amountOutMin 针对的是整条路径的输出
+	hop := [2]*big.Int{eth(2000000), eth(1000)}
+	firstHopOut := eth(19000)
+	finalOut := v2AmountOut(firstHopOut, hop[0], hop[1])
+
+	slip := &victimSlippage{minOut: finalOut, hops: [][2]*big.Int{hop}}
+	if !slip.allows(firstHopOut) {
+		t.Errorf("allows(%s) = false, want true", firstHopOut)
+	}
+	if slip.allows(new(big.Int).Sub(firstHopOut, eth(1))) {
+		t.Error("allows() = true for a first-hop output whose final output is below amountOutMin")
+	}
+}
+
+func TestSandwichTaxAppliedPerLeg(t *testing.T) {
+	reserveIn, reserveOut := eth(1000), eth(2000000)
+	ourIn, victimIn := eth(5), eth(10)
+
+	untaxed := simulateSandwich(ourIn, victimIn, reserveIn, reserveOut, legTax{})
+	if untaxed.profit().Sign() <= 0 {
+		t.Fatalf("untaxed profit = %s, want > 0", untaxed.profit())
+	}
+
+	// 输出代币5%转账税：买入到账和卖出转入交易对各扣一次，约10%的仓位损耗远超毛利；
+	// 只对最终盈利扣税会得到一个仍为正的盈利
+	taxed := simulateSandwich(ourIn, victimIn, reserveIn, reserveOut, legTax{out: 500})
+	if taxed.profit().Sign() != 0 {
+		t.Errorf("taxed profit = %s, want 0 (both legs lose 5%% of the position)", taxed.profit())
+	}
+	wantOurOut := afterTax(v2AmountOut(ourIn, reserveIn, reserveOut), 500)
+	if taxed.ourOut.Cmp(wantOurOut) != 0 {
+		t.Errorf("ourOut = %s, want %s after the transfer tax", taxed.ourOut, wantOurOut)
+	}
+	if taxed.exitOut.Cmp(untaxed.exitOut) >= 0 {
+		t.Errorf("taxed exitOut %s >= untaxed %s", taxed.exitOut, untaxed.exitOut)
+	}
+
+	// 输入代币的税同样作用于受害者转入交易对的数量
+	pool := &sandwichPool{reserveIn: reserveIn, reserveOut: reserveOut, tax: legTax{in: 300}}
+	if got, want := pool.victimOutAfter(new(big.Int), victimIn), v2AmountOut(afterTax(victimIn, 300), reserveIn, reserveOut); got.Cmp(want) != 0 {
+		t.Errorf("victimOutAfter() = %s, want %s", got, want)
+	}
+}
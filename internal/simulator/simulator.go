@@ -2,133 +2,604 @@ package simulator
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"math"
 	"math/big"
 	"sync"
 	"time"
 
 	"mempool-sniper/internal/config"
+	"mempool-sniper/internal/lifecycle"
+	"mempool-sniper/internal/logging"
 	"mempool-sniper/pkg/types"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// logger 模拟器日志（component=simulator）
+var logger = logging.Component("simulator")
+
 // Simulator 交易模拟器
 type Simulator struct {
+	chain      *chainConfig // 链的路由器工厂和借贷市场（创建后不变）
 	client     *ethclient.Client
 	rpcURL     string
 	cfg        *config.SniperConfig
+	cfgVersion uint64 // cfg 所属的配置版本号
 	mu         sync.RWMutex
 	simulated  int64
 	profitable int64
 	failed     int64
+	failures   map[string]int64 // 按类型统计的失败数
+	superseded int64
+	warmupSkip int64
+
+	reserveRejected int64 // 因储备失衡被拒绝的交易数
+	recheckAborted  int64 // 执行前重新模拟未通过的机会数
+	directionSkip   int64 // 因交换方向不在配置范围内而跳过的交易数
+	spamThrottled   int64 // 因属于垃圾交易聚类而跳过的交易数
+	notReplacement  int64 // 只模拟替代交易模式下跳过的非替代交易数
+	l1FeeFailures   int64 // L1数据费估算失败次数
+	attackerSkipped int64 // 疑似夹子攻击者而跳过的交易数
+	runwaySkip      int64 // 因预计打包过快（跑道不足）而跳过的交易数
+
+	impactRejected int64 // 我们自己交易的价格冲击超过上限而放弃的策略评估数
+	frontRunCapped int64 // 按受害者 amountOutMin 截断买入规模的策略评估数
+	victimReverts  int64 // 受害者在当前储备下就达不到 amountOutMin（必然回滚）的策略评估数
+
+	gasEstimated        int64 // 使用 eth_estimateGas 结果的交易数
+	gasEstimateFailures int64 // eth_estimateGas 失败/超时而回退到固定估算的次数
+
+	traced           int64          // 使用 debug_traceCall 追踪到我们买入腿的交易数
+	traceReverted    int64          // 追踪/eth_call 显示受害者会回滚的交易数
+	legReverted      int64          // 追踪显示我们的买入腿会回滚（余额或授权不足等）的交易数
+	traceUnsupported bool           // 节点不支持 debug_traceCall
+	traceAccount     common.Address // 追踪我们买入腿使用的发送地址
+
+	lending lendingState // 清算策略的观察列表和储备缓存
+
+	noStrategy int64 // 没有任何启用的策略适用的交易数
+
+	exactOutputReverted int64 // 精确输出交换所需输入超过 amountInMax（会回滚）而跳过的交易数
+
+	gasUnpriced int64 // 输入代币不是基础资产且无法按交易对价格换算为基础资产而跳过的交易数
+
+	mevResistant int64 // 抗MEV订单流交易（批量拍卖/提交-揭示等）未模拟直接跳过的交易数
+
+	endpoints   []string // 候选RPC节点（连接故障时按顺序轮换）
+	endpointIdx int      // rpcURL 在候选节点中的序号
+
+	poolCtx  context.Context                  // 工作池上下文（重启时新线程在其下启动）
+	poolIn   <-chan *types.DecodedTransaction // 工作池输入通道
+	poolOut  chan<- *types.ProfitAnalysis     // 工作池输出通道
+	dequeued int64                            // 工作线程从输入通道取出的交易数
+
+	startTime time.Time // 工作池启动时间（用于预热判断）
+	received  int64     // 工作池收到的交易数
+	warmedUp  bool      // 预热期是否已结束
+
+	isSuperseded func(hash common.Hash) bool // 判断交易是否已被取消/替代
+
+	latestBlock uint64 // 最新区块号（由新区块订阅更新）
+
+	rpcSwaps int64 // RPC节点热切换次数
+
+	pool      *connPool           // RPC连接池（工作线程固定绑定连接）
+	lifecycle *lifecycle.Recorder // 生命周期事件记录器
+
+	workers      []workerHandle // 运行中的工作线程
+	running      sync.WaitGroup // 所有已启动且尚未退出的工作线程（含已被缩容取消、仍在收尾的）
+	nextWorkerID int
+	latencyEWMA  float64 // 模拟耗时滑动平均(ms)
+
+	fees  feeCache        // 基础费用缓存
+	rates rateCache       // 代币与基础资产兑换比例缓存
+	tips  tipDistribution // 内存池小费分布（用于估算受害者打包区块）
+
+	competition competitionTracker // 近期V2交换（用于悲观盈利估算）
+
+	funnel *lifecycle.Funnel // 机会转化漏斗（为nil表示不统计）
+
+	pairMu   sync.Mutex
+	pairAges map[pairKey]*pairAge     // 交易对创建区块缓存
+	decimals map[common.Address]uint8 // 代币精度缓存
+
+	reserveBaselines map[common.Address]*reserveBaseline // 交易对近期价格基准（储备失衡检查）
 }
 
 // NewSimulator 创建新的模拟器
-func NewSimulator(rpcURL string) *Simulator {
-	client, err := ethclient.Dial(rpcURL)
-	if err != nil {
-		log.Printf("⚠️ 创建模拟器时连接RPC失败: %v", err)
-		// 返回一个无效的模拟器，会在使用时重新连接
-		return &Simulator{
-			rpcURL: rpcURL,
-		}
-	}
+func NewSimulator(rpcURL string, chain *types.ChainInfo) *Simulator {
+	return NewSimulatorWithEndpoints([]string{rpcURL}, chain)
+}
 
-	return &Simulator{
-		client:     client,
-		rpcURL:     rpcURL,
-		simulated:  0,
-		profitable: 0,
-		failed:     0,
+// NewSimulatorWithEndpoints 创建支持故障切换的模拟器：按顺序连接第一个可用节点，
+// 运行中连接故障时连接池轮换到下一个节点
+func NewSimulatorWithEndpoints(rpcURLs []string, chain *types.ChainInfo) *Simulator {
+	s := &Simulator{
+		chain:     newChainConfig(chain),
+		rpcURL:    rpcURLs[0],
+		endpoints: rpcURLs,
+		failures:  make(map[string]int64),
+		pairAges:  make(map[pairKey]*pairAge),
+		decimals:  make(map[common.Address]uint8),
+
+		reserveBaselines: make(map[common.Address]*reserveBaseline),
 	}
+	if err := s.reconnect(); err != nil {
+		logger.Warn("创建模拟器时连接RPC失败", "error", err)
+		// 返回一个无效的模拟器，会在使用时重新连接
+	}
+	return s
 }
 
 // StartWorkerPool 启动模拟器工作池
 func (s *Simulator) StartWorkerPool(ctx context.Context, decodedTxChan <-chan *types.DecodedTransaction, profitChan chan<- *types.ProfitAnalysis, workerCount int) {
-	log.Printf("🔮 启动模拟器工作池，工作线程数: %d", workerCount)
+	logger.Info("启动模拟器工作池", "workers", workerCount)
 
+	// 确保客户端连接
+	if s.client == nil {
+		if err := s.reconnect(); err != nil {
+			logger.Error("模拟器无法连接RPC", "error", err)
+		}
+	}
+
+	s.mu.Lock()
+	poolSize := 1
+	if s.cfg != nil {
+		poolSize = s.cfg.RPCPoolSize
+	}
+	if s.pool == nil {
+		s.pool = newConnPool(s.endpoints, s.endpointIdx, s.client, poolSize)
+	} else {
+		s.pool.grow(poolSize)
+	}
+	s.startTime = time.Now()
+	s.poolCtx, s.poolIn, s.poolOut = ctx, decodedTxChan, profitChan
+
+	autoTune := s.cfg != nil && autoTuneEnabled(s.cfg.SimWorkersMin, s.cfg.SimWorkersMax)
+	if autoTune {
+		workerCount = max(s.cfg.SimWorkersMin, min(workerCount, s.cfg.SimWorkersMax))
+		logger.Info("模拟器工作线程自动调节", "min_workers", s.cfg.SimWorkersMin, "max_workers", s.cfg.SimWorkersMax, "workers", workerCount)
+	}
 	for i := 0; i < workerCount; i++ {
-		go s.worker(ctx, decodedTxChan, profitChan, i)
+		s.spawnWorker(ctx, decodedTxChan, profitChan)
+	}
+	s.mu.Unlock()
+
+	if autoTune {
+		go s.runAutoTuner(ctx, decodedTxChan, profitChan)
+	}
+}
+
+// pinConn 从当前连接池为工作线程绑定可用的RPC连接。连接全部在重建时按退避间隔重试，
+// 不退出工作线程（退出后模拟容量会永久减少）；ctx 结束时返回nil
+func (s *Simulator) pinConn(ctx context.Context, workerID int) (*connPool, *rpcConn) {
+	backoff := 100 * time.Millisecond
+	maxBackoff := 5 * time.Second
+	for {
+		pool := s.currentPool()
+		conn := pool.pin(workerID)
+		if conn.client != nil {
+			return pool, conn
+		}
+
+		pool.reconnect(conn.slot)
+		logger.Warn("工作线程暂无可用RPC连接，稍后重试", "worker_id", workerID, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
 	}
 }
 
 // worker 模拟器工作线程
 func (s *Simulator) worker(ctx context.Context, decodedTxChan <-chan *types.DecodedTransaction, profitChan chan<- *types.ProfitAnalysis, workerID int) {
-	log.Printf("👷 模拟器工作线程 %d 启动", workerID)
+	logger.Debug("工作线程启动", "worker_id", workerID)
 
-	// 确保客户端连接
-	if s.client == nil {
-		if err := s.reconnect(); err != nil {
-			log.Printf("❌ 工作线程 %d 无法连接RPC: %v", workerID, err)
-			return
-		}
+	// 绑定固定的RPC连接
+	pool, conn := s.pinConn(ctx, workerID)
+	if conn == nil {
+		logger.Debug("工作线程停止", "worker_id", workerID)
+		return
 	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("🛑 模拟器工作线程 %d 停止", workerID)
+			logger.Debug("工作线程停止", "worker_id", workerID)
 			return
-		case decodedTx := <-decodedTxChan:
+		case decodedTx, ok := <-decodedTxChan:
+			if !ok {
+				logger.Debug("工作线程输入已排空，停止", "worker_id", workerID)
+				return
+			}
+			s.mu.Lock()
+			s.dequeued++
+			s.mu.Unlock()
 			if decodedTx == nil {
 				continue
 			}
 
+			// 跳过已被取消的交易
+			if s.skipSuperseded(decodedTx.Transaction.Hash) {
+				continue
+			}
+
+			// 疑似夹子攻击者的抢跑交易不作为受害者
+			if s.skipAttacker(decodedTx) {
+				continue
+			}
+
+			// 替代交易模式：只模拟替代(加速)交易
+			if s.skipNonReplacement(decodedTx) {
+				continue
+			}
+
+			// 只模拟配置的交换方向
+			if s.skipDirection(decodedTx) {
+				continue
+			}
+
+			// 垃圾交易聚类按配置跳过模拟
+			if s.skipSpam(decodedTx) {
+				continue
+			}
+
+			// 受害者预计打包过快，来不及通过公开内存池提交
+			if s.skipRunway(decodedTx) {
+				continue
+			}
+
+			// 预热期间缓存尚冷，只解码不模拟
+			if s.inWarmup() {
+				continue
+			}
+
+			// RPC节点热切换后改用新连接池，旧连接池排空后关闭；暂无可用连接时等待重建
+			for conn.client == nil || !pool.acquire() {
+				if pool, conn = s.pinConn(ctx, workerID); conn == nil {
+					return
+				}
+			}
+
 			// 模拟交易执行
-			profitAnalysis := s.SimulateTransaction(ctx, decodedTx)
+			start := time.Now()
+			profitAnalysis := s.simulate(ctx, conn, decodedTx)
+			s.recordLatency(time.Since(start))
+			pool.release()
+			s.funnelStage().Add(lifecycle.FunnelSimulated)
+
+			// 仅在当前连接故障时重新绑定
+			if conn.failed || conn.client == nil {
+				conn = pool.repin(conn)
+			}
+
+			// 模拟期间交易可能已被取消
+			if profitAnalysis != nil && s.skipSuperseded(decodedTx.Transaction.Hash) {
+				continue
+			}
+
 			if profitAnalysis != nil {
 				// 将盈利分析结果发送到结果处理器
 				select {
 				case profitChan <- profitAnalysis:
-					log.Printf("💰 工作线程 %d 模拟完成并发送结果: %s -> 盈利 %s ETH",
-						workerID, decodedTx.Transaction.Hash.Hex(), profitAnalysis.NetProfit.String())
+					logger.Info("模拟完成并发送结果", "worker_id", workerID, "tx_hash", decodedTx.Transaction.Hash.Hex(),
+						"net_profit", profitAnalysis.FormatProfit(profitAnalysis.NetProfit))
 				case <-ctx.Done():
 					return
 				default:
-					log.Printf("⚠️ 工作线程 %d 盈利通道已满，丢弃结果: %s", workerID, decodedTx.Transaction.Hash.Hex())
+					logger.Warn("盈利通道已满，丢弃结果", "worker_id", workerID, "tx_hash", decodedTx.Transaction.Hash.Hex())
 				}
 			}
 		}
 	}
 }
 
-// SimulateTransaction 模拟交易执行
-func (s *Simulator) SimulateTransaction(ctx context.Context, decodedTx *types.DecodedTransaction) *types.ProfitAnalysis {
-	startTime := time.Now()
+// skipSuperseded 检查交易是否已被取消，是则计数并返回true
+func (s *Simulator) skipSuperseded(hash common.Hash) bool {
+	s.mu.RLock()
+	isSuperseded := s.isSuperseded
+	s.mu.RUnlock()
 
+	if isSuperseded == nil || !isSuperseded(hash) {
+		return false
+	}
+
+	logger.Info("交易已被取消，跳过模拟结果", "tx_hash", hash.Hex())
 	s.mu.Lock()
-	s.simulated++
+	s.superseded++
 	s.mu.Unlock()
+	return true
+}
+
+// skipDirection 检查交换方向是否不在配置范围内，是则计数并返回true
+func (s *Simulator) skipDirection(decodedTx *types.DecodedTransaction) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !decodedTx.IsSwap || s.cfg == nil || s.cfg.AllowsDirection(decodedTx.SwapDirection) {
+		return false
+	}
+	s.directionSkip++
+	return true
+}
+
+// 替代(加速)交易处理模式
+const (
+	ReplacementOff   = "off"   // 不区分
+	ReplacementBoost = "boost" // 替代交易不受垃圾聚类和跑道限流
+	ReplacementOnly  = "only"  // 只模拟替代交易
+)
+
+// skipAttacker 跳过疑似夹子攻击者的交易（避免夹攻击者或成为被夹的一方），是则计数并返回true
+func (s *Simulator) skipAttacker(decodedTx *types.DecodedTransaction) bool {
+	if !decodedTx.LikelyAttacker {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attackerSkipped++
+	return true
+}
+
+// skipNonReplacement 只模拟替代交易模式下跳过非替代交易，是则计数并返回true
+func (s *Simulator) skipNonReplacement(decodedTx *types.DecodedTransaction) bool {
+	if decodedTx.Replaces != (common.Hash{}) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cfg == nil || s.cfg.ReplacementMode != ReplacementOnly {
+		return false
+	}
+	s.notReplacement++
+	return true
+}
+
+// boostReplacement 替代交易是否优先处理（不受垃圾聚类和跑道限流）
+func (s *Simulator) boostReplacement(decodedTx *types.DecodedTransaction) bool {
+	if decodedTx.Replaces == (common.Hash{}) {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg != nil && s.cfg.ReplacementMode == ReplacementBoost
+}
+
+// skipSpam 检查交易是否属于垃圾交易聚类且配置了限流，是则计数并返回true
+func (s *Simulator) skipSpam(decodedTx *types.DecodedTransaction) bool {
+	if !decodedTx.SpamCluster || s.boostReplacement(decodedTx) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cfg == nil || !s.cfg.SpamThrottle {
+		return false
+	}
+	s.spamThrottled++
+	return true
+}
+
+// inWarmup 检查是否处于预热期（前N笔交易或前T秒），是则计数并返回true
+func (s *Simulator) inWarmup() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.received++
+	if s.cfg == nil {
+		return false
+	}
+
+	if s.warmedUp {
+		return false
+	}
+
+	warm := s.received > s.cfg.WarmupTransactions &&
+		time.Since(s.startTime) >= time.Duration(s.cfg.WarmupSeconds)*time.Second
+	if warm {
+		s.warmedUp = true
+		if s.warmupSkip > 0 {
+			logger.Info("预热结束，已跳过前面交易的模拟", "skipped", s.warmupSkip)
+		}
+		return false
+	}
+
+	s.warmupSkip++
+	return true
+}
+
+// SetTraceAccount 设置追踪我们买入腿使用的发送地址（默认为占位地址，只有原生代币输入的买入腿能成功）
+func (s *Simulator) SetTraceAccount(account common.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.traceAccount = account
+}
 
+// SetSupersededCheck 设置交易取消/替代判断函数
+func (s *Simulator) SetSupersededCheck(check func(hash common.Hash) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isSuperseded = check
+}
+
+// SimulateTransaction 模拟交易执行
+func (s *Simulator) SimulateTransaction(ctx context.Context, decodedTx *types.DecodedTransaction) *types.ProfitAnalysis {
 	// 确保客户端连接
 	if s.client == nil {
 		if err := s.reconnect(); err != nil {
-			s.mu.Lock()
-			s.failed++
-			s.mu.Unlock()
+			s.count(ctx, &s.simulated)
+			s.recordFailure(fmt.Errorf("%w: %v", rpc.ErrNoResult, err))
 			return nil
 		}
 	}
 
+	conn, release := s.leaseConn()
+	defer release()
+	return s.simulate(ctx, conn, decodedTx)
+}
+
+// count 统计计数加一（执行前复核的重新模拟不计）
+func (s *Simulator) count(ctx context.Context, counter *int64) {
+	if isRecheck(ctx) {
+		return
+	}
+	s.mu.Lock()
+	*counter++
+	s.mu.Unlock()
+}
+
+// simulate 使用指定连接模拟交易执行
+func (s *Simulator) simulate(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) *types.ProfitAnalysis {
+	startTime := time.Now()
+
+	// 抗MEV订单流：成交价由结算方决定，没有可夹的交换，不计入模拟
+	if decodedTx.MEVResistant {
+		s.count(ctx, &s.mevResistant)
+		return nil
+	}
+
+	s.count(ctx, &s.simulated)
+
+	if conn.client == nil {
+		s.recordFailure(rpc.ErrClientQuit)
+		return nil
+	}
+
+	if decodedTx.Transaction == nil || decodedTx.Transaction.Value == nil {
+		s.recordFailure(errInvalidTransaction)
+		return nil
+	}
+
+	// 记录V2交换，供同一交易对上其他交易估算竞争成交量
+	factory, isV2 := s.chain.factory(decodedTx.TargetContract)
+	competitionWindow, profitEstimate := s.competitionSettings()
+	if isV2 && !isRecheck(ctx) {
+		s.competition.observe(decodedTx, factory, competitionWindow)
+	}
+
+	// 过滤储备极度失衡的交易对（可能被操纵或接近枯竭）
+	if isV2 {
+		imbalanced, err := s.isReserveImbalanced(ctx, conn, factory, poolPath(decodedTx))
+		if err != nil {
+			logger.Warn("检查交易对储备失败", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+			s.recordFailure(err)
+			return nil
+		}
+		if imbalanced {
+			logger.Debug("交易涉及储备失衡的交易对，跳过", "tx_hash", decodedTx.Transaction.Hash.Hex())
+			s.count(ctx, &s.reserveRejected)
+			return nil
+		}
+	}
+
+	// 精确输出交换：按储备反推实际输入，超过 amountInMax 时受害者交易会回滚
+	if decodedTx.ExactOutput {
+		resolved, wouldRevert, err := s.resolveExactOutput(ctx, conn, decodedTx)
+		if err != nil {
+			logger.Warn("反推精确输出交换的输入失败", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+			s.recordFailure(err)
+			return nil
+		}
+		if wouldRevert {
+			logger.Debug("精确输出交易所需输入超过 amountInMax，会回滚，跳过", "tx_hash", decodedTx.Transaction.Hash.Hex())
+			s.count(ctx, &s.exactOutputReverted)
+			return nil
+		}
+		decodedTx = resolved
+	}
+
+	// 可选：debug_traceCall 精确模拟：受害者在最新状态上会回滚时跳过，
+	// 再追踪我们的买入腿，获取实际Gas用量和我们的余额变化
+	var trace *traceResult
+	if s.traceEnabled() {
+		reverted, err := s.victimWouldRevert(ctx, conn, decodedTx)
+		if err != nil {
+			logger.Warn("检查受害者交易失败", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+		}
+		if reverted {
+			logger.Debug("交易在最新状态上会回滚，跳过", "tx_hash", decodedTx.Transaction.Hash.Hex())
+			s.count(ctx, &s.traceReverted)
+			return nil
+		}
+		trace = s.traceOurLeg(ctx, conn, decodedTx)
+	}
+
 	// 简化版模拟逻辑
 	// 实际项目中需要实现完整的EVM模拟
 	profitAnalysis := &types.ProfitAnalysis{
+		OpportunityID:  decodedTx.OpportunityID,
 		TxHash:         decodedTx.Transaction.Hash,
 		TargetContract: decodedTx.TargetContract,
 		Method:         decodedTx.Method,
 		SimulationTime: time.Since(startTime).Milliseconds(),
+		Source:         decodedTx,
+		ConfigVersion:  s.configVersion(),
+
+		LeadingApproval: decodedTx.LeadingApproval,
 	}
 
 	// 估算Gas成本
-	gasCost := s.estimateGasCost(decodedTx)
+	tracedGas := uint64(0)
+	if trace != nil {
+		tracedGas = trace.gasUsed
+	}
+	estimation, err := s.estimateGasCost(ctx, conn, decodedTx, tracedGas)
+	if err != nil {
+		logger.Warn("估算Gas成本失败，跳过", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+		s.recordFailure(err)
+		return nil
+	}
+	gasCost := estimation.TotalCost
 	profitAnalysis.GasCost = gasCost
+	profitAnalysis.BaseAsset = types.BaseAssetSymbol(decodedTx.Transaction.ChainID)
+	profitAnalysis.GasUsed = estimation.GasUsed
+	profitAnalysis.GasEstimation = estimation
 
-	// 简化盈利计算
-	// 实际项目中需要根据具体交易进行精确计算
-	profit := s.calculateProfit(decodedTx, gasCost)
+	// 策略按交换路径的输入代币计算盈利，Gas成本以基础资产计价：策略内部按输入代币比较，
+	// 结果统一换算为基础资产，下游阈值、熔断和盈亏统计都以基础资产计价
+	rate, err := s.profitRate(ctx, conn, decodedTx)
+	if err != nil {
+		logger.Warn("无法按基础资产计价，跳过", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+		s.count(ctx, &s.gasUnpriced)
+		return nil
+	}
+	profitGas := rate.fromBase(gasCost)
+
+	// 运行启用的策略，取加权得分最高的结果
+	best := s.evaluateStrategies(ctx, conn, decodedTx, profitGas)
+	if best == nil {
+		s.count(ctx, &s.noStrategy)
+		return nil
+	}
+	profit := rate.toBase(best.profit)
+	profitAnalysis.Strategy = best.name
 	profitAnalysis.Profit = profit
-	profitAnalysis.NetProfit = new(big.Int).Sub(profit, gasCost)
+	if len(decodedTx.Path) > 0 {
+		profitAnalysis.ProfitToken = decodedTx.Path[0]
+	}
+	// 乐观估算假设没有竞争，悲观估算假设同向竞争交换先成交，按配置口径取 NetProfit
+	profitAnalysis.NetProfitOptimistic = new(big.Int).Sub(profit, gasCost)
+	profitAnalysis.NetProfitPessimistic = new(big.Int).Sub(rate.toBase(s.pessimisticProfit(ctx, conn, decodedTx, best, competitionWindow)), gasCost)
+	profitAnalysis.NetProfit = profitAnalysis.NetProfitPessimistic
+	if profitEstimate == ProfitEstimateOptimistic {
+		profitAnalysis.NetProfit = profitAnalysis.NetProfitOptimistic
+	}
+
+	// 同一交易对上的同向竞争交换笔数
+	if isV2 {
+		profitAnalysis.CompetitionCount = s.competitionCount(decodedTx, factory, competitionWindow)
+	}
 
 	// 计算成功率（简化）
 	profitAnalysis.SuccessRate = s.calculateSuccessRate(decodedTx)
@@ -136,52 +607,150 @@ func (s *Simulator) SimulateTransaction(ctx context.Context, decodedTx *types.De
 	// 评估风险等级
 	profitAnalysis.RiskLevel = s.assessRiskLevel(decodedTx, profitAnalysis.SuccessRate)
 
+	// 标注预期打包区块
+	profitAnalysis.TargetBlock = s.targetBlock(ctx, conn)
+
+	// 根据交易对储备计算受害者成交价及我们的买入/卖出价
+	s.fillPrices(ctx, conn, decodedTx, best.name, profitAnalysis)
+
+	// 新建交易对可能被重组移除，标记为低可信度
+	profitAnalysis.LowConfidence = s.hasYoungPair(ctx, conn, decodedTx)
+
+	// 执行前复核的结果由调用方处理，不重复计数和记录
+	if isRecheck(ctx) {
+		return profitAnalysis
+	}
+
 	s.mu.Lock()
 	if profitAnalysis.NetProfit.Cmp(big.NewInt(0)) > 0 {
 		s.profitable++
 	}
+	recorder := s.lifecycle
 	s.mu.Unlock()
 
+	// 记录生命周期：模拟完成
+	detail := map[string]interface{}{
+		"net_profit":   profitAnalysis.NetProfit.String(),
+		"gas_cost":     profitAnalysis.GasCost.String(),
+		"success_rate": profitAnalysis.SuccessRate,
+		"risk_level":   profitAnalysis.RiskLevel,
+	}
+	if trace != nil {
+		detail["traced_gas_used"] = trace.gasUsed
+		detail["balance_deltas"] = trace.deltaStrings()
+	}
+	recorder.Emit(profitAnalysis.OpportunityID, lifecycle.StageSimulated, profitAnalysis.TxHash, detail)
+
 	return profitAnalysis
 }
 
-// estimateGasCost 估算Gas成本
-func (s *Simulator) estimateGasCost(decodedTx *types.DecodedTransaction) *big.Int {
-	// 简化Gas估算
-	// 实际项目中需要根据交易复杂度进行精确估算
-	baseGas := uint64(21000)       // 基础Gas
-	additionalGas := uint64(50000) // 交换操作额外Gas
+// estimateGasCost 估算Gas成本（tracedGas 为追踪得到的实际Gas用量，0表示通过 eth_estimateGas 估算）
+func (s *Simulator) estimateGasCost(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction, tracedGas uint64) (*types.GasEstimation, error) {
+	gasUsed := tracedGas
+	if gasUsed == 0 {
+		gasUsed = s.estimateGasUnits(ctx, conn, decodedTx)
+	}
+	totalGas := s.applyGasSafetyMultiplier(gasUsed)
 
-	totalGas := baseGas + additionalGas
+	// EIP-1559 交易按基础费用 + 小费（不超过上限）计价，传统交易使用其Gas价格
+	pricing := s.effectiveGasPrice(ctx, conn, decodedTx.Transaction)
 
-	// 使用交易中的Gas价格或当前网络平均Gas价格
-	gasPrice := decodedTx.Transaction.GasPrice
-	if gasPrice == nil || gasPrice.Cmp(big.NewInt(0)) == 0 {
-		// 如果没有Gas价格，使用默认值
-		gasPrice = big.NewInt(30000000000) // 30 Gwei
+	estimation, err := s.estimateGas(ctx, conn, decodedTx, totalGas, pricing.price)
+	if err != nil {
+		return nil, err
 	}
+	estimation.BaseFee = pricing.baseFee
+	estimation.PriorityFee = pricing.priorityFee
+	estimation.Legacy = pricing.legacy
+	return estimation, nil
+}
+
+// estimateGasUnits 按解码交易重建调用并通过 eth_estimateGas 估算Gas用量，
+// 失败或超时（SimulationTimeout）时回退到固定估算：基础Gas 21000 + 交换操作 50000
+func (s *Simulator) estimateGasUnits(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) uint64 {
+	const fallbackGas = uint64(21000 + 50000)
 
-	gasCost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(totalGas))
-	return gasCost
+	s.mu.RLock()
+	timeout := 10 * time.Second
+	if s.cfg != nil && s.cfg.SimulationTimeout > 0 {
+		timeout = time.Duration(s.cfg.SimulationTimeout) * time.Second
+	}
+	s.mu.RUnlock()
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx := decodedTx.Transaction
+	gas, err := conn.client.EstimateGas(callCtx, ethereum.CallMsg{
+		From:  tx.From,
+		To:    tx.To,
+		Value: tx.Value,
+		Data:  tx.Data,
+	})
+	if err != nil {
+		conn.fail(err)
+		logger.Warn("eth_estimateGas 失败，使用固定估算", "tx_hash", tx.Hash.Hex(), "error", err)
+		s.mu.Lock()
+		s.gasEstimateFailures++
+		s.mu.Unlock()
+		return fallbackGas
+	}
+
+	s.mu.Lock()
+	s.gasEstimated++
+	s.mu.Unlock()
+	return gas
 }
 
-// calculateProfit 计算盈利
-func (s *Simulator) calculateProfit(decodedTx *types.DecodedTransaction, gasCost *big.Int) *big.Int {
-	// 简化盈利计算
-	// 实际项目中需要根据具体交易对和价格进行精确计算
+// applyGasSafetyMultiplier 对Gas估算值应用安全系数（向上取整）
+func (s *Simulator) applyGasSafetyMultiplier(gas uint64) uint64 {
+	s.mu.RLock()
+	multiplier := float64(1)
+	if s.cfg != nil && s.cfg.GasSafetyMultiplier > 1 {
+		multiplier = s.cfg.GasSafetyMultiplier
+	}
+	s.mu.RUnlock()
 
-	// 基于交易金额的百分比估算盈利
-	baseProfit := new(big.Int).Div(decodedTx.Transaction.Value, big.NewInt(100)) // 1%
+	return uint64(math.Ceil(float64(gas) * multiplier))
+}
 
-	// 减去Gas成本
-	netProfit := new(big.Int).Sub(baseProfit, gasCost)
+// calculateProfit 按受害者第一跳V2交易对的储备估算夹子毛盈利（以路径输入代币计价，未扣除Gas成本，Gas只在净盈利中扣除一次）：
+// 我们以配置的仓位规模买入，受害者成交后卖出；不是V2路由的交换盈利为0，储备读取失败时返回错误
+func (s *Simulator) calculateProfit(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error) {
+	pool, err := s.sandwichPool(ctx, conn, decodedTx)
+	if err != nil {
+		return big.NewInt(0), err
+	}
+	if pool == nil {
+		return big.NewInt(0), nil
+	}
 
-	// 确保盈利不为负
-	if netProfit.Cmp(big.NewInt(0)) < 0 {
-		return big.NewInt(0)
+	// 买入规模按受害者的 amountOutMin 截断，受害者无论如何都会回滚时没有盈利
+	amounts := s.sandwich(ctx, pool, s.sniperInput(decodedTx), decodedTx.AmountIn)
+	if amounts == nil {
+		return big.NewInt(0), nil
 	}
+	// 转账税已按每笔交易扣除（实际到账金额低于交换输出）
+	return amounts.profit(), nil
+}
 
-	return netProfit
+// strategyInput 策略的买入仓位：启发式策略按配置的仓位规模，夹子策略与受害者输入相同
+func (s *Simulator) strategyInput(strategy string, decodedTx *types.DecodedTransaction) *big.Int {
+	if strategy == StrategyHeuristic {
+		return s.sniperInput(decodedTx)
+	}
+	return decodedTx.AmountIn
+}
+
+// sniperInput 我们的买入仓位：配置了 SNIPER_INPUT_SIZE 时使用该值，否则与受害者输入相同
+func (s *Simulator) sniperInput(decodedTx *types.DecodedTransaction) *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.cfg != nil && s.cfg.SniperInputSize != nil && s.cfg.SniperInputSize.Sign() > 0 {
+		return s.cfg.SniperInputSize
+	}
+	return decodedTx.AmountIn
 }
 
 // calculateSuccessRate 计算成功率
@@ -204,7 +773,37 @@ func (s *Simulator) calculateSuccessRate(decodedTx *types.DecodedTransaction) fl
 		baseRate *= 0.9
 	}
 
-	return baseRate
+	// 疑似私有订单流（受保护或诱饵），大幅降低成功率
+	if decodedTx.LikelyPrivate {
+		baseRate *= 0.5
+	}
+
+	// 前面存在nonce空缺，可能长时间卡住（空缺填上前无法打包）
+	if decodedTx.NonceBlocked {
+		baseRate *= 0.5
+	}
+
+	return s.clampSuccessRate(baseRate)
+}
+
+// clampSuccessRate 将成功率限制在配置的 [下限, 上限] 内：
+// 启发式模型在极端输入下可能给出0或接近1的值，而实际竞争中
+// 既不存在必然成功的机会，也很少有完全无望的机会，夹紧后风险评估不会看到失真的数值
+func (s *Simulator) clampSuccessRate(rate float64) float64 {
+	s.mu.RLock()
+	floor, ceiling := 0.0, 1.0
+	if s.cfg != nil {
+		floor, ceiling = s.cfg.SuccessRateFloor, s.cfg.SuccessRateCeiling
+	}
+	s.mu.RUnlock()
+
+	if math.IsNaN(rate) || rate < floor {
+		return floor
+	}
+	if rate > ceiling {
+		return ceiling
+	}
+	return rate
 }
 
 // assessRiskLevel 评估风险等级
@@ -218,19 +817,69 @@ func (s *Simulator) assessRiskLevel(decodedTx *types.DecodedTransaction, success
 	}
 }
 
-// reconnect 重新连接RPC
-func (s *Simulator) reconnect() error {
-	client, err := ethclient.Dial(s.rpcURL)
-	if err != nil {
-		return err
+// targetBlock 计算预期打包区块（最新区块 + 配置偏移量）
+func (s *Simulator) targetBlock(ctx context.Context, conn *rpcConn) uint64 {
+	s.mu.RLock()
+	latest := s.latestBlock
+	offset := uint64(1)
+	if s.cfg != nil && s.cfg.TargetBlockOffset > 0 {
+		offset = s.cfg.TargetBlockOffset
 	}
+	s.mu.RUnlock()
 
+	// 尚未收到新区块时，主动查询一次最新区块号
+	if latest == 0 {
+		number, err := conn.client.BlockNumber(ctx)
+		if err != nil {
+			conn.fail(err)
+			logger.Warn("获取最新区块号失败", "error", err)
+			return 0
+		}
+		s.UpdateHead(number)
+		latest = number
+	}
+
+	return latest + offset
+}
+
+// UpdateHead 更新最新区块号
+func (s *Simulator) UpdateHead(blockNumber uint64) {
 	s.mu.Lock()
-	s.client = client
-	s.mu.Unlock()
+	defer s.mu.Unlock()
+	if blockNumber > s.latestBlock {
+		s.latestBlock = blockNumber
+	}
+}
 
-	log.Println("✅ 模拟器RPC连接成功")
-	return nil
+// reconnect 重新连接RPC
+func (s *Simulator) reconnect() error {
+	s.mu.RLock()
+	endpoints, start := s.endpoints, s.endpointIdx
+	s.mu.RUnlock()
+
+	// 从当前节点开始依次尝试，连接成功的节点成为当前节点
+	var lastErr error
+	for i := range endpoints {
+		index := (start + i) % len(endpoints)
+		client, err := ethclient.Dial(endpoints[index])
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		s.mu.Lock()
+		s.client = client
+		s.rpcURL = endpoints[index]
+		s.endpointIdx = index
+		if s.pool == nil {
+			s.pool = newConnPool(endpoints, index, client, 1)
+		}
+		s.mu.Unlock()
+
+		logger.Info("模拟器RPC连接成功")
+		return nil
+	}
+	return lastErr
 }
 
 // GetStats 获取统计信息
@@ -252,12 +901,78 @@ func (s *Simulator) GetStats() map[string]interface{} {
 		"simulated":          s.simulated,
 		"profitable":         s.profitable,
 		"failed":             s.failed,
+		"failures":           s.failureStats(),
+		"superseded":         s.superseded,
+		"warmup_skipped":     s.warmupSkip,
+		"recheck_aborted":    s.recheckAborted,
+		"direction_skipped":  s.directionSkip,
+		"spam_throttled":     s.spamThrottled,
+		"not_replacement":    s.notReplacement,
+		"l1_fee_failures":    s.l1FeeFailures,
+		"attacker_skipped":   s.attackerSkipped,
+		"runway_skipped":     s.runwaySkip,
+		"gas_estimated":      s.gasEstimated,
+		"impact_rejected":    s.impactRejected,
+		"front_run_capped":   s.frontRunCapped,
+		"victim_reverts":     s.victimReverts,
+		"gas_estimate_fails": s.gasEstimateFailures,
+		"traced":             s.traced,
+		"trace_leg_reverted": s.legReverted,
+		"no_strategy":        s.noStrategy,
+		"trace_reverted":     s.traceReverted,
+		"exact_out_reverted": s.exactOutputReverted,
+		"gas_unpriced":       s.gasUnpriced,
+		"mev_resistant":      s.mevResistant,
+		"trace_unsupported":  s.traceUnsupported,
+		"reserve_rejected":   s.reserveRejected,
 		"success_rate":       successRate,
 		"profitability_rate": profitabilityRate,
-		"rpc_url":            s.rpcURL,
+		"latest_block":       s.latestBlock,
+		"workers":            len(s.workers),
+		"latency_ms":         s.latencyEWMA,
+		"fee_cache":          s.feeStats(),
+		"rate_cache_hits":    s.rates.hitCount(),
+		"rpc_pool":           s.poolStats(),
+		"rpc_swaps":          s.rpcSwaps,
+		"rpc_url":            logging.RedactURL(s.activeRPC()),
+	}
+}
+
+// failureStats 按类型的失败统计（调用方需持有读锁）
+func (s *Simulator) failureStats() map[string]int64 {
+	stats := make(map[string]int64, len(FailureKinds))
+	for _, kind := range FailureKinds {
+		stats[kind] = s.failures[kind]
+	}
+	return stats
+}
+
+// poolStats 连接池统计（调用方需持有读锁）
+func (s *Simulator) poolStats() map[string]interface{} {
+	if s.pool == nil {
+		return nil
+	}
+
+	s.pool.mu.RLock()
+	defer s.pool.mu.RUnlock()
+	return map[string]interface{}{
+		"size":   len(s.pool.clients),
+		"repins": s.pool.repins,
+
+		"endpoint_index": s.pool.active,
+		"endpoints":      len(s.pool.endpoints),
+		"failovers":      s.pool.failovers,
 	}
 }
 
+// activeRPC 当前使用的RPC节点：连接池运行后以连接池故障切换后的节点为准（调用方需持有读锁）
+func (s *Simulator) activeRPC() string {
+	if s.pool == nil {
+		return s.rpcURL
+	}
+	return s.pool.activeEndpoint()
+}
+
 // IsConnected 检查是否已连接
 func (s *Simulator) IsConnected() bool {
 	s.mu.RLock()
@@ -265,11 +980,40 @@ func (s *Simulator) IsConnected() bool {
 	return s.client != nil
 }
 
-// SetConfig 设置配置
-func (s *Simulator) SetConfig(cfg *config.SniperConfig) {
+// SetFunnel 设置机会转化漏斗（统计 simulated 阶段）
+func (s *Simulator) SetFunnel(funnel *lifecycle.Funnel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.funnel = funnel
+}
+
+// funnelStage 获取机会转化漏斗
+func (s *Simulator) funnelStage() *lifecycle.Funnel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.funnel
+}
+
+// SetLifecycleRecorder 设置生命周期事件记录器
+func (s *Simulator) SetLifecycleRecorder(recorder *lifecycle.Recorder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lifecycle = recorder
+}
+
+// SetConfig 设置配置及其版本号（版本号会标记在之后产生的分析结果上）
+func (s *Simulator) SetConfig(cfg *config.SniperConfig, version uint64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.cfg = cfg
+	s.cfgVersion = version
+}
+
+// configVersion 当前配置的版本号
+func (s *Simulator) configVersion() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfgVersion
 }
 
 // AdvancedSimulation 高级模拟（预留接口）
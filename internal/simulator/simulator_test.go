@@ -0,0 +1,151 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"mempool-sniper/internal/config"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func TestClampSuccessRate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.SniperConfig
+		rate float64
+		want float64
+	}{
+		{name: "no config", cfg: nil, rate: 0.28, want: 0.28},
+		{name: "default bounds keep the model value", cfg: &config.SniperConfig{SuccessRateFloor: 0, SuccessRateCeiling: 1}, rate: 0.028, want: 0.028},
+		{name: "default bounds keep certainty", cfg: &config.SniperConfig{SuccessRateFloor: 0, SuccessRateCeiling: 1}, rate: 1, want: 1},
+		{name: "raised to the floor", cfg: &config.SniperConfig{SuccessRateFloor: 0.05, SuccessRateCeiling: 0.95}, rate: 0.028, want: 0.05},
+		{name: "lowered to the ceiling", cfg: &config.SniperConfig{SuccessRateFloor: 0.05, SuccessRateCeiling: 0.95}, rate: 0.99, want: 0.95},
+		{name: "inside the bounds", cfg: &config.SniperConfig{SuccessRateFloor: 0.05, SuccessRateCeiling: 0.95}, rate: 0.5, want: 0.5},
+		{name: "NaN falls to the floor", cfg: &config.SniperConfig{SuccessRateFloor: 0.05, SuccessRateCeiling: 0.95}, rate: math.NaN(), want: 0.05},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Simulator{cfg: tt.cfg}
+			if got := s.clampSuccessRate(tt.rate); got != tt.want {
+				t.Errorf("clampSuccessRate(%v) = %v, want %v", tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInWarmupSkipsConfiguredTransactions(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions int64
+		seconds      int
+		started      time.Duration // 工作池已运行的时长
+		calls        int
+		skipped      int64
+		ended        bool // 是否记录一次预热结束日志
+	}{
+		{name: "no warmup", calls: 5, skipped: 0},
+		{name: "first N transactions", transactions: 3, calls: 10, skipped: 3, ended: true},
+		{name: "seconds not yet elapsed", transactions: 3, seconds: 60, calls: 10, skipped: 10},
+		{name: "seconds already elapsed", transactions: 3, seconds: 60, started: time.Minute, calls: 10, skipped: 3, ended: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			defaultLogger := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+			defer slog.SetDefault(defaultLogger)
+
+			s := &Simulator{
+				cfg:       &config.SniperConfig{WarmupTransactions: tt.transactions, WarmupSeconds: tt.seconds},
+				startTime: time.Now().Add(-tt.started),
+			}
+			var skipped int64
+			for i := 0; i < tt.calls; i++ {
+				if s.inWarmup() {
+					if skipped != int64(i) {
+						t.Fatalf("call %d skipped after warmup had ended", i)
+					}
+					skipped++
+				}
+			}
+			if skipped != tt.skipped || s.warmupSkip != tt.skipped {
+				t.Errorf("skipped %d (counter %d), want %d", skipped, s.warmupSkip, tt.skipped)
+			}
+			if s.warmedUp != (tt.calls > int(tt.skipped)) {
+				t.Errorf("warmedUp = %v after %d of %d calls were skipped", s.warmedUp, tt.skipped, tt.calls)
+			}
+
+			want := 0
+			if tt.ended {
+				want = 1
+			}
+			if ended := strings.Count(logs.String(), "预热结束"); ended != want {
+				t.Fatalf("logged the end of warmup %d times, want %d:\n%s", ended, want, logs.String())
+			}
+			if tt.ended && !strings.Contains(logs.String(), fmt.Sprintf(`"skipped":%d`, tt.skipped)) {
+				t.Errorf("end of warmup log does not report %d skipped transactions:\n%s", tt.skipped, logs.String())
+			}
+		})
+	}
+}
+
+func TestTargetBlockAddsOffset(t *testing.T) {
+	node := startNumberedNode(t, 500)
+
+	tests := []struct {
+		name   string
+		cfg    *config.SniperConfig
+		latest uint64 // 0 表示还没收到新区块头，需要向节点查询
+		want   uint64
+	}{
+		{name: "no config", latest: 100, want: 101},
+		{name: "offset unset defaults to next block", cfg: &config.SniperConfig{}, latest: 100, want: 101},
+		{name: "next block", cfg: &config.SniperConfig{TargetBlockOffset: 1}, latest: 100, want: 101},
+		{name: "several blocks ahead", cfg: &config.SniperConfig{TargetBlockOffset: 3}, latest: 100, want: 103},
+		{name: "head queried from the node", cfg: &config.SniperConfig{TargetBlockOffset: 2}, want: 502},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := ethclient.Dial(node.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			s := &Simulator{cfg: tt.cfg, latestBlock: tt.latest}
+
+			if got := s.targetBlock(context.Background(), &rpcConn{client: client}); got != tt.want {
+				t.Errorf("targetBlock() = %d, want %d", got, tt.want)
+			}
+			// 查询到的区块头记为最新区块，后续模拟不再重复查询
+			if tt.latest == 0 && s.latestBlock != 500 {
+				t.Errorf("latestBlock = %d after querying the node, want 500", s.latestBlock)
+			}
+		})
+	}
+}
+
+func TestTargetBlockNodeUnavailable(t *testing.T) {
+	node := startNumberedNode(t, 500)
+	client, err := ethclient.Dial(node.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	node.Close()
+
+	s := &Simulator{cfg: &config.SniperConfig{TargetBlockOffset: 1}}
+	conn := &rpcConn{client: client}
+	if got := s.targetBlock(context.Background(), conn); got != 0 {
+		t.Errorf("targetBlock() = %d with the node down, want 0", got)
+	}
+	if !conn.failed {
+		t.Error("connection not marked failed after a transport error")
+	}
+}
@@ -0,0 +1,145 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"mempool-sniper/pkg/types"
+)
+
+// 策略名称
+const (
+	StrategyHeuristic   = "heuristic"
+	StrategySandwich    = "sandwich"
+	StrategyLiquidation = "liquidation"
+)
+
+// Strategy 机会评估策略：返回该策略下的预估毛盈利（以交换路径输入代币计价，未扣除Gas成本），不适用时返回nil
+type Strategy interface {
+	Name() string
+	Evaluate(ctx context.Context, s *Simulator, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error)
+}
+
+// strategies 已实现的策略（配置校验只接受这些名称）
+var strategies = map[string]Strategy{
+	StrategyHeuristic: heuristicStrategy{},
+	StrategySandwich:  sandwichStrategy{},
+
+	StrategyLiquidation: LiquidationStrategy{},
+}
+
+// strategyCandidate 某个策略的评估结果
+type strategyCandidate struct {
+	name   string
+	profit *big.Int
+	score  float64 // 按权重调整后的净盈利，见 strategyScore
+}
+
+// evaluateStrategies 运行所有启用的策略，返回加权得分最高的结果（均不适用时返回nil）
+func (s *Simulator) evaluateStrategies(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction, gasCost *big.Int) *strategyCandidate {
+	s.mu.RLock()
+	weights := map[string]float64{StrategyHeuristic: 1}
+	if s.cfg != nil && len(s.cfg.Strategies) > 0 {
+		weights = s.cfg.Strategies
+	}
+	s.mu.RUnlock()
+
+	// 按名称排序，得分相同时结果稳定
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var best *strategyCandidate
+	for _, name := range names {
+		strategy, exists := strategies[name]
+		if !exists {
+			continue
+		}
+
+		profit, err := strategy.Evaluate(ctx, s, conn, decodedTx)
+		if err != nil {
+			logger.Warn("策略评估失败", "strategy", name, "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+			continue
+		}
+		if profit == nil {
+			continue
+		}
+
+		// 各策略都返回毛盈利，扣除同一Gas成本后按净盈利比较
+		net, _ := new(big.Float).SetInt(new(big.Int).Sub(profit, gasCost)).Float64()
+		candidate := &strategyCandidate{name: name, profit: profit, score: strategyScore(net, weights[name])}
+		if best == nil || candidate.score > best.score {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// strategyScore 按权重调整净盈利：盈利时乘以权重，亏损时除以权重，
+// 两种情况下权重越高的策略都越优先（亏损乘以权重会让低权重策略反而得分更高）
+func strategyScore(net, weight float64) float64 {
+	if net < 0 {
+		return net / weight
+	}
+	return net * weight
+}
+
+// exceedsOwnImpact 我们自己交易的价格冲击超过上限时计数并返回true（冲击越大实际盈利越不可靠）
+func (s *Simulator) exceedsOwnImpact(ctx context.Context, impactBps uint64) bool {
+	s.mu.RLock()
+	exceeds := s.cfg != nil && s.cfg.MaxOwnImpactBps != 0 && impactBps > s.cfg.MaxOwnImpactBps
+	s.mu.RUnlock()
+
+	if exceeds {
+		s.count(ctx, &s.impactRejected)
+	}
+	return exceeds
+}
+
+// heuristicStrategy 按交易对储备估算配置仓位规模的夹子盈利（任意输入代币，以输入代币计价）
+type heuristicStrategy struct{}
+
+func (heuristicStrategy) Name() string { return StrategyHeuristic }
+
+func (heuristicStrategy) Evaluate(ctx context.Context, s *Simulator, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error) {
+	if !decodedTx.IsSwap || decodedTx.MEVResistant {
+		return nil, nil
+	}
+	profit, err := s.calculateProfit(ctx, conn, decodedTx)
+	if err != nil {
+		// 储备读取失败：记为模拟失败，不给出盈利
+		s.recordFailure(err)
+		return nil, err
+	}
+	return profit, nil
+}
+
+// sandwichStrategy 在受害者第一跳V2交易对上模拟夹子，仓位与受害者输入相同；
+// 仅在输入代币为包装原生代币时适用（盈利以wei计价）
+type sandwichStrategy struct{}
+
+func (sandwichStrategy) Name() string { return StrategySandwich }
+
+func (sandwichStrategy) Evaluate(ctx context.Context, s *Simulator, conn *rpcConn, decodedTx *types.DecodedTransaction) (*big.Int, error) {
+	if !decodedTx.IsSwap || decodedTx.MEVResistant || len(decodedTx.Path) < 2 {
+		return nil, nil
+	}
+	if weth, ok := types.WrappedNative(decodedTx.Transaction.ChainID); !ok || poolPath(decodedTx)[0] != weth {
+		return nil, nil
+	}
+
+	pool, err := s.sandwichPool(ctx, conn, decodedTx)
+	if err != nil || pool == nil {
+		return nil, err
+	}
+
+	// 买入规模按受害者的 amountOutMin 截断，受害者无论如何都会回滚时不适用
+	amounts := s.sandwich(ctx, pool, decodedTx.AmountIn, decodedTx.AmountIn)
+	if amounts == nil || s.exceedsOwnImpact(ctx, amounts.ourImpactBps) {
+		return nil, nil
+	}
+	return amounts.profit(), nil
+}
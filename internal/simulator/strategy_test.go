@@ -0,0 +1,179 @@
+package simulator
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"mempool-sniper/internal/config"
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var strategyRouter = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D") // Uniswap V2 Router02
+
+// fakePairConn 假节点：WETH/USDC 交易对按给定储备返回 getReserves
+func fakePairConn(t *testing.T, reserveWETH, reserveUSDC *big.Int) *rpcConn {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", fakePairEth(t, reserveWETH, reserveUSDC)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	return &rpcConn{client: ethclient.NewClient(rpc.DialInProc(server))}
+}
+
+// fakePairEth fakePairConn 的 eth 命名空间，供需要组合其他方法的假节点使用
+func fakePairEth(t *testing.T, reserveWETH, reserveUSDC *big.Int) *fakeLendingEth {
+	t.Helper()
+	chain := testChain(t, 1)
+	factory, _ := chain.factory(strategyRouter)
+	key := newPairKey(factory, traceWETH, traceUSDC)
+	pair, ok := chain.pairAddress(key)
+	if !ok {
+		t.Fatal("pairAddress() unknown factory")
+	}
+	reserve0, reserve1 := reserveWETH, reserveUSDC
+	if key.token0 != traceWETH {
+		reserve0, reserve1 = reserve1, reserve0
+	}
+
+	eth := &fakeLendingEth{results: make(map[string][]byte)}
+	eth.set(pair, methodGetReserves, nil, lendingWords(reserve0, reserve1, big.NewInt(0)))
+	return eth
+}
+
+func swapTx(amountIn *big.Int) *types.DecodedTransaction {
+	return &types.DecodedTransaction{
+		Transaction:    &types.Transaction{Hash: common.HexToHash("0x01"), To: &strategyRouter, ChainID: big.NewInt(1)},
+		TargetContract: strategyRouter,
+		IsSwap:         true,
+		Path:           []common.Address{traceWETH, traceUSDC},
+		AmountIn:       amountIn,
+	}
+}
+
+func TestHeuristicReturnsGrossProfit(t *testing.T) {
+	reserveIn, reserveOut := eth(1000), eth(2000000)
+	conn := fakePairConn(t, reserveIn, reserveOut)
+	s := &Simulator{chain: testChain(t, 1)}
+
+	victimIn := eth(10)
+	gross := simulateSandwich(victimIn, victimIn, reserveIn, reserveOut, legTax{}).profit()
+	if gross.Sign() <= 0 {
+		t.Fatalf("fixture profit = %s, want > 0", gross)
+	}
+
+	profit, err := heuristicStrategy{}.Evaluate(context.Background(), s, conn, swapTx(victimIn))
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	// Gas成本只在净盈利中扣除一次：策略返回毛盈利，即使毛盈利低于Gas成本也不截断为0
+	if profit.Cmp(gross) != 0 {
+		t.Errorf("Evaluate() = %s, want gross profit %s", profit, gross)
+	}
+}
+
+// fixedStrategy 返回固定毛盈利的策略，记录是否被调用
+type fixedStrategy struct {
+	name   string
+	profit *big.Int
+	ran    *bool
+}
+
+func (f fixedStrategy) Name() string { return f.name }
+
+func (f fixedStrategy) Evaluate(context.Context, *Simulator, *rpcConn, *types.DecodedTransaction) (*big.Int, error) {
+	*f.ran = true
+	return f.profit, nil
+}
+
+// useStrategies 测试期间替换已注册的策略
+func useStrategies(t *testing.T, profits map[string]int64, ran map[string]*bool) {
+	t.Helper()
+	saved := strategies
+	t.Cleanup(func() { strategies = saved })
+
+	strategies = make(map[string]Strategy, len(profits))
+	for name, profit := range profits {
+		flag := new(bool)
+		ran[name] = flag
+		strategies[name] = fixedStrategy{name: name, profit: big.NewInt(profit), ran: flag}
+	}
+}
+
+func TestEvaluateStrategiesSelection(t *testing.T) {
+	gasCost := big.NewInt(100)
+	tests := []struct {
+		name    string
+		profits map[string]int64   // 各策略的毛盈利
+		weights map[string]float64 // 启用的策略及权重
+		want    string
+	}{
+		{
+			name:    "equal weights pick the higher net",
+			profits: map[string]int64{StrategyHeuristic: 300, StrategySandwich: 500},
+			weights: map[string]float64{StrategyHeuristic: 1, StrategySandwich: 1},
+			want:    StrategySandwich,
+		},
+		{
+			name:    "weight outranks a larger net",
+			profits: map[string]int64{StrategyHeuristic: 300, StrategySandwich: 500},
+			weights: map[string]float64{StrategyHeuristic: 3, StrategySandwich: 1},
+			want:    StrategyHeuristic, // 200×3 > 400×1
+		},
+		{
+			name:    "losses prefer the higher weight",
+			profits: map[string]int64{StrategyHeuristic: 50, StrategySandwich: 50},
+			weights: map[string]float64{StrategyHeuristic: 1, StrategySandwich: 2},
+			want:    StrategySandwich, // -50/2 > -50/1
+		},
+		{
+			name:    "disabled strategy does not run",
+			profits: map[string]int64{StrategyHeuristic: 300, StrategySandwich: 5000},
+			weights: map[string]float64{StrategyHeuristic: 1},
+			want:    StrategyHeuristic,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := make(map[string]*bool)
+			useStrategies(t, tt.profits, ran)
+			s := &Simulator{cfg: &config.SniperConfig{Strategies: tt.weights}}
+
+			best := s.evaluateStrategies(context.Background(), nil, swapTx(eth(1)), gasCost)
+			if best == nil || best.name != tt.want {
+				t.Fatalf("evaluateStrategies() = %+v, want %s", best, tt.want)
+			}
+			if best.profit.Int64() != tt.profits[tt.want] {
+				t.Errorf("profit = %s, want the gross %d", best.profit, tt.profits[tt.want])
+			}
+			for name, flag := range ran {
+				if _, enabled := tt.weights[name]; *flag != enabled {
+					t.Errorf("%s ran = %v, enabled = %v", name, *flag, enabled)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulateSkipsMEVResistantSettlements(t *testing.T) {
+	s := &Simulator{failures: make(map[string]int64)}
+	cow := common.HexToAddress("0x9008D19f58AAbD9eD0D60971565AA8510560ab41")
+	settlement := &types.DecodedTransaction{
+		Transaction:    &types.Transaction{Hash: common.HexToHash("0x02"), To: &cow, Value: big.NewInt(0), ChainID: big.NewInt(1)},
+		TargetContract: cow,
+		Method:         "settle",
+		MEVResistant:   true,
+	}
+
+	if analysis := s.simulate(context.Background(), &rpcConn{}, settlement); analysis != nil {
+		t.Fatalf("simulate() = %+v for a MEV-resistant settlement", analysis)
+	}
+	if s.mevResistant != 1 || s.simulated != 0 || len(s.failures) != 0 {
+		t.Errorf("mev_resistant = %d, simulated = %d, failures = %v; want the settlement skipped before simulation", s.mevResistant, s.simulated, s.failures)
+	}
+}
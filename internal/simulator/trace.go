@@ -0,0 +1,313 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Transfer(address indexed from, address indexed to, uint256 value)
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// traceLog callTracer 输出的事件日志（withLog）
+type traceLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// traceFrame callTracer 输出的调用帧
+type traceFrame struct {
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Error   string         `json:"error,omitempty"`
+	Logs    []traceLog     `json:"logs,omitempty"`
+	Calls   []traceFrame   `json:"calls,omitempty"`
+}
+
+// traceResult 解析后的追踪结果
+type traceResult struct {
+	gasUsed  uint64
+	reverted bool
+	deltas   map[common.Address]*big.Int // 我们（发送地址）的各代币余额变化
+}
+
+// parseTrace 解析 callTracer 结果，统计 holder 的代币余额变化（Transfer事件）；
+// 被回滚的子调用中的事件不计入
+func parseTrace(raw json.RawMessage, holder common.Address) (*traceResult, error) {
+	var root traceFrame
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("%w: 解析追踪结果失败: %v", errInvalidResponse, err)
+	}
+
+	result := &traceResult{
+		gasUsed:  uint64(root.GasUsed),
+		reverted: root.Error != "",
+		deltas:   make(map[common.Address]*big.Int),
+	}
+	if !result.reverted {
+		collectTransfers(&root, holder, result.deltas)
+	}
+	return result, nil
+}
+
+// collectTransfers 递归累加调用帧中与 holder 相关的 Transfer 事件
+func collectTransfers(frame *traceFrame, holder common.Address, deltas map[common.Address]*big.Int) {
+	if frame.Error != "" {
+		return
+	}
+
+	for _, entry := range frame.Logs {
+		if len(entry.Topics) != 3 || entry.Topics[0] != transferTopic || len(entry.Data) < 32 {
+			continue
+		}
+		from := common.BytesToAddress(entry.Topics[1].Bytes())
+		to := common.BytesToAddress(entry.Topics[2].Bytes())
+		amount := new(big.Int).SetBytes(entry.Data[:32])
+
+		delta, exists := deltas[entry.Address]
+		if !exists {
+			delta = new(big.Int)
+			deltas[entry.Address] = delta
+		}
+		if to == holder {
+			delta.Add(delta, amount)
+		}
+		if from == holder {
+			delta.Sub(delta, amount)
+		}
+	}
+
+	for i := range frame.Calls {
+		collectTransfers(&frame.Calls[i], holder, deltas)
+	}
+}
+
+// traceCallArgs 构建 debug_traceCall 的调用参数
+func traceCallArgs(tx *types.Transaction) map[string]interface{} {
+	return map[string]interface{}{
+		"from":  tx.From,
+		"to":    tx.To,
+		"gas":   hexutil.Uint64(tx.GasLimit),
+		"value": (*hexutil.Big)(tx.Value),
+		"data":  hexutil.Bytes(tx.Data),
+	}
+}
+
+// isMethodUnsupported 节点是否不支持该RPC方法
+func isMethodUnsupported(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "method not found")
+}
+
+// traceEnabled 是否启用 debug_traceCall 精确模拟（节点不支持时自动关闭）
+func (s *Simulator) traceEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg != nil && s.cfg.TraceSimulation && !s.traceUnsupported
+}
+
+// victimWouldRevert 检查受害者交易在最新状态上是否会回滚：优先使用 debug_traceCall，
+// 节点不支持时回退到 eth_call。只有执行回滚才算回滚，网络/节点错误作为错误返回
+func (s *Simulator) victimWouldRevert(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) (bool, error) {
+	tx := decodedTx.Transaction
+	if tx.To == nil {
+		return false, nil
+	}
+
+	var raw json.RawMessage
+	err := conn.client.Client().CallContext(ctx, &raw, "debug_traceCall", traceCallArgs(tx), "latest", map[string]interface{}{
+		"tracer": "callTracer",
+	})
+	if err == nil {
+		result, err := parseTrace(raw, tx.From)
+		if err != nil {
+			return false, err
+		}
+		return result.reverted, nil
+	}
+
+	if isMethodUnsupported(err) {
+		logger.Warn("节点不支持 debug_traceCall，回退到 eth_call", "tx_hash", tx.Hash.Hex(), "error", err)
+		s.mu.Lock()
+		s.traceUnsupported = true
+		s.mu.Unlock()
+	} else {
+		conn.fail(err)
+		logger.Warn("debug_traceCall 失败，回退到 eth_call", "tx_hash", tx.Hash.Hex(), "error", err)
+	}
+
+	// 回退：eth_call 只能判断是否回滚
+	_, callErr := conn.client.CallContract(ctx, ethereum.CallMsg{
+		From:  tx.From,
+		To:    tx.To,
+		Gas:   tx.GasLimit,
+		Value: tx.Value,
+		Data:  tx.Data,
+	}, nil)
+	if callErr == nil {
+		return false, nil
+	}
+	if isExecutionReverted(callErr) {
+		return true, nil
+	}
+	conn.fail(callErr)
+	return false, callErr
+}
+
+// defaultTraceAccount 未配置钱包地址时追踪买入腿使用的占位发送地址
+var defaultTraceAccount = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+// ourLegRouterABI 构建我们买入腿调用的V2路由方法
+var ourLegRouterABI = mustParseRouterABI(`[
+	{"type":"function","name":"swapExactETHForTokens","stateMutability":"payable","inputs":[
+		{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},
+		{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"amounts","type":"uint256[]"}]},
+	{"type":"function","name":"swapExactTokensForTokens","stateMutability":"nonpayable","inputs":[
+		{"name":"amountIn","type":"uint256"},{"name":"amountOutMin","type":"uint256"},{"name":"path","type":"address[]"},
+		{"name":"to","type":"address"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"amounts","type":"uint256[]"}]}
+]`)
+
+func mustParseRouterABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid router ABI: %v", err))
+	}
+	return parsed
+}
+
+// ourLegCall 我们在受害者第一跳交易对上的买入腿（debug_traceCall 参数），非V2路由时返回nil
+func (s *Simulator) ourLegCall(decodedTx *types.DecodedTransaction, from common.Address, ourIn *big.Int) (map[string]interface{}, error) {
+	call, err := s.ourLeg(decodedTx, from, ourIn)
+	if err != nil || call == nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"from":  call.From,
+		"to":    *call.To,
+		"gas":   hexutil.Uint64(call.Gas),
+		"value": (*hexutil.Big)(call.Value),
+		"data":  hexutil.Bytes(call.Data),
+	}, nil
+}
+
+// FrontRunCall 构建机会的买入腿调用（买入规模取夹子模拟的预测值，没有时按配置的仓位规模），
+// 供模拟盘影子执行签名；非V2路由时返回nil
+func (s *Simulator) FrontRunCall(analysis *types.ProfitAnalysis, from common.Address) (*ethereum.CallMsg, error) {
+	if analysis.Source == nil || analysis.Source.Transaction == nil {
+		return nil, errInvalidTransaction
+	}
+	ourIn := s.sniperInput(analysis.Source)
+	if analysis.Predicted != nil && analysis.Predicted.OurIn != nil {
+		ourIn = analysis.Predicted.OurIn
+	}
+	return s.ourLeg(analysis.Source, from, ourIn)
+}
+
+// ourLeg 我们在受害者第一跳交易对上的买入腿：与受害者同一路由、同一方向，
+// 按 ourIn 买入，输出发送给自己。非V2路由时返回nil
+func (s *Simulator) ourLeg(decodedTx *types.DecodedTransaction, from common.Address, ourIn *big.Int) (*ethereum.CallMsg, error) {
+	if _, exists := s.chain.factory(decodedTx.TargetContract); !exists || len(decodedTx.Path) < 2 || ourIn == nil || ourIn.Sign() <= 0 {
+		return nil, nil
+	}
+
+	path := poolPath(decodedTx)[:2]
+	deadline := new(big.Int).SetUint64(math.MaxUint64)
+	value := new(big.Int)
+	var data []byte
+	var err error
+	if decodedTx.TokenIn == types.NativeToken {
+		value = ourIn
+		data, err = ourLegRouterABI.Pack("swapExactETHForTokens", new(big.Int), path, from, deadline)
+	} else {
+		data, err = ourLegRouterABI.Pack("swapExactTokensForTokens", ourIn, new(big.Int), path, from, deadline)
+	}
+	if err != nil {
+		return nil, err
+	}
+	router := decodedTx.TargetContract
+	return &ethereum.CallMsg{
+		From:  from,
+		To:    &router,
+		Gas:   decodedTx.Transaction.GasLimit,
+		Value: value,
+		Data:  data,
+	}, nil
+}
+
+// traceOurLeg 使用 debug_traceCall 在最新状态上执行我们的买入腿，获取实际Gas用量和我们的代币余额变化。
+// 原生代币输入时覆盖发送地址的余额；代币输入需要发送地址实际持有代币并已授权路由。
+// 返回 nil 表示未能获得追踪数据（按估算值继续）
+func (s *Simulator) traceOurLeg(ctx context.Context, conn *rpcConn, decodedTx *types.DecodedTransaction) *traceResult {
+	s.mu.RLock()
+	from := s.traceAccount
+	s.mu.RUnlock()
+	if from == (common.Address{}) {
+		from = defaultTraceAccount
+	}
+
+	ourIn := s.sniperInput(decodedTx)
+	args, err := s.ourLegCall(decodedTx, from, ourIn)
+	if err != nil || args == nil {
+		return nil
+	}
+
+	traceConfig := map[string]interface{}{
+		"tracer":       "callTracer",
+		"tracerConfig": map[string]interface{}{"withLog": true},
+	}
+	if decodedTx.TokenIn == types.NativeToken {
+		// 余额覆盖：买入金额的两倍，足够支付调用附带的价值
+		traceConfig["stateOverrides"] = map[common.Address]map[string]interface{}{
+			from: {"balance": (*hexutil.Big)(new(big.Int).Lsh(ourIn, 1))},
+		}
+	}
+	var raw json.RawMessage
+	err = conn.client.Client().CallContext(ctx, &raw, "debug_traceCall", args, "latest", traceConfig)
+	if err != nil {
+		if !isMethodUnsupported(err) {
+			conn.fail(err)
+		}
+		logger.Warn("追踪我们的买入腿失败", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+		return nil
+	}
+
+	result, err := parseTrace(raw, from)
+	if err != nil {
+		logger.Warn("追踪我们的买入腿失败", "tx_hash", decodedTx.Transaction.Hash.Hex(), "error", err)
+		return nil
+	}
+	if result.reverted {
+		logger.Debug("我们的买入腿在最新状态上会回滚，按估算值继续", "tx_hash", decodedTx.Transaction.Hash.Hex())
+		s.count(ctx, &s.legReverted)
+		return nil
+	}
+	s.count(ctx, &s.traced)
+	return result
+}
+
+// deltaStrings 余额变化转为字符串（用于生命周期事件）
+func (r *traceResult) deltaStrings() map[string]string {
+	out := make(map[string]string, len(r.deltas))
+	for token, delta := range r.deltas {
+		if delta.Sign() != 0 {
+			out[token.Hex()] = delta.String()
+		}
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	traceRouter = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D") // Uniswap V2 Router02
+	traceWETH   = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	traceUSDC   = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	tracePair   = common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+	traceOurs   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+)
+
+// revertError 节点返回的执行回滚错误（JSON-RPC 错误码 3）
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+// fakeTraceEth 假节点的 eth 命名空间：eth_call 返回预设错误
+type fakeTraceEth struct {
+	callErr error
+}
+
+func (e *fakeTraceEth) Call(args map[string]interface{}, block string) (string, error) {
+	if e.callErr != nil {
+		return "", e.callErr
+	}
+	return "0x", nil
+}
+
+// fakeTraceDebug 假节点的 debug 命名空间：返回预设的 callTracer 结果并记录请求
+type fakeTraceDebug struct {
+	trace  string
+	args   map[string]interface{}
+	config map[string]interface{}
+}
+
+func (d *fakeTraceDebug) TraceCall(args map[string]interface{}, block string, config map[string]interface{}) (json.RawMessage, error) {
+	d.args, d.config = args, config
+	return json.RawMessage(d.trace), nil
+}
+
+// fakeTraceConn 连接到只注册了给定命名空间的进程内假节点
+func fakeTraceConn(t *testing.T, eth *fakeTraceEth, debug *fakeTraceDebug) *rpcConn {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", eth); err != nil {
+		t.Fatal(err)
+	}
+	if debug != nil {
+		if err := server.RegisterName("debug", debug); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(server.Stop)
+	return &rpcConn{client: ethclient.NewClient(rpc.DialInProc(server))}
+}
+
+// topic 地址按事件主题编码
+func topic(address common.Address) string {
+	return common.BytesToHash(address.Bytes()).Hex()
+}
+
+func TestTraceOurLegCollectsGasAndDeltas(t *testing.T) {
+	transfer := transferTopic.Hex()
+	amount := common.BigToHash(big.NewInt(3000e6)).Hex()
+	// 交易对把 USDC 转给我们；被回滚的子调用中的转账不计入
+	canned := `{"gasUsed":"0x1d4c0","calls":[
+		{"gasUsed":"0x100","logs":[{"address":"` + traceUSDC.Hex() + `","topics":["` + transfer + `","` + topic(tracePair) + `","` + topic(traceOurs) + `"],"data":"` + amount + `"}]},
+		{"gasUsed":"0x100","error":"execution reverted","logs":[{"address":"` + traceUSDC.Hex() + `","topics":["` + transfer + `","` + topic(tracePair) + `","` + topic(traceOurs) + `"],"data":"` + amount + `"}]}
+	]}`
+	debug := &fakeTraceDebug{trace: canned}
+	conn := fakeTraceConn(t, &fakeTraceEth{}, debug)
+
+	s := &Simulator{chain: testChain(t, 1), traceAccount: traceOurs}
+	decodedTx := &types.DecodedTransaction{
+		Transaction:    &types.Transaction{Hash: common.HexToHash("0x01"), To: &traceRouter, GasLimit: 250000, ChainID: big.NewInt(1)},
+		TargetContract: traceRouter,
+		TokenIn:        types.NativeToken,
+		Path:           []common.Address{traceWETH, traceUSDC},
+		AmountIn:       big.NewInt(1e18),
+	}
+
+	result := s.traceOurLeg(context.Background(), conn, decodedTx)
+	if result == nil {
+		t.Fatal("traceOurLeg() = nil")
+	}
+	if result.gasUsed != 0x1d4c0 {
+		t.Errorf("gasUsed = %d, want %d", result.gasUsed, 0x1d4c0)
+	}
+	if got := result.deltas[traceUSDC]; got == nil || got.Cmp(big.NewInt(3000e6)) != 0 {
+		t.Errorf("USDC delta = %v, want 3000e6", got)
+	}
+
+	// 追踪的是我们自己的买入腿：从我们的地址调用同一路由，原生代币输入时覆盖我们的余额
+	if from := common.HexToAddress(debug.args["from"].(string)); from != traceOurs {
+		t.Errorf("traced from %s, want our account %s", from.Hex(), traceOurs.Hex())
+	}
+	if to := common.HexToAddress(debug.args["to"].(string)); to != traceRouter {
+		t.Errorf("traced to %s, want router %s", to.Hex(), traceRouter.Hex())
+	}
+	if _, ok := debug.config["stateOverrides"]; !ok {
+		t.Error("native-input leg traced without a balance override")
+	}
+}
+
+func TestVictimWouldRevertFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		callErr error
+		want    bool
+		wantErr bool
+	}{
+		{name: "executes", callErr: nil, want: false},
+		{name: "execution reverted", callErr: revertError{}, want: true},
+		{name: "revert without data", callErr: errors.New("execution reverted"), want: true},
+		{name: "node error is not a revert", callErr: errors.New("header not found"), want: false, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 节点没有 debug 命名空间：回退到 eth_call
+			conn := fakeTraceConn(t, &fakeTraceEth{callErr: tt.callErr}, nil)
+			s := &Simulator{}
+			decodedTx := &types.DecodedTransaction{
+				Transaction: &types.Transaction{Hash: common.HexToHash("0x01"), To: &traceRouter, GasLimit: 250000, Value: new(big.Int)},
+			}
+
+			reverted, err := s.victimWouldRevert(context.Background(), conn, decodedTx)
+			if reverted != tt.want {
+				t.Errorf("victimWouldRevert() = %v, want %v", reverted, tt.want)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("victimWouldRevert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !s.traceUnsupported {
+				t.Error("traceUnsupported not set when the node lacks debug_traceCall")
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Stats 一次统计快照：采集时间和各组件的统计信息
+type Stats struct {
+	Timestamp  time.Time              `json:"timestamp"`
+	Components map[string]interface{} `json:"components"`
+}
+
+// Stats 采集一次带时间戳的统计快照
+func (s *Server) Stats() Stats {
+	return Stats{
+		Timestamp:  time.Now(),
+		Components: s.Snapshot(),
+	}
+}
+
+// Exporter 按固定间隔把统计快照追加到JSONL文件，文件超过大小上限时轮转
+// （path → path.1 → path.2 …，最多保留 backups 个旧文件）
+type Exporter struct {
+	server   *Server
+	path     string
+	interval time.Duration
+	maxBytes int64
+	backups  int
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	written int64
+	rotated int64
+	failed  int64
+}
+
+// NewExporter 创建统计快照导出器（maxBytes <= 0 表示不轮转）
+func NewExporter(server *Server, path string, interval time.Duration, maxBytes int64, backups int) (*Exporter, error) {
+	e := &Exporter{
+		server:   server,
+		path:     path,
+		interval: interval,
+		maxBytes: maxBytes,
+		backups:  backups,
+	}
+	if err := e.open(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// open 以追加方式打开当前文件（调用方需持有 e.mu 或尚未并发使用）
+func (e *Exporter) open() error {
+	file, err := os.OpenFile(e.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open stats export file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat stats export file: %v", err)
+	}
+	e.file = file
+	e.size = info.Size()
+	return nil
+}
+
+// Start 启动定时导出，ctx 取消时写入最后一次快照并关闭文件
+func (e *Exporter) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		log.Printf("📊 统计快照导出已启动: %s (间隔 %v)", e.path, e.interval)
+		for {
+			select {
+			case <-ctx.Done():
+				e.export()
+				e.Close()
+				return
+			case <-ticker.C:
+				e.export()
+			}
+		}
+	}()
+}
+
+// export 写入一次快照，失败只记录日志
+func (e *Exporter) export() {
+	if err := e.Write(e.server.Stats()); err != nil {
+		log.Printf("⚠️ 导出统计快照失败: %v", err)
+	}
+}
+
+// Write 追加一条快照，写入前超过大小上限时先轮转
+func (e *Exporter) Write(stats Stats) error {
+	line, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.file == nil {
+		return fmt.Errorf("stats export file closed")
+	}
+	if e.maxBytes > 0 && e.size > 0 && e.size+int64(len(line)) > e.maxBytes {
+		if err := e.rotateLocked(); err != nil {
+			e.failed++
+			return err
+		}
+	}
+
+	n, err := e.file.Write(line)
+	e.size += int64(n)
+	if err != nil {
+		e.failed++
+		return err
+	}
+	e.written++
+	return nil
+}
+
+// rotateLocked 关闭当前文件并依次重命名旧文件，超出保留数量的最旧文件被覆盖（调用方需持有 e.mu）
+func (e *Exporter) rotateLocked() error {
+	if err := e.file.Close(); err != nil {
+		return err
+	}
+	e.file = nil
+
+	if e.backups > 0 {
+		for i := e.backups - 1; i >= 1; i-- {
+			older := fmt.Sprintf("%s.%d", e.path, i)
+			if _, err := os.Stat(older); err == nil {
+				if err := os.Rename(older, fmt.Sprintf("%s.%d", e.path, i+1)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := os.Rename(e.path, e.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(e.path); err != nil {
+		return err
+	}
+
+	e.rotated++
+	return e.open()
+}
+
+// GetStats 获取统计信息
+func (e *Exporter) GetStats() map[string]interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return map[string]interface{}{
+		"written":      e.written,
+		"rotated":      e.rotated,
+		"failed":       e.failed,
+		"current_size": e.size,
+	}
+}
+
+// Close 关闭文件
+func (e *Exporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
@@ -0,0 +1,141 @@
+package status
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// readSnapshots 读取JSONL文件中的全部快照
+func readSnapshots(t *testing.T, path string) []Stats {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var snapshots []Stats
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var stats Stats
+		if err := json.Unmarshal(scanner.Bytes(), &stats); err != nil {
+			t.Fatalf("%s: invalid snapshot %q: %v", path, scanner.Text(), err)
+		}
+		snapshots = append(snapshots, stats)
+	}
+	return snapshots
+}
+
+func TestExporterWritesAtInterval(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	server := NewServer("127.0.0.1:0", false)
+	var calls atomic.Int64
+	server.Register("decoder", func() map[string]interface{} {
+		return map[string]interface{}{"processed": calls.Add(1)}
+	})
+
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	exporter, err := NewExporter(server, path, interval, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	exporter.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for exporter.GetStats()["written"].(int64) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats = %v after 5s, want 3 snapshots", exporter.GetStats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	// 取消时写入最后一次快照并关闭文件
+	cancel()
+	for {
+		exporter.mu.Lock()
+		closed := exporter.file == nil
+		exporter.mu.Unlock()
+		if closed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("exporter did not close the file after cancellation")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	snapshots := readSnapshots(t, path)
+	if int64(len(snapshots)) != exporter.GetStats()["written"] || len(snapshots) < 4 {
+		t.Fatalf("%d snapshots in the file, stats %v", len(snapshots), exporter.GetStats())
+	}
+	// 定时快照之间间隔一个周期（最后一次是取消时写入的，不按周期）
+	for i := 1; i < len(snapshots)-1; i++ {
+		if gap := snapshots[i].Timestamp.Sub(snapshots[i-1].Timestamp); gap < interval/2 {
+			t.Errorf("snapshot %d written %v after the previous one, want about %v", i, gap, interval)
+		}
+	}
+	for i, snapshot := range snapshots {
+		decoder, ok := snapshot.Components["decoder"].(map[string]interface{})
+		if !ok || decoder["processed"] != float64(i+1) {
+			t.Errorf("snapshot %d components = %v, want the merged stats of every source", i, snapshot.Components)
+		}
+	}
+}
+
+func TestExporterRotates(t *testing.T) {
+	stats := Stats{Timestamp: time.Unix(1700000000, 0).UTC(), Components: map[string]interface{}{"decoder": map[string]interface{}{"processed": 1}}}
+	line, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lineSize := int64(len(line) + 1)
+
+	tests := []struct {
+		name    string
+		backups int
+		files   []int // 每个文件中的快照数（当前文件在前）
+	}{
+		{name: "keeps backups", backups: 2, files: []int{1, 2, 2}},
+		{name: "no backups", backups: 0, files: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "stats.jsonl")
+			// 每个文件放两条快照
+			exporter, err := NewExporter(NewServer("127.0.0.1:0", false), path, time.Hour, 2*lineSize, tt.backups)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer exporter.Close()
+
+			for i := 0; i < 7; i++ {
+				if err := exporter.Write(stats); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, want := range tt.files {
+				name := path
+				if i > 0 {
+					name = fmt.Sprintf("%s.%d", path, i)
+				}
+				if got := len(readSnapshots(t, name)); got != want {
+					t.Errorf("%s has %d snapshots, want %d", filepath.Base(name), got, want)
+				}
+			}
+			if _, err := os.Stat(fmt.Sprintf("%s.%d", path, len(tt.files))); !os.IsNotExist(err) {
+				t.Errorf("%s.%d exists beyond the backup limit", filepath.Base(path), len(tt.files))
+			}
+			if got := exporter.GetStats(); got["rotated"] != int64(3) || got["written"] != int64(7) || got["current_size"] != lineSize {
+				t.Errorf("stats = %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package status
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+// 时间序列名称
+const (
+	SeriesNetProfit = "net_profit" // 净盈利 (按基础资产计价，18位精度换算)
+	SeriesRisk      = "risk"       // 风险等级 (1=low, 2=medium, 3=high)
+)
+
+// riskScores 风险等级数值化
+var riskScores = map[string]float64{
+	"low":    1,
+	"medium": 2,
+	"high":   3,
+}
+
+// opportunityPoint 一个机会对应的时间点
+type opportunityPoint struct {
+	timestamp int64 // 毫秒
+	netProfit float64
+	risk      float64
+}
+
+// OpportunityLog 最近的可执行机会（环形缓冲），以 Grafana JSON 数据源格式输出
+type OpportunityLog struct {
+	mu     sync.RWMutex
+	points []opportunityPoint
+	next   int
+	size   int
+}
+
+// NewOpportunityLog 创建机会记录（保留最近 size 个）
+func NewOpportunityLog(size int) *OpportunityLog {
+	if size < 1 {
+		size = 1
+	}
+	return &OpportunityLog{points: make([]opportunityPoint, 0, size), size: size}
+}
+
+// Record 记录一个可执行机会
+func (l *OpportunityLog) Record(analysis *types.ProfitAnalysis) {
+	if l == nil {
+		return
+	}
+
+	point := opportunityPoint{
+		timestamp: time.Now().UnixMilli(),
+		netProfit: weiToEther(analysis.NetProfit),
+		risk:      riskScores[analysis.RiskLevel],
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.points) < l.size {
+		l.points = append(l.points, point)
+		return
+	}
+	l.points[l.next] = point
+	l.next = (l.next + 1) % l.size
+}
+
+// grafanaSeries Grafana JSON 数据源的时间序列：datapoints 为 [值, 毫秒时间戳]
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// grafanaQuery /query 请求体（只使用时间范围和目标）
+type grafanaQuery struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+}
+
+// series 按时间顺序输出 [from, to] 内的时间序列（零值表示不限制）
+func (l *OpportunityLog) series(targets []string, from, to time.Time) []grafanaSeries {
+	l.mu.RLock()
+	ordered := make([]opportunityPoint, 0, len(l.points))
+	ordered = append(ordered, l.points[l.next:]...)
+	ordered = append(ordered, l.points[:l.next]...)
+	l.mu.RUnlock()
+
+	result := make([]grafanaSeries, 0, len(targets))
+	for _, target := range targets {
+		series := grafanaSeries{Target: target, Datapoints: [][2]float64{}}
+		for _, point := range ordered {
+			if !from.IsZero() && point.timestamp < from.UnixMilli() {
+				continue
+			}
+			if !to.IsZero() && point.timestamp > to.UnixMilli() {
+				continue
+			}
+			value := point.netProfit
+			if target == SeriesRisk {
+				value = point.risk
+			}
+			series.Datapoints = append(series.Datapoints, [2]float64{value, float64(point.timestamp)})
+		}
+		result = append(result, series)
+	}
+	return result
+}
+
+// handleRoot 数据源连通性测试
+func (l *OpportunityLog) handleRoot(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleSearch 可查询的序列名称
+func (l *OpportunityLog) handleSearch(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, []string{SeriesNetProfit, SeriesRisk})
+}
+
+// handleQuery 返回请求的时间序列；GET 或空请求体时返回全部序列
+func (l *OpportunityLog) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var query grafanaQuery
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			http.Error(w, "invalid query: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	targets := make([]string, 0, len(query.Targets))
+	for _, target := range query.Targets {
+		if target.Target == SeriesNetProfit || target.Target == SeriesRisk {
+			targets = append(targets, target.Target)
+		}
+	}
+	if len(targets) == 0 {
+		targets = []string{SeriesNetProfit, SeriesRisk}
+	}
+
+	writeJSON(w, l.series(targets, query.Range.From, query.Range.To))
+}
+
+func weiToEther(value *big.Int) float64 {
+	if value == nil {
+		return 0
+	}
+	ether, _ := new(big.Float).Quo(new(big.Float).SetInt(value), big.NewFloat(1e18)).Float64()
+	return ether
+}
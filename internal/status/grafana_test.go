@@ -0,0 +1,162 @@
+package status
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+// recordProfits 按顺序记录净盈利（ETH）和风险等级对应的机会
+func recordProfits(log *OpportunityLog, profits []int64, risks []string) {
+	for i, profit := range profits {
+		wei := new(big.Int).Mul(big.NewInt(profit), big.NewInt(1e15)) // profit 单位为 0.001 ETH
+		log.Record(&types.ProfitAnalysis{NetProfit: wei, RiskLevel: risks[i%len(risks)]})
+	}
+}
+
+// grafanaRequest 请求状态服务并解码为通用 JSON，检查的是线上格式而不是内部结构体
+func grafanaRequest(t *testing.T, handler http.Handler, method, path, body string) (int, interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		return rec.Code, nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("%s %s: invalid JSON %q: %v", method, path, rec.Body.String(), err)
+	}
+	return rec.Code, decoded
+}
+
+// 输出符合 Grafana JSON 数据源格式：[{"target": 名称, "datapoints": [[值, 毫秒时间戳], ...]}]，
+// 环形缓冲写满后按时间顺序输出最近的机会
+func TestGrafanaQueryShape(t *testing.T) {
+	before := time.Now().UnixMilli()
+	log := NewOpportunityLog(3)
+	recordProfits(log, []int64{1, 2, 3, 4, 5}, []string{"low", "medium", "high"})
+	after := time.Now().UnixMilli()
+
+	server := NewServer("127.0.0.1:0", false)
+	server.SetOpportunityLog(log)
+	handler := server.Handler()
+
+	if code, _ := grafanaRequest(t, handler, http.MethodGet, "/grafana/", ""); code != http.StatusOK {
+		t.Errorf("GET /grafana/ = %d, want %d", code, http.StatusOK)
+	}
+	if _, search := grafanaRequest(t, handler, http.MethodPost, "/grafana/search", ""); !reflect.DeepEqual(search, []interface{}{SeriesNetProfit, SeriesRisk}) {
+		t.Errorf("search = %v, want [%s %s]", search, SeriesNetProfit, SeriesRisk)
+	}
+
+	// 最近3个机会：0.003/0.004/0.005 ETH，风险 high/low/medium
+	want := map[string][]float64{
+		SeriesNetProfit: {0.003, 0.004, 0.005},
+		SeriesRisk:      {3, 1, 2},
+	}
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		targets []string
+	}{
+		{name: "get returns all series", method: http.MethodGet, targets: []string{SeriesNetProfit, SeriesRisk}},
+		{name: "empty body returns all series", method: http.MethodPost, targets: []string{SeriesNetProfit, SeriesRisk}},
+		{name: "requested order", method: http.MethodPost, body: `{"targets":[{"target":"risk"},{"target":"net_profit"}]}`,
+			targets: []string{SeriesRisk, SeriesNetProfit}},
+		{name: "unknown target ignored", method: http.MethodPost, body: `{"targets":[{"target":"gas"},{"target":"risk"}]}`,
+			targets: []string{SeriesRisk}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, decoded := grafanaRequest(t, handler, tt.method, "/grafana/query", tt.body)
+			if code != http.StatusOK {
+				t.Fatalf("%s /grafana/query = %d, want %d", tt.method, code, http.StatusOK)
+			}
+			series, ok := decoded.([]interface{})
+			if !ok || len(series) != len(tt.targets) {
+				t.Fatalf("query returned %v, want %d series", decoded, len(tt.targets))
+			}
+			for i, target := range tt.targets {
+				entry, ok := series[i].(map[string]interface{})
+				if !ok || len(entry) != 2 || entry["target"] != target {
+					t.Fatalf("series %d = %v, want target %q with datapoints only", i, series[i], target)
+				}
+				points, ok := entry["datapoints"].([]interface{})
+				if !ok || len(points) != len(want[target]) {
+					t.Fatalf("%s datapoints = %v, want %d points", target, entry["datapoints"], len(want[target]))
+				}
+				last := float64(before)
+				for j, raw := range points {
+					point, ok := raw.([]interface{})
+					if !ok || len(point) != 2 {
+						t.Fatalf("%s point %d = %v, want [value, timestamp]", target, j, raw)
+					}
+					value, _ := point[0].(float64)
+					timestamp, _ := point[1].(float64)
+					if value != want[target][j] {
+						t.Errorf("%s point %d value = %v, want %v", target, j, point[0], want[target][j])
+					}
+					if timestamp < last || timestamp > float64(after) {
+						t.Errorf("%s point %d timestamp = %v, want ms in [%d, %d] and not before the previous point", target, j, point[1], int64(last), after)
+					}
+					last = timestamp
+				}
+			}
+		})
+	}
+}
+
+func TestGrafanaQueryRange(t *testing.T) {
+	log := NewOpportunityLog(10)
+	recordProfits(log, []int64{1, 2}, []string{"low"})
+	server := NewServer("127.0.0.1:0", false)
+	server.SetOpportunityLog(log)
+	handler := server.Handler()
+
+	now := time.Now()
+	tests := []struct {
+		name   string
+		from   time.Time
+		to     time.Time
+		points int
+	}{
+		{name: "covers all points", from: now.Add(-time.Hour), to: now.Add(time.Hour), points: 2},
+		{name: "in the future", from: now.Add(time.Hour), to: now.Add(2 * time.Hour)},
+		{name: "in the past", from: now.Add(-2 * time.Hour), to: now.Add(-time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"range":{"from":"` + tt.from.UTC().Format(time.RFC3339Nano) + `","to":"` + tt.to.UTC().Format(time.RFC3339Nano) +
+				`"},"targets":[{"target":"net_profit"}]}`
+			_, decoded := grafanaRequest(t, handler, http.MethodPost, "/grafana/query", body)
+			series, _ := decoded.([]interface{})
+			if len(series) != 1 {
+				t.Fatalf("query returned %v, want one series", decoded)
+			}
+			// 范围内没有点时输出空数组而不是 null，Grafana 不接受 null
+			points, ok := series[0].(map[string]interface{})["datapoints"].([]interface{})
+			if !ok || len(points) != tt.points {
+				t.Errorf("datapoints = %v, want %d points", series[0], tt.points)
+			}
+		})
+	}
+}
+
+func TestGrafanaEndpoints(t *testing.T) {
+	server := NewServer("127.0.0.1:0", false)
+	if code, _ := grafanaRequest(t, server.Handler(), http.MethodGet, "/grafana/query", ""); code != http.StatusNotFound {
+		t.Errorf("GET /grafana/query without a log = %d, want %d", code, http.StatusNotFound)
+	}
+
+	server.SetOpportunityLog(NewOpportunityLog(10))
+	if code, _ := grafanaRequest(t, server.Handler(), http.MethodPost, "/grafana/query", "{"); code != http.StatusBadRequest {
+		t.Errorf("POST /grafana/query with invalid JSON = %d, want %d", code, http.StatusBadRequest)
+	}
+}
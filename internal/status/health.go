@@ -0,0 +1,51 @@
+package status
+
+import (
+	"net/http"
+	"time"
+)
+
+// ConnectionCheck 连接状态检查（返回 true 表示连接正常）
+type ConnectionCheck func() bool
+
+// RegisterConnection 注册连接状态检查，所有检查都通过时 /status 返回200，否则返回503
+func (s *Server) RegisterConnection(name string, check ConnectionCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connections[name] = check
+}
+
+// Health 当前连接状态及整体是否就绪
+func (s *Server) Health() (map[string]bool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ready := true
+	connections := make(map[string]bool, len(s.connections))
+	for name, check := range s.connections {
+		connections[name] = check()
+		ready = ready && connections[name]
+	}
+	return connections, ready
+}
+
+// handleHealthz 存活探针：进程能响应即返回200
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+// handleStatus 就绪探针：连接状态 + 各组件统计，任一连接异常时返回503
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	connections, ready := s.Health()
+	if !ready {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, map[string]interface{}{
+		"ready":       ready,
+		"uptime":      time.Since(s.started).String(),
+		"connections": connections,
+		"components":  s.Snapshot(),
+	})
+}
@@ -0,0 +1,132 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// StatsSource 统计信息来源（各组件的 GetStats）
+type StatsSource func() map[string]interface{}
+
+// Server 状态服务：输出各组件统计信息，可选挂载 pprof 调试接口
+type Server struct {
+	addr    string
+	pprof   bool
+	started time.Time
+
+	mu            sync.RWMutex
+	sources       map[string]StatsSource
+	opportunities *OpportunityLog // 最近机会的时间序列（为nil时不挂载 /grafana）
+
+	connections map[string]ConnectionCheck // 连接状态检查（/status 就绪判断）
+}
+
+// NewServer 创建状态服务（enablePprof 为 true 时挂载 /debug/pprof/）
+func NewServer(addr string, enablePprof bool) *Server {
+	return &Server{
+		addr:        addr,
+		pprof:       enablePprof,
+		started:     time.Now(),
+		sources:     make(map[string]StatsSource),
+		connections: make(map[string]ConnectionCheck),
+	}
+}
+
+// Register 注册统计信息来源
+func (s *Server) Register(name string, source StatsSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources[name] = source
+}
+
+// SetOpportunityLog 设置最近机会记录，挂载 Grafana JSON 数据源接口 /grafana/
+func (s *Server) SetOpportunityLog(log *OpportunityLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opportunities = log
+}
+
+// Handler 构建HTTP路由
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", s.handleStats)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/debug/goroutines", handleGoroutines)
+
+	s.mu.RLock()
+	opportunities := s.opportunities
+	s.mu.RUnlock()
+	if opportunities != nil {
+		mux.HandleFunc("/grafana/", opportunities.handleRoot)
+		mux.HandleFunc("/grafana/search", opportunities.handleSearch)
+		mux.HandleFunc("/grafana/query", opportunities.handleQuery)
+	}
+
+	if s.pprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+// Start 启动状态服务，ctx 取消时关闭
+func (s *Server) Start(ctx context.Context) {
+	server := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		log.Printf("📊 状态服务已启动: http://%s/stats /healthz /status (pprof: %v)", s.addr, s.pprof)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ 状态服务异常退出: %v", err)
+		}
+	}()
+}
+
+// Snapshot 汇总所有来源的统计信息
+func (s *Server) Snapshot() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[string]interface{}, len(s.sources))
+	for name, source := range s.sources {
+		snapshot[name] = source()
+	}
+	return snapshot
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Snapshot())
+}
+
+func handleGoroutines(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"goroutines": runtime.NumGoroutine(),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("⚠️ 状态服务输出失败: %v", err)
+	}
+}
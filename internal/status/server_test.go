@@ -0,0 +1,36 @@
+package status
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPprofMountedOnlyWhenEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		pprof  bool
+		status int
+	}{
+		{name: "enabled", pprof: true, status: http.StatusOK},
+		{name: "disabled", pprof: false, status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewServer("127.0.0.1:0", tt.pprof).Handler()
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+			if rec.Code != tt.status {
+				t.Errorf("GET /debug/pprof/ = %d, want %d", rec.Code, tt.status)
+			}
+
+			// goroutine 数量不受开关影响
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/goroutines", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("GET /debug/goroutines = %d, want %d", rec.Code, http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+
+	_ "modernc.org/sqlite" // 纯Go实现的SQLite驱动（无需cgo，可交叉编译）
+)
+
+// migrations 按顺序执行的建表/升级语句，已执行的版本记录在 PRAGMA user_version 中
+// 只能追加，不能修改已发布的语句
+var migrations = []string{
+	`CREATE TABLE opportunities (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		opportunity_id TEXT    NOT NULL,
+		tx_hash        TEXT    NOT NULL,
+		contract       TEXT    NOT NULL,
+		method         TEXT    NOT NULL,
+		strategy       TEXT    NOT NULL,
+		profit         TEXT    NOT NULL,
+		gas_cost       TEXT    NOT NULL,
+		net_profit     TEXT    NOT NULL,
+		success_rate   REAL    NOT NULL,
+		risk_level     TEXT    NOT NULL,
+		target_block   INTEGER NOT NULL,
+		accepted       INTEGER NOT NULL,
+		created_at     INTEGER NOT NULL
+	);
+	CREATE INDEX idx_opportunities_tx_hash ON opportunities(tx_hash);
+	CREATE INDEX idx_opportunities_created_at ON opportunities(created_at);`,
+	`CREATE TABLE shadow_trades (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		opportunity_id TEXT    NOT NULL,
+		victim_tx      TEXT    NOT NULL,
+		shadow_tx      TEXT    NOT NULL,
+		account        TEXT    NOT NULL,
+		nonce          INTEGER NOT NULL,
+		target_block   INTEGER NOT NULL,
+		net_profit     TEXT    NOT NULL,
+		created_at     INTEGER NOT NULL
+	);
+	CREATE INDEX idx_shadow_trades_created_at ON shadow_trades(created_at);`,
+}
+
+// SQLiteStore 基于SQLite的盈利机会存储
+// 金额以十进制字符串保存（wei可能超出 INTEGER 范围），时间为Unix毫秒
+type SQLiteStore struct {
+	db *sql.DB
+
+	mu     sync.Mutex
+	saved  int64
+	failed int64
+	shadow int64 // 已保存的模拟盘影子成交数
+}
+
+// OpenSQLite 打开（不存在时创建）SQLite数据库并执行未完成的迁移
+// path 为 ":memory:" 时使用内存数据库
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %v", err)
+	}
+	// SQLite 同一时间只允许一个写入者；内存数据库每个连接是独立的库
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure sqlite database: %v", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrate 执行尚未执行的迁移（每个迁移在单独的事务中执行）
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("数据库结构版本 %d 高于程序支持的版本 %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration: %v", err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("迁移到版本 %d 失败: %v", i+1, err)
+		}
+		// PRAGMA 不支持参数绑定
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("迁移到版本 %d 失败: %v", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("迁移到版本 %d 失败: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// SaveOpportunity 记录一个盈利机会
+func (s *SQLiteStore) SaveOpportunity(ctx context.Context, analysis *types.ProfitAnalysis, accepted bool) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO opportunities (opportunity_id, tx_hash, contract, method, strategy, profit, gas_cost,
+			net_profit, success_rate, risk_level, target_block, accepted, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		analysis.OpportunityID,
+		analysis.TxHash.Hex(),
+		analysis.TargetContract.Hex(),
+		analysis.Method,
+		analysis.Strategy,
+		amountString(analysis.Profit),
+		amountString(analysis.GasCost),
+		amountString(analysis.NetProfit),
+		analysis.SuccessRate,
+		analysis.RiskLevel,
+		int64(analysis.TargetBlock),
+		accepted,
+		time.Now().UnixMilli(),
+	)
+
+	s.mu.Lock()
+	if err != nil {
+		s.failed++
+	} else {
+		s.saved++
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to save opportunity: %v", err)
+	}
+	return nil
+}
+
+// SaveShadowTrade 记录一笔模拟盘影子成交
+func (s *SQLiteStore) SaveShadowTrade(ctx context.Context, trade *types.ShadowTrade) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO shadow_trades (opportunity_id, victim_tx, shadow_tx, account, nonce, target_block, net_profit, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		trade.OpportunityID,
+		trade.VictimTx.Hex(),
+		trade.ShadowTx.Hex(),
+		trade.Account.Hex(),
+		int64(trade.Nonce),
+		int64(trade.TargetBlock),
+		amountString(trade.NetProfit),
+		time.Now().UnixMilli(),
+	)
+
+	s.mu.Lock()
+	if err != nil {
+		s.failed++
+	} else {
+		s.shadow++
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to save shadow trade: %v", err)
+	}
+	return nil
+}
+
+// Close 关闭数据库
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// GetStats 获取统计信息
+func (s *SQLiteStore) GetStats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"saved":         s.saved,
+		"failed":        s.failed,
+		"shadow_trades": s.shadow,
+	}
+}
+
+// amountString 金额转为十进制字符串（nil 记为 "0"）
+func amountString(amount *big.Int) string {
+	if amount == nil {
+		return "0"
+	}
+	return amount.String()
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSaveShadowTradeRoundTrip(t *testing.T) {
+	store, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	trade := &types.ShadowTrade{
+		OpportunityID: "opp-1",
+		VictimTx:      common.HexToHash("0x01"),
+		ShadowTx:      common.HexToHash("0x02"),
+		Account:       common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Nonce:         7,
+		TargetBlock:   19000001,
+		NetProfit:     big.NewInt(-12345),
+	}
+	if err := store.SaveShadowTrade(context.Background(), trade); err != nil {
+		t.Fatalf("SaveShadowTrade() error = %v", err)
+	}
+
+	var got types.ShadowTrade
+	var victim, shadow, account, netProfit string
+	err = store.db.QueryRow(`SELECT opportunity_id, victim_tx, shadow_tx, account, nonce, target_block, net_profit FROM shadow_trades`).
+		Scan(&got.OpportunityID, &victim, &shadow, &account, &got.Nonce, &got.TargetBlock, &netProfit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.VictimTx, got.ShadowTx, got.Account = common.HexToHash(victim), common.HexToHash(shadow), common.HexToAddress(account)
+	got.NetProfit, _ = new(big.Int).SetString(netProfit, 10)
+
+	if got.OpportunityID != trade.OpportunityID || got.VictimTx != trade.VictimTx || got.ShadowTx != trade.ShadowTx ||
+		got.Account != trade.Account || got.Nonce != trade.Nonce || got.TargetBlock != trade.TargetBlock || got.NetProfit.Cmp(trade.NetProfit) != 0 {
+		t.Errorf("round trip = %+v, want %+v", got, *trade)
+	}
+}
+
+// sharedMemoryDB 同一进程内多次打开共享的内存数据库（至少一个连接打开时保留）
+func sharedMemoryDB(t *testing.T) string {
+	t.Helper()
+	return "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+}
+
+func TestSaveOpportunityRoundTrip(t *testing.T) {
+	store, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	analysis := &types.ProfitAnalysis{
+		OpportunityID:  "opp-1",
+		TxHash:         common.HexToHash("0x01"),
+		TargetContract: common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"),
+		Method:         "swapExactETHForTokens",
+		Strategy:       "sandwich",
+		Profit:         new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e3)), // 超出 INTEGER 范围
+		GasCost:        big.NewInt(3e15),
+		NetProfit:      big.NewInt(-3e15),
+		SuccessRate:    0.42,
+		RiskLevel:      "HIGH",
+		TargetBlock:    19000001,
+	}
+	if err := store.SaveOpportunity(context.Background(), analysis, true); err != nil {
+		t.Fatalf("SaveOpportunity() error = %v", err)
+	}
+
+	var got types.ProfitAnalysis
+	var txHash, contract, profit, gasCost, netProfit string
+	var accepted bool
+	err = store.db.QueryRow(`SELECT opportunity_id, tx_hash, contract, method, strategy, profit, gas_cost, net_profit,
+		success_rate, risk_level, target_block, accepted FROM opportunities`).
+		Scan(&got.OpportunityID, &txHash, &contract, &got.Method, &got.Strategy, &profit, &gasCost, &netProfit,
+			&got.SuccessRate, &got.RiskLevel, &got.TargetBlock, &accepted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.TxHash, got.TargetContract = common.HexToHash(txHash), common.HexToAddress(contract)
+	got.Profit, _ = new(big.Int).SetString(profit, 10)
+	got.GasCost, _ = new(big.Int).SetString(gasCost, 10)
+	got.NetProfit, _ = new(big.Int).SetString(netProfit, 10)
+
+	if got.OpportunityID != analysis.OpportunityID || got.TxHash != analysis.TxHash || got.TargetContract != analysis.TargetContract ||
+		got.Method != analysis.Method || got.Strategy != analysis.Strategy || got.Profit.Cmp(analysis.Profit) != 0 ||
+		got.GasCost.Cmp(analysis.GasCost) != 0 || got.NetProfit.Cmp(analysis.NetProfit) != 0 || got.SuccessRate != analysis.SuccessRate ||
+		got.RiskLevel != analysis.RiskLevel || got.TargetBlock != analysis.TargetBlock || !accepted {
+		t.Errorf("round trip = %+v (accepted %v), want %+v", got, accepted, *analysis)
+	}
+}
+
+func TestReopenDoesNotRerunMigrations(t *testing.T) {
+	path := sharedMemoryDB(t)
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.SaveShadowTrade(context.Background(), &types.ShadowTrade{OpportunityID: "opp-1", NetProfit: big.NewInt(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 再次打开同一数据库：迁移已全部执行，不重复建表，已有数据保留
+	reopened, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite() on a migrated database error = %v", err)
+	}
+	defer reopened.Close()
+	if err := migrate(reopened.db); err != nil {
+		t.Fatalf("migrate() on a migrated database error = %v", err)
+	}
+
+	var version, trades int
+	if err := reopened.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.db.QueryRow("SELECT COUNT(*) FROM shadow_trades").Scan(&trades); err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) || trades != 1 {
+		t.Errorf("user_version = %d, shadow trades = %d; want %d and the existing trade", version, trades, len(migrations))
+	}
+}
+
+func TestOpenAppliesPendingMigrations(t *testing.T) {
+	path := sharedMemoryDB(t)
+	// 旧版本程序创建的数据库：只执行了第一个迁移
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(migrations[0] + "; PRAGMA user_version = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite() error = %v", err)
+	}
+	defer store.Close()
+	if err := store.SaveShadowTrade(context.Background(), &types.ShadowTrade{OpportunityID: "opp-1", NetProfit: big.NewInt(1)}); err != nil {
+		t.Errorf("SaveShadowTrade() after upgrade error = %v", err)
+	}
+
+	// 数据库版本高于程序支持的版本时拒绝打开
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(migrations)+1)); err != nil {
+		t.Fatal(err)
+	}
+	if newer, err := OpenSQLite(path); err == nil {
+		newer.Close()
+		t.Error("OpenSQLite() accepted a database from a newer version")
+	}
+}
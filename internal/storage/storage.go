@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"context"
+
+	"mempool-sniper/pkg/types"
+)
+
+// Store 盈利机会持久化（用于回测和审计）
+type Store interface {
+	// SaveOpportunity 记录一个盈利机会，accepted 表示是否通过了全部门槛
+	SaveOpportunity(ctx context.Context, analysis *types.ProfitAnalysis, accepted bool) error
+	// SaveShadowTrade 记录一笔模拟盘影子成交（已签名、未广播）
+	SaveShadowTrade(ctx context.Context, trade *types.ShadowTrade) error
+	// Close 关闭存储
+	Close() error
+}
@@ -0,0 +1,98 @@
+package training
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"mempool-sniper/pkg/types"
+)
+
+// Sample 一条训练样本：完整的解码交易和模拟结果（无论是否盈利）
+type Sample struct {
+	Timestamp   time.Time                 `json:"timestamp"`
+	Accepted    bool                      `json:"accepted"` // 是否通过盈利阈值和过滤条件
+	Analysis    *types.ProfitAnalysis     `json:"analysis"`
+	Transaction *types.DecodedTransaction `json:"transaction"`
+}
+
+// Sink 训练数据输出端，按采样率写入JSONL文件，与可执行机会流分开
+type Sink struct {
+	mu         sync.Mutex
+	file       *os.File
+	sampleRate float64
+	rng        *rand.Rand
+	written    int64
+	sampledOut int64
+}
+
+// NewSink 创建训练数据输出端，sampleRate 取值 (0, 1]
+func NewSink(path string, sampleRate float64) (*Sink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open training file: %v", err)
+	}
+
+	return &Sink{
+		file:       file,
+		sampleRate: sampleRate,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Record 按采样率记录一条样本（nil安全）
+func (s *Sink) Record(analysis *types.ProfitAnalysis, accepted bool) error {
+	if s == nil || analysis == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sampleRate < 1 && s.rng.Float64() >= s.sampleRate {
+		s.sampledOut++
+		return nil
+	}
+
+	line, err := json.Marshal(Sample{
+		Timestamp:   time.Now(),
+		Accepted:    accepted,
+		Analysis:    analysis,
+		Transaction: analysis.Source,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.written++
+	return nil
+}
+
+// GetStats 获取统计信息
+func (s *Sink) GetStats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"written":     s.written,
+		"sampled_out": s.sampledOut,
+		"sample_rate": s.sampleRate,
+	}
+}
+
+// Close 关闭文件
+func (s *Sink) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,83 @@
+package training
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mempool-sniper/pkg/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// readSamples 读取JSONL训练文件
+func readSamples(t *testing.T, path string) []Sample {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var sample Sample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			t.Fatalf("invalid sample %q: %v", scanner.Text(), err)
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func TestSinkRecordsUnprofitableAnalyses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "training.jsonl")
+	sink, err := NewSink(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loss := &types.ProfitAnalysis{TxHash: common.HexToHash("0x01"), NetProfit: big.NewInt(-3e15)}
+	gain := &types.ProfitAnalysis{TxHash: common.HexToHash("0x02"), NetProfit: big.NewInt(2e16)}
+	sink.Record(loss, false)
+	sink.Record(gain, true)
+	sink.Record(nil, false)
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	samples := readSamples(t, path)
+	if len(samples) != 2 {
+		t.Fatalf("wrote %d samples, want 2", len(samples))
+	}
+	if samples[0].Accepted || samples[0].Analysis.NetProfit.Cmp(loss.NetProfit) != 0 {
+		t.Errorf("first sample = %+v, want the rejected loss", samples[0].Analysis)
+	}
+	if !samples[1].Accepted || samples[1].Analysis.TxHash != gain.TxHash {
+		t.Errorf("second sample = %+v, want the accepted opportunity", samples[1].Analysis)
+	}
+}
+
+func TestSinkSampleRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "training.jsonl")
+	sink, err := NewSink(path, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	const total = 4000
+	for i := 0; i < total; i++ {
+		sink.Record(&types.ProfitAnalysis{NetProfit: big.NewInt(-1)}, false)
+	}
+
+	stats := sink.GetStats()
+	written, sampledOut := stats["written"].(int64), stats["sampled_out"].(int64)
+	if written+sampledOut != total || written < 800 || written > 1200 {
+		t.Errorf("written = %d, sampled out = %d; want about a quarter of %d", written, sampledOut, total)
+	}
+}
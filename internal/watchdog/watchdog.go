@@ -0,0 +1,120 @@
+package watchdog
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// minCheckInterval 检查间隔下限
+const minCheckInterval = 100 * time.Millisecond
+
+// pool 被监控的工作池
+type pool struct {
+	name     string
+	progress func() int64 // 单调递增的处理计数（从输入通道取出的交易数）
+	backlog  func() int   // 输入通道积压数
+	restart  func()       // 重启工作线程
+
+	last       int64     // 上次检查时的处理计数
+	lastChange time.Time // 处理计数最近一次增长（或没有积压）的时间
+	restarts   int64
+}
+
+// Watchdog 工作池看门狗：输入有积压但处理计数超过 stall 没有增长时，
+// 认为工作线程已全部退出或卡死，调用其重启函数
+type Watchdog struct {
+	mu    sync.Mutex
+	stall time.Duration
+	pools []*pool
+}
+
+// New 创建看门狗（stall 为判定停滞的时长）
+func New(stall time.Duration) *Watchdog {
+	return &Watchdog{stall: stall}
+}
+
+// Register 注册要监控的工作池（只应在 Start 之前调用）
+func (w *Watchdog) Register(name string, progress func() int64, backlog func() int, restart func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pools = append(w.pools, &pool{
+		name:       name,
+		progress:   progress,
+		backlog:    backlog,
+		restart:    restart,
+		last:       progress(),
+		lastChange: time.Now(),
+	})
+}
+
+// Start 定期检查各工作池，直到上下文取消
+func (w *Watchdog) Start(ctx context.Context) {
+	interval := max(w.stall/4, minCheckInterval)
+	log.Printf("🐕 工作池看门狗启动：有积压且 %v 内没有处理任何交易时重启工作线程", w.stall)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				for _, restart := range w.check(now) {
+					restart()
+				}
+			}
+		}
+	}()
+}
+
+// check 更新各工作池的进度，返回需要重启的工作池的重启函数
+func (w *Watchdog) check(now time.Time) []func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var restarts []func()
+	for _, p := range w.pools {
+		current := p.progress()
+		backlog := p.backlog()
+		if current != p.last || backlog == 0 {
+			p.last = current
+			p.lastChange = now
+			continue
+		}
+
+		stalled := now.Sub(p.lastChange)
+		if stalled < w.stall {
+			continue
+		}
+
+		log.Printf("🚨🚨🚨 工作池 %s 已停滞 %v（积压 %d 笔，处理数停在 %d），重启工作线程！",
+			p.name, stalled.Round(time.Millisecond), backlog, current)
+		p.restarts++
+		p.lastChange = now
+		restarts = append(restarts, p.restart)
+	}
+	return restarts
+}
+
+// GetStats 获取各工作池的重启次数和停滞时长
+func (w *Watchdog) GetStats() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	stats := map[string]interface{}{
+		"stall_ms": w.stall.Milliseconds(),
+	}
+	for _, p := range w.pools {
+		stats[p.name] = map[string]interface{}{
+			"restarts":       p.restarts,
+			"idle_ms":        now.Sub(p.lastChange).Milliseconds(),
+			"last_processed": p.last,
+		}
+	}
+	return stats
+}
@@ -0,0 +1,100 @@
+package watchdog
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePool 由测试控制处理计数和积压的工作池
+type fakePool struct {
+	processed atomic.Int64
+	backlog   atomic.Int64
+	restarts  atomic.Int64
+}
+
+func (p *fakePool) register(w *Watchdog, name string) {
+	w.Register(name, p.processed.Load, func() int { return int(p.backlog.Load()) }, func() { p.restarts.Add(1) })
+}
+
+func TestCheckRestartsOnlyStalledPools(t *testing.T) {
+	const stall = time.Second
+
+	tests := []struct {
+		name     string
+		backlog  int64
+		advance  bool // 每次检查之间处理计数是否增长
+		elapsed  time.Duration
+		restarts int
+	}{
+		{name: "stalled with backlog", backlog: 10, elapsed: 2 * stall, restarts: 1},
+		{name: "not stalled long enough", backlog: 10, elapsed: stall / 2, restarts: 0},
+		{name: "making progress", backlog: 10, advance: true, elapsed: 2 * stall, restarts: 0},
+		{name: "idle without backlog", backlog: 0, elapsed: 2 * stall, restarts: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := New(stall)
+			var p fakePool
+			p.backlog.Store(tt.backlog)
+			p.register(w, "decoder")
+
+			start := time.Now()
+			w.check(start)
+			if tt.advance {
+				p.processed.Add(1)
+			}
+			for _, restart := range w.check(start.Add(tt.elapsed)) {
+				restart()
+			}
+
+			if got := int(p.restarts.Load()); got != tt.restarts {
+				t.Errorf("restarts = %d, want %d", got, tt.restarts)
+			}
+			stats := w.GetStats()["decoder"].(map[string]interface{})
+			if stats["restarts"] != int64(tt.restarts) {
+				t.Errorf("stats = %v", stats)
+			}
+		})
+	}
+}
+
+func TestCheckWaitsAnotherStallAfterRestart(t *testing.T) {
+	w := New(time.Second)
+	var p fakePool
+	p.backlog.Store(5)
+	p.register(w, "simulator")
+
+	start := time.Now()
+	w.check(start)
+	if got := len(w.check(start.Add(time.Second))); got != 1 {
+		t.Fatalf("first stall restarted %d pools, want 1", got)
+	}
+	// 重启后给工作线程一个完整的停滞时长恢复
+	if got := len(w.check(start.Add(1500 * time.Millisecond))); got != 0 {
+		t.Errorf("restarted again %d times before another stall elapsed", got)
+	}
+	if got := len(w.check(start.Add(2 * time.Second))); got != 1 {
+		t.Errorf("second stall restarted %d pools, want 1", got)
+	}
+}
+
+func TestStartRestartsStalledPool(t *testing.T) {
+	w := New(minCheckInterval)
+	var p fakePool
+	p.backlog.Store(1)
+	p.register(w, "decoder")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.restarts.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stalled pool was not restarted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
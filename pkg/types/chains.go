@@ -0,0 +1,244 @@
+package types
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 路由器协议版本
+const (
+	RouterV2 = 2 // Uniswap V2 风格（按路径交换，储备量定价）
+	RouterV3 = 3 // Uniswap V3 SwapRouter
+)
+
+// DEXRouter 链上的DEX路由器
+type DEXRouter struct {
+	Name         string
+	Address      common.Address
+	Version      int
+	Factory      common.Address // 仅V2风格：路由器对应的工厂合约
+	InitCodeHash common.Hash    // 仅V2风格：工厂创建交易对的init code hash
+}
+
+// LendingMarket 链上的借贷市场（目前只支持 Aave V3）
+type LendingMarket struct {
+	Name   string
+	Pool   common.Address // Pool 合约（借款/取款的调用目标）
+	Oracle common.Address // AaveOracle：资产价格和价格源
+}
+
+// ChainInfo 单条链的DEX和借贷市场配置
+type ChainInfo struct {
+	ChainID      int64
+	Name         string
+	NativeSymbol string // 原生代币（支付Gas的基础资产）符号
+	Routers      []DEXRouter
+	Lending      []LendingMarket // 清算策略支持的借贷市场（为空表示该链不支持清算）
+}
+
+// ChainRegistry 按ChainID索引的链配置（包装原生代币见 WrappedNativeTokens）
+var ChainRegistry = map[int64]ChainInfo{
+	1: {
+		ChainID:      1,
+		Name:         "Ethereum",
+		NativeSymbol: "ETH",
+		Routers: []DEXRouter{
+			{
+				Name:         "Uniswap V2",
+				Address:      common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"),
+				Version:      RouterV2,
+				Factory:      common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f"),
+				InitCodeHash: common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"),
+			},
+			{
+				Name:    "Uniswap V3",
+				Address: common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564"),
+				Version: RouterV3,
+			},
+			{
+				Name:         "SushiSwap",
+				Address:      common.HexToAddress("0xd9e1cE17f2641f24aE83637ab66a2cca9C378B9F"),
+				Version:      RouterV2,
+				Factory:      common.HexToAddress("0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac"),
+				InitCodeHash: common.HexToHash("0xe18a34eb0e04b04f7a0ac29a6e80748dca96319b42c520b8e8a4a5efc8df1a0f"),
+			},
+		},
+		Lending: []LendingMarket{
+			{
+				Name:   "Aave V3",
+				Pool:   common.HexToAddress("0x87870Bca3F3fD6335C3F4ce8392D69350B4fA4E2"),
+				Oracle: common.HexToAddress("0x54586bE62E3c3580375aE3723C145253060Ca0C2"),
+			},
+		},
+	},
+	56: {
+		ChainID:      56,
+		Name:         "BSC",
+		NativeSymbol: "BNB",
+		Routers: []DEXRouter{
+			{
+				Name:         "PancakeSwap V2",
+				Address:      common.HexToAddress("0x10ED43C718714eb63d5aA57B78B54704E256024E"),
+				Version:      RouterV2,
+				Factory:      common.HexToAddress("0xcA143Ce32Fe78f1f7019d7d551a6402fC5350c73"),
+				InitCodeHash: common.HexToHash("0x00fb7f630766e6a796048ea87d01acd3068e8ff67d078148a3fa3f4a84f69bd5"),
+			},
+		},
+	},
+	137: {
+		ChainID:      137,
+		Name:         "Polygon",
+		NativeSymbol: "MATIC",
+		Routers: []DEXRouter{
+			{
+				Name:         "QuickSwap",
+				Address:      common.HexToAddress("0xa5E0829CaCEd8fFDD4De3c43696c57F7D7A678ff"),
+				Version:      RouterV2,
+				Factory:      common.HexToAddress("0x5757371414417b8C6CAad45bAeF941aBc7d3Ab32"),
+				InitCodeHash: common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"),
+			},
+		},
+		Lending: []LendingMarket{
+			{
+				Name:   "Aave V3",
+				Pool:   common.HexToAddress("0x794a61358D6845594F94dc1DB02A252b5b4814aD"),
+				Oracle: common.HexToAddress("0xb023e699F5a33916Ea823A16485e259257cA8Bd1"),
+			},
+		},
+	},
+	10: {
+		ChainID:      10,
+		Name:         "Optimism",
+		NativeSymbol: "ETH",
+		Routers: []DEXRouter{
+			{
+				Name:         "Uniswap V2",
+				Address:      common.HexToAddress("0x4A7b5Da61326A6379179b40d00F57E5bbDC962c2"),
+				Version:      RouterV2,
+				Factory:      common.HexToAddress("0x0c3c1c532F1e39EdF36BE9Fe0bE1410313E074Bf"),
+				InitCodeHash: common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"),
+			},
+			{
+				Name:    "Uniswap V3",
+				Address: common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564"),
+				Version: RouterV3,
+			},
+		},
+		Lending: []LendingMarket{
+			{
+				Name:   "Aave V3",
+				Pool:   common.HexToAddress("0x794a61358D6845594F94dc1DB02A252b5b4814aD"),
+				Oracle: common.HexToAddress("0xD81eb3728a631871a7eBBaD631b5f424909f0c77"),
+			},
+		},
+	},
+	8453: {
+		ChainID:      8453,
+		Name:         "Base",
+		NativeSymbol: "ETH",
+		Routers: []DEXRouter{
+			// Base 上的 Uniswap V3 只有 SwapRouter02（参数结构不含 deadline），不在此注册
+			{
+				Name:         "Uniswap V2",
+				Address:      common.HexToAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24"),
+				Version:      RouterV2,
+				Factory:      common.HexToAddress("0x8909Dc15e40173Ff4699343b6eB8132c65e18eC6"),
+				InitCodeHash: common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"),
+			},
+		},
+		Lending: []LendingMarket{
+			{
+				Name:   "Aave V3",
+				Pool:   common.HexToAddress("0xA238Dd80C259a72e81d7e4664a9801593F98d1c5"),
+				Oracle: common.HexToAddress("0x2Cc0Fc26eD4563A5ce5e8bdcfe1A2878676Ae156"),
+			},
+		},
+	},
+	42161: {
+		ChainID:      42161,
+		Name:         "Arbitrum One",
+		NativeSymbol: "ETH",
+		Routers: []DEXRouter{
+			{
+				Name:         "Uniswap V2",
+				Address:      common.HexToAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24"),
+				Version:      RouterV2,
+				Factory:      common.HexToAddress("0xf1D7CC64Fb4452F05c498126312eBE29f30Fbcf9"),
+				InitCodeHash: common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"),
+			},
+			{
+				Name:    "Uniswap V3",
+				Address: common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564"),
+				Version: RouterV3,
+			},
+		},
+		Lending: []LendingMarket{
+			{
+				Name:   "Aave V3",
+				Pool:   common.HexToAddress("0x794a61358D6845594F94dc1DB02A252b5b4814aD"),
+				Oracle: common.HexToAddress("0xb56c2F0B653B2e0b10C9b928C8580Ac5Df02C7C7"),
+			},
+		},
+	},
+	11155111: {
+		ChainID:      11155111,
+		Name:         "Sepolia",
+		NativeSymbol: "ETH",
+		Routers: []DEXRouter{
+			{
+				Name:         "Uniswap V2",
+				Address:      common.HexToAddress("0xeE567Fe1712Faf6149d80dA1E6934E354124CfE3"),
+				Version:      RouterV2,
+				Factory:      common.HexToAddress("0xF62c03E08ada871A0bEb309762E260a7a6a880E6"),
+				InitCodeHash: common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"),
+			},
+		},
+	},
+}
+
+// LookupChain 获取链配置
+func LookupChain(chainID int64) (ChainInfo, bool) {
+	chain, exists := ChainRegistry[chainID]
+	return chain, exists
+}
+
+// SupportedChainIDs 已注册的链ID（升序）
+func SupportedChainIDs() []int64 {
+	ids := make([]int64, 0, len(ChainRegistry))
+	for id := range ChainRegistry {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// HasRouterVersion 链上是否有指定版本的路由器
+func (c ChainInfo) HasRouterVersion(version int) bool {
+	for _, router := range c.Routers {
+		if router.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// BaseAssetSymbol 链的基础资产（原生代币）符号：未注册的链按ETH处理（chainID为nil时按以太坊主网处理）
+func BaseAssetSymbol(chainID *big.Int) string {
+	if chainID != nil && chainID.IsInt64() {
+		if chain, exists := ChainRegistry[chainID.Int64()]; exists {
+			return chain.NativeSymbol
+		}
+	}
+	return "ETH"
+}
+
+// IsBaseAsset 代币是否以链的基础资产计价：原生代币（零地址）或其包装代币
+func IsBaseAsset(token common.Address, chainID *big.Int) bool {
+	if IsNativeToken(token) {
+		return true
+	}
+	weth, exists := WrappedNative(chainID)
+	return exists && token == weth
+}
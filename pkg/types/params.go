@@ -0,0 +1,42 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DecodedParameter 按ABI解码的一个调用参数（结构体参数按 "参数名.字段名" 展开）
+type DecodedParameter struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`  // ABI类型，如 uint256、address[]
+	Value interface{} `json:"value"` // JSON友好的值：整数为十进制字符串，地址和字节为0x十六进制
+}
+
+// NewDecodedParameter 按ABI解码值创建参数，把大整数、地址和字节转换为便于阅读的JSON值
+func NewDecodedParameter(name, abiType string, value interface{}) DecodedParameter {
+	switch v := value.(type) {
+	case *big.Int:
+		if v != nil {
+			value = v.String()
+		}
+	case common.Address:
+		value = v.Hex()
+	case []common.Address:
+		addresses := make([]string, len(v))
+		for i, address := range v {
+			addresses[i] = address.Hex()
+		}
+		value = addresses
+	case []byte:
+		value = hexutil.Encode(v)
+	}
+	return DecodedParameter{Name: name, Type: abiType, Value: value}
+}
+
+// ParametersJSON 解码的全部调用参数（名称、类型、值）的JSON表示
+func (tx *DecodedTransaction) ParametersJSON() ([]byte, error) {
+	return json.Marshal(tx.Parameters)
+}
@@ -0,0 +1,97 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NativeToken 原生代币（ETH）的零地址表示，解码结果中以此代表ETH
+var NativeToken = common.Address{}
+
+// WrappedNativeTokens 各链的包装原生代币（交易对中原生代币以此形式存在）
+var WrappedNativeTokens = map[int64]common.Address{
+	1:        common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"), // Ethereum
+	11155111: common.HexToAddress("0xfFf9976782d46CC05630D1f6eBAb18b2324d6B14"), // Sepolia
+	10:       common.HexToAddress("0x4200000000000000000000000000000000000006"), // Optimism
+	8453:     common.HexToAddress("0x4200000000000000000000000000000000000006"), // Base
+	42161:    common.HexToAddress("0x82aF49447D8a07e3bd95BD0d56f35241523fBab1"), // Arbitrum
+	56:       common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"), // BSC (WBNB)
+	137:      common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270"), // Polygon (WMATIC)
+}
+
+// IsNativeToken 是否为原生代币（零地址）
+func IsNativeToken(token common.Address) bool {
+	return token == NativeToken
+}
+
+// WrappedNative 获取链的包装原生代币（chainID为nil时按以太坊主网处理）
+func WrappedNative(chainID *big.Int) (common.Address, bool) {
+	id := int64(1)
+	if chainID != nil && chainID.Sign() > 0 && chainID.IsInt64() {
+		id = chainID.Int64()
+	}
+	weth, exists := WrappedNativeTokens[id]
+	return weth, exists
+}
+
+// PoolToken 交易对中使用的代币：原生代币转换为链的包装代币，其他代币不变
+// （未知链无法转换时返回零地址，调用方需避免用其查询交易对）
+func PoolToken(token common.Address, chainID *big.Int) common.Address {
+	if !IsNativeToken(token) {
+		return token
+	}
+	weth, _ := WrappedNative(chainID)
+	return weth
+}
+
+// PoolPath 将交换路径中的原生代币转换为包装代币（返回新切片）
+func PoolPath(path []common.Address, chainID *big.Int) []common.Address {
+	converted := make([]common.Address, len(path))
+	for i, token := range path {
+		converted[i] = PoolToken(token, chainID)
+	}
+	return converted
+}
+
+// ToBaseUnits 将人类可读数量（如 "50" 或 "0.5"）按代币精度转换为最小单位，
+// 小数位数超过代币精度时返回错误（不做截断）
+func ToBaseUnits(amount string, decimals uint8) (*big.Int, error) {
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, fmt.Errorf("无效数量: %q", amount)
+	}
+	if value.Sign() < 0 {
+		return nil, fmt.Errorf("数量不能小于0: %q", amount)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value.Mul(value, new(big.Rat).SetInt(scale))
+	if !value.IsInt() {
+		return nil, fmt.Errorf("数量 %q 超出代币精度 (%d 位小数)", amount, decimals)
+	}
+	return new(big.Int).Set(value.Num()), nil
+}
+
+// FromBaseUnits 将最小单位按代币精度转换为人类可读数量（精确值，去掉末尾多余的0）
+func FromBaseUnits(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	formatted := new(big.Rat).SetFrac(amount, scale).FloatString(int(decimals))
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+	return formatted
+}
+
+// FormatProfit 按基础资产格式化金额（如 "0.5 BNB"），未标注基础资产的结果按ETH处理
+func (a *ProfitAnalysis) FormatProfit(amount *big.Int) string {
+	if a.BaseAsset == "" {
+		return FromBaseUnits(amount, 18) + " ETH"
+	}
+	return FromBaseUnits(amount, 18) + " " + a.BaseAsset
+}
@@ -9,88 +9,153 @@ import (
 
 // Transaction 交易包装类型
 type Transaction struct {
-	Hash        common.Hash     `json:"hash"`
-	RawTx       *types.Transaction `json:"raw_tx"`
-	From        common.Address  `json:"from"`
-	To          *common.Address `json:"to"`
-	Value       *big.Int        `json:"value"`
-	GasPrice    *big.Int        `json:"gas_price"`
-	GasLimit    uint64          `json:"gas_limit"`
-	Data        []byte          `json:"data"`
-	Nonce       uint64          `json:"nonce"`
-	ChainID     *big.Int        `json:"chain_id"`
-	Timestamp   int64           `json:"timestamp"`
+	Hash          common.Hash        `json:"hash"`
+	RawTx         *types.Transaction `json:"raw_tx"`
+	From          common.Address     `json:"from"`
+	To            *common.Address    `json:"to"`
+	Value         *big.Int           `json:"value"`
+	GasPrice      *big.Int           `json:"gas_price"`
+	GasLimit      uint64             `json:"gas_limit"`
+	Data          []byte             `json:"data"`
+	Nonce         uint64             `json:"nonce"`
+	ChainID       *big.Int           `json:"chain_id"`
+	Timestamp     int64              `json:"timestamp"`
+	Type          uint8              `json:"type"`             // 交易类型 (0 legacy, 2 EIP-1559, 3 blob)
+	BlobGas       uint64             `json:"blob_gas"`         // blob gas用量（仅type-3）
+	BlobGasFeeCap *big.Int           `json:"blob_gas_fee_cap"` // blob gas费上限（仅type-3）
+}
+
+// IsBlob 是否为EIP-4844 blob交易（type-3，不会是交换交易）
+func (t *Transaction) IsBlob() bool {
+	return t.Type == types.BlobTxType
 }
 
 // DecodedTransaction 解码后的交易信息
 type DecodedTransaction struct {
-	Transaction     *Transaction `json:"transaction"`
-	Method          string       `json:"method"`
-	MethodID        []byte       `json:"method_id"`
-	TargetContract  common.Address `json:"target_contract"`
-	Parameters      []interface{} `json:"parameters"`
-	IsSwap          bool         `json:"is_swap"`
-	SwapDirection   string       `json:"swap_direction"` // "buy" or "sell"
-	TokenIn         common.Address `json:"token_in"`
-	TokenOut        common.Address `json:"token_out"`
-	AmountIn        *big.Int     `json:"amount_in"`
-	AmountOutMin    *big.Int     `json:"amount_out_min"`
+	OpportunityID   string             `json:"opportunity_id"` // 生命周期追踪ID
+	Transaction     *Transaction       `json:"transaction"`
+	Method          string             `json:"method"`
+	MethodID        []byte             `json:"method_id"`
+	TargetContract  common.Address     `json:"target_contract"`
+	Parameters      []DecodedParameter `json:"parameters"` // 按ABI解码的全部调用参数（名称+值）
+	IsSwap          bool               `json:"is_swap"`
+	SwapDirection   string             `json:"swap_direction"` // "buy" or "sell"
+	TokenIn         common.Address     `json:"token_in"`
+	TokenOut        common.Address     `json:"token_out"`
+	AmountIn        *big.Int           `json:"amount_in"`
+	AmountOutMin    *big.Int           `json:"amount_out_min"`
+	Path            []common.Address   `json:"path"`
+	Recipient       common.Address     `json:"recipient"`               // 接收地址 (to参数)
+	Deadline        *big.Int           `json:"deadline,omitempty"`      // 交换截止时间 (deadline参数，Unix秒)
+	AnomalousGas    bool               `json:"anomalous_gas"`           // Gas限制远超该方法的正常值
+	LikelyPrivate   bool               `json:"likely_private"`          // 疑似私有订单流（发送者的交易常在打包前极短时间才公开）
+	NonceBlocked    bool               `json:"nonce_blocked"`           // 同一发送者前面存在未填上的nonce空缺，要等空缺填上才能打包
+	SpamCluster     bool               `json:"spam_cluster"`            // 属于大量发送者的相同交换聚类（疑似刷单机器人）
+	Account         common.Address     `json:"account,omitempty"`       // 借贷交易的头寸所属账户（非交换交易）
+	MEVResistant    bool               `json:"mev_resistant"`           // 抗MEV订单流（批量拍卖/提交-揭示等），不可被夹
+	Replaces        common.Hash        `json:"replaces,omitempty"`      // 被本交易替代（相同发送者和nonce）的原交易，零值表示不是替代交易
+	LikelyAttacker  bool               `json:"likely_attacker"`         // 疑似夹子攻击的抢跑交易（同一交易对上以更高Gas价格抢在pending交易前的大额同向交换）
+	LeadingApproval bool               `json:"leading_approval"`        // 发送者不久前授权过该路由使用路径中的代币
+	FeeTier         uint32             `json:"fee_tier,omitempty"`      // V3 第一跳的手续费档位（百万分比，V2 为0）
+	ExactOutput     bool               `json:"exact_output"`            // 精确输出交换（exactOutput/swap*ForExact*）：AmountOutMin 为目标输出
+	AmountInMax     *big.Int           `json:"amount_in_max,omitempty"` // 精确输出交换的最大输入金额（amountInMax，ETH输入时为附带的ETH）
 }
 
 // ProfitAnalysis 盈利分析结果
 type ProfitAnalysis struct {
-	TxHash          common.Hash     `json:"tx_hash"`
-	TargetContract  common.Address `json:"target_contract"`
-	Method          string         `json:"method"`
-	Profit          *big.Int       `json:"profit"`        // 预估盈利 (wei)
-	GasCost         *big.Int       `json:"gas_cost"`      // Gas成本 (wei)
-	NetProfit       *big.Int       `json:"net_profit"`    // 净盈利 (wei)
-	SuccessRate     float64        `json:"success_rate"`  // 成功率 (0-1)
-	RiskLevel       string         `json:"risk_level"`    // 风险等级
-	SimulationTime  int64          `json:"simulation_time"` // 模拟耗时(ms)
-	Config          *SniperConfig  `json:"config"`
+	OpportunityID        string              `json:"opportunity_id"` // 生命周期追踪ID
+	TxHash               common.Hash         `json:"tx_hash"`
+	TargetContract       common.Address      `json:"target_contract"`
+	Method               string              `json:"method"`
+	Strategy             string              `json:"strategy,omitempty"`               // 得分最高的评估策略
+	Profit               *big.Int            `json:"profit"`                           // 预估盈利 (基础资产最小单位，按输入代币现价换算)
+	ProfitToken          common.Address      `json:"profit_token"`                     // 策略计算盈利使用的代币（交换路径的输入代币，零地址表示原生代币）
+	GasCost              *big.Int            `json:"gas_cost"`                         // Gas成本 (基础资产最小单位)
+	BaseAsset            string              `json:"base_asset"`                       // 盈利和Gas成本的计价单位（链的原生代币，如 ETH/BNB/MATIC）
+	GasUsed              uint64              `json:"gas_used"`                         // 估算的Gas用量（含安全系数，GasCost = GasUsed × Gas价格 + L1数据费）
+	GasEstimation        *GasEstimation      `json:"gas_estimation,omitempty"`         // Gas成本明细（有效Gas价格、基础费用、小费）
+	NetProfit            *big.Int            `json:"net_profit"`                       // 净盈利 (基础资产最小单位)
+	NetProfitAfterCosts  *big.Int            `json:"net_profit_after_costs,omitempty"` // 扣除构建者小费和安全缓冲后的净盈利 (wei)
+	NetProfitOptimistic  *big.Int            `json:"net_profit_optimistic,omitempty"`  // 乐观估算的净盈利：假设没有竞争交易 (wei)
+	NetProfitPessimistic *big.Int            `json:"net_profit_pessimistic,omitempty"` // 悲观估算的净盈利：假设同一交易对上的同向pending交换先成交 (wei)
+	SuccessRate          float64             `json:"success_rate"`                     // 成功率 (0-1)
+	RiskLevel            string              `json:"risk_level"`                       // 风险等级
+	SimulationTime       int64               `json:"simulation_time"`                  // 模拟耗时(ms)
+	TargetBlock          uint64              `json:"target_block"`                     // 预期打包区块号（模拟盘按该区块记录成交）
+	LowConfidence        bool                `json:"low_confidence"`                   // 涉及新建交易对，结果可信度低
+	LeadingApproval      bool                `json:"leading_approval"`                 // 受害者交易之前有同一发送者对路由的授权（与 LowConfidence 同时出现时为代币上线信号）
+	CompetitionCount     int                 `json:"competition_count"`                // 竞争窗口内同一交易对上的同向pending交换笔数（仅V2路由）
+	PriceImpactBps       uint64              `json:"price_impact_bps"`                 // 受害者交易在第一跳交易对上的价格冲击（万分比，仅V2路由）
+	VictimPrice          string              `json:"victim_price,omitempty"`           // 受害者实际成交价（输入/输出，按精度归一化）
+	EntryPrice           string              `json:"entry_price,omitempty"`            // 我们的买入价
+	ExitPrice            string              `json:"exit_price,omitempty"`             // 我们的卖出价
+	Predicted            *SandwichPrediction `json:"predicted,omitempty"`              // 夹子模拟预测的各笔成交数量（与实际成交对比）
+	Source               *DecodedTransaction `json:"-"`                                // 原始解码交易（用于执行前重新模拟）
+	ConfigVersion        uint64              `json:"config_version"`                   // 模拟时生效的配置版本号
+	Config               *SniperConfig       `json:"config"`
+}
+
+// SandwichPrediction 第一跳交易对上夹子模拟的成交数量（输入/输出代币最小单位）
+type SandwichPrediction struct {
+	OurIn     *big.Int `json:"our_in"`     // 我们的买入规模
+	OurOut    *big.Int `json:"our_out"`    // 买入到账的输出代币
+	VictimOut *big.Int `json:"victim_out"` // 受害者第一跳得到的输出代币
+	ExitOut   *big.Int `json:"exit_out"`   // 卖出换回的输入代币
+}
+
+// ShadowTrade 模拟盘影子执行记录：按真实执行构建并签名、但未广播的买入交易，假设在目标区块按模拟状态成交
+type ShadowTrade struct {
+	OpportunityID string         `json:"opportunity_id"`
+	VictimTx      common.Hash    `json:"victim_tx"`
+	ShadowTx      common.Hash    `json:"shadow_tx"` // 签名后的交易哈希（没有签名账户或非V2路由时为空）
+	Account       common.Address `json:"account"`   // 本应使用的签名账户
+	Nonce         uint64         `json:"nonce"`     // 本应使用的nonce（未占用）
+	TargetBlock   uint64         `json:"target_block"`
+	NetProfit     *big.Int       `json:"net_profit"` // 假设成交的净盈利 (基础资产最小单位)
 }
 
 // SniperConfig 狙击手配置（用于类型引用）
 type SniperConfig struct {
-	MinProfit        *big.Int `json:"min_profit"`
-	MaxGasPrice      *big.Int `json:"max_gas_price"`
-	MaxGasLimit      uint64   `json:"max_gas_limit"`
+	MinProfit   *big.Int `json:"min_profit"`
+	MaxGasPrice *big.Int `json:"max_gas_price"`
+	MaxGasLimit uint64   `json:"max_gas_limit"`
 }
 
 // ContractInfo 合约信息
 type ContractInfo struct {
-	Address     common.Address `json:"address"`
-	Name        string         `json:"name"`
-	Type        string         `json:"type"`        // DEX, Lending, etc.
-	ABI         string         `json:"abi"`
-	IsVerified  bool           `json:"is_verified"`
+	Address    common.Address `json:"address"`
+	Name       string         `json:"name"`
+	Type       string         `json:"type"` // DEX, Lending, etc.
+	ABI        string         `json:"abi"`
+	IsVerified bool           `json:"is_verified"`
 }
 
 // MethodSignature 方法签名
 type MethodSignature struct {
-	Name        string         `json:"name"`
-	Selector    []byte         `json:"selector"`
-	Parameters  []string       `json:"parameters"`
-	IsSwap      bool           `json:"is_swap"`
+	Name       string   `json:"name"`
+	Selector   []byte   `json:"selector"`
+	Parameters []string `json:"parameters"`
+	IsSwap     bool     `json:"is_swap"`
 }
 
 // SwapInfo 交换信息
 type SwapInfo struct {
-	Dex         string         `json:"dex"`
-	Router      common.Address `json:"router"`
-	Pair        common.Address `json:"pair"`
-	Path        []common.Address `json:"path"`
-	Amounts     []*big.Int     `json:"amounts"`
+	Dex     string           `json:"dex"`
+	Router  common.Address   `json:"router"`
+	Pair    common.Address   `json:"pair"`
+	Path    []common.Address `json:"path"`
+	Amounts []*big.Int       `json:"amounts"`
 }
 
 // GasEstimation Gas估算结果
 type GasEstimation struct {
-	GasUsed     uint64         `json:"gas_used"`
-	GasPrice    *big.Int       `json:"gas_price"`
-	TotalCost   *big.Int       `json:"total_cost"`
-	BaseFee     *big.Int       `json:"base_fee"`
-	PriorityFee *big.Int       `json:"priority_fee"`
+	GasUsed     uint64   `json:"gas_used"`
+	GasPrice    *big.Int `json:"gas_price"`
+	TotalCost   *big.Int `json:"total_cost"`
+	BaseFee     *big.Int `json:"base_fee"`
+	PriorityFee *big.Int `json:"priority_fee"`
+	L1DataFee   *big.Int `json:"l1_data_fee"` // L2的L1数据费（已计入TotalCost）
+	Legacy      bool     `json:"legacy"`      // 链不支持EIP-1559，按传统Gas价格计价
 }
 
 // ErrorType 错误类型
@@ -100,59 +165,5 @@ type ErrorType struct {
 	Level   string `json:"level"` // "info", "warning", "error"
 }
 
-// 预定义的合约地址和方法签名
-var (
-	// 常见DEX路由器地址
-	UniswapV2Router = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
-	UniswapV3Router = common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564")
-	SushiSwapRouter = common.HexToAddress("0xd9e1cE17f2641f24aE83637ab66a2cca9C378B9F")
-
-	// 常见交换方法签名
-	MethodSwapExactETHForTokens = []byte{0x7f, 0xf3, 0x6a, 0xb5} // swapExactETHForTokens
-	MethodSwapExactTokensForETH = []byte{0x18, 0xcb, 0xaf, 0x05} // swapExactTokensForETH
-	MethodSwapExactTokensForTokens = []byte{0x38, 0xed, 0x17, 0x39} // swapExactTokensForTokens
-
-	// 支持的DEX列表
-	SupportedDEX = map[common.Address]string{
-		UniswapV2Router: "Uniswap V2",
-		UniswapV3Router: "Uniswap V3", 
-		SushiSwapRouter: "SushiSwap",
-	}
-
-	// 支持的交换方法
-	SupportedSwapMethods = map[string][]byte{
-		"swapExactETHForTokens":     MethodSwapExactETHForTokens,
-		"swapExactTokensForETH":     MethodSwapExactTokensForETH,
-		"swapExactTokensForTokens":  MethodSwapExactTokensForTokens,
-	}
-)
-
-// IsSupportedContract 检查是否支持该合约
-func IsSupportedContract(address common.Address) bool {
-	_, exists := SupportedDEX[address]
-	return exists
-}
-
-// IsSwapMethod 检查是否是交换方法
-func IsSwapMethod(methodID []byte) bool {
-	for _, supportedMethod := range SupportedSwapMethods {
-		if len(methodID) >= 4 && len(supportedMethod) >= 4 {
-			if string(methodID[:4]) == string(supportedMethod[:4]) {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-// GetMethodName 根据方法ID获取方法名称
-func GetMethodName(methodID []byte) string {
-	for name, id := range SupportedSwapMethods {
-		if len(methodID) >= 4 && len(id) >= 4 {
-			if string(methodID[:4]) == string(id[:4]) {
-				return name
-			}
-		}
-	}
-	return "unknown"
-}
\ No newline at end of file
+// MethodOracleUpdate 预言机价格更新交易的方法名（TargetContract 为聚合器，AmountIn 为新价格）
+const MethodOracleUpdate = "oracleUpdate"
@@ -74,7 +74,10 @@ download_dependencies() {
 # 构建项目
 build_project() {
     log_info "构建项目..."
-    go build -o mempool-sniper .
+    VERSION=$(git describe --tags --always 2>/dev/null || echo dev)
+    COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+    BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+    go build -ldflags "-X mempool-sniper/internal/buildinfo.version=${VERSION} -X mempool-sniper/internal/buildinfo.commit=${COMMIT} -X mempool-sniper/internal/buildinfo.buildTime=${BUILD_TIME}" -o mempool-sniper ./cmd/mempool-sniper
     if [ $? -eq 0 ]; then
         log_success "项目构建成功"
     else
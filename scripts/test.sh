@@ -95,6 +95,19 @@ run_integration_tests() {
     rm -f test-sniper test-output.log
 }
 
+# 运行自检（内置夹具 + 模拟节点，无需真实节点）
+run_selftest() {
+    log_info "运行管道自检..."
+
+    go run ./cmd/mempool-sniper -selftest
+    if [ $? -ne 0 ]; then
+        log_error "管道自检失败"
+        return 1
+    fi
+
+    log_success "管道自检通过"
+}
+
 # 运行代码检查
 run_code_checks() {
     log_info "运行代码检查..."
@@ -107,6 +120,14 @@ run_code_checks() {
         return 1
     fi
     
+    # 检查示例程序与当前API保持一致
+    log_info "检查示例程序可编译..."
+    go build -o /dev/null ./examples/
+    if [ $? -ne 0 ]; then
+        log_error "示例程序编译失败，请同步 examples/ 与当前API"
+        return 1
+    fi
+    
     # 运行静态分析
     log_info "运行静态分析..."
     go vet ./...
@@ -167,6 +188,7 @@ show_help() {
     echo "  -h, --help         显示此帮助信息"
     echo "  -u, --unit         仅运行单元测试"
     echo "  -i, --integration  仅运行集成测试"
+    echo "  -s, --selftest     仅运行管道自检"
     echo "  -c, --check        仅运行代码检查"
     echo "  -p, --performance  仅运行性能测试"
     echo "  -r, --report       生成测试报告"
@@ -190,6 +212,9 @@ main() {
         -i|--integration)
             run_integration_tests
             ;;
+        -s|--selftest)
+            run_selftest
+            ;;
         -c|--check)
             run_code_checks
             ;;
@@ -202,6 +227,7 @@ main() {
         -a|--all)
             run_code_checks
             run_unit_tests
+            run_selftest
             run_integration_tests
             run_performance_tests
             generate_test_report